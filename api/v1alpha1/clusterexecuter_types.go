@@ -4,6 +4,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -17,6 +18,68 @@ type NamespacedName struct {
 type ClusterTargets struct {
 	DBs     []string `json:"dbs,omitempty"`
 	Schemas []string `json:"schemas,omitempty"`
+	// DBResults records the outcome of the execution per database.
+	DBResults map[string]DBResultEnum `json:"dbResults,omitempty"`
+}
+
+// DBResultEnum Enum for the execution outcome of a database
+type DBResultEnum string
+
+const (
+	// DBResultExecuted the schema was applied on the database
+	DBResultExecuted DBResultEnum = "Executed"
+	// DBResultSkipped the database was skipped since it failed the execution pre-condition or a pre-apply hook
+	DBResultSkipped DBResultEnum = "Skipped"
+	// DBResultUpToDate the database was not executed since it already records the schema version
+	DBResultUpToDate DBResultEnum = "UpToDate"
+	// DBResultFailed the schema was applied on the database but failed the post apply verification
+	DBResultFailed DBResultEnum = "Failed"
+)
+
+// DBPhase is the execution phase of a single database
+type DBPhase string
+
+const (
+	// DBPhasePending the database is waiting for execution
+	DBPhasePending DBPhase = "Pending"
+	// DBPhaseInProgress the database is being executed
+	DBPhaseInProgress DBPhase = "InProgress"
+	// DBPhaseSucceeded the database was executed successfully
+	DBPhaseSucceeded DBPhase = "Succeeded"
+	// DBPhaseFailed the database execution failed
+	DBPhaseFailed DBPhase = "Failed"
+)
+
+// BatchModeEnum Enum for the batch execution modes
+type BatchModeEnum string
+
+const (
+	// BatchModeBestEffort executes every cluster and reports the failures.
+	BatchModeBestEffort BatchModeEnum = "BestEffort"
+	// BatchModeAllOrNothing rolls back the succeeded clusters when any cluster fails.
+	BatchModeAllOrNothing BatchModeEnum = "AllOrNothing"
+)
+
+// MergeStrategyType Enum for the ways the desired schema is merged into the current one
+type MergeStrategyType string
+
+const (
+	// MergeStrategyReplace adds, modifies and drops objects until the current schema equals the desired one.
+	MergeStrategyReplace MergeStrategyType = "Replace"
+	// MergeStrategyAdditive only adds new objects, existing objects are neither modified nor dropped.
+	MergeStrategyAdditive MergeStrategyType = "Additive"
+	// MergeStrategyReconcile adds and modifies objects but never drops them.
+	MergeStrategyReconcile MergeStrategyType = "Reconcile"
+)
+
+// KQLAssertion is a query verifying an invariant of the applied schema.
+type KQLAssertion struct {
+	// Query is the read-only query run on the database.
+	Query string `json:"query"`
+	// ExpectedNonEmpty fails the assertion when the query returns no rows.
+	ExpectedNonEmpty bool `json:"expectedNonEmpty,omitempty"`
+	// FailMessage describes the broken invariant.
+	FailMessage string `json:"failMessage,omitempty"`
 }
 
 // ExecutionConfiguration contains the required configuration for execution
@@ -28,6 +91,65 @@ type ExecutionConfiguration struct {
 	Schema       string            `json:"schema,omitempty"`
 	Group        string            `json:"group,omitempty"`
 	Properties   map[string]string `json:"properties,omitempty"`
+	// FailIfDataLoss fails the execution instead of applying changes that lose data.
+	FailIfDataLoss bool `json:"failIfDataLoss,omitempty"`
+	// GarbageCollection drops objects that exist in the target but are missing from the desired schema (kusto only).
+	GarbageCollection bool `json:"garbageCollection,omitempty"`
+	// IngestionPoliciesFile holds the tables ingestion policies (kusto only).
+	IngestionPoliciesFile string `json:"ingestionpoliciesfile,omitempty"`
+	// PartitioningPoliciesFile holds the tables partitioning policies (kusto only).
+	PartitioningPoliciesFile string `json:"partitioningpoliciesfile,omitempty"`
+	// MaterializedViewsFile holds the materialized views (kusto only).
+	MaterializedViewsFile string `json:"materializedviewsfile,omitempty"`
+	// WorkloadGroupsFile holds the cluster workload groups (kusto only).
+	WorkloadGroupsFile string `json:"workloadgroupsfile,omitempty"`
+	// AllowWorkloadGroupDrop drops workload groups missing from the `WorkloadGroupsFile`.
+	AllowWorkloadGroupDrop bool `json:"allowWorkloadGroupDrop,omitempty"`
+	// PreConditionKQL is a read-only query run on every database before execution - databases where it returns no rows are skipped (kusto only).
+	PreConditionKQL string `json:"preConditionKQL,omitempty"`
+	// BatchMode controls how a failure on one cluster of a batch execution affects the others (kusto only).
+	BatchMode BatchModeEnum `json:"batchMode,omitempty"`
+	// MergeStrategy controls which changes are applied to the current schema, `Reconcile` when empty (kusto only).
+	MergeStrategy MergeStrategyType `json:"mergeStrategy,omitempty"`
+	// RollbackFile holds the schema restored on the clusters of a failed `AllOrNothing` batch execution.
+	RollbackFile string `json:"rollbackfile,omitempty"`
+	// RollbackOnFailure restores the `RollbackFile` schema on the databases failing the post apply verification (kusto only).
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+	// DryRunOutputConfigMap only computes the schema diff, which is exported to a `ConfigMap` with this name (kusto only).
+	DryRunOutputConfigMap string `json:"dryRunOutputConfigMap,omitempty"`
+	// DryRunOutputDir holds the delta-kusto diff of every database of a dry run.
+	DryRunOutputDir string `json:"dryrunoutputdir,omitempty"`
+	// AppliedDiffDir holds the delta-kusto delta applied to every database of an execution (kusto only).
+	AppliedDiffDir string `json:"applieddiffdir,omitempty"`
+	// ResultDir holds the outcome and output of every delta-kusto job of an execution (kusto only).
+	ResultDir string `json:"resultDir,omitempty"`
+	// JobID identifies the running delta-kusto job, so it can be cancelled (kusto only).
+	JobID string `json:"jobID,omitempty"`
+	// Assertions are run on every database after the schema is applied (kusto only).
+	Assertions []KQLAssertion `json:"assertions,omitempty"`
+	// IsolateExecutions runs every database in its own delta-kusto working directory, the `JobFile` is relative to it (kusto only).
+	IsolateExecutions bool `json:"isolateExecutions,omitempty"`
+	// DatabaseTimeouts limit the time the delta-kusto job of a database may run, executing every database in its own job (kusto only).
+	DatabaseTimeouts map[string]metav1.Duration `json:"databaseTimeouts,omitempty"`
+	// MaxParallelDatabases is the number of databases executed at once, each in its own delta-kusto job (kusto only).
+	// Zero uses the operator `schemaop_max_parallel_dbs` setting, or 1 when unset.
+	MaxParallelDatabases int `json:"maxParallelDatabases,omitempty"`
+	// MinWorkers is the least number of databases executed at once, at least 1 (kusto only).
+	MinWorkers int `json:"minWorkers,omitempty"`
+	// MaxWorkers caps the number of databases executed at once, at most 50 (kusto only).
+	MaxWorkers int `json:"maxWorkers,omitempty"`
+	// PreserveJobArtifact keeps the delta-kusto job file in a `schema-job-<hash>` `ConfigMap` for auditing (kusto only).
+	PreserveJobArtifact bool `json:"preserveJobArtifact,omitempty"`
+	// ArtifactRetention is the time the job artifact is kept, 7 days when unset.
+	ArtifactRetention *metav1.Duration `json:"artifactRetention,omitempty"`
+	// CanaryMode applies the schema to the `CanaryDatabase` first, and to the other databases only once it passed the
+	// post apply verification (kusto only).
+	CanaryMode bool `json:"canaryMode,omitempty"`
+	// CanaryDatabase is the database of the `CanaryMode` execution, one of the target databases.
+	CanaryDatabase string `json:"canaryDatabase,omitempty"`
+	// SchemaVersion is the checksum of the schema recorded in the `schema.operator/version` metadata of the executed
+	// databases, the databases already recording it are skipped. Empty when the databases are not annotated (kusto only).
+	SchemaVersion string `json:"schemaVersion,omitempty"`
 }
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -35,12 +157,49 @@ type ExecutionConfiguration struct {
 
 // ClusterExecuterSpec defines the desired state of ClusterExecuter
 type ClusterExecuterSpec struct {
-	ClusterUri     string         `json:"clusterUri,omitempty"`
-	ApplyTo        TargetFilter   `json:"applyTo"`
-	Type           DBTypeEnum     `json:"type"`
-	ConfigMapName  NamespacedName `json:"configMapName"`
-	FailIfDataLoss bool           `json:"failIfDataLoss"`
-	Revision       int32          `json:"revision"`
+	ClusterUri     string              `json:"clusterUri,omitempty"`
+	ApplyTo        TargetFilter        `json:"applyTo"`
+	Type           DBTypeEnum          `json:"type"`
+	ConfigMapName  NamespacedName      `json:"configMapName"`
+	FailIfDataLoss bool                `json:"failIfDataLoss"`
+	DatabaseRoles  map[string][]string `json:"databaseRoles,omitempty"`
+	Revision       int32               `json:"revision"`
+	// CooldownSeconds is the time after a successful apply in which reconciles are skipped.
+	// +kubebuilder:validation:Optional
+	CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+	// ObservationMode only computes the `PendingDiff` of the targets and never applies it.
+	// +kubebuilder:validation:Optional
+	ObservationMode bool `json:"observationMode,omitempty"`
+	// SecretRef is the `Secret` key the kql is read from, the config map only holds its checksum.
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+	// TenantID is the Azure tenant of the target clusters, empty for the tenant of the operator.
+	// +kubebuilder:validation:Optional
+	TenantID string `json:"tenantID,omitempty"`
+	// CredentialSecretRef references the service principal credentials of the `TenantID`.
+	// +kubebuilder:validation:Optional
+	CredentialSecretRef *corev1.SecretReference `json:"credentialSecretRef,omitempty"`
+	// EventHubConnectionStringRef is the `Secret` key of the Event Hub connection string schema changes are published to.
+	// +kubebuilder:validation:Optional
+	EventHubConnectionStringRef *corev1.SecretKeySelector `json:"eventHubConnectionStringRef,omitempty"`
+	// Priority orders the pending cluster executers, higher priorities are executed first.
+	// +kubebuilder:validation:Optional
+	Priority int `json:"priority,omitempty"`
+}
+
+// SchemaDiffSummary counts the changes of a delta applied to the target databases
+type SchemaDiffSummary struct {
+	TablesAdded      int `json:"tablesAdded"`
+	TablesDropped    int `json:"tablesDropped"`
+	ColumnsAdded     int `json:"columnsAdded"`
+	ColumnsDropped   int `json:"columnsDropped"`
+	FunctionsChanged int `json:"functionsChanged"`
+	PoliciesChanged  int `json:"policiesChanged"`
+}
+
+// TotalChanges returns the number of changes counted by the summary.
+func (s SchemaDiffSummary) TotalChanges() int {
+	return s.TablesAdded + s.TablesDropped + s.ColumnsAdded + s.ColumnsDropped + s.FunctionsChanged + s.PoliciesChanged
 }
 
 // ClusterExecuterStatus defines the observed state of ClusterExecuter
@@ -55,8 +214,32 @@ type ClusterExecuterStatus struct {
 	Config       ExecutionConfiguration `json:"config,omitempty"`
 	NumFailures  int                    `json:"numFailures,omitempty"`
 	CompletedPCT int                    `json:"completedPct,omitempty"`
+	// SkippedTargets are the databases the last execution skipped since they failed the execution pre-condition
+	// or a pre-apply hook, they are not part of the `DoneTargets`.
+	SkippedTargets ClusterTargets `json:"skipped,omitempty"`
+	// CanaryFailed is set when the canary database of a `CanaryMode` execution failed, the executer halts until it is
+	// forced to reconcile.
+	CanaryFailed bool `json:"canaryFailed,omitempty"`
+	// DatabaseProgress is the execution phase of every target database (kusto only).
+	DatabaseProgress map[string]DBPhase `json:"databaseProgress,omitempty"`
+	// Progress is the number of succeeded databases out of the target databases (e.g. `3/5`).
+	Progress string `json:"progress,omitempty"`
+	// AppliedChecksum is the checksum of the rendered kql and the target databases of the last successful execution.
+	AppliedChecksum string `json:"appliedChecksum,omitempty"`
+	// ShortChecksum is the prefix of the `AppliedChecksum` printed by `kubectl get`.
+	ShortChecksum string `json:"shortChecksum,omitempty"`
+	// LastAppliedAt is the time of the last successful execution.
+	LastAppliedAt *metav1.Time `json:"lastAppliedAt,omitempty"`
+	// ClusterName is the name of the target cluster, the first label of the `clusterUri` host.
+	ClusterName string `json:"clusterName,omitempty"`
+	// Databases is the number of databases in the `DatabaseProgress`.
+	Databases int `json:"databases,omitempty"`
+	// PendingDiff maps every target database to the delta script an observation mode executer would apply.
+	PendingDiff map[string]string `json:"pendingDiff,omitempty"`
+	// LastAppliedDiff summarizes the delta applied by the last successful execution, cleared when an execution starts (kusto only).
+	LastAppliedDiff *SchemaDiffSummary `json:"lastAppliedDiff,omitempty"`
 	// Conditions is an array of conditions.
-	// Known .status.conditions.type are: "Execution"
+	// Known .status.conditions.type are: "Execution", "Ready"
 	//+patchMergeKey=type
 	//+patchStrategy=merge
 	//+listType=map
@@ -70,6 +253,12 @@ type ClusterExecuterStatus struct {
 //+kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.type"
 //+kubebuilder:printcolumn:name="Executed",type="string",JSONPath=".status.conditions[?(@.type=='Execution')].status"
 //+kubebuilder:printcolumn:name="CompletedPCT",type="string",JSONPath=".status.completedPct"
+//+kubebuilder:printcolumn:name="Progress",type="string",JSONPath=".status.progress"
+//+kubebuilder:printcolumn:name="CLUSTER",type="string",JSONPath=".status.clusterName"
+//+kubebuilder:printcolumn:name="DATABASES",type="integer",JSONPath=".status.databases"
+//+kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason"
+//+kubebuilder:printcolumn:name="LAST-APPLIED",type="date",JSONPath=".status.lastAppliedAt",priority=1
+//+kubebuilder:printcolumn:name="CHECKSUM",type="string",JSONPath=".status.shortChecksum",priority=1
 type ClusterExecuter struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,89 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ComplianceExecuterLabel holds the name of the cluster executer a compliance report was written for
+	ComplianceExecuterLabel string = "schema.operator/executer"
+)
+
+// ComplianceStatus is the overall outcome of the assertions of a compliance report
+type ComplianceStatus string
+
+const (
+	// CompliancePass all the assertions passed
+	CompliancePass ComplianceStatus = "Pass"
+	// ComplianceFail all the assertions failed
+	ComplianceFail ComplianceStatus = "Fail"
+	// CompliancePartial some of the assertions failed
+	CompliancePartial ComplianceStatus = "Partial"
+)
+
+// AssertionResult is the outcome of a single assertion on a database
+type AssertionResult struct {
+	Database string `json:"database"`
+	Query    string `json:"query"`
+	Passed   bool   `json:"passed"`
+	RowCount int    `json:"rowCount"`
+	// Message is the fail message of a failed assertion, or the error of its query.
+	Message string `json:"message,omitempty"`
+}
+
+// ComplianceReportSpec holds the assertion results, it is immutable once written
+type ComplianceReportSpec struct {
+	// Executer is the cluster executer whose execution was verified.
+	Executer   NamespacedName `json:"executer"`
+	ClusterUri string         `json:"clusterUri"`
+	Revision   int32          `json:"revision"`
+	// +kubebuilder:validation:Optional
+	Assertions    []AssertionResult `json:"assertions,omitempty"`
+	OverallStatus ComplianceStatus  `json:"overallStatus"`
+	ReportedAt    metav1.Time       `json:"reportedAt"`
+}
+
+// ComplianceReport records whether the schema assertions held after an execution, for auditors
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterUri"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".spec.overallStatus"
+//+kubebuilder:printcolumn:name="Reported",type="date",JSONPath=".spec.reportedAt"
+type ComplianceReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ComplianceReportSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ComplianceReportList contains a list of ComplianceReport
+type ComplianceReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ComplianceReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ComplianceReport{}, &ComplianceReportList{})
+}
+
+// OverallCompliance returns `Pass` when all the `results` passed, `Fail` when none did and `Partial` otherwise.
+func OverallCompliance(results []AssertionResult) ComplianceStatus {
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	switch {
+	case passed == len(results):
+		return CompliancePass
+	case passed == 0:
+		return ComplianceFail
+	}
+	return CompliancePartial
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the `ComplianceReport` validating webhook with the manager.
+func (r *ComplianceReport) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-dbschema-microsoft-com-v1alpha1-compliancereport,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbschema.microsoft.com,resources=compliancereports,verbs=update,versions=v1alpha1,name=vcompliancereport.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ComplianceReport{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ComplianceReport) ValidateCreate() error {
+	return nil
+}
+
+// ValidateUpdate rejects any change of a written report.
+func (r *ComplianceReport) ValidateUpdate(old runtime.Object) error {
+	oldReport, ok := old.(*ComplianceReport)
+	if !ok {
+		return fmt.Errorf("expected a ComplianceReport but got a %T", old)
+	}
+	if !reflect.DeepEqual(oldReport.Spec, r.Spec) {
+		return fmt.Errorf("compliance report %s is immutable", r.Name)
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ComplianceReport) ValidateDelete() error {
+	return nil
+}
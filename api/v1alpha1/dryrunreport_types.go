@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseDelta is the script a dry run would apply to a database
+type DatabaseDelta struct {
+	Database string `json:"database"`
+	Delta    string `json:"delta"`
+}
+
+// DryRunReportSpec holds the changes a dry run execution proposes, it is written once by the operator
+type DryRunReportSpec struct {
+	// Executer is the cluster executer that ran the dry run.
+	Executer   NamespacedName `json:"executer"`
+	ClusterUri string         `json:"clusterUri"`
+	Revision   int32          `json:"revision"`
+	// Source is the schema config map the changes were computed from.
+	Source NamespacedName `json:"source"`
+	// Databases holds the delta of every database with pending changes.
+	// +kubebuilder:validation:Optional
+	Databases []DatabaseDelta `json:"databases,omitempty"`
+	// Summary counts the proposed changes.
+	Summary SchemaDiffSummary `json:"summary"`
+	// TotalChanges is the number of proposed changes.
+	TotalChanges int `json:"totalChanges"`
+	// ExpiresAt is the time the report is deleted.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// DryRunReport surfaces the schema changes a dry run execution would apply, until it expires
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterUri"
+//+kubebuilder:printcolumn:name="Total-Changes",type="integer",JSONPath=".spec.totalChanges"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type DryRunReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DryRunReportSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DryRunReportList contains a list of DryRunReport
+type DryRunReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DryRunReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DryRunReport{}, &DryRunReportList{})
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnforcementMode is how a violated schema rule is handled
+type EnforcementMode string
+
+const (
+	// EnforcementWarn reports the violation and applies the schema
+	EnforcementWarn EnforcementMode = "Warn"
+	// EnforcementDeny blocks the execution of the schema
+	EnforcementDeny EnforcementMode = "Deny"
+	// ConditionPolicyViolation is set on a cluster executer whose schema breaks a `Deny` rule of a global schema policy
+	ConditionPolicyViolation string = "PolicyViolation"
+)
+
+// SchemaRule is a rule the kql of every schema deployment must follow
+type SchemaRule struct {
+	Name string `json:"name"`
+	// Query is a read-only query run with the kql about to be applied in the `proposedKQL` string,
+	// the rule is violated when it returns rows (e.g. `print kql = proposedKQL | where kql matches regex @"\bpassword\s*:"`).
+	Query string `json:"query"`
+	// Enforcement is `Warn` (the default) or `Deny`.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Warn;Deny
+	Enforcement EnforcementMode `json:"enforcement,omitempty"`
+	// Message describes the violation.
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// GlobalSchemaPolicySpec defines the rules checked before a schema is applied to any managed cluster
+type GlobalSchemaPolicySpec struct {
+	// Suspend stops checking the rules of the policy.
+	// +kubebuilder:validation:Optional
+	Suspend bool         `json:"suspend,omitempty"`
+	Rules   []SchemaRule `json:"rules"`
+}
+
+// GlobalSchemaPolicy holds baseline schema rules checked on the kql of every Kusto cluster executer before it executes
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend"
+type GlobalSchemaPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GlobalSchemaPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GlobalSchemaPolicyList contains a list of GlobalSchemaPolicy
+type GlobalSchemaPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GlobalSchemaPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GlobalSchemaPolicy{}, &GlobalSchemaPolicyList{})
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigMapRef references a schema config map
+type ConfigMapRef struct {
+	Name string `json:"name"`
+	// Namespace defaults to the namespace of the referencing object.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SchemaCompositionSpec defines the desired state of SchemaComposition
+type SchemaCompositionSpec struct {
+	// Sources are the config maps whose `kql` keys are merged, every table may only be defined by a single source.
+	// +kubebuilder:validation:MinItems:=1
+	Sources []ConfigMapRef `json:"sources"`
+	// Order lists source names in the order they are merged, the unlisted sources follow in the `sources` order.
+	// +kubebuilder:validation:Optional
+	Order []string `json:"order,omitempty"`
+	// OutputConfigMap is the name of the config map the merged kql is written to, defaults to `<name>-kql`.
+	// +kubebuilder:validation:Optional
+	OutputConfigMap string `json:"outputConfigMap,omitempty"`
+}
+
+// SchemaCompositionStatus defines the observed state of SchemaComposition
+type SchemaCompositionStatus struct {
+	// MergedConfigMap is the config map holding the merged kql, used as the `source` of a schema deployment.
+	MergedConfigMap NamespacedName `json:"mergedConfigMap,omitempty"`
+	// MergedSources are the names of the merged sources in the merge order.
+	MergedSources []string `json:"mergedSources,omitempty"`
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Ready"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaComposition merges the schemas of multiple config maps, e.g. owned by different teams, into a single kql script
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Merged",type="string",JSONPath=".status.mergedConfigMap.name"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+type SchemaComposition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaCompositionSpec   `json:"spec,omitempty"`
+	Status SchemaCompositionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaCompositionList contains a list of SchemaComposition
+type SchemaCompositionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaComposition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaComposition{}, &SchemaCompositionList{})
+}
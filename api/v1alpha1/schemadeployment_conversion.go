@@ -0,0 +1,7 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+// Hub marks v1alpha1 as the stored version of the schema deployments, the other versions are converted to it.
+func (*SchemaDeployment) Hub() {}
@@ -4,6 +4,10 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,6 +27,45 @@ const (
 	FailurePolicyRollback FailurePolicyEnum = "rollback"
 )
 
+// ConflictStrategy Enum for how a schema deployment resolves concurrent updates of its schema source
+// +kubebuilder:validation:Enum=OwnerWins;LatestWins;Manual
+type ConflictStrategy string
+
+const (
+	// ConflictOwnerWins ignores source changes made by another field manager than the owner of the source.
+	ConflictOwnerWins ConflictStrategy = "OwnerWins"
+	// ConflictLatestWins applies the source of the latest version of the schema deployment.
+	ConflictLatestWins ConflictStrategy = "LatestWins"
+	// ConflictManual pauses the schema deployment on a source change until it is approved.
+	ConflictManual ConflictStrategy = "Manual"
+)
+
+// ReconcilePolicyType Enum for when a schema deployment is reconciled
+// +kubebuilder:validation:Enum=OnChange;Periodic;Manual
+type ReconcilePolicyType string
+
+const (
+	// ReconcileOnChange reconciles the schema deployment only when its schema source changed.
+	ReconcileOnChange ReconcilePolicyType = "OnChange"
+	// ReconcilePeriodic reconciles the schema deployment once every `reconcileInterval`, correcting drift.
+	ReconcilePeriodic ReconcilePolicyType = "Periodic"
+	// ReconcileManual reconciles the schema deployment only when it is annotated with a new `schema.operator/trigger`.
+	ReconcileManual ReconcilePolicyType = "Manual"
+)
+
+// DeletionPolicyEnum Enum for the actions run on the target databases when a schema deployment is deleted
+// +kubebuilder:validation:Enum=Retain;DeleteManagedObjects;RevertToBaseline
+type DeletionPolicyEnum string
+
+const (
+	// DeletionPolicyRetain keeps the schema objects in the target databases.
+	DeletionPolicyRetain DeletionPolicyEnum = "Retain"
+	// DeletionPolicyDeleteManagedObjects drops the tables and functions defined in the deployed schema.
+	DeletionPolicyDeleteManagedObjects DeletionPolicyEnum = "DeleteManagedObjects"
+	// DeletionPolicyRevertToBaseline applies the baseline schema config map to the target databases.
+	DeletionPolicyRevertToBaseline DeletionPolicyEnum = "RevertToBaseline"
+)
+
 // DBTypeEnum Enum for the supported DB types
 type DBTypeEnum string
 
@@ -35,8 +78,67 @@ const (
 	DBTypeEventhub DBTypeEnum = "eventhub"
 	// ConditionExecution execution condition status
 	ConditionExecution string = "Execution"
+	// ConditionClusterReachable cluster reachability condition status
+	ConditionClusterReachable string = "ClusterReachable"
+	// WatchLabel marks schema source config maps whose changes trigger a reconcile of the schema deployments using them
+	WatchLabel string = "schema.operator/watch"
+	// ManagedLabel marks the schema deployments reconciled by the operator when the operator config requires it
+	ManagedLabel string = "schema.operator/managed"
+	// ConditionInvalid invalid spec condition status
+	ConditionInvalid string = "Invalid"
+	// ConditionInterrupted is set on a cluster executer whose execution was stopped by an operator shutdown
+	ConditionInterrupted string = "Interrupted"
+	// CancelAnnotation requests cancelling the running executions of the current revision
+	CancelAnnotation string = "schema.operator/cancel"
+	// LastAppliedAnnotation records the time (RFC3339) the schema was last applied by a cluster executer
+	LastAppliedAnnotation string = "schema.operator/last-applied"
+	// ForceReconcileAnnotation set to "true" bypasses the executer cooldown once
+	ForceReconcileAnnotation string = "schema.operator/force-reconcile"
+	// DefaultCooldownSeconds is the default time a cluster executer waits before re-applying the schema
+	DefaultCooldownSeconds int = 60
+	// ConfirmApplyAnnotation set to "true" allows turning off the observation mode of a schema deployment
+	ConfirmApplyAnnotation string = "schema.operator/confirm-apply"
+	// PromotedVersionAnnotation on a schema registry config map holds the schema version applied instead of the latest
+	PromotedVersionAnnotation string = "schema.operator/promoted-version"
+	// PriorityAnnotation sets the priority of a schema deployment or cluster executer without a `Priority` spec field
+	PriorityAnnotation string = "schema.operator/priority"
+	// PriorityNormal is the default reconcile priority
+	PriorityNormal int = 0
+	// PriorityCritical is the highest reconcile priority, e.g. for production schemas
+	PriorityCritical int = 100
+	// SchemaFinalizer runs the deletion policy of a schema deployment before it is removed
+	SchemaFinalizer string = "schema.operator/finalizer"
+	// ConditionDependencyTimeout is set on a schema deployment whose cross cluster dependencies weren't applied in time
+	ConditionDependencyTimeout string = "DependencyTimeout"
+	// ConditionSourceConflict is set on a schema deployment whose source change was rejected or awaits approval
+	ConditionSourceConflict string = "SourceConflict"
+	// ApproveSourceAnnotation set to the `<namespace>/<name>` of a changed source approves it under the `Manual` conflict resolution
+	ApproveSourceAnnotation string = "schema.operator/approve-source"
+	// TriggerAnnotation set to a new UUID reconciles a schema deployment with the `Manual` reconcile policy once
+	TriggerAnnotation string = "schema.operator/trigger"
 )
 
+// SchemaVersionRef references a specific version of a schema config map
+type SchemaVersionRef struct {
+	ConfigMapName string `json:"configMapName"`
+	// ConfigMapNamespace defaults to the namespace of the schema deployment.
+	// +kubebuilder:validation:Optional
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+	// ResourceVersion is the `metadata.resourceVersion` of the config map version to deploy.
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// ClusterDependency is a schema version that must be applied on another cluster before the schema deployment is executed
+type ClusterDependency struct {
+	// ClusterURI is the cluster of the cluster executers the dependency is checked on.
+	ClusterURI string `json:"clusterURI"`
+	// SchemaVersion is the `appliedChecksum` a cluster executer of the cluster must report.
+	SchemaVersion string `json:"schemaVersion"`
+	// Timeout is the time to wait for the dependency, zero waits without limit.
+	// +kubebuilder:validation:Optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
 // TargetFilter contains target filter configuration
 type TargetFilter struct {
 	// +kubebuilder:validation:MinItems:=1
@@ -45,12 +147,38 @@ type TargetFilter struct {
 	DB          string   `json:"db"`
 	// +kubebuilder:validation:Optional
 	Webhook string `json:"webhook,omitempty"`
+	// WebhookHeaders are added to every `Webhook` request (e.g. the `Ocp-Apim-Subscription-Key` of an API gateway),
+	// a `$(SECRET_NAME:KEY)` value is read from the `KEY` of the secret `SECRET_NAME` in the namespace of the schema deployment.
+	// +kubebuilder:validation:Optional
+	WebhookHeaders map[string]string `json:"webhookHeaders,omitempty"`
+	// FilterExpression narrows the databases of `DB` with an expression over their properties (kusto only),
+	// e.g. `db.name =~ "^prod-" && db.tag["env"] == "production" && db.sizeGB > 100`.
+	// +kubebuilder:validation:Optional
+	FilterExpression string `json:"filterExpression,omitempty"`
 	// +kubebuilder:validation:Optional
 	Label string `json:"label,omitempty"`
 	// +kubebuilder:validation:Optional
 	DBS    []string `json:"dbs,omitempty"`
 	Create bool     `json:"create,omitempty"`
 	Regexp bool     `json:"regexp,omitempty"`
+	// AutoCreateDatabases creates listed databases that are missing from the cluster before execution.
+	// +kubebuilder:validation:Optional
+	AutoCreateDatabases bool `json:"autoCreateDatabases,omitempty"`
+	// HotCacheRetention is the caching policy applied to auto created databases (e.g. `30d`).
+	// +kubebuilder:validation:Optional
+	HotCacheRetention string `json:"hotCacheRetention,omitempty"`
+	// SoftDeleteRetention is the retention policy applied to auto created databases (e.g. `365d`).
+	// +kubebuilder:validation:Optional
+	SoftDeleteRetention string `json:"softDeleteRetention,omitempty"`
+	// SchemaURL is downloaded and used as the kql instead of the `ConfigMap` kql (e.g. an Azure Blob SAS URL or a GitHub raw URL).
+	// +kubebuilder:validation:Optional
+	SchemaURL string `json:"schemaURL,omitempty"`
+	// IncludeFollowers keeps the read-only follower databases in the targets, e.g. for schema inspection.
+	// +kubebuilder:validation:Optional
+	IncludeFollowers bool `json:"includeFollowers,omitempty"`
+	// DatabaseTimeouts maps a database to the time its execution may take, the other databases use the operator execution timeout (kusto only).
+	// +kubebuilder:validation:Optional
+	DatabaseTimeouts map[string]metav1.Duration `json:"databaseTimeouts,omitempty"`
 }
 
 // SchemaDeploymentSpec defines the desired state of SchemaDeployment
@@ -61,11 +189,71 @@ type SchemaDeploymentSpec struct {
 	ApplyTo TargetFilter   `json:"applyTo"`
 	Type    DBTypeEnum     `json:"type"`
 	Source  NamespacedName `json:"source,omitempty"`
+	// SecretRef reads the kql from a key of a `Secret` in the namespace of the schema deployment instead of the `source` config map.
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default:=rollback
 	FailurePolicy FailurePolicyEnum `json:"failurePolicy"`
 	// +kubebuilder:default:=true
 	FailIfDataLoss bool `json:"failIfDataLoss"`
+	// DatabaseRoles maps a database role (`admin`, `viewer`, `ingestor`, `monitor`, `user`) to the AAD principals
+	// that should be assigned to it on every target database (kusto only).
+	// +kubebuilder:validation:Optional
+	DatabaseRoles map[string][]string `json:"databaseRoles,omitempty"`
+	// VersionPin deploys exactly the referenced config map version and ignores newer changes until removed.
+	// +kubebuilder:validation:Optional
+	VersionPin *SchemaVersionRef `json:"versionPin,omitempty"`
+	// CooldownSeconds is the time after a successful apply in which the cluster executers skip reconciles.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=60
+	CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+	// ObservationMode only computes the pending schema diff of the targets and never applies it.
+	// Turning it off requires the `schema.operator/confirm-apply` annotation.
+	// +kubebuilder:validation:Optional
+	ObservationMode bool `json:"observationMode,omitempty"`
+	// TenantID is the Azure tenant of the target clusters, empty for the tenant of the operator (kusto only).
+	// +kubebuilder:validation:Optional
+	TenantID string `json:"tenantID,omitempty"`
+	// CredentialSecretRef references a secret with the `clientId` and `clientSecret` of a service principal
+	// in the `tenantID`, used instead of the operator identity (kusto only).
+	// +kubebuilder:validation:Optional
+	CredentialSecretRef *corev1.SecretReference `json:"credentialSecretRef,omitempty"`
+	// EventHubConnectionStringRef is the `Secret` key holding the connection string of an Event Hub every applied
+	// schema change is published to, e.g. for audit systems and monitoring pipelines.
+	// +kubebuilder:validation:Optional
+	EventHubConnectionStringRef *corev1.SecretKeySelector `json:"eventHubConnectionStringRef,omitempty"`
+	// Priority orders the pending cluster executers, higher priorities (up to 100 for critical schemas) are executed first.
+	// Without it the `schema.operator/priority` annotation is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	Priority int `json:"priority,omitempty"`
+	// DeletionPolicy is the action run on the target databases of the current revision when the schema deployment is deleted.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=Retain
+	DeletionPolicy DeletionPolicyEnum `json:"deletionPolicy,omitempty"`
+	// BaselineConfigMapRef is the schema config map applied by the `RevertToBaseline` deletion policy.
+	// +kubebuilder:validation:Optional
+	BaselineConfigMapRef *NamespacedName `json:"baselineConfigMapRef,omitempty"`
+	// ReconcileInterval is the period the schema deployment is reconciled at, e.g. `1m` for frequent drift checks.
+	// Without it the operator requeue interval is used.
+	// +kubebuilder:validation:Optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+	// CrossClusterDependencies delay the execution of new revisions until the dependencies are applied on their clusters,
+	// e.g. materialized views over tables of another cluster.
+	// +kubebuilder:validation:Optional
+	CrossClusterDependencies []ClusterDependency `json:"crossClusterDependencies,omitempty"`
+	// ConflictResolution decides which change of the schema source is applied when the schema deployment is updated concurrently.
+	// Without it every change is applied.
+	// +kubebuilder:validation:Optional
+	ConflictResolution ConflictStrategy `json:"conflictResolution,omitempty"`
+	// ReconcilePolicy decides when the schema deployment is reconciled: `OnChange` when the schema source changed,
+	// `Periodic` every `reconcileInterval` and `Manual` when triggered by the `schema.operator/trigger` annotation.
+	// Without it every event reconciles the schema deployment.
+	// +kubebuilder:validation:Optional
+	ReconcilePolicy ReconcilePolicyType `json:"reconcilePolicy,omitempty"`
 }
 
 // SchemaDeploymentStatus defines the observed state of SchemaDeployment
@@ -80,8 +268,24 @@ type SchemaDeploymentStatus struct {
 	LastSuccessfulRevision int32            `json:"lastSuccessfulRevision"`
 	CurrentVerDeployment   NamespacedName   `json:"currentVerDeployment"`
 	OldVerDeployment       []NamespacedName `json:"oldVerDeployment,omitempty"`
+	// PinnedVersion is the version pin the current revision was created from.
+	PinnedVersion *SchemaVersionRef `json:"pinnedVersion,omitempty"`
+	// ReconcileInterval is the `reconcileInterval` the schema deployment is requeued with.
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+	// DependenciesWaitingSince is the time the schema deployment started waiting for its `crossClusterDependencies`.
+	DependenciesWaitingSince *metav1.Time `json:"dependenciesWaitingSince,omitempty"`
+	// DependencyVersions are the `clusterURI=schemaVersion` of the dependencies waited on since `dependenciesWaitingSince`.
+	DependencyVersions []string `json:"dependencyVersions,omitempty"`
+	// AcceptedSource is the schema source accepted by the `conflictResolution`.
+	AcceptedSource *NamespacedName `json:"acceptedSource,omitempty"`
+	// SourceOwner is the field manager that set the accepted source.
+	SourceOwner string `json:"sourceOwner,omitempty"`
+	// ObservedSourceVersion is the version of the schema source the last reconcile completed with.
+	ObservedSourceVersion string `json:"observedSourceVersion,omitempty"`
+	// LastReconcileTime is the time the last reconcile completed, the revision was executed or failed.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
 	// Conditions is an array of conditions.
-	// Known .status.conditions.type are: "Execution"
+	// Known .status.conditions.type are: "Execution", "Invalid", "DependencyTimeout", "SourceConflict"
 	//+patchMergeKey=type
 	//+patchStrategy=merge
 	//+listType=map
@@ -92,6 +296,7 @@ type SchemaDeploymentStatus struct {
 // SchemaDeployment is the Base CRD for the schema deployment operator
 // it is used to define which schema to deploy to a target cluster
 //+kubebuilder:object:root=true
+//+kubebuilder:storageversion
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.type"
 //+kubebuilder:printcolumn:name="Executed",type="string",JSONPath=".status.conditions[?(@.type=='Execution')].status"
@@ -116,6 +321,46 @@ func init() {
 	SchemeBuilder.Register(&SchemaDeployment{}, &SchemaDeploymentList{})
 }
 
+// ValidateSource checks that the kql is read either from the `source` config map or from the `secretRef` secret.
+func (s *SchemaDeploymentSpec) ValidateSource() error {
+	if s.SecretRef != nil && s.Source.Name != "" {
+		return fmt.Errorf("only one of source and secretRef can be set")
+	}
+	return nil
+}
+
+// principalFQNRe are the AAD principal FQNs of the kusto role commands, e.g. `aaduser=alice@contoso.com`,
+// `aadgroup=<object id>;<tenant>` or `aadapp=<application id>;<tenant>`.
+var principalFQNRe = regexp.MustCompile(`^(?i:aaduser|aadgroup|aadapp)=[\w.@-]+(?:;[\w.-]+)?$`)
+
+// ValidatePrincipalFQN checks that `fqn` is an AAD principal FQN, since it is formatted into the kusto role commands.
+func ValidatePrincipalFQN(fqn string) error {
+	if !principalFQNRe.MatchString(fqn) {
+		return fmt.Errorf("invalid principal %q: expected aaduser=, aadgroup= or aadapp= followed by the principal id", fqn)
+	}
+	return nil
+}
+
+// ValidateDatabaseRoles checks that the principals of the `databaseRoles` are AAD principal FQNs.
+func (s *SchemaDeploymentSpec) ValidateDatabaseRoles() error {
+	for role, principals := range s.DatabaseRoles {
+		for _, principal := range principals {
+			if err := ValidatePrincipalFQN(principal); err != nil {
+				return fmt.Errorf("database role %s: %w", role, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDeletionPolicy checks that the `RevertToBaseline` deletion policy references a baseline config map.
+func (s *SchemaDeploymentSpec) ValidateDeletionPolicy() error {
+	if s.DeletionPolicy == DeletionPolicyRevertToBaseline && (s.BaselineConfigMapRef == nil || s.BaselineConfigMapRef.Name == "") {
+		return fmt.Errorf("the %s deletion policy requires baselineConfigMapRef", DeletionPolicyRevertToBaseline)
+	}
+	return nil
+}
+
 // IsExecuted checks if the schema deployment object was executed.
 func (t *SchemaDeployment) IsExecuted() bool {
 	return t.Status.Executed
@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var schemadeploymentlog = logf.Log.WithName("schemadeployment-resource")
+
+// SetupWebhookWithManager registers the `SchemaDeployment` validating webhook with the manager,
+// together with the `/convert` endpoint serving the v1beta1 version.
+func (r *SchemaDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-dbschema-microsoft-com-v1alpha1-schemadeployment,mutating=false,failurePolicy=fail,sideEffects=None,groups=dbschema.microsoft.com,resources=schemadeployments,verbs=create;update,versions=v1alpha1,name=vschemadeployment.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &SchemaDeployment{}
+
+// ValidateCreate rejects schema deployments reading the kql from both a config map and a secret,
+// reverting to a baseline without a baseline config map, or assigning database roles to invalid principals.
+func (r *SchemaDeployment) ValidateCreate() error {
+	if err := r.Spec.ValidateSource(); err != nil {
+		return err
+	}
+	if err := r.Spec.ValidateDatabaseRoles(); err != nil {
+		return err
+	}
+	return r.Spec.ValidateDeletionPolicy()
+}
+
+// ValidateUpdate rejects turning off the observation mode without the `schema.operator/confirm-apply` annotation.
+func (r *SchemaDeployment) ValidateUpdate(old runtime.Object) error {
+	if err := r.Spec.ValidateSource(); err != nil {
+		return err
+	}
+	if err := r.Spec.ValidateDeletionPolicy(); err != nil {
+		return err
+	}
+	if err := r.Spec.ValidateDatabaseRoles(); err != nil {
+		return err
+	}
+	oldDeployment, ok := old.(*SchemaDeployment)
+	if !ok {
+		return fmt.Errorf("expected a SchemaDeployment but got a %T", old)
+	}
+	if oldDeployment.Spec.ObservationMode && !r.Spec.ObservationMode &&
+		strings.ToLower(r.GetAnnotations()[ConfirmApplyAnnotation]) != "true" {
+		schemadeploymentlog.Info("rejected leaving observation mode", "name", r.Name, "namespace", r.Namespace)
+		return fmt.Errorf("turning off the observation mode of %s requires the %s: \"true\" annotation", r.Name, ConfirmApplyAnnotation)
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SchemaDeployment) ValidateDelete() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchemaExecutionResultSpec holds the outcome of the delta-kusto jobs of an execution, it is written once by the operator
+type SchemaExecutionResultSpec struct {
+	// JobID identifies the delta-kusto job of the execution.
+	JobID      string `json:"jobID"`
+	ClusterURI string `json:"clusterURI"`
+	// Databases are the databases the execution ran on.
+	// +kubebuilder:validation:Optional
+	Databases []string `json:"databases,omitempty"`
+	// DeltaKustoOutput is the output of delta-kusto, a json object of the output of every job by its ID when the
+	// execution ran a job per database.
+	// +kubebuilder:validation:Optional
+	DeltaKustoOutput string      `json:"deltaKustoOutput,omitempty"`
+	StartedAt        metav1.Time `json:"startedAt"`
+	CompletedAt      metav1.Time `json:"completedAt"`
+	// ExitCode is the exit code of the first failed job, zero when the execution succeeded and -1 when a job failed without exiting.
+	ExitCode int `json:"exitCode"`
+	// Checksum is the checksum of the rendered kql and the target databases of the execution.
+	// +kubebuilder:validation:Optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// SchemaExecutionResult keeps the delta-kusto output of an execution, until it is older than the max result age
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Job-ID",type="string",JSONPath=".spec.jobID"
+//+kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterURI"
+//+kubebuilder:printcolumn:name="Exit-Code",type="integer",JSONPath=".spec.exitCode"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SchemaExecutionResult struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SchemaExecutionResultSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaExecutionResultList contains a list of SchemaExecutionResult
+type SchemaExecutionResultList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaExecutionResult `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaExecutionResult{}, &SchemaExecutionResultList{})
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GroupRoleOwner grants full control of the schema group
+	GroupRoleOwner string = "Owner"
+	// GroupRoleWriter grants registering schemas in the schema group
+	GroupRoleWriter string = "Writer"
+	// GroupRoleReader grants reading the schemas of the schema group
+	GroupRoleReader string = "Reader"
+)
+
+// RBACAction is the change made to a role assignment
+type RBACAction string
+
+const (
+	// RBACAdd assigns the role to the principal
+	RBACAdd RBACAction = "Add"
+	// RBACRemove removes the role assignment of the principal
+	RBACRemove RBACAction = "Remove"
+)
+
+// RBACChange is a role assignment change of a schema group
+type RBACChange struct {
+	Action    RBACAction `json:"action"`
+	Principal string     `json:"principal"`
+	Role      string     `json:"role"`
+}
+
+// SchemaGroupSpec defines the desired state of SchemaGroup
+type SchemaGroupSpec struct {
+	// SubscriptionID of the Event Hubs namespace.
+	SubscriptionID string `json:"subscriptionId"`
+	// ResourceGroup of the Event Hubs namespace.
+	ResourceGroup string `json:"resourceGroup"`
+	// Namespace is the name of the Event Hubs namespace of the schema group.
+	Namespace string `json:"namespace"`
+	// GroupName is the name of the schema group, defaults to the name of the resource.
+	// +kubebuilder:validation:Optional
+	GroupName string `json:"groupName,omitempty"`
+	// GroupRBAC maps AAD principal object ids to their roles on the schema group: `Owner`, `Writer` or `Reader`.
+	// Role assignments made by the operator that are not listed are removed.
+	// +kubebuilder:validation:Optional
+	GroupRBAC map[string][]string `json:"groupRBAC,omitempty"`
+	// DryRun plans the role assignment changes into the status without applying them.
+	// +kubebuilder:validation:Optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// SchemaGroupStatus defines the observed state of SchemaGroup
+type SchemaGroupStatus struct {
+	// PlannedChanges are the role assignment changes found by the last dry run.
+	PlannedChanges []RBACChange `json:"plannedChanges,omitempty"`
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Ready"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaGroup is an Event Hubs schema group whose role assignments are managed by the operator
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace"
+//+kubebuilder:printcolumn:name="DryRun",type="boolean",JSONPath=".spec.dryRun"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+type SchemaGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaGroupSpec   `json:"spec,omitempty"`
+	Status SchemaGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaGroupList contains a list of SchemaGroup
+type SchemaGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaGroup{}, &SchemaGroupList{})
+}
+
+// SchemaGroupName returns the name of the schema group.
+func (g *SchemaGroup) SchemaGroupName() string {
+	if g.Spec.GroupName != "" {
+		return g.Spec.GroupName
+	}
+	return g.Name
+}
+
+// Scope returns the ARM resource id of the schema group.
+func (g *SchemaGroup) Scope() string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.EventHub/namespaces/%s/schemagroups/%s",
+		g.Spec.SubscriptionID, g.Spec.ResourceGroup, g.Spec.Namespace, g.SchemaGroupName())
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultMaxHistory is the default number of entries kept in a schema history.
+const DefaultMaxHistory = 50
+
+// SchemaHistoryEntry describes a single applied schema version
+type SchemaHistoryEntry struct {
+	Revision                 int32       `json:"revision"`
+	ConfigMapResourceVersion string      `json:"configMapResourceVersion"`
+	AppliedChecksum          string      `json:"appliedChecksum"`
+	AppliedAt                metav1.Time `json:"appliedAt"`
+	// +kubebuilder:validation:Optional
+	Databases     []string `json:"databases,omitempty"`
+	ChangeSummary string   `json:"changeSummary,omitempty"`
+	// AppliedBy is the manager that last changed the schema config map.
+	AppliedBy string `json:"appliedBy,omitempty"`
+}
+
+// SchemaHistorySpec defines the desired state of SchemaHistory
+type SchemaHistorySpec struct {
+	// MaxHistory is the number of entries to keep, the oldest entries are pruned first.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=50
+	MaxHistory int `json:"maxHistory,omitempty"`
+}
+
+// SchemaHistoryStatus defines the observed state of SchemaHistory
+type SchemaHistoryStatus struct {
+	// History holds the applied schema versions ordered by apply time.
+	History []SchemaHistoryEntry `json:"history,omitempty"`
+}
+
+// SchemaHistory records the schema versions applied by the `SchemaDeployment` of the same name
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Max",type="integer",JSONPath=".spec.maxHistory"
+type SchemaHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaHistorySpec   `json:"spec,omitempty"`
+	Status SchemaHistoryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaHistoryList contains a list of SchemaHistory
+type SchemaHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaHistory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaHistory{}, &SchemaHistoryList{})
+}
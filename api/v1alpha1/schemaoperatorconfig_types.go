@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchemaOperatorConfigName is the name of the `SchemaOperatorConfig` the operator reads, other configs are ignored.
+const SchemaOperatorConfigName = "default"
+
+// SchemaOperatorConfigSpec defines the operator settings, unset (zero) settings keep their environment value or default
+type SchemaOperatorConfigSpec struct {
+	// MaxParallelDBs is the number of databases an isolated kusto execution runs at once, unless the execution sets its own.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxParallelDBs int `json:"maxParallelDBs,omitempty"`
+	// CPUPerWorker is the cpu a parallel delta-kusto job is estimated to use when checking the resource quota of the operator namespace.
+	// +kubebuilder:validation:Optional
+	CPUPerWorker *resource.Quantity `json:"cpuPerWorker,omitempty"`
+	// MemPerWorker is the memory a parallel delta-kusto job is estimated to use when checking the resource quota of the operator namespace.
+	// +kubebuilder:validation:Optional
+	MemPerWorker *resource.Quantity `json:"memPerWorker,omitempty"`
+	// SQLParallelWorkers is the number of schemas sqlpackage runs on at once.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	SQLParallelWorkers int `json:"sqlParallelWorkers,omitempty"`
+	// MaxFailures is the number of failed executions after which a cluster executer stops retrying.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxFailures int `json:"maxFailures,omitempty"`
+	// ExecutionTimeout is the time a delta-kusto execution may run, unless the database has its own timeout.
+	// +kubebuilder:validation:Optional
+	ExecutionTimeout *metav1.Duration `json:"executionTimeout,omitempty"`
+	// WebhookTimeout is the time a database filter webhook request may take.
+	// +kubebuilder:validation:Optional
+	WebhookTimeout *metav1.Duration `json:"webhookTimeout,omitempty"`
+	// KustoPingTimeout is the time a kusto cluster has to answer the reachability check.
+	// +kubebuilder:validation:Optional
+	KustoPingTimeout *metav1.Duration `json:"kustoPingTimeout,omitempty"`
+	// RegistryRequestTimeout is the time an Event Hubs schema registry request may take.
+	// +kubebuilder:validation:Optional
+	RegistryRequestTimeout *metav1.Duration `json:"registryRequestTimeout,omitempty"`
+	// SchemaURLMaxSize is the maximal size in bytes of a schema downloaded from a URL.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	SchemaURLMaxSize int64 `json:"schemaURLMaxSize,omitempty"`
+	// ComplianceReportMaxAge is the time a compliance report is kept.
+	// +kubebuilder:validation:Optional
+	ComplianceReportMaxAge *metav1.Duration `json:"complianceReportMaxAge,omitempty"`
+	// DryRunReportTTL is the time a dry run report is kept.
+	// +kubebuilder:validation:Optional
+	DryRunReportTTL *metav1.Duration `json:"dryRunReportTTL,omitempty"`
+	// MaxResultAge is the time a schema execution result is kept.
+	// +kubebuilder:validation:Optional
+	MaxResultAge *metav1.Duration `json:"maxResultAge,omitempty"`
+	// NamespaceLabelSelector limits the reconciled schema resources to the namespaces matching the selector,
+	// within the watched namespaces of the operator scope.
+	// +kubebuilder:validation:Optional
+	NamespaceLabelSelector *metav1.LabelSelector `json:"namespaceLabelSelector,omitempty"`
+	// RequiredLabels limits the reconciled schema deployments to the ones holding all the labels,
+	// e.g. `schema.operator/managed: "true"`. Every schema deployment is reconciled when empty.
+	// +kubebuilder:validation:Optional
+	RequiredLabels map[string]string `json:"requiredLabels,omitempty"`
+}
+
+// SchemaOperatorConfigStatus defines the observed state of SchemaOperatorConfig
+type SchemaOperatorConfigStatus struct {
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Ready"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaOperatorConfig holds the operator settings which are reloaded without restarting the operator,
+// only the config named `default` is used
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+type SchemaOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaOperatorConfigSpec   `json:"spec,omitempty"`
+	Status SchemaOperatorConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaOperatorConfigList contains a list of SchemaOperatorConfig
+type SchemaOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaOperatorConfig{}, &SchemaOperatorConfigList{})
+}
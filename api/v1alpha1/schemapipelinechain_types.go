@@ -0,0 +1,121 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PipelineStagePhase is the progress of a single stage of a pipeline chain
+type PipelineStagePhase string
+
+const (
+	// PipelineStageWaiting the stage is waiting for the stage it depends on
+	PipelineStageWaiting PipelineStagePhase = "Waiting"
+	// PipelineStageGateFailed the gate query of the stage returned no rows
+	PipelineStageGateFailed PipelineStagePhase = "GateFailed"
+	// PipelineStageApplying the schema is being applied to the stage cluster
+	PipelineStageApplying PipelineStagePhase = "Applying"
+	// PipelineStageSucceeded the schema was applied to the stage cluster
+	PipelineStageSucceeded PipelineStagePhase = "Succeeded"
+	// PipelineStageFailed applying the schema to the stage cluster failed
+	PipelineStageFailed PipelineStagePhase = "Failed"
+	// PipelineStageBlocked a stage the stage depends on failed
+	PipelineStageBlocked PipelineStagePhase = "Blocked"
+)
+
+// PipelineStage is a single cluster of a schema pipeline chain
+type PipelineStage struct {
+	// Name identifies the stage in the status and names the schema deployment of the stage.
+	Name string `json:"name"`
+	// ClusterRef is the uri of the cluster of the stage.
+	ClusterRef string `json:"clusterRef"`
+	// ConfigMapRef is the `ConfigMap` holding the kql applied to the stage cluster.
+	ConfigMapRef corev1.LocalObjectReference `json:"configMapRef"`
+	// WaitForStageIndex is the index of the stage that must succeed before this stage is applied.
+	// A stage waiting for itself (e.g. the first stage), a later stage or a negative index is applied right away.
+	// +kubebuilder:validation:Optional
+	WaitForStageIndex int `json:"waitForStageIndex,omitempty"`
+	// GateQuery is a read-only query run on the cluster of the awaited stage before the stage applies a schema,
+	// the stage is blocked while it returns no rows (kusto only).
+	// +kubebuilder:validation:Optional
+	GateQuery string `json:"gateQuery,omitempty"`
+	// GateDatabase is the database the `GateQuery` runs in, defaults to the `db` of the chain.
+	// +kubebuilder:validation:Optional
+	GateDatabase string `json:"gateDatabase,omitempty"`
+}
+
+// PipelineStageStatus is the observed state of a single stage of a pipeline chain
+type PipelineStageStatus struct {
+	Name  string             `json:"name"`
+	Phase PipelineStagePhase `json:"phase"`
+	// Message describes why the stage is waiting, blocked or failed.
+	Message string `json:"message,omitempty"`
+	// Deployment is the schema deployment applying the stage schema.
+	Deployment NamespacedName `json:"deployment,omitempty"`
+}
+
+// SchemaPipelineChainSpec defines the desired state of SchemaPipelineChain
+type SchemaPipelineChainSpec struct {
+	Type DBTypeEnum `json:"type"`
+	// DB is the database filter of the schema deployments of the stages.
+	DB string `json:"db"`
+	// +kubebuilder:validation:Optional
+	Regexp bool `json:"regexp,omitempty"`
+	// +kubebuilder:default:=true
+	FailIfDataLoss bool `json:"failIfDataLoss"`
+	// Stages are applied in order, every stage once the stage at its `waitForStageIndex` succeeded.
+	// +kubebuilder:validation:MinItems:=1
+	Stages []PipelineStage `json:"stages"`
+}
+
+// SchemaPipelineChainStatus defines the observed state of SchemaPipelineChain
+type SchemaPipelineChainStatus struct {
+	Stages []PipelineStageStatus `json:"stages,omitempty"`
+	// FailedStage is the name of the first stage whose gate or execution failed.
+	FailedStage string `json:"failedStage,omitempty"`
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Ready"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaPipelineChain applies a schema to a sequence of clusters, gating every cluster on the previous one
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Failed",type="string",JSONPath=".status.failedStage"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+type SchemaPipelineChain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaPipelineChainSpec   `json:"spec,omitempty"`
+	Status SchemaPipelineChainStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaPipelineChainList contains a list of SchemaPipelineChain
+type SchemaPipelineChainList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaPipelineChain `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaPipelineChain{}, &SchemaPipelineChainList{})
+}
+
+// AwaitedStage returns the index of the stage the stage `i` waits for, -1 when it is applied right away.
+func (c *SchemaPipelineChain) AwaitedStage(i int) int {
+	wait := c.Spec.Stages[i].WaitForStageIndex
+	if wait < 0 || wait >= i {
+		return -1
+	}
+	return wait
+}
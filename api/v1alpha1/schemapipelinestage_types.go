@@ -0,0 +1,86 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1alpha1
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionReady ready condition status
+	ConditionReady string = "Ready"
+	// ApprovedAnnotation approves a stage that requires approval before promotion
+	ApprovedAnnotation string = "schema.operator/approved"
+)
+
+// SchemaPipelineStageSpec defines the desired state of SchemaPipelineStage
+type SchemaPipelineStageSpec struct {
+	// PreviousStage is the stage that must be ready before this stage is promoted.
+	// +kubebuilder:validation:Optional
+	PreviousStage *corev1.LocalObjectReference `json:"previousStage,omitempty"`
+	// SchemaRef is the `SchemaDeployment` promoted through the pipeline.
+	SchemaRef corev1.LocalObjectReference `json:"schemaRef"`
+	// ClusterRef is the uri of the cluster of the stage environment.
+	ClusterRef string `json:"clusterRef"`
+	// AutoPromotion keeps promoting changes of the schema once the stage was promoted.
+	// When false the stage is promoted once and later changes are not propagated.
+	// +kubebuilder:validation:Optional
+	AutoPromotion bool `json:"autoPromotion,omitempty"`
+	// ApprovalRequired pauses the stage until it is annotated with `schema.operator/approved: "true"`.
+	// +kubebuilder:validation:Optional
+	ApprovalRequired bool `json:"approvalRequired,omitempty"`
+}
+
+// SchemaPipelineStageStatus defines the observed state of SchemaPipelineStage
+type SchemaPipelineStageStatus struct {
+	Promoted   bool           `json:"promoted"`
+	Deployment NamespacedName `json:"deployment,omitempty"`
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Ready"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaPipelineStage is a single environment stage in a schema promotion pipeline
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Previous",type="string",JSONPath=".spec.previousStage.name"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+type SchemaPipelineStage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaPipelineStageSpec   `json:"spec,omitempty"`
+	Status SchemaPipelineStageStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaPipelineStageList contains a list of SchemaPipelineStage
+type SchemaPipelineStageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaPipelineStage `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaPipelineStage{}, &SchemaPipelineStageList{})
+}
+
+// IsReady checks if the stage ready condition is true.
+func (t *SchemaPipelineStage) IsReady() bool {
+	return meta.IsStatusConditionTrue(t.Status.Conditions, ConditionReady)
+}
+
+// IsApproved checks if the stage is annotated as approved.
+func (t *SchemaPipelineStage) IsApproved() bool {
+	return strings.ToLower(t.GetAnnotations()[ApprovedAnnotation]) == "true"
+}
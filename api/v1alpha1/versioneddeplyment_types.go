@@ -4,6 +4,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -16,11 +17,31 @@ type VersionedDeplymentSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// Foo is an example field of VersionedDeplyment. Edit versioneddeplyment_types.go to remove/update
-	Revision       int32          `json:"revision"`
-	ConfigMapName  NamespacedName `json:"configMapName"`
-	ApplyTo        TargetFilter   `json:"applyTo"`
-	Type           DBTypeEnum     `json:"type"`
-	FailIfDataLoss bool           `json:"failIfDataLoss"`
+	Revision       int32               `json:"revision"`
+	ConfigMapName  NamespacedName      `json:"configMapName"`
+	ApplyTo        TargetFilter        `json:"applyTo"`
+	Type           DBTypeEnum          `json:"type"`
+	FailIfDataLoss bool                `json:"failIfDataLoss"`
+	DatabaseRoles  map[string][]string `json:"databaseRoles,omitempty"`
+	// +kubebuilder:validation:Optional
+	CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+	// +kubebuilder:validation:Optional
+	ObservationMode bool `json:"observationMode,omitempty"`
+	// SecretRef is the `Secret` key the kql is read from, the config map only holds its checksum.
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+	// TenantID is the Azure tenant of the target clusters, empty for the tenant of the operator.
+	// +kubebuilder:validation:Optional
+	TenantID string `json:"tenantID,omitempty"`
+	// CredentialSecretRef references the service principal credentials of the `TenantID`.
+	// +kubebuilder:validation:Optional
+	CredentialSecretRef *corev1.SecretReference `json:"credentialSecretRef,omitempty"`
+	// EventHubConnectionStringRef is the `Secret` key of the Event Hub connection string schema changes are published to.
+	// +kubebuilder:validation:Optional
+	EventHubConnectionStringRef *corev1.SecretKeySelector `json:"eventHubConnectionStringRef,omitempty"`
+	// Priority orders the pending cluster executers, higher priorities are executed first.
+	// +kubebuilder:validation:Optional
+	Priority int `json:"priority,omitempty"`
 }
 
 // VersionedDeplymentStatus defines the observed state of VersionedDeplyment
@@ -11,10 +11,42 @@ Licensed under the MIT license.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssertionResult) DeepCopyInto(out *AssertionResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssertionResult.
+func (in *AssertionResult) DeepCopy() *AssertionResult {
+	if in == nil {
+		return nil
+	}
+	out := new(AssertionResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDependency) DeepCopyInto(out *ClusterDependency) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDependency.
+func (in *ClusterDependency) DeepCopy() *ClusterDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterExecuter) DeepCopyInto(out *ClusterExecuter) {
 	*out = *in
@@ -24,18 +56,1217 @@ func (in *ClusterExecuter) DeepCopyInto(out *ClusterExecuter) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuter.
-func (in *ClusterExecuter) DeepCopy() *ClusterExecuter {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuter.
+func (in *ClusterExecuter) DeepCopy() *ClusterExecuter {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExecuter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterExecuter) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExecuterList) DeepCopyInto(out *ClusterExecuterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterExecuter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterList.
+func (in *ClusterExecuterList) DeepCopy() *ClusterExecuterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExecuterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterExecuterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExecuterSpec) DeepCopyInto(out *ClusterExecuterSpec) {
+	*out = *in
+	in.ApplyTo.DeepCopyInto(&out.ApplyTo)
+	out.ConfigMapName = in.ConfigMapName
+	if in.DatabaseRoles != nil {
+		in, out := &in.DatabaseRoles, &out.DatabaseRoles
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialSecretRef != nil {
+		in, out := &in.CredentialSecretRef, &out.CredentialSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.EventHubConnectionStringRef != nil {
+		in, out := &in.EventHubConnectionStringRef, &out.EventHubConnectionStringRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterSpec.
+func (in *ClusterExecuterSpec) DeepCopy() *ClusterExecuterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExecuterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExecuterStatus) DeepCopyInto(out *ClusterExecuterStatus) {
+	*out = *in
+	in.Targets.DeepCopyInto(&out.Targets)
+	in.DoneTargets.DeepCopyInto(&out.DoneTargets)
+	in.Config.DeepCopyInto(&out.Config)
+	in.SkippedTargets.DeepCopyInto(&out.SkippedTargets)
+	if in.DatabaseProgress != nil {
+		in, out := &in.DatabaseProgress, &out.DatabaseProgress
+		*out = make(map[string]DBPhase, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastAppliedAt != nil {
+		in, out := &in.LastAppliedAt, &out.LastAppliedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingDiff != nil {
+		in, out := &in.PendingDiff, &out.PendingDiff
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastAppliedDiff != nil {
+		in, out := &in.LastAppliedDiff, &out.LastAppliedDiff
+		*out = new(SchemaDiffSummary)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterStatus.
+func (in *ClusterExecuterStatus) DeepCopy() *ClusterExecuterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExecuterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTargets) DeepCopyInto(out *ClusterTargets) {
+	*out = *in
+	if in.DBs != nil {
+		in, out := &in.DBs, &out.DBs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Schemas != nil {
+		in, out := &in.Schemas, &out.Schemas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DBResults != nil {
+		in, out := &in.DBResults, &out.DBResults
+		*out = make(map[string]DBResultEnum, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTargets.
+func (in *ClusterTargets) DeepCopy() *ClusterTargets {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTargets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceReport) DeepCopyInto(out *ComplianceReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceReport.
+func (in *ComplianceReport) DeepCopy() *ComplianceReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ComplianceReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceReportList) DeepCopyInto(out *ComplianceReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ComplianceReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceReportList.
+func (in *ComplianceReportList) DeepCopy() *ComplianceReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ComplianceReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceReportSpec) DeepCopyInto(out *ComplianceReportSpec) {
+	*out = *in
+	out.Executer = in.Executer
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]AssertionResult, len(*in))
+		copy(*out, *in)
+	}
+	in.ReportedAt.DeepCopyInto(&out.ReportedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceReportSpec.
+func (in *ComplianceReportSpec) DeepCopy() *ComplianceReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapRef) DeepCopyInto(out *ConfigMapRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapRef.
+func (in *ConfigMapRef) DeepCopy() *ConfigMapRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseDelta) DeepCopyInto(out *DatabaseDelta) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseDelta.
+func (in *DatabaseDelta) DeepCopy() *DatabaseDelta {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseDelta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunReport) DeepCopyInto(out *DryRunReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunReport.
+func (in *DryRunReport) DeepCopy() *DryRunReport {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DryRunReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunReportList) DeepCopyInto(out *DryRunReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DryRunReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunReportList.
+func (in *DryRunReportList) DeepCopy() *DryRunReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DryRunReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunReportSpec) DeepCopyInto(out *DryRunReportSpec) {
+	*out = *in
+	out.Executer = in.Executer
+	out.Source = in.Source
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]DatabaseDelta, len(*in))
+		copy(*out, *in)
+	}
+	out.Summary = in.Summary
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunReportSpec.
+func (in *DryRunReportSpec) DeepCopy() *DryRunReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutionConfiguration) DeepCopyInto(out *ExecutionConfiguration) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]KQLAssertion, len(*in))
+		copy(*out, *in)
+	}
+	if in.DatabaseTimeouts != nil {
+		in, out := &in.DatabaseTimeouts, &out.DatabaseTimeouts
+		*out = make(map[string]v1.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ArtifactRetention != nil {
+		in, out := &in.ArtifactRetention, &out.ArtifactRetention
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionConfiguration.
+func (in *ExecutionConfiguration) DeepCopy() *ExecutionConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutionConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalSchemaPolicy) DeepCopyInto(out *GlobalSchemaPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalSchemaPolicy.
+func (in *GlobalSchemaPolicy) DeepCopy() *GlobalSchemaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalSchemaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GlobalSchemaPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalSchemaPolicyList) DeepCopyInto(out *GlobalSchemaPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GlobalSchemaPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalSchemaPolicyList.
+func (in *GlobalSchemaPolicyList) DeepCopy() *GlobalSchemaPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalSchemaPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GlobalSchemaPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalSchemaPolicySpec) DeepCopyInto(out *GlobalSchemaPolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]SchemaRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalSchemaPolicySpec.
+func (in *GlobalSchemaPolicySpec) DeepCopy() *GlobalSchemaPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalSchemaPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KQLAssertion) DeepCopyInto(out *KQLAssertion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KQLAssertion.
+func (in *KQLAssertion) DeepCopy() *KQLAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(KQLAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedName) DeepCopyInto(out *NamespacedName) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedName.
+func (in *NamespacedName) DeepCopy() *NamespacedName {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedName)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStage) DeepCopyInto(out *PipelineStage) {
+	*out = *in
+	out.ConfigMapRef = in.ConfigMapRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStage.
+func (in *PipelineStage) DeepCopy() *PipelineStage {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStageStatus) DeepCopyInto(out *PipelineStageStatus) {
+	*out = *in
+	out.Deployment = in.Deployment
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStageStatus.
+func (in *PipelineStageStatus) DeepCopy() *PipelineStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBACChange) DeepCopyInto(out *RBACChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBACChange.
+func (in *RBACChange) DeepCopy() *RBACChange {
+	if in == nil {
+		return nil
+	}
+	out := new(RBACChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaComposition) DeepCopyInto(out *SchemaComposition) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaComposition.
+func (in *SchemaComposition) DeepCopy() *SchemaComposition {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaComposition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaComposition) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaCompositionList) DeepCopyInto(out *SchemaCompositionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaComposition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaCompositionList.
+func (in *SchemaCompositionList) DeepCopy() *SchemaCompositionList {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaCompositionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaCompositionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaCompositionSpec) DeepCopyInto(out *SchemaCompositionSpec) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]ConfigMapRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Order != nil {
+		in, out := &in.Order, &out.Order
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaCompositionSpec.
+func (in *SchemaCompositionSpec) DeepCopy() *SchemaCompositionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaCompositionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaCompositionStatus) DeepCopyInto(out *SchemaCompositionStatus) {
+	*out = *in
+	out.MergedConfigMap = in.MergedConfigMap
+	if in.MergedSources != nil {
+		in, out := &in.MergedSources, &out.MergedSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaCompositionStatus.
+func (in *SchemaCompositionStatus) DeepCopy() *SchemaCompositionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaCompositionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaDeployment) DeepCopyInto(out *SchemaDeployment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeployment.
+func (in *SchemaDeployment) DeepCopy() *SchemaDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaDeployment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaDeploymentList) DeepCopyInto(out *SchemaDeploymentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentList.
+func (in *SchemaDeploymentList) DeepCopy() *SchemaDeploymentList {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaDeploymentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaDeploymentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaDeploymentSpec) DeepCopyInto(out *SchemaDeploymentSpec) {
+	*out = *in
+	in.ApplyTo.DeepCopyInto(&out.ApplyTo)
+	out.Source = in.Source
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DatabaseRoles != nil {
+		in, out := &in.DatabaseRoles, &out.DatabaseRoles
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.VersionPin != nil {
+		in, out := &in.VersionPin, &out.VersionPin
+		*out = new(SchemaVersionRef)
+		**out = **in
+	}
+	if in.CredentialSecretRef != nil {
+		in, out := &in.CredentialSecretRef, &out.CredentialSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.EventHubConnectionStringRef != nil {
+		in, out := &in.EventHubConnectionStringRef, &out.EventHubConnectionStringRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BaselineConfigMapRef != nil {
+		in, out := &in.BaselineConfigMapRef, &out.BaselineConfigMapRef
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.ReconcileInterval != nil {
+		in, out := &in.ReconcileInterval, &out.ReconcileInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CrossClusterDependencies != nil {
+		in, out := &in.CrossClusterDependencies, &out.CrossClusterDependencies
+		*out = make([]ClusterDependency, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentSpec.
+func (in *SchemaDeploymentSpec) DeepCopy() *SchemaDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaDeploymentStatus) DeepCopyInto(out *SchemaDeploymentStatus) {
+	*out = *in
+	out.CurrentConfigMap = in.CurrentConfigMap
+	out.CurrentVerDeployment = in.CurrentVerDeployment
+	if in.OldVerDeployment != nil {
+		in, out := &in.OldVerDeployment, &out.OldVerDeployment
+		*out = make([]NamespacedName, len(*in))
+		copy(*out, *in)
+	}
+	if in.PinnedVersion != nil {
+		in, out := &in.PinnedVersion, &out.PinnedVersion
+		*out = new(SchemaVersionRef)
+		**out = **in
+	}
+	if in.ReconcileInterval != nil {
+		in, out := &in.ReconcileInterval, &out.ReconcileInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DependenciesWaitingSince != nil {
+		in, out := &in.DependenciesWaitingSince, &out.DependenciesWaitingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.DependencyVersions != nil {
+		in, out := &in.DependencyVersions, &out.DependencyVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AcceptedSource != nil {
+		in, out := &in.AcceptedSource, &out.AcceptedSource
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentStatus.
+func (in *SchemaDeploymentStatus) DeepCopy() *SchemaDeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaDeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaDiffSummary) DeepCopyInto(out *SchemaDiffSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDiffSummary.
+func (in *SchemaDiffSummary) DeepCopy() *SchemaDiffSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaDiffSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaExecutionResult) DeepCopyInto(out *SchemaExecutionResult) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaExecutionResult.
+func (in *SchemaExecutionResult) DeepCopy() *SchemaExecutionResult {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaExecutionResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaExecutionResult) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaExecutionResultList) DeepCopyInto(out *SchemaExecutionResultList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaExecutionResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaExecutionResultList.
+func (in *SchemaExecutionResultList) DeepCopy() *SchemaExecutionResultList {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaExecutionResultList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaExecutionResultList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaExecutionResultSpec) DeepCopyInto(out *SchemaExecutionResultSpec) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaExecutionResultSpec.
+func (in *SchemaExecutionResultSpec) DeepCopy() *SchemaExecutionResultSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaExecutionResultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaGroup) DeepCopyInto(out *SchemaGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaGroup.
+func (in *SchemaGroup) DeepCopy() *SchemaGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaGroupList) DeepCopyInto(out *SchemaGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaGroupList.
+func (in *SchemaGroupList) DeepCopy() *SchemaGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaGroupSpec) DeepCopyInto(out *SchemaGroupSpec) {
+	*out = *in
+	if in.GroupRBAC != nil {
+		in, out := &in.GroupRBAC, &out.GroupRBAC
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaGroupSpec.
+func (in *SchemaGroupSpec) DeepCopy() *SchemaGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaGroupStatus) DeepCopyInto(out *SchemaGroupStatus) {
+	*out = *in
+	if in.PlannedChanges != nil {
+		in, out := &in.PlannedChanges, &out.PlannedChanges
+		*out = make([]RBACChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaGroupStatus.
+func (in *SchemaGroupStatus) DeepCopy() *SchemaGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaHistory) DeepCopyInto(out *SchemaHistory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaHistory.
+func (in *SchemaHistory) DeepCopy() *SchemaHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaHistory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaHistoryEntry) DeepCopyInto(out *SchemaHistoryEntry) {
+	*out = *in
+	in.AppliedAt.DeepCopyInto(&out.AppliedAt)
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaHistoryEntry.
+func (in *SchemaHistoryEntry) DeepCopy() *SchemaHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaHistoryList) DeepCopyInto(out *SchemaHistoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaHistoryList.
+func (in *SchemaHistoryList) DeepCopy() *SchemaHistoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaHistoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaHistoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaHistorySpec) DeepCopyInto(out *SchemaHistorySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaHistorySpec.
+func (in *SchemaHistorySpec) DeepCopy() *SchemaHistorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaHistorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaHistoryStatus) DeepCopyInto(out *SchemaHistoryStatus) {
+	*out = *in
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]SchemaHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaHistoryStatus.
+func (in *SchemaHistoryStatus) DeepCopy() *SchemaHistoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaHistoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaOperatorConfig) DeepCopyInto(out *SchemaOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaOperatorConfig.
+func (in *SchemaOperatorConfig) DeepCopy() *SchemaOperatorConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterExecuter)
+	out := new(SchemaOperatorConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClusterExecuter) DeepCopyObject() runtime.Object {
+func (in *SchemaOperatorConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -43,31 +1274,31 @@ func (in *ClusterExecuter) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterExecuterList) DeepCopyInto(out *ClusterExecuterList) {
+func (in *SchemaOperatorConfigList) DeepCopyInto(out *SchemaOperatorConfigList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ClusterExecuter, len(*in))
+		*out = make([]SchemaOperatorConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterList.
-func (in *ClusterExecuterList) DeepCopy() *ClusterExecuterList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaOperatorConfigList.
+func (in *SchemaOperatorConfigList) DeepCopy() *SchemaOperatorConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterExecuterList)
+	out := new(SchemaOperatorConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClusterExecuterList) DeepCopyObject() runtime.Object {
+func (in *SchemaOperatorConfigList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -75,28 +1306,80 @@ func (in *ClusterExecuterList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterExecuterSpec) DeepCopyInto(out *ClusterExecuterSpec) {
+func (in *SchemaOperatorConfigSpec) DeepCopyInto(out *SchemaOperatorConfigSpec) {
 	*out = *in
-	in.ApplyTo.DeepCopyInto(&out.ApplyTo)
-	out.ConfigMapName = in.ConfigMapName
+	if in.CPUPerWorker != nil {
+		in, out := &in.CPUPerWorker, &out.CPUPerWorker
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MemPerWorker != nil {
+		in, out := &in.MemPerWorker, &out.MemPerWorker
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ExecutionTimeout != nil {
+		in, out := &in.ExecutionTimeout, &out.ExecutionTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WebhookTimeout != nil {
+		in, out := &in.WebhookTimeout, &out.WebhookTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.KustoPingTimeout != nil {
+		in, out := &in.KustoPingTimeout, &out.KustoPingTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RegistryRequestTimeout != nil {
+		in, out := &in.RegistryRequestTimeout, &out.RegistryRequestTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ComplianceReportMaxAge != nil {
+		in, out := &in.ComplianceReportMaxAge, &out.ComplianceReportMaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DryRunReportTTL != nil {
+		in, out := &in.DryRunReportTTL, &out.DryRunReportTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxResultAge != nil {
+		in, out := &in.MaxResultAge, &out.MaxResultAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NamespaceLabelSelector != nil {
+		in, out := &in.NamespaceLabelSelector, &out.NamespaceLabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequiredLabels != nil {
+		in, out := &in.RequiredLabels, &out.RequiredLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterSpec.
-func (in *ClusterExecuterSpec) DeepCopy() *ClusterExecuterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaOperatorConfigSpec.
+func (in *SchemaOperatorConfigSpec) DeepCopy() *SchemaOperatorConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterExecuterSpec)
+	out := new(SchemaOperatorConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterExecuterStatus) DeepCopyInto(out *ClusterExecuterStatus) {
+func (in *SchemaOperatorConfigStatus) DeepCopyInto(out *SchemaOperatorConfigStatus) {
 	*out = *in
-	in.Targets.DeepCopyInto(&out.Targets)
-	in.DoneTargets.DeepCopyInto(&out.DoneTargets)
-	in.Config.DeepCopyInto(&out.Config)
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -106,80 +1389,124 @@ func (in *ClusterExecuterStatus) DeepCopyInto(out *ClusterExecuterStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExecuterStatus.
-func (in *ClusterExecuterStatus) DeepCopy() *ClusterExecuterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaOperatorConfigStatus.
+func (in *SchemaOperatorConfigStatus) DeepCopy() *SchemaOperatorConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterExecuterStatus)
+	out := new(SchemaOperatorConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterTargets) DeepCopyInto(out *ClusterTargets) {
+func (in *SchemaPipelineChain) DeepCopyInto(out *SchemaPipelineChain) {
 	*out = *in
-	if in.DBs != nil {
-		in, out := &in.DBs, &out.DBs
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineChain.
+func (in *SchemaPipelineChain) DeepCopy() *SchemaPipelineChain {
+	if in == nil {
+		return nil
 	}
-	if in.Schemas != nil {
-		in, out := &in.Schemas, &out.Schemas
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out := new(SchemaPipelineChain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaPipelineChain) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaPipelineChainList) DeepCopyInto(out *SchemaPipelineChainList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SchemaPipelineChain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTargets.
-func (in *ClusterTargets) DeepCopy() *ClusterTargets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineChainList.
+func (in *SchemaPipelineChainList) DeepCopy() *SchemaPipelineChainList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterTargets)
+	out := new(SchemaPipelineChainList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SchemaPipelineChainList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExecutionConfiguration) DeepCopyInto(out *ExecutionConfiguration) {
+func (in *SchemaPipelineChainSpec) DeepCopyInto(out *SchemaPipelineChainSpec) {
 	*out = *in
-	if in.Properties != nil {
-		in, out := &in.Properties, &out.Properties
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]PipelineStage, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionConfiguration.
-func (in *ExecutionConfiguration) DeepCopy() *ExecutionConfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineChainSpec.
+func (in *SchemaPipelineChainSpec) DeepCopy() *SchemaPipelineChainSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ExecutionConfiguration)
+	out := new(SchemaPipelineChainSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamespacedName) DeepCopyInto(out *NamespacedName) {
+func (in *SchemaPipelineChainStatus) DeepCopyInto(out *SchemaPipelineChainStatus) {
 	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]PipelineStageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedName.
-func (in *NamespacedName) DeepCopy() *NamespacedName {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineChainStatus.
+func (in *SchemaPipelineChainStatus) DeepCopy() *SchemaPipelineChainStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NamespacedName)
+	out := new(SchemaPipelineChainStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SchemaDeployment) DeepCopyInto(out *SchemaDeployment) {
+func (in *SchemaPipelineStage) DeepCopyInto(out *SchemaPipelineStage) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -187,18 +1514,18 @@ func (in *SchemaDeployment) DeepCopyInto(out *SchemaDeployment) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeployment.
-func (in *SchemaDeployment) DeepCopy() *SchemaDeployment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineStage.
+func (in *SchemaPipelineStage) DeepCopy() *SchemaPipelineStage {
 	if in == nil {
 		return nil
 	}
-	out := new(SchemaDeployment)
+	out := new(SchemaPipelineStage)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SchemaDeployment) DeepCopyObject() runtime.Object {
+func (in *SchemaPipelineStage) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -206,31 +1533,31 @@ func (in *SchemaDeployment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SchemaDeploymentList) DeepCopyInto(out *SchemaDeploymentList) {
+func (in *SchemaPipelineStageList) DeepCopyInto(out *SchemaPipelineStageList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]SchemaDeployment, len(*in))
+		*out = make([]SchemaPipelineStage, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentList.
-func (in *SchemaDeploymentList) DeepCopy() *SchemaDeploymentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineStageList.
+func (in *SchemaPipelineStageList) DeepCopy() *SchemaPipelineStageList {
 	if in == nil {
 		return nil
 	}
-	out := new(SchemaDeploymentList)
+	out := new(SchemaPipelineStageList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SchemaDeploymentList) DeepCopyObject() runtime.Object {
+func (in *SchemaPipelineStageList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -238,32 +1565,30 @@ func (in *SchemaDeploymentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SchemaDeploymentSpec) DeepCopyInto(out *SchemaDeploymentSpec) {
+func (in *SchemaPipelineStageSpec) DeepCopyInto(out *SchemaPipelineStageSpec) {
 	*out = *in
-	in.ApplyTo.DeepCopyInto(&out.ApplyTo)
-	out.Source = in.Source
+	if in.PreviousStage != nil {
+		in, out := &in.PreviousStage, &out.PreviousStage
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	out.SchemaRef = in.SchemaRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentSpec.
-func (in *SchemaDeploymentSpec) DeepCopy() *SchemaDeploymentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineStageSpec.
+func (in *SchemaPipelineStageSpec) DeepCopy() *SchemaPipelineStageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SchemaDeploymentSpec)
+	out := new(SchemaPipelineStageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SchemaDeploymentStatus) DeepCopyInto(out *SchemaDeploymentStatus) {
+func (in *SchemaPipelineStageStatus) DeepCopyInto(out *SchemaPipelineStageStatus) {
 	*out = *in
-	out.CurrentConfigMap = in.CurrentConfigMap
-	out.CurrentVerDeployment = in.CurrentVerDeployment
-	if in.OldVerDeployment != nil {
-		in, out := &in.OldVerDeployment, &out.OldVerDeployment
-		*out = make([]NamespacedName, len(*in))
-		copy(*out, *in)
-	}
+	out.Deployment = in.Deployment
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -273,12 +1598,42 @@ func (in *SchemaDeploymentStatus) DeepCopyInto(out *SchemaDeploymentStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaDeploymentStatus.
-func (in *SchemaDeploymentStatus) DeepCopy() *SchemaDeploymentStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaPipelineStageStatus.
+func (in *SchemaPipelineStageStatus) DeepCopy() *SchemaPipelineStageStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(SchemaDeploymentStatus)
+	out := new(SchemaPipelineStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaRule) DeepCopyInto(out *SchemaRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaRule.
+func (in *SchemaRule) DeepCopy() *SchemaRule {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaVersionRef) DeepCopyInto(out *SchemaVersionRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaVersionRef.
+func (in *SchemaVersionRef) DeepCopy() *SchemaVersionRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaVersionRef)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -291,11 +1646,25 @@ func (in *TargetFilter) DeepCopyInto(out *TargetFilter) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.WebhookHeaders != nil {
+		in, out := &in.WebhookHeaders, &out.WebhookHeaders
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.DBS != nil {
 		in, out := &in.DBS, &out.DBS
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DatabaseTimeouts != nil {
+		in, out := &in.DatabaseTimeouts, &out.DatabaseTimeouts
+		*out = make(map[string]v1.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetFilter.
@@ -372,6 +1741,37 @@ func (in *VersionedDeplymentSpec) DeepCopyInto(out *VersionedDeplymentSpec) {
 	*out = *in
 	out.ConfigMapName = in.ConfigMapName
 	in.ApplyTo.DeepCopyInto(&out.ApplyTo)
+	if in.DatabaseRoles != nil {
+		in, out := &in.DatabaseRoles, &out.DatabaseRoles
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialSecretRef != nil {
+		in, out := &in.CredentialSecretRef, &out.CredentialSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.EventHubConnectionStringRef != nil {
+		in, out := &in.EventHubConnectionStringRef, &out.EventHubConnectionStringRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionedDeplymentSpec.
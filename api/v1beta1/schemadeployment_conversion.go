@@ -0,0 +1,166 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ conversion.Convertible = &SchemaDeployment{}
+
+// ConvertTo converts the schema deployment to the stored v1alpha1 version.
+func (src *SchemaDeployment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.SchemaDeployment)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = v1alpha1.SchemaDeploymentSpec{
+		ApplyTo: v1alpha1.TargetFilter{
+			ClusterUris:         src.Spec.ApplyTo.ClusterURIs,
+			Schema:              src.Spec.ApplyTo.Schema,
+			DB:                  src.Spec.ApplyTo.DatabaseNamePattern,
+			Webhook:             src.Spec.ApplyTo.Webhook,
+			WebhookHeaders:      src.Spec.ApplyTo.WebhookHeaders,
+			FilterExpression:    src.Spec.ApplyTo.FilterExpression,
+			Label:               src.Spec.ApplyTo.Label,
+			DBS:                 src.Spec.ApplyTo.Databases,
+			Create:              src.Spec.ApplyTo.Create,
+			Regexp:              src.Spec.ApplyTo.Regexp,
+			AutoCreateDatabases: src.Spec.ApplyTo.AutoCreateDatabases,
+			HotCacheRetention:   src.Spec.ApplyTo.HotCacheRetention,
+			SoftDeleteRetention: src.Spec.ApplyTo.SoftDeleteRetention,
+			SchemaURL:           src.Spec.ApplyTo.SchemaURL,
+			IncludeFollowers:    src.Spec.ApplyTo.IncludeFollowers,
+			DatabaseTimeouts:    src.Spec.ApplyTo.DatabaseTimeouts,
+		},
+		Type:                        v1alpha1.DBTypeEnum(src.Spec.Type),
+		Source:                      v1alpha1.NamespacedName(src.Spec.Source),
+		SecretRef:                   src.Spec.SecretRef,
+		FailurePolicy:               v1alpha1.FailurePolicyEnum(src.Spec.FailurePolicy),
+		FailIfDataLoss:              src.Spec.FailIfDataLoss,
+		DatabaseRoles:               src.Spec.DatabaseRoles,
+		VersionPin:                  (*v1alpha1.SchemaVersionRef)(src.Spec.VersionPin),
+		CooldownSeconds:             src.Spec.CooldownSeconds,
+		ObservationMode:             src.Spec.ObservationMode,
+		TenantID:                    src.Spec.TenantID,
+		CredentialSecretRef:         src.Spec.CredentialSecretRef,
+		EventHubConnectionStringRef: src.Spec.EventHubConnectionStringRef,
+		Priority:                    src.Spec.Priority,
+		DeletionPolicy:              v1alpha1.DeletionPolicyEnum(src.Spec.DeletionPolicy),
+		BaselineConfigMapRef:        (*v1alpha1.NamespacedName)(src.Spec.BaselineConfigMapRef),
+		ReconcileInterval:           src.Spec.ReconcileInterval,
+		ConflictResolution:          v1alpha1.ConflictStrategy(src.Spec.ConflictResolution),
+		ReconcilePolicy:             v1alpha1.ReconcilePolicyType(src.Spec.ReconcilePolicy),
+	}
+	if src.Spec.CrossClusterDependencies != nil {
+		dst.Spec.CrossClusterDependencies = make([]v1alpha1.ClusterDependency, len(src.Spec.CrossClusterDependencies))
+		for i, dependency := range src.Spec.CrossClusterDependencies {
+			dst.Spec.CrossClusterDependencies[i] = v1alpha1.ClusterDependency(dependency)
+		}
+	}
+
+	dst.Status = v1alpha1.SchemaDeploymentStatus{
+		Executed:                 src.Status.Executed,
+		DesiredNumberScheduled:   src.Status.DesiredNumberScheduled,
+		CurrentConfigMap:         v1alpha1.NamespacedName(src.Status.CurrentConfigMap),
+		LastConfigMap:            src.Status.LastConfigMap,
+		CurrentRevision:          src.Status.CurrentRevision,
+		LastSuccessfulRevision:   src.Status.LastSuccessfulRevision,
+		CurrentVerDeployment:     v1alpha1.NamespacedName(src.Status.CurrentVerDeployment),
+		PinnedVersion:            (*v1alpha1.SchemaVersionRef)(src.Status.PinnedVersion),
+		ReconcileInterval:        src.Status.ReconcileInterval,
+		DependenciesWaitingSince: src.Status.DependenciesWaitingSince,
+		DependencyVersions:       src.Status.DependencyVersions,
+		AcceptedSource:           (*v1alpha1.NamespacedName)(src.Status.AcceptedSource),
+		SourceOwner:              src.Status.SourceOwner,
+		ObservedSourceVersion:    src.Status.ObservedSourceVersion,
+		LastReconcileTime:        src.Status.LastReconcileTime,
+		Conditions:               src.Status.Conditions,
+	}
+	if src.Status.OldVerDeployment != nil {
+		dst.Status.OldVerDeployment = make([]v1alpha1.NamespacedName, len(src.Status.OldVerDeployment))
+		for i, name := range src.Status.OldVerDeployment {
+			dst.Status.OldVerDeployment[i] = v1alpha1.NamespacedName(name)
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts the stored v1alpha1 schema deployment to this version.
+func (dst *SchemaDeployment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.SchemaDeployment)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = SchemaDeploymentSpec{
+		ApplyTo: TargetFilter{
+			ClusterURIs:         src.Spec.ApplyTo.ClusterUris,
+			Schema:              src.Spec.ApplyTo.Schema,
+			DatabaseNamePattern: src.Spec.ApplyTo.DB,
+			Webhook:             src.Spec.ApplyTo.Webhook,
+			WebhookHeaders:      src.Spec.ApplyTo.WebhookHeaders,
+			FilterExpression:    src.Spec.ApplyTo.FilterExpression,
+			Label:               src.Spec.ApplyTo.Label,
+			Databases:           src.Spec.ApplyTo.DBS,
+			Create:              src.Spec.ApplyTo.Create,
+			Regexp:              src.Spec.ApplyTo.Regexp,
+			AutoCreateDatabases: src.Spec.ApplyTo.AutoCreateDatabases,
+			HotCacheRetention:   src.Spec.ApplyTo.HotCacheRetention,
+			SoftDeleteRetention: src.Spec.ApplyTo.SoftDeleteRetention,
+			SchemaURL:           src.Spec.ApplyTo.SchemaURL,
+			IncludeFollowers:    src.Spec.ApplyTo.IncludeFollowers,
+			DatabaseTimeouts:    src.Spec.ApplyTo.DatabaseTimeouts,
+		},
+		Type:                        DBTypeEnum(src.Spec.Type),
+		Source:                      NamespacedName(src.Spec.Source),
+		SecretRef:                   src.Spec.SecretRef,
+		FailurePolicy:               FailurePolicyEnum(src.Spec.FailurePolicy),
+		FailIfDataLoss:              src.Spec.FailIfDataLoss,
+		DatabaseRoles:               src.Spec.DatabaseRoles,
+		VersionPin:                  (*SchemaVersionRef)(src.Spec.VersionPin),
+		CooldownSeconds:             src.Spec.CooldownSeconds,
+		ObservationMode:             src.Spec.ObservationMode,
+		TenantID:                    src.Spec.TenantID,
+		CredentialSecretRef:         src.Spec.CredentialSecretRef,
+		EventHubConnectionStringRef: src.Spec.EventHubConnectionStringRef,
+		Priority:                    src.Spec.Priority,
+		DeletionPolicy:              DeletionPolicyEnum(src.Spec.DeletionPolicy),
+		BaselineConfigMapRef:        (*NamespacedName)(src.Spec.BaselineConfigMapRef),
+		ReconcileInterval:           src.Spec.ReconcileInterval,
+		ConflictResolution:          ConflictStrategy(src.Spec.ConflictResolution),
+		ReconcilePolicy:             ReconcilePolicyType(src.Spec.ReconcilePolicy),
+	}
+	if src.Spec.CrossClusterDependencies != nil {
+		dst.Spec.CrossClusterDependencies = make([]ClusterDependency, len(src.Spec.CrossClusterDependencies))
+		for i, dependency := range src.Spec.CrossClusterDependencies {
+			dst.Spec.CrossClusterDependencies[i] = ClusterDependency(dependency)
+		}
+	}
+
+	dst.Status = SchemaDeploymentStatus{
+		Executed:                 src.Status.Executed,
+		DesiredNumberScheduled:   src.Status.DesiredNumberScheduled,
+		CurrentConfigMap:         NamespacedName(src.Status.CurrentConfigMap),
+		LastConfigMap:            src.Status.LastConfigMap,
+		CurrentRevision:          src.Status.CurrentRevision,
+		LastSuccessfulRevision:   src.Status.LastSuccessfulRevision,
+		CurrentVerDeployment:     NamespacedName(src.Status.CurrentVerDeployment),
+		PinnedVersion:            (*SchemaVersionRef)(src.Status.PinnedVersion),
+		ReconcileInterval:        src.Status.ReconcileInterval,
+		DependenciesWaitingSince: src.Status.DependenciesWaitingSince,
+		DependencyVersions:       src.Status.DependencyVersions,
+		AcceptedSource:           (*NamespacedName)(src.Status.AcceptedSource),
+		SourceOwner:              src.Status.SourceOwner,
+		ObservedSourceVersion:    src.Status.ObservedSourceVersion,
+		LastReconcileTime:        src.Status.LastReconcileTime,
+		Conditions:               src.Status.Conditions,
+	}
+	if src.Status.OldVerDeployment != nil {
+		dst.Status.OldVerDeployment = make([]NamespacedName, len(src.Status.OldVerDeployment))
+		for i, name := range src.Status.OldVerDeployment {
+			dst.Status.OldVerDeployment[i] = NamespacedName(name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package v1beta1_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	fuzz "github.com/google/gofuzz"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/api/v1beta1"
+)
+
+var _ = Describe("SchemaDeployment conversion", func() {
+	fuzzer := fuzz.New().NilChance(0.2).NumElements(0, 3)
+
+	It("should rename the target filter fields", func() {
+		src := &v1beta1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "default"},
+			Spec: v1beta1.SchemaDeploymentSpec{
+				ApplyTo: v1beta1.TargetFilter{
+					ClusterURIs:         []string{"https://orders.westeurope.kusto.windows.net"},
+					DatabaseNamePattern: "orders-.*",
+					Databases:           []string{"orders-eu"},
+					Regexp:              true,
+				},
+				Type: "kusto",
+			},
+		}
+		hub := &v1alpha1.SchemaDeployment{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Name).To(Equal("orders"))
+		Expect(hub.Spec.ApplyTo.ClusterUris).To(Equal(src.Spec.ApplyTo.ClusterURIs))
+		Expect(hub.Spec.ApplyTo.DB).To(Equal("orders-.*"))
+		Expect(hub.Spec.ApplyTo.DBS).To(Equal([]string{"orders-eu"}))
+		Expect(hub.Spec.ApplyTo.Regexp).To(BeTrue())
+		Expect(hub.Spec.Type).To(Equal(v1alpha1.DBTypeEnum("kusto")))
+	})
+	It("should round-trip v1alpha1 through v1beta1", func() {
+		for i := 0; i < 100; i++ {
+			original := &v1alpha1.SchemaDeployment{}
+			fuzzer.Fuzz(original)
+			original.TypeMeta = metav1.TypeMeta{}
+
+			converted := &v1beta1.SchemaDeployment{}
+			Expect(converted.ConvertFrom(original.DeepCopy())).To(Succeed())
+			restored := &v1alpha1.SchemaDeployment{}
+			Expect(converted.ConvertTo(restored)).To(Succeed())
+			Expect(restored).To(Equal(original))
+		}
+	})
+	It("should round-trip v1beta1 through v1alpha1", func() {
+		for i := 0; i < 100; i++ {
+			original := &v1beta1.SchemaDeployment{}
+			fuzzer.Fuzz(original)
+			original.TypeMeta = metav1.TypeMeta{}
+
+			hub := &v1alpha1.SchemaDeployment{}
+			Expect(original.DeepCopy().ConvertTo(hub)).To(Succeed())
+			restored := &v1beta1.SchemaDeployment{}
+			Expect(restored.ConvertFrom(hub)).To(Succeed())
+			Expect(restored).To(Equal(original))
+		}
+	})
+})
@@ -0,0 +1,220 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FailurePolicyEnum Enum for the different failure policies
+// +kubebuilder:validation:Enum=abort;ignore;rollback
+type FailurePolicyEnum string
+
+// DeletionPolicyEnum Enum for the actions run on the target databases when a schema deployment is deleted
+// +kubebuilder:validation:Enum=Retain;DeleteManagedObjects;RevertToBaseline
+type DeletionPolicyEnum string
+
+// ConflictStrategy Enum for how a schema deployment resolves concurrent updates of its schema source
+// +kubebuilder:validation:Enum=OwnerWins;LatestWins;Manual
+type ConflictStrategy string
+
+// ReconcilePolicyType Enum for when a schema deployment is reconciled
+// +kubebuilder:validation:Enum=OnChange;Periodic;Manual
+type ReconcilePolicyType string
+
+// DBTypeEnum Enum for the supported DB types
+// +kubebuilder:validation:Enum=sqlServer;kusto;eventhub
+type DBTypeEnum string
+
+// NamespacedName references an object by its namespace and name
+type NamespacedName struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// SchemaVersionRef references a specific version of a schema config map
+type SchemaVersionRef struct {
+	ConfigMapName string `json:"configMapName"`
+	// ConfigMapNamespace defaults to the namespace of the schema deployment.
+	// +kubebuilder:validation:Optional
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+	// ResourceVersion is the `metadata.resourceVersion` of the config map version to deploy.
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// ClusterDependency is a schema version that must be applied on another cluster before the schema deployment is executed
+type ClusterDependency struct {
+	ClusterURI    string `json:"clusterURI"`
+	SchemaVersion string `json:"schemaVersion"`
+	// Timeout is the time to wait for the dependency, zero waits without limit.
+	// +kubebuilder:validation:Optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TargetFilter contains target filter configuration
+type TargetFilter struct {
+	// ClusterURIs are the clusters, servers or Event Hubs namespaces the schema is applied to (`clusterUris` in v1alpha1).
+	// +kubebuilder:validation:MinItems:=1
+	ClusterURIs []string `json:"clusterURIs"`
+	Schema      string   `json:"schema,omitempty"`
+	// DatabaseNamePattern matches the target database names, a regular expression when `regexp` is set (`db` in v1alpha1).
+	DatabaseNamePattern string `json:"databaseNamePattern"`
+	// +kubebuilder:validation:Optional
+	Webhook string `json:"webhook,omitempty"`
+	// WebhookHeaders are added to every `Webhook` request, a `$(SECRET_NAME:KEY)` value is read from a secret.
+	// +kubebuilder:validation:Optional
+	WebhookHeaders map[string]string `json:"webhookHeaders,omitempty"`
+	// FilterExpression narrows the databases of `DatabaseNamePattern` with an expression over their properties (kusto only).
+	// +kubebuilder:validation:Optional
+	FilterExpression string `json:"filterExpression,omitempty"`
+	// +kubebuilder:validation:Optional
+	Label string `json:"label,omitempty"`
+	// Databases lists the target databases explicitly (`dbs` in v1alpha1).
+	// +kubebuilder:validation:Optional
+	Databases []string `json:"databases,omitempty"`
+	Create    bool     `json:"create,omitempty"`
+	Regexp    bool     `json:"regexp,omitempty"`
+	// AutoCreateDatabases creates listed databases that are missing from the cluster before execution.
+	// +kubebuilder:validation:Optional
+	AutoCreateDatabases bool `json:"autoCreateDatabases,omitempty"`
+	// HotCacheRetention is the caching policy applied to auto created databases (e.g. `30d`).
+	// +kubebuilder:validation:Optional
+	HotCacheRetention string `json:"hotCacheRetention,omitempty"`
+	// SoftDeleteRetention is the retention policy applied to auto created databases (e.g. `365d`).
+	// +kubebuilder:validation:Optional
+	SoftDeleteRetention string `json:"softDeleteRetention,omitempty"`
+	// SchemaURL is downloaded and used as the kql instead of the `ConfigMap` kql.
+	// +kubebuilder:validation:Optional
+	SchemaURL string `json:"schemaURL,omitempty"`
+	// IncludeFollowers keeps the read-only follower databases in the targets.
+	// +kubebuilder:validation:Optional
+	IncludeFollowers bool `json:"includeFollowers,omitempty"`
+	// DatabaseTimeouts maps a database to the time its execution may take (kusto only).
+	// +kubebuilder:validation:Optional
+	DatabaseTimeouts map[string]metav1.Duration `json:"databaseTimeouts,omitempty"`
+}
+
+// SchemaDeploymentSpec defines the desired state of SchemaDeployment
+type SchemaDeploymentSpec struct {
+	ApplyTo TargetFilter   `json:"applyTo"`
+	Type    DBTypeEnum     `json:"type"`
+	Source  NamespacedName `json:"source,omitempty"`
+	// SecretRef reads the kql from a key of a `Secret` in the namespace of the schema deployment instead of the `source` config map.
+	// +kubebuilder:validation:Optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=rollback
+	FailurePolicy FailurePolicyEnum `json:"failurePolicy"`
+	// +kubebuilder:default:=true
+	FailIfDataLoss bool `json:"failIfDataLoss"`
+	// DatabaseRoles maps a database role to the AAD principals assigned to it on every target database (kusto only).
+	// +kubebuilder:validation:Optional
+	DatabaseRoles map[string][]string `json:"databaseRoles,omitempty"`
+	// VersionPin deploys exactly the referenced config map version and ignores newer changes until removed.
+	// +kubebuilder:validation:Optional
+	VersionPin *SchemaVersionRef `json:"versionPin,omitempty"`
+	// CooldownSeconds is the time after a successful apply in which the cluster executers skip reconciles.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=60
+	CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+	// ObservationMode only computes the pending schema diff of the targets and never applies it.
+	// +kubebuilder:validation:Optional
+	ObservationMode bool `json:"observationMode,omitempty"`
+	// TenantID is the Azure tenant of the target clusters, empty for the tenant of the operator (kusto only).
+	// +kubebuilder:validation:Optional
+	TenantID string `json:"tenantID,omitempty"`
+	// CredentialSecretRef references a secret with the `clientId` and `clientSecret` of a service principal in the `tenantID`.
+	// +kubebuilder:validation:Optional
+	CredentialSecretRef *corev1.SecretReference `json:"credentialSecretRef,omitempty"`
+	// EventHubConnectionStringRef is the `Secret` key holding the connection string of the Event Hub schema changes are published to.
+	// +kubebuilder:validation:Optional
+	EventHubConnectionStringRef *corev1.SecretKeySelector `json:"eventHubConnectionStringRef,omitempty"`
+	// Priority orders the pending cluster executers, higher priorities are executed first.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	Priority int `json:"priority,omitempty"`
+	// DeletionPolicy is the action run on the target databases of the current revision when the schema deployment is deleted.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=Retain
+	DeletionPolicy DeletionPolicyEnum `json:"deletionPolicy,omitempty"`
+	// BaselineConfigMapRef is the schema config map applied by the `RevertToBaseline` deletion policy.
+	// +kubebuilder:validation:Optional
+	BaselineConfigMapRef *NamespacedName `json:"baselineConfigMapRef,omitempty"`
+	// ReconcileInterval is the period the schema deployment is reconciled at.
+	// +kubebuilder:validation:Optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+	// CrossClusterDependencies delay the execution of new revisions until the dependencies are applied on their clusters.
+	// +kubebuilder:validation:Optional
+	CrossClusterDependencies []ClusterDependency `json:"crossClusterDependencies,omitempty"`
+	// ConflictResolution decides which change of the schema source is applied when the schema deployment is updated concurrently.
+	// +kubebuilder:validation:Optional
+	ConflictResolution ConflictStrategy `json:"conflictResolution,omitempty"`
+	// ReconcilePolicy decides when the schema deployment is reconciled: `OnChange`, `Periodic` or `Manual`.
+	// +kubebuilder:validation:Optional
+	ReconcilePolicy ReconcilePolicyType `json:"reconcilePolicy,omitempty"`
+}
+
+// SchemaDeploymentStatus defines the observed state of SchemaDeployment
+type SchemaDeploymentStatus struct {
+	Executed               bool             `json:"executed"`
+	DesiredNumberScheduled int32            `json:"desiredNumberScheduled"`
+	CurrentConfigMap       NamespacedName   `json:"currentConfigMap"`
+	LastConfigMap          string           `json:"lastConfigMap"`
+	CurrentRevision        int32            `json:"currentRevision"`
+	LastSuccessfulRevision int32            `json:"lastSuccessfulRevision"`
+	CurrentVerDeployment   NamespacedName   `json:"currentVerDeployment"`
+	OldVerDeployment       []NamespacedName `json:"oldVerDeployment,omitempty"`
+	// PinnedVersion is the version pin the current revision was created from.
+	PinnedVersion *SchemaVersionRef `json:"pinnedVersion,omitempty"`
+	// ReconcileInterval is the `reconcileInterval` the schema deployment is requeued with.
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+	// DependenciesWaitingSince is the time the schema deployment started waiting for its `crossClusterDependencies`.
+	DependenciesWaitingSince *metav1.Time `json:"dependenciesWaitingSince,omitempty"`
+	// DependencyVersions are the `clusterURI=schemaVersion` of the dependencies waited on since `dependenciesWaitingSince`.
+	DependencyVersions []string `json:"dependencyVersions,omitempty"`
+	// AcceptedSource is the schema source accepted by the `conflictResolution`.
+	AcceptedSource *NamespacedName `json:"acceptedSource,omitempty"`
+	// SourceOwner is the field manager that set the accepted source.
+	SourceOwner string `json:"sourceOwner,omitempty"`
+	// ObservedSourceVersion is the version of the schema source the last reconcile completed with.
+	ObservedSourceVersion string `json:"observedSourceVersion,omitempty"`
+	// LastReconcileTime is the time the last reconcile completed, the revision was executed or failed.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+	// Conditions is an array of conditions.
+	// Known .status.conditions.type are: "Execution", "Invalid", "DependencyTimeout", "SourceConflict"
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SchemaDeployment is the v1beta1 version of the schema deployment, converted to the stored v1alpha1 version by the conversion webhook
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.type"
+//+kubebuilder:printcolumn:name="Executed",type="string",JSONPath=".status.conditions[?(@.type=='Execution')].status"
+type SchemaDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SchemaDeploymentSpec   `json:"spec,omitempty"`
+	Status SchemaDeploymentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SchemaDeploymentList contains a list of SchemaDeployment
+type SchemaDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SchemaDeployment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SchemaDeployment{}, &SchemaDeploymentList{})
+}
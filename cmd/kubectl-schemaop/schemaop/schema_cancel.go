@@ -0,0 +1,126 @@
+package schemaop
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var (
+	cancelLong = `
+		cancel the running executions of the current schema revision.
+		The revision is locked, remove its lock annotation to resume it.`
+
+	cancelExample = `
+		# cancel the running rollout of a schema
+		kubectl schemaop cancel master-test-template`
+)
+
+// SchemaCancelOptions holds the options for 'schema cancel' sub command
+type SchemaCancelOptions struct {
+	CommonOptions
+
+	Namespace string
+	Name      string
+
+	genericclioptions.IOStreams
+}
+
+// NewSchemaCancelOptions returns an initialized SchemaCancelOptions instance
+func NewSchemaCancelOptions(streams genericclioptions.IOStreams) *SchemaCancelOptions {
+	o := &SchemaCancelOptions{
+		IOStreams: streams,
+	}
+	o.SetConfigFlags()
+	return o
+}
+
+// NewCmdSchemaCancel returns a Command instance for cancel sub command
+func NewCmdSchemaCancel(streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewSchemaCancelOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:                   "cancel NAME [flags]",
+		DisableFlagsInUseLine: true,
+		Short:                 "cancel schema rollout",
+		Long:                  cancelLong,
+		Example:               cancelExample,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Namespace, "namespace", o.Namespace, "namespace of schema")
+	cmd.Flags().StringVar(&o.Name, "name", o.Name, "name of schema template")
+
+	return cmd
+}
+
+// Complete completes al the required options
+func (o *SchemaCancelOptions) Complete(cmd *cobra.Command, args []string) error {
+	var err error
+	o.Name, err = cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if o.Name == "" && len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	if err = o.Init(cmd); err != nil {
+		return err
+	}
+	o.Namespace = o.UserNamespace
+	return nil
+}
+
+// Validate makes sure all the provided values for command-line options are valid
+func (o *SchemaCancelOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("the schema template name is required")
+	}
+	return nil
+}
+
+// Run performs the execution of 'schema cancel' sub command
+func (o *SchemaCancelOptions) Run() error {
+	template := &schemav1alpha1.SchemaDeployment{}
+	key := types.NamespacedName{
+		Name:      o.Name,
+		Namespace: o.Namespace,
+	}
+	if err := o.Client.Get(context.TODO(), key, template); err != nil {
+		return fmt.Errorf("unable to get template: %w", err)
+	}
+	patch := client.MergeFrom(template.DeepCopy())
+	annotations := template.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[schemav1alpha1.CancelAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	template.SetAnnotations(annotations)
+	if err := o.Client.Patch(context.TODO(), template, patch); err != nil {
+		return fmt.Errorf("unable to cancel the template rollout: %w", err)
+	}
+	fmt.Fprintf(o.Out, "cancel requested for revision %d of %s\n", template.Status.CurrentRevision, o.Name)
+	return nil
+}
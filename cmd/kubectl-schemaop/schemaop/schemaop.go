@@ -41,6 +41,7 @@ func NewCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	// cmd.AddCommand(NewCmdRolloutResume(f, streams))
 	cmd.AddCommand(NewCmdSchemaUndo(streams))
 	cmd.AddCommand(NewCmdSchemaUpdate(streams))
+	cmd.AddCommand(NewCmdSchemaCancel(streams))
 	// cmd.AddCommand(NewCmdRolloutStatus(f, streams))
 	// cmd.AddCommand(NewCmdRolloutRestart(f, streams))
 
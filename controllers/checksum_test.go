@@ -0,0 +1,89 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("ClusterExecuterChecksum", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "checksum-0-cluster1", Namespace: "default"}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db2", "db1"}}
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "checksum-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	// configFor returns an execution configuration rendering the `kql`.
+	configFor := func(kql string) schemav1alpha1.ExecutionConfiguration {
+		file, err := os.CreateTemp(dir, "kql-*.kql")
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		_, err = file.WriteString(kql)
+		Expect(err).NotTo(HaveOccurred())
+		return schemav1alpha1.ExecutionConfiguration{KQLFile: file.Name()}
+	}
+
+	It("Should skip an unchanged kql", func() {
+		applied, err := appliedChecksum(configFor(".create table T (a:string)"), targets)
+		Expect(err).NotTo(HaveOccurred())
+		current, err := appliedChecksum(configFor(".create table T (a:string)"), schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current).To(Equal(applied))
+
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Status:     schemav1alpha1.ClusterExecuterStatus{AppliedChecksum: applied, Running: true},
+		}
+		reconciler := &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ChecksumTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		Expect(reconciler.skipUnchanged(ctx, executer, targets)).To(Succeed())
+		found := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, found)).To(Succeed())
+		Expect(found.Status.Executed).To(BeTrue())
+		Expect(found.Status.Running).To(BeFalse())
+		Expect(found.Status.DoneTargets).To(Equal(targets))
+	})
+	It("Should execute a changed kql", func() {
+		applied, err := appliedChecksum(configFor(".create table T (a:string)"), targets)
+		Expect(err).NotTo(HaveOccurred())
+		changed, err := appliedChecksum(configFor(".create table T (a:string, b:long)"), targets)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).NotTo(Equal(applied))
+	})
+	It("Should execute the same kql on new databases", func() {
+		applied, err := appliedChecksum(configFor(".create table T (a:string)"), targets)
+		Expect(err).NotTo(HaveOccurred())
+		more, err := appliedChecksum(configFor(".create table T (a:string)"), schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(more).NotTo(Equal(applied))
+	})
+	It("Should not checksum configurations without kql", func() {
+		checksum, err := appliedChecksum(schemav1alpha1.ExecutionConfiguration{DacPac: "schema.dacpac"}, targets)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checksum).To(BeEmpty())
+	})
+})
@@ -5,8 +5,10 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +25,10 @@ import (
 	"github.com/go-logr/logr"
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -46,6 +52,22 @@ var (
 	)
 )
 
+// defaultMaxFailures is the number of failed executions an executer retries, unless the max failures setting is set.
+const defaultMaxFailures = 3
+
+// clusterLockTimeout is how long an executer waits for another execution on the same cluster before requeueing.
+const clusterLockTimeout = 30 * time.Second
+
+// schemaLeaseTTL is how long the schema lease of an executer is held without renewal before another operator instance can take it over,
+// the lease is renewed while the execution runs.
+const schemaLeaseTTL = 10 * time.Minute
+
+// observationReason is the `Ready` condition reason of an executer in observation mode.
+const observationReason = "ObservationMode"
+
+// dryRunReason is the `Execution` condition reason of an executer whose dry run computed the schema diff without applying it.
+const dryRunReason = "DryRun"
+
 func init() {
 	// Register custom metrics with the global prometheus registry
 	metrics.Registry.MustRegister(clusterStatusGauge, clusterSuccessTime)
@@ -57,13 +79,32 @@ type ClusterExecuterReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// KustoClients creates the kusto clients, reusing them across reconciles (optional).
+	KustoClients kustoutils.ClientFactory
+	// APIReader reads directly from the api server, used to fetch the `secretRef` secrets (optional).
+	APIReader client.Reader
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+	// LeaseHolder identifies this operator instance in the schema leases, empty skips the leases (optional).
+	LeaseHolder string
+	// GlobalPolicies checks the `GlobalSchemaPolicy` rules before executing, requires the cluster operator scope.
+	GlobalPolicies bool
+	// Gate tracks the running executions for the graceful shutdown (optional).
+	Gate *ExecutionGate
+	// eventProducers returns the producer of the `eventHubConnectionStringRef`, defaults to the Event Hubs REST API.
+	eventProducers eventProducerFunc
 }
 
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=clusterexecuters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=clusterexecuters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=clusterexecuters/finalizers,verbs=update
-//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;create
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -75,10 +116,18 @@ type ClusterExecuterReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
 func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "ClusterExecuter/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
 	log := r.Log.WithValues("ClusterExecuter", req.NamespacedName)
+	if r.Gate.Closed() {
+		log.Info("operator is shutting down - not reconciling")
+		return ctrl.Result{Requeue: true}, nil
+	}
 
-	// TODO: change to parameter
-	maxFailures := 3
+	maxFailures := config.GetInt(config.MaxFailuresKey)
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFailures
+	}
 
 	executer := &schemav1alpha1.ClusterExecuter{}
 	err := r.Get(ctx, req.NamespacedName, executer)
@@ -95,6 +144,11 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
+	if remaining := cooldownRemaining(executer, time.Now()); remaining > 0 && !forceReconcile(executer) {
+		log.Info("executer applied recently - cooling down", "remaining", remaining)
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
 	if executer.Status.Running {
 		log.Info("executer already Running - wait patiently")
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
@@ -105,6 +159,11 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{Requeue: false}, fmt.Errorf("max retries exhosted")
 	}
 
+	if executer.Status.CanaryFailed && !forceReconcile(executer) {
+		log.Info("canary database failed - halted until forced to reconcile")
+		return ctrl.Result{}, nil
+	}
+
 	notifier := func(pct int) {
 		executer.Status.CompletedPCT = pct
 		err = r.Status().Update(ctx, executer)
@@ -113,39 +172,112 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
-	cluster := clusterUtils.NewCluster(executer.Spec.Type, executer.Spec.ClusterUri, r.Client, notifier)
-	targets, err := cluster.AquireTargets(executer.Spec.ApplyTo)
+	cluster, err := r.newCluster(ctx, executer, notifier)
+	if err != nil {
+		log.Error(err, "failed reading the tenant credentials", "request", req.String())
+		return ctrl.Result{}, err
+	}
+	return r.reconcileCluster(ctx, req, executer, cluster)
+}
+
+// reconcileCluster applies the schema of the executer to the target databases of its `cluster`,
+// recording an event at every phase of the execution.
+func (r *ClusterExecuterReconciler) reconcileCluster(ctx context.Context, req ctrl.Request, executer *schemav1alpha1.ClusterExecuter, cluster clusterUtils.Cluster) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClusterExecuter", req.NamespacedName)
+	backoff, err := r.checkReachable(ctx, cluster, executer, time.Now())
+	if err != nil {
+		log.Error(err, "failed updating the cluster reachability", "request", req.String())
+		return ctrl.Result{}, err
+	}
+	if backoff > 0 {
+		return ctrl.Result{RequeueAfter: backoff}, nil
+	}
+	filter := executer.Spec.ApplyTo
+	if executer.Spec.ObservationMode {
+		// observation is read-only - the listed databases are never created.
+		filter.AutoCreateDatabases = false
+	}
+	if len(filter.WebhookHeaders) > 0 {
+		filter.WebhookHeaders, err = r.webhookHeaders(ctx, executer)
+		if err != nil {
+			log.Error(err, "failed reading the webhook headers", "request", req.String())
+			return ctrl.Result{}, err
+		}
+	}
+	targets, err := cluster.AquireTargets(filter)
 	if err != nil {
 		log.Error(err, "failed retriving targets from cluster", "request", req.String())
 		return ctrl.Result{}, err
 	}
+	RecordEvent(r.recorder, executer, EventAcquiringTargets, fmt.Sprintf("acquired %d target databases on cluster %s", len(targets.DBs), executer.Spec.ClusterUri), false)
+
+	if executer.Spec.ObservationMode {
+		return ctrl.Result{}, r.observe(ctx, cluster, executer, targets)
+	}
+	cond := meta.FindStatusCondition(executer.Status.Conditions, schemav1alpha1.ConditionExecution)
+	if cond != nil && cond.Reason == dryRunReason && cond.ObservedGeneration == executer.Generation &&
+		reflect.DeepEqual(targets, executer.Status.Targets) && !forceReconcile(executer) {
+		log.Info("dry run already done on the targets - returning")
+		return ctrl.Result{}, nil
+	}
 
 	if executer.Status.Executed {
 		log.Info("executer already done - comparing db list")
-		if reflect.DeepEqual(targets, executer.Status.Targets) {
+		unchanged := reflect.DeepEqual(targets, executer.Status.Targets)
+		// the databases skipped by the last execution are retried until they pass the pre-condition.
+		if unchanged && len(executer.Status.SkippedTargets.DBs) == 0 {
 			log.Info("targets already executed - returning")
-			return ctrl.Result{}, nil
+			if err := r.clearForceReconcile(ctx, executer); err != nil {
+				log.Error(err, "failed clearing the force reconcile annotation", "request", req.String())
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, r.syncDatabaseRoles(ctx, cluster, executer, targets)
 		}
-		log.Info("targets changed - re-running")
-		executer.Status.Targets = targets
-		executer.Status.Running = true
-		executer.Status.Executed = false
-		executer.Status.Failed = false
-		err = r.Status().Update(ctx, executer)
-		if err != nil {
-			log.Error(err, "failed updating executer status due to db list chahnge", "request", req.String())
-			return ctrl.Result{}, err
+		if !unchanged {
+			log.Info("targets changed - re-running")
+			RecordEvent(r.recorder, executer, EventDriftDetected, fmt.Sprintf("targets of cluster %s changed from %d to %d databases - re-running", executer.Spec.ClusterUri, len(executer.Status.Targets.DBs), len(targets.DBs)), false)
+			executer.Status.Targets = targets
+			executer.Status.Running = true
+			executer.Status.Executed = false
+			executer.Status.Failed = false
+			err = r.Status().Update(ctx, executer)
+			if err != nil {
+				log.Error(err, "failed updating executer status due to db list chahnge", "request", req.String())
+				return ctrl.Result{}, err
+			}
 		}
 	}
 
 	log.Info("Executer Controller: getting cfgMap")
-	cfgMap := &v1.ConfigMap{}
-	err = r.Get(ctx, types.NamespacedName(executer.Spec.ConfigMapName), cfgMap)
+	cfgMap, err := r.schemaConfigMap(ctx, executer)
 	if err != nil {
 		// r.Telemetry.LogInfoByInstance("ignorable error", "error during fetch from api server", req.String())
 		return ctrl.Result{}, err
 	}
 
+	// serialize executions on the same cluster - other executers wait until the lock is released.
+	lockCtx, cancel := context.WithTimeout(ctx, clusterLockTimeout)
+	defer cancel()
+	unlock, err := clusterUtils.AcquireClusterLock(executer.Spec.ClusterUri, lockCtx)
+	if err != nil {
+		log.Info("cluster is locked by another execution - requeue", "cluster", executer.Spec.ClusterUri)
+		return ctrl.Result{RequeueAfter: clusterLockTimeout}, nil
+	}
+	defer unlock()
+
+	// the cluster lock is per process - the lease keeps other operator instances from running the schema concurrently.
+	if r.LeaseHolder != "" {
+		release, err := clusterUtils.AcquireSchemaLease(ctx, r.Client, req.NamespacedName, r.LeaseHolder, schemaLeaseTTL)
+		if err != nil {
+			if _, held := err.(clusterUtils.ErrLeaseHeld); held {
+				log.Info("schema lease is held by another operator instance - requeue", "error", err.Error())
+				return ctrl.Result{RequeueAfter: schemaLeaseTTL / 2}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		defer release()
+	}
+
 	// Filter out targers already executed
 	targetsToRun := clusterUtils.Difference(targets, executer.Status.DoneTargets)
 	execConfiguration, err := cluster.CreateExecConfiguration(targetsToRun, cfgMap, executer.Spec.FailIfDataLoss)
@@ -153,9 +285,46 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		log.Error(err, "failed creating delta-kusto configuration", "request", req.String())
 		return ctrl.Result{}, err
 	}
+	execConfiguration.DatabaseTimeouts = executer.Spec.ApplyTo.DatabaseTimeouts
+	checksum, err := appliedChecksum(execConfiguration, targets)
+	if err != nil {
+		log.Error(err, "failed computing the kql checksum", "request", req.String())
+		return ctrl.Result{}, err
+	}
+	if checksum != "" && checksum == executer.Status.AppliedChecksum && !forceReconcile(executer) {
+		log.Info("kql already applied to the targets - skipping", "checksum", checksum)
+		return ctrl.Result{}, r.skipUnchanged(ctx, executer, targets)
+	}
+	blocked, err := r.checkGlobalPolicies(ctx, cluster, executer, targetsToRun, execConfiguration)
+	if err != nil {
+		log.Error(err, "failed checking the global schema policies", "request", req.String())
+		return ctrl.Result{}, err
+	}
+	if blocked {
+		return ctrl.Result{RequeueAfter: policyRecheckInterval}, nil
+	}
+	r.limitParallelism(ctx, executer, &execConfiguration, len(targetsToRun.DBs))
+	if !r.Gate.Enter() {
+		log.Info("operator is shutting down - not starting the execution")
+		return ctrl.Result{Requeue: true}, nil
+	}
+	defer r.Gate.Exit()
+	err = kustoutils.StoreJobArtifact(ctx, r.Client, execConfiguration, executer.Spec.ClusterUri, targetsToRun.DBs, executer.Namespace, time.Now())
+	if err != nil {
+		log.Error(err, "failed storing the job artifact", "request", req.String())
+		return ctrl.Result{}, err
+	}
 	// log.Info("Config file generated: ", "file-name", deltaCfgFile)
 	executer.Status.Targets = targets
+	executer.Status.ClusterName = clusterUtils.ClusterNameFromURI(executer.Spec.ClusterUri)
 	executer.Status.Running = true
+	executer.Status.CanaryFailed = false
+	executer.Status.LastAppliedDiff = nil
+	meta.RemoveStatusCondition(&executer.Status.Conditions, schemav1alpha1.ConditionInterrupted)
+	progressExecuter, reportsProgress := cluster.(clusterUtils.ProgressExecuter)
+	if reportsProgress {
+		setPendingDatabases(executer, targetsToRun)
+	}
 	executer.Status.Config = execConfiguration
 	err = r.Status().Update(ctx, executer)
 	if err != nil {
@@ -163,19 +332,44 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
-	// your logic here
-	r.recorder.Event(executer, v1.EventTypeNormal, "Started", "cluster executer started")
+	RecordEvent(r.recorder, executer, EventExecutingSchema, fmt.Sprintf("executing the schema on %d databases of cluster %s", len(targetsToRun.DBs), executer.Spec.ClusterUri), false)
 	// log.Info("running : ", "file-name", deltaCfgFile)
-	_, err = cluster.Execute(targetsToRun, execConfiguration)
+	var results schemav1alpha1.ClusterTargets
+	if reportsProgress {
+		results, err = progressExecuter.ExecuteWithProgress(ctx, targetsToRun, execConfiguration, r.databaseProgressNotifier(ctx, executer))
+	} else {
+		results, err = cluster.Execute(targetsToRun, execConfiguration)
+	}
+	executer.Status.SkippedTargets = skippedTargets(results)
+	applied := clusterUtils.Difference(targetsToRun, executer.Status.SkippedTargets)
+	r.Telemetry.ObserveExecution(clusterUtils.ClusterNameFromURI(executer.Spec.ClusterUri), err)
+	if recordErr := r.recordExecutionResult(ctx, cluster, executer, execConfiguration, targetsToRun, checksum); recordErr != nil {
+		log.Error(recordErr, "failed recording the execution result", "request", req.String())
+	}
 
+	if err != nil && r.Gate.Closed() {
+		log.Info("execution interrupted by the shutdown", "error", err.Error())
+		return ctrl.Result{}, markInterrupted(ctx, r.Client, executer)
+	}
 	if err != nil {
 		log.Error(err, "failed executing the schema on the cluster")
 		clusterStatusGauge.WithLabelValues(clusterUtils.ClusterNameFromURI(executer.Spec.ClusterUri), strconv.Itoa(int(executer.Spec.Revision))).Set(0)
-		r.recorder.Eventf(executer, v1.EventTypeWarning, "Failed", "failed to execute cluster: %s ", executer.Spec.ClusterUri)
+		RecordEvent(r.recorder, executer, EventSchemaFailed, fmt.Sprintf("failed executing the schema on %d databases of cluster %s: %s", len(targetsToRun.DBs), executer.Spec.ClusterUri, err.Error()), true)
+		verifyErr := kustoutils.ErrVerificationFailed{}
+		if errors.As(err, &verifyErr) && verifyErr.RolledBack {
+			RecordEvent(r.recorder, executer, EventRollbackTriggered, fmt.Sprintf("rolled back %d databases of cluster %s failing the verification", len(verifyErr.DBs), executer.Spec.ClusterUri), true)
+		}
+		reason := "Failed"
+		canaryErr := kustoutils.ErrCanaryFailed{}
+		if errors.As(err, &canaryErr) {
+			reason = "CanaryFailed"
+			executer.Status.CanaryFailed = true
+			RecordEvent(r.recorder, executer, EventCanaryFailed, fmt.Sprintf("canary database %s of cluster %s failed - the other databases were not touched", canaryErr.DB, executer.Spec.ClusterUri), true)
+		}
 		meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
 			Type:    schemav1alpha1.ConditionExecution,
 			Status:  metav1.ConditionFalse,
-			Reason:  "Failed",
+			Reason:  reason,
 			Message: err.Error(),
 		})
 		executer.Status.Executed = false
@@ -189,9 +383,16 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 		return ctrl.Result{}, err
 	}
+	if execConfiguration.DryRunOutputDir != "" {
+		// nothing was applied - the executer is neither executed nor done with the targets.
+		return ctrl.Result{}, r.completeDryRun(ctx, cluster, executer, execConfiguration)
+	}
 	clusterStatusGauge.WithLabelValues(clusterUtils.ClusterNameFromURI(executer.Spec.ClusterUri), strconv.Itoa(int(executer.Spec.Revision))).Set(1)
 	clusterSuccessTime.WithLabelValues(clusterUtils.ClusterNameFromURI(executer.Spec.ClusterUri), strconv.Itoa(int(executer.Spec.Revision))).SetToCurrentTime()
-	r.recorder.Event(executer, v1.EventTypeNormal, "Executed", "cluster executer finished")
+	RecordEvent(r.recorder, executer, EventSchemaApplied, fmt.Sprintf("schema applied to %d databases of cluster %s", len(applied.DBs), executer.Spec.ClusterUri), false)
+	if len(executer.Status.SkippedTargets.DBs) > 0 {
+		RecordEvent(r.recorder, executer, EventDatabasesSkipped, fmt.Sprintf("skipped %d databases of cluster %s failing the pre-condition: %s", len(executer.Status.SkippedTargets.DBs), executer.Spec.ClusterUri, strings.Join(executer.Status.SkippedTargets.DBs, ", ")), false)
+	}
 	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
 		Type:   schemav1alpha1.ConditionExecution,
 		Status: metav1.ConditionTrue,
@@ -199,21 +400,324 @@ func (r *ClusterExecuterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	})
 	executer.Status.Running = false
 	executer.Status.Executed = true
-	executer.Status.DoneTargets = executer.Status.Targets
+	// the skipped databases are retried by the next execution, the checksum only counts once they are applied.
+	executer.Status.DoneTargets = clusterUtils.Difference(executer.Status.Targets, executer.Status.SkippedTargets)
+	executer.Status.AppliedChecksum = checksum
+	if len(executer.Status.SkippedTargets.DBs) > 0 {
+		executer.Status.AppliedChecksum = ""
+	}
+	executer.Status.ShortChecksum = shortChecksum(checksum)
+	executer.Status.LastAppliedAt = &metav1.Time{Time: time.Now()}
+	executer.Status.PendingDiff = nil
+	executer.Status.LastAppliedDiff = r.appliedDiff(cluster, execConfiguration)
+	meta.RemoveStatusCondition(&executer.Status.Conditions, schemav1alpha1.ConditionReady)
 
 	err = r.Status().Update(ctx, executer)
 	if err != nil {
 		log.Error(err, "failed updating executer status", "request", req.String())
 		return ctrl.Result{}, err
 	}
+	err = r.markApplied(ctx, executer, time.Now())
+	if err != nil {
+		log.Error(err, "failed annotating the executer apply time", "request", req.String())
+		return ctrl.Result{}, err
+	}
+
+	// the execution already succeeded - a failed publish is reported without failing the reconcile.
+	err = r.publishSchemaChange(ctx, executer, applied.DBs, checksum)
+	if err != nil {
+		log.Error(err, "failed publishing the schema change", "request", req.String())
+		RecordEvent(r.recorder, executer, EventPublishFailed, fmt.Sprintf("failed publishing the schema change of cluster %s: %s", executer.Spec.ClusterUri, err.Error()), true)
+	}
+	// the execution already succeeded - a missing report is reported without failing the reconcile.
+	err = r.reportCompliance(ctx, cluster, executer, targetsToRun, execConfiguration.Assertions, time.Now())
+	if err != nil {
+		log.Error(err, "failed writing the compliance report", "request", req.String())
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "ComplianceReportFailed", "failed writing the compliance report: %s", err.Error())
+	}
+	return ctrl.Result{}, r.syncDatabaseRoles(ctx, cluster, executer, executer.Status.Targets)
+}
 
-	return ctrl.Result{}, nil
+// completeDryRun exports and reports the schema diff computed by a dry run, and marks the dry run done on the targets
+// without updating the applied status of the executer.
+func (r *ClusterExecuterReconciler) completeDryRun(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, config schemav1alpha1.ExecutionConfiguration) error {
+	if config.DryRunOutputConfigMap != "" {
+		if err := r.exportSchemaDiff(ctx, cluster, executer, config); err != nil {
+			return err
+		}
+	}
+	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+		Type:               schemav1alpha1.ConditionExecution,
+		Status:             metav1.ConditionFalse,
+		Reason:             dryRunReason,
+		Message:            "the schema diff was computed without applying it",
+		ObservedGeneration: executer.Generation,
+	})
+	executer.Status.Running = false
+	executer.Status.Failed = false
+	if err := r.Status().Update(ctx, executer); err != nil {
+		r.Log.Error(err, "failed updating executer status", "executer", executer.Name)
+		return err
+	}
+	if err := r.clearForceReconcile(ctx, executer); err != nil {
+		return err
+	}
+	r.recorder.Eventf(executer, v1.EventTypeNormal, "DryRunCompleted", "schema diff computed on %d databases of cluster %s", len(executer.Status.Targets.DBs), executer.Spec.ClusterUri)
+	// the dry run already succeeded - a missing report is reported without failing the reconcile.
+	if err := r.reportDryRun(ctx, cluster, executer, config, time.Now()); err != nil {
+		r.Log.Error(err, "failed writing the dry run report", "executer", executer.Name)
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "DryRunReportFailed", "failed writing the dry run report: %s", err.Error())
+	}
+	return nil
+}
+
+// appliedDiff returns the summary of the delta the execution described by `config` applied,
+// nil when the cluster doesn't record it or it can't be read.
+func (r *ClusterExecuterReconciler) appliedDiff(cluster clusterUtils.Cluster, config schemav1alpha1.ExecutionConfiguration) *schemav1alpha1.SchemaDiffSummary {
+	reader, ok := cluster.(clusterUtils.AppliedDiffReader)
+	if !ok {
+		return nil
+	}
+	summary, err := reader.AppliedDiffSummary(config)
+	if err != nil {
+		r.Log.Error(err, "failed reading the applied delta", "job", config.JobID)
+		return nil
+	}
+	return summary
+}
+
+// appliedChecksum returns the checksum of the kql rendered by the `config` on the `targets` databases,
+// or an empty checksum for configurations without a kql file.
+func appliedChecksum(config schemav1alpha1.ExecutionConfiguration, targets schemav1alpha1.ClusterTargets) (string, error) {
+	if config.KQLFile == "" {
+		return "", nil
+	}
+	kql, err := kustoutils.RenderedKQL(config)
+	if err != nil {
+		return "", err
+	}
+	dbs := append([]string{}, targets.DBs...)
+	sort.Strings(dbs)
+	// the databases are part of the checksum, so the same kql applied to new databases is not skipped.
+	return kustoutils.ComputeKQLChecksum(kql + "\n" + strings.Join(dbs, "\n")), nil
+}
+
+// skipUnchanged marks the executer done on the `targets` without executing, the kql was already applied to them.
+func (r *ClusterExecuterReconciler) skipUnchanged(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets) error {
+	r.recorder.Event(executer, v1.EventTypeNormal, "Unchanged", "kql already applied - execution skipped")
+	executer.Status.Targets = targets
+	executer.Status.DoneTargets = targets
+	executer.Status.Running = false
+	executer.Status.Executed = true
+	executer.Status.Failed = false
+	return r.Status().Update(ctx, executer)
+}
+
+// schemaConfigMap fetches the schema `ConfigMap` of the executer, with the kql of its `SecretRef`
+// and pointing it to the `SchemaURL` when set.
+func (r *ClusterExecuterReconciler) schemaConfigMap(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (*v1.ConfigMap, error) {
+	cfgMap := &v1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName(executer.Spec.ConfigMapName), cfgMap)
+	if err != nil {
+		return nil, err
+	}
+	if cfgMap.Data == nil {
+		cfgMap.Data = map[string]string{}
+	}
+	if executer.Spec.SecretRef != nil {
+		kql, err := r.secretKQL(ctx, executer, cfgMap)
+		if err != nil {
+			return nil, err
+		}
+		cfgMap.Data["kql"] = kql
+	}
+	if url := executer.Spec.ApplyTo.SchemaURL; url != "" {
+		// the kql is downloaded by the cluster instead of read from the configmap.
+		cfgMap.Data[kustoutils.SchemaURLKey] = url
+	}
+	return cfgMap, nil
+}
+
+// schemaKQL returns the kql the executer applies, resolved the way its execution resolves it - read from the
+// `secretRef` or downloaded from the `schemaURL` when set, instead of the `kql` key of its config map.
+func (r *ClusterExecuterReconciler) schemaKQL(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (string, error) {
+	cfgMap, err := r.schemaConfigMap(ctx, executer)
+	if err != nil {
+		return "", err
+	}
+	kql := cfgMap.Data["kql"]
+	if url := cfgMap.Data[kustoutils.SchemaURLKey]; url != "" {
+		schema, err := kustoutils.ImportSchemaFromURL(ctx, url, nil)
+		if err != nil {
+			return "", err
+		}
+		kql = string(schema)
+	}
+	if strings.TrimSpace(kql) == "" {
+		return "", fmt.Errorf("no kql found for the executer %s", executer.Name)
+	}
+	return kql, nil
+}
+
+// observe stores the schema diff of the targets in the executer `PendingDiff` without applying it.
+// The `Ready` condition stays `Unknown` while observing, and its observed generation avoids recomputing an unchanged diff.
+func (r *ClusterExecuterReconciler) observe(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets) error {
+	cond := meta.FindStatusCondition(executer.Status.Conditions, schemav1alpha1.ConditionReady)
+	if cond != nil && cond.Reason == observationReason && cond.ObservedGeneration == executer.Generation &&
+		reflect.DeepEqual(targets, executer.Status.Targets) {
+		r.Log.Info("pending diff already observed", "executer", executer.Name)
+		return nil
+	}
+	observer, ok := cluster.(clusterUtils.Observer)
+	if !ok {
+		return fmt.Errorf("observation mode is not supported for %s clusters", executer.Spec.Type)
+	}
+	cfgMap, err := r.schemaConfigMap(ctx, executer)
+	if err != nil {
+		return err
+	}
+	cfgMap.Data[kustoutils.DryRunKey] = "true"
+	config, err := cluster.CreateExecConfiguration(targets, cfgMap, executer.Spec.FailIfDataLoss)
+	if err != nil {
+		r.Log.Error(err, "failed creating the observation configuration", "cluster", executer.Spec.ClusterUri)
+		return err
+	}
+	diff, err := observer.ObserveSchema(targets, config)
+	if err != nil {
+		r.Log.Error(err, "failed observing the schema", "cluster", executer.Spec.ClusterUri)
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "ObservationFailed", "failed to observe the schema of cluster: %s ", executer.Spec.ClusterUri)
+		return err
+	}
+	executer.Status.PendingDiff = make(map[string]string, len(diff.Databases))
+	for _, db := range diff.Databases {
+		executer.Status.PendingDiff[db.Database] = db.Delta
+	}
+	executer.Status.Targets = targets
+	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+		Type:               schemav1alpha1.ConditionReady,
+		Status:             metav1.ConditionUnknown,
+		Reason:             observationReason,
+		Message:            fmt.Sprintf("%d databases with pending changes", len(diff.Databases)),
+		ObservedGeneration: executer.Generation,
+	})
+	r.recorder.Eventf(executer, v1.EventTypeNormal, "Observed", "pending schema diff observed on %d databases", len(diff.Databases))
+	return r.Status().Update(ctx, executer)
+}
+
+// skippedTargets returns the databases the execution `results` skipped.
+func skippedTargets(results schemav1alpha1.ClusterTargets) schemav1alpha1.ClusterTargets {
+	skipped := schemav1alpha1.ClusterTargets{}
+	for db, result := range results.DBResults {
+		if result == schemav1alpha1.DBResultSkipped {
+			skipped.DBs = append(skipped.DBs, db)
+		}
+	}
+	sort.Strings(skipped.DBs)
+	return skipped
+}
+
+// setPendingDatabases marks the databases about to be executed as `Pending`, keeping the progress of the databases already done.
+func setPendingDatabases(executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets) {
+	if executer.Status.DatabaseProgress == nil {
+		executer.Status.DatabaseProgress = make(map[string]schemav1alpha1.DBPhase)
+	}
+	for _, db := range targets.DBs {
+		executer.Status.DatabaseProgress[db] = schemav1alpha1.DBPhasePending
+	}
+	executer.Status.Progress = databaseProgressSummary(executer.Status.DatabaseProgress)
+	executer.Status.Databases = len(executer.Status.DatabaseProgress)
+}
+
+// shortChecksum returns the prefix of the checksum printed by `kubectl get`.
+func shortChecksum(checksum string) string {
+	if len(checksum) > 8 {
+		return checksum[:8]
+	}
+	return checksum
+}
+
+// databaseProgressSummary returns the number of succeeded databases out of all the databases.
+func databaseProgressSummary(progress map[string]schemav1alpha1.DBPhase) string {
+	succeeded := 0
+	for _, phase := range progress {
+		if phase == schemav1alpha1.DBPhaseSucceeded {
+			succeeded++
+		}
+	}
+	return fmt.Sprintf("%d/%d", succeeded, len(progress))
+}
+
+// databaseProgressNotifier returns a notifier patching the executer status with the phase of every database.
+// A patch only carries the changed database, so it does not conflict with other status writes.
+func (r *ClusterExecuterReconciler) databaseProgressNotifier(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) kustoutils.DatabaseProgressFunc {
+	return func(db string, phase schemav1alpha1.DBPhase) {
+		patch := client.MergeFrom(executer.DeepCopy())
+		if executer.Status.DatabaseProgress == nil {
+			executer.Status.DatabaseProgress = make(map[string]schemav1alpha1.DBPhase)
+		}
+		executer.Status.DatabaseProgress[db] = phase
+		executer.Status.Progress = databaseProgressSummary(executer.Status.DatabaseProgress)
+		executer.Status.Databases = len(executer.Status.DatabaseProgress)
+		err := r.Status().Patch(ctx, executer, patch)
+		if err != nil {
+			r.Log.Error(err, "failed patching the database progress", "database", db, "phase", phase)
+		}
+	}
+}
+
+// exportSchemaDiff stores the diff computed by a dry run in the configured `ConfigMap`, when the cluster type supports it.
+func (r *ClusterExecuterReconciler) exportSchemaDiff(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, config schemav1alpha1.ExecutionConfiguration) error {
+	reader, ok := cluster.(clusterUtils.DiffReader)
+	if !ok {
+		return nil
+	}
+	diff, err := reader.SchemaDiff(config)
+	if err == nil {
+		err = kustoutils.ExportSchemaDiff(ctx, r.Client, diff, executer.Namespace, config.DryRunOutputConfigMap)
+	}
+	if err != nil {
+		r.Log.Error(err, "failed exporting the schema diff", "cluster", executer.Spec.ClusterUri)
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "DiffExportFailed", "failed to export the schema diff of cluster: %s ", executer.Spec.ClusterUri)
+		return err
+	}
+	r.recorder.Eventf(executer, v1.EventTypeNormal, "DiffExported", "schema diff exported to config map %s", config.DryRunOutputConfigMap)
+	return nil
+}
+
+// syncDatabaseRoles aligns the database roles of the targets with the executer spec, when the cluster type supports it.
+func (r *ClusterExecuterReconciler) syncDatabaseRoles(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets) error {
+	syncer, ok := cluster.(clusterUtils.RoleSyncer)
+	if !ok || len(executer.Spec.DatabaseRoles) == 0 {
+		return nil
+	}
+	err := syncer.SyncDatabaseRoles(ctx, targets, executer.Spec.DatabaseRoles)
+	if err != nil {
+		r.Log.Error(err, "failed syncing database roles", "cluster", executer.Spec.ClusterUri)
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "RolesSyncFailed", "failed to sync database roles on cluster: %s ", executer.Spec.ClusterUri)
+		return err
+	}
+	return nil
+}
+
+// requestPriority returns the priority of the executer of `req`, executers missing from the cache have the normal priority.
+func (r *ClusterExecuterReconciler) requestPriority(req ctrl.Request) int {
+	executer := &schemav1alpha1.ClusterExecuter{}
+	if err := r.Get(context.Background(), req.NamespacedName, executer); err != nil {
+		return schemav1alpha1.PriorityNormal
+	}
+	return objectPriority(executer, executer.Spec.Priority)
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// The executers are reconciled in priority order, so critical schemas are executed first when many executers are pending.
 func (r *ClusterExecuterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("ClusterExecuter")
+	prioritized := NewPrioritizedReconciler(r.Health.Wrap(r), r.requestPriority, 1)
+	if err := mgr.Add(prioritized); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&schemav1alpha1.ClusterExecuter{}).
-		Complete(r)
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(prioritized)
 }
@@ -0,0 +1,120 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// DefaultMaxReportAge is the time compliance reports are kept, unless `MaxReportAge` or the max report age setting is set.
+const DefaultMaxReportAge = 90 * 24 * time.Hour
+
+// ComplianceReportReconciler garbage collects the expired ComplianceReport objects
+type ComplianceReportReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+	// MaxReportAge is the time a report is kept after it was written, zero for the configured or `DefaultMaxReportAge`.
+	MaxReportAge time.Duration
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=compliancereports,verbs=get;list;watch;create;delete
+
+// Reconcile deletes the report once it is older than `MaxReportAge`, and otherwise requeues it until then.
+func (r *ComplianceReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "ComplianceReport/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("ComplianceReport", req.NamespacedName)
+
+	report := &schemav1alpha1.ComplianceReport{}
+	err := r.Get(ctx, req.NamespacedName, report)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if remaining := reportExpiry(report, r.maxReportAge(), time.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("compliance report expired - deleting", "reportedAt", report.Spec.ReportedAt)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, report))
+}
+
+func (r *ComplianceReportReconciler) maxReportAge() time.Duration {
+	if r.MaxReportAge > 0 {
+		return r.MaxReportAge
+	}
+	if maxAge := config.GetDuration(config.ComplianceReportMaxAgeKey); maxAge > 0 {
+		return maxAge
+	}
+	return DefaultMaxReportAge
+}
+
+// reportExpiry returns the time left until the report is older than `maxAge`, zero once it expired.
+func reportExpiry(report *schemav1alpha1.ComplianceReport, maxAge time.Duration, now time.Time) time.Duration {
+	remaining := report.Spec.ReportedAt.Add(maxAge).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ComplianceReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.ComplianceReport{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(r.Health.Wrap(r))
+}
+
+// reportCompliance runs the `assertions` on the executed `targets` and writes their results to a new `ComplianceReport`.
+// Reports are never updated, every execution writes its own report.
+func (r *ClusterExecuterReconciler) reportCompliance(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets, assertions []schemav1alpha1.KQLAssertion, now time.Time) error {
+	checker, ok := cluster.(clusterUtils.ComplianceChecker)
+	if !ok || len(assertions) == 0 {
+		return nil
+	}
+	results := checker.ComplianceResults(ctx, targets, assertions)
+	report := &schemav1alpha1.ComplianceReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", executer.Name, now.UTC().Format("20060102150405")),
+			Namespace: executer.Namespace,
+			Labels:    map[string]string{schemav1alpha1.ComplianceExecuterLabel: executer.Name},
+		},
+		Spec: schemav1alpha1.ComplianceReportSpec{
+			Executer:      schemav1alpha1.NamespacedName{Name: executer.Name, Namespace: executer.Namespace},
+			ClusterUri:    executer.Spec.ClusterUri,
+			Revision:      executer.Spec.Revision,
+			Assertions:    results,
+			OverallStatus: schemav1alpha1.OverallCompliance(results),
+			ReportedAt:    metav1.NewTime(now),
+		},
+	}
+	err := r.Create(ctx, report)
+	if errors.IsAlreadyExists(err) {
+		r.Log.Info("compliance report already written", "report", report.Name)
+		return nil
+	}
+	return err
+}
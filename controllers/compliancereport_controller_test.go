@@ -0,0 +1,138 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// auditedCluster is an observing cluster whose assertions fail on `failing` databases.
+type auditedCluster struct {
+	observingCluster
+	failing map[string]bool
+}
+
+func (c *auditedCluster) ComplianceResults(ctx context.Context, targets schemav1alpha1.ClusterTargets, assertions []schemav1alpha1.KQLAssertion) []schemav1alpha1.AssertionResult {
+	results := []schemav1alpha1.AssertionResult{}
+	for _, db := range targets.DBs {
+		for _, assertion := range assertions {
+			result := schemav1alpha1.AssertionResult{Database: db, Query: assertion.Query, Passed: !c.failing[db], RowCount: 1}
+			if !result.Passed {
+				result.Message = assertion.FailMessage
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+var _ = Describe("ComplianceReport", func() {
+	ctx := context.Background()
+	assertions := []schemav1alpha1.KQLAssertion{{Query: "Users | where isnotempty(Email)", ExpectedNonEmpty: false, FailMessage: "Email is not masked"}}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	reportedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var c client.Client
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).Build()
+	})
+
+	newExecuterReconciler := func() *ClusterExecuterReconciler {
+		return &ClusterExecuterReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("ComplianceReportTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	executer := &schemav1alpha1.ClusterExecuter{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit-2-cluster1", Namespace: "default"},
+		Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://cluster1.westeurope.kusto.windows.net", Revision: 2},
+	}
+	reportKey := types.NamespacedName{Name: "audit-2-cluster1-20261001120000", Namespace: "default"}
+
+	getReport := func() *schemav1alpha1.ComplianceReport {
+		report := &schemav1alpha1.ComplianceReport{}
+		Expect(c.Get(ctx, reportKey, report)).To(Succeed())
+		return report
+	}
+
+	It("Should write the assertion results after an execution", func() {
+		cluster := &auditedCluster{failing: map[string]bool{"db2": true}}
+		Expect(newExecuterReconciler().reportCompliance(ctx, cluster, executer, targets, assertions, reportedAt)).To(Succeed())
+
+		report := getReport()
+		Expect(report.Labels).To(HaveKeyWithValue(schemav1alpha1.ComplianceExecuterLabel, executer.Name))
+		Expect(report.Spec.Revision).To(Equal(int32(2)))
+		Expect(report.Spec.Assertions).To(HaveLen(2))
+		Expect(report.Spec.Assertions[1].Message).To(Equal("Email is not masked"))
+		Expect(report.Spec.OverallStatus).To(Equal(schemav1alpha1.CompliancePartial))
+		Expect(report.Spec.ReportedAt.Time.Equal(reportedAt)).To(BeTrue())
+	})
+	It("Should skip clusters without assertions", func() {
+		Expect(newExecuterReconciler().reportCompliance(ctx, &auditedCluster{}, executer, targets, nil, reportedAt)).To(Succeed())
+		Expect(newExecuterReconciler().reportCompliance(ctx, &observingCluster{}, executer, targets, assertions, reportedAt)).To(Succeed())
+		reports := &schemav1alpha1.ComplianceReportList{}
+		Expect(c.List(ctx, reports)).To(Succeed())
+		Expect(reports.Items).To(BeEmpty())
+	})
+	It("Should never change a written report", func() {
+		reconciler := newExecuterReconciler()
+		Expect(reconciler.reportCompliance(ctx, &auditedCluster{}, executer, targets, assertions, reportedAt)).To(Succeed())
+		Expect(reconciler.reportCompliance(ctx, &auditedCluster{failing: map[string]bool{"db1": true, "db2": true}}, executer, targets, assertions, reportedAt)).To(Succeed())
+		report := getReport()
+		Expect(report.Spec.OverallStatus).To(Equal(schemav1alpha1.CompliancePass))
+
+		changed := report.DeepCopy()
+		changed.Spec.OverallStatus = schemav1alpha1.ComplianceFail
+		Expect(changed.ValidateUpdate(report)).To(HaveOccurred())
+		relabeled := report.DeepCopy()
+		relabeled.Labels["team"] = "audit"
+		Expect(relabeled.ValidateUpdate(report)).To(Succeed())
+	})
+	It("Should garbage collect the expired reports", func() {
+		Expect(newExecuterReconciler().reportCompliance(ctx, &auditedCluster{}, executer, targets, assertions, time.Now().Add(-2*time.Hour))).To(Succeed())
+		fresh := &schemav1alpha1.ComplianceReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "fresh", Namespace: "default"},
+			Spec:       schemav1alpha1.ComplianceReportSpec{OverallStatus: schemav1alpha1.CompliancePass, ReportedAt: metav1.Now()},
+		}
+		Expect(c.Create(ctx, fresh)).To(Succeed())
+		reports := &schemav1alpha1.ComplianceReportList{}
+		Expect(c.List(ctx, reports)).To(Succeed())
+		Expect(reports.Items).To(HaveLen(2))
+
+		gc := &ComplianceReportReconciler{
+			Client:       c,
+			Log:          ctrl.Log.WithName("controllers").WithName("ComplianceReportGCTest"),
+			Scheme:       newFakeScheme(),
+			MaxReportAge: time.Hour,
+		}
+		for _, report := range reports.Items {
+			res, err := gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&report)})
+			Expect(err).NotTo(HaveOccurred())
+			if report.Name == "fresh" {
+				Expect(res.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			} else {
+				Expect(res.RequeueAfter).To(BeZero())
+			}
+		}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(fresh), &schemav1alpha1.ComplianceReport{})).To(Succeed())
+		Expect(reports.Items[0].Name).NotTo(Equal("fresh"))
+		err := c.Get(ctx, client.ObjectKeyFromObject(&reports.Items[0]), &schemav1alpha1.ComplianceReport{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
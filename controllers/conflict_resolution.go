@@ -0,0 +1,112 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// resolveSourceConflict runs the `conflictResolution` of the template before a revision is computed, returning
+// whether the reconcile proceeds with the current source. A template older than the api server version is requeued,
+// so only the latest submitted version is processed. A changed source is then accepted, or rejected under
+// `OwnerWins` when another field manager changed it, or paused under `Manual` until it is approved.
+func (r *SchemaDeploymentReconciler) resolveSourceConflict(ctx context.Context, template *schemav1alpha1.SchemaDeployment) (ctrl.Result, bool, error) {
+	strategy := template.Spec.ConflictResolution
+	if strategy == "" {
+		return ctrl.Result{}, true, nil
+	}
+	log := r.Log.WithValues("SchemaDeployment", client.ObjectKeyFromObject(template))
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	latest := &schemav1alpha1.SchemaDeployment{}
+	if err := reader.Get(ctx, client.ObjectKeyFromObject(template), latest); err != nil {
+		return ctrl.Result{}, false, client.IgnoreNotFound(err)
+	}
+	if latest.ResourceVersion != template.ResourceVersion {
+		log.Info("the schema deployment changed since it was read - requeue", "resourceVersion", template.ResourceVersion, "latest", latest.ResourceVersion)
+		return ctrl.Result{Requeue: true}, false, nil
+	}
+
+	source := sourceName(template)
+	manager := sourceManager(latest)
+	accepted := template.Status.AcceptedSource
+	switch {
+	case accepted == nil || *accepted == source:
+	case strategy == schemav1alpha1.ConflictOwnerWins && template.Status.SourceOwner != "" && manager != template.Status.SourceOwner:
+		return ctrl.Result{}, false, r.setSourceConflict(ctx, template, "NotOwner",
+			fmt.Sprintf("source %s/%s was set by %s instead of the owner %s", source.Namespace, source.Name, manager, template.Status.SourceOwner))
+	case strategy == schemav1alpha1.ConflictManual && template.GetAnnotations()[schemav1alpha1.ApproveSourceAnnotation] != source.Namespace+"/"+source.Name:
+		return ctrl.Result{}, false, r.setSourceConflict(ctx, template, "PendingApproval",
+			fmt.Sprintf("source %s/%s requires the %s: %q annotation", source.Namespace, source.Name, schemav1alpha1.ApproveSourceAnnotation, source.Namespace+"/"+source.Name))
+	default:
+		log.Info("source change accepted", "source", source, "manager", manager, "strategy", strategy)
+	}
+
+	if accepted != nil && *accepted == source && meta.FindStatusCondition(template.Status.Conditions, schemav1alpha1.ConditionSourceConflict) == nil {
+		return ctrl.Result{}, true, nil
+	}
+	if accepted == nil || *accepted != source {
+		template.Status.AcceptedSource = &source
+		template.Status.SourceOwner = manager
+	}
+	meta.RemoveStatusCondition(&template.Status.Conditions, schemav1alpha1.ConditionSourceConflict)
+	if err := r.Status().Update(ctx, template); err != nil {
+		log.Error(err, "failed updating the accepted source")
+		return ctrl.Result{}, false, err
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// setSourceConflict sets the `SourceConflict` condition of the template, recording an event when it changed.
+func (r *SchemaDeploymentReconciler) setSourceConflict(ctx context.Context, template *schemav1alpha1.SchemaDeployment, reason, message string) error {
+	current := meta.FindStatusCondition(template.Status.Conditions, schemav1alpha1.ConditionSourceConflict)
+	if current != nil && current.Status == metav1.ConditionTrue && current.Reason == reason && current.Message == message {
+		return nil
+	}
+	r.recorder.Event(template, corev1.EventTypeWarning, schemav1alpha1.ConditionSourceConflict, message)
+	meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionSourceConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	return r.Status().Update(ctx, template)
+}
+
+// sourceManager returns the field manager that last set the `source` or `secretRef` of the template, empty when unknown.
+func sourceManager(template *schemav1alpha1.SchemaDeployment) string {
+	manager := ""
+	var latest *metav1.Time
+	for _, entry := range template.ManagedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		spec := fields["f:spec"]
+		_, source := spec["f:source"]
+		_, secretRef := spec["f:secretRef"]
+		if !source && !secretRef {
+			continue
+		}
+		if manager == "" || (entry.Time != nil && (latest == nil || latest.Before(entry.Time))) {
+			manager = entry.Manager
+			latest = entry.Time
+		}
+	}
+	return manager
+}
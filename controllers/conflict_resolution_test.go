@@ -0,0 +1,144 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("SchemaDeploymentConflictResolution", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "conflicts", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+	var c client.Client
+
+	setup := func(strategy schemav1alpha1.ConflictStrategy) {
+		objects := []client.Object{}
+		for _, name := range []string{"first-kql", "second-kql", "third-kql"} {
+			objects = append(objects, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Data:       map[string]string{"kql": ".create-merge table " + name[:len(name)-4] + " (a:string)"},
+			})
+		}
+		objects = append(objects, &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type:               schemav1alpha1.DBTypeKusto,
+				Source:             schemav1alpha1.NamespacedName{Name: "first-kql", Namespace: "default"},
+				ConflictResolution: strategy,
+			},
+		})
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(objects...).Build()
+		reconciler = &SchemaDeploymentReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("ConflictResolutionTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	template := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(c.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	// update sets the source of the template as the field manager `manager`.
+	update := func(source, manager string) {
+		updated := template()
+		updated.Spec.Source.Name = source
+		updated.ManagedFields = []metav1.ManagedFieldsEntry{{
+			Manager:    manager,
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			Time:       &metav1.Time{Time: time.Now()},
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:source":{"f:name":{}}}}`)},
+		}}
+		Expect(c.Update(ctx, updated)).To(Succeed())
+	}
+	resolve := func() bool {
+		_, proceed, err := reconciler.resolveSourceConflict(ctx, template())
+		Expect(err).NotTo(HaveOccurred())
+		return proceed
+	}
+	reconcile := func() ctrl.Result {
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+	conflict := func() *metav1.Condition {
+		return meta.FindStatusCondition(template().Status.Conditions, schemav1alpha1.ConditionSourceConflict)
+	}
+	revisionSource := func(revision string) string {
+		deployment := &schemav1alpha1.VersionedDeplyment{}
+		err := c.Get(ctx, types.NamespacedName{Name: key.Name + "-" + revision, Namespace: key.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			return ""
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return deployment.Spec.ConfigMapName.Name
+	}
+
+	It("Should requeue a stale version and process only the latest source with LatestWins", func() {
+		setup(schemav1alpha1.ConflictLatestWins)
+		stale := template()
+		update("second-kql", "alice")
+		update("third-kql", "bob")
+
+		_, proceed, err := reconciler.resolveSourceConflict(ctx, stale)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proceed).To(BeFalse())
+
+		reconcile()
+		Expect(template().Status.AcceptedSource).To(Equal(&schemav1alpha1.NamespacedName{Name: "third-kql", Namespace: "default"}))
+		Expect(template().Status.SourceOwner).To(Equal("bob"))
+		Expect(revisionSource("0")).To(HavePrefix("third-kql"))
+	})
+	It("Should ignore source changes of other field managers with OwnerWins", func() {
+		setup(schemav1alpha1.ConflictOwnerWins)
+		update("first-kql", "alice")
+		Expect(resolve()).To(BeTrue())
+		Expect(template().Status.SourceOwner).To(Equal("alice"))
+
+		update("second-kql", "bob")
+		Expect(resolve()).To(BeFalse())
+		Expect(conflict().Reason).To(Equal("NotOwner"))
+		Expect(template().Status.AcceptedSource.Name).To(Equal("first-kql"))
+
+		update("third-kql", "alice")
+		Expect(resolve()).To(BeTrue())
+		Expect(conflict()).To(BeNil())
+		Expect(template().Status.AcceptedSource.Name).To(Equal("third-kql"))
+	})
+	It("Should pause on a source change until it is approved with Manual", func() {
+		setup(schemav1alpha1.ConflictManual)
+		reconcile()
+		Expect(revisionSource("0")).To(HavePrefix("first-kql"))
+
+		update("second-kql", "alice")
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(conflict().Reason).To(Equal("PendingApproval"))
+		Expect(revisionSource("1")).To(BeEmpty())
+
+		approved := template()
+		approved.Annotations = map[string]string{schemav1alpha1.ApproveSourceAnnotation: "default/second-kql"}
+		Expect(c.Update(ctx, approved)).To(Succeed())
+		reconcile()
+		Expect(conflict()).To(BeNil())
+		Expect(revisionSource("1")).To(HavePrefix("second-kql"))
+	})
+})
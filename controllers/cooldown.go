@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// cooldownRemaining returns the time left of the executer cooldown, zero when the executer may reconcile.
+// The cooldown starts at the `schema.operator/last-applied` annotation and lasts `CooldownSeconds`.
+func cooldownRemaining(executer *schemav1alpha1.ClusterExecuter, now time.Time) time.Duration {
+	if executer.Spec.CooldownSeconds <= 0 {
+		return 0
+	}
+	lastApplied, err := time.Parse(time.RFC3339, executer.GetAnnotations()[schemav1alpha1.LastAppliedAnnotation])
+	if err != nil {
+		return 0
+	}
+	remaining := lastApplied.Add(time.Duration(executer.Spec.CooldownSeconds) * time.Second).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// forceReconcile reports if the executer is annotated to bypass the cooldown.
+func forceReconcile(executer *schemav1alpha1.ClusterExecuter) bool {
+	return strings.ToLower(executer.GetAnnotations()[schemav1alpha1.ForceReconcileAnnotation]) == "true"
+}
+
+// markApplied records the apply time in the `schema.operator/last-applied` annotation and clears the force annotation.
+func (r *ClusterExecuterReconciler) markApplied(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, now time.Time) error {
+	return r.patchAnnotations(ctx, executer, func(annotations map[string]string) {
+		annotations[schemav1alpha1.LastAppliedAnnotation] = now.UTC().Format(time.RFC3339)
+		delete(annotations, schemav1alpha1.ForceReconcileAnnotation)
+	})
+}
+
+// clearForceReconcile removes the force annotation once the forced reconcile is done.
+func (r *ClusterExecuterReconciler) clearForceReconcile(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) error {
+	if !forceReconcile(executer) {
+		return nil
+	}
+	return r.patchAnnotations(ctx, executer, func(annotations map[string]string) {
+		delete(annotations, schemav1alpha1.ForceReconcileAnnotation)
+	})
+}
+
+func (r *ClusterExecuterReconciler) patchAnnotations(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, mutate func(map[string]string)) error {
+	patch := client.MergeFrom(executer.DeepCopy())
+	annotations := executer.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	mutate(annotations)
+	executer.SetAnnotations(annotations)
+	return r.Patch(ctx, executer, patch)
+}
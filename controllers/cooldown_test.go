@@ -0,0 +1,80 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("ClusterExecuterCooldown", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "cooldown-0-cluster1", Namespace: "default"}
+
+	// newReconciler returns a reconciler of a running executer last applied `ago`.
+	// A running executer requeues after a minute, so a reconcile passing the cooldown never reaches the cluster.
+	newReconciler := func(ago time.Duration, annotations map[string]string) *ClusterExecuterReconciler {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[schemav1alpha1.LastAppliedAnnotation] = time.Now().Add(-ago).UTC().Format(time.RFC3339)
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Annotations: annotations},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{CooldownSeconds: schemav1alpha1.DefaultCooldownSeconds},
+			Status:     schemav1alpha1.ClusterExecuterStatus{Running: true},
+		}
+		return &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("CooldownTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+
+	It("should skip the reconcile within the cooldown", func() {
+		res, err := newReconciler(10*time.Second, nil).Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically(">", 40*time.Second))
+		Expect(res.RequeueAfter).To(BeNumerically("<=", 50*time.Second))
+	})
+	It("should reconcile after the cooldown", func() {
+		res, err := newReconciler(2*time.Minute, nil).Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+	})
+	It("should bypass the cooldown when forced", func() {
+		reconciler := newReconciler(10*time.Second, map[string]string{schemav1alpha1.ForceReconcileAnnotation: "true"})
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+	})
+	It("should not cool down without a cooldown", func() {
+		executer := &schemav1alpha1.ClusterExecuter{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{schemav1alpha1.LastAppliedAnnotation: time.Now().UTC().Format(time.RFC3339)},
+		}}
+		Expect(cooldownRemaining(executer, time.Now())).To(BeZero())
+	})
+	It("should record the apply time and clear the force annotation", func() {
+		reconciler := newReconciler(time.Hour, map[string]string{schemav1alpha1.ForceReconcileAnnotation: "true"})
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		now := time.Now().Truncate(time.Second)
+		Expect(reconciler.markApplied(ctx, executer, now)).To(Succeed())
+
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		Expect(executer.Annotations).NotTo(HaveKey(schemav1alpha1.ForceReconcileAnnotation))
+		Expect(executer.Annotations[schemav1alpha1.LastAppliedAnnotation]).To(Equal(now.UTC().Format(time.RFC3339)))
+		Expect(cooldownRemaining(executer, now)).To(Equal(time.Minute))
+	})
+})
@@ -0,0 +1,113 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// dependencyVersions returns the `clusterURI=schemaVersion` of the dependencies, identifying the versions waited on.
+func dependencyVersions(dependencies []schemav1alpha1.ClusterDependency) []string {
+	versions := make([]string, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		versions = append(versions, dependency.ClusterURI+"="+dependency.SchemaVersion)
+	}
+	return versions
+}
+
+// sameCluster compares cluster URIs ignoring the case and a trailing slash.
+func sameCluster(a, b string) bool {
+	return normalizeClusterURI(a) == normalizeClusterURI(b)
+}
+
+// pendingDependencies returns the dependencies no cluster executer of their cluster applied yet.
+func (r *SchemaDeploymentReconciler) pendingDependencies(ctx context.Context, dependencies []schemav1alpha1.ClusterDependency) ([]schemav1alpha1.ClusterDependency, error) {
+	executers := &schemav1alpha1.ClusterExecuterList{}
+	if err := r.List(ctx, executers); err != nil {
+		return nil, err
+	}
+	pending := []schemav1alpha1.ClusterDependency{}
+	for _, dependency := range dependencies {
+		applied := false
+		for _, executer := range executers.Items {
+			if sameCluster(executer.Spec.ClusterUri, dependency.ClusterURI) && executer.Status.AppliedChecksum == dependency.SchemaVersion {
+				applied = true
+				break
+			}
+		}
+		if !applied {
+			pending = append(pending, dependency)
+		}
+	}
+	return pending, nil
+}
+
+// waitForDependencies checks the `crossClusterDependencies` of the template before a new revision is executed.
+// Pending dependencies requeue the template after a tenth of their timeout, once a timeout elapsed the
+// `DependencyTimeout` condition is set and the template isn't requeued until the dependency versions change.
+// Returns whether all the dependencies are applied.
+func (r *SchemaDeploymentReconciler) waitForDependencies(ctx context.Context, template *schemav1alpha1.SchemaDeployment) (ctrl.Result, bool, error) {
+	log := r.Log.WithValues("SchemaDeployment", template.Namespace+"/"+template.Name)
+	versions := dependencyVersions(template.Spec.CrossClusterDependencies)
+	if template.Status.DependenciesWaitingSince == nil || !reflect.DeepEqual(template.Status.DependencyVersions, versions) {
+		now := metav1.Now()
+		template.Status.DependenciesWaitingSince = &now
+		template.Status.DependencyVersions = versions
+		meta.RemoveStatusCondition(&template.Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)
+	} else if meta.IsStatusConditionTrue(template.Status.Conditions, schemav1alpha1.ConditionDependencyTimeout) {
+		log.Info("cross cluster dependencies timed out - waiting for a dependency version change")
+		return ctrl.Result{}, false, nil
+	}
+
+	pending, err := r.pendingDependencies(ctx, template.Spec.CrossClusterDependencies)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if len(pending) == 0 {
+		template.Status.DependenciesWaitingSince = nil
+		template.Status.DependencyVersions = nil
+		meta.RemoveStatusCondition(&template.Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)
+		return ctrl.Result{}, true, nil
+	}
+
+	waited := time.Since(template.Status.DependenciesWaitingSince.Time)
+	requeueAfter := r.requeueInterval(template)
+	for _, dependency := range pending {
+		timeout := dependency.Timeout.Duration
+		if timeout <= 0 {
+			continue
+		}
+		if waited >= timeout {
+			message := fmt.Sprintf("schema version %s wasn't applied on cluster %s within %s", dependency.SchemaVersion, dependency.ClusterURI, timeout)
+			r.recorder.Event(template, corev1.EventTypeWarning, "DependencyTimeout", message)
+			meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+				Type:    schemav1alpha1.ConditionDependencyTimeout,
+				Status:  metav1.ConditionTrue,
+				Reason:  "DependencyTimeout",
+				Message: message,
+			})
+			return ctrl.Result{}, false, r.Status().Update(ctx, template)
+		}
+		if timeout/10 < requeueAfter {
+			requeueAfter = timeout / 10
+		}
+	}
+	meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionDependencyTimeout,
+		Status:  metav1.ConditionFalse,
+		Reason:  "WaitingForDependencies",
+		Message: fmt.Sprintf("waiting for %d cross cluster dependencies", len(pending)),
+	})
+	log.Info("waiting for cross cluster dependencies", "pending", len(pending), "requeueAfter", requeueAfter)
+	return ctrl.Result{RequeueAfter: requeueAfter}, false, r.Status().Update(ctx, template)
+}
@@ -0,0 +1,121 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("SchemaDeploymentCrossClusterDependencies", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "views", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+
+	dependency := schemav1alpha1.ClusterDependency{
+		ClusterURI:    "https://tables.westeurope.kusto.windows.net",
+		SchemaVersion: "v1-checksum",
+		Timeout:       metav1.Duration{Duration: 10 * time.Minute},
+	}
+	setup := func(status schemav1alpha1.SchemaDeploymentStatus, executers ...*schemav1alpha1.ClusterExecuter) {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "views-kql", Namespace: "default"},
+			Data:       map[string]string{"kql": ".create materialized-view V on table T { T | summarize count() by a }"},
+		}
+		builder := fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap,
+			&schemav1alpha1.SchemaDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec: schemav1alpha1.SchemaDeploymentSpec{
+					Type:                     schemav1alpha1.DBTypeKusto,
+					Source:                   schemav1alpha1.NamespacedName{Name: "views-kql", Namespace: "default"},
+					CrossClusterDependencies: []schemav1alpha1.ClusterDependency{dependency},
+				},
+				Status: status,
+			})
+		for _, executer := range executers {
+			builder = builder.WithObjects(executer)
+		}
+		reconciler = &SchemaDeploymentReconciler{
+			Client:          builder.Build(),
+			Log:             ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+			Scheme:          newFakeScheme(),
+			recorder:        record.NewFakeRecorder(10),
+			RequeueInterval: 3 * time.Minute,
+		}
+	}
+	executer := func(clusterURI, checksum string) *schemav1alpha1.ClusterExecuter {
+		return &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "tables-0-tables", Namespace: "tables"},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: clusterURI},
+			Status:     schemav1alpha1.ClusterExecuterStatus{AppliedChecksum: checksum},
+		}
+	}
+	reconcile := func() ctrl.Result {
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+	template := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	versionedDeploymentExists := func() bool {
+		err := reconciler.Get(ctx, types.NamespacedName{Name: "views-0", Namespace: "default"}, &schemav1alpha1.VersionedDeplyment{})
+		if errors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	It("Should execute once the dependency version is applied", func() {
+		setup(schemav1alpha1.SchemaDeploymentStatus{}, executer("https://tables.westeurope.kusto.windows.net/", "v1-checksum"))
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: 3 * time.Minute}))
+		Expect(versionedDeploymentExists()).To(BeTrue())
+		Expect(template().Status.DependenciesWaitingSince).To(BeNil())
+		Expect(meta.FindStatusCondition(template().Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)).To(BeNil())
+	})
+	It("Should requeue after a tenth of the timeout while the dependency is pending", func() {
+		setup(schemav1alpha1.SchemaDeploymentStatus{}, executer("https://tables.westeurope.kusto.windows.net", "v0-checksum"))
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+		Expect(versionedDeploymentExists()).To(BeFalse())
+		waiting := template()
+		Expect(waiting.Status.DependenciesWaitingSince).NotTo(BeNil())
+		Expect(waiting.Status.CurrentRevision).To(BeZero())
+		Expect(meta.IsStatusConditionFalse(waiting.Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)).To(BeTrue())
+	})
+	It("Should stop retrying once the dependency timed out until its version changes", func() {
+		since := metav1.NewTime(time.Now().Add(-time.Hour))
+		setup(schemav1alpha1.SchemaDeploymentStatus{
+			DependenciesWaitingSince: &since,
+			DependencyVersions:       dependencyVersions([]schemav1alpha1.ClusterDependency{dependency}),
+		})
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(meta.IsStatusConditionTrue(template().Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)).To(BeTrue())
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(versionedDeploymentExists()).To(BeFalse())
+
+		updated := template()
+		updated.Spec.CrossClusterDependencies[0].SchemaVersion = "v2-checksum"
+		Expect(reconciler.Update(ctx, updated)).To(Succeed())
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+		waiting := template()
+		Expect(waiting.Status.DependenciesWaitingSince.Time).To(BeTemporally(">", since.Time))
+		Expect(meta.IsStatusConditionFalse(waiting.Status.Conditions, schemav1alpha1.ConditionDependencyTimeout)).To(BeTrue())
+	})
+})
@@ -0,0 +1,78 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("ClusterExecuterDatabaseProgress", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "progress-0-cluster1", Namespace: "default"}
+	var reconciler *ClusterExecuterReconciler
+
+	BeforeEach(func() {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		}
+		reconciler = &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("DatabaseProgressTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should patch the status after every database", func() {
+		executer := getExecuter()
+		setPendingDatabases(executer, schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}})
+		Expect(reconciler.Status().Update(ctx, executer)).To(Succeed())
+		Expect(getExecuter().Status.Progress).To(Equal("0/3"))
+
+		// another writer updates the status while the databases are executed.
+		other := getExecuter()
+		other.Status.CompletedPCT = 10
+		Expect(reconciler.Status().Update(ctx, other)).To(Succeed())
+
+		notify := reconciler.databaseProgressNotifier(ctx, executer)
+		notify("db1", schemav1alpha1.DBPhaseInProgress)
+		Expect(getExecuter().Status.DatabaseProgress["db1"]).To(Equal(schemav1alpha1.DBPhaseInProgress))
+		notify("db1", schemav1alpha1.DBPhaseSucceeded)
+		notify("db2", schemav1alpha1.DBPhaseInProgress)
+
+		// the execution is cancelled before db2 completes.
+		found := getExecuter()
+		Expect(found.Status.DatabaseProgress).To(Equal(map[string]schemav1alpha1.DBPhase{
+			"db1": schemav1alpha1.DBPhaseSucceeded,
+			"db2": schemav1alpha1.DBPhaseInProgress,
+			"db3": schemav1alpha1.DBPhasePending,
+		}))
+		Expect(found.Status.Progress).To(Equal("1/3"))
+		Expect(found.Status.CompletedPCT).To(Equal(10))
+	})
+	It("Should keep the progress of databases already done", func() {
+		executer := getExecuter()
+		executer.Status.DatabaseProgress = map[string]schemav1alpha1.DBPhase{"db1": schemav1alpha1.DBPhaseSucceeded, "db2": schemav1alpha1.DBPhaseFailed}
+		setPendingDatabases(executer, schemav1alpha1.ClusterTargets{DBs: []string{"db2"}})
+		Expect(executer.Status.DatabaseProgress["db1"]).To(Equal(schemav1alpha1.DBPhaseSucceeded))
+		Expect(executer.Status.DatabaseProgress["db2"]).To(Equal(schemav1alpha1.DBPhasePending))
+		Expect(executer.Status.Progress).To(Equal("1/2"))
+	})
+})
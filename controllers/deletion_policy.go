@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+)
+
+// executerClusterFunc returns the cluster the executer applies its schema to.
+type executerClusterFunc func(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (clusterUtils.Cluster, error)
+
+// handleDeletion adds the `SchemaFinalizer` to a live template, and runs the deletion policy of a deleted template
+// before removing its finalizer. It reports whether the template is being deleted.
+func (r *SchemaDeploymentReconciler) handleDeletion(ctx context.Context, template *schemav1alpha1.SchemaDeployment) (bool, error) {
+	if template.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(template, schemav1alpha1.SchemaFinalizer) {
+			return false, nil
+		}
+		controllerutil.AddFinalizer(template, schemav1alpha1.SchemaFinalizer)
+		return false, r.Update(ctx, template)
+	}
+	if !controllerutil.ContainsFinalizer(template, schemav1alpha1.SchemaFinalizer) {
+		return true, nil
+	}
+	if err := r.runDeletionPolicy(ctx, template); err != nil {
+		r.recorder.Eventf(template, corev1.EventTypeWarning, "DeletionPolicyFailed", "failed running the %s deletion policy: %s", deletionPolicy(template), err.Error())
+		return true, err
+	}
+	controllerutil.RemoveFinalizer(template, schemav1alpha1.SchemaFinalizer)
+	return true, r.Update(ctx, template)
+}
+
+// deletionPolicy returns the deletion policy of the template, `Retain` when unset.
+func deletionPolicy(template *schemav1alpha1.SchemaDeployment) schemav1alpha1.DeletionPolicyEnum {
+	if template.Spec.DeletionPolicy == "" {
+		return schemav1alpha1.DeletionPolicyRetain
+	}
+	return template.Spec.DeletionPolicy
+}
+
+// runDeletionPolicy runs the deletion policy of the template on the databases its current revision was applied to.
+func (r *SchemaDeploymentReconciler) runDeletionPolicy(ctx context.Context, template *schemav1alpha1.SchemaDeployment) error {
+	log := r.Log.WithValues("SchemaDeployment", template.Name)
+	policy := deletionPolicy(template)
+	if policy == schemav1alpha1.DeletionPolicyRetain || template.Status.CurrentVerDeployment.Name == "" {
+		return nil
+	}
+	if err := template.Spec.ValidateDeletionPolicy(); err != nil {
+		return err
+	}
+	deployment := &schemav1alpha1.VersionedDeplyment{}
+	err := r.Get(ctx, types.NamespacedName(template.Status.CurrentVerDeployment), deployment)
+	if errors.IsNotFound(err) {
+		log.Info("the current versioned deployment is already deleted - nothing to clean up")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	baseline := &corev1.ConfigMap{}
+	switch policy {
+	case schemav1alpha1.DeletionPolicyDeleteManagedObjects:
+	case schemav1alpha1.DeletionPolicyRevertToBaseline:
+		ref := *template.Spec.BaselineConfigMapRef
+		if ref.Namespace == "" {
+			ref.Namespace = template.Namespace
+		}
+		if err := r.Get(ctx, types.NamespacedName(ref), baseline); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported deletion policy %s", policy)
+	}
+
+	for _, name := range deployment.Status.Executers {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		err = r.Get(ctx, types.NamespacedName(name), executer)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		targets := executer.Status.DoneTargets
+		if len(targets.DBs) == 0 {
+			continue
+		}
+		cluster, err := r.executerCluster(ctx, executer)
+		if err != nil {
+			return err
+		}
+		if policy == schemav1alpha1.DeletionPolicyDeleteManagedObjects {
+			dropper, ok := cluster.(clusterUtils.ObjectDropper)
+			if !ok {
+				log.Info("the cluster type can't drop the managed objects", "cluster", executer.Spec.ClusterUri)
+				continue
+			}
+			// the managed objects are the ones of the kql the executer applied, wherever it was read from.
+			executers := &ClusterExecuterReconciler{Client: r.Client, APIReader: r.APIReader}
+			var kql string
+			kql, err = executers.schemaKQL(ctx, executer)
+			if err != nil {
+				return err
+			}
+			err = dropper.DropManagedObjects(ctx, targets, kql)
+		} else {
+			err = r.applyBaseline(cluster, executer, targets, baseline)
+		}
+		if err != nil {
+			return err
+		}
+		log.Info("ran the deletion policy", "policy", policy, "cluster", executer.Spec.ClusterUri, "databases", len(targets.DBs))
+	}
+	r.recorder.Eventf(template, corev1.EventTypeNormal, "DeletionPolicyApplied", "ran the %s deletion policy on the databases of revision %d", policy, template.Status.CurrentRevision)
+	return nil
+}
+
+// applyBaseline applies the `baseline` schema config map to the targets of the executer cluster.
+func (r *SchemaDeploymentReconciler) applyBaseline(cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets, baseline *corev1.ConfigMap) error {
+	config, err := cluster.CreateExecConfiguration(targets, baseline, executer.Spec.FailIfDataLoss)
+	if err != nil {
+		return err
+	}
+	_, err = cluster.Execute(targets, config)
+	return err
+}
+
+// executerCluster returns the cluster of the executer, with the executer tenant credentials.
+func (r *SchemaDeploymentReconciler) executerCluster(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (clusterUtils.Cluster, error) {
+	if r.clusters != nil {
+		return r.clusters(ctx, executer)
+	}
+	executers := &ClusterExecuterReconciler{Client: r.Client, APIReader: r.APIReader}
+	return executers.newCluster(ctx, executer, nil)
+}
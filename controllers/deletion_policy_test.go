@@ -0,0 +1,221 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// finalizingCluster records the objects dropped from and the schemas applied to its databases.
+type finalizingCluster struct {
+	scriptedCluster
+	dropped []string
+	applied []string
+}
+
+func (c *finalizingCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	return schemav1alpha1.ExecutionConfiguration{KQLFile: cfgMap.Data["kql"]}, nil
+}
+
+func (c *finalizingCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	for _, db := range targets.DBs {
+		c.applied = append(c.applied, db+": "+config.KQLFile)
+	}
+	return targets, nil
+}
+
+func (c *finalizingCluster) DropManagedObjects(ctx context.Context, targets schemav1alpha1.ClusterTargets, kql string) error {
+	for _, db := range targets.DBs {
+		c.dropped = append(c.dropped, db+": "+kql)
+	}
+	return nil
+}
+
+var _ = Describe("Deletion policy", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "retired", Namespace: "default"}
+	const kql = ".create-merge table Orders (id:string)"
+	const baselineKQL = ".create-merge table Legacy (id:string)"
+	var c client.Client
+	var cluster *finalizingCluster
+	var reconciler *SchemaDeploymentReconciler
+
+	newTemplate := func(policy schemav1alpha1.DeletionPolicyEnum) *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type:                 schemav1alpha1.DBTypeKusto,
+				DeletionPolicy:       policy,
+				BaselineConfigMapRef: &schemav1alpha1.NamespacedName{Name: "baseline-kql"},
+			},
+			Status: schemav1alpha1.SchemaDeploymentStatus{
+				CurrentRevision:      1,
+				CurrentConfigMap:     schemav1alpha1.NamespacedName{Name: "retired-kql-1", Namespace: key.Namespace},
+				CurrentVerDeployment: schemav1alpha1.NamespacedName{Name: "retired-1", Namespace: key.Namespace},
+			},
+		}
+		Expect(c.Create(ctx, template)).To(Succeed())
+		return template
+	}
+
+	BeforeEach(func() {
+		objects := []client.Object{
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "retired-kql-1", Namespace: key.Namespace}, Data: map[string]string{"kql": kql}},
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "baseline-kql", Namespace: key.Namespace}, Data: map[string]string{"kql": baselineKQL}},
+			&schemav1alpha1.VersionedDeplyment{
+				ObjectMeta: metav1.ObjectMeta{Name: "retired-1", Namespace: key.Namespace},
+				Status: schemav1alpha1.VersionedDeplymentStatus{
+					Executers: []schemav1alpha1.NamespacedName{{Name: "retired-1-cluster1", Namespace: key.Namespace}},
+				},
+			},
+			&schemav1alpha1.ClusterExecuter{
+				ObjectMeta: metav1.ObjectMeta{Name: "retired-1-cluster1", Namespace: key.Namespace},
+				Spec: schemav1alpha1.ClusterExecuterSpec{
+					ClusterUri:    "https://cluster1.westeurope.kusto.windows.net",
+					Type:          schemav1alpha1.DBTypeKusto,
+					ConfigMapName: schemav1alpha1.NamespacedName{Name: "retired-kql-1", Namespace: key.Namespace},
+				},
+				Status: schemav1alpha1.ClusterExecuterStatus{DoneTargets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(objects...).Build()
+		cluster = &finalizingCluster{}
+		reconciler = &SchemaDeploymentReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("DeletionPolicyTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+			clusters: func(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (clusterUtils.Cluster, error) {
+				return cluster, nil
+			},
+		}
+	})
+
+	getTemplate := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(c.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	// finalize adds the finalizer to the template, deletes it and runs the finalizer.
+	finalize := func(template *schemav1alpha1.SchemaDeployment) {
+		deleted, err := reconciler.handleDeletion(ctx, template)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(BeFalse())
+		Expect(controllerutil.ContainsFinalizer(getTemplate(), schemav1alpha1.SchemaFinalizer)).To(BeTrue())
+
+		Expect(c.Delete(ctx, template)).To(Succeed())
+		deleted, err = reconciler.handleDeletion(ctx, getTemplate())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(BeTrue())
+		err = c.Get(ctx, key, &schemav1alpha1.SchemaDeployment{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	}
+
+	It("Should keep the schema objects by default", func() {
+		finalize(newTemplate(""))
+		Expect(cluster.dropped).To(BeEmpty())
+		Expect(cluster.applied).To(BeEmpty())
+	})
+	It("Should drop the managed objects", func() {
+		finalize(newTemplate(schemav1alpha1.DeletionPolicyDeleteManagedObjects))
+		Expect(cluster.dropped).To(Equal([]string{"db1: " + kql, "db2: " + kql}))
+		Expect(cluster.applied).To(BeEmpty())
+	})
+	// updateExecuter applies `update` to the executer of the current revision.
+	updateExecuter := func(update func(executer *schemav1alpha1.ClusterExecuter)) {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "retired-1-cluster1", Namespace: key.Namespace}, executer)).To(Succeed())
+		update(executer)
+		Expect(c.Update(ctx, executer)).To(Succeed())
+	}
+	It("Should drop the managed objects of a secret kql", func() {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders-kql", Namespace: key.Namespace},
+			Data:       map[string][]byte{"kql": []byte(kql)},
+		}
+		Expect(c.Create(ctx, secret)).To(Succeed())
+		cfgMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "retired-kql-1", Namespace: key.Namespace}}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cfgMap), cfgMap)).To(Succeed())
+		cfgMap.Data = map[string]string{secretChecksumKey: kustoutils.ComputeKQLChecksum(kql)}
+		Expect(c.Update(ctx, cfgMap)).To(Succeed())
+		updateExecuter(func(executer *schemav1alpha1.ClusterExecuter) {
+			executer.Spec.SecretRef = &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secret.Name}, Key: "kql"}
+		})
+
+		finalize(newTemplate(schemav1alpha1.DeletionPolicyDeleteManagedObjects))
+		Expect(cluster.dropped).To(Equal([]string{"db1: " + kql, "db2: " + kql}))
+	})
+	It("Should drop the managed objects of a downloaded kql", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, kql)
+		}))
+		defer server.Close()
+		updateExecuter(func(executer *schemav1alpha1.ClusterExecuter) {
+			executer.Spec.ApplyTo.SchemaURL = server.URL + "/schema.kql"
+		})
+
+		finalize(newTemplate(schemav1alpha1.DeletionPolicyDeleteManagedObjects))
+		Expect(cluster.dropped).To(Equal([]string{"db1: " + kql, "db2: " + kql}))
+	})
+	It("Should keep the finalizer when the kql is empty", func() {
+		cfgMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "retired-kql-1", Namespace: key.Namespace}}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cfgMap), cfgMap)).To(Succeed())
+		cfgMap.Data = map[string]string{"kql": ""}
+		Expect(c.Update(ctx, cfgMap)).To(Succeed())
+		template := newTemplate(schemav1alpha1.DeletionPolicyDeleteManagedObjects)
+		_, err := reconciler.handleDeletion(ctx, template)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Delete(ctx, template)).To(Succeed())
+
+		deleted, err := reconciler.handleDeletion(ctx, getTemplate())
+		Expect(deleted).To(BeTrue())
+		Expect(err).To(MatchError("no kql found for the executer retired-1-cluster1"))
+		Expect(cluster.dropped).To(BeEmpty())
+		Expect(controllerutil.ContainsFinalizer(getTemplate(), schemav1alpha1.SchemaFinalizer)).To(BeTrue())
+	})
+	It("Should revert to the baseline schema", func() {
+		finalize(newTemplate(schemav1alpha1.DeletionPolicyRevertToBaseline))
+		Expect(cluster.applied).To(Equal([]string{"db1: " + baselineKQL, "db2: " + baselineKQL}))
+		Expect(cluster.dropped).To(BeEmpty())
+	})
+	It("Should keep the finalizer until the baseline is applied", func() {
+		template := newTemplate(schemav1alpha1.DeletionPolicyRevertToBaseline)
+		template.Spec.BaselineConfigMapRef = &schemav1alpha1.NamespacedName{Name: "missing-kql"}
+		Expect(c.Update(ctx, template)).To(Succeed())
+		_, err := reconciler.handleDeletion(ctx, template)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Delete(ctx, template)).To(Succeed())
+
+		deleted, err := reconciler.handleDeletion(ctx, getTemplate())
+		Expect(deleted).To(BeTrue())
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(controllerutil.ContainsFinalizer(getTemplate(), schemav1alpha1.SchemaFinalizer)).To(BeTrue())
+	})
+	It("Should require a baseline for the RevertToBaseline policy", func() {
+		spec := schemav1alpha1.SchemaDeploymentSpec{DeletionPolicy: schemav1alpha1.DeletionPolicyRevertToBaseline}
+		Expect(spec.ValidateDeletionPolicy()).To(HaveOccurred())
+		spec.BaselineConfigMapRef = &schemav1alpha1.NamespacedName{Name: "baseline-kql"}
+		Expect(spec.ValidateDeletionPolicy()).To(Succeed())
+	})
+})
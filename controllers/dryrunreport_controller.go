@@ -0,0 +1,117 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// DefaultDryRunReportTTL is the time dry run reports are kept, unless the dry run report ttl setting is set.
+const DefaultDryRunReportTTL = 24 * time.Hour
+
+// DryRunReportReconciler garbage collects the expired DryRunReport objects
+type DryRunReportReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=dryrunreports,verbs=get;list;watch;create;delete
+
+// Reconcile deletes the report once its `ExpiresAt` passed, and otherwise requeues it until then.
+func (r *DryRunReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "DryRunReport/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("DryRunReport", req.NamespacedName)
+
+	report := &schemav1alpha1.DryRunReport{}
+	err := r.Get(ctx, req.NamespacedName, report)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if remaining := report.Spec.ExpiresAt.Sub(time.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("dry run report expired - deleting", "expiresAt", report.Spec.ExpiresAt)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, report))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DryRunReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.DryRunReport{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(r.Health.Wrap(r))
+}
+
+// dryRunReportTTL returns the time a dry run report is kept.
+func dryRunReportTTL() time.Duration {
+	if ttl := config.GetDuration(config.DryRunReportTTLKey); ttl > 0 {
+		return ttl
+	}
+	return DefaultDryRunReportTTL
+}
+
+// reportDryRun writes the diff computed by the dry run described by `config` to a new `DryRunReport`,
+// when the cluster type supports dry runs. Like compliance reports, every dry run writes its own report.
+func (r *ClusterExecuterReconciler) reportDryRun(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, config schemav1alpha1.ExecutionConfiguration, now time.Time) error {
+	reader, ok := cluster.(clusterUtils.DiffReader)
+	if !ok || config.DryRunOutputDir == "" {
+		return nil
+	}
+	diff, err := reader.SchemaDiff(config)
+	if err != nil {
+		return err
+	}
+	databases := make([]schemav1alpha1.DatabaseDelta, 0, len(diff.Databases))
+	for _, db := range diff.Databases {
+		databases = append(databases, schemav1alpha1.DatabaseDelta{Database: db.Database, Delta: db.Delta})
+	}
+	summary := diff.Summary()
+	report := &schemav1alpha1.DryRunReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", executer.Name, now.UTC().Format("20060102150405")),
+			Namespace: executer.Namespace,
+			Labels:    map[string]string{schemav1alpha1.ComplianceExecuterLabel: executer.Name},
+		},
+		Spec: schemav1alpha1.DryRunReportSpec{
+			Executer:     schemav1alpha1.NamespacedName{Name: executer.Name, Namespace: executer.Namespace},
+			ClusterUri:   executer.Spec.ClusterUri,
+			Revision:     executer.Spec.Revision,
+			Source:       executer.Spec.ConfigMapName,
+			Databases:    databases,
+			Summary:      summary,
+			TotalChanges: summary.TotalChanges(),
+			ExpiresAt:    metav1.NewTime(now.Add(dryRunReportTTL())),
+		},
+	}
+	err = r.Create(ctx, report)
+	if errors.IsAlreadyExists(err) {
+		r.Log.Info("dry run report already written", "report", report.Name)
+		return nil
+	}
+	return err
+}
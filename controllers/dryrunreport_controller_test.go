@@ -0,0 +1,167 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// dryRunCluster is a scripted cluster that computes `diff` instead of applying it when `dryRun` is set.
+type dryRunCluster struct {
+	scriptedCluster
+	dryRun bool
+	diff   kustoutils.SchemaDiff
+}
+
+func (c *dryRunCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	config := schemav1alpha1.ExecutionConfiguration{}
+	if c.dryRun {
+		config.DryRunOutputDir = "/tmp/delta-test"
+	}
+	return config, nil
+}
+
+func (c *dryRunCluster) SchemaDiff(config schemav1alpha1.ExecutionConfiguration) (kustoutils.SchemaDiff, error) {
+	return c.diff, nil
+}
+
+var _ = Describe("DryRunReport", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "preview-1-cluster1", Namespace: "default"}
+	source := schemav1alpha1.NamespacedName{Name: "preview-kql", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	diff := kustoutils.SchemaDiff{
+		ClusterURI: uri,
+		Databases: []kustoutils.DatabaseDiff{
+			{Database: "db1", Delta: ".create table T (a:string)\n.create-or-alter function F() { T }"},
+			{Database: "db2", Delta: ".drop table Old"},
+		},
+	}
+	var c client.Client
+
+	BeforeEach(func() {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: source.Name, Namespace: source.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)", kustoutils.DryRunKey: "true"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: source,
+				Revision:      1,
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer).Build()
+	})
+	execute := func(cluster *dryRunCluster) {
+		reconciler := &ClusterExecuterReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("DryRunReportTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	listReports := func() []schemav1alpha1.DryRunReport {
+		reports := &schemav1alpha1.DryRunReportList{}
+		Expect(c.List(ctx, reports)).To(Succeed())
+		return reports.Items
+	}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+
+	It("Should write the proposed changes of a dry run", func() {
+		execute(&dryRunCluster{scriptedCluster: scriptedCluster{targets: targets}, dryRun: true, diff: diff})
+
+		reports := listReports()
+		Expect(reports).To(HaveLen(1))
+		report := reports[0]
+		Expect(report.Namespace).To(Equal(key.Namespace))
+		Expect(report.Labels).To(HaveKeyWithValue(schemav1alpha1.ComplianceExecuterLabel, key.Name))
+		Expect(report.Spec.ClusterUri).To(Equal(uri))
+		Expect(report.Spec.Source).To(Equal(source))
+		Expect(report.Spec.Databases).To(Equal([]schemav1alpha1.DatabaseDelta{
+			{Database: "db1", Delta: diff.Databases[0].Delta},
+			{Database: "db2", Delta: diff.Databases[1].Delta},
+		}))
+		Expect(report.Spec.Summary.TablesAdded).To(Equal(1))
+		Expect(report.Spec.Summary.TablesDropped).To(Equal(1))
+		Expect(report.Spec.TotalChanges).To(Equal(report.Spec.Summary.TotalChanges()))
+		Expect(report.Spec.TotalChanges).To(BeNumerically(">=", 3))
+		Expect(report.Spec.ExpiresAt.Time).To(BeTemporally("~", time.Now().Add(DefaultDryRunReportTTL), time.Minute))
+	})
+	It("Should not mark the targets of a dry run applied", func() {
+		cluster := &dryRunCluster{scriptedCluster: scriptedCluster{targets: targets}, dryRun: true, diff: diff}
+		execute(cluster)
+
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		Expect(executer.Status.Executed).To(BeFalse())
+		Expect(executer.Status.Running).To(BeFalse())
+		Expect(executer.Status.DoneTargets.DBs).To(BeEmpty())
+		Expect(executer.Status.AppliedChecksum).To(BeEmpty())
+		Expect(executer.Status.LastAppliedAt).To(BeNil())
+		Expect(executer.Annotations).NotTo(HaveKey(schemav1alpha1.LastAppliedAnnotation))
+		cond := meta.FindStatusCondition(executer.Status.Conditions, schemav1alpha1.ConditionExecution)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Reason).To(Equal(dryRunReason))
+
+		// the dry run is not repeated on the same targets.
+		Expect(c.DeleteAllOf(ctx, &schemav1alpha1.DryRunReport{}, client.InNamespace(key.Namespace))).To(Succeed())
+		execute(cluster)
+		Expect(listReports()).To(BeEmpty())
+	})
+	It("Should not write a report for a live execution", func() {
+		execute(&dryRunCluster{scriptedCluster: scriptedCluster{targets: targets}, diff: diff})
+		Expect(listReports()).To(BeEmpty())
+	})
+	It("Should garbage collect the expired reports", func() {
+		expired := &schemav1alpha1.DryRunReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: "default"},
+			Spec:       schemav1alpha1.DryRunReportSpec{ExpiresAt: metav1.NewTime(time.Now().Add(-time.Minute))},
+		}
+		fresh := &schemav1alpha1.DryRunReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "fresh", Namespace: "default"},
+			Spec:       schemav1alpha1.DryRunReportSpec{ExpiresAt: metav1.NewTime(time.Now().Add(time.Hour))},
+		}
+		Expect(c.Create(ctx, expired)).To(Succeed())
+		Expect(c.Create(ctx, fresh)).To(Succeed())
+
+		gc := &DryRunReportReconciler{
+			Client: c,
+			Log:    ctrl.Log.WithName("controllers").WithName("DryRunReportGCTest"),
+			Scheme: newFakeScheme(),
+		}
+		res, err := gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(expired)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		err = c.Get(ctx, client.ObjectKeyFromObject(expired), &schemav1alpha1.DryRunReport{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		res, err = gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(fresh)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(fresh), &schemav1alpha1.DryRunReport{})).To(Succeed())
+	})
+})
@@ -0,0 +1,57 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+)
+
+// eventProducerFunc returns the producer sending the events to the Event Hub of the `connectionString`.
+type eventProducerFunc func(connectionString string) (eventhubs.EventProducer, error)
+
+// publishSchemaChange publishes the schema applied to the `dbs` to the Event Hub of the executer
+// `eventHubConnectionStringRef`, nothing without one.
+func (r *ClusterExecuterReconciler) publishSchemaChange(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, dbs []string, checksum string) error {
+	ref := executer.Spec.EventHubConnectionStringRef
+	if ref == nil {
+		return nil
+	}
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	connectionString, err := readSecretKQL(ctx, reader, executer.Namespace, ref)
+	if err != nil {
+		return err
+	}
+	producer, err := r.eventProducer(connectionString)
+	if err != nil {
+		return err
+	}
+	appliedAt := time.Now()
+	if executer.Status.LastAppliedAt != nil {
+		appliedAt = executer.Status.LastAppliedAt.Time
+	}
+	return eventhubs.NewSchemaEventStream(producer).Publish(ctx, eventhubs.SchemaChangeEvent{
+		ClusterURI: executer.Spec.ClusterUri,
+		Databases:  dbs,
+		Diff:       executer.Status.LastAppliedDiff,
+		Checksum:   checksum,
+		AppliedAt:  appliedAt.UTC(),
+		AppliedBy:  client.ObjectKeyFromObject(executer).String(),
+	})
+}
+
+// eventProducer returns the producer of the `connectionString`, sending with the Event Hubs REST API unless replaced.
+func (r *ClusterExecuterReconciler) eventProducer(connectionString string) (eventhubs.EventProducer, error) {
+	if r.eventProducers != nil {
+		return r.eventProducers(connectionString)
+	}
+	return eventhubs.NewConnectionStringProducer(connectionString, nil)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+)
+
+// recordingProducer records the sent events, failing with `err` when set.
+type recordingProducer struct {
+	events [][]byte
+	err    error
+}
+
+func (p *recordingProducer) SendEvent(ctx context.Context, body []byte) error {
+	p.events = append(p.events, body)
+	return p.err
+}
+
+var _ = Describe("SchemaChangeEventStream", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "stream-0-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	const connectionString = "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0;EntityPath=schema"
+	var reconciler *ClusterExecuterReconciler
+	var recorder *record.FakeRecorder
+	var producer *recordingProducer
+
+	BeforeEach(func() {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "stream-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "schema-events", Namespace: key.Namespace},
+			Data:       map[string][]byte{"connectionString": []byte(connectionString)},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+				EventHubConnectionStringRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: secret.Name},
+					Key:                  "connectionString",
+				},
+			},
+		}
+		recorder = record.NewFakeRecorder(10)
+		producer = &recordingProducer{}
+		reconciler = &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, secret, executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("EventStreamTest"),
+			Scheme:   newFakeScheme(),
+			recorder: recorder,
+			eventProducers: func(connStr string) (eventhubs.EventProducer, error) {
+				Expect(connStr).To(Equal(connectionString))
+				return producer, nil
+			},
+		}
+	})
+	reconcile := func(cluster *scriptedCluster) {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("Should publish the applied schema change", func() {
+		reconcile(&scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}})
+		Expect(producer.events).To(HaveLen(1))
+
+		payload := map[string]interface{}{}
+		Expect(json.Unmarshal(producer.events[0], &payload)).To(Succeed())
+		Expect(payload).To(HaveKeyWithValue("clusterURI", uri))
+		Expect(payload).To(HaveKeyWithValue("databases", []interface{}{"db1", "db2"}))
+		Expect(payload).To(HaveKeyWithValue("appliedBy", key.String()))
+		Expect(payload).To(HaveKey("appliedAt"))
+		Expect(payload).To(HaveKey("checksum"))
+		Expect(payload).NotTo(HaveKey("diff"))
+
+		event := eventhubs.SchemaChangeEvent{}
+		Expect(json.Unmarshal(producer.events[0], &event)).To(Succeed())
+		Expect(event.AppliedAt.IsZero()).To(BeFalse())
+	})
+	It("Should not publish dry-run executions", func() {
+		reconcile(&scriptedCluster{
+			targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1"}},
+			config:  schemav1alpha1.ExecutionConfiguration{DryRunOutputDir: "/tmp/dry-run"},
+		})
+		Expect(producer.events).To(BeEmpty())
+	})
+	It("Should report a failed publish without failing the execution", func() {
+		producer.err = fmt.Errorf("event hub unavailable")
+		reconcile(&scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}})
+		Expect(producer.events).To(HaveLen(1))
+		recorded := []string{}
+		for len(recorder.Events) > 0 {
+			recorded = append(recorded, <-recorder.Events)
+		}
+		Expect(recorded).To(ContainElement(
+			"Warning PublishFailed failed publishing the schema change of cluster " + uri + ": event hub unavailable"))
+	})
+})
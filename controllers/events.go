@@ -0,0 +1,47 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// The reasons of the events recorded at the phase transitions of a schema execution.
+const (
+	// EventAcquiringTargets the target databases of the cluster were listed
+	EventAcquiringTargets = "AcquiringTargets"
+	// EventExecutingSchema the schema execution started
+	EventExecutingSchema = "ExecutingSchema"
+	// EventSchemaApplied the schema was applied to the target databases
+	EventSchemaApplied = "SchemaApplied"
+	// EventSchemaFailed the schema execution failed
+	EventSchemaFailed = "SchemaFailed"
+	// EventDriftDetected the target databases changed since the schema was applied
+	EventDriftDetected = "DriftDetected"
+	// EventRollbackTriggered the schema is rolled back after a failure
+	EventRollbackTriggered = "RollbackTriggered"
+	// EventCanaryFailed the canary database failed, the execution halted before the other databases
+	EventCanaryFailed = "CanaryFailed"
+	// EventParallelismReduced the resource quota has no room for all the parallel delta-kusto jobs of the execution
+	EventParallelismReduced = "ParallelismReduced"
+	// EventPublishFailed the applied schema change could not be published to the Event Hub
+	EventPublishFailed = "PublishFailed"
+	// EventDatabasesSkipped databases were skipped since they failed the execution pre-condition
+	EventDatabasesSkipped = "DatabasesSkipped"
+)
+
+// RecordEvent records a `Normal` event, or a `Warning` event when `isWarning` is set, on the `cr` object.
+// A nil `recorder` records nothing.
+func RecordEvent(recorder record.EventRecorder, cr runtime.Object, reason, message string, isWarning bool) {
+	if recorder == nil {
+		return
+	}
+	eventType := v1.EventTypeNormal
+	if isWarning {
+		eventType = v1.EventTypeWarning
+	}
+	recorder.Event(cr, eventType, reason, message)
+}
@@ -0,0 +1,189 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// scriptedCluster executes `config` on the `targets` databases, returning the `results` of the databases
+// and failing with `err` when set.
+type scriptedCluster struct {
+	targets schemav1alpha1.ClusterTargets
+	config  schemav1alpha1.ExecutionConfiguration
+	results map[string]schemav1alpha1.DBResultEnum
+	err     error
+}
+
+func (c *scriptedCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	return c.targets, nil
+}
+
+func (c *scriptedCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	targets.DBResults = c.results
+	return targets, c.err
+}
+
+func (c *scriptedCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	return c.config, nil
+}
+
+var _ = Describe("Execution events", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "events-0-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	var reconciler *ClusterExecuterReconciler
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "events-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+			},
+		}
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("EventsTest"),
+			Scheme:   newFakeScheme(),
+			recorder: recorder,
+		}
+	})
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+	reconcile := func(cluster *scriptedCluster) error {
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, getExecuter(), cluster)
+		return err
+	}
+	events := func() []string {
+		recorded := []string{}
+		for len(recorder.Events) > 0 {
+			recorded = append(recorded, <-recorder.Events)
+		}
+		return recorded
+	}
+
+	It("Should record the phases of a successful execution in order", func() {
+		Expect(reconcile(&scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}})).To(Succeed())
+		Expect(events()).To(Equal([]string{
+			"Normal AcquiringTargets acquired 2 target databases on cluster " + uri,
+			"Normal ExecutingSchema executing the schema on 2 databases of cluster " + uri,
+			"Normal SchemaApplied schema applied to 2 databases of cluster " + uri,
+		}))
+
+		// a new database appears after the schema was applied.
+		Expect(reconcile(&scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}}})).To(Succeed())
+		Expect(events()).To(Equal([]string{
+			"Normal AcquiringTargets acquired 3 target databases on cluster " + uri,
+			"Normal DriftDetected targets of cluster " + uri + " changed from 2 to 3 databases - re-running",
+			"Normal ExecutingSchema executing the schema on 1 databases of cluster " + uri,
+			"Normal SchemaApplied schema applied to 1 databases of cluster " + uri,
+		}))
+	})
+	It("Should record the failure and the rollback of an execution", func() {
+		cluster := &scriptedCluster{
+			targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}},
+			err:     kustoutils.ErrVerificationFailed{DBs: []string{"db2"}, RolledBack: true},
+		}
+		Expect(reconcile(cluster)).To(Succeed())
+		Expect(getExecuter().Status.Failed).To(BeTrue())
+		Expect(events()).To(Equal([]string{
+			"Normal AcquiringTargets acquired 2 target databases on cluster " + uri,
+			"Normal ExecutingSchema executing the schema on 2 databases of cluster " + uri,
+			"Warning SchemaFailed failed executing the schema on 2 databases of cluster " + uri + ": post apply verification failed on db2 - rolled back",
+			"Warning RollbackTriggered rolled back 1 databases of cluster " + uri + " failing the verification",
+		}))
+	})
+	It("Should not record a rollback for other failures", func() {
+		Expect(reconcile(&scriptedCluster{err: errors.New("delta-kusto failed")})).To(Succeed())
+		Expect(getExecuter().Status.Failed).To(BeTrue())
+		Expect(events()).To(Equal([]string{
+			"Normal AcquiringTargets acquired 0 target databases on cluster " + uri,
+			"Normal ExecutingSchema executing the schema on 0 databases of cluster " + uri,
+			"Warning SchemaFailed failed executing the schema on 0 databases of cluster " + uri + ": delta-kusto failed",
+		}))
+	})
+	It("Should halt the executer when the canary database failed", func() {
+		cluster := &scriptedCluster{
+			targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}},
+			err:     kustoutils.ErrCanaryFailed{DB: "db1", Err: errors.New("post apply verification failed on db1")},
+		}
+		Expect(reconcile(cluster)).To(Succeed())
+		executer := getExecuter()
+		Expect(executer.Status.CanaryFailed).To(BeTrue())
+		Expect(executer.Status.Conditions).To(ContainElement(HaveField("Reason", "CanaryFailed")))
+		Expect(events()).To(ContainElement(
+			"Warning CanaryFailed canary database db1 of cluster " + uri + " failed - the other databases were not touched",
+		))
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(events()).To(BeEmpty())
+
+		Expect(reconcile(&scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}})).To(Succeed())
+		Expect(getExecuter().Status.CanaryFailed).To(BeFalse())
+	})
+	It("Should not count the skipped databases as applied", func() {
+		dir, err := os.MkdirTemp("", "events-")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		kqlFile := filepath.Join(dir, "schema.kql")
+		Expect(os.WriteFile(kqlFile, []byte(".create-merge table T (a:string)"), 0600)).To(Succeed())
+		cluster := &scriptedCluster{
+			targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}},
+			config:  schemav1alpha1.ExecutionConfiguration{KQLFile: kqlFile},
+			results: map[string]schemav1alpha1.DBResultEnum{"db1": schemav1alpha1.DBResultExecuted, "db2": schemav1alpha1.DBResultSkipped, "db3": schemav1alpha1.DBResultUpToDate},
+		}
+		Expect(reconcile(cluster)).To(Succeed())
+		executer := getExecuter()
+		Expect(executer.Status.Executed).To(BeTrue())
+		Expect(executer.Status.SkippedTargets.DBs).To(Equal([]string{"db2"}))
+		Expect(executer.Status.DoneTargets.DBs).To(Equal([]string{"db1", "db3"}))
+		Expect(executer.Status.AppliedChecksum).To(BeEmpty())
+		Expect(events()).To(Equal([]string{
+			"Normal AcquiringTargets acquired 3 target databases on cluster " + uri,
+			"Normal ExecutingSchema executing the schema on 3 databases of cluster " + uri,
+			"Normal SchemaApplied schema applied to 2 databases of cluster " + uri,
+			"Normal DatabasesSkipped skipped 1 databases of cluster " + uri + " failing the pre-condition: db2",
+		}))
+
+		// the skipped database is retried once it passes the pre-condition.
+		cluster.results = nil
+		Expect(reconcile(cluster)).To(Succeed())
+		executer = getExecuter()
+		Expect(executer.Status.SkippedTargets.DBs).To(BeEmpty())
+		Expect(executer.Status.DoneTargets.DBs).To(ConsistOf("db1", "db2", "db3"))
+		Expect(executer.Status.AppliedChecksum).NotTo(BeEmpty())
+		Expect(events()).To(ContainElement("Normal ExecutingSchema executing the schema on 1 databases of cluster " + uri))
+	})
+	It("Should ignore a missing recorder", func() {
+		RecordEvent(nil, &schemav1alpha1.ClusterExecuter{}, EventSchemaApplied, "schema applied", false)
+	})
+})
@@ -0,0 +1,56 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+const (
+	// ClusterURIField indexes the schema deployments by the normalized `applyTo.clusterUris`.
+	ClusterURIField = "spec.applyTo.clusterUris"
+	// DatabaseField indexes the schema deployments by their `applyTo.db` filter.
+	DatabaseField = "spec.applyTo.db"
+)
+
+// normalizeClusterURI lowercases the cluster URI and trims its trailing slash.
+func normalizeClusterURI(uri string) string {
+	return strings.ToLower(strings.TrimSuffix(uri, "/"))
+}
+
+// SetupFieldIndexes registers the schema deployment field indexes with the manager cache,
+// so the schema deployments of a cluster are found without listing all of them.
+func SetupFieldIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	err := indexer.IndexField(ctx, &schemav1alpha1.SchemaDeployment{}, ClusterURIField, func(obj client.Object) []string {
+		template := obj.(*schemav1alpha1.SchemaDeployment)
+		uris := make([]string, 0, len(template.Spec.ApplyTo.ClusterUris))
+		for _, uri := range template.Spec.ApplyTo.ClusterUris {
+			uris = append(uris, normalizeClusterURI(uri))
+		}
+		return uris
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &schemav1alpha1.SchemaDeployment{}, DatabaseField, func(obj client.Object) []string {
+		if db := obj.(*schemav1alpha1.SchemaDeployment).Spec.ApplyTo.DB; db != "" {
+			return []string{db}
+		}
+		return nil
+	})
+}
+
+// GetCRsForCluster returns the schema deployments applied to the cluster, looked up with the `ClusterURIField` index.
+func GetCRsForCluster(ctx context.Context, reader client.Reader, clusterURI string) ([]schemav1alpha1.SchemaDeployment, error) {
+	templates := &schemav1alpha1.SchemaDeploymentList{}
+	err := reader.List(ctx, templates, client.MatchingFields{ClusterURIField: normalizeClusterURI(clusterURI)})
+	if err != nil {
+		return nil, err
+	}
+	return templates.Items, nil
+}
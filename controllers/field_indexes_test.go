@@ -0,0 +1,104 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// indexedReader serves the field selector lists from the registered indexes like the manager cache,
+// recording the looked up `field=value`s. Lists without a field selector fail.
+type indexedReader struct {
+	client.Reader
+	indexer cache.Indexer
+	lookups []string
+}
+
+func newIndexedReader() *indexedReader {
+	return &indexedReader{indexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})}
+}
+
+func (r *indexedReader) IndexField(ctx context.Context, obj client.Object, field string, extract client.IndexerFunc) error {
+	return r.indexer.AddIndexers(cache.Indexers{field: func(obj interface{}) ([]string, error) {
+		return extract(obj.(client.Object)), nil
+	}})
+}
+
+func (r *indexedReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil || len(listOpts.FieldSelector.Requirements()) != 1 {
+		return fmt.Errorf("unindexed list")
+	}
+	requirement := listOpts.FieldSelector.Requirements()[0]
+	r.lookups = append(r.lookups, requirement.Field+"="+requirement.Value)
+	found, err := r.indexer.ByIndex(requirement.Field, requirement.Value)
+	if err != nil {
+		return err
+	}
+	items := make([]runtime.Object, 0, len(found))
+	for _, obj := range found {
+		items = append(items, obj.(runtime.Object).DeepCopyObject())
+	}
+	return meta.SetList(list, items)
+}
+
+var _ = Describe("SchemaDeploymentFieldIndexes", func() {
+	ctx := context.Background()
+	const clusterA = "https://a.westeurope.kusto.windows.net"
+	const clusterB = "https://b.westeurope.kusto.windows.net"
+	var reader *indexedReader
+
+	BeforeEach(func() {
+		reader = newIndexedReader()
+		Expect(SetupFieldIndexes(ctx, reader)).To(Succeed())
+		for i := 0; i < 100; i++ {
+			uri := clusterA
+			if i%4 == 0 {
+				uri = clusterB
+			}
+			Expect(reader.indexer.Add(&schemav1alpha1.SchemaDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("schema-%d", i), Namespace: "default"},
+				Spec: schemav1alpha1.SchemaDeploymentSpec{
+					ApplyTo: schemav1alpha1.TargetFilter{ClusterUris: []string{uri}, DB: fmt.Sprintf("db%d", i%2)},
+				},
+			})).To(Succeed())
+		}
+	})
+
+	It("Should return only the schema deployments of the cluster", func() {
+		templates, err := GetCRsForCluster(ctx, reader, clusterB)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(templates).To(HaveLen(25))
+		for _, template := range templates {
+			Expect(template.Spec.ApplyTo.ClusterUris).To(ConsistOf(clusterB))
+		}
+		Expect(reader.lookups).To(Equal([]string{ClusterURIField + "=" + clusterB}))
+
+		templates, err = GetCRsForCluster(ctx, reader, clusterA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(templates).To(HaveLen(75))
+	})
+	It("Should ignore the case and the trailing slash of the cluster URI", func() {
+		templates, err := GetCRsForCluster(ctx, reader, "HTTPS://B.westeurope.kusto.windows.net/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(templates).To(HaveLen(25))
+	})
+	It("Should index the database filter", func() {
+		found, err := reader.indexer.ByIndex(DatabaseField, "db1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(HaveLen(50))
+	})
+})
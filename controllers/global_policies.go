@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+)
+
+// policyRecheckInterval is the time after which an execution blocked by a `Deny` schema rule checks the policies again.
+const policyRecheckInterval = time.Minute
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=globalschemapolicies,verbs=get;list;watch
+
+// checkGlobalPolicies runs the rules of the active global schema policies on the kql of `config` before it is executed.
+// Violated `Warn` rules are reported as events, violated `Deny` rules set the `PolicyViolation` condition and block the execution.
+func (r *ClusterExecuterReconciler) checkGlobalPolicies(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (bool, error) {
+	checker, ok := cluster.(clusterUtils.PolicyChecker)
+	if !r.GlobalPolicies || !ok {
+		return false, nil
+	}
+	policies := &schemav1alpha1.GlobalSchemaPolicyList{}
+	if err := r.List(ctx, policies); err != nil {
+		return false, err
+	}
+	rules := []schemav1alpha1.SchemaRule{}
+	for _, policy := range policies.Items {
+		if !policy.Spec.Suspend {
+			rules = append(rules, policy.Spec.Rules...)
+		}
+	}
+	violations, err := checker.CheckSchemaRules(ctx, targets, config, rules)
+	if err != nil {
+		return false, err
+	}
+	denied := []string{}
+	for _, violation := range violations {
+		if violation.Rule.Enforcement == schemav1alpha1.EnforcementDeny {
+			denied = append(denied, violation.Message)
+			continue
+		}
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "PolicyWarning", "schema rule %s: %s", violation.Rule.Name, violation.Message)
+	}
+	if len(denied) == 0 {
+		// the status is updated when the execution starts.
+		meta.RemoveStatusCondition(&executer.Status.Conditions, schemav1alpha1.ConditionPolicyViolation)
+		return false, nil
+	}
+	message := strings.Join(denied, "; ")
+	r.Log.Info("schema violates the global schema policies - execution blocked", "executer", executer.Name, "violations", message)
+	r.recorder.Eventf(executer, v1.EventTypeWarning, "PolicyViolation", "execution blocked: %s", message)
+	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+		Type:               schemav1alpha1.ConditionPolicyViolation,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Denied",
+		Message:            message,
+		ObservedGeneration: executer.Generation,
+	})
+	return true, r.Status().Update(ctx, executer)
+}
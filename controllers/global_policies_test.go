@@ -0,0 +1,118 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// policedCluster is an observing cluster applying `kql`, its rule queries are regular expressions matching the violating kql.
+type policedCluster struct {
+	observingCluster
+	kql string
+}
+
+func (c *policedCluster) CheckSchemaRules(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, rules []schemav1alpha1.SchemaRule) ([]kustoutils.RuleViolation, error) {
+	violations := []kustoutils.RuleViolation{}
+	for _, rule := range rules {
+		if regexp.MustCompile(rule.Query).MatchString(c.kql) {
+			violations = append(violations, kustoutils.RuleViolation{Rule: rule, Message: rule.Message})
+		}
+	}
+	return violations, nil
+}
+
+var _ = Describe("GlobalSchemaPolicy", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "policy-0-cluster1", Namespace: "default"}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+	unsafeColumns := &schemav1alpha1.GlobalSchemaPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "unsafe-columns"},
+		Spec: schemav1alpha1.GlobalSchemaPolicySpec{Rules: []schemav1alpha1.SchemaRule{{
+			Name:        "no-password-columns",
+			Query:       `\bpassword\s*:`,
+			Enforcement: schemav1alpha1.EnforcementDeny,
+			Message:     "columns must not be named password",
+		}}},
+	}
+	var reconciler *ClusterExecuterReconciler
+	var recorder *record.FakeRecorder
+
+	newReconciler := func(objs ...client.Object) {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Generation: 1},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://cluster1.westeurope.kusto.windows.net"},
+		}
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &ClusterExecuterReconciler{
+			Client:         fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(append(objs, executer)...).Build(),
+			Log:            ctrl.Log.WithName("controllers").WithName("GlobalSchemaPolicyTest"),
+			Scheme:         newFakeScheme(),
+			recorder:       recorder,
+			GlobalPolicies: true,
+		}
+	}
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should block an execution violating a Deny rule", func() {
+		newReconciler(unsafeColumns.DeepCopy())
+		cluster := &policedCluster{kql: ".create-merge table Users (Name:string, password:string)"}
+		blocked, err := reconciler.checkGlobalPolicies(ctx, cluster, getExecuter(), targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocked).To(BeTrue())
+		cond := meta.FindStatusCondition(getExecuter().Status.Conditions, schemav1alpha1.ConditionPolicyViolation)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(Equal("columns must not be named password"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("execution blocked")))
+
+		executer := getExecuter()
+		cluster.kql = ".create-merge table Users (Name:string, passwordHash:string)"
+		blocked, err = reconciler.checkGlobalPolicies(ctx, cluster, executer, targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocked).To(BeFalse())
+		Expect(meta.FindStatusCondition(executer.Status.Conditions, schemav1alpha1.ConditionPolicyViolation)).To(BeNil())
+	})
+	It("Should only warn about Warn rules", func() {
+		warning := unsafeColumns.DeepCopy()
+		warning.Spec.Rules[0].Enforcement = schemav1alpha1.EnforcementWarn
+		newReconciler(warning)
+		cluster := &policedCluster{kql: ".create-merge table Users (password:string)"}
+		blocked, err := reconciler.checkGlobalPolicies(ctx, cluster, getExecuter(), targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocked).To(BeFalse())
+		Expect(recorder.Events).To(Receive(ContainSubstring("no-password-columns")))
+	})
+	It("Should skip suspended policies and clusters without rule support", func() {
+		suspended := unsafeColumns.DeepCopy()
+		suspended.Spec.Suspend = true
+		newReconciler(suspended)
+		blocked, err := reconciler.checkGlobalPolicies(ctx, &policedCluster{kql: "password:string"}, getExecuter(), targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocked).To(BeFalse())
+
+		newReconciler(unsafeColumns.DeepCopy())
+		blocked, err = reconciler.checkGlobalPolicies(ctx, &observingCluster{}, getExecuter(), targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocked).To(BeFalse())
+	})
+})
@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/go-logr/logr"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// JobArtifactReconciler garbage collects the expired delta-kusto job artifact config maps
+type JobArtifactReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+}
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;delete
+
+// Reconcile deletes the job artifact once its expires-at annotation passed, and otherwise requeues it until then.
+// Artifacts without a valid expiry are kept.
+func (r *JobArtifactReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "JobArtifact/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("JobArtifact", req.NamespacedName)
+
+	cfgMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, req.NamespacedName, cfgMap)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cfgMap.Labels[kustoutils.JobArtifactLabel] != "true" {
+		return ctrl.Result{}, nil
+	}
+
+	expiresAt, ok := kustoutils.ArtifactExpiresAt(cfgMap)
+	if !ok {
+		log.Info("job artifact has no valid expiry - keeping it", "expiresAt", cfgMap.Annotations[kustoutils.ExpiresAtAnnotation])
+		return ctrl.Result{}, nil
+	}
+	if remaining := expiresAt.Sub(time.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("job artifact expired - deleting", "expiresAt", expiresAt)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, cfgMap))
+}
+
+// jobArtifactConfigMap filters the watched config maps to the job artifacts.
+var jobArtifactConfigMap = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetLabels()[kustoutils.JobArtifactLabel] == "true"
+})
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *JobArtifactReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("jobartifact").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(jobArtifactConfigMap)).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(r.Health.Wrap(r))
+}
@@ -0,0 +1,139 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// jobFileCluster is a scripted cluster whose configuration runs the `jobFile`, preserving it when `preserve` is set.
+type jobFileCluster struct {
+	scriptedCluster
+	jobFile  string
+	preserve bool
+}
+
+func (c *jobFileCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	return schemav1alpha1.ExecutionConfiguration{JobFile: c.jobFile, PreserveJobArtifact: c.preserve}, nil
+}
+
+var _ = Describe("JobArtifact", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "audit-1-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	const job = "jobs:\n  main:\n    target:\n      adx:\n        clusterUri: https://cluster1.westeurope.kusto.windows.net\n"
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	var c client.Client
+	var dir, jobFile string
+
+	BeforeEach(func() {
+		var err error
+		// GinkgoT().TempDir() returns "" in ginkgo v1, which would write the job file into the package directory
+		dir, err = os.MkdirTemp("", "jobartifact-")
+		Expect(err).NotTo(HaveOccurred())
+		jobFile = filepath.Join(dir, "job-1.yaml")
+		Expect(os.WriteFile(jobFile, []byte(job), 0o600)).To(Succeed())
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "audit-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer).Build()
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	execute := func(cluster *jobFileCluster) {
+		reconciler := &ClusterExecuterReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("JobArtifactTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	listArtifacts := func() []v1.ConfigMap {
+		cfgMaps := &v1.ConfigMapList{}
+		Expect(c.List(ctx, cfgMaps, client.MatchingLabels{kustoutils.JobArtifactLabel: "true"})).To(Succeed())
+		return cfgMaps.Items
+	}
+
+	It("Should store the job file when the artifact is preserved", func() {
+		execute(&jobFileCluster{scriptedCluster: scriptedCluster{targets: targets}, jobFile: jobFile, preserve: true})
+
+		artifacts := listArtifacts()
+		Expect(artifacts).To(HaveLen(1))
+		artifact := artifacts[0]
+		Expect(artifact.Name).To(HavePrefix("schema-job-"))
+		Expect(artifact.Namespace).To(Equal(key.Namespace))
+		Expect(artifact.Data).To(Equal(map[string]string{kustoutils.JobArtifactKey: job}))
+		Expect(artifact.Labels).To(HaveKeyWithValue(kustoutils.ClusterLabel, kustoutils.ClusterNameFromURI(uri)))
+		Expect(artifact.Labels).To(HaveKey(kustoutils.JobTimestampLabel))
+		Expect(artifact.Annotations).To(HaveKeyWithValue(kustoutils.JobDatabasesAnnotation, "db1,db2"))
+		expiresAt, ok := kustoutils.ArtifactExpiresAt(&artifact)
+		Expect(ok).To(BeTrue())
+		Expect(expiresAt).To(BeTemporally("~", time.Now().Add(kustoutils.DefaultArtifactRetention), time.Minute))
+	})
+	It("Should not store the job file otherwise", func() {
+		execute(&jobFileCluster{scriptedCluster: scriptedCluster{targets: targets}, jobFile: jobFile})
+		Expect(listArtifacts()).To(BeEmpty())
+	})
+	It("Should garbage collect the expired artifacts", func() {
+		artifact := func(name string, expiresAt time.Time) *v1.ConfigMap {
+			return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{kustoutils.JobArtifactLabel: "true"},
+				Annotations: map[string]string{kustoutils.ExpiresAtAnnotation: expiresAt.UTC().Format(time.RFC3339)},
+			}}
+		}
+		expired := artifact("schema-job-expired", time.Now().Add(-time.Minute))
+		fresh := artifact("schema-job-fresh", time.Now().Add(time.Hour))
+		Expect(c.Create(ctx, expired)).To(Succeed())
+		Expect(c.Create(ctx, fresh)).To(Succeed())
+
+		gc := &JobArtifactReconciler{
+			Client: c,
+			Log:    ctrl.Log.WithName("controllers").WithName("JobArtifactGCTest"),
+			Scheme: newFakeScheme(),
+		}
+		res, err := gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(expired)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		err = c.Get(ctx, client.ObjectKeyFromObject(expired), &v1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		res, err = gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(fresh)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(fresh), &v1.ConfigMap{})).To(Succeed())
+	})
+})
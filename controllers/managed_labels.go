@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// managedLabels is the label requirement of the operator config, shared by the schema deployment reconcilers.
+var managedLabels = &labelRequirement{}
+
+// labelRequirement holds the `requiredLabels` of the operator config.
+// Without required labels every resource is reconciled.
+type labelRequirement struct {
+	mu     sync.RWMutex
+	labels predicate.Predicate
+}
+
+// orManaged returns the requirement, or the shared `managedLabels` when nil.
+func (l *labelRequirement) orManaged() *labelRequirement {
+	if l != nil {
+		return l
+	}
+	return managedLabels
+}
+
+// setLabels replaces the required labels, no labels reconcile every resource.
+func (l *labelRequirement) setLabels(required map[string]string) error {
+	var labels predicate.Predicate
+	if len(required) > 0 {
+		var err error
+		labels, err = predicate.LabelSelectorPredicate(metav1.LabelSelector{MatchLabels: required})
+		if err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.labels = labels
+	return nil
+}
+
+// current returns the predicate of the required labels, nil when none are required.
+func (l *labelRequirement) current() predicate.Predicate {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.labels
+}
+
+// selects returns true when `obj` holds the required labels.
+func (l *labelRequirement) selects(obj client.Object) bool {
+	labels := l.current()
+	return labels == nil || labels.Generic(event.GenericEvent{Object: obj})
+}
+
+// filter passes the events of the objects holding the required labels at the time of the event.
+func (l *labelRequirement) filter() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			labels := l.current()
+			return labels == nil || labels.Create(e)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			labels := l.current()
+			return labels == nil || labels.Update(e)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			labels := l.current()
+			return labels == nil || labels.Delete(e)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			labels := l.current()
+			return labels == nil || labels.Generic(e)
+		},
+	}
+}
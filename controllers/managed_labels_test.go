@@ -0,0 +1,113 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("RequiredLabels", func() {
+	ctx := context.Background()
+	managed := map[string]string{schemav1alpha1.ManagedLabel: "true"}
+
+	deployment := func(name string, labels map[string]string) *schemav1alpha1.SchemaDeployment {
+		return &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type:   schemav1alpha1.DBTypeKusto,
+				Source: schemav1alpha1.NamespacedName{Name: "shared-kql", Namespace: "default"},
+			},
+		}
+	}
+
+	It("should only pass the events of labeled schema deployments", func() {
+		required := &labelRequirement{}
+		Expect(required.setLabels(managed)).To(Succeed())
+		filter := required.filter()
+		labeled := deployment("labeled", managed)
+		unlabeled := deployment("unlabeled", nil)
+		other := deployment("other", map[string]string{schemav1alpha1.ManagedLabel: "false"})
+
+		Expect(filter.Create(event.CreateEvent{Object: labeled})).To(BeTrue())
+		Expect(filter.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: labeled})).To(BeTrue())
+		Expect(filter.Delete(event.DeleteEvent{Object: labeled})).To(BeTrue())
+		for _, obj := range []*schemav1alpha1.SchemaDeployment{unlabeled, other} {
+			Expect(filter.Create(event.CreateEvent{Object: obj})).To(BeFalse())
+			Expect(filter.Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj})).To(BeFalse())
+			Expect(filter.Delete(event.DeleteEvent{Object: obj})).To(BeFalse())
+			Expect(filter.Generic(event.GenericEvent{Object: obj})).To(BeFalse())
+		}
+	})
+	It("should pass every event without required labels", func() {
+		required := &labelRequirement{}
+		Expect(required.filter().Create(event.CreateEvent{Object: deployment("unlabeled", nil)})).To(BeTrue())
+
+		Expect(required.setLabels(managed)).To(Succeed())
+		Expect(required.setLabels(nil)).To(Succeed())
+		Expect(required.filter().Create(event.CreateEvent{Object: deployment("unlabeled", nil)})).To(BeTrue())
+	})
+	It("should not enqueue unlabeled schema deployments of a changed config map", func() {
+		Expect(managedLabels.setLabels(managed)).To(Succeed())
+		defer func() { Expect(managedLabels.setLabels(nil)).To(Succeed()) }()
+		reconciler := &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(
+				deployment("labeled", managed),
+				deployment("unlabeled", nil),
+			).Build(),
+			Log: ctrl.Log.WithName("controllers").WithName("RequiredLabelsTest"),
+		}
+		cfgMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared-kql", Namespace: "default"}}
+		Expect(reconciler.schemaDeploymentsForConfigMap(cfgMap)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "labeled", Namespace: "default"}},
+		))
+	})
+	It("should set the required labels of the operator config", func() {
+		operatorConfig := &schemav1alpha1.SchemaOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: schemav1alpha1.SchemaOperatorConfigName},
+			Spec:       schemav1alpha1.SchemaOperatorConfigSpec{RequiredLabels: managed},
+		}
+		required := &labelRequirement{}
+		reconciler := &SchemaOperatorConfigReconciler{
+			Client:    fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(operatorConfig).Build(),
+			Log:       ctrl.Log.WithName("controllers").WithName("RequiredLabelsTest"),
+			Scheme:    newFakeScheme(),
+			selection: &namespaceSelection{},
+			required:  required,
+		}
+		key := types.NamespacedName{Name: operatorConfig.Name}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(required.selects(deployment("labeled", managed))).To(BeTrue())
+		Expect(required.selects(deployment("unlabeled", nil))).To(BeFalse())
+
+		updated := &schemav1alpha1.SchemaOperatorConfig{}
+		Expect(reconciler.Get(ctx, key, updated)).To(Succeed())
+		updated.Spec.RequiredLabels = map[string]string{"not a label key!": "true"}
+		Expect(reconciler.Update(ctx, updated)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, key, updated)).To(Succeed())
+		ready := meta.FindStatusCondition(updated.Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal("InvalidRequiredLabels"))
+
+		Expect(reconciler.Delete(ctx, updated)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(required.selects(deployment("unlabeled", nil))).To(BeTrue())
+	})
+})
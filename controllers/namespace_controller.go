@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// NamespaceReconciler re-evaluates the `namespaceLabelSelector` of the operator config when the labels of a namespace change
+type NamespaceReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// selection is the namespace selection updated by the reconciler, the shared `managedNamespaces` when nil.
+	selection *namespaceSelection
+}
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Reconcile adds the namespace to the reconciled namespaces when its labels match the selector, and removes it once they
+// no longer match or the namespace is deleted.
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "Namespace/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("Namespace", req.Name)
+
+	var namespace *corev1.Namespace
+	found := &corev1.Namespace{}
+	err := r.Get(ctx, req.NamespacedName, found)
+	if err == nil {
+		namespace = found
+	} else if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	selected, changed := r.selection.orManaged().evaluate(req.Name, namespace)
+	if changed && selected {
+		log.Info("namespace matches the namespace selector - reconciling its schema resources")
+	} else if changed {
+		log.Info("namespace no longer matches the namespace selector - ignoring its schema resources")
+	}
+	return ctrl.Result{}, nil
+}
+
+// LoadNamespaceSelector selects the namespaces matching the `namespaceLabelSelector` of the `default` operator config,
+// and sets its `requiredLabels`, before the reconcilers start. Without the config every namespace is reconciled.
+func LoadNamespaceSelector(ctx context.Context, reader client.Reader) error {
+	operatorConfig := &schemav1alpha1.SchemaOperatorConfig{}
+	err := reader.Get(ctx, types.NamespacedName{Name: schemav1alpha1.SchemaOperatorConfigName}, operatorConfig)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if err = managedLabels.setLabels(operatorConfig.Spec.RequiredLabels); err != nil {
+		return err
+	}
+	return managedNamespaces.setSelector(ctx, reader, operatorConfig.Spec.NamespaceLabelSelector)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		WithEventFilter(predicate.LabelChangedPredicate{}).
+		Complete(r.Health.Wrap(r))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// managedNamespaces is the namespace selection of the operator config, shared by all the reconcilers.
+var managedNamespaces = &namespaceSelection{}
+
+// watchedNamespaces filters the events to the objects in `namespaces`, an empty list passes all the events.
+// The manager cache is restricted to the same namespaces - the filter guards reconcilers sharing a wider cache.
+// Objects of namespaces outside the `namespaceLabelSelector` of the operator config are filtered as well.
+func watchedNamespaces(namespaces []string) predicate.Predicate {
+	return managedNamespaces.filter(namespaces)
+}
+
+// namespaceSelection holds the namespaces matching the `namespaceLabelSelector` of the operator config.
+// Without a selector every namespace is selected.
+type namespaceSelection struct {
+	mu       sync.RWMutex
+	selector labels.Selector
+	selected map[string]bool
+}
+
+// orManaged returns the selection, or the shared `managedNamespaces` when nil.
+func (s *namespaceSelection) orManaged() *namespaceSelection {
+	if s != nil {
+		return s
+	}
+	return managedNamespaces
+}
+
+// selects returns true when the resources of `namespace` are reconciled.
+func (s *namespaceSelection) selects(namespace string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.selector == nil || s.selected[namespace]
+}
+
+// setSelector replaces the selector and lists the namespaces matching it, a nil selector selects every namespace.
+func (s *namespaceSelection) setSelector(ctx context.Context, reader client.Reader, labelSelector *metav1.LabelSelector) error {
+	if labelSelector == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.selector, s.selected = nil, nil
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return err
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err = reader.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return err
+	}
+	selected := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		selected[ns.Name] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selector, s.selected = selector, selected
+	return nil
+}
+
+// evaluate matches the labels of `namespace` against the selector, a nil namespace (deleted) is removed from the selection.
+// It returns whether the namespace is selected and whether it joined or left the selection.
+func (s *namespaceSelection) evaluate(name string, namespace *corev1.Namespace) (selected bool, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.selector == nil {
+		return true, false
+	}
+	selected = namespace != nil && s.selector.Matches(labels.Set(namespace.Labels))
+	changed = selected != s.selected[name]
+	if selected {
+		s.selected[name] = true
+	} else {
+		delete(s.selected, name)
+	}
+	return selected, changed
+}
+
+// filter passes the events of objects in `namespaces` (all when empty) which are also selected.
+func (s *namespaceSelection) filter(namespaces []string) predicate.Predicate {
+	watched := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		watched[ns] = true
+	}
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return (len(watched) == 0 || watched[obj.GetNamespace()]) && s.selects(obj.GetNamespace())
+	})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+func namespacedDeployment(namespace string) *schemav1alpha1.SchemaDeployment {
+	return &schemav1alpha1.SchemaDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "schema", Namespace: namespace},
+	}
+}
+
+var _ = Describe("WatchedNamespaces", func() {
+	It("should not reconcile resources of unlisted namespaces", func() {
+		filter := watchedNamespaces([]string{"team-a", "team-b"})
+		other := namespacedDeployment("team-c")
+		Expect(filter.Create(event.CreateEvent{Object: other})).To(BeFalse())
+		Expect(filter.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: other})).To(BeFalse())
+		Expect(filter.Delete(event.DeleteEvent{Object: other})).To(BeFalse())
+		Expect(filter.Generic(event.GenericEvent{Object: other})).To(BeFalse())
+
+		Expect(filter.Create(event.CreateEvent{Object: namespacedDeployment("team-b")})).To(BeTrue())
+	})
+	It("should reconcile all namespaces when none are listed", func() {
+		filter := watchedNamespaces(nil)
+		Expect(filter.Create(event.CreateEvent{Object: namespacedDeployment("team-c")})).To(BeTrue())
+	})
+})
+
+var _ = Describe("NamespaceSelection", func() {
+	ctx := context.Background()
+	var c client.Client
+	var selection *namespaceSelection
+	var reconciler *NamespaceReconciler
+
+	namespace := func(name string, enabled bool) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if enabled {
+			ns.Labels = map[string]string{"schema-operator-enabled": "true"}
+		}
+		return ns
+	}
+	reconcile := func(name string) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(namespace("team-a", true), namespace("team-b", false)).Build()
+		selection = &namespaceSelection{}
+		reconciler = &NamespaceReconciler{
+			Client:    c,
+			Log:       ctrl.Log.WithName("controllers").WithName("NamespaceTest"),
+			Scheme:    newFakeScheme(),
+			selection: selection,
+		}
+		selector := &metav1.LabelSelector{MatchLabels: map[string]string{"schema-operator-enabled": "true"}}
+		Expect(selection.setSelector(ctx, c, selector)).To(Succeed())
+	})
+
+	It("should only reconcile the namespaces matching the selector", func() {
+		filter := selection.filter(nil)
+		Expect(filter.Create(event.CreateEvent{Object: namespacedDeployment("team-a")})).To(BeTrue())
+		Expect(filter.Create(event.CreateEvent{Object: namespacedDeployment("team-b")})).To(BeFalse())
+		Expect(selection.filter([]string{"team-b"}).Create(event.CreateEvent{Object: namespacedDeployment("team-a")})).To(BeFalse())
+	})
+	It("should add a namespace once it is labeled", func() {
+		Expect(c.Update(ctx, namespace("team-b", true))).To(Succeed())
+		reconcile("team-b")
+		Expect(selection.selects("team-b")).To(BeTrue())
+		Expect(selection.filter(nil).Create(event.CreateEvent{Object: namespacedDeployment("team-b")})).To(BeTrue())
+	})
+	It("should remove a namespace once it is unlabeled or deleted", func() {
+		Expect(c.Update(ctx, namespace("team-a", false))).To(Succeed())
+		reconcile("team-a")
+		Expect(selection.selects("team-a")).To(BeFalse())
+
+		Expect(c.Update(ctx, namespace("team-a", true))).To(Succeed())
+		reconcile("team-a")
+		Expect(selection.selects("team-a")).To(BeTrue())
+		Expect(c.Delete(ctx, namespace("team-a", true))).To(Succeed())
+		reconcile("team-a")
+		Expect(selection.selects("team-a")).To(BeFalse())
+	})
+	It("should select every namespace without a selector", func() {
+		Expect(selection.setSelector(ctx, c, nil)).To(Succeed())
+		reconcile("team-b")
+		Expect(selection.selects("team-b")).To(BeTrue())
+		Expect(selection.selects("team-c")).To(BeTrue())
+	})
+	It("should load the selector of the operator config", func() {
+		operatorConfig := &schemav1alpha1.SchemaOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: schemav1alpha1.SchemaOperatorConfigName},
+			Spec: schemav1alpha1.SchemaOperatorConfigSpec{
+				NamespaceLabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"schema-operator-enabled": "true"}},
+			},
+		}
+		Expect(c.Create(ctx, operatorConfig)).To(Succeed())
+		Expect(LoadNamespaceSelector(ctx, c)).To(Succeed())
+		defer func() { Expect(managedNamespaces.setSelector(ctx, c, nil)).To(Succeed()) }()
+		Expect(managedNamespaces.selects("team-a")).To(BeTrue())
+		Expect(managedNamespaces.selects("team-b")).To(BeFalse())
+	})
+})
@@ -0,0 +1,117 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// observingCluster is a cluster computing a fixed diff, it fails any execution.
+type observingCluster struct {
+	diff     kustoutils.SchemaDiff
+	observed int
+	dryRun   string
+	filter   schemav1alpha1.TargetFilter
+}
+
+func (c *observingCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	c.filter = filter
+	return schemav1alpha1.ClusterTargets{DBs: filter.DBS}, nil
+}
+
+func (c *observingCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	return schemav1alpha1.ClusterTargets{}, fmt.Errorf("execute called in observation mode")
+}
+
+func (c *observingCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	c.dryRun = cfgMap.Data[kustoutils.DryRunKey]
+	return schemav1alpha1.ExecutionConfiguration{DryRunOutputDir: "/tmp/observed"}, nil
+}
+
+func (c *observingCluster) ObserveSchema(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (kustoutils.SchemaDiff, error) {
+	c.observed++
+	return c.diff, nil
+}
+
+var _ = Describe("ClusterExecuterObservation", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "observe-0-cluster1", Namespace: "default"}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	var reconciler *ClusterExecuterReconciler
+	var cluster *observingCluster
+
+	BeforeEach(func() {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Generation: 1},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ObservationMode: true,
+				ConfigMapName:   schemav1alpha1.NamespacedName{Name: "observe-kql", Namespace: key.Namespace},
+			},
+		}
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "observe-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		reconciler = &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer, cfgMap).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ObservationTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		cluster = &observingCluster{diff: kustoutils.SchemaDiff{
+			Databases: []kustoutils.DatabaseDiff{{Database: "db1", Delta: ".create table T (a:string)"}},
+		}}
+	})
+
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should store the pending diff without executing", func() {
+		Expect(reconciler.observe(ctx, cluster, getExecuter(), targets)).To(Succeed())
+		Expect(cluster.dryRun).To(Equal("true"))
+
+		found := getExecuter()
+		Expect(found.Status.PendingDiff).To(Equal(map[string]string{"db1": ".create table T (a:string)"}))
+		Expect(found.Status.Executed).To(BeFalse())
+		cond := meta.FindStatusCondition(found.Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(cond.Reason).To(Equal(observationReason))
+	})
+	It("Should not observe an unchanged executer twice", func() {
+		Expect(reconciler.observe(ctx, cluster, getExecuter(), targets)).To(Succeed())
+		Expect(reconciler.observe(ctx, cluster, getExecuter(), targets)).To(Succeed())
+		Expect(cluster.observed).To(Equal(1))
+
+		Expect(reconciler.observe(ctx, cluster, getExecuter(), schemav1alpha1.ClusterTargets{DBs: []string{"db1"}})).To(Succeed())
+		Expect(cluster.observed).To(Equal(2))
+	})
+	It("Should not create the listed databases", func() {
+		executer := getExecuter()
+		executer.Spec.ApplyTo = schemav1alpha1.TargetFilter{DBS: []string{"db1"}, AutoCreateDatabases: true}
+		Expect(reconciler.Update(ctx, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, getExecuter(), cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.filter.DBS).To(Equal([]string{"db1"}))
+		Expect(cluster.filter.AutoCreateDatabases).To(BeFalse())
+		Expect(cluster.observed).To(Equal(1))
+	})
+})
@@ -0,0 +1,145 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	kustofake "github.com/microsoft/azure-schema-operator/pkg/kustoutils/fake"
+)
+
+// concurrencyPlugin records the peak number of delta-kusto jobs running at once, it aborts every job
+// so delta-kusto itself never runs.
+type concurrencyPlugin struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	jobs    int
+}
+
+func (p *concurrencyPlugin) PreProcess(jobFilePath string) error {
+	p.mu.Lock()
+	p.running++
+	p.jobs++
+	if p.running > p.peak {
+		p.peak = p.running
+	}
+	p.mu.Unlock()
+	// keep the jobs running together
+	time.Sleep(20 * time.Millisecond)
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return errors.New("aborted by the test")
+}
+
+func (p *concurrencyPlugin) PostProcess(result *kustoutils.DeltaResult) error {
+	return nil
+}
+
+var _ = Describe("ClusterExecuterParallelism", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "parallel-0-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	dbs := []string{"db1", "db2", "db3", "db4", "db5", "db6"}
+	var reconciler *ClusterExecuterReconciler
+	var cluster *kustoutils.KustoCluster
+	var plugin *concurrencyPlugin
+
+	// setup creates the executer of the `dbs`, whose schema config map holds `data` next to the kql.
+	setup := func(data map[string]string, objects ...*v1.ResourceQuota) {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "parallel-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		for k, v := range data {
+			cfgMap.Data[k] = v
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+				ApplyTo:       schemav1alpha1.TargetFilter{DBS: dbs},
+			},
+		}
+		builder := fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer)
+		for _, obj := range objects {
+			builder = builder.WithObjects(obj)
+		}
+		reconciler = &ClusterExecuterReconciler{
+			Client:   builder.Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ParallelismTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	reconcile := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	BeforeEach(func() {
+		plugin = &concurrencyPlugin{}
+		cluster = &kustoutils.KustoCluster{URI: uri, Client: kustofake.NewFakeKustoCluster(uri).WithDatabaseList(dbs)}
+		cluster.DeltaWrapper().RegisterPlugin(plugin)
+	})
+
+	It("Should run the configured number of databases at once", func() {
+		setup(map[string]string{kustoutils.MaxParallelDatabasesKey: "3"})
+		executer := reconcile()
+		Expect(executer.Status.Failed).To(BeTrue())
+		Expect(plugin.jobs).To(Equal(len(dbs)))
+		Expect(plugin.peak).To(Equal(3))
+	})
+	It("Should run the databases at once set for the operator", func() {
+		config.SetOverrides(map[string]interface{}{config.MaxParallelDBsKey: 2})
+		defer config.SetOverrides(nil)
+		setup(nil)
+		reconcile()
+		Expect(plugin.jobs).To(Equal(len(dbs)))
+		Expect(plugin.peak).To(Equal(2))
+	})
+	It("Should run the databases the resource quota has room for at once", func() {
+		hard := v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("1")}
+		quota := &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: key.Namespace},
+			Spec:       v1.ResourceQuotaSpec{Hard: hard},
+			Status:     v1.ResourceQuotaStatus{Hard: hard, Used: v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("250m")}},
+		}
+		setup(map[string]string{kustoutils.MaxParallelDatabasesKey: "6"}, quota)
+		executer := reconcile()
+		// 750m cpu left fits 3 workers of 250m.
+		Expect(executer.Status.Config.MaxParallelDatabases).To(Equal(3))
+		Expect(plugin.jobs).To(Equal(len(dbs)))
+		Expect(plugin.peak).To(Equal(3))
+	})
+	It("Should run a single job without parallel databases", func() {
+		setup(nil)
+		Expect(reconcile().Status.Failed).To(BeTrue())
+		Expect(plugin.jobs).To(Equal(1))
+		Expect(plugin.peak).To(Equal(1))
+	})
+})
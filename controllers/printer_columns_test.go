@@ -0,0 +1,105 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+type printerColumn struct {
+	Name     string `json:"name"`
+	JSONPath string `json:"jsonPath"`
+	Priority int    `json:"priority"`
+}
+
+var _ = Describe("ClusterExecuterPrinterColumns", func() {
+	crdFile := filepath.Join("..", "charts", "azure-schema-operator", "crds",
+		"apiextensions.k8s.io_v1_customresourcedefinition_clusterexecuters.dbschema.microsoft.com.yaml")
+
+	columns := func() []printerColumn {
+		data, err := os.ReadFile(crdFile)
+		Expect(err).NotTo(HaveOccurred())
+		crd := struct {
+			Spec struct {
+				Versions []struct {
+					AdditionalPrinterColumns []printerColumn `json:"additionalPrinterColumns"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}{}
+		Expect(yaml.Unmarshal(data, &crd)).To(Succeed())
+		Expect(crd.Spec.Versions).To(HaveLen(1))
+		return crd.Spec.Versions[0].AdditionalPrinterColumns
+	}
+	printColumn := func(column printerColumn, obj interface{}) string {
+		path := jsonpath.New(column.Name).AllowMissingKeys(true)
+		Expect(path.Parse("{" + column.JSONPath + "}")).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(path.Execute(out, obj)).To(Succeed())
+		return out.String()
+	}
+
+	It("Should match the printcolumn markers of the ClusterExecuter", func() {
+		source, err := os.ReadFile(filepath.Join("..", "api", "v1alpha1", "clusterexecuter_types.go"))
+		Expect(err).NotTo(HaveOccurred())
+		markers := regexp.MustCompile(`printcolumn:name="([^"]+)",type="[^"]+",JSONPath="([^"]+)"`).FindAllStringSubmatch(string(source), -1)
+		printed := columns()
+		Expect(printed).To(HaveLen(len(markers)))
+		for i, marker := range markers {
+			Expect(printed[i].Name).To(Equal(marker[1]))
+			Expect(printed[i].JSONPath).To(Equal(marker[2]))
+		}
+	})
+	It("Should print the status of a sample executer", func() {
+		executer := &schemav1alpha1.ClusterExecuter{
+			Spec: schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://sampleadx.westeurope.kusto.windows.net", Type: schemav1alpha1.DBTypeKusto},
+			Status: schemav1alpha1.ClusterExecuterStatus{
+				DatabaseProgress: map[string]schemav1alpha1.DBPhase{"db1": schemav1alpha1.DBPhasePending, "db2": schemav1alpha1.DBPhasePending},
+			},
+		}
+		setPendingDatabases(executer, schemav1alpha1.ClusterTargets{DBs: []string{"db3"}})
+		executer.Status.ClusterName = "sampleadx"
+		executer.Status.ShortChecksum = shortChecksum("0123456789abcdef")
+		executer.Status.LastAppliedAt = &metav1.Time{Time: time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)}
+		meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+			Type: schemav1alpha1.ConditionReady, Status: metav1.ConditionUnknown, Reason: observationReason,
+		})
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(executer)
+		Expect(err).NotTo(HaveOccurred())
+
+		printed := map[string]string{}
+		for _, column := range columns() {
+			printed[column.Name] = printColumn(column, obj)
+		}
+		Expect(printed).To(HaveKeyWithValue("CLUSTER", "sampleadx"))
+		Expect(printed).To(HaveKeyWithValue("DATABASES", "3"))
+		Expect(printed).To(HaveKeyWithValue("STATUS", observationReason))
+		Expect(printed).To(HaveKeyWithValue("LAST-APPLIED", "2022-06-01T12:00:00Z"))
+		Expect(printed).To(HaveKeyWithValue("CHECKSUM", "01234567"))
+		Expect(printed).To(HaveKeyWithValue("Progress", "0/3"))
+		Expect(printed).To(HaveKeyWithValue("TYPE", string(schemav1alpha1.DBTypeKusto)))
+	})
+	It("Should only print the time and checksum in the wide output", func() {
+		wide := []string{}
+		for _, column := range columns() {
+			if column.Priority > 0 {
+				wide = append(wide, column.Name)
+			}
+		}
+		Expect(wide).To(ConsistOf("LAST-APPLIED", "CHECKSUM"))
+	})
+})
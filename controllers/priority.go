@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/priorityqueue"
+)
+
+// objectPriority returns the `Priority` spec field of `obj`, or its `schema.operator/priority` annotation when the field is unset.
+// Priorities are clamped to 0-100.
+func objectPriority(obj client.Object, specPriority int) int {
+	priority := specPriority
+	if priority == schemav1alpha1.PriorityNormal {
+		priority, _ = strconv.Atoi(obj.GetAnnotations()[schemav1alpha1.PriorityAnnotation])
+	}
+	switch {
+	case priority < schemav1alpha1.PriorityNormal:
+		return schemav1alpha1.PriorityNormal
+	case priority > schemav1alpha1.PriorityCritical:
+		return schemav1alpha1.PriorityCritical
+	}
+	return priority
+}
+
+// PrioritizedReconciler queues the requests of the controller in a priority queue, and reconciles them
+// with `reconciler` in priority order, so a burst of pending low priority requests doesn't delay critical ones.
+// It is added to the manager as a runnable running the workers.
+type PrioritizedReconciler struct {
+	queue      *priorityqueue.Queue
+	reconciler reconcile.Reconciler
+	workers    int
+}
+
+// NewPrioritizedReconciler returns a reconciler queuing requests by `priority`, reconciled by `workers` workers.
+func NewPrioritizedReconciler(reconciler reconcile.Reconciler, priority func(req ctrl.Request) int, workers int) *PrioritizedReconciler {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &PrioritizedReconciler{
+		queue:      priorityqueue.New(func(item interface{}) int { return priority(item.(ctrl.Request)) }, nil),
+		reconciler: reconciler,
+		workers:    workers,
+	}
+}
+
+// Reconcile queues the request, it is reconciled once the higher priority requests are done.
+func (p *PrioritizedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	p.queue.Add(req)
+	return ctrl.Result{}, nil
+}
+
+// Start runs the workers until `ctx` is done.
+func (p *PrioritizedReconciler) Start(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p.processNext(ctx) {
+			}
+		}()
+	}
+	<-ctx.Done()
+	p.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// NeedLeaderElection runs the workers only on the leader, like the controllers.
+func (p *PrioritizedReconciler) NeedLeaderElection() bool {
+	return true
+}
+
+// processNext reconciles the queued request with the highest priority, and requeues it like the controller-runtime controllers.
+// It returns false once the queue is shut down.
+func (p *PrioritizedReconciler) processNext(ctx context.Context) bool {
+	item, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(item)
+	req := item.(ctrl.Request)
+	result, err := p.reconciler.Reconcile(ctx, req)
+	switch {
+	case err != nil:
+		logf.FromContext(ctx).Error(err, "Reconciler error", "request", req.String())
+		p.queue.AddRateLimited(req)
+	case result.RequeueAfter > 0:
+		p.queue.Forget(req)
+		p.queue.AddAfter(req, result.RequeueAfter)
+	case result.Requeue:
+		p.queue.AddRateLimited(req)
+	default:
+		p.queue.Forget(req)
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// recordingReconciler records the order of the reconciled requests.
+type recordingReconciler struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recordingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, req.Name)
+	return ctrl.Result{}, nil
+}
+
+func (r *recordingReconciler) reconciled() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.names...)
+}
+
+var _ = Describe("Executer priorities", func() {
+	ctx := context.Background()
+
+	newExecuter := func(name string, priority int, annotation string) *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{Priority: priority},
+		}
+		if annotation != "" {
+			executer.Annotations = map[string]string{schemav1alpha1.PriorityAnnotation: annotation}
+		}
+		return executer
+	}
+
+	It("Should read the priority from the spec or the annotation", func() {
+		cases := []struct {
+			name       string
+			priority   int
+			annotation string
+			expected   int
+		}{
+			{"no priority", 0, "", schemav1alpha1.PriorityNormal},
+			{"spec priority", 40, "", 40},
+			{"spec over annotation", 40, "100", 40},
+			{"annotation", 0, "100", schemav1alpha1.PriorityCritical},
+			{"invalid annotation", 0, "high", schemav1alpha1.PriorityNormal},
+			{"clamped annotation", 0, "500", schemav1alpha1.PriorityCritical},
+		}
+		for _, tc := range cases {
+			executer := newExecuter("executer", tc.priority, tc.annotation)
+			Expect(objectPriority(executer, executer.Spec.Priority)).To(Equal(tc.expected), tc.name)
+		}
+	})
+
+	It("Should reconcile the pending executers by priority then in arrival order", func() {
+		executers := []*schemav1alpha1.ClusterExecuter{
+			newExecuter("dev-1", 0, ""),
+			newExecuter("staging-1", 10, ""),
+			newExecuter("dev-2", 0, ""),
+			newExecuter("prod-1", 0, "100"),
+			newExecuter("staging-2", 10, ""),
+			newExecuter("prod-2", schemav1alpha1.PriorityCritical, ""),
+		}
+		builder := fake.NewClientBuilder().WithScheme(newFakeScheme())
+		for _, executer := range executers {
+			builder = builder.WithObjects(executer)
+		}
+		reconciler := &ClusterExecuterReconciler{Client: builder.Build(), Scheme: newFakeScheme()}
+		recorder := &recordingReconciler{}
+		prioritized := NewPrioritizedReconciler(recorder, reconciler.requestPriority, 1)
+
+		// the executers are pending before the workers start, e.g. after an operator restart.
+		for _, executer := range executers {
+			_, err := prioritized.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: executer.Name, Namespace: executer.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		workerCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go func() {
+			defer GinkgoRecover()
+			Expect(prioritized.Start(workerCtx)).To(Succeed())
+		}()
+		Eventually(recorder.reconciled).Should(Equal([]string{"prod-1", "prod-2", "staging-1", "staging-2", "dev-1", "dev-2"}))
+	})
+})
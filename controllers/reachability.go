@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+)
+
+const (
+	// minUnreachableBackoff is the first requeue delay of an executer whose cluster is unreachable.
+	minUnreachableBackoff = 5 * time.Second
+	// maxUnreachableBackoff caps the requeue delay of an executer whose cluster is unreachable.
+	maxUnreachableBackoff = 5 * time.Minute
+)
+
+// checkReachable pings the cluster of the executer and records the result in the `ClusterReachable` condition.
+// It returns the delay before the next attempt when the cluster is unreachable, zero when it is reachable.
+func (r *ClusterExecuterReconciler) checkReachable(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, now time.Time) (time.Duration, error) {
+	pinger, ok := cluster.(clusterUtils.Pinger)
+	if !ok {
+		return 0, nil
+	}
+	err := pinger.Ping(ctx)
+	if err == nil {
+		if meta.IsStatusConditionTrue(executer.Status.Conditions, schemav1alpha1.ConditionClusterReachable) {
+			return 0, nil
+		}
+		meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+			Type:   schemav1alpha1.ConditionClusterReachable,
+			Status: metav1.ConditionTrue,
+			Reason: "Reachable",
+		})
+		return 0, r.Status().Update(ctx, executer)
+	}
+	r.Log.Error(err, "cluster is unreachable", "cluster", executer.Spec.ClusterUri)
+	backoff := unreachableBackoff(executer, now)
+	if !meta.IsStatusConditionFalse(executer.Status.Conditions, schemav1alpha1.ConditionClusterReachable) {
+		r.recorder.Eventf(executer, v1.EventTypeWarning, "Unreachable", "cluster %s is unreachable", executer.Spec.ClusterUri)
+	}
+	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionClusterReachable,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Unreachable",
+		Message: err.Error(),
+	})
+	return backoff, r.Status().Update(ctx, executer)
+}
+
+// unreachableBackoff returns the requeue delay of an executer whose cluster is unreachable.
+// The delay is the time the cluster has been unreachable, so it doubles with every attempt.
+func unreachableBackoff(executer *schemav1alpha1.ClusterExecuter, now time.Time) time.Duration {
+	cond := meta.FindStatusCondition(executer.Status.Conditions, schemav1alpha1.ConditionClusterReachable)
+	if cond == nil || cond.Status != metav1.ConditionFalse {
+		return minUnreachableBackoff
+	}
+	backoff := now.Sub(cond.LastTransitionTime.Time)
+	if backoff < minUnreachableBackoff {
+		return minUnreachableBackoff
+	}
+	if backoff > maxUnreachableBackoff {
+		return maxUnreachableBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,92 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// pingingCluster is an observing cluster answering pings with `err`.
+type pingingCluster struct {
+	observingCluster
+	err error
+}
+
+func (c *pingingCluster) Ping(ctx context.Context) error {
+	return c.err
+}
+
+var _ = Describe("ClusterExecuterReachability", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "reachability-0-cluster1", Namespace: "default"}
+	var reconciler *ClusterExecuterReconciler
+
+	BeforeEach(func() {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://cluster1.eastus.kusto.windows.net"},
+		}
+		reconciler = &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ReachabilityTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should back off while the cluster is unreachable and recover once it answers", func() {
+		cluster := &pingingCluster{err: context.DeadlineExceeded}
+		now := time.Now()
+		backoff, err := reconciler.checkReachable(ctx, cluster, getExecuter(), now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backoff).To(Equal(minUnreachableBackoff))
+		cond := meta.FindStatusCondition(getExecuter().Status.Conditions, schemav1alpha1.ConditionClusterReachable)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Message).To(Equal(context.DeadlineExceeded.Error()))
+
+		backoff, err = reconciler.checkReachable(ctx, cluster, getExecuter(), now.Add(time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backoff).To(BeNumerically("~", time.Minute, time.Second))
+		backoff, err = reconciler.checkReachable(ctx, cluster, getExecuter(), now.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backoff).To(Equal(maxUnreachableBackoff))
+
+		cluster.err = nil
+		backoff, err = reconciler.checkReachable(ctx, cluster, getExecuter(), now.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backoff).To(BeZero())
+		Expect(meta.IsStatusConditionTrue(getExecuter().Status.Conditions, schemav1alpha1.ConditionClusterReachable)).To(BeTrue())
+	})
+	It("Should skip clusters that cannot be pinged", func() {
+		backoff, err := reconciler.checkReachable(ctx, &observingCluster{}, getExecuter(), time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backoff).To(BeZero())
+		Expect(getExecuter().Status.Conditions).To(BeEmpty())
+	})
+	It("Should report a failed ping as unreachable", func() {
+		_, err := reconciler.checkReachable(ctx, &pingingCluster{err: fmt.Errorf("no such host")}, getExecuter(), time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionFalse(getExecuter().Status.Conditions, schemav1alpha1.ConditionClusterReachable)).To(BeTrue())
+	})
+})
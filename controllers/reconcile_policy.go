@@ -0,0 +1,73 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// reconcileDue runs the `reconcilePolicy` of the template once the `version` of its schema source is known, returning
+// whether the reconcile proceeds. A started reconcile proceeds until it completes - its revision was executed or failed -
+// so the policy only decides when a new one starts: `OnChange` once the source version changed, `Periodic` once the
+// `reconcileInterval` passed since the last one and `Manual` while the template holds the `schema.operator/trigger` annotation.
+func (r *SchemaDeploymentReconciler) reconcileDue(template *schemav1alpha1.SchemaDeployment, version string, now time.Time) (ctrl.Result, bool) {
+	log := r.Log.WithValues("SchemaDeployment", client.ObjectKeyFromObject(template))
+	switch template.Spec.ReconcilePolicy {
+	case schemav1alpha1.ReconcileOnChange:
+		if template.Status.ObservedSourceVersion == version {
+			log.Info("the schema source did not change - skip", "version", version)
+			return ctrl.Result{}, false
+		}
+	case schemav1alpha1.ReconcilePeriodic:
+		if last := template.Status.LastReconcileTime; last != nil {
+			if remaining := last.Add(r.requeueInterval(template)).Sub(now); remaining > 0 {
+				log.Info("reconciled recently - waiting for the next period", "remaining", remaining)
+				return ctrl.Result{RequeueAfter: remaining}, false
+			}
+		}
+	case schemav1alpha1.ReconcileManual:
+		if template.GetAnnotations()[schemav1alpha1.TriggerAnnotation] == "" {
+			log.Info("waiting for the trigger annotation", "annotation", schemav1alpha1.TriggerAnnotation)
+			return ctrl.Result{}, false
+		}
+	}
+	return ctrl.Result{}, true
+}
+
+// observeReconcile records the source `version` and the time of a completed reconcile on the template status, to be
+// saved by the caller. Templates without a reconcile policy are left as is.
+func observeReconcile(template *schemav1alpha1.SchemaDeployment, version string, now time.Time) {
+	if template.Spec.ReconcilePolicy == "" {
+		return
+	}
+	template.Status.ObservedSourceVersion = version
+	template.Status.LastReconcileTime = &metav1.Time{Time: now}
+}
+
+// clearTrigger removes the trigger annotation once the reconcile it triggered completed.
+func (r *SchemaDeploymentReconciler) clearTrigger(ctx context.Context, template *schemav1alpha1.SchemaDeployment) error {
+	if _, ok := template.GetAnnotations()[schemav1alpha1.TriggerAnnotation]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(template.DeepCopy())
+	annotations := template.GetAnnotations()
+	delete(annotations, schemav1alpha1.TriggerAnnotation)
+	template.SetAnnotations(annotations)
+	return r.Patch(ctx, template, patch)
+}
+
+// sourceVersion identifies the version of the schema source, the kql checksum of a `secretRef` source.
+func sourceVersion(template *schemav1alpha1.SchemaDeployment, cfgMap *corev1.ConfigMap) string {
+	if template.Spec.VersionPin == nil && template.Spec.SecretRef != nil {
+		return cfgMap.Data[secretChecksumKey]
+	}
+	return cfgMap.ResourceVersion
+}
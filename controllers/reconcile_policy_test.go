@@ -0,0 +1,135 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("SchemaDeploymentReconcilePolicy", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "policy", Namespace: "default"}
+	cfgKey := types.NamespacedName{Name: "policy-kql", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+	var recorder *record.FakeRecorder
+
+	setup := func(policy schemav1alpha1.ReconcilePolicyType, interval *metav1.Duration) {
+		recorder = record.NewFakeRecorder(20)
+		reconciler = &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(
+				&v1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: cfgKey.Name, Namespace: cfgKey.Namespace},
+					Data:       map[string]string{"kql": ".create table T1 (a:string)"},
+				},
+				&schemav1alpha1.SchemaDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+					Spec: schemav1alpha1.SchemaDeploymentSpec{
+						Type:              schemav1alpha1.DBTypeKusto,
+						Source:            schemav1alpha1.NamespacedName(cfgKey),
+						ReconcilePolicy:   policy,
+						ReconcileInterval: interval,
+					},
+					Status: schemav1alpha1.SchemaDeploymentStatus{ReconcileInterval: interval},
+				}).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ReconcilePolicyTest"),
+			Scheme:   newFakeScheme(),
+			recorder: recorder,
+		}
+	}
+	reconcile := func() ctrl.Result {
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+	getTemplate := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	executed := func() bool {
+		found := false
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; event == "Normal Executed Scheme was deployed" {
+				found = true
+			}
+		}
+		return found
+	}
+	// execute creates the versioned deployment of the current revision and completes it.
+	execute := func() {
+		reconcile()
+		dep := &schemav1alpha1.VersionedDeplyment{}
+		Expect(reconciler.Get(ctx, types.NamespacedName(getTemplate().Status.CurrentVerDeployment), dep)).To(Succeed())
+		dep.Status.Executed = true
+		Expect(reconciler.Status().Update(ctx, dep)).To(Succeed())
+		reconcile()
+		Expect(executed()).To(BeTrue())
+	}
+
+	It("Should reconcile OnChange only when the config map changed", func() {
+		setup(schemav1alpha1.ReconcileOnChange, nil)
+		execute()
+		cfgMap := &v1.ConfigMap{}
+		Expect(reconciler.Get(ctx, cfgKey, cfgMap)).To(Succeed())
+		Expect(getTemplate().Status.ObservedSourceVersion).To(Equal(cfgMap.ResourceVersion))
+
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(executed()).To(BeFalse())
+
+		cfgMap.Data["kql"] = ".create table T2 (a:string)"
+		Expect(reconciler.Update(ctx, cfgMap)).To(Succeed())
+		reconcile()
+		Expect(getTemplate().Status.CurrentRevision).To(Equal(int32(1)))
+	})
+	It("Should reconcile Periodic once the reconcile interval passed", func() {
+		setup(schemav1alpha1.ReconcilePeriodic, &metav1.Duration{Duration: time.Hour})
+		execute()
+		Expect(getTemplate().Status.LastReconcileTime).NotTo(BeNil())
+
+		res := reconcile()
+		Expect(res.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+		Expect(res.RequeueAfter).To(BeNumerically("<=", time.Hour))
+		Expect(executed()).To(BeFalse())
+
+		template := getTemplate()
+		template.Status.LastReconcileTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+		Expect(reconciler.Status().Update(ctx, template)).To(Succeed())
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: time.Hour}))
+		Expect(executed()).To(BeTrue())
+		Expect(getTemplate().Status.LastReconcileTime.After(time.Now().Add(-time.Minute))).To(BeTrue())
+	})
+	It("Should reconcile Manual only when triggered and clear the trigger", func() {
+		setup(schemav1alpha1.ReconcileManual, nil)
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(getTemplate().Status.CurrentVerDeployment.Name).To(BeEmpty())
+
+		template := getTemplate()
+		template.Annotations = map[string]string{schemav1alpha1.TriggerAnnotation: "5f0c1a3e-8f4b-4b8e-9a57-2d3c4e5f6a7b"}
+		Expect(reconciler.Update(ctx, template)).To(Succeed())
+		execute()
+		Expect(getTemplate().Annotations).NotTo(HaveKey(schemav1alpha1.TriggerAnnotation))
+
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(executed()).To(BeFalse())
+	})
+	It("Should reconcile on every event without a reconcile policy", func() {
+		setup("", nil)
+		execute()
+		reconcile()
+		Expect(executed()).To(BeTrue())
+		Expect(getTemplate().Status.LastReconcileTime).To(BeNil())
+	})
+})
@@ -0,0 +1,113 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+const (
+	// DefaultCPUPerWorker is the cpu a parallel delta-kusto job is estimated to use, unless the cpu per worker setting is set.
+	DefaultCPUPerWorker = "250m"
+	// DefaultMemPerWorker is the memory a parallel delta-kusto job is estimated to use, unless the memory per worker setting is set.
+	DefaultMemPerWorker = "256Mi"
+)
+
+//+kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+
+// quotaResources maps the quota resource names to the cpu and memory of a worker they limit.
+var quotaResources = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:            corev1.ResourceCPU,
+	corev1.ResourceRequestsCPU:    corev1.ResourceCPU,
+	corev1.ResourceLimitsCPU:      corev1.ResourceCPU,
+	corev1.ResourceMemory:         corev1.ResourceMemory,
+	corev1.ResourceRequestsMemory: corev1.ResourceMemory,
+	corev1.ResourceLimitsMemory:   corev1.ResourceMemory,
+}
+
+// workerResources returns the cpu and memory a parallel delta-kusto job is estimated to use.
+func workerResources() corev1.ResourceList {
+	quantity := func(key, fallback string) resource.Quantity {
+		if q, err := resource.ParseQuantity(config.GetString(key)); err == nil && !q.IsZero() {
+			return q
+		}
+		return resource.MustParse(fallback)
+	}
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    quantity(config.CPUPerWorkerKey, DefaultCPUPerWorker),
+		corev1.ResourceMemory: quantity(config.MemPerWorkerKey, DefaultMemPerWorker),
+	}
+}
+
+// quotaWorkers returns how many of the `workers` the resource quotas of `namespace` have room for, given the cpu and
+// memory of a worker. All the workers fit a namespace without quotas, and at least one worker always runs.
+func quotaWorkers(ctx context.Context, reader client.Reader, namespace string, workers int) (int, error) {
+	quotas := &corev1.ResourceQuotaList{}
+	if err := reader.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return workers, err
+	}
+	perWorker := workerResources()
+	allowed := workers
+	for _, quota := range quotas.Items {
+		hard := quota.Status.Hard
+		if len(hard) == 0 {
+			hard = quota.Spec.Hard
+		}
+		for name, limit := range hard {
+			resourceName, ok := quotaResources[name]
+			if !ok {
+				continue
+			}
+			available := limit.DeepCopy()
+			if used, ok := quota.Status.Used[name]; ok {
+				available.Sub(used)
+			}
+			need := perWorker[resourceName]
+			if fit := int(available.MilliValue() / need.MilliValue()); fit < allowed {
+				allowed = fit
+			}
+		}
+	}
+	if allowed < 1 {
+		return 1, nil
+	}
+	return allowed, nil
+}
+
+// limitParallelism reduces the databases the kusto execution of `exeCfg` runs at once to what the resource quotas of
+// the operator namespace (where the delta-kusto jobs run) have room for, and records a `Warning` event when it does.
+func (r *ClusterExecuterReconciler) limitParallelism(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, exeCfg *schemav1alpha1.ExecutionConfiguration, total int) {
+	workers := kustoutils.ParallelDatabases(*exeCfg, total)
+	if executer.Spec.Type != schemav1alpha1.DBTypeKusto || workers <= 1 {
+		return
+	}
+	namespace := config.OperatorNamespace()
+	if namespace == "" {
+		namespace = executer.Namespace
+	}
+	allowed, err := quotaWorkers(ctx, r.Client, namespace, workers)
+	if err != nil {
+		r.Log.Error(err, "failed reading the resource quotas - keeping the parallelism", "namespace", namespace)
+		return
+	}
+	if allowed >= workers {
+		return
+	}
+	exeCfg.MaxParallelDatabases = allowed
+	exeCfg.MaxWorkers = allowed
+	if exeCfg.MinWorkers > allowed {
+		exeCfg.MinWorkers = allowed
+	}
+	RecordEvent(r.recorder, executer, EventParallelismReduced,
+		fmt.Sprintf("the resource quota of namespace %s has room for %d of the %d parallel delta-kusto jobs", namespace, allowed, workers), true)
+}
@@ -0,0 +1,99 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+)
+
+var _ = Describe("ResourceQuota", func() {
+	ctx := context.Background()
+	executer := &schemav1alpha1.ClusterExecuter{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota-cluster1", Namespace: "default"},
+		Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://cluster1.westeurope.kusto.windows.net", Type: schemav1alpha1.DBTypeKusto},
+	}
+	quota := func(hard, used corev1.ResourceList) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: executer.Namespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+			Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	var recorder *record.FakeRecorder
+	limit := func(exeCfg *schemav1alpha1.ExecutionConfiguration, quotas ...*corev1.ResourceQuota) {
+		builder := fake.NewClientBuilder().WithScheme(newFakeScheme())
+		for _, q := range quotas {
+			builder = builder.WithObjects(q)
+		}
+		reconciler := &ClusterExecuterReconciler{
+			Client:   builder.Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ResourceQuotaTest"),
+			Scheme:   newFakeScheme(),
+			recorder: recorder,
+		}
+		reconciler.limitParallelism(ctx, executer, exeCfg, 20)
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+	})
+	AfterEach(func() {
+		config.SetOverrides(nil)
+	})
+
+	It("should reduce the parallelism to the room left in a tight quota", func() {
+		exeCfg := &schemav1alpha1.ExecutionConfiguration{MaxParallelDatabases: 10, MinWorkers: 5}
+		limit(exeCfg, quota(
+			corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2"), corev1.ResourceRequestsMemory: resource.MustParse("4Gi")},
+			corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1250m"), corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+		))
+		// 750m cpu left fits 3 workers of 250m, 3Gi memory would fit 12.
+		Expect(exeCfg.MaxParallelDatabases).To(Equal(3))
+		Expect(exeCfg.MaxWorkers).To(Equal(3))
+		Expect(exeCfg.MinWorkers).To(Equal(3))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(EventParallelismReduced), ContainSubstring("room for 3 of the 10"))))
+	})
+	It("should use the configured worker resources", func() {
+		config.SetOverrides(map[string]interface{}{config.MemPerWorkerKey: "1Gi"})
+		exeCfg := &schemav1alpha1.ExecutionConfiguration{MaxParallelDatabases: 10}
+		limit(exeCfg, quota(corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("4Gi")}, nil))
+		Expect(exeCfg.MaxParallelDatabases).To(Equal(4))
+	})
+	It("should keep one worker when the quota is exhausted", func() {
+		exeCfg := &schemav1alpha1.ExecutionConfiguration{MaxParallelDatabases: 10}
+		limit(exeCfg, quota(
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		))
+		Expect(exeCfg.MaxParallelDatabases).To(Equal(1))
+	})
+	It("should keep the full parallelism with a relaxed quota", func() {
+		exeCfg := &schemav1alpha1.ExecutionConfiguration{MaxParallelDatabases: 10}
+		limit(exeCfg, quota(
+			corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("16"), corev1.ResourceRequestsMemory: resource.MustParse("64Gi"), corev1.ResourcePods: resource.MustParse("2")},
+			corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2"), corev1.ResourcePods: resource.MustParse("2")},
+		))
+		Expect(exeCfg.MaxParallelDatabases).To(Equal(10))
+		Expect(exeCfg.MaxWorkers).To(BeZero())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+	It("should keep the full parallelism without quotas", func() {
+		exeCfg := &schemav1alpha1.ExecutionConfiguration{MaxParallelDatabases: 10}
+		limit(exeCfg)
+		Expect(exeCfg.MaxParallelDatabases).To(Equal(10))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
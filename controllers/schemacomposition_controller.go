@@ -0,0 +1,199 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// SchemaCompositionReconciler reconciles a SchemaComposition object
+type SchemaCompositionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemacompositions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemacompositions/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemacompositions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;update;create;patch;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile merges the kql of the composition sources into the output config map,
+// which is labeled so the schema deployments using it as their source are reconciled on every change.
+// Sources defining the same table are rejected and the output config map is left unchanged.
+func (r *SchemaCompositionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaComposition/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaComposition", req.NamespacedName)
+
+	composition := &schemav1alpha1.SchemaComposition{}
+	err := r.Get(ctx, req.NamespacedName, composition)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	sources := make([]kustoutils.KQLSource, 0, len(composition.Spec.Sources))
+	for _, ref := range composition.Spec.Sources {
+		cfgMap := &corev1.ConfigMap{}
+		err = r.Get(ctx, compositionSourceKey(composition, ref), cfgMap)
+		if apierrors.IsNotFound(err) {
+			log.Info("composition source not found", "source", ref.Name)
+			return ctrl.Result{}, r.setCompositionReady(ctx, composition, metav1.ConditionFalse, "SourceNotFound", fmt.Sprintf("config map %s not found", ref.Name))
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+		sources = append(sources, kustoutils.KQLSource{Name: ref.Name, KQL: cfgMap.Data["kql"]})
+	}
+
+	merged, err := kustoutils.ComposeKQL(sources, composition.Spec.Order)
+	duplicate := kustoutils.ErrDuplicateTable{}
+	if errors.As(err, &duplicate) {
+		r.recorder.Event(composition, corev1.EventTypeWarning, "DuplicateTable", err.Error())
+		return ctrl.Result{}, r.setCompositionReady(ctx, composition, metav1.ConditionFalse, "DuplicateTable", err.Error())
+	} else if err != nil {
+		return ctrl.Result{}, r.setCompositionReady(ctx, composition, metav1.ConditionFalse, "InvalidOrder", err.Error())
+	}
+
+	key := types.NamespacedName{Name: mergedConfigMapName(composition), Namespace: composition.Namespace}
+	if err = r.writeMergedConfigMap(ctx, composition, key, merged); err != nil {
+		log.Error(err, "failed writing the merged config map", "configMap", key.Name)
+		return ctrl.Result{}, err
+	}
+	composition.Status.MergedConfigMap = schemav1alpha1.NamespacedName(key)
+	composition.Status.MergedSources = mergeOrder(composition)
+	return ctrl.Result{}, r.setCompositionReady(ctx, composition, metav1.ConditionTrue, "Merged", fmt.Sprintf("merged %d sources", len(sources)))
+}
+
+// writeMergedConfigMap creates or updates the `key` config map with the merged kql, owned by the composition.
+func (r *SchemaCompositionReconciler) writeMergedConfigMap(ctx context.Context, composition *schemav1alpha1.SchemaComposition, key types.NamespacedName, merged string) error {
+	cfgMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, key, cfgMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+	if !found {
+		cfgMap.ObjectMeta = metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}
+	} else if cfgMap.Data["kql"] == merged && cfgMap.Labels[schemav1alpha1.WatchLabel] == "true" {
+		return nil
+	}
+	if cfgMap.Labels == nil {
+		cfgMap.Labels = make(map[string]string)
+	}
+	cfgMap.Labels[schemav1alpha1.WatchLabel] = "true"
+	if cfgMap.Data == nil {
+		cfgMap.Data = make(map[string]string)
+	}
+	cfgMap.Data["kql"] = merged
+	if !found {
+		if err = ctrl.SetControllerReference(composition, cfgMap, r.Scheme); err != nil {
+			return err
+		}
+		return r.Create(ctx, cfgMap)
+	}
+	return r.Update(ctx, cfgMap)
+}
+
+func (r *SchemaCompositionReconciler) setCompositionReady(ctx context.Context, composition *schemav1alpha1.SchemaComposition, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&composition.Status.Conditions, metav1.Condition{
+		Type:               schemav1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: composition.Generation,
+	})
+	return r.Status().Update(ctx, composition)
+}
+
+// mergedConfigMapName returns the name of the config map the composition writes its merged kql to.
+func mergedConfigMapName(composition *schemav1alpha1.SchemaComposition) string {
+	if composition.Spec.OutputConfigMap != "" {
+		return composition.Spec.OutputConfigMap
+	}
+	return composition.Name + "-kql"
+}
+
+// mergeOrder returns the names of the sources in the order they are merged.
+func mergeOrder(composition *schemav1alpha1.SchemaComposition) []string {
+	ordered := append([]string{}, composition.Spec.Order...)
+	for _, ref := range composition.Spec.Sources {
+		listed := false
+		for _, name := range composition.Spec.Order {
+			listed = listed || name == ref.Name
+		}
+		if !listed {
+			ordered = append(ordered, ref.Name)
+		}
+	}
+	return ordered
+}
+
+// compositionSourceKey returns the key of a source config map, in the namespace of the composition by default.
+func compositionSourceKey(composition *schemav1alpha1.SchemaComposition, ref schemav1alpha1.ConfigMapRef) types.NamespacedName {
+	if ref.Namespace == "" {
+		return types.NamespacedName{Name: ref.Name, Namespace: composition.Namespace}
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+}
+
+// compositionsForConfigMap returns the compositions merging the config map, so they are reconciled when it changes.
+func (r *SchemaCompositionReconciler) compositionsForConfigMap(obj client.Object) []reconcile.Request {
+	compositions := &schemav1alpha1.SchemaCompositionList{}
+	if err := r.List(context.Background(), compositions); err != nil {
+		r.Log.Error(err, "failed listing the schema compositions", "configMap", obj.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+	for i := range compositions.Items {
+		composition := &compositions.Items[i]
+		for _, ref := range composition.Spec.Sources {
+			if compositionSourceKey(composition, ref) == key {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(composition)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaCompositionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("SchemaComposition")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaComposition{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.compositionsForConfigMap)).
+		Complete(r.Health.Wrap(r))
+}
@@ -0,0 +1,99 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("SchemaComposition", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "warehouse", Namespace: "default"}
+	var c client.Client
+	var reconciler *SchemaCompositionReconciler
+
+	newSource := func(name, kql string) *v1.ConfigMap {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: key.Namespace}, Data: map[string]string{"kql": kql}}
+	}
+	newComposition := func(order ...string) *schemav1alpha1.SchemaComposition {
+		return &schemav1alpha1.SchemaComposition{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.SchemaCompositionSpec{
+				Sources: []schemav1alpha1.ConfigMapRef{{Name: "orders-kql"}, {Name: "billing-kql"}},
+				Order:   order,
+			},
+		}
+	}
+	setup := func(objects ...client.Object) {
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(objects...).Build()
+		reconciler = &SchemaCompositionReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaCompositionTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+	reconcile := func() *schemav1alpha1.SchemaComposition {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		composition := &schemav1alpha1.SchemaComposition{}
+		Expect(c.Get(ctx, key, composition)).To(Succeed())
+		return composition
+	}
+
+	It("Should write the merged kql in the configured order", func() {
+		setup(newComposition("billing-kql"),
+			newSource("orders-kql", ".create-merge table Orders (id:string)"),
+			newSource("billing-kql", ".create-merge table Invoices (id:string)"))
+		composition := reconcile()
+		Expect(meta.IsStatusConditionTrue(composition.Status.Conditions, schemav1alpha1.ConditionReady)).To(BeTrue())
+		Expect(composition.Status.MergedSources).To(Equal([]string{"billing-kql", "orders-kql"}))
+		Expect(composition.Status.MergedConfigMap).To(Equal(schemav1alpha1.NamespacedName{Name: "warehouse-kql", Namespace: key.Namespace}))
+
+		merged := &v1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName(composition.Status.MergedConfigMap), merged)).To(Succeed())
+		Expect(merged.Labels).To(HaveKeyWithValue(schemav1alpha1.WatchLabel, "true"))
+		Expect(merged.OwnerReferences).To(HaveLen(1))
+		Expect(merged.Data["kql"]).To(Equal(`// -- source: billing-kql --
+.create-merge table Invoices (id:string)
+
+// -- source: orders-kql --
+.create-merge table Orders (id:string)
+`))
+	})
+	It("Should reject sources defining the same table", func() {
+		setup(newComposition(),
+			newSource("orders-kql", ".create-merge table Orders (id:string)"),
+			newSource("billing-kql", ".create-merge table Orders (id:string, amount:real)"))
+		composition := reconcile()
+		cond := meta.FindStatusCondition(composition.Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal("DuplicateTable"))
+		Expect(cond.Message).To(Equal("table Orders is defined in both orders-kql and billing-kql"))
+		err := c.Get(ctx, types.NamespacedName{Name: "warehouse-kql", Namespace: key.Namespace}, &v1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+	It("Should wait for missing sources", func() {
+		setup(newComposition(), newSource("orders-kql", ".create-merge table Orders (id:string)"))
+		cond := meta.FindStatusCondition(reconcile().Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(cond.Reason).To(Equal("SourceNotFound"))
+		Expect(reconciler.compositionsForConfigMap(newSource("billing-kql", ""))).To(HaveLen(1))
+		Expect(reconciler.compositionsForConfigMap(newSource("other-kql", ""))).To(BeEmpty())
+	})
+})
@@ -14,15 +14,24 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	// telemetry "github.com/Azure/azure-service-operator/pkg/telemetry"
 	"github.com/go-logr/logr"
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
 	"github.com/microsoft/azure-schema-operator/pkg/utils/schemaversions"
 	"github.com/rs/zerolog/log"
 )
@@ -34,8 +43,27 @@ type SchemaDeploymentReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+	// APIReader reads directly from the api server, used to fetch pinned config map versions (optional).
+	APIReader client.Reader
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+	// RequeueInterval is the period the schema deployments without a `reconcileInterval` are requeued at while
+	// their revision is executed, zero for `DefaultRequeueInterval`.
+	RequeueInterval time.Duration
+	// WatchSecrets reconciles the schema deployments whose `secretRef` secret changed, for secrets labeled
+	// with `schema.operator/watch: "true"`. It requires `list` and `watch` on the secrets.
+	WatchSecrets bool
+	// clusters returns the cluster of an executer for the deletion policies, defaults to the executer cluster.
+	clusters executerClusterFunc
 }
 
+// DefaultRequeueInterval is the requeue period of the schema deployments, unless the reconciler `RequeueInterval` is set.
+const DefaultRequeueInterval = 1 * time.Minute
+
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemadeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemadeployments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemadeployments/finalizers,verbs=update
@@ -53,6 +81,8 @@ type SchemaDeploymentReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
 func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaDeployment/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
 	log := r.Log.WithValues("SchemaDeployment", req.NamespacedName)
 	log.Info("SchemaDeploymentReconciler - start ")
 	template := &schemav1alpha1.SchemaDeployment{}
@@ -62,32 +92,106 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if deleted, err := r.handleDeletion(ctx, template); deleted || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if _, ok := template.GetAnnotations()[schemav1alpha1.CancelAnnotation]; ok {
+		return ctrl.Result{}, r.cancelExecutions(ctx, template)
+	}
+
+	if err := template.Spec.ValidateSource(); err != nil {
+		return ctrl.Result{}, r.setInvalid(ctx, template, "InvalidSource", err.Error())
+	}
+	if err := template.Spec.ValidateDeletionPolicy(); err != nil {
+		return ctrl.Result{}, r.setInvalid(ctx, template, "InvalidDeletionPolicy", err.Error())
+	}
+	if !reflect.DeepEqual(template.Status.ReconcileInterval, template.Spec.ReconcileInterval) {
+		// the previous requeue may be far off - start the new interval right away.
+		template.Status.ReconcileInterval = template.Spec.ReconcileInterval
+		if err := r.Status().Update(ctx, template); err != nil {
+			log.Error(err, "failed updating the reconcile interval", "request", req.String())
+			return ctrl.Result{}, err
+		}
+		log.Info("reconcile interval changed - requeue", "interval", r.requeueInterval(template))
+		return ctrl.Result{Requeue: true}, nil
+	}
+	if result, proceed, err := r.resolveSourceConflict(ctx, template); !proceed || err != nil {
+		return result, err
+	}
+
 	// Start logic here...
 
 	//a. get configMap to file
 	cfgMap := &corev1.ConfigMap{}
 
-	err = r.Get(ctx, types.NamespacedName(template.Spec.Source), cfgMap)
-	if err != nil {
-		// r.Telemetry.LogInfoByInstance("ignorable error", "error during fetch from api server", req.String())
-		log.Error(err, "Failed to fetch the configMap")
-		return ctrl.Result{}, err
+	if template.Spec.VersionPin != nil {
+		cfgMap, err = r.pinnedConfigMap(ctx, template)
+		if errors.IsNotFound(err) {
+			log.Info("the pinned config map version does not exist", "ResourceVersion", template.Spec.VersionPin.ResourceVersion)
+			r.recorder.Eventf(template, corev1.EventTypeWarning, "Invalid", "config map %s version %s not found", template.Spec.VersionPin.ConfigMapName, template.Spec.VersionPin.ResourceVersion)
+			meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+				Type:    schemav1alpha1.ConditionInvalid,
+				Status:  metav1.ConditionTrue,
+				Reason:  "VersionPinNotFound",
+				Message: fmt.Sprintf("config map %s has no resource version %s", template.Spec.VersionPin.ConfigMapName, template.Spec.VersionPin.ResourceVersion),
+			})
+			err = r.Status().Update(ctx, template)
+			if err != nil {
+				log.Error(err, "failed updating status", "request", req.String())
+			}
+			return ctrl.Result{}, err
+		} else if err != nil {
+			log.Error(err, "Failed to fetch the pinned configMap")
+			return ctrl.Result{}, err
+		}
+	} else if template.Spec.SecretRef != nil {
+		cfgMap, err = r.secretSourceConfigMap(ctx, template)
+		if errors.IsForbidden(err) {
+			log.Info("the operator is not allowed to read the schema secret", "Secret", template.Spec.SecretRef.Name)
+			return ctrl.Result{}, r.setInvalid(ctx, template, "SecretForbidden",
+				fmt.Sprintf("the operator needs get on secrets in namespace %s to read secret %s", template.Namespace, template.Spec.SecretRef.Name))
+		} else if err != nil {
+			log.Error(err, "Failed to read the kql secret")
+			return ctrl.Result{}, err
+		}
+	} else {
+		err = r.Get(ctx, types.NamespacedName(template.Spec.Source), cfgMap)
+		if err != nil {
+			// r.Telemetry.LogInfoByInstance("ignorable error", "error during fetch from api server", req.String())
+			log.Error(err, "Failed to fetch the configMap")
+			return ctrl.Result{}, err
+		}
+		// Set template instance as the owner and controller of the configMap
+		if !metav1.IsControlledBy(cfgMap, template) {
+			err = ctrl.SetControllerReference(template, cfgMap, r.Scheme)
+			if err != nil {
+				log.Error(err, "Failed to set the cfgMap ownership", "Namespace", cfgMap.Namespace, "Name", cfgMap.Name)
+				return ctrl.Result{}, err
+			}
+			err = r.Update(ctx, cfgMap)
+			if err != nil {
+				log.Error(err, "Failed to update the cfgMap ownership", "Namespace", cfgMap.Namespace, "Name", cfgMap.Name)
+				return ctrl.Result{}, err
+			}
+		}
 	}
-	// Set template instance as the owner and controller of the configMap
-	err = ctrl.SetControllerReference(template, cfgMap, r.Scheme)
-	if err != nil {
-		log.Error(err, "Failed to set the cfgMap ownership", "Namespace", cfgMap.Namespace, "Name", cfgMap.Name)
-		return ctrl.Result{}, err
+	version := sourceVersion(template, cfgMap)
+	if result, due := r.reconcileDue(template, version, time.Now()); !due {
+		return result, nil
 	}
-	err = r.Update(ctx, cfgMap)
-	if err != nil {
-		log.Error(err, "Failed to update the cfgMap ownership", "Namespace", cfgMap.Namespace, "Name", cfgMap.Name)
-		return ctrl.Result{}, err
+	meta.RemoveStatusCondition(&template.Status.Conditions, schemav1alpha1.ConditionInvalid)
+	newRevision := template.Status.CurrentConfigMap.Name == "" || !r.compareConfigMap(ctx, template.Status.CurrentConfigMap, cfgMap)
+	if newRevision && len(template.Spec.CrossClusterDependencies) > 0 {
+		result, ready, err := r.waitForDependencies(ctx, template)
+		if !ready || err != nil {
+			return result, err
+		}
 	}
 	if template.Status.CurrentConfigMap.Name == "" {
 		log.Info("First run - revision 0")
 		template.Status.CurrentRevision = 0
-	} else if !r.compareConfigMap(ctx, template.Status.CurrentConfigMap, cfgMap) {
+	} else if newRevision {
 		log.Info("the config map changed - increase revision")
 		template.Status.CurrentRevision = template.Status.CurrentRevision + 1
 	} else {
@@ -102,16 +206,20 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if err != nil && errors.IsNotFound(err) {
 		log.Info("Creating Versioned deployment and immutable config map")
 		// Define a new deployment
+		source := sourceName(template)
 		imm := true
 		verCfgMap := &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      schemaversions.NameForConfigMap(template.Spec.Source.Name, template.Status.CurrentRevision),
-				Namespace: template.Spec.Source.Namespace,
+				Name:      schemaversions.NameForConfigMap(source.Name, template.Status.CurrentRevision),
+				Namespace: source.Namespace,
 			},
 			Data:       cfgMap.Data,
 			BinaryData: cfgMap.BinaryData,
 			Immutable:  &imm,
 		}
+		if promoted, ok := cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation]; ok {
+			verCfgMap.Annotations = map[string]string{schemav1alpha1.PromotedVersionAnnotation: promoted}
+		}
 		err = r.Create(ctx, verCfgMap)
 		if err != nil {
 			log.Error(err, "Failed to create new versioned cfgMap", "Namespace", verCfgMap.Namespace, "Name", verCfgMap.Name)
@@ -128,12 +236,20 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 				Revision: template.Status.CurrentRevision,
 				ConfigMapName: schemav1alpha1.NamespacedName{
-					Name:      schemaversions.NameForConfigMap(template.Spec.Source.Name, template.Status.CurrentRevision),
+					Name:      schemaversions.NameForConfigMap(source.Name, template.Status.CurrentRevision),
 					Namespace: template.Namespace,
 				},
-				ApplyTo:        template.Spec.ApplyTo,
-				Type:           template.Spec.Type,
-				FailIfDataLoss: template.Spec.FailIfDataLoss,
+				ApplyTo:                     template.Spec.ApplyTo,
+				Type:                        template.Spec.Type,
+				FailIfDataLoss:              template.Spec.FailIfDataLoss,
+				DatabaseRoles:               template.Spec.DatabaseRoles,
+				CooldownSeconds:             template.Spec.CooldownSeconds,
+				ObservationMode:             template.Spec.ObservationMode,
+				SecretRef:                   template.Spec.SecretRef,
+				TenantID:                    template.Spec.TenantID,
+				CredentialSecretRef:         template.Spec.CredentialSecretRef,
+				EventHubConnectionStringRef: template.Spec.EventHubConnectionStringRef,
+				Priority:                    objectPriority(template, template.Spec.Priority),
 			},
 		}
 		// Set template instance as the owner and controller
@@ -162,9 +278,10 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			Namespace: template.Namespace,
 		}
 		template.Status.CurrentConfigMap = schemav1alpha1.NamespacedName{
-			Name:      schemaversions.NameForConfigMap(template.Spec.Source.Name, template.Status.CurrentRevision),
+			Name:      schemaversions.NameForConfigMap(source.Name, template.Status.CurrentRevision),
 			Namespace: template.Namespace,
 		}
+		template.Status.PinnedVersion = template.Spec.VersionPin
 
 		// template.Status = status
 		err = r.Status().Update(ctx, template)
@@ -177,7 +294,7 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		// return ctrl.Result{Requeue: true}, nil
 		log.Info("Versioned Deployment created successfully - return and requeue")
 		r.recorder.Eventf(template, corev1.EventTypeNormal, "Created", "Created versioned deployment %q", dep.Name)
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return ctrl.Result{RequeueAfter: r.requeueInterval(template)}, nil
 
 	} else if err != nil {
 		log.Error(err, "Failed to get Deployment")
@@ -192,7 +309,7 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 	if changed {
 		log.Info("Versioned Deployment changed successfully - return and requeue")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return ctrl.Result{RequeueAfter: r.requeueInterval(template)}, nil
 	}
 
 	log.Info("Checking versioned deployment status")
@@ -205,6 +322,11 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if versionedDeployment.IsExecuted() {
 		template.Status.Executed = true // don't override - maybe need refresh?
 		template.Status.LastSuccessfulRevision = template.Status.CurrentRevision
+		err = r.recordSchemaHistory(ctx, template, versionedDeployment, cfgMap)
+		if err != nil {
+			log.Error(err, "failed recording the schema history", "request", req.String())
+			return ctrl.Result{}, err
+		}
 
 		// template.Status = status
 
@@ -214,14 +336,19 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			Status: metav1.ConditionTrue,
 			Reason: "Executed",
 		})
+		observeReconcile(template, version, time.Now())
 		err = r.Status().Update(ctx, template)
 		if err != nil {
 			log.Error(err, "failed updating status to executed", "request", req.String())
 			return ctrl.Result{}, err
 		}
+		if err = r.clearTrigger(ctx, template); err != nil {
+			log.Error(err, "failed clearing the trigger annotation", "request", req.String())
+			return ctrl.Result{}, err
+		}
 	} else if versionedDeployment.IsRunning() {
 		log.Info("Still running - wait more")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return ctrl.Result{RequeueAfter: r.requeueInterval(template)}, nil
 	} else if versionedDeployment.IsFailed() {
 		log.Info("Failed to execute schema change")
 
@@ -232,18 +359,102 @@ func (r *SchemaDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			Reason:  "Failed",
 			Message: "Schema execution failure",
 		})
+		observeReconcile(template, version, time.Now())
 		err = r.Status().Update(ctx, template)
 		if err != nil {
 			log.Error(err, "failed updating status ", "request", req.String())
 			return ctrl.Result{}, err
 		}
+		if err = r.clearTrigger(ctx, template); err != nil {
+			log.Error(err, "failed clearing the trigger annotation", "request", req.String())
+			return ctrl.Result{}, err
+		}
 		return r.handleFailure(template)
 	}
 
 	log.Info("exiting reconciliation")
+	if template.Spec.ReconcileInterval != nil || template.Spec.ReconcilePolicy == schemav1alpha1.ReconcilePeriodic {
+		return ctrl.Result{RequeueAfter: r.requeueInterval(template)}, err
+	}
 	return ctrl.Result{}, err
 }
 
+// requeueInterval returns the `reconcileInterval` of the template, or the requeue interval of the reconciler.
+func (r *SchemaDeploymentReconciler) requeueInterval(template *schemav1alpha1.SchemaDeployment) time.Duration {
+	if interval := template.Spec.ReconcileInterval; interval != nil && interval.Duration > 0 {
+		return interval.Duration
+	}
+	if r.RequeueInterval > 0 {
+		return r.RequeueInterval
+	}
+	return DefaultRequeueInterval
+}
+
+// setInvalid sets the `Invalid` condition of the template with the `reason` and `message`.
+func (r *SchemaDeploymentReconciler) setInvalid(ctx context.Context, template *schemav1alpha1.SchemaDeployment, reason, message string) error {
+	r.recorder.Event(template, corev1.EventTypeWarning, "Invalid", message)
+	meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionInvalid,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	err := r.Status().Update(ctx, template)
+	if err != nil {
+		r.Log.Error(err, "failed updating status", "SchemaDeployment", template.Name)
+	}
+	return err
+}
+
+// pinnedConfigMap returns the config map version referenced by the template version pin.
+// Once a revision was created from the pin, its immutable versioned config map is used so later
+// changes to the source are ignored.
+func (r *SchemaDeploymentReconciler) pinnedConfigMap(ctx context.Context, template *schemav1alpha1.SchemaDeployment) (*corev1.ConfigMap, error) {
+	pin := template.Spec.VersionPin
+	cfgMap := &corev1.ConfigMap{}
+	if reflect.DeepEqual(template.Status.PinnedVersion, pin) && template.Status.CurrentConfigMap.Name != "" {
+		err := r.Get(ctx, types.NamespacedName(template.Status.CurrentConfigMap), cfgMap)
+		return cfgMap, err
+	}
+
+	namespace := pin.ConfigMapNamespace
+	if namespace == "" {
+		namespace = template.Namespace
+	}
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: pin.ConfigMapName}, cfgMap)
+	if err == nil && cfgMap.ResourceVersion == pin.ResourceVersion {
+		return cfgMap, nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// the config map changed since - fetch the pinned version from the api server.
+	cfgMaps := &corev1.ConfigMapList{}
+	err = reader.List(ctx, cfgMaps, &client.ListOptions{
+		Namespace:     namespace,
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", pin.ConfigMapName),
+		Raw: &metav1.ListOptions{
+			ResourceVersion:      pin.ResourceVersion,
+			ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+		},
+	})
+	if err != nil && !errors.IsGone(err) && !errors.IsInvalid(err) && !errors.IsBadRequest(err) {
+		return nil, err
+	}
+	if err == nil {
+		for i := range cfgMaps.Items {
+			if cfgMaps.Items[i].Name == pin.ConfigMapName && cfgMaps.Items[i].ResourceVersion == pin.ResourceVersion {
+				return &cfgMaps.Items[i], nil
+			}
+		}
+	}
+	return nil, errors.NewNotFound(corev1.Resource("configmaps"), pin.ConfigMapName)
+}
+
 func (r *SchemaDeploymentReconciler) compareConfigMap(ctx context.Context, currentConfigMap schemav1alpha1.NamespacedName, cfgMap *corev1.ConfigMap) bool {
 	if currentConfigMap.Name == "" {
 		log.Info().Msg("current Map is empty - new template.")
@@ -258,7 +469,8 @@ func (r *SchemaDeploymentReconciler) compareConfigMap(ctx context.Context, curre
 	}
 	log.Info().Str("curr", currCfgMap.Data["kql"]).Str("new", cfgMap.Data["kql"]).Msg("Compare kql strings")
 
-	return (reflect.DeepEqual(currCfgMap.Data, cfgMap.Data) && reflect.DeepEqual(currCfgMap.BinaryData, cfgMap.BinaryData))
+	return (reflect.DeepEqual(currCfgMap.Data, cfgMap.Data) && reflect.DeepEqual(currCfgMap.BinaryData, cfgMap.BinaryData) &&
+		currCfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation] == cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation])
 }
 
 func (r *SchemaDeploymentReconciler) compareAndUpdateVersionedDeployment(ctx context.Context, template *schemav1alpha1.SchemaDeployment, deployment *schemav1alpha1.VersionedDeplyment) (bool, error) {
@@ -272,6 +484,38 @@ func (r *SchemaDeploymentReconciler) compareAndUpdateVersionedDeployment(ctx con
 		deployment.Spec.FailIfDataLoss = template.Spec.FailIfDataLoss
 		changed = true
 	}
+	if !reflect.DeepEqual(template.Spec.DatabaseRoles, deployment.Spec.DatabaseRoles) {
+		deployment.Spec.DatabaseRoles = template.Spec.DatabaseRoles
+		changed = true
+	}
+	if template.Spec.CooldownSeconds != deployment.Spec.CooldownSeconds {
+		deployment.Spec.CooldownSeconds = template.Spec.CooldownSeconds
+		changed = true
+	}
+	if template.Spec.ObservationMode != deployment.Spec.ObservationMode {
+		deployment.Spec.ObservationMode = template.Spec.ObservationMode
+		changed = true
+	}
+	if !reflect.DeepEqual(template.Spec.SecretRef, deployment.Spec.SecretRef) {
+		deployment.Spec.SecretRef = template.Spec.SecretRef
+		changed = true
+	}
+	if template.Spec.TenantID != deployment.Spec.TenantID {
+		deployment.Spec.TenantID = template.Spec.TenantID
+		changed = true
+	}
+	if !reflect.DeepEqual(template.Spec.CredentialSecretRef, deployment.Spec.CredentialSecretRef) {
+		deployment.Spec.CredentialSecretRef = template.Spec.CredentialSecretRef
+		changed = true
+	}
+	if !reflect.DeepEqual(template.Spec.EventHubConnectionStringRef, deployment.Spec.EventHubConnectionStringRef) {
+		deployment.Spec.EventHubConnectionStringRef = template.Spec.EventHubConnectionStringRef
+		changed = true
+	}
+	if priority := objectPriority(template, template.Spec.Priority); priority != deployment.Spec.Priority {
+		deployment.Spec.Priority = priority
+		changed = true
+	}
 
 	if changed {
 		err = r.Update(ctx, deployment)
@@ -300,6 +544,7 @@ func (r *SchemaDeploymentReconciler) handleFailure(template *schemav1alpha1.Sche
 			log.Error().Err(err).Msg("Failed to rollback source schema")
 			return ctrl.Result{}, err
 		}
+		RecordEvent(r.recorder, template, EventRollbackTriggered, fmt.Sprintf("rolling back revision %d to revision %d", template.Status.CurrentRevision, template.Status.LastSuccessfulRevision), true)
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	case schemav1alpha1.FailurePolicyIgnore:
 		log.Info().Msg("handling failure - ignore policy.")
@@ -310,6 +555,45 @@ func (r *SchemaDeploymentReconciler) handleFailure(template *schemav1alpha1.Sche
 	return ctrl.Result{}, nil
 }
 
+// cancelExecutions locks the current revision, so it is not retried, and cancels its running executions.
+func (r *SchemaDeploymentReconciler) cancelExecutions(ctx context.Context, template *schemav1alpha1.SchemaDeployment) error {
+	log := r.Log.WithValues("SchemaDeployment", template.Name)
+	current := template.Status.CurrentVerDeployment
+	if current.Name != "" {
+		err := r.lockVersionedDeployment(ctx, current)
+		if err != nil {
+			return err
+		}
+		deployment := &schemav1alpha1.VersionedDeplyment{}
+		err = r.Get(ctx, types.NamespacedName(current), deployment)
+		if err != nil {
+			return err
+		}
+		for _, name := range deployment.Status.Executers {
+			executer := &schemav1alpha1.ClusterExecuter{}
+			err = r.Get(ctx, types.NamespacedName(name), executer)
+			if err != nil {
+				log.Error(err, "failed to find the executer", "executer", name.Name)
+				continue
+			}
+			if !executer.Status.Running || executer.Status.Config.JobID == "" {
+				continue
+			}
+			err = kustoutils.CancelSchemaJob(ctx, executer.Status.Config.JobID)
+			if err != nil {
+				log.Error(err, "failed to cancel the execution", "executer", name.Name)
+				return err
+			}
+			log.Info("cancelled the execution", "executer", name.Name, "job", executer.Status.Config.JobID)
+		}
+	}
+	r.recorder.Eventf(template, corev1.EventTypeNormal, "Cancelled", "cancelled the executions of revision %d", template.Status.CurrentRevision)
+	annotations := template.GetAnnotations()
+	delete(annotations, schemav1alpha1.CancelAnnotation)
+	template.SetAnnotations(annotations)
+	return r.Update(ctx, template)
+}
+
 func (r *SchemaDeploymentReconciler) lockVersionedDeployment(ctx context.Context, deploymentName schemav1alpha1.NamespacedName) error {
 	log := r.Log
 	deployment := &schemav1alpha1.VersionedDeplyment{}
@@ -337,13 +621,44 @@ func (r *SchemaDeploymentReconciler) lockVersionedDeployment(ctx context.Context
 	return nil
 }
 
+// schemaDeploymentsForConfigMap maps a changed `ConfigMap` to all the schema deployments using it as their source.
+func (r *SchemaDeploymentReconciler) schemaDeploymentsForConfigMap(obj client.Object) []reconcile.Request {
+	deployments := &schemav1alpha1.SchemaDeploymentList{}
+	err := r.List(context.Background(), deployments)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed listing schema deployments for configmap %s", obj.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, deployment := range deployments.Items {
+		if deployment.Spec.Source.Name == obj.GetName() && deployment.Spec.Source.Namespace == obj.GetNamespace() && managedLabels.selects(&deployment) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}})
+		}
+	}
+	return requests
+}
+
+// watchedSource filters the watched config maps and secrets to the ones labeled with `schema.operator/watch: "true"`.
+var watchedSource = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetLabels()[schemav1alpha1.WatchLabel] == "true"
+})
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SchemaDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("SchemaDeployment")
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&schemav1alpha1.SchemaDeployment{}).
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaDeployment{}, builder.WithPredicates(managedLabels.filter())).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
 		Owns(&schemav1alpha1.VersionedDeplyment{}).
 		Owns(&corev1.ConfigMap{}).
-		// WithOptions(controller.Options{MaxConcurrentReconciles: 2}).
-		Complete(r)
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.schemaDeploymentsForConfigMap),
+			builder.WithPredicates(watchedSource))
+	if r.WatchSecrets {
+		bldr = bldr.Watches(&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.schemaDeploymentsForSecret),
+			builder.WithPredicates(watchedSource))
+	}
+	// WithOptions(controller.Options{MaxConcurrentReconciles: 2}).
+	return bldr.Complete(r.Health.Wrap(r))
 }
@@ -11,11 +11,18 @@ import (
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kutoschemav1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/schemaversions"
 )
 
 var _ = Describe("SchemaDeploymentController", func() {
@@ -111,3 +118,238 @@ var _ = Describe("SchemaDeploymentController", func() {
 		})
 	})
 })
+
+var _ = Describe("SchemaDeploymentConfigMapWatch", func() {
+	newDeployment := func(name, namespace, source string) *schemav1alpha1.SchemaDeployment {
+		return &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type:   schemav1alpha1.DBTypeKusto,
+				Source: schemav1alpha1.NamespacedName{Name: source, Namespace: "default"},
+			},
+		}
+	}
+	cfgMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shared-kql",
+			Namespace: "default",
+			Labels:    map[string]string{schemav1alpha1.WatchLabel: "true"},
+		},
+	}
+
+	It("Should enqueue every schema deployment referencing the config map", func() {
+		reconciler := &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(
+				newDeployment("first", "default", "shared-kql"),
+				newDeployment("second", "other", "shared-kql"),
+				newDeployment("third", "default", "other-kql"),
+			).Build(),
+			Log: ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+		}
+		requests := reconciler.schemaDeploymentsForConfigMap(cfgMap)
+		Expect(requests).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "first", Namespace: "default"}},
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "second", Namespace: "other"}},
+		))
+	})
+
+	It("Should only watch labeled config maps", func() {
+		Expect(watchedSource.Update(event.UpdateEvent{ObjectOld: cfgMap, ObjectNew: cfgMap})).To(BeTrue())
+		unlabeled := cfgMap.DeepCopy()
+		unlabeled.Labels = map[string]string{schemav1alpha1.WatchLabel: "false"}
+		Expect(watchedSource.Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: unlabeled})).To(BeFalse())
+		unlabeled.Labels = nil
+		Expect(watchedSource.Create(event.CreateEvent{Object: unlabeled})).To(BeFalse())
+	})
+})
+
+var _ = Describe("SchemaDeploymentVersionPin", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "pinned", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+
+	BeforeEach(func() {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "pinned-kql", Namespace: "default"},
+			Data:       map[string]string{"kql": ".create table T1 (a:string)"},
+		}
+		reconciler = &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap,
+				&schemav1alpha1.SchemaDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+					Spec: schemav1alpha1.SchemaDeploymentSpec{
+						Type:   schemav1alpha1.DBTypeKusto,
+						Source: schemav1alpha1.NamespacedName{Name: "pinned-kql", Namespace: "default"},
+					},
+				}).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getTemplate := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	pin := func(resourceVersion string) {
+		template := getTemplate()
+		template.Spec.VersionPin = &schemav1alpha1.SchemaVersionRef{ConfigMapName: "pinned-kql", ResourceVersion: resourceVersion}
+		Expect(reconciler.Update(ctx, template)).To(Succeed())
+	}
+	updateSource := func(kql string) {
+		cfgMap := &v1.ConfigMap{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "pinned-kql", Namespace: "default"}, cfgMap)).To(Succeed())
+		cfgMap.Data["kql"] = kql
+		Expect(reconciler.Update(ctx, cfgMap)).To(Succeed())
+	}
+	revisionKQL := func(revision int32) string {
+		cfgMap := &v1.ConfigMap{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: schemaversions.NameForConfigMap("pinned-kql", revision), Namespace: "default"}, cfgMap)).To(Succeed())
+		return cfgMap.Data["kql"]
+	}
+
+	It("Should keep deploying the pinned version after the config map changes", func() {
+		cfgMap := &v1.ConfigMap{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "pinned-kql", Namespace: "default"}, cfgMap)).To(Succeed())
+		pin(cfgMap.ResourceVersion)
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getTemplate().Status.CurrentRevision).To(Equal(int32(0)))
+
+		updateSource(".create table T2 (a:string)")
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		template := getTemplate()
+		Expect(template.Status.CurrentRevision).To(Equal(int32(0)))
+		Expect(template.Status.PinnedVersion).To(Equal(template.Spec.VersionPin))
+		Expect(revisionKQL(0)).To(Equal(".create table T1 (a:string)"))
+
+		template.Spec.VersionPin = nil
+		Expect(reconciler.Update(ctx, template)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		template = getTemplate()
+		Expect(template.Status.CurrentRevision).To(Equal(int32(1)))
+		Expect(template.Status.PinnedVersion).To(BeNil())
+		Expect(revisionKQL(1)).To(Equal(".create table T2 (a:string)"))
+	})
+
+	It("Should mark the deployment invalid when the pinned version does not exist", func() {
+		pin("424242")
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		template := getTemplate()
+		Expect(meta.IsStatusConditionTrue(template.Status.Conditions, schemav1alpha1.ConditionInvalid)).To(BeTrue())
+		Expect(template.Status.CurrentVerDeployment.Name).To(BeEmpty())
+	})
+})
+
+var _ = Describe("SchemaDeploymentCancel", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "cancelled", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+
+	BeforeEach(func() {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "cancelled-0-cluster1", Namespace: "default"},
+			Status: schemav1alpha1.ClusterExecuterStatus{
+				Running: true,
+				Config:  schemav1alpha1.ExecutionConfiguration{JobID: "job-not-running"},
+			},
+		}
+		reconciler = &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer,
+				&schemav1alpha1.VersionedDeplyment{
+					ObjectMeta: metav1.ObjectMeta{Name: "cancelled-0", Namespace: "default"},
+					Status: schemav1alpha1.VersionedDeplymentStatus{
+						Executers: []schemav1alpha1.NamespacedName{{Name: executer.Name, Namespace: executer.Namespace}},
+					},
+				},
+				&schemav1alpha1.SchemaDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        key.Name,
+						Namespace:   key.Namespace,
+						Annotations: map[string]string{schemav1alpha1.CancelAnnotation: "2026-01-01T00:00:00Z"},
+					},
+					Status: schemav1alpha1.SchemaDeploymentStatus{
+						CurrentVerDeployment: schemav1alpha1.NamespacedName{Name: "cancelled-0", Namespace: "default"},
+					},
+				}).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	It("Should lock the current revision and clear the cancel request", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		deployment := &schemav1alpha1.VersionedDeplyment{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "cancelled-0", Namespace: "default"}, deployment)).To(Succeed())
+		Expect(deployment.Annotations).To(HaveKeyWithValue("lock", "true"))
+
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, key, template)).To(Succeed())
+		Expect(template.Annotations).NotTo(HaveKey(schemav1alpha1.CancelAnnotation))
+	})
+})
+
+var _ = Describe("SchemaDeploymentReconcileInterval", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "interval", Namespace: "default"}
+	var reconciler *SchemaDeploymentReconciler
+
+	setup := func(interval *metav1.Duration) {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "interval-kql", Namespace: "default"},
+			Data:       map[string]string{"kql": ".create table T1 (a:string)"},
+		}
+		reconciler = &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap,
+				&schemav1alpha1.SchemaDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+					Spec: schemav1alpha1.SchemaDeploymentSpec{
+						Type:              schemav1alpha1.DBTypeKusto,
+						Source:            schemav1alpha1.NamespacedName{Name: "interval-kql", Namespace: "default"},
+						ReconcileInterval: interval,
+					},
+				}).Build(),
+			Log:             ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+			Scheme:          newFakeScheme(),
+			recorder:        record.NewFakeRecorder(10),
+			RequeueInterval: 3 * time.Minute,
+		}
+	}
+	reconcile := func() ctrl.Result {
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	It("Should requeue at the reconcile interval", func() {
+		setup(&metav1.Duration{Duration: 5 * time.Second})
+		Expect(reconcile()).To(Equal(ctrl.Result{Requeue: true}))
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+	})
+	It("Should requeue right away once the interval changes", func() {
+		setup(&metav1.Duration{Duration: 5 * time.Second})
+		reconcile()
+		reconcile()
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, key, template)).To(Succeed())
+		template.Spec.ReconcileInterval = &metav1.Duration{Duration: 30 * time.Second}
+		Expect(reconciler.Update(ctx, template)).To(Succeed())
+		Expect(reconcile()).To(Equal(ctrl.Result{Requeue: true}))
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: 30 * time.Second}))
+	})
+	It("Should use the operator requeue interval without a reconcile interval", func() {
+		setup(nil)
+		Expect(reconcile()).To(Equal(ctrl.Result{RequeueAfter: 3 * time.Minute}))
+		reconciler.RequeueInterval = 0
+		Expect(reconciler.requeueInterval(&schemav1alpha1.SchemaDeployment{})).To(Equal(DefaultRequeueInterval))
+	})
+})
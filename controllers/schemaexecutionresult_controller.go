@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// DefaultMaxResultAge is the time schema execution results are kept, unless the max result age setting is set.
+const DefaultMaxResultAge = 7 * 24 * time.Hour
+
+// SchemaExecutionResultReconciler garbage collects the SchemaExecutionResult objects older than the max result age
+type SchemaExecutionResultReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemaexecutionresults,verbs=get;list;watch;create;delete
+
+// Reconcile deletes the result once the max result age passed since its execution completed, and otherwise requeues it until then.
+func (r *SchemaExecutionResultReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaExecutionResult/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaExecutionResult", req.NamespacedName)
+
+	result := &schemav1alpha1.SchemaExecutionResult{}
+	err := r.Get(ctx, req.NamespacedName, result)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if remaining := result.Spec.CompletedAt.Add(maxResultAge()).Sub(time.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("schema execution result expired - deleting", "completedAt", result.Spec.CompletedAt)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, result))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaExecutionResultReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaExecutionResult{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(r.Health.Wrap(r))
+}
+
+// maxResultAge returns the time a schema execution result is kept.
+func maxResultAge() time.Duration {
+	if age := config.GetDuration(config.MaxResultAgeKey); age > 0 {
+		return age
+	}
+	return DefaultMaxResultAge
+}
+
+// recordExecutionResult writes the outcome of the delta-kusto jobs run by the execution described by `config` to a new
+// `SchemaExecutionResult` owned by the executer, when the cluster type records it.
+func (r *ClusterExecuterReconciler) recordExecutionResult(ctx context.Context, cluster clusterUtils.Cluster, executer *schemav1alpha1.ClusterExecuter, config schemav1alpha1.ExecutionConfiguration, targets schemav1alpha1.ClusterTargets, checksum string) error {
+	reader, ok := cluster.(clusterUtils.ResultReader)
+	if !ok {
+		return nil
+	}
+	execution, err := reader.ExecutionResult(config)
+	if err != nil || execution == nil {
+		return err
+	}
+	result := &schemav1alpha1.SchemaExecutionResult{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", executer.Name, execution.StartedAt.UTC().Format("20060102150405")),
+			Namespace: executer.Namespace,
+			Labels:    map[string]string{schemav1alpha1.ComplianceExecuterLabel: executer.Name},
+		},
+		Spec: schemav1alpha1.SchemaExecutionResultSpec{
+			JobID:            config.JobID,
+			ClusterURI:       executer.Spec.ClusterUri,
+			Databases:        targets.DBs,
+			DeltaKustoOutput: execution.Output,
+			StartedAt:        metav1.NewTime(execution.StartedAt),
+			CompletedAt:      metav1.NewTime(execution.CompletedAt),
+			ExitCode:         execution.ExitCode,
+			Checksum:         checksum,
+		},
+	}
+	if err := ctrl.SetControllerReference(executer, result, r.Scheme); err != nil {
+		return err
+	}
+	err = r.Create(ctx, result)
+	if errors.IsAlreadyExists(err) {
+		r.Log.Info("schema execution result already written", "result", result.Name)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// resultCluster is a scripted cluster recording the `result` for every execution.
+type resultCluster struct {
+	scriptedCluster
+	result *kustoutils.ExecutionResult
+}
+
+func (c *resultCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
+	return schemav1alpha1.ExecutionConfiguration{JobID: "job-1234"}, nil
+}
+
+func (c *resultCluster) ExecutionResult(config schemav1alpha1.ExecutionConfiguration) (*kustoutils.ExecutionResult, error) {
+	return c.result, nil
+}
+
+var _ = Describe("SchemaExecutionResult", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "results-1-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	startedAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	var c client.Client
+
+	BeforeEach(func() {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "results-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "executer-uid"},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer).Build()
+	})
+	execute := func(cluster *resultCluster) error {
+		reconciler := &ClusterExecuterReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaExecutionResultTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		return err
+	}
+	listResults := func() []schemav1alpha1.SchemaExecutionResult {
+		results := &schemav1alpha1.SchemaExecutionResultList{}
+		Expect(c.List(ctx, results, client.InNamespace(key.Namespace))).To(Succeed())
+		return results.Items
+	}
+
+	It("Should write the result of the execution owned by the executer", func() {
+		Expect(execute(&resultCluster{
+			scriptedCluster: scriptedCluster{targets: targets},
+			result:          &kustoutils.ExecutionResult{Output: `{"actions":2}`, StartedAt: startedAt, CompletedAt: startedAt.Add(time.Minute)},
+		})).To(Succeed())
+
+		results := listResults()
+		Expect(results).To(HaveLen(1))
+		result := results[0]
+		Expect(result.Name).To(Equal(key.Name + "-20220601120000"))
+		Expect(result.Labels).To(HaveKeyWithValue(schemav1alpha1.ComplianceExecuterLabel, key.Name))
+		Expect(result.Spec.JobID).To(Equal("job-1234"))
+		Expect(result.Spec.ClusterURI).To(Equal(uri))
+		Expect(result.Spec.Databases).To(Equal(targets.DBs))
+		Expect(result.Spec.DeltaKustoOutput).To(Equal(`{"actions":2}`))
+		Expect(result.Spec.StartedAt.Time).To(BeTemporally("==", startedAt))
+		Expect(result.Spec.CompletedAt.Time).To(BeTemporally("==", startedAt.Add(time.Minute)))
+		Expect(result.Spec.ExitCode).To(BeZero())
+
+		owner := metav1.GetControllerOf(&result)
+		Expect(owner).NotTo(BeNil())
+		Expect(owner.Kind).To(Equal("ClusterExecuter"))
+		Expect(owner.Name).To(Equal(key.Name))
+		Expect(owner.UID).To(Equal(types.UID("executer-uid")))
+	})
+	It("Should write the result of a failed execution", func() {
+		_ = execute(&resultCluster{
+			scriptedCluster: scriptedCluster{targets: targets, err: errors.New("delta-kusto failed")},
+			result:          &kustoutils.ExecutionResult{Output: "connection refused", StartedAt: startedAt, CompletedAt: startedAt, ExitCode: 3},
+		})
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		Expect(executer.Status.Failed).To(BeTrue())
+
+		results := listResults()
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Spec.ExitCode).To(Equal(3))
+		Expect(results[0].Spec.DeltaKustoOutput).To(Equal("connection refused"))
+	})
+	It("Should not write a result when the cluster recorded none", func() {
+		Expect(execute(&resultCluster{scriptedCluster: scriptedCluster{targets: targets}})).To(Succeed())
+		Expect(listResults()).To(BeEmpty())
+	})
+	It("Should prune the results older than the max result age", func() {
+		result := func(name string, completedAt time.Time) *schemav1alpha1.SchemaExecutionResult {
+			return &schemav1alpha1.SchemaExecutionResult{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: key.Namespace},
+				Spec: schemav1alpha1.SchemaExecutionResultSpec{
+					JobID:       name,
+					ClusterURI:  uri,
+					StartedAt:   metav1.NewTime(completedAt),
+					CompletedAt: metav1.NewTime(completedAt),
+				},
+			}
+		}
+		old := result("old", time.Now().Add(-DefaultMaxResultAge-time.Minute))
+		recent := result("recent", time.Now().Add(-time.Hour))
+		Expect(c.Create(ctx, old)).To(Succeed())
+		Expect(c.Create(ctx, recent)).To(Succeed())
+
+		gc := &SchemaExecutionResultReconciler{
+			Client: c,
+			Log:    ctrl.Log.WithName("controllers").WithName("SchemaExecutionResultGCTest"),
+			Scheme: newFakeScheme(),
+		}
+		res, err := gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(old)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		err = c.Get(ctx, client.ObjectKeyFromObject(old), &schemav1alpha1.SchemaExecutionResult{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		res, err = gc.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(recent)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically("~", DefaultMaxResultAge-time.Hour, time.Minute))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(recent), &schemav1alpha1.SchemaExecutionResult{})).To(Succeed())
+	})
+})
@@ -0,0 +1,120 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// schemaGroupResyncInterval is the time between syncs of the schema group role assignments, reverting manual changes.
+const schemaGroupResyncInterval = 10 * time.Minute
+
+// SchemaGroupReconciler reconciles a SchemaGroup object
+type SchemaGroupReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+	// RoleAssignments is the ARM role assignments client, nil uses a client authorized with the environment credentials.
+	RoleAssignments *schemaregistry.RoleAssignmentsClient
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemagroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemagroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemagroups/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile syncs the role assignments of the schema group with its `groupRBAC`,
+// or plans the changes into the status in dry run mode.
+func (r *SchemaGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaGroup/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaGroup", req.NamespacedName)
+
+	group := &schemav1alpha1.SchemaGroup{}
+	err := r.Get(ctx, req.NamespacedName, group)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	rbac, err := r.roleAssignments()
+	if err != nil {
+		log.Error(err, "failed to create the role assignments client")
+		return ctrl.Result{}, err
+	}
+	changes, err := eventhubs.SyncGroupRBAC(ctx, rbac, group.Scope(), group.Spec.GroupRBAC, group.Spec.DryRun)
+	var unknownRole eventhubs.ErrUnknownGroupRole
+	if errors.As(err, &unknownRole) {
+		log.Info("invalid schema group role", "principal", unknownRole.Principal, "role", unknownRole.Role)
+		r.recorder.Event(group, corev1.EventTypeWarning, "Invalid", err.Error())
+		return ctrl.Result{}, r.setReady(ctx, group, metav1.ConditionFalse, "InvalidRole", err.Error())
+	}
+	if err != nil {
+		log.Error(err, "failed syncing the schema group role assignments")
+		r.recorder.Eventf(group, corev1.EventTypeWarning, "Failed", "failed syncing the role assignments: %s", err.Error())
+		if statusErr := r.setReady(ctx, group, metav1.ConditionFalse, "SyncFailed", err.Error()); statusErr != nil {
+			log.Error(statusErr, "failed updating status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	if group.Spec.DryRun {
+		group.Status.PlannedChanges = changes
+		return ctrl.Result{RequeueAfter: schemaGroupResyncInterval}, r.setReady(ctx, group, metav1.ConditionTrue, "DryRun", "")
+	}
+	for _, change := range changes {
+		r.recorder.Eventf(group, corev1.EventTypeNormal, string(change.Action), "%s role %s of %s", change.Action, change.Role, change.Principal)
+	}
+	group.Status.PlannedChanges = nil
+	return ctrl.Result{RequeueAfter: schemaGroupResyncInterval}, r.setReady(ctx, group, metav1.ConditionTrue, "Synced", "")
+}
+
+func (r *SchemaGroupReconciler) roleAssignments() (schemaregistry.RoleAssignmentsClient, error) {
+	if r.RoleAssignments != nil {
+		return *r.RoleAssignments, nil
+	}
+	return schemaregistry.NewRoleAssignmentsClientFromEnvironment()
+}
+
+func (r *SchemaGroupReconciler) setReady(ctx context.Context, group *schemav1alpha1.SchemaGroup, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&group.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	return r.Status().Update(ctx, group)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("SchemaGroup")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaGroup{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Complete(r.Health.Wrap(r))
+}
@@ -0,0 +1,111 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("SchemaGroupController", func() {
+	const namespace = "default"
+	var (
+		ctx        context.Context
+		reconciler *SchemaGroupReconciler
+		server     *httptest.Server
+		methods    []string
+	)
+	groupKey := types.NamespacedName{Name: "orders", Namespace: namespace}
+
+	setup := func(dryRun bool, roles ...string) {
+		ctx = context.Background()
+		methods = nil
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"value":[]}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		rbac := schemaregistry.NewRoleAssignmentsClient()
+		rbac.Endpoint = strings.TrimPrefix(server.URL, "https://")
+		rbac.Sender = server.Client()
+
+		group := &schemav1alpha1.SchemaGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: groupKey.Name, Namespace: namespace},
+			Spec: schemav1alpha1.SchemaGroupSpec{
+				SubscriptionID: "sub",
+				ResourceGroup:  "rg",
+				Namespace:      "ns",
+				GroupRBAC:      map[string][]string{"alice": roles},
+				DryRun:         dryRun,
+			},
+		}
+		s := newFakeScheme()
+		reconciler = &SchemaGroupReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(s).WithObjects(group).Build(),
+			Log:             ctrl.Log.WithName("controllers").WithName("SchemaGroupTest"),
+			Scheme:          s,
+			recorder:        record.NewFakeRecorder(10),
+			RoleAssignments: &rbac,
+		}
+	}
+	AfterEach(func() {
+		server.Close()
+	})
+
+	reconcile := func() *schemav1alpha1.SchemaGroup {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: groupKey})
+		Expect(err).NotTo(HaveOccurred())
+		group := &schemav1alpha1.SchemaGroup{}
+		Expect(reconciler.Get(ctx, groupKey, group)).To(Succeed())
+		return group
+	}
+	readyReason := func(group *schemav1alpha1.SchemaGroup) string {
+		cond := meta.FindStatusCondition(group.Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(cond).NotTo(BeNil())
+		return cond.Reason
+	}
+
+	It("Should plan the role assignments in dry run", func() {
+		setup(true, schemav1alpha1.GroupRoleWriter)
+		group := reconcile()
+		Expect(readyReason(group)).To(Equal("DryRun"))
+		Expect(group.Status.PlannedChanges).To(Equal([]schemav1alpha1.RBACChange{
+			{Action: schemav1alpha1.RBACAdd, Principal: "alice", Role: schemav1alpha1.GroupRoleWriter},
+		}))
+		Expect(methods).To(Equal([]string{http.MethodGet}))
+	})
+
+	It("Should assign the roles", func() {
+		setup(false, schemav1alpha1.GroupRoleWriter)
+		group := reconcile()
+		Expect(readyReason(group)).To(Equal("Synced"))
+		Expect(group.Status.PlannedChanges).To(BeEmpty())
+		Expect(methods).To(Equal([]string{http.MethodGet, http.MethodPut}))
+	})
+
+	It("Should mark unknown roles invalid", func() {
+		setup(false, "Admin")
+		group := reconcile()
+		Expect(readyReason(group)).To(Equal("InvalidRole"))
+		Expect(methods).To(BeEmpty())
+	})
+})
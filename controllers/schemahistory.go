@@ -0,0 +1,122 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/schemaversions"
+)
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemahistories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemahistories/status,verbs=get;update;patch
+
+// recordSchemaHistory appends the applied revision to the `SchemaHistory` of the template, creating it if needed.
+func (r *SchemaDeploymentReconciler) recordSchemaHistory(ctx context.Context, template *schemav1alpha1.SchemaDeployment, dep *schemav1alpha1.VersionedDeplyment, cfgMap *corev1.ConfigMap) error {
+	history := &schemav1alpha1.SchemaHistory{}
+	err := r.Get(ctx, types.NamespacedName{Name: template.Name, Namespace: template.Namespace}, history)
+	if errors.IsNotFound(err) {
+		history = &schemav1alpha1.SchemaHistory{
+			ObjectMeta: metav1.ObjectMeta{Name: template.Name, Namespace: template.Namespace},
+			Spec:       schemav1alpha1.SchemaHistorySpec{MaxHistory: schemav1alpha1.DefaultMaxHistory},
+		}
+		err = ctrl.SetControllerReference(template, history, r.Scheme)
+		if err != nil {
+			return err
+		}
+		err = r.Create(ctx, history)
+	}
+	if err != nil {
+		return err
+	}
+
+	checksum := configMapChecksum(cfgMap)
+	if n := len(history.Status.History); n > 0 {
+		last := history.Status.History[n-1]
+		if last.Revision == template.Status.CurrentRevision && last.AppliedChecksum == checksum {
+			return nil
+		}
+	}
+	dbs, err := r.appliedDatabases(ctx, dep)
+	if err != nil {
+		return err
+	}
+	schemaversions.AppendHistory(history, schemav1alpha1.SchemaHistoryEntry{
+		Revision:                 template.Status.CurrentRevision,
+		ConfigMapResourceVersion: cfgMap.ResourceVersion,
+		AppliedChecksum:          checksum,
+		AppliedAt:                metav1.Now(),
+		Databases:                dbs,
+		ChangeSummary:            fmt.Sprintf("revision %d of %s/%s applied to %d databases", template.Status.CurrentRevision, cfgMap.Namespace, cfgMap.Name, len(dbs)),
+		AppliedBy:                lastManager(cfgMap),
+	})
+	return r.Status().Update(ctx, history)
+}
+
+// appliedDatabases returns the databases the executers of the versioned deployment applied the schema on.
+func (r *SchemaDeploymentReconciler) appliedDatabases(ctx context.Context, dep *schemav1alpha1.VersionedDeplyment) ([]string, error) {
+	seen := map[string]struct{}{}
+	dbs := []string{}
+	for _, name := range dep.Status.Executers {
+		if name.Name == "" {
+			continue
+		}
+		executer := &schemav1alpha1.ClusterExecuter{}
+		err := r.Get(ctx, types.NamespacedName(name), executer)
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range executer.Status.DoneTargets.DBs {
+			if _, found := seen[db]; !found {
+				seen[db] = struct{}{}
+				dbs = append(dbs, db)
+			}
+		}
+	}
+	sort.Strings(dbs)
+	return dbs, nil
+}
+
+// configMapChecksum returns the sha256 of the config map data.
+func configMapChecksum(cfgMap *corev1.ConfigMap) string {
+	keys := make([]string, 0, len(cfgMap.Data)+len(cfgMap.BinaryData))
+	for key := range cfgMap.Data {
+		keys = append(keys, key)
+	}
+	for key := range cfgMap.BinaryData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte(cfgMap.Data[key]))
+		h.Write(cfgMap.BinaryData[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lastManager returns the field manager of the latest change to the object.
+func lastManager(obj metav1.Object) string {
+	manager := ""
+	var latest *metav1.Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && (latest == nil || !entry.Time.Before(latest)) {
+			latest = entry.Time
+			manager = entry.Manager
+		}
+	}
+	return manager
+}
@@ -0,0 +1,86 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("SchemaHistoryRecording", func() {
+	ctx := context.Background()
+	var (
+		reconciler *SchemaDeploymentReconciler
+		template   *schemav1alpha1.SchemaDeployment
+		dep        *schemav1alpha1.VersionedDeplyment
+		cfgMap     *v1.ConfigMap
+	)
+
+	BeforeEach(func() {
+		template = &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "history", Namespace: "default", UID: "history-uid"},
+		}
+		cfgMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "history-kql", Namespace: "default"},
+			Data:       map[string]string{"kql": ".create table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "history-0-cluster1", Namespace: "default"},
+			Status: schemav1alpha1.ClusterExecuterStatus{
+				DoneTargets: schemav1alpha1.ClusterTargets{DBs: []string{"db2", "db1"}},
+			},
+		}
+		dep = &schemav1alpha1.VersionedDeplyment{
+			ObjectMeta: metav1.ObjectMeta{Name: "history-0", Namespace: "default"},
+			Status: schemav1alpha1.VersionedDeplymentStatus{
+				Executers: []schemav1alpha1.NamespacedName{{Name: executer.Name, Namespace: executer.Namespace}},
+			},
+		}
+		reconciler = &SchemaDeploymentReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(template, cfgMap, executer, dep).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaHistoryTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getHistory := func() *schemav1alpha1.SchemaHistory {
+		history := &schemav1alpha1.SchemaHistory{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "history", Namespace: "default"}, history)).To(Succeed())
+		return history
+	}
+
+	It("Should record every applied revision once", func() {
+		Expect(reconciler.recordSchemaHistory(ctx, template, dep, cfgMap)).To(Succeed())
+		history := getHistory()
+		Expect(history.OwnerReferences).To(HaveLen(1))
+		Expect(history.Status.History).To(HaveLen(1))
+		entry := history.Status.History[0]
+		Expect(entry.Revision).To(Equal(int32(0)))
+		Expect(entry.Databases).To(Equal([]string{"db1", "db2"}))
+		Expect(entry.ConfigMapResourceVersion).To(Equal(cfgMap.ResourceVersion))
+		Expect(entry.AppliedChecksum).To(Equal(configMapChecksum(cfgMap)))
+
+		Expect(reconciler.recordSchemaHistory(ctx, template, dep, cfgMap)).To(Succeed())
+		Expect(getHistory().Status.History).To(HaveLen(1))
+
+		template.Status.CurrentRevision = 1
+		cfgMap.Data["kql"] = ".create table T2 (a:string)"
+		Expect(reconciler.recordSchemaHistory(ctx, template, dep, cfgMap)).To(Succeed())
+		history = getHistory()
+		Expect(history.Status.History).To(HaveLen(2))
+		Expect(history.Status.History[1].Revision).To(Equal(int32(1)))
+		Expect(history.Status.History[1].AppliedChecksum).NotTo(Equal(entry.AppliedChecksum))
+	})
+})
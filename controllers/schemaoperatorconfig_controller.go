@@ -0,0 +1,140 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// SchemaOperatorConfigReconciler reloads the operator settings from the `default` SchemaOperatorConfig object
+type SchemaOperatorConfigReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// selection is the namespace selection of the `namespaceLabelSelector`, the shared `managedNamespaces` when nil.
+	selection *namespaceSelection
+	// required is the label requirement of the `requiredLabels`, the shared `managedLabels` when nil.
+	required *labelRequirement
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemaoperatorconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemaoperatorconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Reconcile overrides the environment settings with the settings of the `default` config,
+// once the config is deleted the operator falls back to the environment settings.
+func (r *SchemaOperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaOperatorConfig/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaOperatorConfig", req.Name)
+
+	operatorConfig := &schemav1alpha1.SchemaOperatorConfig{}
+	err := r.Get(ctx, req.NamespacedName, operatorConfig)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil && req.Name == schemav1alpha1.SchemaOperatorConfigName {
+			log.Info("operator config deleted - using the environment settings")
+			config.SetOverrides(nil)
+			_ = r.required.orManaged().setLabels(nil)
+			return ctrl.Result{}, r.selection.orManaged().setSelector(ctx, r.Client, nil)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	condition := metav1.Condition{
+		Type:               schemav1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Applied",
+		Message:            "the settings are applied",
+		ObservedGeneration: operatorConfig.Generation,
+	}
+	if operatorConfig.Name == schemav1alpha1.SchemaOperatorConfigName {
+		log.Info("reloading the operator settings")
+		config.SetOverrides(configOverrides(operatorConfig.Spec))
+		if err = r.selection.orManaged().setSelector(ctx, r.Client, operatorConfig.Spec.NamespaceLabelSelector); err != nil {
+			log.Error(err, "failed selecting the namespaces")
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "InvalidNamespaceSelector"
+			condition.Message = err.Error()
+		}
+		if err = r.required.orManaged().setLabels(operatorConfig.Spec.RequiredLabels); err != nil {
+			log.Error(err, "failed setting the required labels")
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "InvalidRequiredLabels"
+			condition.Message = err.Error()
+		}
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Ignored"
+		condition.Message = fmt.Sprintf("only the %s config is used", schemav1alpha1.SchemaOperatorConfigName)
+	}
+	meta.SetStatusCondition(&operatorConfig.Status.Conditions, condition)
+	return ctrl.Result{}, r.Status().Update(ctx, operatorConfig)
+}
+
+// configOverrides returns the configuration overrides of the settings set in `spec`.
+func configOverrides(spec schemav1alpha1.SchemaOperatorConfigSpec) map[string]interface{} {
+	values := map[string]interface{}{}
+	ints := map[string]int{
+		config.MaxParallelDBsKey: spec.MaxParallelDBs,
+		config.ParallelWorkers:   spec.SQLParallelWorkers,
+		config.MaxFailuresKey:    spec.MaxFailures,
+	}
+	for key, value := range ints {
+		if value > 0 {
+			values[key] = value
+		}
+	}
+	quantities := map[string]*resource.Quantity{
+		config.CPUPerWorkerKey: spec.CPUPerWorker,
+		config.MemPerWorkerKey: spec.MemPerWorker,
+	}
+	for key, value := range quantities {
+		if value != nil && !value.IsZero() {
+			values[key] = value.String()
+		}
+	}
+	if spec.SchemaURLMaxSize > 0 {
+		values[config.SchemaURLMaxSizeKey] = spec.SchemaURLMaxSize
+	}
+	durations := map[string]*metav1.Duration{
+		config.ExecutionTimeoutKey:       spec.ExecutionTimeout,
+		config.WebhookTimeoutKey:         spec.WebhookTimeout,
+		config.KustoPingTimeoutKey:       spec.KustoPingTimeout,
+		config.RegistryRequestTimeoutKey: spec.RegistryRequestTimeout,
+		config.ComplianceReportMaxAgeKey: spec.ComplianceReportMaxAge,
+		config.DryRunReportTTLKey:        spec.DryRunReportTTL,
+		config.MaxResultAgeKey:           spec.MaxResultAge,
+	}
+	for key, value := range durations {
+		if value != nil && value.Duration > 0 {
+			values[key] = value.Duration
+		}
+	}
+	return values
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaOperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaOperatorConfig{}).
+		Complete(r.Health.Wrap(r))
+}
@@ -0,0 +1,126 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// unreachableKusto is a kusto client failing every management command.
+type unreachableKusto struct {
+	gateKusto
+}
+
+func (u *unreachableKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	return nil, errors.New("no such host")
+}
+
+var _ = Describe("SchemaOperatorConfig", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: schemav1alpha1.SchemaOperatorConfigName}
+	var reconciler *SchemaOperatorConfigReconciler
+
+	BeforeEach(func() {
+		operatorConfig := &schemav1alpha1.SchemaOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name},
+			Spec: schemav1alpha1.SchemaOperatorConfigSpec{
+				MaxParallelDBs: 2,
+				WebhookTimeout: &metav1.Duration{Duration: 20 * time.Second},
+			},
+		}
+		s := newFakeScheme()
+		reconciler = &SchemaOperatorConfigReconciler{
+			Client: fake.NewClientBuilder().WithScheme(s).WithObjects(operatorConfig).Build(),
+			Log:    ctrl.Log.WithName("controllers").WithName("SchemaOperatorConfigTest"),
+			Scheme: s,
+		}
+	})
+	AfterEach(func() {
+		config.SetOverrides(nil)
+	})
+
+	reconcile := func(name string) *schemav1alpha1.SchemaOperatorConfig {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		Expect(err).NotTo(HaveOccurred())
+		operatorConfig := &schemav1alpha1.SchemaOperatorConfig{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: name}, operatorConfig)).To(Succeed())
+		return operatorConfig
+	}
+
+	It("Should reload the settings on every change", func() {
+		operatorConfig := reconcile(key.Name)
+		Expect(meta.IsStatusConditionTrue(operatorConfig.Status.Conditions, schemav1alpha1.ConditionReady)).To(BeTrue())
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(Equal(2))
+		Expect(config.GetDuration(config.WebhookTimeoutKey)).To(Equal(20 * time.Second))
+
+		operatorConfig.Spec.MaxParallelDBs = 6
+		operatorConfig.Spec.WebhookTimeout = nil
+		Expect(reconciler.Update(ctx, operatorConfig)).To(Succeed())
+		reconcile(key.Name)
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(Equal(6))
+		Expect(config.GetDuration(config.WebhookTimeoutKey)).To(BeZero())
+	})
+	It("Should fall back to the environment once deleted", func() {
+		reconcile(key.Name)
+		Expect(reconciler.Delete(ctx, &schemav1alpha1.SchemaOperatorConfig{ObjectMeta: metav1.ObjectMeta{Name: key.Name}})).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(BeZero())
+	})
+	It("Should ignore configs other than default", func() {
+		other := &schemav1alpha1.SchemaOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec:       schemav1alpha1.SchemaOperatorConfigSpec{MaxParallelDBs: 9},
+		}
+		Expect(reconciler.Create(ctx, other)).To(Succeed())
+		other = reconcile(other.Name)
+		Expect(meta.FindStatusCondition(other.Status.Conditions, schemav1alpha1.ConditionReady).Reason).To(Equal("Ignored"))
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(BeZero())
+	})
+	It("Should apply the max failures on the next executer reconcile", func() {
+		const uri = "https://cluster1.westeurope.kusto.windows.net"
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "failing-cluster1", Namespace: "default"},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{Type: schemav1alpha1.DBTypeKusto, ClusterUri: uri},
+			Status:     schemav1alpha1.ClusterExecuterStatus{Failed: true, NumFailures: 4},
+		}
+		s := newFakeScheme()
+		executerReconciler := &ClusterExecuterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(s).WithObjects(executer).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaOperatorConfigTest"),
+			Scheme:   s,
+			recorder: record.NewFakeRecorder(10),
+			KustoClients: &fakeClientFactory{clusters: map[string]*kustoutils.KustoCluster{
+				uri + "#": {URI: uri, Client: &unreachableKusto{}},
+			}},
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: executer.Name, Namespace: executer.Namespace}}
+		_, err := executerReconciler.Reconcile(ctx, req)
+		Expect(err).To(MatchError("max retries exhosted"))
+
+		operatorConfig := reconcile(key.Name)
+		operatorConfig.Spec.MaxFailures = 10
+		Expect(reconciler.Update(ctx, operatorConfig)).To(Succeed())
+		reconcile(key.Name)
+		res, err := executerReconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(minUnreachableBackoff))
+	})
+})
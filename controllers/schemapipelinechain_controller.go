@@ -0,0 +1,272 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// SchemaPipelineChainReconciler reconciles a SchemaPipelineChain object
+type SchemaPipelineChainReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+	// KustoClients creates the kusto clients running the gate queries, reusing them across reconciles (optional).
+	KustoClients kustoutils.ClientFactory
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinechains,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinechains/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinechains/finalizers,verbs=update
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemadeployments,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;update;create;patch;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile applies the stages of the chain in order - a stage is applied once the stage it waits for succeeded
+// and its gate query passed on the cluster of that stage. A failed stage blocks the stages waiting for it.
+func (r *SchemaPipelineChainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaPipelineChain/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaPipelineChain", req.NamespacedName)
+
+	chain := &schemav1alpha1.SchemaPipelineChain{}
+	err := r.Get(ctx, req.NamespacedName, chain)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	statuses := make([]schemav1alpha1.PipelineStageStatus, len(chain.Spec.Stages))
+	failed := -1
+	succeeded := 0
+	for i := range chain.Spec.Stages {
+		statuses[i], err = r.reconcileStage(ctx, chain, i, statuses)
+		if err != nil {
+			log.Error(err, "failed applying the stage", "stage", chain.Spec.Stages[i].Name)
+			return ctrl.Result{}, err
+		}
+		switch statuses[i].Phase {
+		case schemav1alpha1.PipelineStageSucceeded:
+			succeeded++
+		case schemav1alpha1.PipelineStageFailed, schemav1alpha1.PipelineStageGateFailed:
+			if failed < 0 {
+				failed = i
+			}
+		}
+	}
+
+	failedStage := ""
+	cond := metav1.Condition{Type: schemav1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "Progressing"}
+	switch {
+	case failed >= 0:
+		failedStage = statuses[failed].Name
+		cond.Reason = "StageFailed"
+		cond.Message = fmt.Sprintf("stage %s failed: %s", failedStage, statuses[failed].Message)
+		if chain.Status.FailedStage != failedStage {
+			r.recorder.Eventf(chain, corev1.EventTypeWarning, "StageFailed", "stage %s failed", failedStage)
+		}
+	case succeeded == len(statuses):
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Succeeded"
+	}
+	chain.Status.Stages = statuses
+	chain.Status.FailedStage = failedStage
+	meta.SetStatusCondition(&chain.Status.Conditions, cond)
+	err = r.Status().Update(ctx, chain)
+	if err != nil {
+		log.Error(err, "failed updating the chain status")
+		return ctrl.Result{}, err
+	}
+	if cond.Status != metav1.ConditionTrue {
+		// failed gates are retried, e.g. until the integration tests of the previous stage are done.
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileStage applies the stage `i` once the stage it waits for succeeded, and returns the stage status.
+// The gate query of the stage runs whenever the stage deployment is about to change.
+func (r *SchemaPipelineChainReconciler) reconcileStage(ctx context.Context, chain *schemav1alpha1.SchemaPipelineChain, i int, statuses []schemav1alpha1.PipelineStageStatus) (schemav1alpha1.PipelineStageStatus, error) {
+	stage := chain.Spec.Stages[i]
+	status := schemav1alpha1.PipelineStageStatus{Name: stage.Name, Phase: schemav1alpha1.PipelineStageWaiting}
+	wait := chain.AwaitedStage(i)
+	if wait >= 0 {
+		switch statuses[wait].Phase {
+		case schemav1alpha1.PipelineStageSucceeded:
+		case schemav1alpha1.PipelineStageFailed, schemav1alpha1.PipelineStageGateFailed, schemav1alpha1.PipelineStageBlocked:
+			status.Phase = schemav1alpha1.PipelineStageBlocked
+			status.Message = fmt.Sprintf("stage %s did not succeed", statuses[wait].Name)
+			return status, nil
+		default:
+			status.Message = fmt.Sprintf("waiting for stage %s", statuses[wait].Name)
+			return status, nil
+		}
+	}
+
+	source := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: stage.ConfigMapRef.Name, Namespace: chain.Namespace}, source)
+	if err != nil {
+		return status, err
+	}
+	key := types.NamespacedName{Name: chain.Name + "-" + stage.Name, Namespace: chain.Namespace}
+	status.Deployment = schemav1alpha1.NamespacedName(key)
+	inSync, err := r.stageInSync(ctx, chain, stage, source, key)
+	if err != nil {
+		return status, err
+	}
+	if !inSync {
+		if wait >= 0 && stage.GateQuery != "" {
+			passed, err := r.gatePasses(ctx, chain, chain.Spec.Stages[wait], stage)
+			if err != nil || !passed {
+				status.Phase = schemav1alpha1.PipelineStageGateFailed
+				status.Message = fmt.Sprintf("gate query returned no rows on %s", chain.Spec.Stages[wait].ClusterRef)
+				if err != nil {
+					status.Message = err.Error()
+				}
+				return status, nil
+			}
+		}
+		if err = copyConfigMap(ctx, r.Client, source, key); err != nil {
+			return status, err
+		}
+		if err = r.applyStage(ctx, chain, stage, key); err != nil {
+			return status, err
+		}
+		r.recorder.Eventf(chain, corev1.EventTypeNormal, "Applying", "applying stage %s to %s", stage.Name, stage.ClusterRef)
+	}
+
+	deployment := &schemav1alpha1.SchemaDeployment{}
+	err = r.Get(ctx, key, deployment)
+	if err != nil {
+		return status, err
+	}
+	status.Phase = schemav1alpha1.PipelineStageApplying
+	cond := meta.FindStatusCondition(deployment.Status.Conditions, schemav1alpha1.ConditionExecution)
+	switch {
+	case cond == nil:
+	case cond.Status == metav1.ConditionTrue:
+		status.Phase = schemav1alpha1.PipelineStageSucceeded
+	case cond.Status == metav1.ConditionFalse && cond.Reason == "Failed":
+		status.Phase = schemav1alpha1.PipelineStageFailed
+		status.Message = cond.Message
+	}
+	return status, nil
+}
+
+// stageInSync reports if the stage config map and deployment `key` already apply the `source` kql to the stage cluster.
+func (r *SchemaPipelineChainReconciler) stageInSync(ctx context.Context, chain *schemav1alpha1.SchemaPipelineChain, stage schemav1alpha1.PipelineStage, source *corev1.ConfigMap, key types.NamespacedName) (bool, error) {
+	cfgMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, key, cfgMap)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !reflect.DeepEqual(cfgMap.Data, source.Data) || !reflect.DeepEqual(cfgMap.BinaryData, source.BinaryData) {
+		return false, nil
+	}
+	deployment := &schemav1alpha1.SchemaDeployment{}
+	err = r.Get(ctx, key, deployment)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	desired := deployment.Spec.DeepCopy()
+	setStageSpec(desired, chain, stage, key)
+	return reflect.DeepEqual(*desired, deployment.Spec), nil
+}
+
+// applyStage creates or updates the schema deployment `key` of the stage, owned by the chain.
+func (r *SchemaPipelineChainReconciler) applyStage(ctx context.Context, chain *schemav1alpha1.SchemaPipelineChain, stage schemav1alpha1.PipelineStage, key types.NamespacedName) error {
+	deployment := &schemav1alpha1.SchemaDeployment{}
+	err := r.Get(ctx, key, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		deployment = &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				FailurePolicy:   schemav1alpha1.FailurePolicyRollback,
+				CooldownSeconds: schemav1alpha1.DefaultCooldownSeconds,
+			},
+		}
+		setStageSpec(&deployment.Spec, chain, stage, key)
+		if err = ctrl.SetControllerReference(chain, deployment, r.Scheme); err != nil {
+			return err
+		}
+		return r.Create(ctx, deployment)
+	}
+	setStageSpec(&deployment.Spec, chain, stage, key)
+	return r.Update(ctx, deployment)
+}
+
+// setStageSpec sets the fields of the deployment `spec` managed by the chain, keeping the other fields.
+func setStageSpec(spec *schemav1alpha1.SchemaDeploymentSpec, chain *schemav1alpha1.SchemaPipelineChain, stage schemav1alpha1.PipelineStage, key types.NamespacedName) {
+	spec.Type = chain.Spec.Type
+	spec.Source = schemav1alpha1.NamespacedName(key)
+	spec.FailIfDataLoss = chain.Spec.FailIfDataLoss
+	spec.ApplyTo.ClusterUris = []string{stage.ClusterRef}
+	spec.ApplyTo.DB = chain.Spec.DB
+	spec.ApplyTo.Regexp = chain.Spec.Regexp
+}
+
+// gatePasses runs the gate query of `stage` on the cluster of the `awaited` stage and reports if it returned any rows.
+func (r *SchemaPipelineChainReconciler) gatePasses(ctx context.Context, chain *schemav1alpha1.SchemaPipelineChain, awaited, stage schemav1alpha1.PipelineStage) (bool, error) {
+	if chain.Spec.Type != schemav1alpha1.DBTypeKusto {
+		return false, fmt.Errorf("gate queries are not supported for %s clusters", chain.Spec.Type)
+	}
+	if err := kustoutils.ValidatePreConditionKQL(stage.GateQuery); err != nil {
+		return false, fmt.Errorf("invalid gate query: %w", err)
+	}
+	db := stage.GateDatabase
+	if db == "" {
+		db = chain.Spec.DB
+	}
+	var cluster *kustoutils.KustoCluster
+	if r.KustoClients != nil {
+		cluster = r.KustoClients.GetOrCreateCluster(awaited.ClusterRef)
+	} else {
+		cluster = kustoutils.NewKustoCluster(awaited.ClusterRef)
+	}
+	return cluster.CheckPreCondition(ctx, db, stage.GateQuery)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaPipelineChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("SchemaPipelineChain")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaPipelineChain{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Owns(&schemav1alpha1.SchemaDeployment{}).
+		Complete(r.Health.Wrap(r))
+}
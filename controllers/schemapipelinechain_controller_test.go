@@ -0,0 +1,171 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/Azure/azure-kusto-go/kusto/data/value"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// gateKusto is a kusto client answering every query with `rows` rows, recording the queries.
+type gateKusto struct {
+	rows    int
+	queries []string
+}
+
+func (g *gateKusto) Close() error { return nil }
+
+func (g *gateKusto) Auth() kusto.Authorization { return kusto.Authorization{} }
+
+func (g *gateKusto) Endpoint() string { return "" }
+
+func (g *gateKusto) Query(ctx context.Context, db string, query kusto.Stmt, options ...kusto.QueryOption) (*kusto.RowIterator, error) {
+	g.queries = append(g.queries, query.String())
+	mr, err := kusto.NewMockRows(table.Columns{{Name: "Passed", Type: types.Bool}})
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < g.rows; i++ {
+		if err := mr.Row(value.Values{value.Bool{Valid: true, Value: true}}); err != nil {
+			return nil, err
+		}
+	}
+	iter := &kusto.RowIterator{}
+	return iter, iter.Mock(mr)
+}
+
+func (g *gateKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	panic("not implemented")
+}
+
+func (g *gateKusto) HttpClient() *http.Client { return &http.Client{} }
+
+var _ = Describe("SchemaPipelineChainController", func() {
+	const (
+		namespace     = "default"
+		validationURI = "https://validation.westeurope.kusto.windows.net"
+		productionURI = "https://production.westeurope.kusto.windows.net"
+	)
+	ctx := context.Background()
+	key := k8stypes.NamespacedName{Name: "orders", Namespace: namespace}
+	var (
+		reconciler *SchemaPipelineChainReconciler
+		gate       *gateKusto
+	)
+
+	BeforeEach(func() {
+		chain := &schemav1alpha1.SchemaPipelineChain{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: namespace},
+			Spec: schemav1alpha1.SchemaPipelineChainSpec{
+				Type:           schemav1alpha1.DBTypeKusto,
+				DB:             "orders",
+				FailIfDataLoss: true,
+				Stages: []schemav1alpha1.PipelineStage{
+					{Name: "validation", ClusterRef: validationURI, ConfigMapRef: corev1.LocalObjectReference{Name: "orders-kql"}},
+					{
+						Name:              "production",
+						ClusterRef:        productionURI,
+						ConfigMapRef:      corev1.LocalObjectReference{Name: "orders-kql"},
+						WaitForStageIndex: 0,
+						GateQuery:         "IntegrationTests | where Passed",
+					},
+				},
+			},
+		}
+		cfgMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders-kql", Namespace: namespace},
+			Data:       map[string]string{"kql": ".create-merge table Orders (id:string)"},
+		}
+		gate = &gateKusto{}
+		s := newFakeScheme()
+		reconciler = &SchemaPipelineChainReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(s).WithObjects(chain, cfgMap).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaPipelineChainTest"),
+			Scheme:   s,
+			recorder: record.NewFakeRecorder(10),
+			KustoClients: &fakeClientFactory{clusters: map[string]*kustoutils.KustoCluster{
+				validationURI + "#": {URI: validationURI, Client: gate},
+			}},
+		}
+	})
+
+	reconcile := func() *schemav1alpha1.SchemaPipelineChain {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		chain := &schemav1alpha1.SchemaPipelineChain{}
+		Expect(reconciler.Get(ctx, key, chain)).To(Succeed())
+		return chain
+	}
+
+	setExecution := func(stage string, status metav1.ConditionStatus, reason string) {
+		deployment := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, k8stypes.NamespacedName{Name: key.Name + "-" + stage, Namespace: namespace}, deployment)).To(Succeed())
+		meta.SetStatusCondition(&deployment.Status.Conditions, metav1.Condition{Type: schemav1alpha1.ConditionExecution, Status: status, Reason: reason})
+		Expect(reconciler.Status().Update(ctx, deployment)).To(Succeed())
+	}
+
+	productionDeployed := func() bool {
+		deployment := &schemav1alpha1.SchemaDeployment{}
+		return reconciler.Get(ctx, k8stypes.NamespacedName{Name: key.Name + "-production", Namespace: namespace}, deployment) == nil
+	}
+
+	It("Should advance to the next stage once the gate passes", func() {
+		chain := reconcile()
+		Expect(chain.Status.Stages[0].Phase).To(Equal(schemav1alpha1.PipelineStageApplying))
+		Expect(chain.Status.Stages[1].Phase).To(Equal(schemav1alpha1.PipelineStageWaiting))
+		Expect(productionDeployed()).To(BeFalse())
+
+		setExecution("validation", metav1.ConditionTrue, "Executed")
+		chain = reconcile()
+		Expect(gate.queries).To(Equal([]string{"IntegrationTests | where Passed"}))
+		Expect(chain.Status.Stages[1].Phase).To(Equal(schemav1alpha1.PipelineStageGateFailed))
+		Expect(chain.Status.FailedStage).To(Equal("production"))
+		Expect(meta.FindStatusCondition(chain.Status.Conditions, schemav1alpha1.ConditionReady).Reason).To(Equal("StageFailed"))
+		Expect(productionDeployed()).To(BeFalse())
+
+		gate.rows = 1
+		chain = reconcile()
+		Expect(chain.Status.Stages[1].Phase).To(Equal(schemav1alpha1.PipelineStageApplying))
+		Expect(chain.Status.FailedStage).To(BeEmpty())
+		deployment := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, k8stypes.NamespacedName(chain.Status.Stages[1].Deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.ApplyTo.ClusterUris).To(Equal([]string{productionURI}))
+		Expect(deployment.Spec.ApplyTo.DB).To(Equal("orders"))
+
+		setExecution("production", metav1.ConditionTrue, "Executed")
+		chain = reconcile()
+		Expect(meta.IsStatusConditionTrue(chain.Status.Conditions, schemav1alpha1.ConditionReady)).To(BeTrue())
+		Expect(gate.queries).To(HaveLen(2))
+	})
+
+	It("Should block the next stage when a stage fails", func() {
+		gate.rows = 1
+		reconcile()
+		setExecution("validation", metav1.ConditionFalse, "Failed")
+
+		chain := reconcile()
+		Expect(chain.Status.Stages[0].Phase).To(Equal(schemav1alpha1.PipelineStageFailed))
+		Expect(chain.Status.Stages[1].Phase).To(Equal(schemav1alpha1.PipelineStageBlocked))
+		Expect(chain.Status.FailedStage).To(Equal("validation"))
+		Expect(gate.queries).To(BeEmpty())
+		Expect(productionDeployed()).To(BeFalse())
+	})
+})
@@ -0,0 +1,194 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/go-logr/logr"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+)
+
+// SchemaPipelineStageReconciler reconciles a SchemaPipelineStage object
+type SchemaPipelineStageReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
+}
+
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinestages,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinestages/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemapipelinestages/finalizers,verbs=update
+//+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=schemadeployments,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;update;create;patch;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile promotes the stage once its previous stage is ready and, when required, the stage was approved.
+// The stage is ready once the schema deployment it promoted was executed.
+func (r *SchemaPipelineStageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "SchemaPipelineStage/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
+	log := r.Log.WithValues("SchemaPipelineStage", req.NamespacedName)
+
+	stage := &schemav1alpha1.SchemaPipelineStage{}
+	err := r.Get(ctx, req.NamespacedName, stage)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if stage.Spec.PreviousStage != nil {
+		previous := &schemav1alpha1.SchemaPipelineStage{}
+		err = r.Get(ctx, types.NamespacedName{Name: stage.Spec.PreviousStage.Name, Namespace: stage.Namespace}, previous)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed fetching the previous stage", "previous", stage.Spec.PreviousStage.Name)
+			return ctrl.Result{}, err
+		}
+		if err != nil || !previous.IsReady() {
+			log.Info("previous stage is not ready - waiting", "previous", stage.Spec.PreviousStage.Name)
+			return ctrl.Result{RequeueAfter: 1 * time.Minute}, r.setReady(ctx, stage, metav1.ConditionFalse, "WaitingForPreviousStage")
+		}
+	}
+
+	if stage.Spec.ApprovalRequired && !stage.IsApproved() {
+		log.Info("stage is waiting for approval")
+		return ctrl.Result{}, r.setReady(ctx, stage, metav1.ConditionFalse, "WaitingForApproval")
+	}
+
+	if !stage.Status.Promoted || stage.Spec.AutoPromotion {
+		err = r.PromoteStage(ctx, stage)
+		if err != nil {
+			log.Error(err, "failed promoting the stage")
+			r.recorder.Eventf(stage, corev1.EventTypeWarning, "Failed", "failed promoting %s", stage.Spec.SchemaRef.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	deployment := &schemav1alpha1.SchemaDeployment{}
+	err = r.Get(ctx, types.NamespacedName(stage.Status.Deployment), deployment)
+	if err != nil {
+		log.Error(err, "failed fetching the stage deployment")
+		return ctrl.Result{}, err
+	}
+	if !meta.IsStatusConditionTrue(deployment.Status.Conditions, schemav1alpha1.ConditionExecution) {
+		log.Info("stage deployment not executed yet")
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, r.setReady(ctx, stage, metav1.ConditionFalse, "Promoting")
+	}
+	return ctrl.Result{}, r.setReady(ctx, stage, metav1.ConditionTrue, "Executed")
+}
+
+// PromoteStage copies the schema referenced by the stage into a `ConfigMap` and `SchemaDeployment`
+// owned by the stage, targeting the stage cluster.
+func (r *SchemaPipelineStageReconciler) PromoteStage(ctx context.Context, stage *schemav1alpha1.SchemaPipelineStage) error {
+	source := &schemav1alpha1.SchemaDeployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: stage.Spec.SchemaRef.Name, Namespace: stage.Namespace}, source)
+	if err != nil {
+		return err
+	}
+	sourceCfgMap := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName(source.Spec.Source), sourceCfgMap)
+	if err != nil {
+		return err
+	}
+
+	name := stage.Name + "-" + source.Name
+	if err = copyConfigMap(ctx, r.Client, sourceCfgMap, types.NamespacedName{Name: name, Namespace: stage.Namespace}); err != nil {
+		return err
+	}
+
+	spec := *source.Spec.DeepCopy()
+	spec.Source = schemav1alpha1.NamespacedName{Name: name, Namespace: stage.Namespace}
+	spec.ApplyTo.ClusterUris = []string{stage.Spec.ClusterRef}
+
+	deployment := &schemav1alpha1.SchemaDeployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: stage.Namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		deployment = &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: stage.Namespace},
+			Spec:       spec,
+		}
+		if err = ctrl.SetControllerReference(stage, deployment, r.Scheme); err != nil {
+			return err
+		}
+		if err = r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if !reflect.DeepEqual(deployment.Spec, spec) {
+		deployment.Spec = spec
+		if err = r.Update(ctx, deployment); err != nil {
+			return err
+		}
+	}
+
+	r.recorder.Eventf(stage, corev1.EventTypeNormal, "Promoted", "promoted %s to %s", source.Name, stage.Spec.ClusterRef)
+	stage.Status.Promoted = true
+	stage.Status.Deployment = schemav1alpha1.NamespacedName{Name: name, Namespace: stage.Namespace}
+	return r.Status().Update(ctx, stage)
+}
+
+// copyConfigMap creates or updates the config map `key` with the data of `source`.
+// The copy is not owned - the schema deployment using it takes ownership, like for any schema source.
+func copyConfigMap(ctx context.Context, c client.Client, source *corev1.ConfigMap, key types.NamespacedName) error {
+	cfgMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, key, cfgMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		cfgMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Data:       source.Data,
+			BinaryData: source.BinaryData,
+		}
+		return c.Create(ctx, cfgMap)
+	}
+	if reflect.DeepEqual(cfgMap.Data, source.Data) && reflect.DeepEqual(cfgMap.BinaryData, source.BinaryData) {
+		return nil
+	}
+	cfgMap.Data = source.Data
+	cfgMap.BinaryData = source.BinaryData
+	return c.Update(ctx, cfgMap)
+}
+
+func (r *SchemaPipelineStageReconciler) setReady(ctx context.Context, stage *schemav1alpha1.SchemaPipelineStage, status metav1.ConditionStatus, reason string) error {
+	meta.SetStatusCondition(&stage.Status.Conditions, metav1.Condition{
+		Type:   schemav1alpha1.ConditionReady,
+		Status: status,
+		Reason: reason,
+	})
+	return r.Status().Update(ctx, stage)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SchemaPipelineStageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("SchemaPipelineStage")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&schemav1alpha1.SchemaPipelineStage{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
+		Owns(&schemav1alpha1.SchemaDeployment{}).
+		Complete(r.Health.Wrap(r))
+}
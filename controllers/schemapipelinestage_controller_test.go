@@ -0,0 +1,166 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// newFakeScheme returns a scheme with the core and operator types for fake clients.
+func newFakeScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	Expect(schemav1alpha1.AddToScheme(s)).To(Succeed())
+	return s
+}
+
+var _ = Describe("SchemaPipelineStageController", func() {
+	const namespace = "default"
+	var (
+		ctx        context.Context
+		reconciler *SchemaPipelineStageReconciler
+		stageKey   types.NamespacedName
+	)
+
+	newStage := func(name string, previous string, approvalRequired, autoPromotion bool) *schemav1alpha1.SchemaPipelineStage {
+		stage := &schemav1alpha1.SchemaPipelineStage{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: schemav1alpha1.SchemaPipelineStageSpec{
+				SchemaRef:        corev1.LocalObjectReference{Name: "schema"},
+				ClusterRef:       "https://" + name + ".westeurope.kusto.windows.net",
+				ApprovalRequired: approvalRequired,
+				AutoPromotion:    autoPromotion,
+			},
+		}
+		if previous != "" {
+			stage.Spec.PreviousStage = &corev1.LocalObjectReference{Name: previous}
+		}
+		return stage
+	}
+
+	setup := func(objs ...client.Object) {
+		ctx = context.Background()
+		cfgMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "schema-kql", Namespace: namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		source := &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "schema", Namespace: namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type:   schemav1alpha1.DBTypeKusto,
+				Source: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: namespace},
+				ApplyTo: schemav1alpha1.TargetFilter{
+					ClusterUris: []string{"https://dev.westeurope.kusto.windows.net"},
+					DB:          "db1",
+				},
+			},
+		}
+		objs = append(objs, cfgMap, source)
+		s := newFakeScheme()
+		reconciler = &SchemaPipelineStageReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SchemaPipelineStageTest"),
+			Scheme:   s,
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+
+	getStage := func() *schemav1alpha1.SchemaPipelineStage {
+		stage := &schemav1alpha1.SchemaPipelineStage{}
+		Expect(reconciler.Get(ctx, stageKey, stage)).To(Succeed())
+		return stage
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: stageKey})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	readyReason := func() string {
+		cond := meta.FindStatusCondition(getStage().Status.Conditions, schemav1alpha1.ConditionReady)
+		Expect(cond).NotTo(BeNil())
+		return cond.Reason
+	}
+
+	BeforeEach(func() {
+		stageKey = types.NamespacedName{Name: "staging", Namespace: namespace}
+	})
+
+	It("Should block until the previous stage is ready", func() {
+		dev := newStage("dev", "", false, true)
+		setup(dev, newStage("staging", "dev", false, true))
+
+		reconcile()
+		Expect(readyReason()).To(Equal("WaitingForPreviousStage"))
+		Expect(getStage().Status.Promoted).To(BeFalse())
+
+		meta.SetStatusCondition(&dev.Status.Conditions, metav1.Condition{Type: schemav1alpha1.ConditionReady, Status: metav1.ConditionTrue, Reason: "Executed"})
+		Expect(reconciler.Status().Update(ctx, dev)).To(Succeed())
+
+		reconcile()
+		Expect(getStage().Status.Promoted).To(BeTrue())
+		Expect(readyReason()).To(Equal("Promoting"))
+	})
+
+	It("Should wait for approval before promoting", func() {
+		setup(newStage("staging", "", true, true))
+
+		reconcile()
+		Expect(readyReason()).To(Equal("WaitingForApproval"))
+		Expect(getStage().Status.Promoted).To(BeFalse())
+
+		stage := getStage()
+		stage.SetAnnotations(map[string]string{schemav1alpha1.ApprovedAnnotation: "true"})
+		Expect(reconciler.Update(ctx, stage)).To(Succeed())
+
+		reconcile()
+		Expect(getStage().Status.Promoted).To(BeTrue())
+	})
+
+	It("Should promote the schema to the stage cluster", func() {
+		setup(newStage("staging", "", false, false))
+
+		reconcile()
+		deployment := &schemav1alpha1.SchemaDeployment{}
+		Expect(reconciler.Get(ctx, types.NamespacedName(getStage().Status.Deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.ApplyTo.ClusterUris).To(Equal([]string{"https://staging.westeurope.kusto.windows.net"}))
+		Expect(deployment.Spec.ApplyTo.DB).To(Equal("db1"))
+
+		meta.SetStatusCondition(&deployment.Status.Conditions, metav1.Condition{Type: schemav1alpha1.ConditionExecution, Status: metav1.ConditionTrue, Reason: "Executed"})
+		Expect(reconciler.Status().Update(ctx, deployment)).To(Succeed())
+		reconcile()
+		Expect(getStage().IsReady()).To(BeTrue())
+	})
+
+	It("Should only propagate schema changes with auto promotion", func() {
+		for _, autoPromotion := range []bool{false, true} {
+			setup(newStage("staging", "", false, autoPromotion))
+			reconcile()
+
+			source := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "schema-kql", Namespace: namespace}, source)).To(Succeed())
+			source.Data["kql"] = ".create-merge table T (a:string, b:string)"
+			Expect(reconciler.Update(ctx, source)).To(Succeed())
+			reconcile()
+
+			promoted := &corev1.ConfigMap{}
+			Expect(reconciler.Get(ctx, types.NamespacedName(getStage().Status.Deployment), promoted)).To(Succeed())
+			Expect(promoted.Data["kql"] == source.Data["kql"]).To(Equal(autoPromotion))
+		}
+	})
+})
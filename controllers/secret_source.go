@@ -0,0 +1,107 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// secretChecksumKey is the versioned config map key holding the checksum of the kql read from a `secretRef`.
+// The kql itself is never copied out of the secret.
+const secretChecksumKey = "secretChecksum"
+
+// readSecretKQL reads the kql from the `ref` key of a secret in `namespace`.
+// The secrets are read directly from the api server, so the operator only needs `get` on the secrets it uses.
+func readSecretKQL(ctx context.Context, reader client.Reader, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret)
+	if err != nil {
+		return "", err
+	}
+	kql, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, ref.Name, ref.Key)
+	}
+	return string(kql), nil
+}
+
+// sourceName returns the name of the schema source - the `source` config map or the `secretRef` secret.
+func sourceName(template *schemav1alpha1.SchemaDeployment) schemav1alpha1.NamespacedName {
+	if ref := template.Spec.SecretRef; ref != nil {
+		return schemav1alpha1.NamespacedName{Name: ref.Name, Namespace: template.Namespace}
+	}
+	return template.Spec.Source
+}
+
+// schemaDeploymentsForSecret maps a changed `Secret` to the schema deployments reading their kql from it with `secretRef`.
+// The secret of a `secretRef` is always in the namespace of its schema deployment.
+func (r *SchemaDeploymentReconciler) schemaDeploymentsForSecret(obj client.Object) []reconcile.Request {
+	deployments := &schemav1alpha1.SchemaDeploymentList{}
+	err := r.List(context.Background(), deployments, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed listing schema deployments for secret %s", obj.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, deployment := range deployments.Items {
+		if ref := deployment.Spec.SecretRef; ref != nil && ref.Name == obj.GetName() && managedLabels.selects(&deployment) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}})
+		}
+	}
+	return requests
+}
+
+// secretSourceConfigMap returns a config map standing for the `secretRef` of the template, holding only the kql checksum.
+// A changed secret changes the checksum, which creates a new revision.
+func (r *SchemaDeploymentReconciler) secretSourceConfigMap(ctx context.Context, template *schemav1alpha1.SchemaDeployment) (*corev1.ConfigMap, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	kql, err := readSecretKQL(ctx, reader, template.Namespace, template.Spec.SecretRef)
+	if err != nil {
+		return nil, err
+	}
+	source := sourceName(template)
+	cfgMap := &corev1.ConfigMap{}
+	cfgMap.Name = source.Name
+	cfgMap.Namespace = source.Namespace
+	cfgMap.Data = map[string]string{secretChecksumKey: kustoutils.ComputeKQLChecksum(kql)}
+	return cfgMap, nil
+}
+
+// secretKQL reads the kql of the executer `secretRef`, failing when the secret changed since the revision was created.
+func (r *ClusterExecuterReconciler) secretKQL(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, cfgMap *corev1.ConfigMap) (string, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	kql, err := readSecretKQL(ctx, reader, executer.Namespace, executer.Spec.SecretRef)
+	if err != nil {
+		return "", err
+	}
+	if checksum := cfgMap.Data[secretChecksumKey]; checksum != "" && checksum != kustoutils.ComputeKQLChecksum(kql) {
+		return "", fmt.Errorf("secret %s changed since revision %d was created", executer.Spec.SecretRef.Name, executer.Spec.Revision)
+	}
+	return kql, nil
+}
+
+// webhookHeaders returns the `webhookHeaders` of the executer target filter with their secret references read from the
+// namespace of the executer, since the kusto clusters are shared by the executers of every namespace.
+func (r *ClusterExecuterReconciler) webhookHeaders(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (map[string]string, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	return kustoutils.ResolveHeaderSecrets(ctx, reader, executer.Namespace, executer.Spec.ApplyTo.WebhookHeaders)
+}
@@ -0,0 +1,247 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/schemaversions"
+)
+
+// forbiddenReader is a reader without the permission to get secrets.
+type forbiddenReader struct {
+	client.Reader
+}
+
+func (r *forbiddenReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*v1.Secret); ok {
+		return errors.NewForbidden(v1.Resource("secrets"), key.Name, nil)
+	}
+	return r.Reader.Get(ctx, key, obj)
+}
+
+var _ = Describe("SchemaDeploymentSecretRef", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "secret-schema", Namespace: "default"}
+	const kql = ".create external table T (a:string) kind=storage dataformat=csv ( h@'https://account.blob.core.windows.net/c;sastoken' )"
+	var reconciler *SchemaDeploymentReconciler
+	var k8sClient client.Client
+
+	BeforeEach(func() {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "schema-kql", Namespace: key.Namespace},
+			Data:       map[string][]byte{"kql": []byte(kql)},
+		}
+		template := &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.SchemaDeploymentSpec{
+				Type: schemav1alpha1.DBTypeKusto,
+				SecretRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "schema-kql"},
+					Key:                  "kql",
+				},
+			},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(secret, template).Build()
+		reconciler = &SchemaDeploymentReconciler{
+			Client:   k8sClient,
+			Log:      ctrl.Log.WithName("controllers").WithName("SecretRefTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getTemplate := func() *schemav1alpha1.SchemaDeployment {
+		template := &schemav1alpha1.SchemaDeployment{}
+		Expect(k8sClient.Get(ctx, key, template)).To(Succeed())
+		return template
+	}
+	executerReconciler := func() *ClusterExecuterReconciler {
+		return &ClusterExecuterReconciler{
+			Client:   k8sClient,
+			Log:      ctrl.Log.WithName("controllers").WithName("SecretRefTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+	}
+
+	It("Should read the kql from the secret without copying it", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		template := getTemplate()
+		Expect(meta.FindStatusCondition(template.Status.Conditions, schemav1alpha1.ConditionInvalid)).To(BeNil())
+
+		verCfgMap := &v1.ConfigMap{}
+		cfgMapName := types.NamespacedName{Name: schemaversions.NameForConfigMap("schema-kql", 0), Namespace: key.Namespace}
+		Expect(k8sClient.Get(ctx, cfgMapName, verCfgMap)).To(Succeed())
+		Expect(verCfgMap.Data).To(HaveKey(secretChecksumKey))
+		Expect(verCfgMap.Data).NotTo(HaveKey("kql"))
+
+		deployment := &schemav1alpha1.VersionedDeplyment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName(template.Status.CurrentVerDeployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.SecretRef).To(Equal(template.Spec.SecretRef))
+
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-schema-0-cluster1", Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ConfigMapName: schemav1alpha1.NamespacedName(cfgMapName),
+				SecretRef:     deployment.Spec.SecretRef,
+			},
+		}
+		cfgMap, err := executerReconciler().schemaConfigMap(ctx, executer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfgMap.Data["kql"]).To(Equal(kql))
+
+		// the secret changes after the revision was created.
+		secret := &v1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "schema-kql", Namespace: key.Namespace}, secret)).To(Succeed())
+		secret.Data["kql"] = []byte(".create table T (a:string)")
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+		_, err = executerReconciler().schemaConfigMap(ctx, executer)
+		Expect(err).To(MatchError(ContainSubstring("changed since revision")))
+	})
+	It("Should reject a deployment with both a config map and a secret", func() {
+		template := getTemplate()
+		template.Spec.Source = schemav1alpha1.NamespacedName{Name: "schema-cfgmap", Namespace: key.Namespace}
+		Expect(k8sClient.Update(ctx, template)).To(Succeed())
+		Expect(template.ValidateCreate()).To(HaveOccurred())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		cond := meta.FindStatusCondition(getTemplate().Status.Conditions, schemav1alpha1.ConditionInvalid)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Reason).To(Equal("InvalidSource"))
+	})
+	It("Should report the missing permission to get the secret", func() {
+		reconciler.APIReader = &forbiddenReader{Reader: k8sClient}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		cond := meta.FindStatusCondition(getTemplate().Status.Conditions, schemav1alpha1.ConditionInvalid)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Reason).To(Equal("SecretForbidden"))
+		Expect(cond.Message).To(ContainSubstring("get on secrets in namespace default"))
+	})
+})
+
+var _ = Describe("SchemaDeploymentSecretWatch", func() {
+	newDeployment := func(name, namespace, secret string) *schemav1alpha1.SchemaDeployment {
+		deployment := &schemav1alpha1.SchemaDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       schemav1alpha1.SchemaDeploymentSpec{Type: schemav1alpha1.DBTypeKusto},
+		}
+		if secret != "" {
+			deployment.Spec.SecretRef = &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secret}, Key: "kql"}
+		} else {
+			deployment.Spec.Source = schemav1alpha1.NamespacedName{Name: "shared-kql", Namespace: namespace}
+		}
+		return deployment
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shared-kql",
+			Namespace: "default",
+			Labels:    map[string]string{schemav1alpha1.WatchLabel: "true"},
+		},
+	}
+
+	It("Should enqueue every schema deployment reading the secret", func() {
+		reconciler := &SchemaDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(
+				newDeployment("first", "default", "shared-kql"),
+				newDeployment("second", "default", "shared-kql"),
+				newDeployment("other-namespace", "other", "shared-kql"),
+				newDeployment("other-secret", "default", "other-kql"),
+				newDeployment("config-map", "default", ""),
+			).Build(),
+			Log: ctrl.Log.WithName("controllers").WithName("SchemaDeploymentTest"),
+		}
+		requests := reconciler.schemaDeploymentsForSecret(secret)
+		Expect(requests).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "first", Namespace: "default"}},
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "second", Namespace: "default"}},
+		))
+	})
+
+	It("Should only watch labeled secrets", func() {
+		Expect(watchedSource.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})).To(BeTrue())
+		unlabeled := secret.DeepCopy()
+		unlabeled.Labels = nil
+		Expect(watchedSource.Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: unlabeled})).To(BeFalse())
+	})
+})
+
+// filterCluster is a scripted cluster recording the target filter it was given.
+type filterCluster struct {
+	scriptedCluster
+	filter schemav1alpha1.TargetFilter
+}
+
+func (c *filterCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	c.filter = filter
+	return c.targets, nil
+}
+
+var _ = Describe("WebhookHeaders", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "headers-1-cluster1", Namespace: "default"}
+	headers := map[string]string{"Ocp-Apim-Subscription-Key": "$(apim:key)", "X-Team": "schemas"}
+
+	reconcile := func(objs ...client.Object) (*filterCluster, error) {
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "headers-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    "https://cluster1.westeurope.kusto.windows.net",
+				Type:          schemav1alpha1.DBTypeKusto,
+				ApplyTo:       schemav1alpha1.TargetFilter{Webhook: "https://dbs.example.com", WebhookHeaders: headers},
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(append(objs, cfgMap, executer)...).Build()
+		reconciler := &ClusterExecuterReconciler{
+			Client:   c,
+			Log:      ctrl.Log.WithName("controllers").WithName("WebhookHeadersTest"),
+			Scheme:   newFakeScheme(),
+			recorder: record.NewFakeRecorder(10),
+		}
+		cluster := &filterCluster{}
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, executer, cluster)
+		return cluster, err
+	}
+
+	It("Should read the header secrets from the namespace of the executer", func() {
+		cluster, err := reconcile(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "apim", Namespace: key.Namespace},
+			Data:       map[string][]byte{"key": []byte("s3cr3t")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.filter.WebhookHeaders).To(Equal(map[string]string{"Ocp-Apim-Subscription-Key": "s3cr3t", "X-Team": "schemas"}))
+		Expect(headers).To(HaveKeyWithValue("Ocp-Apim-Subscription-Key", "$(apim:key)"))
+	})
+	It("Should not acquire the targets without the header secret", func() {
+		_, err := reconcile(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "apim", Namespace: "other"},
+			Data:       map[string][]byte{"key": []byte("s3cr3t")},
+		})
+		Expect(err).To(MatchError(ContainSubstring("failed reading header Ocp-Apim-Subscription-Key from secret apim")))
+	})
+})
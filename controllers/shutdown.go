@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// DefaultGracefulShutdownTimeout is the time the running executions have to finish once the operator is stopped.
+const DefaultGracefulShutdownTimeout = 30 * time.Second
+
+// ExecutionGate tracks the running executions, and stops new executions from starting once the operator shuts down.
+// A nil gate lets every execution start.
+type ExecutionGate struct {
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+// Enter registers a starting execution, it returns false once the gate is closed.
+// Every execution that entered calls `Exit` when it is done.
+func (g *ExecutionGate) Enter() bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.running.Add(1)
+	return true
+}
+
+// Exit marks an execution that entered the gate done.
+func (g *ExecutionGate) Exit() {
+	if g != nil {
+		g.running.Done()
+	}
+}
+
+// Closed reports whether the operator is shutting down.
+func (g *ExecutionGate) Closed() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// Drain closes the gate and waits up to `timeout` for the running executions, it reports whether they all finished.
+func (g *ExecutionGate) Drain(timeout time.Duration) bool {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		g.running.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// DrainOnShutdown returns the context the manager runs in, which is done once `shutdown` is done (e.g. on SIGTERM)
+// and the running executions are drained with `DrainExecutions`.
+func DrainOnShutdown(shutdown context.Context, c client.Client, gate *ExecutionGate, timeout time.Duration) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		<-shutdown.Done()
+		if err := DrainExecutions(ctx, c, gate, timeout); err != nil {
+			logf.Log.WithName("shutdown").Error(err, "failed draining the running executions")
+		}
+	}()
+	return ctx
+}
+
+// DrainExecutions stops new executions and waits up to `timeout` for the running ones to finish.
+// The delta-kusto jobs still running after the timeout are cancelled, and the cluster executers still running
+// get the `Interrupted` condition, so the next operator instance runs them again.
+func DrainExecutions(ctx context.Context, c client.Client, gate *ExecutionGate, timeout time.Duration) error {
+	log := logf.Log.WithName("shutdown")
+	if timeout <= 0 {
+		timeout = DefaultGracefulShutdownTimeout
+	}
+	log.Info("draining the running executions", "timeout", timeout)
+	if !gate.Drain(timeout) {
+		log.Info("executions still running after the graceful shutdown timeout - cancelling their jobs")
+		if err := kustoutils.CancelAllSchemaJobs(ctx); err != nil {
+			log.Error(err, "failed cancelling the running delta-kusto jobs")
+		}
+		// the cancelled executions record their interruption themselves.
+		gate.Drain(kustoutils.DefaultCancelTimeout)
+	}
+
+	executers := &schemav1alpha1.ClusterExecuterList{}
+	if err := c.List(ctx, executers); err != nil {
+		return err
+	}
+	for i := range executers.Items {
+		executer := &executers.Items[i]
+		if !executer.Status.Running {
+			continue
+		}
+		log.Info("execution interrupted by the shutdown", "executer", client.ObjectKeyFromObject(executer))
+		if err := markInterrupted(ctx, c, executer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markInterrupted records that the operator stopped while `executer` was running,
+// it is no longer running so the next reconcile executes it again.
+func markInterrupted(ctx context.Context, c client.Client, executer *schemav1alpha1.ClusterExecuter) error {
+	executer.Status.Running = false
+	meta.SetStatusCondition(&executer.Status.Conditions, metav1.Condition{
+		Type:    schemav1alpha1.ConditionInterrupted,
+		Status:  metav1.ConditionTrue,
+		Reason:  "OperatorShutdown",
+		Message: "the operator stopped during the execution",
+	})
+	return c.Status().Update(ctx, executer)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+var _ = Describe("Graceful shutdown", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "shutdown-0-cluster1", Namespace: "default"}
+
+	newClient := func(running bool) client.Client {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec:       schemav1alpha1.ClusterExecuterSpec{ClusterUri: "https://cluster1.westeurope.kusto.windows.net"},
+			Status:     schemav1alpha1.ClusterExecuterStatus{Running: running},
+		}
+		return fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(executer).Build()
+	}
+	getExecuter := func(c client.Client) *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(c.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should wait for the running executions before stopping the manager", func() {
+		c := newClient(false)
+		gate := &ExecutionGate{}
+		Expect(gate.Enter()).To(BeTrue())
+		shutdown, sigterm := context.WithCancel(ctx)
+		managerCtx := DrainOnShutdown(shutdown, c, gate, time.Minute)
+
+		sigterm()
+		Eventually(gate.Closed).Should(BeTrue())
+		Expect(gate.Enter()).To(BeFalse())
+		Consistently(managerCtx.Done(), 200*time.Millisecond).ShouldNot(BeClosed())
+
+		gate.Exit()
+		Eventually(managerCtx.Done()).Should(BeClosed())
+		Expect(meta.FindStatusCondition(getExecuter(c).Status.Conditions, schemav1alpha1.ConditionInterrupted)).To(BeNil())
+	})
+
+	It("Should mark the executions still running after the timeout interrupted", func() {
+		c := newClient(true)
+		gate := &ExecutionGate{}
+		Expect(gate.Enter()).To(BeTrue())
+		start := time.Now()
+		Expect(DrainExecutions(ctx, c, gate, 100*time.Millisecond)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+
+		executer := getExecuter(c)
+		Expect(executer.Status.Running).To(BeFalse())
+		Expect(meta.IsStatusConditionTrue(executer.Status.Conditions, schemav1alpha1.ConditionInterrupted)).To(BeTrue())
+		gate.Exit()
+	})
+
+	It("Should requeue reconciles once the gate is closed", func() {
+		gate := &ExecutionGate{}
+		Expect(gate.Drain(time.Second)).To(BeTrue())
+		reconciler := &ClusterExecuterReconciler{
+			Client: newClient(false),
+			Log:    ctrl.Log.WithName("controllers").WithName("ShutdownTest"),
+			Scheme: newFakeScheme(),
+			Gate:   gate,
+		}
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Requeue).To(BeTrue())
+	})
+})
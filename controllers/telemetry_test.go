@@ -0,0 +1,106 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	faketelemetry "github.com/microsoft/azure-schema-operator/pkg/telemetry/fake"
+)
+
+var _ = Describe("ReconcilerTelemetry", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "telemetry-0-cluster1", Namespace: "default"}
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	var tracer *faketelemetry.Tracer
+	var operatorTelemetry *telemetry.SchemaOperatorTelemetry
+	var reconciler *ClusterExecuterReconciler
+
+	BeforeEach(func() {
+		tracer = &faketelemetry.Tracer{}
+		var err error
+		operatorTelemetry, err = telemetry.NewTelemetry(telemetry.TelemetryOptions{Registerer: prometheus.NewRegistry(), Tracer: tracer})
+		Expect(err).NotTo(HaveOccurred())
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "telemetry-kql", Namespace: key.Namespace},
+			Data:       map[string]string{"kql": ".create-merge table T (a:string)"},
+		}
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri:    uri,
+				Type:          schemav1alpha1.DBTypeKusto,
+				ConfigMapName: schemav1alpha1.NamespacedName{Name: cfgMap.Name, Namespace: cfgMap.Namespace},
+			},
+		}
+		reconciler = &ClusterExecuterReconciler{
+			Client:    fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(cfgMap, executer).Build(),
+			Log:       ctrl.Log.WithName("controllers").WithName("TelemetryTest"),
+			Scheme:    newFakeScheme(),
+			recorder:  record.NewFakeRecorder(10),
+			Telemetry: operatorTelemetry,
+		}
+	})
+	getExecuter := func() *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{}
+		Expect(reconciler.Get(ctx, key, executer)).To(Succeed())
+		return executer
+	}
+
+	It("Should count the executions of the cluster", func() {
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, getExecuter(), &scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, err: fmt.Errorf("failed")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getExecuter().Status.Failed).To(BeTrue())
+		_, err = reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, getExecuter(), &scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(operatorTelemetry.Metrics.Executions.WithLabelValues("cluster1", "success"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(operatorTelemetry.Metrics.Executions.WithLabelValues("cluster1", "failure"))).To(Equal(1.0))
+	})
+	It("Should pass the telemetry to the created kusto clusters", func() {
+		cluster, err := reconciler.newCluster(ctx, getExecuter(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		kustoCluster, ok := cluster.(*kustoutils.KustoCluster)
+		Expect(ok).To(BeTrue())
+		Expect(kustoCluster.Telemetry).To(BeIdenticalTo(operatorTelemetry))
+		Expect(kustoCluster.DeltaWrapper().Telemetry).To(BeIdenticalTo(operatorTelemetry))
+	})
+	It("Should trace the reconciles", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "missing", Namespace: key.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+		deployments := &SchemaDeploymentReconciler{
+			Client:    reconciler.Client,
+			Log:       ctrl.Log.WithName("controllers").WithName("TelemetryTest"),
+			Scheme:    newFakeScheme(),
+			Telemetry: operatorTelemetry,
+		}
+		_, err = deployments.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "missing", Namespace: key.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.SpanNames()).To(Equal([]string{"ClusterExecuter/Reconcile", "SchemaDeployment/Reconcile"}))
+		for _, span := range tracer.Spans() {
+			Expect(span.Ended).To(BeTrue())
+		}
+	})
+	It("Should reconcile without telemetry", func() {
+		reconciler.Telemetry = nil
+		_, err := reconciler.reconcileCluster(ctx, ctrl.Request{NamespacedName: key}, getExecuter(), &scriptedCluster{targets: schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "missing", Namespace: key.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,90 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	clusterUtils "github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/utils"
+)
+
+const (
+	// credentialClientIDKey is the credential secret key holding the client ID of the tenant service principal.
+	credentialClientIDKey = "clientId"
+	// credentialClientSecretKey is the credential secret key holding the client secret of the tenant service principal.
+	credentialClientSecretKey = "clientSecret"
+	// credentialTenantIDKey is the credential secret key holding the tenant, used when the spec has no `tenantID`.
+	credentialTenantIDKey = "tenantId"
+)
+
+// newCluster returns the cluster of the executer, kusto clusters of another tenant are authorized with the tenant credentials.
+// The clusters created for the reconcile get the reconciler telemetry, the cached ones the telemetry of the `KustoClients`.
+func (r *ClusterExecuterReconciler) newCluster(ctx context.Context, executer *schemav1alpha1.ClusterExecuter, notifier utils.NotifyProgressFunc) (clusterUtils.Cluster, error) {
+	uri := executer.Spec.ClusterUri
+	if executer.Spec.Type != schemav1alpha1.DBTypeKusto {
+		return clusterUtils.NewCluster(executer.Spec.Type, uri, r.Client, notifier), nil
+	}
+	creds, err := r.tenantCredentials(ctx, executer)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case creds != nil && r.KustoClients != nil:
+		return r.KustoClients.GetOrCreateTenantCluster(uri, *creds), nil
+	case creds != nil:
+		cluster := kustoutils.NewKustoClusterWithCredentials(uri, *creds)
+		cluster.SetTelemetry(r.Telemetry)
+		return cluster, nil
+	case r.KustoClients != nil:
+		return r.KustoClients.GetOrCreateCluster(uri), nil
+	}
+	cluster := kustoutils.NewKustoCluster(uri)
+	cluster.SetTelemetry(r.Telemetry)
+	return cluster, nil
+}
+
+// tenantCredentials returns the credentials of the executer tenant, nil for the operator identity.
+// Without a `CredentialSecretRef` the operator service principal is used in the `TenantID`.
+// The `CredentialSecretRef` may not reference a secret in another namespace.
+func (r *ClusterExecuterReconciler) tenantCredentials(ctx context.Context, executer *schemav1alpha1.ClusterExecuter) (*kustoutils.TenantCredentials, error) {
+	ref := executer.Spec.CredentialSecretRef
+	if ref == nil {
+		if executer.Spec.TenantID == "" {
+			return nil, nil
+		}
+		creds := kustoutils.OperatorTenantCredentials(executer.Spec.TenantID)
+		return &creds, nil
+	}
+	// the secret is read with the operator access, so it must be in the namespace of the executer.
+	namespace := executer.Namespace
+	if ref.Namespace != "" && ref.Namespace != namespace {
+		return nil, fmt.Errorf("credential secret %s/%s must be in the namespace %s of the executer", ref.Namespace, ref.Name, namespace)
+	}
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, err
+	}
+	creds := &kustoutils.TenantCredentials{
+		TenantID:     executer.Spec.TenantID,
+		ClientID:     string(secret.Data[credentialClientIDKey]),
+		ClientSecret: string(secret.Data[credentialClientSecretKey]),
+	}
+	if creds.TenantID == "" {
+		creds.TenantID = string(secret.Data[credentialTenantIDKey])
+	}
+	if creds.TenantID == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+		return nil, fmt.Errorf("credential secret %s/%s requires a tenant, %s and %s", namespace, ref.Name, credentialClientIDKey, credentialClientSecretKey)
+	}
+	return creds, nil
+}
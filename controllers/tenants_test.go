@@ -0,0 +1,123 @@
+package controllers
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+// fakeClientFactory creates a cluster per tenant and records the credentials it was asked for.
+type fakeClientFactory struct {
+	clusters map[string]*kustoutils.KustoCluster
+	creds    []kustoutils.TenantCredentials
+}
+
+func (f *fakeClientFactory) GetOrCreateCluster(uri string) *kustoutils.KustoCluster {
+	return f.GetOrCreateTenantCluster(uri, kustoutils.TenantCredentials{})
+}
+
+func (f *fakeClientFactory) GetOrCreateTenantCluster(uri string, creds kustoutils.TenantCredentials) *kustoutils.KustoCluster {
+	f.creds = append(f.creds, creds)
+	key := uri + "#" + creds.TenantID
+	if cluster, ok := f.clusters[key]; ok {
+		return cluster
+	}
+	cluster := &kustoutils.KustoCluster{URI: uri, TenantID: creds.TenantID}
+	f.clusters[key] = cluster
+	return cluster
+}
+
+var _ = Describe("ClusterExecuterTenants", func() {
+	ctx := context.Background()
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	var reconciler *ClusterExecuterReconciler
+	var factory *fakeClientFactory
+
+	credentialSecret := func(name string, data map[string]string) *v1.Secret {
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Data: map[string][]byte{}}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		return secret
+	}
+	executerFor := func(tenantID, secret string) *schemav1alpha1.ClusterExecuter {
+		executer := &schemav1alpha1.ClusterExecuter{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-0-cluster1", Namespace: "default"},
+			Spec: schemav1alpha1.ClusterExecuterSpec{
+				ClusterUri: uri,
+				Type:       schemav1alpha1.DBTypeKusto,
+				TenantID:   tenantID,
+			},
+		}
+		if secret != "" {
+			executer.Spec.CredentialSecretRef = &v1.SecretReference{Name: secret}
+		}
+		return executer
+	}
+
+	BeforeEach(func() {
+		factory = &fakeClientFactory{clusters: map[string]*kustoutils.KustoCluster{}}
+		reconciler = &ClusterExecuterReconciler{
+			Client: fake.NewClientBuilder().WithScheme(newFakeScheme()).WithObjects(
+				credentialSecret("customer-a", map[string]string{"clientId": "client-a", "clientSecret": "secret-a"}),
+				credentialSecret("customer-b", map[string]string{"tenantId": "tenant-b", "clientId": "client-b", "clientSecret": "secret-b"}),
+				credentialSecret("broken", map[string]string{"clientId": "client-c"}),
+			).Build(),
+			Log:          ctrl.Log.WithName("controllers").WithName("TenantsTest"),
+			Scheme:       newFakeScheme(),
+			recorder:     record.NewFakeRecorder(10),
+			KustoClients: factory,
+		}
+	})
+
+	It("Should use a different client for every tenant", func() {
+		clusterA, err := reconciler.newCluster(ctx, executerFor("tenant-a", "customer-a"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		clusterB, err := reconciler.newCluster(ctx, executerFor("", "customer-b"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clusterA).NotTo(BeIdenticalTo(clusterB))
+		Expect(factory.creds).To(Equal([]kustoutils.TenantCredentials{
+			{TenantID: "tenant-a", ClientID: "client-a", ClientSecret: "secret-a"},
+			{TenantID: "tenant-b", ClientID: "client-b", ClientSecret: "secret-b"},
+		}))
+
+		again, err := reconciler.newCluster(ctx, executerFor("tenant-a", "customer-a"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(clusterA))
+	})
+	It("Should use the operator identity without a tenant", func() {
+		cluster, err := reconciler.newCluster(ctx, executerFor("", ""), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.(*kustoutils.KustoCluster).TenantID).To(BeEmpty())
+		Expect(factory.creds).To(Equal([]kustoutils.TenantCredentials{{}}))
+	})
+	It("Should reject incomplete credentials", func() {
+		_, err := reconciler.newCluster(ctx, executerFor("tenant-c", "broken"), nil)
+		Expect(err).To(MatchError(ContainSubstring("clientSecret")))
+		_, err = reconciler.newCluster(ctx, executerFor("tenant-c", "missing"), nil)
+		Expect(err).To(HaveOccurred())
+		Expect(factory.creds).To(BeEmpty())
+	})
+	It("Should reject credentials of another namespace", func() {
+		executer := executerFor("tenant-a", "customer-a")
+		executer.Spec.CredentialSecretRef.Namespace = "kube-system"
+		_, err := reconciler.newCluster(ctx, executer, nil)
+		Expect(err).To(MatchError(ContainSubstring("must be in the namespace default")))
+		executer.Spec.CredentialSecretRef.Namespace = "default"
+		_, err = reconciler.newCluster(ctx, executer, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(factory.creds).To(HaveLen(1))
+	})
+})
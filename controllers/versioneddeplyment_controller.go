@@ -5,6 +5,7 @@ package controllers
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"time"
 
@@ -22,6 +23,8 @@ import (
 	"github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	"github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
 	"github.com/rs/zerolog/log"
 )
 
@@ -31,6 +34,12 @@ type VersionedDeplymentReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+	// Health records successful reconciles for the readiness probe (optional).
+	Health *health.Server
+	// Telemetry traces the reconciles (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	// Namespaces restricts the reconciled resources to these namespaces, empty reconciles all namespaces.
+	Namespaces []string
 }
 
 //+kubebuilder:rbac:groups=dbschema.microsoft.com,resources=versioneddeplyments,verbs=get;list;watch;create;update;patch;delete
@@ -50,6 +59,8 @@ type VersionedDeplymentReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
 func (r *VersionedDeplymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = r.Telemetry.StartSpan(ctx, "VersionedDeplyment/Reconcile")
+	defer r.Telemetry.EndSpan(ctx, nil)
 	log := r.Log.WithValues("SchemaDeployment", req.NamespacedName)
 	log.Info("VersionedDeplyment - start")
 	versionedDeplyment := &schemav1alpha1.VersionedDeplyment{}
@@ -205,8 +216,16 @@ func (r *VersionedDeplymentReconciler) executerForCluster(uri string, versionedD
 				Namespace: versionedDeplyment.Spec.ConfigMapName.Namespace,
 				Name:      versionedDeplyment.Spec.ConfigMapName.Name,
 			},
-			FailIfDataLoss: versionedDeplyment.Spec.FailIfDataLoss,
-			Revision:       versionedDeplyment.Spec.Revision,
+			FailIfDataLoss:              versionedDeplyment.Spec.FailIfDataLoss,
+			DatabaseRoles:               versionedDeplyment.Spec.DatabaseRoles,
+			Revision:                    versionedDeplyment.Spec.Revision,
+			CooldownSeconds:             versionedDeplyment.Spec.CooldownSeconds,
+			ObservationMode:             versionedDeplyment.Spec.ObservationMode,
+			SecretRef:                   versionedDeplyment.Spec.SecretRef,
+			TenantID:                    versionedDeplyment.Spec.TenantID,
+			CredentialSecretRef:         versionedDeplyment.Spec.CredentialSecretRef,
+			EventHubConnectionStringRef: versionedDeplyment.Spec.EventHubConnectionStringRef,
+			Priority:                    versionedDeplyment.Spec.Priority,
 		},
 		Status: schemav1alpha1.ClusterExecuterStatus{},
 	}
@@ -249,12 +268,61 @@ func (r *VersionedDeplymentReconciler) compareAndUpdateExecuter(ctx context.Cont
 		executer.Spec.ApplyTo.Schema = versionedDeplyment.Spec.ApplyTo.Schema
 		changed = true
 	}
+	if versionedDeplyment.Spec.ApplyTo.SchemaURL != executer.Spec.ApplyTo.SchemaURL {
+		executer.Spec.ApplyTo.SchemaURL = versionedDeplyment.Spec.ApplyTo.SchemaURL
+		changed = true
+	}
+	if versionedDeplyment.Spec.ApplyTo.FilterExpression != executer.Spec.ApplyTo.FilterExpression {
+		executer.Spec.ApplyTo.FilterExpression = versionedDeplyment.Spec.ApplyTo.FilterExpression
+		changed = true
+	}
+	if versionedDeplyment.Spec.ApplyTo.IncludeFollowers != executer.Spec.ApplyTo.IncludeFollowers {
+		executer.Spec.ApplyTo.IncludeFollowers = versionedDeplyment.Spec.ApplyTo.IncludeFollowers
+		changed = true
+	}
+	if !reflect.DeepEqual(versionedDeplyment.Spec.ApplyTo.DatabaseTimeouts, executer.Spec.ApplyTo.DatabaseTimeouts) {
+		executer.Spec.ApplyTo.DatabaseTimeouts = versionedDeplyment.Spec.ApplyTo.DatabaseTimeouts
+		changed = true
+	}
 
 	if versionedDeplyment.Spec.FailIfDataLoss != executer.Spec.FailIfDataLoss {
 		executer.Spec.FailIfDataLoss = versionedDeplyment.Spec.FailIfDataLoss
 		changed = true
 	}
 
+	if !reflect.DeepEqual(versionedDeplyment.Spec.DatabaseRoles, executer.Spec.DatabaseRoles) {
+		executer.Spec.DatabaseRoles = versionedDeplyment.Spec.DatabaseRoles
+		changed = true
+	}
+	if versionedDeplyment.Spec.CooldownSeconds != executer.Spec.CooldownSeconds {
+		executer.Spec.CooldownSeconds = versionedDeplyment.Spec.CooldownSeconds
+		changed = true
+	}
+	if versionedDeplyment.Spec.ObservationMode != executer.Spec.ObservationMode {
+		executer.Spec.ObservationMode = versionedDeplyment.Spec.ObservationMode
+		changed = true
+	}
+	if !reflect.DeepEqual(versionedDeplyment.Spec.SecretRef, executer.Spec.SecretRef) {
+		executer.Spec.SecretRef = versionedDeplyment.Spec.SecretRef
+		changed = true
+	}
+	if versionedDeplyment.Spec.TenantID != executer.Spec.TenantID {
+		executer.Spec.TenantID = versionedDeplyment.Spec.TenantID
+		changed = true
+	}
+	if !reflect.DeepEqual(versionedDeplyment.Spec.CredentialSecretRef, executer.Spec.CredentialSecretRef) {
+		executer.Spec.CredentialSecretRef = versionedDeplyment.Spec.CredentialSecretRef
+		changed = true
+	}
+	if !reflect.DeepEqual(versionedDeplyment.Spec.EventHubConnectionStringRef, executer.Spec.EventHubConnectionStringRef) {
+		executer.Spec.EventHubConnectionStringRef = versionedDeplyment.Spec.EventHubConnectionStringRef
+		changed = true
+	}
+	if versionedDeplyment.Spec.Priority != executer.Spec.Priority {
+		executer.Spec.Priority = versionedDeplyment.Spec.Priority
+		changed = true
+	}
+
 	if changed {
 		err = r.Update(ctx, executer)
 		if err != nil {
@@ -306,7 +374,8 @@ func (r *VersionedDeplymentReconciler) SetupWithManager(mgr ctrl.Manager) error
 	r.recorder = mgr.GetEventRecorderFor("VersionedDeplyment")
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&schemav1alpha1.VersionedDeplyment{}).
+		WithEventFilter(watchedNamespaces(r.Namespaces)).
 		Owns(&schemav1alpha1.ClusterExecuter{}).
 		Owns(&v1.ConfigMap{}).
-		Complete(r)
+		Complete(r.Health.Wrap(r))
 }
@@ -4,8 +4,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -15,11 +19,17 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	schemav1beta1 "github.com/microsoft/azure-schema-operator/api/v1beta1"
 	"github.com/microsoft/azure-schema-operator/controllers"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -31,6 +41,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(schemav1alpha1.AddToScheme(scheme))
+	utilruntime.Must(schemav1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -72,6 +83,28 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	scope, err := config.LoadScopeConfig(configFile)
+	if err != nil {
+		setupLog.Error(err, "unable to load the operator scope")
+		os.Exit(1)
+	}
+	namespaces, err := scope.Apply(&options, config.OperatorNamespace())
+	if err != nil {
+		setupLog.Error(err, "invalid operator scope")
+		os.Exit(1)
+	}
+	setupLog.Info("operator scope", "scope", scope.LeaderElection.OperatorScope, "namespaces", namespaces)
+
+	// the probes are served by the operator probe server instead of the manager.
+	probeServer := health.NewServer(options.HealthProbeBindAddress)
+	options.HealthProbeBindAddress = "0"
+
+	// the pod name identifies the operator instance in the schema leases.
+	leaseHolder, err := os.Hostname()
+	if err != nil {
+		setupLog.Error(err, "unable to read the hostname")
+		os.Exit(1)
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
@@ -79,43 +112,218 @@ func main() {
 		os.Exit(1)
 	}
 
+	// the telemetry metrics are served on the manager metrics endpoint.
+	operatorTelemetry, err := telemetry.NewTelemetry(telemetry.TelemetryOptions{LogLevel: zerolog.GlobalLevel()})
+	if err != nil {
+		setupLog.Error(err, "unable to set up the telemetry")
+		os.Exit(1)
+	}
+
+	if err = controllers.SetupFieldIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to index the schema deployments")
+		os.Exit(1)
+	}
 	if err = (&controllers.SchemaDeploymentReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("SchemaDeployment"),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("SchemaDeployment"),
+		Scheme:          mgr.GetScheme(),
+		APIReader:       mgr.GetAPIReader(),
+		Health:          probeServer,
+		Telemetry:       operatorTelemetry,
+		Namespaces:      namespaces,
+		RequeueInterval: config.GetDuration(config.RequeueIntervalKey),
+		WatchSecrets:    viper.GetBool(config.WatchSecretsKey),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SchemaDeployment")
 		os.Exit(1)
 	}
+	// the gate keeps new executions from starting once the operator is stopped.
+	gate := &controllers.ExecutionGate{}
+	kustoClients := kustoutils.NewClusterClientCache(viper.GetDuration(config.KustoClientTTLKey))
+	kustoClients.Telemetry = operatorTelemetry
 	if err = (&controllers.ClusterExecuterReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ClusterExecuter"),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("ClusterExecuter"),
+		Scheme:         mgr.GetScheme(),
+		Health:         probeServer,
+		Telemetry:      operatorTelemetry,
+		Namespaces:     namespaces,
+		KustoClients:   kustoClients,
+		APIReader:      mgr.GetAPIReader(),
+		LeaseHolder:    leaseHolder,
+		Gate:           gate,
+		GlobalPolicies: scope.LeaderElection.OperatorScope == config.ClusterScope,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterExecuter")
 		os.Exit(1)
 	}
 	if err = (&controllers.VersionedDeplymentReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("VersionedDeployment"),
-		Scheme: mgr.GetScheme(),
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("VersionedDeployment"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VersionedDeplyment")
 		os.Exit(1)
 	}
+	if err = (&controllers.SchemaPipelineStageReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("SchemaPipelineStage"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SchemaPipelineStage")
+		os.Exit(1)
+	}
+	if err = (&controllers.SchemaPipelineChainReconciler{
+		Client:       mgr.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("SchemaPipelineChain"),
+		Scheme:       mgr.GetScheme(),
+		Health:       probeServer,
+		Telemetry:    operatorTelemetry,
+		Namespaces:   namespaces,
+		KustoClients: kustoClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SchemaPipelineChain")
+		os.Exit(1)
+	}
+	if err = (&controllers.SchemaGroupReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("SchemaGroup"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SchemaGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.ComplianceReportReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("ComplianceReport"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ComplianceReport")
+		os.Exit(1)
+	}
+	if err = (&controllers.DryRunReportReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("DryRunReport"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DryRunReport")
+		os.Exit(1)
+	}
+	if err = (&controllers.JobArtifactReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("JobArtifact"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "JobArtifact")
+		os.Exit(1)
+	}
+	if err = (&controllers.SchemaExecutionResultReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("SchemaExecutionResult"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SchemaExecutionResult")
+		os.Exit(1)
+	}
+	if err = (&controllers.SchemaCompositionReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("SchemaComposition"),
+		Scheme:     mgr.GetScheme(),
+		Health:     probeServer,
+		Telemetry:  operatorTelemetry,
+		Namespaces: namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SchemaComposition")
+		os.Exit(1)
+	}
+	// a namespace scoped operator can't watch the cluster scoped operator config.
+	if scope.LeaderElection.OperatorScope == config.ClusterScope {
+		if err = (&controllers.SchemaOperatorConfigReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("SchemaOperatorConfig"),
+			Scheme:    mgr.GetScheme(),
+			Health:    probeServer,
+			Telemetry: operatorTelemetry,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SchemaOperatorConfig")
+			os.Exit(1)
+		}
+		if err = (&controllers.NamespaceReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("Namespace"),
+			Scheme:    mgr.GetScheme(),
+			Health:    probeServer,
+			Telemetry: operatorTelemetry,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Namespace")
+			os.Exit(1)
+		}
+		// the cache isn't started yet - the namespaces are listed from the API server.
+		if err = controllers.LoadNamespaceSelector(context.Background(), mgr.GetAPIReader()); err != nil {
+			setupLog.Error(err, "unable to select the namespaces")
+			os.Exit(1)
+		}
+	}
+	if viper.GetBool(config.EnableWebhooksKey) {
+		if err = (&schemav1alpha1.SchemaDeployment{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SchemaDeployment")
+			os.Exit(1)
+		}
+		if err = (&schemav1alpha1.ComplianceReport{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ComplianceReport")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+	for _, uri := range strings.Split(viper.GetString(config.StartupProbeClustersKey), ",") {
+		if uri = strings.TrimSpace(uri); uri == "" {
+			continue
+		}
+		uri := uri
+		probeServer.AddStartupCheck(uri, func(ctx context.Context) error {
+			_, err := kustoClients.GetOrCreateCluster(uri).TestConnection(ctx)
+			return err
+		})
+	}
+
+	if err := mgr.Add(probeServer.Runnable(mgr.GetCache())); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		setupLog.Error(err, "unable to set up ready check")
+
+	// the manager keeps running until the executions are drained, or the graceful shutdown timeout passes.
+	shutdown, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx := controllers.DrainOnShutdown(shutdown, mgr.GetClient(), gate, config.GetDuration(config.GracefulShutdownTimeoutKey))
+	if err := probeServer.Start(ctx); err != nil {
+		setupLog.Error(err, "unable to start probe server")
 		os.Exit(1)
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -3,6 +3,7 @@ package cluster
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 import (
+	"context"
 	"strings"
 
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
@@ -21,6 +22,56 @@ type Cluster interface {
 	CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error)
 }
 
+// RoleSyncer is implemented by cluster types that can manage database roles.
+type RoleSyncer interface {
+	SyncDatabaseRoles(ctx context.Context, targets schemav1alpha1.ClusterTargets, roles map[string][]string) error
+}
+
+// DiffReader is implemented by cluster types that support dry runs.
+type DiffReader interface {
+	SchemaDiff(config schemav1alpha1.ExecutionConfiguration) (kustoutils.SchemaDiff, error)
+}
+
+// AppliedDiffReader is implemented by cluster types that record the delta applied by an execution.
+type AppliedDiffReader interface {
+	AppliedDiffSummary(config schemav1alpha1.ExecutionConfiguration) (*schemav1alpha1.SchemaDiffSummary, error)
+}
+
+// ResultReader is implemented by cluster types that record the outcome of the jobs of an execution.
+type ResultReader interface {
+	ExecutionResult(config schemav1alpha1.ExecutionConfiguration) (*kustoutils.ExecutionResult, error)
+}
+
+// ProgressExecuter is implemented by cluster types that report the execution progress of every database.
+type ProgressExecuter interface {
+	ExecuteWithProgress(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress kustoutils.DatabaseProgressFunc) (schemav1alpha1.ClusterTargets, error)
+}
+
+// Observer is implemented by cluster types that compute the pending schema diff without applying it.
+type Observer interface {
+	ObserveSchema(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (kustoutils.SchemaDiff, error)
+}
+
+// ComplianceChecker is implemented by cluster types that can report the results of the schema assertions.
+type ComplianceChecker interface {
+	ComplianceResults(ctx context.Context, targets schemav1alpha1.ClusterTargets, assertions []schemav1alpha1.KQLAssertion) []schemav1alpha1.AssertionResult
+}
+
+// PolicyChecker is implemented by cluster types that can check the kql about to be applied against schema rules.
+type PolicyChecker interface {
+	CheckSchemaRules(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, rules []schemav1alpha1.SchemaRule) ([]kustoutils.RuleViolation, error)
+}
+
+// ObjectDropper is implemented by cluster types that can drop the objects defined in a schema.
+type ObjectDropper interface {
+	DropManagedObjects(ctx context.Context, targets schemav1alpha1.ClusterTargets, kql string) error
+}
+
+// Pinger is implemented by cluster types that can verify the cluster is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 // NewCluster will create an appropriate cluster implementation for the given type.
 func NewCluster(clusterType schemav1alpha1.DBTypeEnum, uri string, c client.Client, notifier utils.NotifyProgressFunc) Cluster {
 	switch clusterType {
@@ -0,0 +1,152 @@
+package cluster
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// leasePrefix prefixes the names of the schema leases, keeping them apart from other leases in the namespace.
+const leasePrefix = "schema-lock-"
+
+// ErrLeaseHeld is returned when the schema lease is held by another holder, the `Holder` is empty when it took the lease concurrently.
+type ErrLeaseHeld struct {
+	Resource types.NamespacedName
+	Holder   string
+}
+
+func (e ErrLeaseHeld) Error() string {
+	if e.Holder == "" {
+		return fmt.Sprintf("the schema lease of %s is held by another holder", e.Resource)
+	}
+	return fmt.Sprintf("the schema lease of %s is held by %s", e.Resource, e.Holder)
+}
+
+// AcquireSchemaLease takes the `Lease` of the resource `resourceKey` for `holder`, so schema executions of the
+// resource are not run by two operator instances at once. A lease held by another holder is taken over once it
+// was not renewed for `ttl`, otherwise `ErrLeaseHeld` is returned. The lease is renewed every third of `ttl` until
+// the returned function releases it, so an execution running longer than `ttl` keeps it.
+func AcquireSchemaLease(ctx context.Context, c client.Client, resourceKey types.NamespacedName, holder string, ttl time.Duration) (func(), error) {
+	key := types.NamespacedName{Namespace: resourceKey.Namespace, Name: leasePrefix + resourceKey.Name}
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(ttl.Seconds())
+	lease := &coordinationv1.Lease{}
+	err := c.Get(ctx, key, lease)
+	switch {
+	case apierrors.IsNotFound(err):
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		err = c.Create(ctx, lease)
+		if apierrors.IsAlreadyExists(err) {
+			return nil, ErrLeaseHeld{Resource: resourceKey}
+		}
+	case err != nil:
+	default:
+		if current := leaseHolder(lease); current != "" && current != holder && !leaseExpired(lease, now.Time) {
+			return nil, ErrLeaseHeld{Resource: resourceKey, Holder: current}
+		}
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.LeaseDurationSeconds = &seconds
+		lease.Spec.AcquireTime = &now
+		lease.Spec.RenewTime = &now
+		// the update fails with a conflict when another holder took the lease since it was read.
+		err = c.Update(ctx, lease)
+		if apierrors.IsConflict(err) {
+			return nil, ErrLeaseHeld{Resource: resourceKey}
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("failed acquiring the schema lease of %s", resourceKey)
+		return nil, err
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		renewSchemaLease(c, key, holder, ttl/3, stop)
+	}()
+	return func() {
+		close(stop)
+		<-done
+		releaseSchemaLease(c, key, holder)
+	}, nil
+}
+
+// renewSchemaLease renews the lease `key` of `holder` every `interval` until `stop` is closed or another holder took it over.
+func renewSchemaLease(c client.Client, key types.NamespacedName, holder string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx := context.Background()
+		lease := &coordinationv1.Lease{}
+		if err := c.Get(ctx, key, lease); err != nil {
+			log.Error().Err(err).Msgf("failed fetching the schema lease %s", key)
+			continue
+		}
+		if leaseHolder(lease) != holder {
+			log.Info().Msgf("the schema lease %s was taken over by %s", key, leaseHolder(lease))
+			return
+		}
+		now := metav1.NewMicroTime(time.Now())
+		lease.Spec.RenewTime = &now
+		if err := c.Update(ctx, lease); err != nil {
+			log.Error().Err(err).Msgf("failed renewing the schema lease %s", key)
+		}
+	}
+}
+
+// releaseSchemaLease clears the holder of the lease `key`, unless another holder took it over.
+func releaseSchemaLease(c client.Client, key types.NamespacedName, holder string) {
+	ctx := context.Background()
+	lease := &coordinationv1.Lease{}
+	err := c.Get(ctx, key, lease)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed fetching the schema lease %s", key)
+		return
+	}
+	if leaseHolder(lease) != holder {
+		log.Info().Msgf("the schema lease %s was taken over by %s", key, leaseHolder(lease))
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	err = c.Update(ctx, lease)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed releasing the schema lease %s", key)
+	}
+}
+
+func leaseHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// leaseExpired reports if the lease was not renewed within its duration.
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
@@ -0,0 +1,106 @@
+package cluster_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/cluster"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("AcquireSchemaLease", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+		key = types.NamespacedName{Namespace: "default", Name: "executer"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).Build()
+	})
+
+	It("should let a single reconciler hold the lease at a time", func() {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		wg := sync.WaitGroup{}
+		for _, holder := range []string{"operator-a", "operator-b"} {
+			wg.Add(1)
+			go func(holder string) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				var release func()
+				Eventually(func() error {
+					var err error
+					release, err = cluster.AcquireSchemaLease(ctx, c, key, holder, time.Minute)
+					return err
+				}, 5*time.Second, 5*time.Millisecond).Should(Succeed())
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				release()
+			}(holder)
+		}
+		wg.Wait()
+		Expect(maxRunning).To(Equal(1))
+	})
+	It("should report the holder of the lease", func() {
+		release, err := cluster.AcquireSchemaLease(ctx, c, key, "operator-a", time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cluster.AcquireSchemaLease(ctx, c, key, "operator-b", time.Minute)
+		Expect(err).To(Equal(cluster.ErrLeaseHeld{Resource: key, Holder: "operator-a"}))
+
+		release()
+		release, err = cluster.AcquireSchemaLease(ctx, c, key, "operator-b", time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		release()
+	})
+	It("should renew the lease while it is held", func() {
+		release, err := cluster.AcquireSchemaLease(ctx, c, key, "operator-a", time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(func() error {
+			_, err := cluster.AcquireSchemaLease(ctx, c, key, "operator-b", time.Second)
+			return err
+		}, 2500*time.Millisecond, 250*time.Millisecond).Should(Equal(cluster.ErrLeaseHeld{Resource: key, Holder: "operator-a"}))
+
+		release()
+		release, err = cluster.AcquireSchemaLease(ctx, c, key, "operator-b", time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		release()
+	})
+	It("should take over an expired lease", func() {
+		holder := "operator-a"
+		seconds := int32(60)
+		renewed := metav1.NewMicroTime(time.Now().Add(-2 * time.Minute))
+		Expect(c.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: "schema-lock-" + key.Name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &seconds,
+				RenewTime:            &renewed,
+			},
+		})).To(Succeed())
+		release, err := cluster.AcquireSchemaLease(ctx, c, key, "operator-b", time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		release()
+	})
+})
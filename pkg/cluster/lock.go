@@ -0,0 +1,27 @@
+package cluster
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+)
+
+// clusterLocks holds a single slot channel per cluster URI.
+var clusterLocks sync.Map
+
+// AcquireClusterLock serializes schema executions on the cluster `uri`.
+// It blocks until the lock is acquired or `ctx` is done, and returns the function releasing the lock.
+func AcquireClusterLock(uri string, ctx context.Context) (func(), error) {
+	lock, _ := clusterLocks.LoadOrStore(uri, make(chan struct{}, 1))
+	slot := lock.(chan struct{})
+	select {
+	case slot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	once := sync.Once{}
+	return func() {
+		once.Do(func() { <-slot })
+	}, nil
+}
@@ -0,0 +1,67 @@
+package cluster_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/cluster"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AcquireClusterLock", func() {
+	const uri = "https://locked.eastus.kusto.windows.net"
+
+	It("should serialize executions on the same cluster", func() {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				unlock, err := cluster.AcquireClusterLock(uri, context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				defer unlock()
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		Expect(maxRunning).To(Equal(1))
+	})
+	It("should not lock other clusters", func() {
+		unlock, err := cluster.AcquireClusterLock(uri, context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		defer unlock()
+		other, err := cluster.AcquireClusterLock("https://other.eastus.kusto.windows.net", context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		other()
+	})
+	It("should give up when the context is cancelled", func() {
+		unlock, err := cluster.AcquireClusterLock(uri, context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = cluster.AcquireClusterLock(uri, ctx)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+
+		unlock()
+		unlock()
+		unlock, err = cluster.AcquireClusterLock(uri, context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		unlock()
+	})
+})
@@ -26,6 +26,52 @@ const (
 	ParallelWorkers = "schemaop_parallel_workers"
 	// AllowLocalDacPac adds support for local dacpac files
 	AllowLocalDacPac = "schemaop_allow_local_dacpac"
+	// SchemaURLMaxSizeKey maximal size in bytes of a schema downloaded from a URL
+	SchemaURLMaxSizeKey = "schemaop_schema_url_max_size"
+	// KustoClientTTLKey duration a cached kusto client is reused (e.g. `30m`)
+	KustoClientTTLKey = "schemaop_kusto_client_ttl"
+	// ClusterDiscoveryTTLKey duration the kusto clusters discovered with Azure Resource Graph are reused (e.g. `10m`)
+	ClusterDiscoveryTTLKey = "schemaop_cluster_discovery_ttl"
+	// WatchedNamespacesKey comma separated list of namespaces the operator watches, empty watches all namespaces
+	WatchedNamespacesKey = "schemaop_watched_namespaces"
+	// OperatorScopeKey scope of the operator - `cluster` or `namespace`
+	OperatorScopeKey = "schemaop_operator_scope"
+	// OperatorNamespaceKey namespace the operator runs in
+	OperatorNamespaceKey = "schemaop_operator_namespace"
+	// EnableWebhooksKey serves the admission webhooks when `true` (requires the webhook serving certificates)
+	EnableWebhooksKey = "schemaop_enable_webhooks"
+	// RegistryRequestTimeoutKey duration an Event Hubs schema registry request may take (e.g. `10s`)
+	RegistryRequestTimeoutKey = "schemaop_registry_request_timeout"
+	// KustoPingTimeoutKey duration a kusto cluster has to answer the reachability check (e.g. `5s`)
+	KustoPingTimeoutKey = "schemaop_kusto_ping_timeout"
+	// KustoMgmtTimeoutKey duration a kusto management command listing or creating databases may take (e.g. `30s`)
+	KustoMgmtTimeoutKey = "schemaop_kusto_mgmt_timeout"
+	// ComplianceReportMaxAgeKey duration a compliance report is kept (e.g. `2160h`)
+	ComplianceReportMaxAgeKey = "schemaop_compliance_report_max_age"
+	// DryRunReportTTLKey duration a dry run report is kept (e.g. `48h`)
+	DryRunReportTTLKey = "schemaop_dry_run_report_ttl"
+	// MaxResultAgeKey duration a schema execution result is kept (e.g. `168h`)
+	MaxResultAgeKey = "schemaop_max_result_age"
+	// MaxParallelDBsKey number of databases an isolated kusto execution runs at once unless it sets its own, zero for one
+	MaxParallelDBsKey = "schemaop_max_parallel_dbs"
+	// CPUPerWorkerKey cpu a parallel delta-kusto job is estimated to use when checking the resource quota (e.g. `250m`)
+	CPUPerWorkerKey = "schemaop_cpu_per_worker"
+	// MemPerWorkerKey memory a parallel delta-kusto job is estimated to use when checking the resource quota (e.g. `256Mi`)
+	MemPerWorkerKey = "schemaop_mem_per_worker"
+	// MaxFailuresKey number of failed executions after which a cluster executer stops retrying
+	MaxFailuresKey = "schemaop_max_failures"
+	// ExecutionTimeoutKey duration a delta-kusto execution may run, unless the database has its own timeout (e.g. `10m`)
+	ExecutionTimeoutKey = "schemaop_execution_timeout"
+	// GracefulShutdownTimeoutKey duration the running executions have to finish once the operator is stopped (e.g. `2m`)
+	GracefulShutdownTimeoutKey = "schemaop_graceful_shutdown_timeout"
+	// RequeueIntervalKey period the schema deployments without a `reconcileInterval` are requeued at (e.g. `5m`)
+	RequeueIntervalKey = "schemaop_requeue_interval"
+	// WebhookTimeoutKey duration a database filter webhook request may take (e.g. `30s`)
+	WebhookTimeoutKey = "schemaop_webhook_timeout"
+	// WatchSecretsKey reconciles the schema deployments whose labeled `secretRef` secret changed when `true` (requires `list` and `watch` on secrets)
+	WatchSecretsKey = "schemaop_watch_secrets"
+	// StartupProbeClustersKey comma separated kusto cluster uris the operator must reach before the startup probe succeeds
+	StartupProbeClustersKey = "schemaop_startup_probe_clusters"
 )
 
 func init() {
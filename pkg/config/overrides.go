@@ -0,0 +1,63 @@
+package config
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// overrides hold the settings of the `SchemaOperatorConfig`, they take precedence over the environment.
+var overrides = struct {
+	sync.RWMutex
+	values map[string]interface{}
+}{}
+
+// SetOverrides replaces the overridden settings with `values` (keyed by the configuration keys), nil clears them.
+// The values are `int`, `int64`, `string` or `time.Duration` matching the getter of their key.
+func SetOverrides(values map[string]interface{}) {
+	overrides.Lock()
+	defer overrides.Unlock()
+	overrides.values = values
+}
+
+func override(key string) interface{} {
+	overrides.RLock()
+	defer overrides.RUnlock()
+	return overrides.values[key]
+}
+
+// GetInt returns the overridden value of `key`, or its environment value when it is not overridden.
+func GetInt(key string) int {
+	if value, ok := override(key).(int); ok {
+		return value
+	}
+	return viper.GetInt(key)
+}
+
+// GetInt64 returns the overridden value of `key`, or its environment value when it is not overridden.
+func GetInt64(key string) int64 {
+	if value, ok := override(key).(int64); ok {
+		return value
+	}
+	return viper.GetInt64(key)
+}
+
+// GetString returns the overridden value of `key`, or its environment value when it is not overridden.
+func GetString(key string) string {
+	if value, ok := override(key).(string); ok {
+		return value
+	}
+	return viper.GetString(key)
+}
+
+// GetDuration returns the overridden value of `key`, or its environment value when it is not overridden.
+func GetDuration(key string) time.Duration {
+	if value, ok := override(key).(time.Duration); ok {
+		return value
+	}
+	return viper.GetDuration(key)
+}
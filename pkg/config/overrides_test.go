@@ -0,0 +1,40 @@
+package config_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = Describe("Overrides", func() {
+	AfterEach(func() {
+		config.SetOverrides(nil)
+		viper.Set(config.MaxParallelDBsKey, "")
+		viper.Set(config.WebhookTimeoutKey, "")
+	})
+
+	It("should take precedence over the environment", func() {
+		viper.Set(config.MaxParallelDBsKey, "4")
+		viper.Set(config.WebhookTimeoutKey, "10s")
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(Equal(4))
+
+		config.SetOverrides(map[string]interface{}{
+			config.MaxParallelDBsKey:   2,
+			config.SchemaURLMaxSizeKey: int64(1024),
+		})
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(Equal(2))
+		Expect(config.GetInt64(config.SchemaURLMaxSizeKey)).To(Equal(int64(1024)))
+		Expect(config.GetDuration(config.WebhookTimeoutKey)).To(Equal(10 * time.Second))
+	})
+	It("should fall back to the environment once cleared", func() {
+		viper.Set(config.MaxParallelDBsKey, "4")
+		config.SetOverrides(map[string]interface{}{config.MaxParallelDBsKey: 2})
+		config.SetOverrides(nil)
+		Expect(config.GetInt(config.MaxParallelDBsKey)).To(Equal(4))
+	})
+})
@@ -0,0 +1,126 @@
+package config
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/yaml"
+)
+
+// OperatorScope defines the resources the operator is allowed to manage.
+type OperatorScope string
+
+const (
+	// ClusterScope the operator watches the schema resources of the whole cluster (or the `WatchedNamespaces`).
+	ClusterScope OperatorScope = "cluster"
+	// NamespaceScope the operator watches only the schema resources of its own namespace.
+	NamespaceScope OperatorScope = "namespace"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod service account.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// LeaderElectionConfig holds the operator settings of the `leaderElection` section of the manager config file.
+type LeaderElectionConfig struct {
+	// OperatorScope is the scope the operator runs in, `cluster` by default.
+	OperatorScope OperatorScope `json:"operatorScope,omitempty"`
+}
+
+// ScopeConfig holds the operator settings of the manager config file which are not part of the controller-runtime configuration.
+type ScopeConfig struct {
+	// WatchedNamespaces are the namespaces the operator watches, empty watches all namespaces.
+	WatchedNamespaces []string             `json:"watchedNamespaces,omitempty"`
+	LeaderElection    LeaderElectionConfig `json:"leaderElection,omitempty"`
+}
+
+// LoadScopeConfig reads the `ScopeConfig` from the manager config file at `path` (if set).
+// The `schemaop_watched_namespaces` and `schemaop_operator_scope` environment values override the file.
+func LoadScopeConfig(path string) (ScopeConfig, error) {
+	cfg := ScopeConfig{}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("could not read file at %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(content, &cfg); err != nil {
+			return cfg, fmt.Errorf("could not parse the scope of %s: %w", path, err)
+		}
+	}
+	if namespaces := strings.TrimSpace(viper.GetString(WatchedNamespacesKey)); namespaces != "" {
+		cfg.WatchedNamespaces = strings.Split(namespaces, ",")
+	}
+	if scope := strings.TrimSpace(viper.GetString(OperatorScopeKey)); scope != "" {
+		cfg.LeaderElection.OperatorScope = OperatorScope(scope)
+	}
+	watched := make([]string, 0, len(cfg.WatchedNamespaces))
+	for _, ns := range cfg.WatchedNamespaces {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			watched = append(watched, ns)
+		}
+	}
+	cfg.WatchedNamespaces = watched
+	if cfg.LeaderElection.OperatorScope == "" {
+		cfg.LeaderElection.OperatorScope = ClusterScope
+	}
+	return cfg, nil
+}
+
+// OperatorNamespace returns the namespace the operator runs in.
+func OperatorNamespace() string {
+	if ns := strings.TrimSpace(viper.GetString(OperatorNamespaceKey)); ns != "" {
+		return ns
+	}
+	content, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// Namespaces returns the namespaces the manager should watch, an empty list watches the whole cluster.
+// A `namespace` scoped operator watches only `operatorNamespace`.
+func (c ScopeConfig) Namespaces(operatorNamespace string) ([]string, error) {
+	switch c.LeaderElection.OperatorScope {
+	case ClusterScope:
+		return c.WatchedNamespaces, nil
+	case NamespaceScope:
+		if operatorNamespace == "" {
+			return nil, fmt.Errorf("the operator namespace is required for a %s scoped operator", NamespaceScope)
+		}
+		for _, ns := range c.WatchedNamespaces {
+			if ns != operatorNamespace {
+				return nil, fmt.Errorf("a %s scoped operator can't watch namespace %s", NamespaceScope, ns)
+			}
+		}
+		return []string{operatorNamespace}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator scope %q", c.LeaderElection.OperatorScope)
+	}
+}
+
+// Apply restricts the manager `options` to the watched namespaces.
+// A `namespace` scoped operator also keeps its leader election lease in its own namespace.
+func (c ScopeConfig) Apply(options *ctrl.Options, operatorNamespace string) ([]string, error) {
+	namespaces, err := c.Namespaces(operatorNamespace)
+	if err != nil {
+		return nil, err
+	}
+	switch len(namespaces) {
+	case 0:
+	case 1:
+		options.Namespace = namespaces[0]
+	default:
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+	if c.LeaderElection.OperatorScope == NamespaceScope {
+		options.LeaderElectionNamespace = operatorNamespace
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,105 @@
+package config_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Operator scope", func() {
+	var cfgDir, cfgFile string
+
+	BeforeEach(func() {
+		var err error
+		cfgDir, err = os.MkdirTemp("", "scope")
+		Expect(err).NotTo(HaveOccurred())
+		cfgFile = filepath.Join(cfgDir, "controller_manager_config.yaml")
+		Expect(os.WriteFile(cfgFile, []byte(`apiVersion: controller-runtime.sigs.k8s.io/v1alpha1
+kind: ControllerManagerConfig
+leaderElection:
+  leaderElect: true
+  operatorScope: cluster
+watchedNamespaces:
+  - team-a
+  - team-b
+`), 0600)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(cfgDir)
+		viper.Set(config.WatchedNamespacesKey, "")
+		viper.Set(config.OperatorScopeKey, "")
+	})
+
+	It("should read the watched namespaces from the config file", func() {
+		scope, err := config.LoadScopeConfig(cfgFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scope.LeaderElection.OperatorScope).To(Equal(config.ClusterScope))
+		Expect(scope.WatchedNamespaces).To(Equal([]string{"team-a", "team-b"}))
+
+		options := ctrl.Options{}
+		namespaces, err := scope.Apply(&options, "schema-operator-system")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaces).To(Equal([]string{"team-a", "team-b"}))
+		Expect(options.NewCache).NotTo(BeNil())
+		Expect(options.LeaderElectionNamespace).To(BeEmpty())
+	})
+	It("should let the environment override the config file", func() {
+		viper.Set(config.WatchedNamespacesKey, "team-c, ")
+		scope, err := config.LoadScopeConfig(cfgFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		options := ctrl.Options{}
+		namespaces, err := scope.Apply(&options, "schema-operator-system")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaces).To(Equal([]string{"team-c"}))
+		Expect(options.Namespace).To(Equal("team-c"))
+	})
+	It("should watch all namespaces by default", func() {
+		scope, err := config.LoadScopeConfig("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scope.LeaderElection.OperatorScope).To(Equal(config.ClusterScope))
+
+		options := ctrl.Options{}
+		namespaces, err := scope.Apply(&options, "schema-operator-system")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaces).To(BeEmpty())
+		Expect(options.Namespace).To(BeEmpty())
+		Expect(options.NewCache).To(BeNil())
+	})
+	It("should restrict a namespace scoped operator to its own namespace", func() {
+		viper.Set(config.OperatorScopeKey, "namespace")
+		scope, err := config.LoadScopeConfig("")
+		Expect(err).NotTo(HaveOccurred())
+
+		options := ctrl.Options{}
+		namespaces, err := scope.Apply(&options, "schema-operator-system")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaces).To(Equal([]string{"schema-operator-system"}))
+		Expect(options.Namespace).To(Equal("schema-operator-system"))
+		Expect(options.LeaderElectionNamespace).To(Equal("schema-operator-system"))
+
+		_, err = scope.Namespaces("")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject a namespace scoped operator watching other namespaces", func() {
+		viper.Set(config.OperatorScopeKey, "namespace")
+		scope, err := config.LoadScopeConfig(cfgFile)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = scope.Namespaces("schema-operator-system")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject an unknown scope", func() {
+		viper.Set(config.OperatorScopeKey, "galaxy")
+		scope, err := config.LoadScopeConfig("")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = scope.Namespaces("schema-operator-system")
+		Expect(err).To(HaveOccurred())
+	})
+})
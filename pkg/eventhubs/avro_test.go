@@ -0,0 +1,110 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Avro schema validation", func() {
+	valid := []struct {
+		name    string
+		content string
+	}{
+		{"primitive", `"string"`},
+		{"record", `{"type":"record","name":"Order","namespace":"com.example","fields":[{"name":"id","type":"string"},{"name":"amount","type":"double"}]}`},
+		{"nested named types", `{"type":"record","name":"Order","namespace":"com.example","fields":[
+			{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}},
+			{"name":"previous","type":["null","Status"]},
+			{"name":"hash","type":{"type":"fixed","name":"MD5","size":16}},
+			{"name":"tags","type":{"type":"map","values":{"type":"array","items":"com.example.MD5"}}}]}`},
+		{"recursive record", `{"type":"record","name":"Node","fields":[{"name":"next","type":["null","Node"]}]}`},
+	}
+	for _, tc := range valid {
+		tc := tc
+		It("should accept a "+tc.name+" schema", func() {
+			Expect(schemaregistry.ValidateAvroSchema(tc.content)).To(Succeed())
+		})
+	}
+
+	invalid := []struct {
+		name     string
+		content  string
+		expected schemaregistry.ErrInvalidAvroSchema
+	}{
+		{"missing record name", `{"type":"record","fields":[]}`,
+			schemaregistry.ErrInvalidAvroSchema{Reason: `missing required attribute "name"`}},
+		{"missing record fields", `{"type":"record","name":"Order"}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Order", Reason: `missing required attribute "fields"`}},
+		{"missing field type", `{"type":"record","name":"Order","fields":[{"name":"id"}]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Order.id", Reason: `missing required attribute "type"`}},
+		{"missing array items", `{"type":"record","name":"Order","fields":[{"name":"lines","type":{"type":"array"}}]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Order.lines", Reason: `missing required attribute "items"`}},
+		{"unknown type", `{"type":"record","name":"Order","fields":[{"name":"id","type":"uuid"}]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Order.id", Reason: `unknown type "uuid"`}},
+		{"unresolved reference", `{"type":"record","name":"Order","namespace":"com.example","fields":[{"name":"customer","type":["null","Customer"]}]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "com.example.Order.customer[1]", Reason: `unknown type "Customer"`}},
+		{"duplicate field", `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"id","type":"long"}]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Order.id", Reason: `duplicate field name "id"`}},
+		{"duplicate enum symbol", `{"type":"enum","name":"Status","symbols":["NEW","NEW"]}`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "Status", Reason: `duplicate enum symbol "NEW"`}},
+		{"duplicate union type", `["null","string","null"]`,
+			schemaregistry.ErrInvalidAvroSchema{Field: "[2]", Reason: `duplicate union type "null"`}},
+	}
+	for _, tc := range invalid {
+		tc := tc
+		It("should report a "+tc.name, func() {
+			Expect(schemaregistry.ValidateAvroSchema(tc.content)).To(Equal(tc.expected))
+		})
+	}
+
+	It("should reject malformed json", func() {
+		err := schemaregistry.ValidateAvroSchema(`{"type":"record"`)
+		Expect(err).To(BeAssignableToTypeOf(schemaregistry.ErrInvalidAvroSchema{}))
+		Expect(err.Error()).To(ContainSubstring("malformed json"))
+	})
+
+	Context("when registering a schema", func() {
+		var server *httptest.Server
+		var requests int
+
+		BeforeEach(func() {
+			requests = 0
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Schema-Id", "a1b2")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		newClient := func() schemaregistry.SchemaClient {
+			client := schemaregistry.NewSchemaClient(strings.TrimPrefix(server.URL, "https://"))
+			client.Sender = server.Client()
+			return client
+		}
+
+		It("should register a valid schema", func() {
+			id, err := newClient().RegisterSchema(context.Background(), "orders", "Order", schemaregistry.AvroFormat,
+				`{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*id.ID).To(Equal("a1b2"))
+			Expect(requests).To(Equal(1))
+		})
+		It("should not send an invalid schema", func() {
+			_, err := newClient().RegisterSchema(context.Background(), "orders", "Order", schemaregistry.AvroFormat,
+				`{"type":"record","name":"Order","fields":[{"name":"id","type":"Customer"}]}`)
+			Expect(err).To(Equal(schemaregistry.ErrInvalidAvroSchema{Field: "Order.id", Reason: `unknown type "Customer"`}))
+			Expect(requests).To(BeZero())
+		})
+	})
+})
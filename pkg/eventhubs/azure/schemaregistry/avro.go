@@ -0,0 +1,285 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// avroName matches the valid names of Avro named types, fields and enum symbols.
+var avroName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// avroPrimitives are the Avro primitive type names.
+var avroPrimitives = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// ErrInvalidAvroSchema is returned when a schema is not a valid Avro schema definition.
+// `Field` is the dotted path of the invalid element, empty for the schema root.
+type ErrInvalidAvroSchema struct {
+	Field  string
+	Reason string
+}
+
+func (e ErrInvalidAvroSchema) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid avro schema: %s", e.Reason)
+	}
+	return fmt.Sprintf("invalid avro schema at %s: %s", e.Field, e.Reason)
+}
+
+// ValidateAvroSchema verifies the `content` is a valid Avro schema definition:
+// known type names, resolved named type references and unique field names.
+func ValidateAvroSchema(content string) error {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	var schema interface{}
+	if err := decoder.Decode(&schema); err != nil {
+		return ErrInvalidAvroSchema{Reason: fmt.Sprintf("malformed json: %s", err)}
+	}
+	if decoder.More() {
+		return ErrInvalidAvroSchema{Reason: "malformed json: unexpected content after the schema"}
+	}
+	v := &avroValidator{named: map[string]bool{}}
+	return v.validate(schema, "", "")
+}
+
+// avroValidator walks an Avro schema keeping the named types defined so far.
+type avroValidator struct {
+	named map[string]bool
+}
+
+func (v *avroValidator) validate(schema interface{}, namespace, path string) error {
+	switch s := schema.(type) {
+	case string:
+		return v.resolve(s, namespace, path)
+	case []interface{}:
+		return v.validateUnion(s, namespace, path)
+	case map[string]interface{}:
+		return v.validateComplex(s, namespace, path)
+	default:
+		return ErrInvalidAvroSchema{Field: path, Reason: fmt.Sprintf("unexpected schema %v", schema)}
+	}
+}
+
+// resolve verifies `name` is a primitive or an already defined named type.
+func (v *avroValidator) resolve(name, namespace, path string) error {
+	if avroPrimitives[name] {
+		return nil
+	}
+	if v.named[name] || v.named[fullName(name, namespace)] {
+		return nil
+	}
+	return ErrInvalidAvroSchema{Field: path, Reason: fmt.Sprintf("unknown type %q", name)}
+}
+
+func (v *avroValidator) validateUnion(members []interface{}, namespace, path string) error {
+	if len(members) == 0 {
+		return ErrInvalidAvroSchema{Field: path, Reason: "empty union"}
+	}
+	seen := map[string]bool{}
+	for i, member := range members {
+		memberPath := fmt.Sprintf("%s[%d]", path, i)
+		if _, ok := member.([]interface{}); ok {
+			return ErrInvalidAvroSchema{Field: memberPath, Reason: "unions may not immediately contain other unions"}
+		}
+		if err := v.validate(member, namespace, memberPath); err != nil {
+			return err
+		}
+		key := unionKey(member, namespace)
+		if seen[key] {
+			return ErrInvalidAvroSchema{Field: memberPath, Reason: fmt.Sprintf("duplicate union type %q", key)}
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func (v *avroValidator) validateComplex(schema map[string]interface{}, namespace, path string) error {
+	raw, ok := schema["type"]
+	if !ok {
+		return ErrInvalidAvroSchema{Field: path, Reason: "missing required attribute \"type\""}
+	}
+	typeName, ok := raw.(string)
+	if !ok {
+		// `{"type": {...}}` and `{"type": [...]}` wrap another schema.
+		return v.validate(raw, namespace, path)
+	}
+	switch typeName {
+	case "record", "error":
+		return v.validateRecord(schema, namespace, path)
+	case "enum":
+		return v.validateEnum(schema, namespace, path)
+	case "fixed":
+		return v.validateFixed(schema, namespace, path)
+	case "array":
+		items, ok := schema["items"]
+		if !ok {
+			return ErrInvalidAvroSchema{Field: path, Reason: "missing required attribute \"items\""}
+		}
+		return v.validate(items, namespace, path+".items")
+	case "map":
+		values, ok := schema["values"]
+		if !ok {
+			return ErrInvalidAvroSchema{Field: path, Reason: "missing required attribute \"values\""}
+		}
+		return v.validate(values, namespace, path+".values")
+	default:
+		return v.resolve(typeName, namespace, path)
+	}
+}
+
+func (v *avroValidator) validateRecord(schema map[string]interface{}, namespace, path string) error {
+	name, namespace, err := v.define(schema, namespace, path)
+	if err != nil {
+		return err
+	}
+	rawFields, ok := schema["fields"]
+	if !ok {
+		return ErrInvalidAvroSchema{Field: name, Reason: "missing required attribute \"fields\""}
+	}
+	fields, ok := rawFields.([]interface{})
+	if !ok {
+		return ErrInvalidAvroSchema{Field: name, Reason: "\"fields\" must be an array"}
+	}
+	seen := map[string]bool{}
+	for i, rawField := range fields {
+		field, ok := rawField.(map[string]interface{})
+		if !ok {
+			return ErrInvalidAvroSchema{Field: fmt.Sprintf("%s.fields[%d]", name, i), Reason: "a field must be an object"}
+		}
+		fieldName, _ := field["name"].(string)
+		if fieldName == "" {
+			return ErrInvalidAvroSchema{Field: fmt.Sprintf("%s.fields[%d]", name, i), Reason: "missing required attribute \"name\""}
+		}
+		fieldPath := name + "." + fieldName
+		if !avroName.MatchString(fieldName) {
+			return ErrInvalidAvroSchema{Field: fieldPath, Reason: fmt.Sprintf("invalid field name %q", fieldName)}
+		}
+		if seen[fieldName] {
+			return ErrInvalidAvroSchema{Field: fieldPath, Reason: fmt.Sprintf("duplicate field name %q", fieldName)}
+		}
+		seen[fieldName] = true
+		fieldType, ok := field["type"]
+		if !ok {
+			return ErrInvalidAvroSchema{Field: fieldPath, Reason: "missing required attribute \"type\""}
+		}
+		if err := v.validate(fieldType, namespace, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *avroValidator) validateEnum(schema map[string]interface{}, namespace, path string) error {
+	name, _, err := v.define(schema, namespace, path)
+	if err != nil {
+		return err
+	}
+	symbols, ok := schema["symbols"].([]interface{})
+	if !ok {
+		return ErrInvalidAvroSchema{Field: name, Reason: "missing required attribute \"symbols\""}
+	}
+	seen := map[string]bool{}
+	for _, raw := range symbols {
+		symbol, _ := raw.(string)
+		if !avroName.MatchString(symbol) {
+			return ErrInvalidAvroSchema{Field: name, Reason: fmt.Sprintf("invalid enum symbol %v", raw)}
+		}
+		if seen[symbol] {
+			return ErrInvalidAvroSchema{Field: name, Reason: fmt.Sprintf("duplicate enum symbol %q", symbol)}
+		}
+		seen[symbol] = true
+	}
+	return nil
+}
+
+func (v *avroValidator) validateFixed(schema map[string]interface{}, namespace, path string) error {
+	name, _, err := v.define(schema, namespace, path)
+	if err != nil {
+		return err
+	}
+	size, ok := schema["size"].(json.Number)
+	if !ok {
+		return ErrInvalidAvroSchema{Field: name, Reason: "missing required attribute \"size\""}
+	}
+	if n, err := size.Int64(); err != nil || n < 0 {
+		return ErrInvalidAvroSchema{Field: name, Reason: fmt.Sprintf("invalid size %s", size)}
+	}
+	return nil
+}
+
+// define registers the named type of `schema` and returns its full name and namespace.
+// The name is registered before the type body is validated so records may reference themselves.
+func (v *avroValidator) define(schema map[string]interface{}, namespace, path string) (string, string, error) {
+	name, _ := schema["name"].(string)
+	if name == "" {
+		return "", "", ErrInvalidAvroSchema{Field: path, Reason: "missing required attribute \"name\""}
+	}
+	if ns, ok := schema["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	full := fullName(name, namespace)
+	for _, part := range strings.Split(full, ".") {
+		if !avroName.MatchString(part) {
+			return "", "", ErrInvalidAvroSchema{Field: path, Reason: fmt.Sprintf("invalid name %q", full)}
+		}
+	}
+	if avroPrimitives[name] {
+		return "", "", ErrInvalidAvroSchema{Field: path, Reason: fmt.Sprintf("%q is a primitive type name", name)}
+	}
+	if v.named[full] {
+		return "", "", ErrInvalidAvroSchema{Field: full, Reason: fmt.Sprintf("type %q is already defined", full)}
+	}
+	v.named[full] = true
+	if i := strings.LastIndex(full, "."); i >= 0 {
+		namespace = full[:i]
+	} else {
+		namespace = ""
+	}
+	return full, namespace, nil
+}
+
+// fullName qualifies a short `name` with the enclosing `namespace`.
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// unionKey identifies a union member - a union may hold a single schema of every unnamed type.
+func unionKey(member interface{}, namespace string) string {
+	switch m := member.(type) {
+	case string:
+		if avroPrimitives[m] {
+			return m
+		}
+		return fullName(m, namespace)
+	case map[string]interface{}:
+		typeName, _ := m["type"].(string)
+		switch typeName {
+		case "record", "error", "enum", "fixed":
+			name, _ := m["name"].(string)
+			if ns, ok := m["namespace"].(string); ok && !strings.Contains(name, ".") {
+				return fullName(name, ns)
+			}
+			return fullName(name, namespace)
+		case "array", "map":
+			return typeName
+		}
+		return unionKey(m["type"], namespace)
+	}
+	return fmt.Sprint(member)
+}
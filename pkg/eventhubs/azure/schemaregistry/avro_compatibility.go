@@ -0,0 +1,258 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// avroPromotions are the writer types each reader primitive can read, following the Avro schema resolution rules.
+var avroPromotions = map[string][]string{
+	"long":   {"int"},
+	"float":  {"int", "long"},
+	"double": {"int", "long", "float"},
+	"string": {"bytes"},
+	"bytes":  {"string"},
+}
+
+// BackwardCompatible reports if data written with the `writer` Avro schema can be read with the `reader` schema.
+func BackwardCompatible(reader, writer string) (bool, error) {
+	for _, schema := range []string{reader, writer} {
+		if err := ValidateAvroSchema(schema); err != nil {
+			return false, err
+		}
+	}
+	var r, w interface{}
+	if err := json.Unmarshal([]byte(reader), &r); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(writer), &w); err != nil {
+		return false, err
+	}
+	c := &avroResolver{
+		readerTypes: avroNamedTypes(r, "", map[string]map[string]interface{}{}),
+		writerTypes: avroNamedTypes(w, "", map[string]map[string]interface{}{}),
+		visited:     map[string]bool{},
+	}
+	return c.compatible(r, w), nil
+}
+
+// avroResolver matches a reader schema against a writer schema.
+type avroResolver struct {
+	readerTypes map[string]map[string]interface{}
+	writerTypes map[string]map[string]interface{}
+	// visited holds the record pairs being matched, so recursive records terminate.
+	visited map[string]bool
+}
+
+func (c *avroResolver) compatible(reader, writer interface{}) bool {
+	reader = resolveAvroType(reader, c.readerTypes)
+	writer = resolveAvroType(writer, c.writerTypes)
+	if members, ok := writer.([]interface{}); ok {
+		for _, member := range members {
+			if !c.compatible(reader, member) {
+				return false
+			}
+		}
+		return true
+	}
+	if members, ok := reader.([]interface{}); ok {
+		for _, member := range members {
+			if c.compatible(member, writer) {
+				return true
+			}
+		}
+		return false
+	}
+	readerType, writerType := avroTypeName(reader), avroTypeName(writer)
+	if avroPrimitives[readerType] || avroPrimitives[writerType] {
+		if readerType == writerType {
+			return true
+		}
+		for _, promoted := range avroPromotions[readerType] {
+			if promoted == writerType {
+				return true
+			}
+		}
+		return false
+	}
+	if readerType != writerType {
+		return false
+	}
+	r, w := reader.(map[string]interface{}), writer.(map[string]interface{})
+	switch readerType {
+	case "array":
+		return c.compatible(r["items"], w["items"])
+	case "map":
+		return c.compatible(r["values"], w["values"])
+	case "fixed":
+		return shortAvroName(r) == shortAvroName(w) && r["size"] == w["size"]
+	case "enum":
+		if shortAvroName(r) != shortAvroName(w) {
+			return false
+		}
+		if _, ok := r["default"]; ok {
+			return true
+		}
+		symbols := map[interface{}]bool{}
+		for _, symbol := range r["symbols"].([]interface{}) {
+			symbols[symbol] = true
+		}
+		for _, symbol := range w["symbols"].([]interface{}) {
+			if !symbols[symbol] {
+				return false
+			}
+		}
+		return true
+	case "record", "error":
+		return c.compatibleRecords(r, w)
+	}
+	return false
+}
+
+func (c *avroResolver) compatibleRecords(reader, writer map[string]interface{}) bool {
+	if shortAvroName(reader) != shortAvroName(writer) {
+		return false
+	}
+	pair := shortAvroName(reader) + "|" + shortAvroName(writer)
+	if c.visited[pair] {
+		return true
+	}
+	c.visited[pair] = true
+	defer delete(c.visited, pair)
+
+	writerFields := map[string]map[string]interface{}{}
+	for _, raw := range writer["fields"].([]interface{}) {
+		field := raw.(map[string]interface{})
+		writerFields[field["name"].(string)] = field
+	}
+	for _, raw := range reader["fields"].([]interface{}) {
+		field := raw.(map[string]interface{})
+		written, ok := writerFields[field["name"].(string)]
+		if !ok {
+			for _, alias := range stringList(field["aliases"]) {
+				if written, ok = writerFields[alias]; ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			if _, hasDefault := field["default"]; !hasDefault {
+				return false
+			}
+			continue
+		}
+		if !c.compatible(field["type"], written["type"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// avroNamedTypes collects the named type definitions of the schema by full name.
+func avroNamedTypes(schema interface{}, namespace string, types map[string]map[string]interface{}) map[string]map[string]interface{} {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, member := range s {
+			avroNamedTypes(member, namespace, types)
+		}
+	case map[string]interface{}:
+		switch avroTypeName(s) {
+		case "record", "error", "enum", "fixed":
+			name, _ := s["name"].(string)
+			if ns, ok := s["namespace"].(string); ok && !strings.Contains(name, ".") {
+				namespace = ns
+			}
+			full := fullName(name, namespace)
+			types[full] = s
+			if i := strings.LastIndex(full, "."); i >= 0 {
+				namespace = full[:i]
+			}
+			if fields, ok := s["fields"].([]interface{}); ok {
+				for _, raw := range fields {
+					if field, ok := raw.(map[string]interface{}); ok {
+						avroNamedTypes(field["type"], namespace, types)
+					}
+				}
+			}
+		case "array":
+			avroNamedTypes(s["items"], namespace, types)
+		case "map":
+			avroNamedTypes(s["values"], namespace, types)
+		default:
+			if _, nested := s["type"].(string); !nested {
+				avroNamedTypes(s["type"], namespace, types)
+			}
+		}
+	}
+	return types
+}
+
+// resolveAvroType replaces the named type references and the `{"type": ...}` wrappers with the type definition.
+func resolveAvroType(schema interface{}, types map[string]map[string]interface{}) interface{} {
+	switch s := schema.(type) {
+	case string:
+		if avroPrimitives[s] {
+			return s
+		}
+		if def, ok := types[s]; ok {
+			return def
+		}
+		for full, def := range types {
+			if strings.HasSuffix(full, "."+s) {
+				return def
+			}
+		}
+		return s
+	case map[string]interface{}:
+		switch t := s["type"].(type) {
+		case string:
+			if avroPrimitives[t] {
+				return t
+			}
+			switch t {
+			case "record", "error", "enum", "fixed", "array", "map":
+				return s
+			}
+			return resolveAvroType(t, types)
+		default:
+			return resolveAvroType(t, types)
+		}
+	}
+	return schema
+}
+
+// avroTypeName returns the type of a resolved schema.
+func avroTypeName(schema interface{}) string {
+	switch s := schema.(type) {
+	case string:
+		return s
+	case map[string]interface{}:
+		t, _ := s["type"].(string)
+		return t
+	}
+	return ""
+}
+
+// shortAvroName returns the unqualified name of a named type.
+func shortAvroName(schema map[string]interface{}) string {
+	name, _ := schema["name"].(string)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func stringList(value interface{}) []string {
+	list := []string{}
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,122 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of schemas a batch registers in parallel.
+const DefaultBatchConcurrency = 5
+
+// SchemaDef is a schema to register in a batch.
+type SchemaDef struct {
+	Name    string
+	Format  string
+	Content string
+}
+
+// SchemaRegistration is a schema to register with `RegisterSchemasBatch`.
+type SchemaRegistration = SchemaDef
+
+// SchemaRegistrationResult is the outcome of registering a single schema of a batch.
+type SchemaRegistrationResult struct {
+	SchemaID SchemaID
+	// Err is the registration error, nil when the schema was registered.
+	Err error
+}
+
+// ErrSchemaRegistration is the failure of a single schema of a batch.
+type ErrSchemaRegistration struct {
+	Index int
+	Name  string
+	Err   error
+}
+
+func (e ErrSchemaRegistration) Error() string {
+	return fmt.Sprintf("failed registering schema %s: %s", e.Name, e.Err)
+}
+
+func (e ErrSchemaRegistration) Unwrap() error {
+	return e.Err
+}
+
+// ErrSchemaBatch holds an `ErrSchemaRegistration` for every schema of the batch that failed, ordered by index.
+type ErrSchemaBatch []ErrSchemaRegistration
+
+func (e ErrSchemaBatch) Error() string {
+	failures := make([]string, 0, len(e))
+	for _, err := range e {
+		failures = append(failures, err.Error())
+	}
+	return fmt.Sprintf("%d schemas failed registration: %s", len(e), strings.Join(failures, "; "))
+}
+
+// RegisterSchemaBatch registers the schemas in the group in parallel, bounded by the batch concurrency of the client.
+// The returned `SchemaID`s are aligned with `schemas` - a failed schema gets a zero `SchemaID` and is reported in the `ErrSchemaBatch`.
+func (client SchemaClient) RegisterSchemaBatch(ctx context.Context, groupName string, schemas []SchemaDef) ([]SchemaID, error) {
+	results, _ := client.RegisterSchemasBatch(ctx, groupName, schemas)
+	ids := make([]SchemaID, len(results))
+	var failed ErrSchemaBatch
+	for i, result := range results {
+		if result.Err != nil {
+			failed = append(failed, ErrSchemaRegistration{Index: i, Name: schemas[i].Name, Err: result.Err})
+			continue
+		}
+		ids[i] = result.SchemaID
+	}
+	if len(failed) == 0 {
+		return ids, nil
+	}
+	return ids, failed
+}
+
+// RegisterSchemasBatch registers the schemas in the group with a pool of workers sized by the batch concurrency of the client.
+// The results are aligned with `schemas` and hold the error of every failed schema, the registered schemas are kept on partial failures.
+// Once `ctx` is cancelled the schemas not started yet fail with the context error, which is returned as well.
+func (client SchemaClient) RegisterSchemasBatch(ctx context.Context, groupName string, schemas []SchemaRegistration) ([]SchemaRegistrationResult, error) {
+	workers := client.batchConcurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	if workers > len(schemas) {
+		workers = len(schemas)
+	}
+	results := make([]SchemaRegistrationResult, len(schemas))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				schema := schemas[i]
+				id, err := client.RegisterSchema(ctx, groupName, schema.Name, schema.Format, schema.Content)
+				if err != nil {
+					results[i] = SchemaRegistrationResult{Err: err}
+					continue
+				}
+				results[i] = SchemaRegistrationResult{SchemaID: id}
+			}
+		}()
+	}
+	for i := range schemas {
+		// checked first so that a free worker doesn't pick schemas of a cancelled batch.
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return results, ctx.Err()
+}
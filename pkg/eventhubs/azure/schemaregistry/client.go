@@ -11,6 +11,8 @@ package schemaregistry
 // Changes may cause incorrect behavior and will be lost if the code is regenerated.
 
 import (
+	"net/http"
+
 	"github.com/Azure/go-autorest/autorest"
 )
 
@@ -18,6 +20,14 @@ import (
 type BaseClient struct {
 	autorest.Client
 	Endpoint string
+
+	httpClient       *http.Client
+	batchConcurrency int
+	failover         *failoverSender
+	middleware       []Middleware
+	next             autorest.Sender
+	retryPolicy      *RetryPolicy
+	clock            Clock
 }
 
 // New creates an instance of the BaseClient client.
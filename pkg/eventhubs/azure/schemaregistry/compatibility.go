@@ -0,0 +1,121 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// MaxCompatibilityVersions is the number of versions a compatibility matrix may cover.
+const MaxCompatibilityVersions = 20
+
+// ErrTooManyVersions is returned when a schema has more than `MaxCompatibilityVersions` versions.
+var ErrTooManyVersions = errors.New("too many schema versions for a compatibility matrix")
+
+// VersionCompatibility reports if data written with `FromVersion` can be read with `ToVersion`.
+type VersionCompatibility struct {
+	FromVersion int32
+	ToVersion   int32
+	Compatible  bool
+}
+
+// List returns the schema versions, whichever field the registry filled.
+func (v SchemaVersions) List() []int32 {
+	if v.Versions != nil {
+		return *v.Versions
+	}
+	if v.SchemaVersions != nil {
+		return *v.SchemaVersions
+	}
+	return nil
+}
+
+// CompatibilityMatrix checks the backward compatibility of every ordered pair of versions of the Avro schema.
+// Returns `ErrTooManyVersions` when the schema has more than `MaxCompatibilityVersions` versions.
+func (client SchemaClient) CompatibilityMatrix(ctx context.Context, groupName, schemaName string) ([]VersionCompatibility, error) {
+	result, err := client.GetVersions(ctx, groupName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	versions := append([]int32{}, result.List()...)
+	if len(versions) > MaxCompatibilityVersions {
+		return nil, fmt.Errorf("%w: %s has %d versions", ErrTooManyVersions, schemaName, len(versions))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	contents := make(map[int32]string, len(versions))
+	for _, version := range versions {
+		content, err := client.GetSchemaVersion(ctx, groupName, schemaName, version)
+		if err != nil {
+			return nil, err
+		}
+		contents[version] = content
+	}
+
+	matrix := []VersionCompatibility{}
+	for i, from := range versions {
+		for _, to := range versions[i+1:] {
+			compatible, err := BackwardCompatible(contents[to], contents[from])
+			if err != nil {
+				return nil, fmt.Errorf("failed checking version %d against version %d: %w", to, from, err)
+			}
+			matrix = append(matrix, VersionCompatibility{FromVersion: from, ToVersion: to, Compatible: compatible})
+		}
+	}
+	return matrix, nil
+}
+
+// GetSchemaVersion gets the content of a version of the schema.
+func (client SchemaClient) GetSchemaVersion(ctx context.Context, groupName, schemaName string, version int32) (string, error) {
+	req, err := client.GetSchemaVersionPreparer(ctx, groupName, schemaName, version)
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetSchemaVersion", nil, "Failure preparing request")
+	}
+	resp, err := client.GetVersionsSender(req)
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetSchemaVersion", resp, "Failure sending request")
+	}
+	var content []byte
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		byReadingBody(&content),
+		autorest.ByClosing())
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetSchemaVersion", resp, "Failure responding to request")
+	}
+	return string(content), nil
+}
+
+// GetSchemaVersionPreparer prepares the GetSchemaVersion request.
+func (client SchemaClient) GetSchemaVersionPreparer(ctx context.Context, groupName, schemaName string, version int32) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"groupName":     autorest.Encode("path", groupName),
+		"schemaName":    autorest.Encode("path", schemaName),
+		"schemaVersion": autorest.Encode("path", version),
+	}
+
+	const APIVersion = "2021-10"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/$schemaGroups/{groupName}/schemas/{schemaName}/versions/{schemaVersion}", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
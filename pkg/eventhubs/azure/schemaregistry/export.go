@@ -0,0 +1,247 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// ExportedSchema is a schema version in a schema registry export.
+type ExportedSchema struct {
+	Name    string `json:"name"`
+	Version int32  `json:"version"`
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+// SchemaNames a page of the schema names of a schema group.
+type SchemaNames struct {
+	autorest.Response `json:"-"`
+	Value             *[]string `json:"value,omitempty"`
+	NextLink          *string   `json:"nextLink,omitempty"`
+}
+
+// ExportSchemaRegistry streams all the versions of all the schemas in the group as a JSON array of `ExportedSchema`.
+// The schema names are listed before returning, errors fetching the versions fail the read of the returned reader.
+func (client SchemaClient) ExportSchemaRegistry(ctx context.Context, groupName string) (io.Reader, error) {
+	names, err := client.ListSchemas(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(client.exportSchemas(ctx, groupName, names, pw))
+	}()
+	return pr, nil
+}
+
+func (client SchemaClient) exportSchemas(ctx context.Context, groupName string, names []string, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	for _, name := range names {
+		schemas, err := client.schemaVersions(ctx, groupName, name)
+		if err != nil {
+			return err
+		}
+		for _, schema := range schemas {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			content, err := json.Marshal(schema)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(content); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// ImportSchemaRegistry registers the schemas of an `ExportSchemaRegistry` export that are missing from the group.
+// The versions of each schema are registered in their exported order, a version whose content is already
+// registered under the schema name is skipped.
+func (client SchemaClient) ImportSchemaRegistry(ctx context.Context, groupName string, r io.Reader) error {
+	schemas := []ExportedSchema{}
+	if err := json.NewDecoder(r).Decode(&schemas); err != nil {
+		return fmt.Errorf("invalid schema registry export: %w", err)
+	}
+	sort.SliceStable(schemas, func(i, j int) bool {
+		if schemas[i].Name != schemas[j].Name {
+			return schemas[i].Name < schemas[j].Name
+		}
+		return schemas[i].Version < schemas[j].Version
+	})
+
+	registered := map[string]map[string]bool{}
+	for _, schema := range schemas {
+		contents, ok := registered[schema.Name]
+		if !ok {
+			existing, err := client.schemaVersions(ctx, groupName, schema.Name)
+			if err != nil {
+				return err
+			}
+			contents = map[string]bool{}
+			for _, version := range existing {
+				contents[version.Content] = true
+			}
+			registered[schema.Name] = contents
+		}
+		if contents[schema.Content] {
+			continue
+		}
+		if _, err := client.RegisterSchema(ctx, groupName, schema.Name, schema.Format, schema.Content); err != nil {
+			return fmt.Errorf("failed importing version %d of %s: %w", schema.Version, schema.Name, err)
+		}
+		contents[schema.Content] = true
+	}
+	return nil
+}
+
+// schemaVersions gets all the versions of the schema in ascending order, none when the schema does not exist.
+func (client SchemaClient) schemaVersions(ctx context.Context, groupName, schemaName string) ([]ExportedSchema, error) {
+	result, err := client.GetVersions(ctx, groupName, schemaName)
+	var detailed autorest.DetailedError
+	if errors.As(err, &detailed) && detailed.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := append([]int32{}, result.List()...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	schemas := make([]ExportedSchema, 0, len(versions))
+	for _, version := range versions {
+		schema, err := client.GetExportedSchema(ctx, groupName, schemaName, version)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// GetExportedSchema gets a version of the schema with its format, Protobuf content is decoded.
+func (client SchemaClient) GetExportedSchema(ctx context.Context, groupName, schemaName string, version int32) (ExportedSchema, error) {
+	result := ExportedSchema{Name: schemaName, Version: version, Format: AvroFormat}
+	req, err := client.GetSchemaVersionPreparer(ctx, groupName, schemaName, version)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetExportedSchema", nil, "Failure preparing request")
+	}
+	resp, err := client.GetVersionsSender(req)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetExportedSchema", resp, "Failure sending request")
+	}
+	var content []byte
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		byReadingBody(&content),
+		autorest.ByClosing())
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetExportedSchema", resp, "Failure responding to request")
+	}
+	result.Content = string(content)
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		for format := range schemaContentTypes {
+			if strings.EqualFold(params["serialization"], format) {
+				result.Format = format
+			}
+		}
+	}
+	if result.Format == ProtobufFormat {
+		decoded, err := base64.StdEncoding.DecodeString(result.Content)
+		if err != nil {
+			return result, fmt.Errorf("version %d of %s is not a base64 encoded protobuf schema: %w", version, schemaName, err)
+		}
+		result.Content = string(decoded)
+	}
+	return result, nil
+}
+
+// ListSchemas gets the names of the schemas in the group, following the result pages.
+func (client SchemaClient) ListSchemas(ctx context.Context, groupName string) ([]string, error) {
+	req, err := client.ListSchemasPreparer(ctx, groupName)
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "ListSchemas", nil, "Failure preparing request")
+	}
+	names := []string{}
+	for req != nil {
+		resp, err := client.GetVersionsSender(req)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "ListSchemas", resp, "Failure sending request")
+		}
+		page, err := client.ListSchemasResponder(resp)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "ListSchemas", resp, "Failure responding to request")
+		}
+		if page.Value != nil {
+			names = append(names, *page.Value...)
+		}
+		req = nil
+		if page.NextLink != nil && strings.TrimSpace(*page.NextLink) != "" {
+			req, err = autorest.Prepare((&http.Request{}).WithContext(ctx),
+				autorest.AsGet(),
+				autorest.WithBaseURL(*page.NextLink))
+			if err != nil {
+				return nil, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "ListSchemas", nil, "Failure preparing next page request")
+			}
+		}
+	}
+	return names, nil
+}
+
+// ListSchemasPreparer prepares the ListSchemas request.
+func (client SchemaClient) ListSchemasPreparer(ctx context.Context, groupName string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"groupName": autorest.Encode("path", groupName),
+	}
+
+	const APIVersion = "2022-10"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/$schemaGroups/{groupName}/schemas", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// ListSchemasResponder handles the response to the ListSchemas request. The method always
+// closes the http.Response Body.
+func (client SchemaClient) ListSchemasResponder(resp *http.Response) (result SchemaNames, err error) {
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	return
+}
@@ -0,0 +1,121 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// DefaultPrimaryProbeInterval is the time the fallback endpoint serves the requests before the primary is tried again.
+const DefaultPrimaryProbeInterval = time.Minute
+
+// WithFallbackEndpoint sends the requests to the fallback `endpoint` when the primary endpoint fails with a 5xx or a network error.
+// The primary endpoint is probed again with the first request after the probe interval (see `WithPrimaryProbeInterval`).
+func WithFallbackEndpoint(endpoint string) Option {
+	return func(c *BaseClient) {
+		c.failoverSender().fallback = endpoint
+	}
+}
+
+// WithPrimaryProbeInterval sets the time the fallback endpoint serves the requests before the primary is tried again.
+func WithPrimaryProbeInterval(d time.Duration) Option {
+	return func(c *BaseClient) {
+		c.failoverSender().probeInterval = d
+	}
+}
+
+// ActiveEndpoint returns the endpoint currently serving the requests.
+func (c BaseClient) ActiveEndpoint() string {
+	if c.failover == nil {
+		return c.Endpoint
+	}
+	return c.failover.active(c.Endpoint)
+}
+
+// failoverSender returns the sender switching between the primary and the fallback endpoints, creating it on first use.
+func (c *BaseClient) failoverSender() *failoverSender {
+	if c.failover == nil {
+		c.failover = &failoverSender{next: c.http(), probeInterval: DefaultPrimaryProbeInterval}
+		c.Sender = c.failover
+	}
+	return c.failover
+}
+
+// failoverSender retries the failed requests of the primary endpoint on the fallback endpoint.
+type failoverSender struct {
+	next          autorest.Sender
+	fallback      string
+	probeInterval time.Duration
+
+	mu       sync.Mutex
+	failedAt time.Time
+	onBackup bool
+}
+
+// active returns the endpoint serving the requests of the `primary` endpoint.
+func (s *failoverSender) active(primary string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onBackup {
+		return s.fallback
+	}
+	return primary
+}
+
+// Do sends the request to the active endpoint, failing over to the fallback endpoint when the primary fails.
+func (s *failoverSender) Do(r *http.Request) (*http.Response, error) {
+	if s.fallback == "" {
+		return s.next.Do(r)
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+	}
+
+	s.mu.Lock()
+	probe := !s.onBackup || time.Since(s.failedAt) >= s.probeInterval
+	s.mu.Unlock()
+	if probe {
+		resp, err := s.send(r, r.URL.Host, body)
+		if !failed(resp, err) {
+			s.mu.Lock()
+			s.onBackup = false
+			s.mu.Unlock()
+			return resp, err
+		}
+		autorest.DrainResponseBody(resp)
+		s.mu.Lock()
+		s.onBackup = true
+		s.failedAt = time.Now()
+		s.mu.Unlock()
+	}
+	return s.send(r, s.fallback, body)
+}
+
+// send sends a copy of the request to the `host` endpoint.
+func (s *failoverSender) send(r *http.Request, host string, body []byte) (*http.Response, error) {
+	req := r.Clone(r.Context())
+	req.URL.Host = host
+	req.Host = ""
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	return s.next.Do(req)
+}
+
+// failed reports if the response is a network error or a server error.
+func failed(resp *http.Response, err error) bool {
+	return err != nil || resp == nil || resp.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,89 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// retryBackoff is the delay before the first retry of `RetryMiddleware`, doubled on every further attempt.
+const retryBackoff = 100 * time.Millisecond
+
+// Middleware wraps the sender of the registry requests, e.g. to log, authenticate or throttle them.
+type Middleware func(autorest.Sender) autorest.Sender
+
+// WithMiddleware sends the requests through the middleware, the first registered middleware sees the request first.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *BaseClient) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// Do sends the request through the registered middleware to the client sender.
+func (c BaseClient) Do(r *http.Request) (*http.Response, error) {
+	sender := c.next
+	if sender == nil {
+		sender = c.Sender
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		sender = c.middleware[i](sender)
+	}
+	return sender.Do(r)
+}
+
+// useMiddleware routes the requests through `Do` once all the options are applied,
+// so the middleware wraps the sender the options configured (e.g. the failover sender).
+func (c *BaseClient) useMiddleware() {
+	if len(c.middleware) == 0 {
+		return
+	}
+	c.http()
+	c.next = c.Sender
+	c.Sender = autorest.SenderFunc(c.Do)
+}
+
+// LoggingMiddleware logs the method, URL and status of every request.
+func LoggingMiddleware(logger zerolog.Logger) Middleware {
+	return func(next autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(r)
+			if err != nil {
+				logger.Error().Err(err).Str("method", r.Method).Str("url", r.URL.String()).Msg("schema registry request failed")
+				return resp, err
+			}
+			logger.Info().Str("method", r.Method).Str("url", r.URL.String()).Int("status", resp.StatusCode).
+				Dur("duration", time.Since(start)).Msg("schema registry request")
+			return resp, err
+		})
+	}
+}
+
+// RetryMiddleware retries the requests up to `maxAttempts` times by a `RetryPolicy` doubling 100ms between the attempts.
+// The client retry policy still wraps the middleware, use `WithRetryPolicy(1, 0, 0, 0)` to only retry in the middleware.
+func RetryMiddleware(maxAttempts int) Middleware {
+	policy := RetryPolicy{MaxAttempts: maxAttempts, InitialDelay: retryBackoff}
+	return Middleware(policy.sendDecorator(realClock{}))
+}
+
+// RateLimitMiddleware limits the requests sent by all the clients using the returned middleware to `rps` per second.
+// A non-positive rate doesn't limit the requests.
+func RateLimitMiddleware(rps float64) Middleware {
+	limiter := rate.NewLimiter(rate.Limit(rps), 1)
+	return func(next autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+			if rps > 0 {
+				if err := limiter.Wait(r.Context()); err != nil {
+					return nil, err
+				}
+			}
+			return next.Do(r)
+		})
+	}
+}
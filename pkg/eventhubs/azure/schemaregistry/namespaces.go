@@ -0,0 +1,162 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+const (
+	// armEndpoint is the Azure Resource Manager endpoint of the public cloud.
+	armEndpoint = "management.azure.com"
+	// armResource is the resource of the Azure Resource Manager access tokens.
+	armResource = "https://management.azure.com"
+)
+
+// Namespace is the Event Hubs namespace ARM resource.
+type Namespace struct {
+	ID         *string              `json:"id,omitempty"`
+	Name       *string              `json:"name,omitempty"`
+	Properties *NamespaceProperties `json:"properties,omitempty"`
+}
+
+// NamespaceProperties the properties of an Event Hubs namespace.
+type NamespaceProperties struct {
+	// ServiceBusEndpoint - endpoint of the namespace, e.g. `https://ns.servicebus.windows.net:443/`.
+	ServiceBusEndpoint *string `json:"serviceBusEndpoint,omitempty"`
+}
+
+// NamespaceList a page of Event Hubs namespaces.
+type NamespaceList struct {
+	autorest.Response `json:"-"`
+	Value             *[]Namespace `json:"value,omitempty"`
+	NextLink          *string      `json:"nextLink,omitempty"`
+}
+
+// NamespacesClient lists the Event Hubs namespaces of a subscription with Azure Resource Manager.
+type NamespacesClient struct {
+	BaseClient
+	SubscriptionID string
+}
+
+// NewNamespacesClient creates an instance of the NamespacesClient client for the subscription.
+func NewNamespacesClient(subscriptionID string, opts ...Option) NamespacesClient {
+	return NamespacesClient{BaseClient: NewWithOptions(armEndpoint, opts...), SubscriptionID: subscriptionID}
+}
+
+// DiscoverEventHubNamespaces returns the schema registry endpoints of the Event Hubs namespaces in the resource group.
+// The request is authorized with the environment credentials.
+func DiscoverEventHubNamespaces(ctx context.Context, subscriptionID, resourceGroup string) ([]string, error) {
+	authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(armResource)
+	if err != nil {
+		return nil, err
+	}
+	client := NewNamespacesClient(subscriptionID)
+	client.Authorizer = authorizer
+	return client.SchemaRegistryEndpoints(ctx, resourceGroup)
+}
+
+// SchemaRegistryEndpoints returns the schema registry endpoints of the Event Hubs namespaces in the resource group.
+func (client NamespacesClient) SchemaRegistryEndpoints(ctx context.Context, resourceGroup string) ([]string, error) {
+	namespaces, err := client.ListByResourceGroup(ctx, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if endpoint := SchemaRegistryEndpoint(ns); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// SchemaRegistryEndpoint returns the schema registry endpoint (the namespace host) of the namespace,
+// the public cloud host of the namespace name when its endpoint is not set.
+func SchemaRegistryEndpoint(ns Namespace) string {
+	if ns.Properties != nil && ns.Properties.ServiceBusEndpoint != nil {
+		if u, err := url.Parse(*ns.Properties.ServiceBusEndpoint); err == nil && u.Hostname() != "" {
+			return u.Hostname()
+		}
+	}
+	if ns.Name != nil && *ns.Name != "" {
+		return *ns.Name + ".servicebus.windows.net"
+	}
+	return ""
+}
+
+// ListByResourceGroup gets all the Event Hubs namespaces of the resource group, following the result pages.
+func (client NamespacesClient) ListByResourceGroup(ctx context.Context, resourceGroup string) ([]Namespace, error) {
+	req, err := client.ListByResourceGroupPreparer(ctx, resourceGroup)
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "schemaregistry.NamespacesClient", "ListByResourceGroup", nil, "Failure preparing request")
+	}
+	namespaces := []Namespace{}
+	for req != nil {
+		resp, err := client.Send(req, client.retrySender())
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.NamespacesClient", "ListByResourceGroup", resp, "Failure sending request")
+		}
+		page, err := client.ListByResourceGroupResponder(resp)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.NamespacesClient", "ListByResourceGroup", resp, "Failure responding to request")
+		}
+		if page.Value != nil {
+			namespaces = append(namespaces, *page.Value...)
+		}
+		req = nil
+		if page.NextLink != nil && strings.TrimSpace(*page.NextLink) != "" {
+			req, err = autorest.Prepare((&http.Request{}).WithContext(ctx),
+				autorest.AsGet(),
+				autorest.WithBaseURL(*page.NextLink))
+			if err != nil {
+				return nil, autorest.NewErrorWithError(err, "schemaregistry.NamespacesClient", "ListByResourceGroup", nil, "Failure preparing next page request")
+			}
+		}
+	}
+	return namespaces, nil
+}
+
+// ListByResourceGroupPreparer prepares the ListByResourceGroup request.
+func (client NamespacesClient) ListByResourceGroupPreparer(ctx context.Context, resourceGroup string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"resourceGroupName": autorest.Encode("path", resourceGroup),
+		"subscriptionId":    autorest.Encode("path", client.SubscriptionID),
+	}
+
+	const APIVersion = "2021-11-01"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.EventHub/namespaces", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// ListByResourceGroupResponder handles the response to the ListByResourceGroup request. The method always
+// closes the http.Response Body.
+func (client NamespacesClient) ListByResourceGroupResponder(resp *http.Response) (result NamespaceList, err error) {
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	return
+}
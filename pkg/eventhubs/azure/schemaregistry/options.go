@@ -0,0 +1,160 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConns is the number of idle connections the client keeps, as in the Azure SDK transport.
+	DefaultMaxIdleConns = 100
+	// DefaultMaxIdleConnsPerHost is the number of idle connections the client keeps per host.
+	DefaultMaxIdleConnsPerHost = http.DefaultMaxIdleConnsPerHost
+	// DefaultIdleConnTimeout is the time an idle connection is kept, as in the Azure SDK transport.
+	DefaultIdleConnTimeout = 90 * time.Second
+	// DefaultKeepAlive is the TCP keep-alive period of the connections, as in the Azure SDK transport.
+	DefaultKeepAlive = 30 * time.Second
+	// dialTimeout is the time a connection may take to establish.
+	dialTimeout = 30 * time.Second
+)
+
+// Option configures a `BaseClient`.
+type Option func(*BaseClient)
+
+// NewWithOptions creates an instance of the BaseClient client configured by the options.
+func NewWithOptions(endpoint string, opts ...Option) BaseClient {
+	client := New(endpoint)
+	for _, opt := range opts {
+		opt(&client)
+	}
+	client.useMiddleware()
+	return client
+}
+
+// NewSchemaGroupsClientWithOptions creates an instance of the SchemaGroupsClient client configured by the options.
+func NewSchemaGroupsClientWithOptions(endpoint string, opts ...Option) SchemaGroupsClient {
+	return SchemaGroupsClient{NewWithOptions(endpoint, opts...)}
+}
+
+// NewSchemaClientWithOptions creates an instance of the SchemaClient client configured by the options.
+func NewSchemaClientWithOptions(endpoint string, opts ...Option) SchemaClient {
+	return SchemaClient{NewWithOptions(endpoint, opts...)}
+}
+
+// WithRequestTimeout limits every registry request to `d`, including the wait between retries.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *BaseClient) {
+		c.http().Timeout = d
+		c.RetryDuration = d
+	}
+}
+
+// WithTLSConfig replaces the TLS configuration of the client transport, e.g. to trust a private CA with `RootCAs`.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *BaseClient) {
+		transport := c.transport().Clone()
+		transport.TLSClientConfig = cfg.Clone()
+		c.http().Transport = transport
+	}
+}
+
+// WithClientCert authenticates the client with the PEM encoded certificate and key (mTLS).
+// Returns an error when the certificate or the key can't be parsed.
+func WithClientCert(certPEM, keyPEM []byte) (Option, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return func(c *BaseClient) {
+		transport := c.transport().Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.Certificates = append(transport.TLSClientConfig.Certificates, cert)
+		c.http().Transport = transport
+	}, nil
+}
+
+// WithBatchConcurrency limits the number of schemas a batch registers in parallel.
+func WithBatchConcurrency(n int) Option {
+	return func(c *BaseClient) {
+		c.batchConcurrency = n
+	}
+}
+
+// WithConnectionPool sets the idle connections the client keeps for reuse, the defaults are used for non-positive values.
+func WithConnectionPool(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) Option {
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = DefaultMaxIdleConnsPerHost
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleConnTimeout
+	}
+	return func(c *BaseClient) {
+		transport := c.transport().Clone()
+		transport.MaxIdleConns = maxIdle
+		transport.MaxIdleConnsPerHost = maxIdlePerHost
+		transport.IdleConnTimeout = idleTimeout
+		c.http().Transport = transport
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period of the client connections, a negative period disables the keep-alives.
+func WithKeepAlive(d time.Duration) Option {
+	return func(c *BaseClient) {
+		transport := c.transport().Clone()
+		transport.DialContext = (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: d,
+		}).DialContext
+		c.http().Transport = transport
+	}
+}
+
+// http returns the `http.Client` sending the client requests.
+// The autorest default sender is shared by all the clients, so a dedicated one is created on first use.
+func (c *BaseClient) http() *http.Client {
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Transport: defaultTransport()}
+		c.Sender = c.httpClient
+	}
+	return c.httpClient
+}
+
+// transport returns the `http.Transport` of the client, the defaults when another round tripper is used.
+func (c *BaseClient) transport() *http.Transport {
+	if transport, ok := c.http().Transport.(*http.Transport); ok {
+		return transport
+	}
+	return defaultTransport()
+}
+
+// defaultTransport returns a transport with the autorest sender defaults.
+func defaultTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: DefaultKeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion:    tls.VersionTLS12,
+			Renegotiation: tls.RenegotiateNever,
+		},
+	}
+}
@@ -0,0 +1,658 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProtobufFormat is the serialization format of Protocol Buffers schemas.
+const ProtobufFormat = "Protobuf"
+
+const (
+	// maxFieldNumber is the largest protobuf field number.
+	maxFieldNumber = 536870911
+	// reservedFieldNumbersStart - reservedFieldNumbersEnd are reserved for the protobuf implementation.
+	reservedFieldNumbersStart = 19000
+	reservedFieldNumbersEnd   = 19999
+)
+
+// protobufScalars are the protobuf scalar value types.
+var protobufScalars = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+// ErrInvalidProtobufSchema is returned when a schema is not a valid `.proto` definition.
+type ErrInvalidProtobufSchema struct {
+	Line   int
+	Reason string
+}
+
+func (e ErrInvalidProtobufSchema) Error() string {
+	return fmt.Sprintf("invalid protobuf schema at line %d: %s", e.Line, e.Reason)
+}
+
+// ValidateProtobufSchema verifies the `content` is a valid `.proto` definition: a known syntax, well formed declarations,
+// unique field names and numbers, and resolved message and enum types.
+// Qualified types are not resolved when the file has imports, since they may be defined by the imported files.
+func ValidateProtobufSchema(content string) error {
+	tokens, err := tokenizeProto(content)
+	if err != nil {
+		return err
+	}
+	p := &protoParser{tokens: tokens, declared: map[string]bool{}}
+	if err := p.parseFile(); err != nil {
+		return err
+	}
+	return p.resolve()
+}
+
+// protoToken is a lexical token of a `.proto` file.
+type protoToken struct {
+	text string
+	line int
+	str  bool
+}
+
+// tokenizeProto splits the `.proto` content into identifiers, numbers, strings and symbols, skipping the comments.
+func tokenizeProto(content string) ([]protoToken, error) {
+	tokens := []protoToken{}
+	runes := []rune(content)
+	line := 1
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := line
+			i += 2
+			for ; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+			}
+			if i+1 >= len(runes) {
+				return nil, ErrInvalidProtobufSchema{Line: start, Reason: "unterminated comment"}
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' {
+					i++
+				} else if runes[i] == '\n' {
+					return nil, ErrInvalidProtobufSchema{Line: line, Reason: "unterminated string"}
+				}
+			}
+			if i >= len(runes) {
+				return nil, ErrInvalidProtobufSchema{Line: line, Reason: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, protoToken{text: string(runes[start+1 : i-1]), line: line, str: true})
+		case r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '+':
+			start := i
+			for i++; i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])); i++ {
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		case strings.ContainsRune("{}[]()<>;,=:", r):
+			tokens = append(tokens, protoToken{text: string(r), line: line})
+			i++
+		default:
+			return nil, ErrInvalidProtobufSchema{Line: line, Reason: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return tokens, nil
+}
+
+// protoReference is a message or enum type used by a field.
+type protoReference struct {
+	name  string
+	scope string
+	line  int
+}
+
+// protoParser validates the declarations of a tokenized `.proto` file.
+type protoParser struct {
+	tokens     []protoToken
+	pos        int
+	syntax     string
+	pkg        string
+	hasImports bool
+	declared   map[string]bool
+	references []protoReference
+	// fields are the `Message.field` paths of the message fields, relative to the package.
+	fields []string
+}
+
+func (p *protoParser) peek() protoToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	line := 1
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return protoToken{line: line}
+}
+
+func (p *protoParser) next() protoToken {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *protoParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *protoParser) errorf(t protoToken, format string, args ...interface{}) error {
+	return ErrInvalidProtobufSchema{Line: t.line, Reason: fmt.Sprintf(format, args...)}
+}
+
+// expect consumes the `text` token.
+func (p *protoParser) expect(text string) error {
+	t := p.next()
+	if t.str || t.text != text {
+		if t.text == "" && !t.str {
+			return p.errorf(t, "expected %q, found end of file", text)
+		}
+		return p.errorf(t, "expected %q, found %q", text, t.text)
+	}
+	return nil
+}
+
+// ident consumes an identifier, qualified (`a.b.C`) when `qualified` is set.
+func (p *protoParser) ident(qualified bool) (protoToken, error) {
+	t := p.next()
+	if t.str || !isProtoIdent(t.text, qualified) {
+		return t, p.errorf(t, "expected an identifier, found %q", t.text)
+	}
+	return t, nil
+}
+
+// skipStatement consumes the tokens up to the end of the statement, including nested blocks.
+func (p *protoParser) skipStatement() error {
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		if t.str {
+			continue
+		}
+		switch t.text {
+		case "{", "[", "(":
+			depth++
+		case "}", "]", ")":
+			depth--
+			if depth == 0 && t.text == "}" {
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf(p.peek(), "unexpected end of file")
+}
+
+func (p *protoParser) parseFile() error {
+	if p.peek().text == "syntax" && !p.peek().str {
+		p.next()
+		if err := p.expect("="); err != nil {
+			return err
+		}
+		t := p.next()
+		if !t.str || (t.text != "proto2" && t.text != "proto3") {
+			return p.errorf(t, "unknown syntax %q", t.text)
+		}
+		p.syntax = t.text
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	} else {
+		p.syntax = "proto2"
+	}
+	for !p.done() {
+		t := p.peek()
+		switch t.text {
+		case "package":
+			p.next()
+			name, err := p.ident(true)
+			if err != nil {
+				return err
+			}
+			p.pkg = name.text
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case "import":
+			p.next()
+			if p.peek().text == "public" || p.peek().text == "weak" {
+				p.next()
+			}
+			if path := p.next(); !path.str {
+				return p.errorf(path, "expected the import path, found %q", path.text)
+			}
+			p.hasImports = true
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case "option", "extend":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case "message":
+			if err := p.parseMessage(p.pkg); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(p.pkg); err != nil {
+				return err
+			}
+		case "service":
+			if err := p.parseService(); err != nil {
+				return err
+			}
+		case ";":
+			p.next()
+		default:
+			return p.errorf(t, "unexpected %q", t.text)
+		}
+	}
+	return nil
+}
+
+// declare registers the message or enum `name` in the `scope` and returns its full name.
+func (p *protoParser) declare(name protoToken, scope string) (string, error) {
+	full := qualifyProto(name.text, scope)
+	if p.declared[full] {
+		return "", p.errorf(name, "%s is already defined", full)
+	}
+	p.declared[full] = true
+	return full, nil
+}
+
+// protoFields tracks the field names and numbers of a message.
+type protoFields struct {
+	names   map[string]bool
+	numbers map[int64]string
+}
+
+func (p *protoParser) parseMessage(scope string) error {
+	p.next()
+	name, err := p.ident(false)
+	if err != nil {
+		return err
+	}
+	full, err := p.declare(name, scope)
+	if err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	fields := &protoFields{names: map[string]bool{}, numbers: map[int64]string{}}
+	for {
+		t := p.peek()
+		if t.text == "" && !t.str {
+			return p.errorf(t, "message %s is not closed", full)
+		}
+		switch t.text {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "message":
+			err = p.parseMessage(full)
+		case "enum":
+			err = p.parseEnum(full)
+		case "option", "reserved", "extensions", "extend":
+			err = p.skipStatement()
+		case "oneof":
+			err = p.parseOneof(full, fields)
+		default:
+			err = p.parseField(full, fields, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *protoParser) parseOneof(scope string, fields *protoFields) error {
+	p.next()
+	if _, err := p.ident(false); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		switch p.peek().text {
+		case "}":
+			p.next()
+			return nil
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseField(scope, fields, false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseField validates a `[label] type name = number [options];` or `map<key, value> name = number;` field.
+func (p *protoParser) parseField(scope string, fields *protoFields, labels bool) error {
+	t := p.peek()
+	if labels && (t.text == "repeated" || t.text == "optional" || t.text == "required") {
+		if t.text == "required" && p.syntax == "proto3" {
+			return p.errorf(t, "required fields are not allowed in proto3")
+		}
+		p.next()
+	}
+	if p.peek().text == "map" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "<" {
+		p.next()
+		p.next()
+		key, err := p.ident(false)
+		if err != nil {
+			return err
+		}
+		if !protobufScalars[key.text] || key.text == "double" || key.text == "float" || key.text == "bytes" {
+			return p.errorf(key, "invalid map key type %q", key.text)
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+		if err := p.fieldType(scope); err != nil {
+			return err
+		}
+		if err := p.expect(">"); err != nil {
+			return err
+		}
+	} else if err := p.fieldType(scope); err != nil {
+		return err
+	}
+	name, err := p.ident(false)
+	if err != nil {
+		return err
+	}
+	if fields.names[name.text] {
+		return p.errorf(name, "duplicate field name %q", name.text)
+	}
+	fields.names[name.text] = true
+	p.fields = append(p.fields, strings.TrimPrefix(qualifyProto(name.text, scope), p.pkg+"."))
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	numberToken := p.next()
+	number, err := strconv.ParseInt(numberToken.text, 0, 64)
+	if err != nil || numberToken.str {
+		return p.errorf(numberToken, "invalid field number %q", numberToken.text)
+	}
+	if number < 1 || number > maxFieldNumber || (number >= reservedFieldNumbersStart && number <= reservedFieldNumbersEnd) {
+		return p.errorf(numberToken, "field number %d of %s is out of range", number, name.text)
+	}
+	if other, ok := fields.numbers[number]; ok {
+		return p.errorf(numberToken, "field number %d of %s is already used by %s", number, name.text, other)
+	}
+	fields.numbers[number] = name.text
+	if p.peek().text == "[" {
+		if err := p.skipOptions(); err != nil {
+			return err
+		}
+	}
+	return p.expect(";")
+}
+
+// fieldType consumes a field type, recording the message and enum references.
+func (p *protoParser) fieldType(scope string) error {
+	t, err := p.ident(true)
+	if err != nil {
+		return err
+	}
+	if !protobufScalars[t.text] {
+		p.references = append(p.references, protoReference{name: t.text, scope: scope, line: t.line})
+	}
+	return nil
+}
+
+// skipOptions consumes a `[...]` options list.
+func (p *protoParser) skipOptions() error {
+	open := p.next()
+	depth := 1
+	for !p.done() {
+		t := p.next()
+		if t.str {
+			continue
+		}
+		switch t.text {
+		case "[":
+			depth++
+		case "]":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf(open, "options are not closed")
+}
+
+func (p *protoParser) parseEnum(scope string) error {
+	p.next()
+	name, err := p.ident(false)
+	if err != nil {
+		return err
+	}
+	full, err := p.declare(name, scope)
+	if err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	values := map[string]bool{}
+	first := true
+	for {
+		t := p.peek()
+		switch {
+		case t.text == "" && !t.str:
+			return p.errorf(t, "enum %s is not closed", full)
+		case t.text == "}":
+			p.next()
+			if first {
+				return p.errorf(t, "enum %s has no values", full)
+			}
+			return nil
+		case t.text == ";":
+			p.next()
+		case t.text == "option" || t.text == "reserved":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			value, err := p.ident(false)
+			if err != nil {
+				return err
+			}
+			if values[value.text] {
+				return p.errorf(value, "duplicate enum value %q", value.text)
+			}
+			values[value.text] = true
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			numberToken := p.next()
+			number, err := strconv.ParseInt(numberToken.text, 0, 32)
+			if err != nil || numberToken.str {
+				return p.errorf(numberToken, "invalid enum number %q", numberToken.text)
+			}
+			if first && p.syntax == "proto3" && number != 0 {
+				return p.errorf(numberToken, "the first value of enum %s must be zero in proto3", full)
+			}
+			first = false
+			if p.peek().text == "[" {
+				if err := p.skipOptions(); err != nil {
+					return err
+				}
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseService() error {
+	p.next()
+	if _, err := p.ident(false); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		t := p.peek()
+		switch t.text {
+		case "":
+			return p.errorf(t, "service is not closed")
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case "rpc":
+			if err := p.parseRPC(); err != nil {
+				return err
+			}
+		default:
+			return p.errorf(t, "unexpected %q", t.text)
+		}
+	}
+}
+
+// parseRPC validates a `rpc Name ([stream] Request) returns ([stream] Response);` method.
+func (p *protoParser) parseRPC() error {
+	p.next()
+	if _, err := p.ident(false); err != nil {
+		return err
+	}
+	if err := p.rpcType(); err != nil {
+		return err
+	}
+	if err := p.expect("returns"); err != nil {
+		return err
+	}
+	if err := p.rpcType(); err != nil {
+		return err
+	}
+	if p.peek().text == "{" {
+		return p.skipStatement()
+	}
+	return p.expect(";")
+}
+
+// rpcType consumes the `([stream] Type)` of a method request or response.
+func (p *protoParser) rpcType() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if p.peek().text == "stream" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text != ")" {
+		p.next()
+	}
+	if err := p.fieldType(p.pkg); err != nil {
+		return err
+	}
+	return p.expect(")")
+}
+
+// resolve verifies every referenced type is declared, searching from the innermost scope outwards.
+func (p *protoParser) resolve() error {
+	for _, ref := range p.references {
+		if p.resolved(ref) {
+			continue
+		}
+		if p.hasImports {
+			continue
+		}
+		return ErrInvalidProtobufSchema{Line: ref.line, Reason: fmt.Sprintf("unknown type %q", ref.name)}
+	}
+	return nil
+}
+
+func (p *protoParser) resolved(ref protoReference) bool {
+	if strings.HasPrefix(ref.name, ".") {
+		return p.declared[strings.TrimPrefix(ref.name, ".")]
+	}
+	scope := ref.scope
+	for {
+		if p.declared[qualifyProto(ref.name, scope)] {
+			return true
+		}
+		if scope == "" {
+			return false
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// qualifyProto returns the full name of `name` declared in `scope`.
+func qualifyProto(name, scope string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// isProtoIdent reports if `text` is an identifier, a dotted full identifier when `qualified` is set.
+func isProtoIdent(text string, qualified bool) bool {
+	if text == "" {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(text, "."), ".")
+	if len(parts) > 1 && !qualified {
+		return false
+	}
+	if strings.HasPrefix(text, ".") && !qualified {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+		for i, r := range part {
+			if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+				return false
+			}
+		}
+	}
+	return true
+}
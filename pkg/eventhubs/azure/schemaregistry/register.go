@@ -0,0 +1,155 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// AvroFormat is the serialization format of Avro schemas.
+const AvroFormat = "Avro"
+
+// JSONFormat is the serialization format of JSON schemas.
+const JSONFormat = "Json"
+
+// schemaContentTypes maps the schema formats to the content type of their requests and responses.
+var schemaContentTypes = map[string]string{
+	AvroFormat:     "application/json; serialization=Avro; charset=utf-8",
+	ProtobufFormat: "text/plain; serialization=Protobuf; charset=utf-8",
+	JSONFormat:     "application/json; serialization=Json; charset=utf-8",
+}
+
+// RegisterSchema validates the schema content of the given `format` and registers it.
+// Returns the `SchemaID` the registry assigned to the new schema version.
+func (client SchemaClient) RegisterSchema(ctx context.Context, groupName, schemaName, format, schemaContent string) (SchemaID, error) {
+	result := SchemaID{}
+	switch format {
+	case AvroFormat:
+		if err := ValidateAvroSchema(schemaContent); err != nil {
+			return result, err
+		}
+	case ProtobufFormat:
+		return client.RegisterProtobufSchema(ctx, groupName, schemaName, schemaContent)
+	default:
+		return result, fmt.Errorf("unsupported schema format %q", format)
+	}
+	// the generated Register sends the content JSON encoded as a string, the registry expects the schema itself.
+	req, err := client.RegisterFormatPreparer(ctx, groupName, schemaName, AvroFormat, schemaContent)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterSchema", nil, "Failure preparing request")
+	}
+	resp, err := client.RegisterSender(req)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterSchema", resp, "Failure sending request")
+	}
+	registered, err := client.RegisterResponder(resp)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterSchema", resp, "Failure responding to request")
+	}
+	return schemaID(registered), nil
+}
+
+// RegisterProtobufSchema validates the `.proto` content and registers it base64 encoded.
+func (client SchemaClient) RegisterProtobufSchema(ctx context.Context, groupName, schemaName, protoContent string) (SchemaID, error) {
+	result := SchemaID{}
+	if err := ValidateProtobufSchema(protoContent); err != nil {
+		return result, err
+	}
+	req, err := client.RegisterFormatPreparer(ctx, groupName, schemaName, ProtobufFormat, base64.StdEncoding.EncodeToString([]byte(protoContent)))
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterProtobufSchema", nil, "Failure preparing request")
+	}
+	resp, err := client.RegisterSender(req)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterProtobufSchema", resp, "Failure sending request")
+	}
+	registered, err := client.RegisterResponder(resp)
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "RegisterProtobufSchema", resp, "Failure responding to request")
+	}
+	return schemaID(registered), nil
+}
+
+// RegisterFormatPreparer prepares a Register request of a schema in the given `format`, sending the content as is.
+func (client SchemaClient) RegisterFormatPreparer(ctx context.Context, groupName, schemaName, format, schemaContent string) (*http.Request, error) {
+	contentType, ok := schemaContentTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema format %q", format)
+	}
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"groupName":  autorest.Encode("path", groupName),
+		"schemaName": autorest.Encode("path", schemaName),
+	}
+
+	const APIVersion = "2021-10"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType(contentType),
+		autorest.AsPut(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/$schemaGroups/{groupName}/schemas/{schemaName}", pathParameters),
+		autorest.WithString(schemaContent),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// GetProtobufSchema gets the `.proto` content of the registered schema ID.
+func (client SchemaClient) GetProtobufSchema(ctx context.Context, ID string) (string, error) {
+	req, err := client.GetByIDPreparer(ctx, ID)
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetProtobufSchema", nil, "Failure preparing request")
+	}
+	resp, err := client.GetByIDSender(req)
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetProtobufSchema", resp, "Failure sending request")
+	}
+	var content []byte
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		byReadingBody(&content),
+		autorest.ByClosing())
+	if err != nil {
+		return "", autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "GetProtobufSchema", resp, "Failure responding to request")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(content))
+	if err != nil {
+		return "", fmt.Errorf("schema %s is not a base64 encoded protobuf schema: %w", ID, err)
+	}
+	return string(decoded), nil
+}
+
+// byReadingBody returns a RespondDecorator that reads the raw response body into `content`.
+func byReadingBody(content *[]byte) autorest.RespondDecorator {
+	return func(r autorest.Responder) autorest.Responder {
+		return autorest.ResponderFunc(func(resp *http.Response) error {
+			if err := r.Respond(resp); err != nil {
+				return err
+			}
+			body, err := io.ReadAll(resp.Body)
+			*content = body
+			return err
+		})
+	}
+}
+
+// schemaID returns the `SchemaID` of a Register response.
+func schemaID(resp autorest.Response) SchemaID {
+	id := resp.Header.Get("Schema-Id")
+	return SchemaID{ID: &id}
+}
@@ -0,0 +1,140 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// RetryPolicy retries the registry requests failing with a transport error or one of the
+// `autorest.StatusCodesForRetry` statuses, waiting a doubling delay between the attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first attempt.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between the attempts, zero doesn't cap it.
+	MaxDelay time.Duration
+	// JitterFraction randomizes every delay by up to this fraction in either direction, e.g. 0.2 waits 80%-120% of the delay.
+	JitterFraction float64
+}
+
+// Clock waits between the retries of a request.
+type Clock interface {
+	// Sleep waits `d`, or returns the context error once it is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// realClock waits with a timer.
+type realClock struct{}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithRetryPolicy replaces the autorest retry defaults (`RetryAttempts` retries doubling `RetryDuration` from 30s)
+// by `maxAttempts` attempts doubling `initialDelay` up to `maxDelay`, each delay randomized by `jitterFraction`.
+func WithRetryPolicy(maxAttempts int, initialDelay, maxDelay time.Duration, jitterFraction float64) Option {
+	return func(c *BaseClient) {
+		c.retryPolicy = &RetryPolicy{
+			MaxAttempts:    maxAttempts,
+			InitialDelay:   initialDelay,
+			MaxDelay:       maxDelay,
+			JitterFraction: jitterFraction,
+		}
+	}
+}
+
+// WithClock waits between the retries with `clock`, e.g. to observe the delays in tests.
+func WithClock(clock Clock) Option {
+	return func(c *BaseClient) {
+		c.clock = clock
+	}
+}
+
+// retrySender returns the decorator retrying the client requests by its retry policy,
+// the autorest defaults of the client when no policy is set.
+func (c BaseClient) retrySender() autorest.SendDecorator {
+	// `RetryAttempts` doesn't count the first attempt.
+	policy := RetryPolicy{MaxAttempts: c.RetryAttempts + 1, InitialDelay: c.RetryDuration}
+	if c.retryPolicy != nil {
+		policy = *c.retryPolicy
+	}
+	clock := c.clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	return policy.sendDecorator(clock)
+}
+
+// Delay returns the delay before retry number `retry` (0 based) without the jitter.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.InitialDelay
+	for i := 0; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+func (p RetryPolicy) sendDecorator(clock Clock) autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+			rr := autorest.NewRetriableRequest(r)
+			var resp *http.Response
+			var err error
+			for attempt := 0; ; attempt++ {
+				if err = rr.Prepare(); err != nil {
+					return resp, err
+				}
+				resp, err = s.Do(rr.Request())
+				if !retriable(resp, err) || attempt >= p.MaxAttempts-1 || r.Context().Err() != nil {
+					return resp, err
+				}
+				autorest.DrainResponseBody(resp)
+				if sleepErr := clock.Sleep(r.Context(), jitter(p.Delay(attempt), p.JitterFraction)); sleepErr != nil {
+					return resp, sleepErr
+				}
+			}
+		})
+	}
+}
+
+// retriable returns true for transport errors and the `autorest.StatusCodesForRetry` statuses.
+// Like the autorest retries, failing to refresh the token is not retried as it will never succeed.
+func retriable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !autorest.IsTokenRefreshError(err)
+	}
+	return autorest.ResponseHasStatusCode(resp, autorest.StatusCodesForRetry...)
+}
+
+// jitter randomizes `d` by up to `fraction` in either direction, using `crypto/rand` so clients don't retry in step.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return d
+	}
+	// a uniform value in [-1, 1)
+	r := float64(binary.BigEndian.Uint64(b[:])>>11)/(1<<52) - 1
+	return time.Duration(float64(d) * (1 + fraction*r))
+}
@@ -0,0 +1,249 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+// roleAssignmentsAPIVersion is the Microsoft.Authorization api version of the role assignment requests.
+const roleAssignmentsAPIVersion = "2022-04-01"
+
+// RoleAssignment is an Azure role assignment ARM resource.
+type RoleAssignment struct {
+	autorest.Response `json:"-"`
+	ID                *string                   `json:"id,omitempty"`
+	Name              *string                   `json:"name,omitempty"`
+	Properties        *RoleAssignmentProperties `json:"properties,omitempty"`
+}
+
+// RoleAssignmentProperties the properties of a role assignment.
+type RoleAssignmentProperties struct {
+	// RoleDefinitionID - the ARM resource id of the assigned role definition.
+	RoleDefinitionID *string `json:"roleDefinitionId,omitempty"`
+	// PrincipalID - the AAD object id of the principal.
+	PrincipalID *string `json:"principalId,omitempty"`
+	// Scope - the resource id the role is assigned at (read only).
+	Scope *string `json:"scope,omitempty"`
+	// Description - free text, used to mark the assignments made by the operator.
+	Description *string `json:"description,omitempty"`
+}
+
+// RoleAssignmentList a page of role assignments.
+type RoleAssignmentList struct {
+	autorest.Response `json:"-"`
+	Value             *[]RoleAssignment `json:"value,omitempty"`
+	NextLink          *string           `json:"nextLink,omitempty"`
+}
+
+// roleAssignmentCreate is the body of the role assignment create request.
+type roleAssignmentCreate struct {
+	Properties RoleAssignmentProperties `json:"properties"`
+}
+
+// RoleAssignmentsClient manages Azure role assignments with Azure Resource Manager.
+type RoleAssignmentsClient struct {
+	BaseClient
+}
+
+// NewRoleAssignmentsClient creates an instance of the RoleAssignmentsClient client.
+func NewRoleAssignmentsClient(opts ...Option) RoleAssignmentsClient {
+	return RoleAssignmentsClient{BaseClient: NewWithOptions(armEndpoint, opts...)}
+}
+
+// NewRoleAssignmentsClientFromEnvironment creates a RoleAssignmentsClient authorized with the environment credentials.
+func NewRoleAssignmentsClientFromEnvironment(opts ...Option) (RoleAssignmentsClient, error) {
+	client := NewRoleAssignmentsClient(opts...)
+	authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(armResource)
+	if err != nil {
+		return client, err
+	}
+	client.Authorizer = authorizer
+	return client, nil
+}
+
+// ListForScope gets the role assignments at the scope, following the result pages.
+func (client RoleAssignmentsClient) ListForScope(ctx context.Context, scope string) ([]RoleAssignment, error) {
+	req, err := client.ListForScopePreparer(ctx, scope)
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "ListForScope", nil, "Failure preparing request")
+	}
+	assignments := []RoleAssignment{}
+	for req != nil {
+		resp, err := client.Send(req, client.retrySender())
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "ListForScope", resp, "Failure sending request")
+		}
+		page, err := client.ListForScopeResponder(resp)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "ListForScope", resp, "Failure responding to request")
+		}
+		if page.Value != nil {
+			assignments = append(assignments, *page.Value...)
+		}
+		req = nil
+		if page.NextLink != nil && strings.TrimSpace(*page.NextLink) != "" {
+			req, err = autorest.Prepare((&http.Request{}).WithContext(ctx),
+				autorest.AsGet(),
+				autorest.WithBaseURL(*page.NextLink))
+			if err != nil {
+				return nil, autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "ListForScope", nil, "Failure preparing next page request")
+			}
+		}
+	}
+	return assignments, nil
+}
+
+// ListForScopePreparer prepares the ListForScope request.
+func (client RoleAssignmentsClient) ListForScopePreparer(ctx context.Context, scope string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"scope": strings.TrimPrefix(scope, "/"),
+	}
+
+	queryParameters := map[string]interface{}{
+		"$filter":     autorest.Encode("query", "atScope()"),
+		"api-version": roleAssignmentsAPIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/{scope}/providers/Microsoft.Authorization/roleAssignments", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// ListForScopeResponder handles the response to the ListForScope request. The method always
+// closes the http.Response Body.
+func (client RoleAssignmentsClient) ListForScopeResponder(resp *http.Response) (result RoleAssignmentList, err error) {
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	return
+}
+
+// Create assigns a role at the scope.
+// Parameters:
+// scope - the resource id the role is assigned at.
+// name - the GUID name of the role assignment.
+func (client RoleAssignmentsClient) Create(ctx context.Context, scope, name string, properties RoleAssignmentProperties) (result RoleAssignment, err error) {
+	req, err := client.CreatePreparer(ctx, scope, name, properties)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Create", nil, "Failure preparing request")
+		return
+	}
+
+	resp, err := client.Send(req, client.retrySender())
+	if err != nil {
+		result.Response = autorest.Response{Response: resp}
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Create", resp, "Failure sending request")
+		return
+	}
+
+	result, err = client.CreateResponder(resp)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Create", resp, "Failure responding to request")
+	}
+	return
+}
+
+// CreatePreparer prepares the Create request.
+func (client RoleAssignmentsClient) CreatePreparer(ctx context.Context, scope, name string, properties RoleAssignmentProperties) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"roleAssignmentName": autorest.Encode("path", name),
+		"scope":              strings.TrimPrefix(scope, "/"),
+	}
+
+	queryParameters := map[string]interface{}{
+		"api-version": roleAssignmentsAPIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType("application/json; charset=utf-8"),
+		autorest.AsPut(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/{scope}/providers/Microsoft.Authorization/roleAssignments/{roleAssignmentName}", pathParameters),
+		autorest.WithJSON(roleAssignmentCreate{Properties: properties}),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// CreateResponder handles the response to the Create request. The method always
+// closes the http.Response Body.
+func (client RoleAssignmentsClient) CreateResponder(resp *http.Response) (result RoleAssignment, err error) {
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusCreated),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	return
+}
+
+// Delete removes the role assignment.
+// Parameters:
+// roleAssignmentID - the ARM resource id of the role assignment.
+func (client RoleAssignmentsClient) Delete(ctx context.Context, roleAssignmentID string) (result autorest.Response, err error) {
+	req, err := client.DeletePreparer(ctx, roleAssignmentID)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Delete", nil, "Failure preparing request")
+		return
+	}
+
+	resp, err := client.Send(req, client.retrySender())
+	if err != nil {
+		result = autorest.Response{Response: resp}
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Delete", resp, "Failure sending request")
+		return
+	}
+
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusNoContent),
+		autorest.ByClosing())
+	result = autorest.Response{Response: resp}
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.RoleAssignmentsClient", "Delete", resp, "Failure responding to request")
+	}
+	return
+}
+
+// DeletePreparer prepares the Delete request.
+func (client RoleAssignmentsClient) DeletePreparer(ctx context.Context, roleAssignmentID string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"roleAssignmentId": strings.TrimPrefix(roleAssignmentID, "/"),
+	}
+
+	queryParameters := map[string]interface{}{
+		"api-version": roleAssignmentsAPIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsDelete(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/{roleAssignmentId}", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
@@ -90,7 +90,7 @@ func (client SchemaClient) GetByIDPreparer(ctx context.Context, ID string) (*htt
 // GetByIDSender sends the GetByID request. The method will close the
 // http.Response Body if it receives an error.
 func (client SchemaClient) GetByIDSender(req *http.Request) (*http.Response, error) {
-	return client.Send(req, autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	return client.Send(req, client.retrySender())
 }
 
 // GetByIDResponder handles the response to the GetByID request. The method always
@@ -177,7 +177,7 @@ func (client SchemaClient) GetVersionsPreparer(ctx context.Context, groupName st
 // GetVersionsSender sends the GetVersions request. The method will close the
 // http.Response Body if it receives an error.
 func (client SchemaClient) GetVersionsSender(req *http.Request) (*http.Response, error) {
-	return client.Send(req, autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	return client.Send(req, client.retrySender())
 }
 
 // GetVersionsResponder handles the response to the GetVersions request. The method always
@@ -269,7 +269,7 @@ func (client SchemaClient) QueryIDByContentPreparer(ctx context.Context, groupNa
 // QueryIDByContentSender sends the QueryIDByContent request. The method will close the
 // http.Response Body if it receives an error.
 func (client SchemaClient) QueryIDByContentSender(req *http.Request) (*http.Response, error) {
-	return client.Send(req, autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	return client.Send(req, client.retrySender())
 }
 
 // QueryIDByContentResponder handles the response to the QueryIDByContent request. The method always
@@ -359,7 +359,7 @@ func (client SchemaClient) RegisterPreparer(ctx context.Context, groupName strin
 // RegisterSender sends the Register request. The method will close the
 // http.Response Body if it receives an error.
 func (client SchemaClient) RegisterSender(req *http.Request) (*http.Response, error) {
-	return client.Send(req, autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	return client.Send(req, client.retrySender())
 }
 
 // RegisterResponder handles the response to the Register request. The method always
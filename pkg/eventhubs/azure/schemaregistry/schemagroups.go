@@ -9,6 +9,7 @@ package schemaregistry
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure"
@@ -82,7 +83,7 @@ func (client SchemaGroupsClient) ListPreparer(ctx context.Context) (*http.Reques
 // ListSender sends the List request. The method will close the
 // http.Response Body if it receives an error.
 func (client SchemaGroupsClient) ListSender(req *http.Request) (*http.Response, error) {
-	return client.Send(req, autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	return client.Send(req, client.retrySender())
 }
 
 // ListResponder handles the response to the List request. The method always
@@ -96,3 +97,89 @@ func (client SchemaGroupsClient) ListResponder(resp *http.Response) (result Sche
 	result.Response = autorest.Response{Response: resp}
 	return
 }
+
+// ListWithPrefix gets the list of schema groups user is authorized to access whose name starts with the prefix.
+// Servers that reject the groupNamePrefix query parameter are answered by filtering the full List.
+// Parameters:
+// prefix - schema group name prefix, an empty prefix lists all the schema groups.
+func (client SchemaGroupsClient) ListWithPrefix(ctx context.Context, prefix string) (result SchemaGroups, err error) {
+	if prefix == "" {
+		return client.List(ctx)
+	}
+	if tracing.IsEnabled() {
+		ctx = tracing.StartSpan(ctx, fqdn+"/SchemaGroupsClient.ListWithPrefix")
+		defer func() {
+			sc := -1
+			if result.Response.Response != nil {
+				sc = result.Response.Response.StatusCode
+			}
+			tracing.EndSpan(ctx, sc, err)
+		}()
+	}
+	req, err := client.ListWithPrefixPreparer(ctx, prefix)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaGroupsClient", "ListWithPrefix", nil, "Failure preparing request")
+		return
+	}
+
+	resp, err := client.ListSender(req)
+	if err != nil {
+		result.Response = autorest.Response{Response: resp}
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaGroupsClient", "ListWithPrefix", resp, "Failure sending request")
+		return
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		// the query parameter is not supported - filter the full list instead.
+		_ = autorest.Respond(resp, autorest.ByDiscardingBody(), autorest.ByClosing())
+		result, err = client.List(ctx)
+		if err != nil {
+			return
+		}
+		return filterSchemaGroups(result, prefix), nil
+	}
+
+	result, err = client.ListResponder(resp)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaGroupsClient", "ListWithPrefix", resp, "Failure responding to request")
+		return
+	}
+
+	// servers ignoring the query parameter return all the schema groups.
+	return filterSchemaGroups(result, prefix), nil
+}
+
+// ListWithPrefixPreparer prepares the ListWithPrefix request.
+func (client SchemaGroupsClient) ListWithPrefixPreparer(ctx context.Context, prefix string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	const APIVersion = "2021-10"
+	queryParameters := map[string]interface{}{
+		"api-version":     APIVersion,
+		"groupNamePrefix": autorest.Encode("query", prefix),
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPath("/$schemaGroups"),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// filterSchemaGroups keeps the schema groups whose name starts with the prefix.
+func filterSchemaGroups(groups SchemaGroups, prefix string) SchemaGroups {
+	if groups.SchemaGroups == nil {
+		return groups
+	}
+	filtered := []string{}
+	for _, group := range *groups.SchemaGroups {
+		if strings.HasPrefix(group, prefix) {
+			filtered = append(filtered, group)
+		}
+	}
+	groups.SchemaGroups = &filtered
+	return groups
+}
@@ -15,6 +15,7 @@ package schemaregistryapi
 //         // SchemaGroupsClientAPI contains the set of methods on the SchemaGroupsClient type.
 //         type SchemaGroupsClientAPI interface {
 //             List(ctx context.Context) (result schemaregistry.SchemaGroups, err error)
+//             ListWithPrefix(ctx context.Context, prefix string) (result schemaregistry.SchemaGroups, err error)
 //         }
 
 //         var _ SchemaGroupsClientAPI = (*schemaregistry.SchemaGroupsClient)(nil)
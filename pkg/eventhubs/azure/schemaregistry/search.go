@@ -0,0 +1,219 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// SchemaFormat is the serialization format of a schema: `AvroFormat`, `JSONFormat` or `ProtobufFormat`.
+type SchemaFormat = string
+
+// SchemaSearchResult is the latest version of a schema with fields matching a search.
+type SchemaSearchResult struct {
+	SchemaName string
+	Version    int32
+	Format     SchemaFormat
+	// MatchingFields are the dotted paths of the matching fields, e.g. `customer.email` or `Order.customer` for Protobuf.
+	MatchingFields []string
+}
+
+// SearchOption configures a `SearchSchemas` search.
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	format SchemaFormat
+}
+
+// WithSearchFormat restricts the search to the schemas of the `format`.
+func WithSearchFormat(format SchemaFormat) SearchOption {
+	return func(o *searchOptions) {
+		o.format = format
+	}
+}
+
+// SearchSchemas searches the latest version of every schema in the group for the fields whose name contains
+// the `query`, ignoring the case. Schemas without matching fields, or whose content can't be parsed, are left out.
+// The results are ordered by the schema name.
+func (client SchemaClient) SearchSchemas(ctx context.Context, groupName, query string, opts ...SearchOption) ([]SchemaSearchResult, error) {
+	options := searchOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	names, err := client.ListSchemas(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	query = strings.ToLower(query)
+	results := []SchemaSearchResult{}
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		latest, found, err := client.latestSchema(ctx, groupName, name)
+		if err != nil {
+			return nil, err
+		}
+		if !found || (options.format != "" && !strings.EqualFold(latest.Format, options.format)) {
+			continue
+		}
+		fields, err := SchemaFields(latest.Format, latest.Content)
+		if err != nil {
+			continue
+		}
+		matching := []string{}
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field[strings.LastIndex(field, ".")+1:]), query) {
+				matching = append(matching, field)
+			}
+		}
+		if len(matching) > 0 {
+			results = append(results, SchemaSearchResult{SchemaName: name, Version: latest.Version, Format: latest.Format, MatchingFields: matching})
+		}
+	}
+	return results, nil
+}
+
+// latestSchema gets the latest version of the schema, false when the schema does not exist.
+func (client SchemaClient) latestSchema(ctx context.Context, groupName, schemaName string) (ExportedSchema, bool, error) {
+	versions, err := client.GetVersions(ctx, groupName, schemaName)
+	var detailed autorest.DetailedError
+	if errors.As(err, &detailed) && detailed.StatusCode == http.StatusNotFound {
+		return ExportedSchema{}, false, nil
+	}
+	if err != nil {
+		return ExportedSchema{}, false, err
+	}
+	list := versions.List()
+	if len(list) == 0 {
+		return ExportedSchema{}, false, nil
+	}
+	latest := list[0]
+	for _, version := range list {
+		if version > latest {
+			latest = version
+		}
+	}
+	schema, err := client.GetExportedSchema(ctx, groupName, schemaName, latest)
+	return schema, err == nil, err
+}
+
+// SchemaFields returns the dotted paths of the fields of the schema `content` in the `format`, in declaration order.
+func SchemaFields(format SchemaFormat, content string) ([]string, error) {
+	switch {
+	case strings.EqualFold(format, ProtobufFormat):
+		tokens, err := tokenizeProto(content)
+		if err != nil {
+			return nil, err
+		}
+		p := &protoParser{tokens: tokens, declared: map[string]bool{}}
+		if err := p.parseFile(); err != nil {
+			return nil, err
+		}
+		return p.fields, nil
+	case strings.EqualFold(format, AvroFormat), strings.EqualFold(format, JSONFormat):
+		var schema interface{}
+		if err := json.Unmarshal([]byte(content), &schema); err != nil {
+			return nil, fmt.Errorf("invalid %s schema: %w", format, err)
+		}
+		fields := []string{}
+		if strings.EqualFold(format, AvroFormat) {
+			avroFields(schema, "", map[string]bool{}, &fields)
+		} else {
+			jsonSchemaFields(schema, "", &fields)
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema format %q", format)
+	}
+}
+
+// avroFields appends the fields of the records in the Avro `schema` under `path`, each named record is walked once.
+func avroFields(schema interface{}, path string, seen map[string]bool, fields *[]string) {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, member := range s {
+			avroFields(member, path, seen, fields)
+		}
+	case map[string]interface{}:
+		if name, ok := s["name"].(string); ok && s["fields"] != nil {
+			if seen[name] {
+				return
+			}
+			seen[name] = true
+		}
+		for _, key := range []string{"type", "items", "values"} {
+			if nested, ok := s[key].(map[string]interface{}); ok {
+				avroFields(nested, path, seen, fields)
+			} else if union, ok := s[key].([]interface{}); ok {
+				avroFields(union, path, seen, fields)
+			}
+		}
+		recordFields, _ := s["fields"].([]interface{})
+		for _, field := range recordFields {
+			f, ok := field.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := f["name"].(string)
+			fieldPath := joinFieldPath(path, name)
+			*fields = append(*fields, fieldPath)
+			avroFields(f["type"], fieldPath, seen, fields)
+		}
+	}
+}
+
+// jsonSchemaFields appends the properties of the JSON `schema` under `path`, including the nested and combined schemas.
+func jsonSchemaFields(schema interface{}, path string, fields *[]string) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldPath := joinFieldPath(path, name)
+			*fields = append(*fields, fieldPath)
+			jsonSchemaFields(properties[name], fieldPath, fields)
+		}
+	}
+	jsonSchemaFields(s["items"], path, fields)
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		combined, _ := s[key].([]interface{})
+		for _, member := range combined {
+			jsonSchemaFields(member, path, fields)
+		}
+	}
+	for _, key := range []string{"definitions", "$defs"} {
+		definitions, _ := s[key].(map[string]interface{})
+		names := make([]string, 0, len(definitions))
+		for name := range definitions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			jsonSchemaFields(definitions[name], name, fields)
+		}
+	}
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
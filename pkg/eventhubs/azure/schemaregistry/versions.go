@@ -0,0 +1,63 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// DeleteSchemaVersion deletes a version of the schema, deleting a missing version succeeds.
+func (client SchemaClient) DeleteSchemaVersion(ctx context.Context, groupName, schemaName string, version int32) (result autorest.Response, err error) {
+	req, err := client.DeleteSchemaVersionPreparer(ctx, groupName, schemaName, version)
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "DeleteSchemaVersion", nil, "Failure preparing request")
+		return
+	}
+
+	resp, err := client.GetVersionsSender(req)
+	if err != nil {
+		result = autorest.Response{Response: resp}
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "DeleteSchemaVersion", resp, "Failure sending request")
+		return
+	}
+
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusNoContent, http.StatusNotFound),
+		autorest.ByClosing())
+	result = autorest.Response{Response: resp}
+	if err != nil {
+		err = autorest.NewErrorWithError(err, "schemaregistry.SchemaClient", "DeleteSchemaVersion", resp, "Failure responding to request")
+	}
+	return
+}
+
+// DeleteSchemaVersionPreparer prepares the DeleteSchemaVersion request.
+func (client SchemaClient) DeleteSchemaVersionPreparer(ctx context.Context, groupName, schemaName string, version int32) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": client.Endpoint,
+	}
+
+	pathParameters := map[string]interface{}{
+		"groupName":     autorest.Encode("path", groupName),
+		"schemaName":    autorest.Encode("path", schemaName),
+		"schemaVersion": autorest.Encode("path", version),
+	}
+
+	const APIVersion = "2022-10"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsDelete(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPathParameters("/$schemaGroups/{groupName}/schemas/{schemaName}/versions/{schemaVersion}", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
@@ -0,0 +1,149 @@
+package schemaregistry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+const (
+	// registryScope is the scope of the schema registry access tokens.
+	registryScope = "https://eventhubs.azure.net/.default"
+	// defaultAuthorityHost is used when `AZURE_AUTHORITY_HOST` is not set.
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+	// tokenRefreshMargin is the time before expiry when the access token is refreshed.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// WithWorkloadIdentityAuth authenticates the client with Workload Identity federated credentials:
+// the projected service account token in `tokenFile` is exchanged for an Azure AD access token of the `clientID` application.
+// The authority defaults to the public cloud and can be changed with the `AZURE_AUTHORITY_HOST` environment variable.
+func WithWorkloadIdentityAuth(tenantID, clientID, tokenFile string) Option {
+	return func(c *BaseClient) {
+		c.Authorizer = &workloadIdentityAuthorizer{
+			tenantID:  tenantID,
+			clientID:  clientID,
+			tokenFile: tokenFile,
+			client:    &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+}
+
+// workloadIdentityAuthorizer sets the `Bearer` token exchanged for the federated service account token.
+type workloadIdentityAuthorizer struct {
+	tenantID  string
+	clientID  string
+	tokenFile string
+	client    *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// tokenRefreshError implements `adal.TokenRefreshError` for the failed token exchanges.
+type tokenRefreshError struct {
+	err error
+}
+
+func (e tokenRefreshError) Error() string {
+	return e.err.Error()
+}
+
+func (e tokenRefreshError) Unwrap() error {
+	return e.err
+}
+
+// Response is nil - the token endpoint response is closed once the exchange fails.
+func (e tokenRefreshError) Response() *http.Response {
+	return nil
+}
+
+// tokenResponse is the Azure AD token endpoint response.
+type tokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresIn   json.Number `json:"expires_in"`
+}
+
+// WithAuthorization returns a PrepareDecorator that adds the `Authorization` header with the access token.
+func (a *workloadIdentityAuthorizer) WithAuthorization() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil {
+				return r, err
+			}
+			token, err := a.accessToken(r.Context())
+			if err != nil {
+				// a token refresh error is not retried by the autorest senders.
+				return r, autorest.NewErrorWithError(tokenRefreshError{err: err}, "schemaregistry.workloadIdentityAuthorizer", "WithAuthorization", nil, "Failed to acquire the access token")
+			}
+			return autorest.Prepare(r, autorest.WithBearerAuthorization(token))
+		})
+	}
+}
+
+// accessToken returns the cached access token, exchanging a new one when it expires within the `tokenRefreshMargin`.
+func (a *workloadIdentityAuthorizer) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Add(tokenRefreshMargin).Before(a.expires) {
+		return a.token, nil
+	}
+	// the service account token is rotated by the kubelet, so it is read on every exchange.
+	assertion, err := os.ReadFile(a.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed reading the service account token: %w", err)
+	}
+	form := url.Values{
+		"client_id":             {a.clientID},
+		"scope":                 {registryScope},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenEndpoint(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed exchanging the service account token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed exchanging the service account token: %s", resp.Status)
+	}
+	token := tokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed parsing the token response: %w", err)
+	}
+	expiresIn, err := token.ExpiresIn.Int64()
+	if err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid token response")
+	}
+	a.token = token.AccessToken
+	a.expires = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return a.token, nil
+}
+
+// tokenEndpoint returns the Azure AD v2 token endpoint of the tenant.
+func (a *workloadIdentityAuthorizer) tokenEndpoint() string {
+	host := os.Getenv("AZURE_AUTHORITY_HOST")
+	if host == "" {
+		host = defaultAuthorityHost
+	}
+	return fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(host, "/"), url.PathEscape(a.tenantID))
+}
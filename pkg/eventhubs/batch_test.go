@@ -0,0 +1,153 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("RegisterSchemaBatch", func() {
+	var server *httptest.Server
+	var inFlight, maxInFlight int32
+	// onRequest runs on every registration request with the schema name.
+	var onRequest func(name string)
+
+	BeforeEach(func() {
+		inFlight, maxInFlight = 0, 0
+		onRequest = func(string) {}
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			name := path.Base(r.URL.Path)
+			onRequest(name)
+			if strings.HasPrefix(name, "bad") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Schema-Id", "id-"+name)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(opts ...schemaregistry.Option) schemaregistry.SchemaClient {
+		client := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"), opts...)
+		client.Sender = server.Client()
+		return client
+	}
+	schemaDefs := func(names ...string) []schemaregistry.SchemaDef {
+		defs := make([]schemaregistry.SchemaDef, 0, len(names))
+		for _, name := range names {
+			defs = append(defs, schemaregistry.SchemaDef{
+				Name:    name,
+				Format:  schemaregistry.AvroFormat,
+				Content: fmt.Sprintf(`{"type":"record","name":"%s","fields":[{"name":"id","type":"string"}]}`, name),
+			})
+		}
+		return defs
+	}
+
+	It("should register the schemas in order", func() {
+		names := []string{}
+		for i := 0; i < 12; i++ {
+			names = append(names, fmt.Sprintf("schema%d", i))
+		}
+		ids, err := newClient(schemaregistry.WithBatchConcurrency(3)).RegisterSchemaBatch(context.Background(), "orders", schemaDefs(names...))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(len(names)))
+		for i, id := range ids {
+			Expect(*id.ID).To(Equal("id-" + names[i]))
+		}
+		Expect(atomic.LoadInt32(&maxInFlight)).To(BeNumerically("<=", 3))
+		Expect(atomic.LoadInt32(&maxInFlight)).To(BeNumerically(">", 1))
+	})
+	It("should limit the concurrency to 5 by default", func() {
+		names := []string{}
+		for i := 0; i < 15; i++ {
+			names = append(names, fmt.Sprintf("schema%d", i))
+		}
+		_, err := newClient().RegisterSchemaBatch(context.Background(), "orders", schemaDefs(names...))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&maxInFlight)).To(BeNumerically("<=", schemaregistry.DefaultBatchConcurrency))
+	})
+	It("should report the failed schemas", func() {
+		defs := schemaDefs("first", "bad1", "second", "bad2")
+		defs = append(defs, schemaregistry.SchemaDef{Name: "invalid", Format: schemaregistry.AvroFormat, Content: `{"type":"record"}`})
+		ids, err := newClient().RegisterSchemaBatch(context.Background(), "orders", defs)
+		Expect(ids).To(HaveLen(5))
+		Expect(*ids[0].ID).To(Equal("id-first"))
+		Expect(ids[1]).To(Equal(schemaregistry.SchemaID{}))
+		Expect(*ids[2].ID).To(Equal("id-second"))
+		Expect(ids[3]).To(Equal(schemaregistry.SchemaID{}))
+		Expect(ids[4]).To(Equal(schemaregistry.SchemaID{}))
+
+		var batchErr schemaregistry.ErrSchemaBatch
+		Expect(errors.As(err, &batchErr)).To(BeTrue())
+		Expect(batchErr).To(HaveLen(3))
+		Expect(batchErr[0].Index).To(Equal(1))
+		Expect(batchErr[1].Index).To(Equal(3))
+		Expect(batchErr[2].Name).To(Equal("invalid"))
+		Expect(errors.As(batchErr[2], new(schemaregistry.ErrInvalidAvroSchema))).To(BeTrue())
+	})
+	It("should return the results of all the schemas", func() {
+		results, err := newClient(schemaregistry.WithBatchConcurrency(2)).RegisterSchemasBatch(context.Background(), "orders", schemaDefs("first", "second", "third"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		for i, name := range []string{"first", "second", "third"} {
+			Expect(results[i].Err).NotTo(HaveOccurred())
+			Expect(*results[i].SchemaID.ID).To(Equal("id-" + name))
+		}
+	})
+	It("should keep the registered schemas on partial failures", func() {
+		results, err := newClient().RegisterSchemasBatch(context.Background(), "orders", schemaDefs("first", "bad1", "second"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		Expect(*results[0].SchemaID.ID).To(Equal("id-first"))
+		Expect(results[1].Err).To(HaveOccurred())
+		Expect(results[1].SchemaID).To(Equal(schemaregistry.SchemaID{}))
+		Expect(*results[2].SchemaID.ID).To(Equal("id-second"))
+	})
+	It("should stop registering once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		onRequest = func(name string) {
+			if name == "schema2" {
+				cancel()
+			}
+		}
+		names := []string{}
+		for i := 0; i < 6; i++ {
+			names = append(names, fmt.Sprintf("schema%d", i))
+		}
+		results, err := newClient(schemaregistry.WithBatchConcurrency(1)).RegisterSchemasBatch(ctx, "orders", schemaDefs(names...))
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(results).To(HaveLen(6))
+		Expect(*results[0].SchemaID.ID).To(Equal("id-schema0"))
+		Expect(*results[1].SchemaID.ID).To(Equal("id-schema1"))
+		for _, result := range results[3:] {
+			Expect(result.Err).To(MatchError(context.Canceled))
+		}
+	})
+})
@@ -0,0 +1,96 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Schema compatibility", func() {
+	It("should follow the Avro resolution rules", func() {
+		compatible, err := schemaregistry.BackwardCompatible(
+			`{"type":"record","name":"Order","fields":[{"name":"amount","type":"long"}]}`,
+			`{"type":"record","name":"Order","fields":[{"name":"amount","type":"int"}]}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compatible).To(BeTrue())
+
+		compatible, err = schemaregistry.BackwardCompatible(
+			`{"type":"record","name":"Order","fields":[{"name":"amount","type":"int"}]}`,
+			`{"type":"record","name":"Order","fields":[{"name":"amount","type":"long"}]}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compatible).To(BeFalse())
+
+		compatible, err = schemaregistry.BackwardCompatible(
+			`{"type":"enum","name":"Status","symbols":["NEW"]}`,
+			`{"type":"enum","name":"Status","symbols":["NEW","DONE"]}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compatible).To(BeFalse())
+	})
+
+	Context("with a registry", func() {
+		var server *httptest.Server
+		var versions map[string]string
+
+		BeforeEach(func() {
+			versions = map[string]string{
+				"1": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`,
+				"2": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"amount","type":"double","default":0}]}`,
+				"3": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"amount","type":"double"}]}`,
+			}
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/versions") {
+					list := []int{}
+					for i := len(versions); i > 0; i-- {
+						list = append(list, i)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					Expect(json.NewEncoder(w).Encode(map[string][]int{"schemaVersions": list})).To(Succeed())
+					return
+				}
+				content, ok := versions[path.Base(r.URL.Path)]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(content))
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		newClient := func() schemaregistry.SchemaClient {
+			client := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+			client.Sender = server.Client()
+			return client
+		}
+
+		It("should check every ordered pair of versions", func() {
+			matrix, err := newClient().CompatibilityMatrix(context.Background(), "orders", "Order")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matrix).To(Equal([]schemaregistry.VersionCompatibility{
+				{FromVersion: 1, ToVersion: 2, Compatible: true},
+				{FromVersion: 1, ToVersion: 3, Compatible: false},
+				{FromVersion: 2, ToVersion: 3, Compatible: true},
+			}))
+		})
+		It("should refuse schemas with too many versions", func() {
+			for i := 4; i <= schemaregistry.MaxCompatibilityVersions+1; i++ {
+				versions[strconv.Itoa(i)] = versions["1"]
+			}
+			_, err := newClient().CompatibilityMatrix(context.Background(), "orders", "Order")
+			Expect(errors.Is(err, schemaregistry.ErrTooManyVersions)).To(BeTrue())
+		})
+	})
+})
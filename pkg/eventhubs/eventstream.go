@@ -0,0 +1,148 @@
+package eventhubs
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// sasTokenTTL is the time the shared access signature of an event is valid.
+const sasTokenTTL = time.Hour
+
+// SchemaChangeEvent is published to the event stream once a schema was applied to the databases of a cluster.
+type SchemaChangeEvent struct {
+	ClusterURI string   `json:"clusterURI"`
+	Databases  []string `json:"databases"`
+	// Diff is the summary of the applied delta, nil when it wasn't recorded.
+	Diff *schemav1alpha1.SchemaDiffSummary `json:"diff,omitempty"`
+	// Checksum is the checksum of the applied kql.
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"appliedAt"`
+	// AppliedBy is the `<namespace>/<name>` of the cluster executer that applied the schema.
+	AppliedBy string `json:"appliedBy"`
+}
+
+// EventProducer sends an event to an Event Hub. It is the seam for an `azeventhubs.ProducerClient` backed producer,
+// which sends a single event batch per call.
+type EventProducer interface {
+	SendEvent(ctx context.Context, body []byte) error
+}
+
+// SchemaEventStream publishes the schema changes to an Event Hub, for audit systems and monitoring pipelines.
+type SchemaEventStream struct {
+	producer EventProducer
+}
+
+// NewSchemaEventStream returns an event stream sending the schema changes with `producer`.
+func NewSchemaEventStream(producer EventProducer) *SchemaEventStream {
+	return &SchemaEventStream{producer: producer}
+}
+
+// NewSchemaEventStreamFromConnectionString returns an event stream sending the schema changes to the Event Hub of
+// the `connectionString`, which must include its `EntityPath`. A nil `httpClient` uses `http.DefaultClient`.
+func NewSchemaEventStreamFromConnectionString(connectionString string, httpClient *http.Client) (*SchemaEventStream, error) {
+	producer, err := NewConnectionStringProducer(connectionString, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchemaEventStream(producer), nil
+}
+
+// Publish sends the json of `event` to the Event Hub.
+func (s *SchemaEventStream) Publish(ctx context.Context, event SchemaChangeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	log.Debug().Msgf("publishing the schema change of %s", event.ClusterURI)
+	return s.producer.SendEvent(ctx, body)
+}
+
+// ConnectionStringProducer sends events with the Event Hubs REST API, authorized by the shared access key of a connection string.
+// TODO: replace it with a producer wrapping `azeventhubs.ProducerClient` once the `sdk/messaging/azeventhubs` module (and its
+// AMQP dependency) is added to go.mod - the REST API has no batching, no AAD authentication and no AMQP retries.
+type ConnectionStringProducer struct {
+	// URL is the `https://<namespace>/<event hub>` resource the events are sent to.
+	URL     string
+	KeyName string
+	Key     string
+
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewConnectionStringProducer parses an Event Hub `connectionString`, e.g.
+// `Endpoint=sb://<namespace>.servicebus.windows.net/;SharedAccessKeyName=<name>;SharedAccessKey=<key>;EntityPath=<event hub>`.
+func NewConnectionStringProducer(connectionString string, httpClient *http.Client) (*ConnectionStringProducer, error) {
+	properties := map[string]string{}
+	for _, part := range strings.Split(connectionString, ";") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			properties[strings.ToLower(name)] = value
+		}
+	}
+	endpoint, err := url.Parse(properties["endpoint"])
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid event hub connection string: no endpoint")
+	}
+	for _, name := range []string{"sharedaccesskeyname", "sharedaccesskey", "entitypath"} {
+		if properties[name] == "" {
+			return nil, fmt.Errorf("invalid event hub connection string: no %s", name)
+		}
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &ConnectionStringProducer{
+		URL:        "https://" + endpoint.Host + "/" + properties["entitypath"],
+		KeyName:    properties["sharedaccesskeyname"],
+		Key:        properties["sharedaccesskey"],
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// SendEvent posts `body` as a single event.
+func (p *ConnectionStringProducer) SendEvent(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL+"/messages?timeout=60&api-version=2014-01", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+	req.Header.Set("Authorization", p.sasToken())
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed sending the event to %s", p.URL)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sending the event to %s failed with status %d: %s", p.URL, resp.StatusCode, string(msg))
+	}
+	return nil
+}
+
+// sasToken returns the shared access signature of the event hub, valid for the `sasTokenTTL`.
+func (p *ConnectionStringProducer) sasToken() string {
+	resource := url.QueryEscape(p.URL)
+	expiry := strconv.FormatInt(p.now().Add(sasTokenTTL).Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(p.Key))
+	mac.Write([]byte(resource + "\n" + expiry))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s", resource, url.QueryEscape(signature), expiry, p.KeyName)
+}
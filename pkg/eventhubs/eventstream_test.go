@@ -0,0 +1,93 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+)
+
+// capturingProducer captures the sent events.
+type capturingProducer struct {
+	events [][]byte
+}
+
+func (p *capturingProducer) SendEvent(ctx context.Context, body []byte) error {
+	p.events = append(p.events, body)
+	return nil
+}
+
+var _ = Describe("Schema event stream", func() {
+	ctx := context.Background()
+	const connectionString = "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0;EntityPath=hub"
+	event := eventhubs.SchemaChangeEvent{
+		ClusterURI: "https://cluster1.westeurope.kusto.windows.net",
+		Databases:  []string{"db1", "db2"},
+		Diff:       &schemav1alpha1.SchemaDiffSummary{TablesAdded: 1},
+		Checksum:   "abc123",
+		AppliedAt:  time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC),
+		AppliedBy:  "default/schema-0-cluster1",
+	}
+
+	It("should publish the json of the schema change", func() {
+		producer := &capturingProducer{}
+		Expect(eventhubs.NewSchemaEventStream(producer).Publish(ctx, event)).To(Succeed())
+		Expect(producer.events).To(HaveLen(1))
+
+		payload := map[string]interface{}{}
+		Expect(json.Unmarshal(producer.events[0], &payload)).To(Succeed())
+		Expect(payload).To(HaveKeyWithValue("clusterURI", event.ClusterURI))
+		Expect(payload).To(HaveKeyWithValue("databases", []interface{}{"db1", "db2"}))
+		Expect(payload).To(HaveKeyWithValue("checksum", "abc123"))
+		Expect(payload).To(HaveKeyWithValue("appliedAt", "2022-05-01T10:00:00Z"))
+		Expect(payload).To(HaveKeyWithValue("appliedBy", "default/schema-0-cluster1"))
+		Expect(payload).To(HaveKey("diff"))
+	})
+	It("should send the event with the shared access signature of the connection string", func() {
+		var received []byte
+		var auth, requestPath string
+		status := http.StatusCreated
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestPath = r.URL.Path
+			auth = r.Header.Get("Authorization")
+			received, _ = io.ReadAll(r.Body)
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+
+		producer, err := eventhubs.NewConnectionStringProducer(connectionString, server.Client())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(producer.URL).To(Equal("https://ns.servicebus.windows.net/hub"))
+		Expect(producer.KeyName).To(Equal("send"))
+		producer.URL = server.URL + "/hub"
+
+		Expect(eventhubs.NewSchemaEventStream(producer).Publish(ctx, event)).To(Succeed())
+		Expect(requestPath).To(Equal("/hub/messages"))
+		Expect(auth).To(MatchRegexp(`^SharedAccessSignature sr=[^&]+&sig=[^&]+&se=\d+&skn=send$`))
+		Expect(received).To(ContainSubstring(`"checksum":"abc123"`))
+
+		status = http.StatusUnauthorized
+		Expect(producer.SendEvent(ctx, received)).NotTo(Succeed())
+	})
+	It("should reject incomplete connection strings", func() {
+		for _, invalid := range []string{
+			"",
+			"SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0;EntityPath=hub",
+			"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKey=c2VjcmV0;EntityPath=hub",
+			"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0",
+		} {
+			_, err := eventhubs.NewSchemaEventStreamFromConnectionString(invalid, nil)
+			Expect(err).To(HaveOccurred(), invalid)
+		}
+	})
+})
@@ -0,0 +1,151 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+type registeredVersion struct {
+	contentType string
+	content     string
+}
+
+// memoryRegistry mocks the schema registry of one group.
+type memoryRegistry struct {
+	sync.Mutex
+	schemas       map[string][]registeredVersion
+	registrations int
+	// brokenVersions fails the requests of the schema versions content.
+	brokenVersions bool
+}
+
+func (m *memoryRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/$schemaGroups/orders/schemas"), "/")
+	switch {
+	case r.Method == http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		Expect(err).NotTo(HaveOccurred())
+		name := parts[1]
+		m.schemas[name] = append(m.schemas[name], registeredVersion{contentType: r.Header.Get("Content-Type"), content: string(body)})
+		m.registrations++
+		w.Header().Set("Schema-Id", fmt.Sprintf("%s-%d", name, len(m.schemas[name])))
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 1:
+		names := []string{}
+		for name := range m.schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Header().Set("Content-Type", "application/json")
+		Expect(json.NewEncoder(w).Encode(map[string][]string{"value": names})).To(Succeed())
+	case len(parts) == 3:
+		versions, ok := m.schemas[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		list := []int{}
+		for i := range versions {
+			list = append(list, i+1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		Expect(json.NewEncoder(w).Encode(map[string][]int{"schemaVersions": list})).To(Succeed())
+	case m.brokenVersions:
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		version, _ := strconv.Atoi(parts[3])
+		versions := m.schemas[parts[1]]
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", strings.ReplaceAll(versions[version-1].contentType, "; charset=utf-8", ""))
+		_, _ = w.Write([]byte(versions[version-1].content))
+	}
+}
+
+var _ = Describe("Schema registry export", func() {
+	var source, target *memoryRegistry
+	var sourceServer, targetServer *httptest.Server
+
+	BeforeEach(func() {
+		source = &memoryRegistry{schemas: map[string][]registeredVersion{}}
+		target = &memoryRegistry{schemas: map[string][]registeredVersion{}}
+		sourceServer = httptest.NewTLSServer(source)
+		targetServer = httptest.NewTLSServer(target)
+	})
+	AfterEach(func() {
+		sourceServer.Close()
+		targetServer.Close()
+	})
+	newClient := func(server *httptest.Server) schemaregistry.SchemaClient {
+		client := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+		client.Sender = server.Client()
+		return client
+	}
+
+	It("should round trip all the versions of the group", func() {
+		ctx := context.Background()
+		client := newClient(sourceServer)
+		schemas := []schemaregistry.SchemaDef{
+			{Name: "Order", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`},
+			{Name: "Order", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"note","type":"string","default":""}]}`},
+			{Name: "Customer", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Customer","fields":[{"name":"name","type":"string"}]}`},
+			{Name: "Customer", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Customer","fields":[{"name":"name","type":"string"},{"name":"age","type":"int","default":0}]}`},
+			{Name: "Invoice", Format: schemaregistry.ProtobufFormat, Content: "syntax = \"proto3\";\nmessage Invoice {\n  string id = 1;\n}\n"},
+			{Name: "Invoice", Format: schemaregistry.ProtobufFormat, Content: "syntax = \"proto3\";\nmessage Invoice {\n  string id = 1;\n  double total = 2;\n}\n"},
+		}
+		for _, schema := range schemas {
+			_, err := client.RegisterSchema(ctx, "orders", schema.Name, schema.Format, schema.Content)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		export, err := client.ExportSchemaRegistry(ctx, "orders")
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(export)
+		Expect(err).NotTo(HaveOccurred())
+		exported := []schemaregistry.ExportedSchema{}
+		Expect(json.Unmarshal(data, &exported)).To(Succeed())
+		Expect(exported).To(HaveLen(6))
+		for _, schema := range exported {
+			Expect(schemas).To(ContainElement(schemaregistry.SchemaDef{Name: schema.Name, Format: schema.Format, Content: schema.Content}))
+		}
+
+		By("importing into an empty registry")
+		Expect(newClient(targetServer).ImportSchemaRegistry(ctx, "orders", strings.NewReader(string(data)))).To(Succeed())
+		Expect(target.schemas).To(Equal(source.schemas))
+
+		reexport, err := newClient(targetServer).ExportSchemaRegistry(ctx, "orders")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(io.ReadAll(reexport)).To(MatchJSON(data))
+
+		By("skipping the registered versions")
+		Expect(newClient(targetServer).ImportSchemaRegistry(ctx, "orders", strings.NewReader(string(data)))).To(Succeed())
+		Expect(target.registrations).To(Equal(6))
+	})
+	It("should fail the export read when a version cannot be fetched", func() {
+		source.schemas["Order"] = []registeredVersion{{contentType: "application/json; serialization=Avro", content: `{"type":"string"}`}}
+		source.brokenVersions = true
+		export, err := newClient(sourceServer).ExportSchemaRegistry(context.Background(), "orders")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = io.ReadAll(export)
+		Expect(err).To(HaveOccurred())
+	})
+})
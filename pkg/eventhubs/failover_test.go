@@ -0,0 +1,99 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Fallback endpoint", func() {
+	var primary, fallback *httptest.Server
+	var primaryDown int32
+	var primaryHits, fallbackHits int32
+
+	newRegistry := func(hits *int32, down *int32) *httptest.Server {
+		return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(hits, 1)
+			if down != nil && atomic.LoadInt32(down) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"schemaGroups":["orders"]}`))
+		}))
+	}
+
+	BeforeEach(func() {
+		primaryDown, primaryHits, fallbackHits = 1, 0, 0
+		primary = newRegistry(&primaryHits, &primaryDown)
+		fallback = newRegistry(&fallbackHits, nil)
+	})
+	AfterEach(func() {
+		primary.Close()
+		fallback.Close()
+	})
+
+	newClient := func() schemaregistry.SchemaGroupsClient {
+		roots := x509.NewCertPool()
+		roots.AddCert(primary.Certificate())
+		roots.AddCert(fallback.Certificate())
+		return schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(primary.URL, "https://"),
+			schemaregistry.WithFallbackEndpoint(strings.TrimPrefix(fallback.URL, "https://")),
+			schemaregistry.WithPrimaryProbeInterval(100*time.Millisecond),
+			schemaregistry.WithTLSConfig(&tls.Config{RootCAs: roots}))
+	}
+
+	It("should fail over and switch back once the primary recovers", func() {
+		client := newClient()
+		Expect(client.ActiveEndpoint()).To(Equal(client.Endpoint))
+
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ActiveEndpoint()).To(Equal(strings.TrimPrefix(fallback.URL, "https://")))
+		Expect(atomic.LoadInt32(&primaryHits)).To(Equal(int32(1)))
+		Expect(atomic.LoadInt32(&fallbackHits)).To(Equal(int32(1)))
+
+		By("serving from the fallback until the probe interval passes")
+		_, err = client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&primaryHits)).To(Equal(int32(1)))
+		Expect(atomic.LoadInt32(&fallbackHits)).To(Equal(int32(2)))
+
+		By("switching back to the recovered primary")
+		atomic.StoreInt32(&primaryDown, 0)
+		time.Sleep(150 * time.Millisecond)
+		result, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal([]string{"orders"}))
+		Expect(client.ActiveEndpoint()).To(Equal(client.Endpoint))
+		Expect(atomic.LoadInt32(&primaryHits)).To(Equal(int32(2)))
+		Expect(atomic.LoadInt32(&fallbackHits)).To(Equal(int32(2)))
+	})
+	It("should fail over on network errors", func() {
+		client := newClient()
+		primary.Close()
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&fallbackHits)).To(Equal(int32(1)))
+	})
+	It("should keep the primary when it is healthy", func() {
+		atomic.StoreInt32(&primaryDown, 0)
+		client := newClient()
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ActiveEndpoint()).To(Equal(client.Endpoint))
+		Expect(atomic.LoadInt32(&fallbackHits)).To(BeZero())
+	})
+})
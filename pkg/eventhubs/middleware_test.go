@@ -0,0 +1,114 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Client middleware", func() {
+	var server *httptest.Server
+	var hits, failures int32
+
+	BeforeEach(func() {
+		hits, failures = 0, 0
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"schemaGroups":["orders"]}`))
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(mw ...schemaregistry.Middleware) schemaregistry.SchemaGroupsClient {
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+		return schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(server.URL, "https://"),
+			schemaregistry.WithTLSConfig(&tls.Config{RootCAs: roots}),
+			schemaregistry.WithMiddleware(mw...))
+	}
+
+	It("should run the middleware in the registration order", func() {
+		var mu sync.Mutex
+		calls := []string{}
+		recording := func(name string) schemaregistry.Middleware {
+			return func(next autorest.Sender) autorest.Sender {
+				return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+					mu.Lock()
+					calls = append(calls, name+" request")
+					mu.Unlock()
+					resp, err := next.Do(r)
+					mu.Lock()
+					calls = append(calls, name+" response")
+					mu.Unlock()
+					return resp, err
+				})
+			}
+		}
+		client := newClient(recording("first"), recording("second"), recording("third"))
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal([]string{
+			"first request", "second request", "third request",
+			"third response", "second response", "first response",
+		}))
+	})
+	It("should retry server errors", func() {
+		failures = 2
+		result, err := newClient(schemaregistry.RetryMiddleware(3)).List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal([]string{"orders"}))
+		Expect(atomic.LoadInt32(&hits)).To(Equal(int32(3)))
+	})
+	It("should only retry in the middleware with a single attempt client policy", func() {
+		failures = 5
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+		client := schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(server.URL, "https://"),
+			schemaregistry.WithTLSConfig(&tls.Config{RootCAs: roots}),
+			schemaregistry.WithRetryPolicy(1, 0, 0, 0),
+			schemaregistry.WithMiddleware(schemaregistry.RetryMiddleware(3)))
+		_, err := client.List(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(atomic.LoadInt32(&hits)).To(Equal(int32(3)))
+	})
+	It("should log the requests", func() {
+		var logs bytes.Buffer
+		_, err := newClient(schemaregistry.LoggingMiddleware(zerolog.New(&logs))).List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logs.String()).To(ContainSubstring(`"method":"GET"`))
+		Expect(logs.String()).To(ContainSubstring(`"status":200`))
+		Expect(logs.String()).To(ContainSubstring(server.URL))
+	})
+	It("should limit the request rate", func() {
+		client := newClient(schemaregistry.RateLimitMiddleware(10))
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.List(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 180*time.Millisecond))
+	})
+})
@@ -0,0 +1,64 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Namespace discovery", func() {
+	const (
+		subscriptionID = "00000000-0000-0000-0000-000000000003"
+		resourceGroup  = "schema-rg"
+	)
+	var server *httptest.Server
+	var paths []string
+
+	BeforeEach(func() {
+		paths = nil
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = w.Write([]byte(`{"value":[{"name":"legacy"}]}`))
+				return
+			}
+			fmt.Fprintf(w, `{"value":[
+				{"name":"orders","properties":{"serviceBusEndpoint":"https://orders.servicebus.windows.net:443/"}},
+				{"name":"payments","properties":{"serviceBusEndpoint":"https://payments.servicebus.chinacloudapi.cn:443/"}}
+			],"nextLink":"%s/next?page=2"}`, server.URL)
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should return the schema registry endpoints of the namespaces", func() {
+		client := schemaregistry.NewNamespacesClient(subscriptionID)
+		client.Endpoint = strings.TrimPrefix(server.URL, "https://")
+		client.Sender = server.Client()
+		endpoints, err := client.SchemaRegistryEndpoints(context.Background(), resourceGroup)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal([]string{
+			"orders.servicebus.windows.net",
+			"payments.servicebus.chinacloudapi.cn",
+			"legacy.servicebus.windows.net",
+		}))
+		Expect(paths).To(Equal([]string{
+			"/subscriptions/" + subscriptionID + "/resourceGroups/" + resourceGroup + "/providers/Microsoft.EventHub/namespaces",
+			"/next",
+		}))
+	})
+	It("should skip namespaces without a name or endpoint", func() {
+		Expect(schemaregistry.SchemaRegistryEndpoint(schemaregistry.Namespace{})).To(BeEmpty())
+	})
+})
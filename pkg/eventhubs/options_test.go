@@ -0,0 +1,195 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+// newClientCert returns a self signed PEM encoded client certificate and key.
+func newClientCert() ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "schemaop"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+var _ = Describe("Client options", func() {
+	Context("with a request timeout", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should cancel slow requests", func() {
+			client := schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(server.URL, "https://"),
+				schemaregistry.WithRequestTimeout(50*time.Millisecond))
+			Expect(client.RetryDuration).To(Equal(50 * time.Millisecond))
+			httpClient, ok := client.Sender.(*http.Client)
+			Expect(ok).To(BeTrue())
+			Expect(httpClient.Timeout).To(Equal(50 * time.Millisecond))
+			httpClient.Transport = server.Client().Transport
+
+			start := time.Now()
+			_, err := client.List(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Client.Timeout exceeded"))
+			Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		})
+		It("should not share the sender between clients", func() {
+			first := schemaregistry.NewSchemaClientWithOptions("first", schemaregistry.WithRequestTimeout(time.Second))
+			second := schemaregistry.NewSchemaClientWithOptions("second", schemaregistry.WithRequestTimeout(time.Minute))
+			Expect(first.Sender.(*http.Client).Timeout).To(Equal(time.Second))
+			Expect(second.Sender.(*http.Client).Timeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("with a TLS configuration", func() {
+		var server *httptest.Server
+		var caPool *x509.CertPool
+
+		newServer := func(clientAuth tls.ClientAuthType, clientCAs *x509.CertPool) {
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"schemaGroups":["orders"]}`))
+			}))
+			server.TLS = &tls.Config{ClientAuth: clientAuth, ClientCAs: clientCAs}
+			server.StartTLS()
+			caPool = x509.NewCertPool()
+			caPool.AddCert(server.Certificate())
+		}
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should trust the custom CA", func() {
+			newServer(tls.NoClientCert, nil)
+			endpoint := strings.TrimPrefix(server.URL, "https://")
+			_, err := schemaregistry.NewSchemaGroupsClientWithOptions(endpoint,
+				schemaregistry.WithRequestTimeout(10*time.Millisecond)).List(context.Background())
+			Expect(err).To(HaveOccurred())
+
+			client := schemaregistry.NewSchemaGroupsClientWithOptions(endpoint,
+				schemaregistry.WithRequestTimeout(time.Second),
+				schemaregistry.WithTLSConfig(&tls.Config{RootCAs: caPool}))
+			result, err := client.List(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*result.SchemaGroups).To(Equal([]string{"orders"}))
+			Expect(client.Sender.(*http.Client).Timeout).To(Equal(time.Second))
+		})
+		It("should authenticate with the client certificate", func() {
+			certPEM, keyPEM := newClientCert()
+			block, _ := pem.Decode(certPEM)
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			clientCAs := x509.NewCertPool()
+			clientCAs.AddCert(cert)
+			newServer(tls.RequireAndVerifyClientCert, clientCAs)
+			endpoint := strings.TrimPrefix(server.URL, "https://")
+
+			_, err = schemaregistry.NewSchemaGroupsClientWithOptions(endpoint,
+				schemaregistry.WithRequestTimeout(10*time.Millisecond),
+				schemaregistry.WithTLSConfig(&tls.Config{RootCAs: caPool})).List(context.Background())
+			Expect(err).To(HaveOccurred())
+
+			withCert, err := schemaregistry.WithClientCert(certPEM, keyPEM)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = schemaregistry.NewSchemaGroupsClientWithOptions(endpoint,
+				schemaregistry.WithTLSConfig(&tls.Config{RootCAs: caPool}), withCert).List(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should reject an invalid client certificate", func() {
+			certPEM, _ := newClientCert()
+			_, err := schemaregistry.WithClientCert(certPEM, []byte("not a key"))
+			Expect(err).To(MatchError(ContainSubstring("invalid client certificate")))
+		})
+	})
+
+	Context("with a connection pool", func() {
+		var server *httptest.Server
+		var connections int32
+
+		BeforeEach(func() {
+			connections = 0
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"schemaGroups":[]}`))
+			}))
+			server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&connections, 1)
+				}
+			}
+			server.StartTLS()
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should reuse the connections", func() {
+			roots := x509.NewCertPool()
+			roots.AddCert(server.Certificate())
+			client := schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(server.URL, "https://"),
+				schemaregistry.WithTLSConfig(&tls.Config{RootCAs: roots}),
+				schemaregistry.WithConnectionPool(10, 4, time.Minute),
+				schemaregistry.WithKeepAlive(time.Minute))
+			transport := client.Sender.(*http.Client).Transport.(*http.Transport)
+			Expect(transport.MaxIdleConns).To(Equal(10))
+			Expect(transport.MaxIdleConnsPerHost).To(Equal(4))
+			Expect(transport.IdleConnTimeout).To(Equal(time.Minute))
+			Expect(transport.TLSClientConfig.RootCAs).To(Equal(roots))
+
+			for i := 0; i < 5; i++ {
+				_, err := client.List(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(atomic.LoadInt32(&connections)).To(Equal(int32(1)))
+		})
+		It("should use the default pool settings", func() {
+			client := schemaregistry.NewSchemaGroupsClientWithOptions("registry", schemaregistry.WithConnectionPool(0, 0, 0))
+			transport := client.Sender.(*http.Client).Transport.(*http.Transport)
+			Expect(transport.MaxIdleConns).To(Equal(schemaregistry.DefaultMaxIdleConns))
+			Expect(transport.MaxIdleConnsPerHost).To(Equal(schemaregistry.DefaultMaxIdleConnsPerHost))
+			Expect(transport.IdleConnTimeout).To(Equal(schemaregistry.DefaultIdleConnTimeout))
+		})
+	})
+})
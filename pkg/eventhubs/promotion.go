@@ -0,0 +1,175 @@
+package eventhubs
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNonSequentialPromotion is returned when a promotion skips or reverts versions.
+type ErrNonSequentialPromotion struct {
+	From int
+	To   int
+}
+
+func (e ErrNonSequentialPromotion) Error() string {
+	return fmt.Sprintf("promoting version %d to %d is not sequential, the next version is %d", e.From, e.To, e.From+1)
+}
+
+// ErrPromotedVersionMismatch is returned when the promotion does not start from the currently promoted version.
+type ErrPromotedVersionMismatch struct {
+	Promoted int
+	From     int
+}
+
+func (e ErrPromotedVersionMismatch) Error() string {
+	return fmt.Sprintf("the promoted version is %d, not %d", e.Promoted, e.From)
+}
+
+// ErrIncompatiblePromotion is returned when the promoted version cannot read the data of the previous version.
+type ErrIncompatiblePromotion struct {
+	Schema string
+	From   int
+	To     int
+}
+
+func (e ErrIncompatiblePromotion) Error() string {
+	return fmt.Sprintf("version %d of %s is not backward compatible with version %d", e.To, e.Schema, e.From)
+}
+
+// SchemaPromoter promotes schema versions, tracking the promoted version on the schema `ConfigMap`.
+type SchemaPromoter struct {
+	Client    client.Client
+	Schemas   schemaregistry.SchemaClient
+	ConfigMap types.NamespacedName
+	// NeverDeletePromoted keeps the promoted version when pruning old versions.
+	NeverDeletePromoted bool
+}
+
+// NewSchemaPromoter returns a `SchemaPromoter` for the schema in the given `ConfigMap` of the registry.
+func (r *Registry) NewSchemaPromoter(c client.Client, cfgMap types.NamespacedName) (*SchemaPromoter, error) {
+	schemas, err := r.schemaClient()
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaPromoter{Client: c, Schemas: schemas, ConfigMap: cfgMap}, nil
+}
+
+// PromoteSchemaVersion promotes version `from` of the schema to the next version `to`.
+// The new version must be backward compatible with the promoted one - it has to read the data written with it.
+func (p *SchemaPromoter) PromoteSchemaVersion(ctx context.Context, group, schemaName string, from, to int) error {
+	if to != from+1 {
+		return ErrNonSequentialPromotion{From: from, To: to}
+	}
+	cfgMap := &v1.ConfigMap{}
+	err := p.Client.Get(ctx, p.ConfigMap, cfgMap)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed fetching the config map %s", p.ConfigMap)
+		return err
+	}
+	promoted, ok, err := promotedVersion(cfgMap)
+	if err != nil {
+		return err
+	}
+	if ok && promoted != from {
+		return ErrPromotedVersionMismatch{Promoted: promoted, From: from}
+	}
+
+	writer, err := p.Schemas.GetSchemaVersion(ctx, group, schemaName, int32(from))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed fetching version %d of %s", from, schemaName)
+		return err
+	}
+	reader, err := p.Schemas.GetSchemaVersion(ctx, group, schemaName, int32(to))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed fetching version %d of %s", to, schemaName)
+		return err
+	}
+	compatible, err := schemaregistry.BackwardCompatible(reader, writer)
+	if err != nil {
+		return err
+	}
+	if !compatible {
+		return ErrIncompatiblePromotion{Schema: schemaName, From: from, To: to}
+	}
+
+	patch := client.MergeFrom(cfgMap.DeepCopy())
+	if cfgMap.Annotations == nil {
+		cfgMap.Annotations = map[string]string{}
+	}
+	cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation] = strconv.Itoa(to)
+	err = p.Client.Patch(ctx, cfgMap, patch)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed updating the promoted version of %s", schemaName)
+		return err
+	}
+	log.Info().Msgf("promoted %s to version %d", schemaName, to)
+	return nil
+}
+
+// PruneOldSchemaVersions deletes the versions of the schema but the `keepLatest` most recent ones.
+// The versions are listed once, versions registered while pruning are kept. Returns the number of deleted versions.
+func (p *SchemaPromoter) PruneOldSchemaVersions(ctx context.Context, group, schemaName string, keepLatest int) (int, error) {
+	if keepLatest < 1 {
+		return 0, fmt.Errorf("pruning %s must keep at least the latest version, got %d", schemaName, keepLatest)
+	}
+	promoted, guarded := 0, false
+	if p.NeverDeletePromoted {
+		cfgMap := &v1.ConfigMap{}
+		err := p.Client.Get(ctx, p.ConfigMap, cfgMap)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed fetching the config map %s", p.ConfigMap)
+			return 0, err
+		}
+		promoted, guarded, err = promotedVersion(cfgMap)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := p.Schemas.GetVersions(ctx, group, schemaName)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed listing the versions of %s", schemaName)
+		return 0, err
+	}
+	versions := append([]int32{}, result.List()...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	deleted := 0
+	for i := keepLatest; i < len(versions); i++ {
+		if guarded && int(versions[i]) == promoted {
+			log.Info().Msgf("keeping the promoted version %d of %s", promoted, schemaName)
+			continue
+		}
+		_, err = p.Schemas.DeleteSchemaVersion(ctx, group, schemaName, versions[i])
+		if err != nil {
+			log.Error().Err(err).Msgf("failed deleting version %d of %s", versions[i], schemaName)
+			return deleted, err
+		}
+		deleted++
+	}
+	log.Info().Msgf("pruned %d versions of %s", deleted, schemaName)
+	return deleted, nil
+}
+
+// promotedVersion returns the promoted version of the `ConfigMap`, false if none was promoted.
+func promotedVersion(cfgMap *v1.ConfigMap) (int, bool, error) {
+	current, ok := cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	promoted, err := strconv.Atoi(current)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid promoted version %q: %w", current, err)
+	}
+	return promoted, true, nil
+}
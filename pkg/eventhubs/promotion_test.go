@@ -0,0 +1,94 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Schema promotion", func() {
+	var server *httptest.Server
+	var promoter *eventhubs.SchemaPromoter
+	var requests int
+	key := types.NamespacedName{Namespace: "default", Name: "orders"}
+
+	BeforeEach(func() {
+		requests = 0
+		versions := map[string]string{
+			"1": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`,
+			"2": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"amount","type":"double","default":0}]}`,
+			"3": `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"amount","type":"double"},{"name":"currency","type":"string"}]}`,
+		}
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			content, ok := versions[path.Base(r.URL.Path)]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}))
+		schemas := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+		schemas.Sender = server.Client()
+		cfgMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   key.Namespace,
+				Name:        key.Name,
+				Annotations: map[string]string{schemav1alpha1.PromotedVersionAnnotation: "1"},
+			},
+			Data: map[string]string{"group": "orders", "templateName": "Order"},
+		}
+		promoter = &eventhubs.SchemaPromoter{
+			Client:    fake.NewClientBuilder().WithObjects(cfgMap).Build(),
+			Schemas:   schemas,
+			ConfigMap: key,
+		}
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	promotedVersion := func() string {
+		cfgMap := &v1.ConfigMap{}
+		Expect(promoter.Client.Get(context.Background(), key, cfgMap)).To(Succeed())
+		return cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation]
+	}
+
+	It("should record the promoted version", func() {
+		Expect(promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 1, 2)).To(Succeed())
+		Expect(promotedVersion()).To(Equal("2"))
+	})
+	It("should only promote to the next version", func() {
+		err := promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 1, 3)
+		Expect(err).To(Equal(eventhubs.ErrNonSequentialPromotion{From: 1, To: 3}))
+		err = promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 2, 1)
+		Expect(err).To(Equal(eventhubs.ErrNonSequentialPromotion{From: 2, To: 1}))
+		Expect(requests).To(BeZero())
+		Expect(promotedVersion()).To(Equal("1"))
+	})
+	It("should start from the promoted version", func() {
+		err := promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 2, 3)
+		Expect(err).To(Equal(eventhubs.ErrPromotedVersionMismatch{Promoted: 1, From: 2}))
+		Expect(promotedVersion()).To(Equal("1"))
+	})
+	It("should not promote an incompatible version", func() {
+		Expect(promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 1, 2)).To(Succeed())
+		err := promoter.PromoteSchemaVersion(context.Background(), "orders", "Order", 2, 3)
+		Expect(err).To(Equal(eventhubs.ErrIncompatiblePromotion{Schema: "Order", From: 2, To: 3}))
+		Expect(promotedVersion()).To(Equal("2"))
+	})
+})
@@ -0,0 +1,147 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+const orderProto = `syntax = "proto3";
+
+package com.example.orders;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/orders";
+
+// Order is a customer order.
+message Order {
+  string id = 1;
+  Status status = 2;
+  repeated Line lines = 3;
+  map<string, string> labels = 4 [deprecated = true];
+  google.protobuf.Timestamp created = 5;
+  oneof payment {
+    string card = 6;
+    string voucher = 7;
+  }
+  reserved 8, 9;
+
+  message Line {
+    string sku = 1;
+    int32 quantity = 2;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_NEW = 1;
+}
+
+service Orders {
+  rpc Get (Order) returns (stream Order);
+}
+`
+
+var _ = Describe("Protobuf schemas", func() {
+	It("should accept a valid proto", func() {
+		Expect(schemaregistry.ValidateProtobufSchema(orderProto)).To(Succeed())
+	})
+
+	invalid := []struct {
+		name     string
+		content  string
+		expected schemaregistry.ErrInvalidProtobufSchema
+	}{
+		{"unknown syntax", `syntax = "proto4";`,
+			schemaregistry.ErrInvalidProtobufSchema{Line: 1, Reason: `unknown syntax "proto4"`}},
+		{"missing field number", "syntax = \"proto3\";\nmessage Order {\n  string id;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 3, Reason: `expected "=", found ";"`}},
+		{"unclosed message", "syntax = \"proto3\";\nmessage Order {\n  string id = 1;\n",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 3, Reason: `message Order is not closed`}},
+		{"duplicate field number", "syntax = \"proto3\";\nmessage Order {\n  string id = 1;\n  string name = 1;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 4, Reason: `field number 1 of name is already used by id`}},
+		{"duplicate field name", "syntax = \"proto3\";\nmessage Order {\n  string id = 1;\n  int64 id = 2;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 4, Reason: `duplicate field name "id"`}},
+		{"unknown type", "syntax = \"proto3\";\nmessage Order {\n  Customer customer = 1;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 3, Reason: `unknown type "Customer"`}},
+		{"non zero first enum value", "syntax = \"proto3\";\nenum Status {\n  NEW = 1;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 3, Reason: `the first value of enum Status must be zero in proto3`}},
+		{"required proto3 field", "syntax = \"proto3\";\nmessage Order {\n  required string id = 1;\n}",
+			schemaregistry.ErrInvalidProtobufSchema{Line: 3, Reason: `required fields are not allowed in proto3`}},
+	}
+	for _, tc := range invalid {
+		tc := tc
+		It("should report a "+tc.name, func() {
+			Expect(schemaregistry.ValidateProtobufSchema(tc.content)).To(Equal(tc.expected))
+		})
+	}
+
+	Context("with a registry", func() {
+		var server *httptest.Server
+		var schemas map[string]string
+		var contentTypes []string
+
+		BeforeEach(func() {
+			schemas = map[string]string{}
+			contentTypes = nil
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPut:
+					body, err := io.ReadAll(r.Body)
+					Expect(err).NotTo(HaveOccurred())
+					id := fmt.Sprintf("id-%s", path.Base(r.URL.Path))
+					schemas[id] = string(body)
+					contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+					w.Header().Set("Schema-Id", id)
+					w.WriteHeader(http.StatusNoContent)
+				case http.MethodGet:
+					content, ok := schemas[path.Base(r.URL.Path)]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(content))
+				}
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		newClient := func() schemaregistry.SchemaClient {
+			client := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+			client.Sender = server.Client()
+			return client
+		}
+
+		It("should register and retrieve the proto", func() {
+			client := newClient()
+			id, err := client.RegisterProtobufSchema(context.Background(), "orders", "Order", orderProto)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*id.ID).To(Equal("id-Order"))
+			Expect(schemas["id-Order"]).To(Equal(base64.StdEncoding.EncodeToString([]byte(orderProto))))
+			Expect(contentTypes).To(Equal([]string{"text/plain; serialization=Protobuf; charset=utf-8"}))
+
+			content, err := client.GetProtobufSchema(context.Background(), *id.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(orderProto))
+		})
+		It("should not send a malformed proto", func() {
+			_, err := newClient().RegisterSchema(context.Background(), "orders", "Order", schemaregistry.ProtobufFormat, "message Order {")
+			Expect(err).To(BeAssignableToTypeOf(schemaregistry.ErrInvalidProtobufSchema{}))
+			Expect(schemas).To(BeEmpty())
+		})
+	})
+})
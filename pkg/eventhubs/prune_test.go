@@ -0,0 +1,96 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Schema version pruning", func() {
+	var server *httptest.Server
+	var lock sync.Mutex
+	var versions []int32
+	var deleted []int32
+	key := types.NamespacedName{Namespace: "default", Name: "orders"}
+
+	BeforeEach(func() {
+		versions = []int32{1, 2, 3, 4, 5, 6}
+		deleted = nil
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			switch r.Method {
+			case http.MethodGet:
+				Expect(r.URL.Path).To(HaveSuffix("/versions"))
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(map[string][]int32{"schemaVersions": versions})).To(Succeed())
+			case http.MethodDelete:
+				version, err := strconv.Atoi(path.Base(r.URL.Path))
+				Expect(err).NotTo(HaveOccurred())
+				deleted = append(deleted, int32(version))
+				// a version registered while pruning
+				versions = append(versions, versions[len(versions)-1]+1)
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	newPromoter := func(promoted string, neverDeletePromoted bool) *eventhubs.SchemaPromoter {
+		schemas := schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+		schemas.Sender = server.Client()
+		cfgMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		if promoted != "" {
+			cfgMap.Annotations = map[string]string{schemav1alpha1.PromotedVersionAnnotation: promoted}
+		}
+		return &eventhubs.SchemaPromoter{
+			Client:              fake.NewClientBuilder().WithObjects(cfgMap).Build(),
+			Schemas:             schemas,
+			ConfigMap:           key,
+			NeverDeletePromoted: neverDeletePromoted,
+		}
+	}
+
+	It("should delete the versions but the latest ones", func() {
+		count, err := newPromoter("2", false).PruneOldSchemaVersions(context.Background(), "orders", "Order", 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(4))
+		Expect(deleted).To(Equal([]int32{4, 3, 2, 1}))
+	})
+	It("should keep the promoted version", func() {
+		count, err := newPromoter("2", true).PruneOldSchemaVersions(context.Background(), "orders", "Order", 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(3))
+		Expect(deleted).To(Equal([]int32{4, 3, 1}))
+	})
+	It("should keep all versions when there are fewer than requested", func() {
+		count, err := newPromoter("", true).PruneOldSchemaVersions(context.Background(), "orders", "Order", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+		Expect(deleted).To(BeEmpty())
+	})
+	It("should always keep the latest version", func() {
+		_, err := newPromoter("", false).PruneOldSchemaVersions(context.Background(), "orders", "Order", 0)
+		Expect(err).To(HaveOccurred())
+		Expect(deleted).To(BeEmpty())
+	})
+})
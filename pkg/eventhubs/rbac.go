@@ -0,0 +1,136 @@
+package eventhubs
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+	"github.com/rs/zerolog/log"
+)
+
+// ManagedRoleAssignmentDescription tags the role assignments made by the operator,
+// only these are removed when their principal or role is no longer listed.
+const ManagedRoleAssignmentDescription = "managed-by: azure-schema-operator"
+
+// groupRoleDefinitions are the built-in Azure role definition ids of the schema group roles.
+var groupRoleDefinitions = map[string]string{
+	// Owner
+	schemav1alpha1.GroupRoleOwner: "8e3af657-a8ff-443c-a75c-2fe8c4bcb635",
+	// Schema Registry Contributor
+	schemav1alpha1.GroupRoleWriter: "5dffeca3-4936-4216-b2bc-10343a5abb25",
+	// Schema Registry Reader
+	schemav1alpha1.GroupRoleReader: "2c56ea50-c6b3-40a6-83c0-9d98858bc7d2",
+}
+
+// ErrUnknownGroupRole is returned for a role that is not one of the schema group roles.
+type ErrUnknownGroupRole struct {
+	Principal string
+	Role      string
+}
+
+func (e ErrUnknownGroupRole) Error() string {
+	return fmt.Sprintf("unknown role %q for principal %s, expected Owner, Writer or Reader", e.Role, e.Principal)
+}
+
+// SyncGroupRBAC assigns the roles of `groupRBAC` at the schema group scope and removes the managed
+// assignments that are not listed. Returns the changes, which are only planned when `dryRun` is set.
+func SyncGroupRBAC(ctx context.Context, client schemaregistry.RoleAssignmentsClient, scope string, groupRBAC map[string][]string, dryRun bool) ([]schemav1alpha1.RBACChange, error) {
+	desired := map[string]bool{}
+	for principal, roles := range groupRBAC {
+		for _, role := range roles {
+			if _, ok := groupRoleDefinitions[role]; !ok {
+				return nil, ErrUnknownGroupRole{Principal: principal, Role: role}
+			}
+			desired[principal+"|"+role] = true
+		}
+	}
+
+	assignments, err := client.ListForScope(ctx, scope)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed listing the role assignments of %s", scope)
+		return nil, err
+	}
+	existing := map[string]bool{}
+	removed := map[string]string{}
+	changes := []schemav1alpha1.RBACChange{}
+	for _, assignment := range assignments {
+		p := assignment.Properties
+		if p == nil || p.PrincipalID == nil || p.RoleDefinitionID == nil || assignment.ID == nil {
+			continue
+		}
+		// atScope() also returns the assignments inherited from the parent scopes.
+		if p.Scope != nil && !strings.EqualFold(*p.Scope, scope) {
+			continue
+		}
+		role := groupRole(*p.RoleDefinitionID)
+		key := *p.PrincipalID + "|" + role
+		existing[key] = true
+		if !desired[key] && p.Description != nil && *p.Description == ManagedRoleAssignmentDescription {
+			removed[key] = *assignment.ID
+			changes = append(changes, schemav1alpha1.RBACChange{Action: schemav1alpha1.RBACRemove, Principal: *p.PrincipalID, Role: role})
+		}
+	}
+	for key := range desired {
+		if !existing[key] {
+			principal, role, _ := strings.Cut(key, "|")
+			changes = append(changes, schemav1alpha1.RBACChange{Action: schemav1alpha1.RBACAdd, Principal: principal, Role: role})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Principal != changes[j].Principal {
+			return changes[i].Principal < changes[j].Principal
+		}
+		if changes[i].Role != changes[j].Role {
+			return changes[i].Role < changes[j].Role
+		}
+		return changes[i].Action < changes[j].Action
+	})
+	if dryRun {
+		return changes, nil
+	}
+
+	subscription := strings.Split(strings.TrimPrefix(scope, "/"), "/")
+	for _, change := range changes {
+		key := change.Principal + "|" + change.Role
+		switch change.Action {
+		case schemav1alpha1.RBACAdd:
+			roleDefinition := groupRoleDefinitions[change.Role]
+			if len(subscription) > 1 {
+				roleDefinition = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscription[1], roleDefinition)
+			}
+			description := ManagedRoleAssignmentDescription
+			name := uuid.NewSHA1(uuid.NameSpaceURL, []byte(scope+"|"+key)).String()
+			_, err = client.Create(ctx, scope, name, schemaregistry.RoleAssignmentProperties{
+				RoleDefinitionID: &roleDefinition,
+				PrincipalID:      &change.Principal,
+				Description:      &description,
+			})
+		case schemav1alpha1.RBACRemove:
+			_, err = client.Delete(ctx, removed[key])
+		}
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to %s the %s role of %s", strings.ToLower(string(change.Action)), change.Role, change.Principal)
+			return changes, err
+		}
+		log.Info().Msgf("%s the %s role of %s on %s", change.Action, change.Role, change.Principal, scope)
+	}
+	return changes, nil
+}
+
+// groupRole returns the schema group role of the role definition, the definition id for other roles.
+func groupRole(roleDefinitionID string) string {
+	id := strings.ToLower(path.Base(roleDefinitionID))
+	for role, definition := range groupRoleDefinitions {
+		if definition == id {
+			return role
+		}
+	}
+	return id
+}
@@ -0,0 +1,129 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs"
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+const groupScope = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.EventHub/namespaces/ns/schemagroups/orders"
+
+// armRBAC mocks the Azure Resource Manager role assignments API.
+type armRBAC struct {
+	sync.Mutex
+	assignments []schemaregistry.RoleAssignment
+	created     []schemaregistry.RoleAssignment
+	deleted     []string
+}
+
+func (a *armRBAC) assign(name, principal, roleDefinition, scope string, managed bool) {
+	id := scope + "/providers/Microsoft.Authorization/roleAssignments/" + name
+	definition := "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/" + roleDefinition
+	properties := &schemaregistry.RoleAssignmentProperties{PrincipalID: &principal, RoleDefinitionID: &definition, Scope: &scope}
+	if managed {
+		description := eventhubs.ManagedRoleAssignmentDescription
+		properties.Description = &description
+	}
+	a.assignments = append(a.assignments, schemaregistry.RoleAssignment{ID: &id, Name: &name, Properties: properties})
+}
+
+func (a *armRBAC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.Lock()
+	defer a.Unlock()
+	Expect(r.URL.Query().Get("api-version")).To(Equal("2022-04-01"))
+	switch r.Method {
+	case http.MethodGet:
+		Expect(r.URL.Path).To(Equal(groupScope + "/providers/Microsoft.Authorization/roleAssignments"))
+		Expect(r.URL.Query().Get("$filter")).To(Equal("atScope()"))
+		w.Header().Set("Content-Type", "application/json")
+		Expect(json.NewEncoder(w).Encode(schemaregistry.RoleAssignmentList{Value: &a.assignments})).To(Succeed())
+	case http.MethodPut:
+		body := schemaregistry.RoleAssignment{}
+		Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+		id := r.URL.Path
+		body.ID = &id
+		a.created = append(a.created, body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		Expect(json.NewEncoder(w).Encode(body)).To(Succeed())
+	case http.MethodDelete:
+		a.deleted = append(a.deleted, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+var _ = Describe("Schema group RBAC", func() {
+	const (
+		ownerRole  = "8e3af657-a8ff-443c-a75c-2fe8c4bcb635"
+		writerRole = "5dffeca3-4936-4216-b2bc-10343a5abb25"
+		readerRole = "2c56ea50-c6b3-40a6-83c0-9d98858bc7d2"
+	)
+	var server *httptest.Server
+	var arm *armRBAC
+	var client schemaregistry.RoleAssignmentsClient
+
+	BeforeEach(func() {
+		arm = &armRBAC{}
+		arm.assign("kept", "alice", writerRole, groupScope, true)
+		arm.assign("stale", "bob", readerRole, groupScope, true)
+		arm.assign("manual", "carol", ownerRole, groupScope, false)
+		arm.assign("inherited", "dave", readerRole, "/subscriptions/sub", true)
+		server = httptest.NewTLSServer(arm)
+		client = schemaregistry.NewRoleAssignmentsClient()
+		client.Endpoint = strings.TrimPrefix(server.URL, "https://")
+		client.Sender = server.Client()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	groupRBAC := map[string][]string{
+		"alice": {schemav1alpha1.GroupRoleWriter, schemav1alpha1.GroupRoleReader},
+		"erin":  {schemav1alpha1.GroupRoleOwner},
+	}
+	expected := []schemav1alpha1.RBACChange{
+		{Action: schemav1alpha1.RBACAdd, Principal: "alice", Role: schemav1alpha1.GroupRoleReader},
+		{Action: schemav1alpha1.RBACRemove, Principal: "bob", Role: schemav1alpha1.GroupRoleReader},
+		{Action: schemav1alpha1.RBACAdd, Principal: "erin", Role: schemav1alpha1.GroupRoleOwner},
+	}
+
+	It("should add the listed roles and remove the unlisted managed assignments", func() {
+		changes, err := eventhubs.SyncGroupRBAC(context.Background(), client, groupScope, groupRBAC, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(expected))
+
+		Expect(arm.deleted).To(Equal([]string{groupScope + "/providers/Microsoft.Authorization/roleAssignments/stale"}))
+		Expect(arm.created).To(HaveLen(2))
+		for i, added := range []struct{ principal, roleDefinition string }{{"alice", readerRole}, {"erin", ownerRole}} {
+			assignment := arm.created[i]
+			Expect(*assignment.ID).To(HavePrefix(groupScope + "/providers/Microsoft.Authorization/roleAssignments/"))
+			Expect(*assignment.Properties.Description).To(Equal(eventhubs.ManagedRoleAssignmentDescription))
+			Expect(*assignment.Properties.PrincipalID).To(Equal(added.principal))
+			Expect(*assignment.Properties.RoleDefinitionID).To(Equal("/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/" + added.roleDefinition))
+		}
+	})
+	It("should only plan the changes in dry run", func() {
+		changes, err := eventhubs.SyncGroupRBAC(context.Background(), client, groupScope, groupRBAC, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(expected))
+		Expect(arm.created).To(BeEmpty())
+		Expect(arm.deleted).To(BeEmpty())
+	})
+	It("should refuse unknown roles", func() {
+		_, err := eventhubs.SyncGroupRBAC(context.Background(), client, groupScope, map[string][]string{"alice": {"Admin"}}, false)
+		Expect(err).To(Equal(eventhubs.ErrUnknownGroupRole{Principal: "alice", Role: "Admin"}))
+		Expect(arm.created).To(BeEmpty())
+		Expect(arm.deleted).To(BeEmpty())
+	})
+})
@@ -0,0 +1,106 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+// mockClock records the retry delays instead of waiting.
+type mockClock struct {
+	delays []time.Duration
+	// onSleep runs on every wait, e.g. to cancel the request.
+	onSleep func()
+}
+
+func (m *mockClock) Sleep(ctx context.Context, d time.Duration) error {
+	m.delays = append(m.delays, d)
+	if m.onSleep != nil {
+		m.onSleep()
+	}
+	return ctx.Err()
+}
+
+var _ = Describe("Client retry policy", func() {
+	var server *httptest.Server
+	var hits, failures int32
+	var clock *mockClock
+
+	BeforeEach(func() {
+		hits, failures = 0, 2
+		clock = &mockClock{}
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"schemaGroups":["orders"]}`))
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(opts ...schemaregistry.Option) schemaregistry.SchemaGroupsClient {
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+		opts = append(opts, schemaregistry.WithTLSConfig(&tls.Config{RootCAs: roots}), schemaregistry.WithClock(clock))
+		return schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(server.URL, "https://"), opts...)
+	}
+
+	It("should retry with doubling jittered delays", func() {
+		client := newClient(schemaregistry.WithRetryPolicy(3, 100*time.Millisecond, time.Second, 0.2))
+		result, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal([]string{"orders"}))
+		Expect(hits).To(Equal(int32(3)))
+		Expect(clock.delays).To(HaveLen(2))
+		Expect(clock.delays[0]).To(BeNumerically(">=", 80*time.Millisecond))
+		Expect(clock.delays[0]).To(BeNumerically("<=", 120*time.Millisecond))
+		Expect(clock.delays[1]).To(BeNumerically(">=", 160*time.Millisecond))
+		Expect(clock.delays[1]).To(BeNumerically("<=", 240*time.Millisecond))
+	})
+	It("should fail once the attempts are exhausted", func() {
+		result, err := newClient(schemaregistry.WithRetryPolicy(2, 100*time.Millisecond, time.Second, 0)).List(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(result.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(hits).To(Equal(int32(2)))
+		Expect(clock.delays).To(Equal([]time.Duration{100 * time.Millisecond}))
+	})
+	It("should cap the delays", func() {
+		policy := schemaregistry.RetryPolicy{MaxAttempts: 10, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+		Expect([]time.Duration{policy.Delay(0), policy.Delay(1), policy.Delay(2), policy.Delay(3), policy.Delay(8)}).To(
+			Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}))
+	})
+	It("should stop retrying once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock.onSleep = cancel
+		_, err := newClient(schemaregistry.WithRetryPolicy(5, 100*time.Millisecond, time.Second, 0)).List(ctx)
+		Expect(err).To(MatchError(ContainSubstring(context.Canceled.Error())))
+		Expect(hits).To(Equal(int32(1)))
+		Expect(clock.delays).To(HaveLen(1))
+	})
+	It("should keep the autorest retry defaults without a policy", func() {
+		failures = 1
+		client := newClient()
+		client.RetryDuration = 10 * time.Millisecond
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clock.delays).To(Equal([]time.Duration{10 * time.Millisecond}))
+	})
+})
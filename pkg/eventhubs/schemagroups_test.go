@@ -0,0 +1,80 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("SchemaGroups", func() {
+	groups := []string{"orders/eu", "orders/us", "payments", "orders&more"}
+	var server *httptest.Server
+	var queries []string
+	var supportsPrefix bool
+
+	newClient := func() schemaregistry.SchemaGroupsClient {
+		client := schemaregistry.NewSchemaGroupsClient(strings.TrimPrefix(server.URL, "https://"))
+		client.Sender = server.Client()
+		return client
+	}
+
+	BeforeEach(func() {
+		queries = nil
+		supportsPrefix = true
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			prefix, filtered := r.URL.Query()["groupNamePrefix"]
+			if filtered && !supportsPrefix {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			matching := []string{}
+			for _, group := range groups {
+				if !filtered || strings.HasPrefix(group, prefix[0]) {
+					matching = append(matching, group)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			Expect(json.NewEncoder(w).Encode(map[string][]string{"schemaGroups": matching})).To(Succeed())
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should query the registry with the encoded prefix", func() {
+		result, err := newClient().ListWithPrefix(context.Background(), "orders&")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal([]string{"orders&more"}))
+		Expect(queries).To(HaveLen(1))
+		Expect(queries[0]).To(ContainSubstring("groupNamePrefix=orders%26"))
+	})
+	It("should encode the prefix special characters", func() {
+		_, err := newClient().ListWithPrefix(context.Background(), "orders/e u%")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries[0]).To(ContainSubstring("groupNamePrefix=orders%2Fe+u%25"))
+	})
+	It("should filter the full list when the prefix is not supported", func() {
+		supportsPrefix = false
+		result, err := newClient().ListWithPrefix(context.Background(), "orders/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal([]string{"orders/eu", "orders/us"}))
+		Expect(queries).To(HaveLen(2))
+		Expect(queries[1]).NotTo(ContainSubstring("groupNamePrefix"))
+	})
+	It("should list all the groups for an empty prefix", func() {
+		result, err := newClient().ListWithPrefix(context.Background(), "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result.SchemaGroups).To(Equal(groups))
+		Expect(queries).To(Equal([]string{"api-version=2021-10"}))
+	})
+})
@@ -5,6 +5,8 @@ package eventhubs
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"time"
 
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
@@ -13,10 +15,14 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
 	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
 	"github.com/rs/zerolog/log"
 )
 
+// DefaultRequestTimeout is the time a schema registry request may take.
+const DefaultRequestTimeout = 30 * time.Second
+
 // Registry represents eventhub schema `Registry` object
 type Registry struct {
 	Endpoint string
@@ -31,19 +37,27 @@ func NewRegistry(uri string) *Registry {
 	return cls
 }
 
+// requestTimeout returns the configured schema registry request timeout.
+func requestTimeout() time.Duration {
+	if timeout := config.GetDuration(config.RegistryRequestTimeoutKey); timeout > 0 {
+		return timeout
+	}
+	return DefaultRequestTimeout
+}
+
 // AquireTargets for eventhubs is a no-op function (required by the interface)
 func (r *Registry) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
 	targets := schemav1alpha1.ClusterTargets{}
 	return targets, nil
 }
 
-// Execute registers the given schema in the schema registry
-func (r *Registry) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
-	done := schemav1alpha1.ClusterTargets{}
+// schemaClient returns a schema registry client authorized with the default azure credential
+func (r *Registry) schemaClient() (schemaregistry.SchemaClient, error) {
+	client := schemaregistry.NewSchemaClientWithOptions(r.Endpoint, schemaregistry.WithRequestTimeout(requestTimeout()))
 	cred, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
 		log.Error().Err(err).Msg("Authentication failure")
-		return done, err
+		return client, err
 	}
 	t, _ := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"https://eventhubs.azure.net/.default"}})
 	// log.Printf("got token: %s", t.Token)
@@ -51,18 +65,25 @@ func (r *Registry) Execute(targets schemav1alpha1.ClusterTargets, config schemav
 	adalToken := adal.Token{
 		AccessToken: t.Token,
 	}
-	authorizer := autorest.NewBearerAuthorizer(&adalToken)
+	client.Authorizer = autorest.NewBearerAuthorizer(&adalToken)
+	return client, nil
+}
 
-	client := schemaregistry.NewSchemaClient(r.Endpoint)
-	client.Authorizer = authorizer
+// Execute registers the given schema in the schema registry
+func (r *Registry) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	done := schemav1alpha1.ClusterTargets{}
+	client, err := r.schemaClient()
+	if err != nil {
+		return done, err
+	}
 	ctx := context.Background()
 
-	resp, err := client.Register(ctx, config.Group, config.TemplateName, config.Schema)
+	id, err := client.RegisterSchema(ctx, config.Group, config.TemplateName, schemaregistry.AvroFormat, config.Schema)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to register")
 		return done, err
 	}
-	schemaId := resp.Header.Get("Schema-Id")
+	schemaId := *id.ID
 	log.Info().Msgf("registered the schema: %s", schemaId)
 	done.Schemas = append(done.Schemas, schemaId)
 	return done, nil
@@ -93,5 +114,22 @@ func (r *Registry) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets
 	if group, ok := cfgMap.Data["group"]; ok {
 		config.Group = group
 	}
+	if promoted, ok := cfgMap.Annotations[schemav1alpha1.PromotedVersionAnnotation]; ok {
+		version, err := strconv.ParseInt(promoted, 10, 32)
+		if err != nil {
+			log.Error().Err(err).Msgf("invalid promoted version %q", promoted)
+			return config, err
+		}
+		client, err := r.schemaClient()
+		if err != nil {
+			return config, err
+		}
+		log.Info().Msgf("using the promoted version %d of the schema", version)
+		config.Schema, err = client.GetSchemaVersion(context.Background(), config.Group, config.TemplateName, int32(version))
+		if err != nil {
+			log.Error().Err(err).Msgf("failed fetching the promoted version %d", version)
+			return config, err
+		}
+	}
 	return config, nil
 }
@@ -0,0 +1,68 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Schema registry search", func() {
+	var registry *memoryRegistry
+	var server *httptest.Server
+	var client schemaregistry.SchemaClient
+
+	BeforeEach(func() {
+		registry = &memoryRegistry{schemas: map[string][]registeredVersion{}}
+		server = httptest.NewTLSServer(registry)
+		client = schemaregistry.NewSchemaClientWithOptions(strings.TrimPrefix(server.URL, "https://"))
+		client.Sender = server.Client()
+
+		ctx := context.Background()
+		schemas := []schemaregistry.SchemaDef{
+			{Name: "Order", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`},
+			{Name: "Order", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},` +
+				`{"name":"customer","type":{"type":"record","name":"Customer","fields":[{"name":"Email","type":"string"}]}}]}`},
+			{Name: "Payment", Format: schemaregistry.AvroFormat, Content: `{"type":"record","name":"Payment","fields":[{"name":"amount","type":"double"}]}`},
+			{Name: "Invoice", Format: schemaregistry.ProtobufFormat, Content: "syntax = \"proto3\";\nmessage Invoice {\n  string id = 1;\n  string billing_email = 2;\n}\n"},
+			{Name: "Shipment", Format: schemaregistry.ProtobufFormat, Content: "syntax = \"proto3\";\nmessage Shipment {\n  string address = 1;\n}\n"},
+		}
+		for _, schema := range schemas {
+			_, err := client.RegisterSchema(ctx, "orders", schema.Name, schema.Format, schema.Content)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		registry.schemas["Refund"] = []registeredVersion{{
+			contentType: "application/json; serialization=Json",
+			content:     `{"type":"object","properties":{"reason":{"type":"string"},"amount":{"type":"number"}}}`,
+		}}
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should find the fields of the latest versions ignoring the case", func() {
+		results, err := client.SearchSchemas(context.Background(), "orders", "email")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]schemaregistry.SchemaSearchResult{
+			{SchemaName: "Invoice", Version: 1, Format: schemaregistry.ProtobufFormat, MatchingFields: []string{"Invoice.billing_email"}},
+			{SchemaName: "Order", Version: 2, Format: schemaregistry.AvroFormat, MatchingFields: []string{"customer.Email"}},
+		}))
+	})
+	It("should restrict the search to a format", func() {
+		results, err := client.SearchSchemas(context.Background(), "orders", "amount", schemaregistry.WithSearchFormat(schemaregistry.JSONFormat))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]schemaregistry.SchemaSearchResult{
+			{SchemaName: "Refund", Version: 1, Format: schemaregistry.JSONFormat, MatchingFields: []string{"amount"}},
+		}))
+
+		results, err = client.SearchSchemas(context.Background(), "orders", "missing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(BeEmpty())
+	})
+})
@@ -0,0 +1,104 @@
+package eventhubs_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/microsoft/azure-schema-operator/pkg/eventhubs/azure/schemaregistry"
+)
+
+var _ = Describe("Workload Identity", func() {
+	const (
+		tenantID = "00000000-0000-0000-0000-000000000001"
+		clientID = "00000000-0000-0000-0000-000000000002"
+	)
+	var tokenServer, registry *httptest.Server
+	var tokenFile string
+	var exchanges []http.Request
+	var assertions []string
+	var authorizations []string
+	var expiresIn int
+
+	BeforeEach(func() {
+		exchanges, assertions, authorizations = nil, nil, nil
+		expiresIn = 3600
+		tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			exchanges = append(exchanges, *r)
+			assertions = append(assertions, r.PostForm.Get("client_assertion"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"token_type":"Bearer","access_token":"token-%d","expires_in":%d}`, len(exchanges), expiresIn)
+		}))
+		registry = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorizations = append(authorizations, r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"schemaGroups":[]}`))
+		}))
+		dir, err := os.MkdirTemp("", "workload-identity")
+		Expect(err).NotTo(HaveOccurred())
+		tokenFile = filepath.Join(dir, "token")
+		Expect(os.WriteFile(tokenFile, []byte("sa-token-1\n"), 0600)).To(Succeed())
+		os.Setenv("AZURE_AUTHORITY_HOST", tokenServer.URL+"/")
+	})
+	AfterEach(func() {
+		os.Unsetenv("AZURE_AUTHORITY_HOST")
+		os.RemoveAll(filepath.Dir(tokenFile))
+		tokenServer.Close()
+		registry.Close()
+	})
+
+	newClient := func() schemaregistry.SchemaGroupsClient {
+		client := schemaregistry.NewSchemaGroupsClientWithOptions(strings.TrimPrefix(registry.URL, "https://"),
+			schemaregistry.WithWorkloadIdentityAuth(tenantID, clientID, tokenFile))
+		client.Sender = registry.Client()
+		return client
+	}
+
+	It("should exchange the service account token", func() {
+		_, err := newClient().List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exchanges).To(HaveLen(1))
+		Expect(exchanges[0].URL.Path).To(Equal("/" + tenantID + "/oauth2/v2.0/token"))
+		Expect(exchanges[0].PostForm.Get("client_id")).To(Equal(clientID))
+		Expect(exchanges[0].PostForm.Get("scope")).To(Equal("https://eventhubs.azure.net/.default"))
+		Expect(exchanges[0].PostForm.Get("client_assertion_type")).To(Equal("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"))
+		Expect(assertions).To(Equal([]string{"sa-token-1"}))
+		Expect(authorizations).To(Equal([]string{"Bearer token-1"}))
+	})
+	It("should reuse the token until it is about to expire", func() {
+		client := newClient()
+		for i := 0; i < 2; i++ {
+			_, err := client.List(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(exchanges).To(HaveLen(1))
+		Expect(authorizations).To(Equal([]string{"Bearer token-1", "Bearer token-1"}))
+	})
+	It("should refresh the token 5 minutes before expiry", func() {
+		expiresIn = 299
+		client := newClient()
+		_, err := client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(tokenFile, []byte("sa-token-2"), 0600)).To(Succeed())
+		_, err = client.List(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assertions).To(Equal([]string{"sa-token-1", "sa-token-2"}))
+		Expect(authorizations).To(Equal([]string{"Bearer token-1", "Bearer token-2"}))
+	})
+	It("should fail without the service account token", func() {
+		Expect(os.Remove(tokenFile)).To(Succeed())
+		_, err := newClient().List(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("failed reading the service account token")))
+		Expect(authorizations).To(BeEmpty())
+	})
+})
@@ -0,0 +1,202 @@
+package health
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Response is the JSON body returned by the probe endpoints.
+type Response struct {
+	Status string `json:"status"`
+	Uptime string `json:"uptime"`
+}
+
+// StartupCheck verifies a dependency of the operator is reachable, e.g. a target cluster.
+type StartupCheck func(ctx context.Context) error
+
+// Server serves the `/healthz`, `/readyz` and `/startupz` probes of the operator.
+// `/healthz` succeeds once the manager is running, `/readyz` only after the cache
+// is synced and at least one reconcile finished successfully, `/startupz` once all the startup checks pass.
+type Server struct {
+	addr       string
+	started    time.Time
+	running    int32
+	synced     int32
+	reconciled int32
+	listener   net.Listener
+	checks     map[string]StartupCheck
+}
+
+// NewServer returns a new probe server listening on `addr`.
+func NewServer(addr string) *Server {
+	return &Server{
+		addr:    addr,
+		started: time.Now(),
+	}
+}
+
+// SetRunning marks the controller manager as running.
+func (s *Server) SetRunning() {
+	atomic.StoreInt32(&s.running, 1)
+}
+
+// SetCacheSynced marks the initial cache sync as completed.
+func (s *Server) SetCacheSynced() {
+	atomic.StoreInt32(&s.synced, 1)
+}
+
+// ReconcileSucceeded records a successful reconcile.
+func (s *Server) ReconcileSucceeded() {
+	if s == nil {
+		return
+	}
+	atomic.StoreInt32(&s.reconciled, 1)
+}
+
+// AddStartupCheck adds the `check` named `name` to the startup probe, the checks are added before the server starts.
+func (s *Server) AddStartupCheck(name string, check StartupCheck) {
+	if s.checks == nil {
+		s.checks = make(map[string]StartupCheck)
+	}
+	s.checks[name] = check
+}
+
+// IsStarted runs the startup checks, it fails on the first failing check.
+func (s *Server) IsStarted(ctx context.Context) bool {
+	for name, check := range s.checks {
+		if err := check(ctx); err != nil {
+			log.Error().Err(err).Msgf("startup check %s failed", name)
+			return false
+		}
+	}
+	return true
+}
+
+// IsHealthy checks if the controller manager is running.
+func (s *Server) IsHealthy() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
+
+// IsReady checks if the cache is synced and a reconcile already succeeded.
+func (s *Server) IsReady() bool {
+	return s.IsHealthy() && atomic.LoadInt32(&s.synced) == 1 && atomic.LoadInt32(&s.reconciled) == 1
+}
+
+// Wrap returns a reconciler that records every successful reconcile of `r`.
+// A nil server returns `r` as is.
+func (s *Server) Wrap(r reconcile.Reconciler) reconcile.Reconciler {
+	if s == nil {
+		return r
+	}
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		res, err := r.Reconcile(ctx, req)
+		if err == nil {
+			s.ReconcileSucceeded()
+		}
+		return res, err
+	})
+}
+
+// Runnable returns a manager runnable that marks the manager as running when started
+// and the cache as synced once the initial sync of `c` completes.
+func (s *Server) Runnable(c cache.Cache) manager.Runnable {
+	return &managerProbe{server: s, cache: c}
+}
+
+// managerProbe tracks the manager state, it runs on every replica regardless of leader election.
+type managerProbe struct {
+	server *Server
+	cache  cache.Cache
+}
+
+func (p *managerProbe) Start(ctx context.Context) error {
+	p.server.SetRunning()
+	if p.cache.WaitForCacheSync(ctx) {
+		p.server.SetCacheSynced()
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (p *managerProbe) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the http handler serving the probe endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, s.IsHealthy(), "not running")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, s.IsReady(), "not ready")
+	})
+	mux.HandleFunc("/startupz", func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, s.IsStarted(r.Context()), "not started")
+	})
+	return mux
+}
+
+func (s *Server) respond(w http.ResponseWriter, ok bool, failure string) {
+	resp := Response{Status: "ok", Uptime: time.Since(s.started).Round(time.Second).String()}
+	code := http.StatusOK
+	if !ok {
+		resp.Status = failure
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("failed writing probe response")
+	}
+}
+
+// Addr returns the address the server listens on, once started.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Start listens on the server address and serves the probes until `ctx` is done,
+// then shuts the server down gracefully.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to listen on %s", s.addr)
+		return err
+	}
+	s.listener = listener
+	srv := &http.Server{Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("failed shutting down the probe server")
+		}
+	}()
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("probe server stopped")
+		}
+	}()
+	return nil
+}
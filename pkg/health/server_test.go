@@ -0,0 +1,110 @@
+package health_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/microsoft/azure-schema-operator/pkg/health"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func probe(server *health.Server, path string) (int, health.Response) {
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", server.Addr(), path))
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+	body := health.Response{}
+	Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+	return resp.StatusCode, body
+}
+
+var _ = Describe("Server", func() {
+	var server *health.Server
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		server = health.NewServer("127.0.0.1:0")
+		Expect(server.Start(ctx)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should not be healthy or ready before the manager runs", func() {
+		code, body := probe(server, "/healthz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body.Status).To(Equal("not running"))
+		code, _ = probe(server, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should move from not ready to ready", func() {
+		server.SetRunning()
+		code, body := probe(server, "/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body.Status).To(Equal("ok"))
+		Expect(body.Uptime).NotTo(BeEmpty())
+
+		code, body = probe(server, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body.Status).To(Equal("not ready"))
+
+		server.SetCacheSynced()
+		code, _ = probe(server, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+		server.ReconcileSucceeded()
+		code, body = probe(server, "/readyz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body.Status).To(Equal("ok"))
+	})
+
+	It("should only count successful reconciles", func() {
+		server.SetRunning()
+		server.SetCacheSynced()
+		failing := server.Wrap(reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, fmt.Errorf("failed")
+		}))
+		_, err := failing.Reconcile(context.Background(), reconcile.Request{})
+		Expect(err).To(HaveOccurred())
+		Expect(server.IsReady()).To(BeFalse())
+
+		succeeding := server.Wrap(reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, nil
+		}))
+		_, err = succeeding.Reconcile(context.Background(), reconcile.Request{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.IsReady()).To(BeTrue())
+	})
+
+	It("should be started once all the startup checks pass", func() {
+		code, _ := probe(server, "/startupz")
+		Expect(code).To(Equal(http.StatusOK))
+
+		failing := fmt.Errorf("unreachable")
+		checked := health.NewServer("127.0.0.1:0")
+		checked.AddStartupCheck("cluster1", func(ctx context.Context) error { return nil })
+		checked.AddStartupCheck("cluster2", func(ctx context.Context) error { return failing })
+		ctx, stop := context.WithCancel(context.Background())
+		defer stop()
+		Expect(checked.Start(ctx)).To(Succeed())
+
+		code, body := probe(checked, "/startupz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body.Status).To(Equal("not started"))
+
+		failing = nil
+		code, body = probe(checked, "/startupz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body.Status).To(Equal("ok"))
+	})
+})
@@ -0,0 +1,119 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AssertionsKey is the `ConfigMap` key holding the json list of post-apply assertions.
+	AssertionsKey = "assertions"
+)
+
+// AssertionResult is the outcome of a `KQLAssertion` on a database.
+type AssertionResult struct {
+	Assertion schemav1alpha1.KQLAssertion
+	Passed    bool
+	Error     error
+	RowCount  int
+}
+
+// ErrAssertionFailed is returned when a post-apply assertion fails on a database.
+type ErrAssertionFailed struct {
+	DB      string
+	Message string
+}
+
+func (e ErrAssertionFailed) Error() string {
+	return fmt.Sprintf("schema assertion failed on %s: %s", e.DB, e.Message)
+}
+
+// ParseAssertions reads the json list of assertions and validates every query is read-only.
+func ParseAssertions(data string) ([]schemav1alpha1.KQLAssertion, error) {
+	var assertions []schemav1alpha1.KQLAssertion
+	err := json.Unmarshal([]byte(data), &assertions)
+	if err != nil {
+		return nil, err
+	}
+	for _, assertion := range assertions {
+		if err := ValidatePreConditionKQL(assertion.Query); err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", assertion.Query, err)
+		}
+	}
+	return assertions, nil
+}
+
+// RunSchemaTests runs the `assertions` on the database `db`.
+// An assertion fails if its query errors, or returns no rows when `ExpectedNonEmpty` is set.
+// The returned error reports the first failed assertion.
+func RunSchemaTests(ctx context.Context, cluster *KustoCluster, db string, assertions []schemav1alpha1.KQLAssertion) ([]AssertionResult, error) {
+	results := make([]AssertionResult, 0, len(assertions))
+	var failed error
+	for _, assertion := range assertions {
+		result := AssertionResult{Assertion: assertion}
+		result.RowCount, result.Error = countRows(ctx, cluster, db, assertion.Query)
+		result.Passed = result.Error == nil && (!assertion.ExpectedNonEmpty || result.RowCount > 0)
+		if !result.Passed && failed == nil {
+			message := assertion.FailMessage
+			if message == "" {
+				message = fmt.Sprintf("%q returned no rows", assertion.Query)
+			}
+			if result.Error != nil {
+				message = fmt.Sprintf("%s: %v", message, result.Error)
+			}
+			failed = ErrAssertionFailed{DB: db, Message: message}
+		}
+		results = append(results, result)
+	}
+	if failed != nil {
+		log.Error().Err(failed).Msgf("schema tests failed on %s", db)
+	}
+	return results, failed
+}
+
+// countRows runs the query `kql` on `db` and returns the number of rows in the result.
+func countRows(ctx context.Context, cluster *KustoCluster, db, kql string) (int, error) {
+	iter, err := cluster.Client.Query(ctx, db, newUnsafeStmt(kql))
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Stop()
+
+	rows := 0
+	err = iter.Do(
+		func(row *table.Row) error {
+			rows++
+			return nil
+		},
+	)
+	return rows, err
+}
+
+// ComplianceResults runs the `assertions` on every target database and returns their results for a compliance report.
+// Unlike `RunSchemaTests` every assertion is run, also after one failed.
+func (c *KustoCluster) ComplianceResults(ctx context.Context, targets schemav1alpha1.ClusterTargets, assertions []schemav1alpha1.KQLAssertion) []schemav1alpha1.AssertionResult {
+	results := make([]schemav1alpha1.AssertionResult, 0, len(targets.DBs)*len(assertions))
+	for _, db := range targets.DBs {
+		for _, assertion := range assertions {
+			result := schemav1alpha1.AssertionResult{Database: db, Query: assertion.Query}
+			rows, err := countRows(ctx, c, db, assertion.Query)
+			result.RowCount = rows
+			result.Passed = err == nil && (!assertion.ExpectedNonEmpty || rows > 0)
+			switch {
+			case err != nil:
+				result.Message = err.Error()
+			case !result.Passed:
+				result.Message = assertion.FailMessage
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
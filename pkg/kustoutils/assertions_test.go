@@ -0,0 +1,97 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+// rowCountHandler answers every query with the number of rows configured for its table, failing on unknown tables.
+func rowCountHandler(counts map[string]int) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		name := strings.TrimSpace(strings.Split(stmt, "|")[0])
+		count, ok := counts[name]
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve table expression named '%s'", name)
+		}
+		rows := [][]string{}
+		for i := 0; i < count; i++ {
+			rows = append(rows, []string{name})
+		}
+		return mockRows(table.Columns{{Name: "Name", Type: types.String}}, rows...)
+	}
+}
+
+var _ = Describe("Schema tests", func() {
+	assertions := []schemav1alpha1.KQLAssertion{
+		{Query: "Events | take 1", ExpectedNonEmpty: true, FailMessage: "Events is empty"},
+		{Query: "Audit | take 1"},
+	}
+
+	It("should pass when every assertion holds", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: rowCountHandler(map[string]int{"Events": 3, "Audit": 0})}}
+		results, err := kustoutils.RunSchemaTests(context.Background(), cluster, "db1", assertions)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Passed).To(BeTrue())
+		Expect(results[0].RowCount).To(Equal(3))
+		Expect(results[1].Passed).To(BeTrue())
+		Expect(results[1].RowCount).To(Equal(0))
+	})
+	It("should fail on empty results when rows are expected", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: rowCountHandler(map[string]int{"Events": 0, "Audit": 0})}}
+		results, err := kustoutils.RunSchemaTests(context.Background(), cluster, "db1", assertions)
+		Expect(err).To(Equal(kustoutils.ErrAssertionFailed{DB: "db1", Message: "Events is empty"}))
+		Expect(results[0].Passed).To(BeFalse())
+		Expect(results[0].Error).NotTo(HaveOccurred())
+		Expect(results[1].Passed).To(BeTrue())
+	})
+	It("should fail on query errors", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: rowCountHandler(map[string]int{"Events": 1})}}
+		results, err := kustoutils.RunSchemaTests(context.Background(), cluster, "db1", assertions)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Audit"))
+		Expect(results[0].Passed).To(BeTrue())
+		Expect(results[1].Passed).To(BeFalse())
+		Expect(results[1].Error).To(HaveOccurred())
+	})
+	It("should read the assertions from the configmap", func() {
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+		cfgMap := &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":                    ".create-merge table Events (Timestamp:datetime)",
+				kustoutils.AssertionsKey: `[{"query": "Events | take 1", "expectedNonEmpty": true, "failMessage": "Events is empty"}]`,
+			},
+		}
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.Assertions).To(Equal(assertions[:1]))
+
+		cfgMap.Data[kustoutils.AssertionsKey] = `[{"query": ".drop table Events"}]`
+		_, err = cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should run every assertion for the compliance report", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: rowCountHandler(map[string]int{"Events": 0})}}
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+		results := cluster.ComplianceResults(context.Background(), targets, assertions)
+		Expect(results).To(HaveLen(4))
+		Expect(results[0]).To(Equal(schemav1alpha1.AssertionResult{Database: "db1", Query: "Events | take 1", Message: "Events is empty"}))
+		Expect(results[1].Passed).To(BeFalse())
+		Expect(results[1].Message).To(ContainSubstring("Audit"))
+		Expect(results[2].Database).To(Equal("db2"))
+		Expect(schemav1alpha1.OverallCompliance(results)).To(Equal(schemav1alpha1.ComplianceFail))
+	})
+})
@@ -0,0 +1,110 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// ClusterResult is the outcome of a batch execution on a single cluster.
+type ClusterResult struct {
+	URI string
+	// Diff holds the targets the schema was applied to.
+	Diff schemav1alpha1.ClusterTargets
+	Err  error
+	// RolledBack is set when the change was reverted after another cluster failed.
+	RolledBack bool
+}
+
+// BatchExecute applies `config` in parallel on every cluster with its matching `targets`.
+// In `AllOrNothing` mode a failure on any cluster rolls back the succeeded ones to the `RollbackFile` schema
+// and an error is returned. In `BestEffort` mode (the default) the results are returned regardless of failures.
+func BatchExecute(ctx context.Context, clusters []*KustoCluster, targets []schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) ([]ClusterResult, error) {
+	if len(clusters) != len(targets) {
+		return nil, fmt.Errorf("got %d clusters but %d targets", len(clusters), len(targets))
+	}
+	allOrNothing := config.BatchMode == schemav1alpha1.BatchModeAllOrNothing
+	if allOrNothing && config.RollbackFile == "" {
+		return nil, fmt.Errorf("all or nothing batch execution requires a rollback file")
+	}
+
+	results := make([]ClusterResult, len(clusters))
+	wg := sync.WaitGroup{}
+	for i := range clusters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = clusters[i].batchExecute(ctx, targets[i], config)
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return results, nil
+	}
+	if !allOrNothing {
+		log.Warn().Msgf("batch execution failed on %d of %d clusters", failed, len(clusters))
+		return results, nil
+	}
+
+	var rollbackErr error
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		err := clusters[i].rollback(results[i].Diff, config)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed rolling back %s", results[i].URI)
+			rollbackErr = err
+			continue
+		}
+		results[i].RolledBack = true
+	}
+	if rollbackErr != nil {
+		return results, fmt.Errorf("batch execution failed on %d clusters and rollback failed: %w", failed, rollbackErr)
+	}
+	return results, fmt.Errorf("batch execution failed on %d clusters - rolled back", failed)
+}
+
+// batchExecute runs `config` on the cluster with a job for its own `targets`.
+func (c *KustoCluster) batchExecute(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) ClusterResult {
+	res := ClusterResult{URI: c.URI}
+	if res.Err = ctx.Err(); res.Err != nil {
+		return res
+	}
+	config.JobFile, res.Err = c.createJobFile(targets.DBs, config.KQLFile, config)
+	if res.Err != nil {
+		return res
+	}
+	res.Diff, res.Err = c.Execute(targets, config)
+	return res
+}
+
+// rollback restores the `RollbackFile` schema on the databases changed by the batch execution.
+func (c *KustoCluster) rollback(done schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) error {
+	if len(done.DBs) == 0 {
+		return nil
+	}
+	log.Info().Strs("dbs", done.DBs).Msgf("rolling back %s", c.URI)
+	// the rollback may need to drop what the failed batch added.
+	config.FailIfDataLoss = false
+	config.IngestionPoliciesFile = ""
+	config.PartitioningPoliciesFile = ""
+	config.MaterializedViewsFile = ""
+	jobFile, err := c.createJobFile(done.DBs, config.RollbackFile, config)
+	if err != nil {
+		return err
+	}
+	return runDeltaJob(context.Background(), c.wrapper, config.JobID, "", jobFile, config.ResultDir)
+}
@@ -0,0 +1,94 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	clusterAURI = "https://clustera.westeurope.kusto.windows.net"
+	clusterBURI = "https://clusterb.eastus.kusto.windows.net"
+)
+
+var _ = Describe("BatchExecute", func() {
+	var (
+		mu      sync.Mutex
+		jobs    []string
+		restore func()
+		config  schemav1alpha1.ExecutionConfiguration
+	)
+	clusters := []*kustoutils.KustoCluster{
+		{URI: clusterAURI, Client: &mockKusto{}},
+		{URI: clusterBURI, Client: &mockKusto{}},
+	}
+	targets := []schemav1alpha1.ClusterTargets{{DBs: []string{"db1"}}, {DBs: []string{"db2"}}}
+
+	BeforeEach(func() {
+		jobs = nil
+		// delta-kusto fails on cluster A
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+			job, err := ioutil.ReadFile(jobFile)
+			Expect(err).NotTo(HaveOccurred())
+			mu.Lock()
+			jobs = append(jobs, string(job))
+			mu.Unlock()
+			if strings.Contains(string(job), clusterAURI) {
+				return errors.New("delta-kusto failed")
+			}
+			return nil
+		})
+		kqlFile, err := kustoutils.StoreKQLSchemaToFile(".create-merge table T (a:string)")
+		Expect(err).NotTo(HaveOccurred())
+		rollbackFile, err := kustoutils.StoreKQLSchemaToFile(".create-merge table T0 (a:string)")
+		Expect(err).NotTo(HaveOccurred())
+		config = schemav1alpha1.ExecutionConfiguration{KQLFile: kqlFile, RollbackFile: rollbackFile}
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should roll back cluster B when cluster A fails in all or nothing mode", func() {
+		config.BatchMode = schemav1alpha1.BatchModeAllOrNothing
+		results, err := kustoutils.BatchExecute(context.Background(), clusters, targets, config)
+		Expect(err).To(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].URI).To(Equal(clusterAURI))
+		Expect(results[0].Err).To(MatchError("delta-kusto failed"))
+		Expect(results[1].URI).To(Equal(clusterBURI))
+		Expect(results[1].Err).NotTo(HaveOccurred())
+		Expect(results[1].Diff.DBs).To(Equal([]string{"db2"}))
+		Expect(results[1].RolledBack).To(BeTrue())
+
+		Expect(jobs).To(HaveLen(3))
+		rollback := jobs[2]
+		Expect(rollback).To(ContainSubstring(clusterBURI))
+		Expect(rollback).To(ContainSubstring("- filePath: " + config.RollbackFile))
+		Expect(rollback).To(ContainSubstring("database: db2"))
+	})
+	It("should return every result in best effort mode", func() {
+		config.BatchMode = schemav1alpha1.BatchModeBestEffort
+		results, err := kustoutils.BatchExecute(context.Background(), clusters, targets, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Err).To(HaveOccurred())
+		Expect(results[1].Err).NotTo(HaveOccurred())
+		Expect(results[1].RolledBack).To(BeFalse())
+		Expect(jobs).To(HaveLen(2))
+	})
+	It("should require a rollback file in all or nothing mode", func() {
+		config.BatchMode = schemav1alpha1.BatchModeAllOrNothing
+		config.RollbackFile = ""
+		_, err := kustoutils.BatchExecute(context.Background(), clusters, targets, config)
+		Expect(err).To(HaveOccurred())
+		Expect(jobs).To(BeEmpty())
+	})
+})
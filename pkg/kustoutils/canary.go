@@ -0,0 +1,118 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+const (
+	// CanaryModeKey is the `ConfigMap` key applying the schema to the canary database before the other databases.
+	CanaryModeKey = "canaryMode"
+	// CanaryDatabaseKey is the `ConfigMap` key of the canary database, one of the target databases.
+	CanaryDatabaseKey = "canaryDatabase"
+)
+
+// ErrCanaryFailed is returned when the canary database failed the execution or its verification,
+// the other databases were not touched.
+type ErrCanaryFailed struct {
+	DB  string
+	Err error
+}
+
+func (e ErrCanaryFailed) Error() string {
+	return fmt.Sprintf("canary database %s failed: %s", e.DB, e.Err.Error())
+}
+
+func (e ErrCanaryFailed) Unwrap() error {
+	return e.Err
+}
+
+// parseCanary sets the canary settings of the `ConfigMap` `data` on `config`,
+// the canary database must be one of the `dbs` the execution targets.
+func parseCanary(data map[string]string, dbs []string, config *schemav1alpha1.ExecutionConfiguration) error {
+	canary, ok := data[CanaryModeKey]
+	if !ok {
+		return nil
+	}
+	var err error
+	config.CanaryMode, err = strconv.ParseBool(canary)
+	if err != nil {
+		return fmt.Errorf("invalid %s value: %s", CanaryModeKey, canary)
+	}
+	if !config.CanaryMode {
+		return nil
+	}
+	config.CanaryDatabase = data[CanaryDatabaseKey]
+	for _, db := range dbs {
+		if db == config.CanaryDatabase {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q must be one of the target databases", CanaryDatabaseKey, config.CanaryDatabase)
+}
+
+// canaryFirst returns the `dbs` with the canary database of `config` first, and whether the execution has a canary.
+// An execution targeting only the canary database runs as usual.
+func canaryFirst(dbs []string, config schemav1alpha1.ExecutionConfiguration) ([]string, bool) {
+	if !config.CanaryMode || len(dbs) < 2 {
+		return dbs, false
+	}
+	ordered := []string{config.CanaryDatabase}
+	found := false
+	for _, db := range dbs {
+		if db == config.CanaryDatabase {
+			found = true
+			continue
+		}
+		ordered = append(ordered, db)
+	}
+	if !found {
+		return dbs, false
+	}
+	return ordered, true
+}
+
+// executeCanary applies the schema to the canary database, verified by the `PostApplyVerifiers`, and only once it
+// passed to the rest of the `targets`. A failed canary returns `ErrCanaryFailed` without touching the other databases.
+func (c *KustoCluster) executeCanary(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress DatabaseProgressFunc) (schemav1alpha1.ClusterTargets, error) {
+	config.CanaryMode = false
+	canary := config.CanaryDatabase
+	canaryConfig := config
+	jobFile, err := c.createJobFile([]string{canary}, config.KQLFile, config)
+	if err != nil {
+		return schemav1alpha1.ClusterTargets{}, err
+	}
+	canaryConfig.JobFile = jobFile
+	done, err := c.executeTraced(ctx, schemav1alpha1.ClusterTargets{DBs: []string{canary}}, canaryConfig, progress)
+	if err != nil {
+		return done, ErrCanaryFailed{DB: canary, Err: err}
+	}
+	if len(done.DBs) == 0 {
+		return done, ErrCanaryFailed{DB: canary, Err: fmt.Errorf("the canary was not applied")}
+	}
+
+	rest := make([]string, 0, len(targets.DBs)-1)
+	for _, db := range targets.DBs {
+		if db != canary {
+			rest = append(rest, db)
+		}
+	}
+	restConfig := config
+	restConfig.JobFile, err = c.createJobFile(rest, config.KQLFile, config)
+	if err != nil {
+		return done, err
+	}
+	// workload groups are cluster level - the canary execution synced them.
+	restConfig.WorkloadGroupsFile = ""
+	executed, err := c.executeTraced(ctx, schemav1alpha1.ClusterTargets{DBs: rest}, restConfig, progress)
+	for db, result := range executed.DBResults {
+		done.DBResults[db] = result
+	}
+	done.DBs = append(done.DBs, executed.DBs...)
+	return done, err
+}
@@ -0,0 +1,99 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Canary execution", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}}
+	var cfgMap *v1.ConfigMap
+	var restore func()
+	var jobs []string
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		jobs = nil
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+			job, err := ioutil.ReadFile(jobFile)
+			jobs = append(jobs, string(job))
+			return err
+		})
+		cfgMap = &v1.ConfigMap{Data: map[string]string{
+			"kql":                        ".create-merge table Events (Timestamp:datetime)",
+			kustoutils.CanaryModeKey:     "true",
+			kustoutils.CanaryDatabaseKey: "db2",
+		}}
+		cluster = &kustoutils.KustoCluster{
+			URI:                "https://mock.eastus.kusto.windows.net",
+			Client:             &scriptedKusto{mgmt: tablesHandler(map[string][]string{"db1": {"Events"}, "db2": {"Events"}, "db3": {"Events"}})},
+			PostApplyVerifiers: []kustoutils.PostApplyVerifier{kustoutils.TableExistsVerifier("Events")},
+		}
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should read the canary settings", func() {
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.CanaryMode).To(BeTrue())
+		Expect(exeCfg.CanaryDatabase).To(Equal("db2"))
+
+		cfgMap.Data[kustoutils.CanaryDatabaseKey] = "db4"
+		_, err = cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).To(MatchError(ContainSubstring("must be one of the target databases")))
+		cfgMap.Data[kustoutils.CanaryModeKey] = "maybe"
+		_, err = cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should continue with the other databases once the canary passed", func() {
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(Equal([]string{"db2", "db1", "db3"}))
+		Expect(done.DBResults).To(HaveLen(3))
+
+		Expect(jobs).To(HaveLen(2))
+		Expect(jobs[0]).To(ContainSubstring("database: db2"))
+		Expect(jobs[0]).NotTo(ContainSubstring("database: db1"))
+		Expect(jobs[1]).To(ContainSubstring("database: db1"))
+		Expect(jobs[1]).To(ContainSubstring("database: db3"))
+		Expect(jobs[1]).NotTo(ContainSubstring("database: db2"))
+	})
+	It("should halt without touching the other databases when the canary fails verification", func() {
+		cluster.Client = &scriptedKusto{mgmt: tablesHandler(map[string][]string{"db1": {"Events"}, "db3": {"Events"}})}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		done, err := cluster.Execute(targets, exeCfg)
+		canaryErr := kustoutils.ErrCanaryFailed{}
+		Expect(errors.As(err, &canaryErr)).To(BeTrue())
+		Expect(canaryErr.DB).To(Equal("db2"))
+		Expect(errors.As(err, &kustoutils.ErrVerificationFailed{})).To(BeTrue())
+		Expect(done.DBs).To(BeEmpty())
+		Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{"db2": schemav1alpha1.DBResultFailed}))
+		Expect(jobs).To(HaveLen(1))
+	})
+	It("should run the canary first when reporting progress", func() {
+		cluster.Client = &scriptedKusto{mgmt: tablesHandler(map[string][]string{"db1": {"Events"}, "db3": {"Events"}})}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		phases := map[string]schemav1alpha1.DBPhase{}
+		_, err = cluster.ExecuteWithProgress(context.Background(), targets, exeCfg, func(db string, phase schemav1alpha1.DBPhase) {
+			phases[db] = phase
+		})
+		Expect(errors.As(err, &kustoutils.ErrCanaryFailed{})).To(BeTrue())
+		Expect(phases).To(Equal(map[string]schemav1alpha1.DBPhase{"db2": schemav1alpha1.DBPhaseFailed}))
+		Expect(jobs).To(HaveLen(1))
+	})
+})
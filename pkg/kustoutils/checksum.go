@@ -0,0 +1,38 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// ComputeKQLChecksum returns the hex encoded sha256 of the `kql`.
+func ComputeKQLChecksum(kql string) string {
+	sum := sha256.Sum256([]byte(kql))
+	return hex.EncodeToString(sum[:])
+}
+
+// RenderedKQL returns the kql applied by the `config` - the schema, ingestion and partitioning policies, materialized views and workload groups files
+// as stored by `CreateExecConfiguration`, after the schema was downloaded from its `schemaURL`.
+func RenderedKQL(config schemav1alpha1.ExecutionConfiguration) (string, error) {
+	var kql strings.Builder
+	for _, file := range []string{config.KQLFile, config.IngestionPoliciesFile, config.PartitioningPoliciesFile, config.MaterializedViewsFile, config.WorkloadGroupsFile} {
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed reading the rendered kql %s", file)
+			return "", err
+		}
+		kql.Write(content)
+		kql.WriteString("\n")
+	}
+	return kql.String(), nil
+}
@@ -0,0 +1,36 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"os"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("KQL checksum", func() {
+	It("should compute the sha256 of the kql", func() {
+		Expect(kustoutils.ComputeKQLChecksum("")).To(Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+		Expect(kustoutils.ComputeKQLChecksum(".create table T (a:string)")).NotTo(Equal(kustoutils.ComputeKQLChecksum(".create table T (b:string)")))
+	})
+	It("should read the rendered kql of the configuration", func() {
+		cluster := &kustoutils.KustoCluster{URI: "https://testcluster.westeurope.kusto.windows.net", Client: &mockKusto{}}
+		cfgMap := &v1.ConfigMap{Data: map[string]string{
+			"kql":                           ".create-merge table T (a:string)",
+			kustoutils.IngestionPoliciesKey: ".alter table T policy streamingingestion enable",
+		}}
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		kql, err := kustoutils.RenderedKQL(exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kql).To(Equal(".create-merge table T (a:string)\n.alter table T policy streamingingestion enable\n"))
+
+		Expect(os.Remove(exeCfg.KQLFile)).To(Succeed())
+		_, err = kustoutils.RenderedKQL(exeCfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
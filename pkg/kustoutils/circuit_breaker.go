@@ -0,0 +1,157 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"errors"
+	"sync"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// CircuitState is the state of a `CircuitBreakerKustoCluster`
+type CircuitState int
+
+const (
+	// CircuitClosed calls pass through to the cluster
+	CircuitClosed CircuitState = iota
+	// CircuitOpen calls are blocked
+	CircuitOpen
+	// CircuitHalfOpen a single probe call is allowed
+	CircuitHalfOpen
+)
+
+// ErrCircuitOpen is returned for calls blocked by an open circuit breaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "Open"
+	case CircuitHalfOpen:
+		return "HalfOpen"
+	}
+	return "Closed"
+}
+
+// CircuitBreakerKustoCluster stops calling a degraded `KustoCluster` after consecutive failures.
+// After `FailureThreshold` consecutive errors the breaker opens for `OpenDuration`, then a single probe
+// call is allowed - a successful probe closes the breaker and a failed one opens it again.
+type CircuitBreakerKustoCluster struct {
+	*KustoCluster
+	FailureThreshold int
+	OpenDuration     time.Duration
+	// Gauge reports the current `CircuitState` of the breaker.
+	Gauge prometheus.Gauge
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreakerKustoCluster returns a closed `CircuitBreakerKustoCluster` for `cluster`.
+func NewCircuitBreakerKustoCluster(cluster *KustoCluster, failureThreshold int, openDuration time.Duration) *CircuitBreakerKustoCluster {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	cb := &CircuitBreakerKustoCluster{
+		KustoCluster:     cluster,
+		FailureThreshold: failureThreshold,
+		OpenDuration:     openDuration,
+		Gauge:            circuitBreakerState.WithLabelValues(cluster.URI),
+	}
+	cb.Gauge.Set(float64(CircuitClosed))
+	return cb
+}
+
+// State returns the current state of the breaker.
+func (c *CircuitBreakerKustoCluster) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh()
+	return c.state
+}
+
+// refresh moves an open breaker to half open once `OpenDuration` passed - must be called with the lock held.
+func (c *CircuitBreakerKustoCluster) refresh() {
+	if c.state == CircuitOpen && time.Now().Sub(c.openedAt) >= c.OpenDuration {
+		c.setState(CircuitHalfOpen)
+	}
+}
+
+func (c *CircuitBreakerKustoCluster) setState(state CircuitState) {
+	if c.state != state {
+		log.Info().Msgf("circuit breaker for %s moved from %s to %s", c.URI, c.state, state)
+	}
+	c.state = state
+	c.Gauge.Set(float64(state))
+}
+
+// allow reports if a call may proceed.
+func (c *CircuitBreakerKustoCluster) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refresh()
+	switch c.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if c.probing {
+			return ErrCircuitOpen
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call.
+func (c *CircuitBreakerKustoCluster) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+	if err == nil {
+		c.failures = 0
+		c.setState(CircuitClosed)
+		return
+	}
+	c.failures++
+	if c.state == CircuitHalfOpen || c.failures >= c.FailureThreshold {
+		c.openedAt = time.Now()
+		c.setState(CircuitOpen)
+	}
+}
+
+// AquireTargets filters the DBs in the cluster unless the breaker is open.
+func (c *CircuitBreakerKustoCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	if err := c.allow(); err != nil {
+		return schemav1alpha1.ClusterTargets{}, err
+	}
+	targets, err := c.KustoCluster.AquireTargets(filter)
+	c.record(err)
+	return targets, err
+}
+
+// ListDatabases lists kusto databases matching the regexp expression unless the breaker is open.
+func (c *CircuitBreakerKustoCluster) ListDatabases(expression string) ([]string, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	dbs, err := c.KustoCluster.ListDatabases(expression)
+	c.record(err)
+	return dbs, err
+}
+
+// Execute runs the `ExecutionConfiguration` on the provided targets unless the breaker is open.
+func (c *CircuitBreakerKustoCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	if err := c.allow(); err != nil {
+		return schemav1alpha1.ClusterTargets{}, err
+	}
+	done, err := c.KustoCluster.Execute(targets, config)
+	c.record(err)
+	return done, err
+}
@@ -0,0 +1,95 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("CircuitBreakerKustoCluster", func() {
+	const openDuration = 50 * time.Millisecond
+	var (
+		client  *scriptedKusto
+		breaker *kustoutils.CircuitBreakerKustoCluster
+		healthy bool
+	)
+
+	BeforeEach(func() {
+		healthy = false
+		client = &scriptedKusto{mgmt: func(db, stmt string) (*kusto.RowIterator, error) {
+			if !healthy {
+				return nil, errors.New("cluster is degraded")
+			}
+			return mockRows(table.Columns{{Name: "DatabaseName", Type: types.String}}, []string{"db1"})
+		}}
+		cluster := &kustoutils.KustoCluster{URI: "https://breaker.eastus.kusto.windows.net", Client: client}
+		breaker = kustoutils.NewCircuitBreakerKustoCluster(cluster, 3, openDuration)
+	})
+
+	It("should open after consecutive failures and block calls", func() {
+		for i := 0; i < 3; i++ {
+			Expect(breaker.State()).To(Equal(kustoutils.CircuitClosed))
+			_, err := breaker.ListDatabases("")
+			Expect(err).To(MatchError("cluster is degraded"))
+		}
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitOpen))
+		Expect(testutil.ToFloat64(breaker.Gauge)).To(Equal(float64(kustoutils.CircuitOpen)))
+
+		calls := len(client.stmts)
+		_, err := breaker.AquireTargets(schemav1alpha1.TargetFilter{DB: "db"})
+		Expect(err).To(Equal(kustoutils.ErrCircuitOpen))
+		_, err = breaker.Execute(schemav1alpha1.ClusterTargets{}, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).To(Equal(kustoutils.ErrCircuitOpen))
+		Expect(client.stmts).To(HaveLen(calls))
+	})
+	It("should reset the failure count on success", func() {
+		for i := 0; i < 2; i++ {
+			_, _ = breaker.ListDatabases("")
+		}
+		healthy = true
+		_, err := breaker.ListDatabases("")
+		Expect(err).NotTo(HaveOccurred())
+		healthy = false
+		for i := 0; i < 2; i++ {
+			_, _ = breaker.ListDatabases("")
+		}
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitClosed))
+	})
+	It("should close after a successful probe", func() {
+		for i := 0; i < 3; i++ {
+			_, _ = breaker.ListDatabases("")
+		}
+		time.Sleep(openDuration)
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitHalfOpen))
+		Expect(testutil.ToFloat64(breaker.Gauge)).To(Equal(float64(kustoutils.CircuitHalfOpen)))
+
+		healthy = true
+		dbs, err := breaker.ListDatabases("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(Equal([]string{"db1"}))
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitClosed))
+		Expect(testutil.ToFloat64(breaker.Gauge)).To(Equal(float64(kustoutils.CircuitClosed)))
+	})
+	It("should open again after a failed probe", func() {
+		for i := 0; i < 3; i++ {
+			_, _ = breaker.ListDatabases("")
+		}
+		time.Sleep(openDuration)
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitHalfOpen))
+		_, err := breaker.ListDatabases("")
+		Expect(err).To(MatchError("cluster is degraded"))
+		Expect(breaker.State()).To(Equal(kustoutils.CircuitOpen))
+		_, err = breaker.ListDatabases("")
+		Expect(err).To(Equal(kustoutils.ErrCircuitOpen))
+	})
+})
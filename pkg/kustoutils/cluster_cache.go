@@ -0,0 +1,147 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	kustoerrors "github.com/Azure/azure-kusto-go/kusto/data/errors"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultClusterClientTTL is the time a cached cluster client is reused before it is recreated.
+const DefaultClusterClientTTL = 30 * time.Minute
+
+// ClientFactory creates the `KustoCluster` clients of the reconcilers.
+type ClientFactory interface {
+	// GetOrCreateCluster returns a cluster authorized with the operator identity.
+	GetOrCreateCluster(uri string) *KustoCluster
+	// GetOrCreateTenantCluster returns a cluster authorized with the `creds` of a managed tenant.
+	GetOrCreateTenantCluster(uri string, creds TenantCredentials) *KustoCluster
+}
+
+var _ ClientFactory = &ClusterClientCache{}
+
+// ClusterClientCache reuses the `KustoCluster` clients, with their connections and tokens, across reconciles.
+// A client is evicted once its `TTL` passes or when the cluster rejects its token (401).
+type ClusterClientCache struct {
+	TTL time.Duration
+	// Telemetry is set on the created clusters (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+
+	mu       sync.RWMutex
+	clusters map[string]cachedCluster
+	// newCluster and newTenantCluster create the clients, replaced in tests.
+	newCluster       func(uri string) *KustoCluster
+	newTenantCluster func(uri string, creds TenantCredentials) *KustoCluster
+	now              func() time.Time
+}
+
+type cachedCluster struct {
+	cluster *KustoCluster
+	created time.Time
+}
+
+// NewClusterClientCache returns an empty cache whose clients expire after `ttl`.
+// A non positive `ttl` uses the `DefaultClusterClientTTL`.
+func NewClusterClientCache(ttl time.Duration) *ClusterClientCache {
+	if ttl <= 0 {
+		ttl = DefaultClusterClientTTL
+	}
+	return &ClusterClientCache{
+		TTL:              ttl,
+		clusters:         make(map[string]cachedCluster),
+		newCluster:       NewKustoCluster,
+		newTenantCluster: NewKustoClusterWithCredentials,
+		now:              time.Now,
+	}
+}
+
+// GetOrCreateCluster returns the cached cluster for `uri`, creating a new one if it is missing or expired.
+func (c *ClusterClientCache) GetOrCreateCluster(uri string) *KustoCluster {
+	return c.getOrCreate(uri, uri, func() *KustoCluster { return c.newCluster(uri) })
+}
+
+// GetOrCreateTenantCluster returns the cached cluster for `uri` authorized with `creds`, creating a new one if it is missing or expired.
+// Every tenant, client and secret gets its own client, so schemas of different tenants never share credentials,
+// a wrong secret never gets an authorized client and a rotated secret is used right away.
+func (c *ClusterClientCache) GetOrCreateTenantCluster(uri string, creds TenantCredentials) *KustoCluster {
+	secret := sha256.Sum256([]byte(creds.ClientSecret))
+	key := uri + "#" + creds.TenantID + "#" + creds.ClientID + "#" + hex.EncodeToString(secret[:])
+	return c.getOrCreate(key, uri, func() *KustoCluster { return c.newTenantCluster(uri, creds) })
+}
+
+func (c *ClusterClientCache) getOrCreate(key, uri string, create func() *KustoCluster) *KustoCluster {
+	c.mu.RLock()
+	entry, ok := c.clusters[key]
+	c.mu.RUnlock()
+	if ok && c.now().Sub(entry.created) < c.TTL {
+		return entry.cluster
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// another reconcile may have refreshed the client while waiting for the lock.
+	entry, ok = c.clusters[key]
+	if ok && c.now().Sub(entry.created) < c.TTL {
+		return entry.cluster
+	}
+	log.Debug().Msgf("creating a kusto client for %s", uri)
+	cluster := create()
+	cluster.SetTelemetry(c.Telemetry)
+	if cluster.Client != nil {
+		cluster.Client = &evictingClient{QueryClient: cluster.Client, evict: func() { c.evict(key, cluster) }}
+	}
+	c.clusters[key] = cachedCluster{cluster: cluster, created: c.now()}
+	return cluster
+}
+
+// Evict removes the cached cluster for `uri`, the next `GetOrCreateCluster` creates a new client.
+func (c *ClusterClientCache) Evict(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clusters, uri)
+}
+
+// evict removes `cluster` from the cache unless it was already replaced.
+func (c *ClusterClientCache) evict(key string, cluster *KustoCluster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.clusters[key]; ok && entry.cluster == cluster {
+		log.Info().Msgf("kusto client for %s is unauthorized - evicting it from the cache", cluster.URI)
+		delete(c.clusters, key)
+	}
+}
+
+// evictingClient evicts the cached cluster when a call fails with 401.
+type evictingClient struct {
+	QueryClient
+	evict func()
+}
+
+func (e *evictingClient) Query(ctx context.Context, db string, query kusto.Stmt, options ...kusto.QueryOption) (*kusto.RowIterator, error) {
+	iter, err := e.QueryClient.Query(ctx, db, query, options...)
+	e.check(err)
+	return iter, err
+}
+
+func (e *evictingClient) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	iter, err := e.QueryClient.Mgmt(ctx, db, query, options...)
+	e.check(err)
+	return iter, err
+}
+
+func (e *evictingClient) check(err error) {
+	var httpErr *kustoerrors.HttpError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+		e.evict()
+	}
+}
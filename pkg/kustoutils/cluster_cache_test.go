@@ -0,0 +1,94 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	kustoerrors "github.com/Azure/azure-kusto-go/kusto/data/errors"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cluster client cache", func() {
+	const uri = "https://cluster1.westeurope.kusto.windows.net"
+	var now time.Time
+	var created int
+	var status int
+	var cache *kustoutils.ClusterClientCache
+
+	BeforeEach(func() {
+		now = time.Now()
+		created = 0
+		status = http.StatusOK
+		cache = kustoutils.NewTestClusterClientCache(time.Minute, func(uri string) *kustoutils.KustoCluster {
+			created++
+			return &kustoutils.KustoCluster{URI: uri, Client: &scriptedKusto{mgmt: func(db, stmt string) (*kusto.RowIterator, error) {
+				if status != http.StatusOK {
+					return nil, kustoerrors.HTTP(kustoerrors.OpMgmt, http.StatusText(status), status, ioutil.NopCloser(strings.NewReader("")), "")
+				}
+				return mockRows(nil)
+			}}}
+		}, func() time.Time { return now })
+	})
+
+	It("should return the same cluster for the same uri", func() {
+		cluster := cache.GetOrCreateCluster(uri)
+		Expect(cache.GetOrCreateCluster(uri)).To(BeIdenticalTo(cluster))
+		Expect(cache.GetOrCreateCluster("https://cluster2.westeurope.kusto.windows.net")).NotTo(BeIdenticalTo(cluster))
+		Expect(created).To(Equal(2))
+	})
+	It("should create a new cluster after the ttl", func() {
+		cluster := cache.GetOrCreateCluster(uri)
+		now = now.Add(59 * time.Second)
+		Expect(cache.GetOrCreateCluster(uri)).To(BeIdenticalTo(cluster))
+		now = now.Add(time.Second)
+		Expect(cache.GetOrCreateCluster(uri)).NotTo(BeIdenticalTo(cluster))
+		Expect(created).To(Equal(2))
+	})
+	It("should evict unauthorized clients", func() {
+		cluster := cache.GetOrCreateCluster(uri)
+		status = http.StatusForbidden
+		_, err := cluster.ListDatabases("")
+		Expect(err).To(HaveOccurred())
+		Expect(cache.GetOrCreateCluster(uri)).To(BeIdenticalTo(cluster))
+
+		status = http.StatusUnauthorized
+		_, err = cluster.ListDatabases("")
+		Expect(err).To(HaveOccurred())
+		Expect(cache.GetOrCreateCluster(uri)).NotTo(BeIdenticalTo(cluster))
+		Expect(created).To(Equal(2))
+	})
+	It("should keep a cluster for every tenant", func() {
+		tenants := []string{}
+		kustoutils.SetTenantClusterFactory(cache, func(uri string, creds kustoutils.TenantCredentials) *kustoutils.KustoCluster {
+			tenants = append(tenants, creds.TenantID)
+			return &kustoutils.KustoCluster{URI: uri, TenantID: creds.TenantID, Client: &mockKusto{}}
+		})
+		tenantA := cache.GetOrCreateTenantCluster(uri, kustoutils.TenantCredentials{TenantID: "tenant-a", ClientID: "client-a"})
+		tenantB := cache.GetOrCreateTenantCluster(uri, kustoutils.TenantCredentials{TenantID: "tenant-b", ClientID: "client-b"})
+		Expect(tenantA).NotTo(BeIdenticalTo(tenantB))
+		Expect(cache.GetOrCreateTenantCluster(uri, kustoutils.TenantCredentials{TenantID: "tenant-a", ClientID: "client-a"})).To(BeIdenticalTo(tenantA))
+		Expect(cache.GetOrCreateCluster(uri)).NotTo(BeIdenticalTo(tenantA))
+		Expect(tenants).To(Equal([]string{"tenant-a", "tenant-b"}))
+	})
+	It("should keep a cluster for every client secret", func() {
+		secrets := []string{}
+		kustoutils.SetTenantClusterFactory(cache, func(uri string, creds kustoutils.TenantCredentials) *kustoutils.KustoCluster {
+			secrets = append(secrets, creds.ClientSecret)
+			return &kustoutils.KustoCluster{URI: uri, TenantID: creds.TenantID, Client: &mockKusto{}}
+		})
+		creds := kustoutils.TenantCredentials{TenantID: "tenant-a", ClientID: "client-a", ClientSecret: "secret"}
+		authorized := cache.GetOrCreateTenantCluster(uri, creds)
+		wrong := creds
+		wrong.ClientSecret = "wrong"
+		Expect(cache.GetOrCreateTenantCluster(uri, wrong)).NotTo(BeIdenticalTo(authorized))
+		Expect(cache.GetOrCreateTenantCluster(uri, creds)).To(BeIdenticalTo(authorized))
+		Expect(secrets).To(Equal([]string{"secret", "wrong"}))
+	})
+})
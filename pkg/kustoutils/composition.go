@@ -0,0 +1,77 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"strings"
+)
+
+// KQLSource is the kql script of a single source of a composed schema.
+type KQLSource struct {
+	Name string
+	KQL  string
+}
+
+// ErrDuplicateTable is returned when two sources of a composed schema define the same table.
+type ErrDuplicateTable struct {
+	Source1   string
+	Source2   string
+	TableName string
+}
+
+func (e ErrDuplicateTable) Error() string {
+	return fmt.Sprintf("table %s is defined in both %s and %s", e.TableName, e.Source1, e.Source2)
+}
+
+// ComposeKQL concatenates the kql of the sources into a single script, each source preceded by a
+// `// -- source: <name> --` delimiter. The sources named in `order` are merged first in that order,
+// followed by the others in their original order. A table may only be defined by a single source.
+func ComposeKQL(sources []KQLSource, order []string) (string, error) {
+	ordered, err := orderSources(sources, order)
+	if err != nil {
+		return "", err
+	}
+	owners := map[string]string{}
+	var merged strings.Builder
+	for _, source := range ordered {
+		tables, _, _ := ParseKQLObjects(source.KQL)
+		for _, table := range tables {
+			if owner, found := owners[table]; found && owner != source.Name {
+				return "", ErrDuplicateTable{Source1: owner, Source2: source.Name, TableName: table}
+			}
+			owners[table] = source.Name
+		}
+		fmt.Fprintf(&merged, "// -- source: %s --\n%s\n\n", source.Name, strings.TrimSpace(source.KQL))
+	}
+	return strings.TrimSuffix(merged.String(), "\n"), nil
+}
+
+// orderSources returns the sources named in `order` in that order, followed by the remaining sources.
+func orderSources(sources []KQLSource, order []string) ([]KQLSource, error) {
+	byName := make(map[string]int, len(sources))
+	for i, source := range sources {
+		if _, found := byName[source.Name]; found {
+			return nil, fmt.Errorf("source %s is listed more than once", source.Name)
+		}
+		byName[source.Name] = i
+	}
+	ordered := make([]KQLSource, 0, len(sources))
+	placed := make(map[string]bool, len(sources))
+	for _, name := range order {
+		i, found := byName[name]
+		if !found {
+			return nil, fmt.Errorf("order references unknown source %s", name)
+		}
+		if !placed[name] {
+			ordered = append(ordered, sources[i])
+			placed[name] = true
+		}
+	}
+	for _, source := range sources {
+		if !placed[source.Name] {
+			ordered = append(ordered, source)
+		}
+	}
+	return ordered, nil
+}
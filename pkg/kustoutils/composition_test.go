@@ -0,0 +1,54 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"errors"
+
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema composition", func() {
+	sources := []kustoutils.KQLSource{
+		{Name: "orders", KQL: ".create-merge table Orders (id:string)\n"},
+		{Name: "billing", KQL: ".create-merge table Invoices (id:string)\n.create-or-alter function LatestInvoices() { Invoices }"},
+		{Name: "shared", KQL: ".create-or-alter function Today() { now() }"},
+	}
+
+	It("should merge the sources with delimiters", func() {
+		merged, err := kustoutils.ComposeKQL(sources, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(merged).To(Equal(`// -- source: orders --
+.create-merge table Orders (id:string)
+
+// -- source: billing --
+.create-merge table Invoices (id:string)
+.create-or-alter function LatestInvoices() { Invoices }
+
+// -- source: shared --
+.create-or-alter function Today() { now() }
+`))
+	})
+	It("should merge the ordered sources first", func() {
+		merged, err := kustoutils.ComposeKQL(sources, []string{"shared", "billing"})
+		Expect(err).NotTo(HaveOccurred())
+		tables, functions, err := kustoutils.ParseKQLObjects(merged)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tables).To(Equal([]string{"Invoices", "Orders"}))
+		Expect(functions).To(Equal([]string{"Today", "LatestInvoices"}))
+	})
+	It("should reject an order of unknown sources", func() {
+		_, err := kustoutils.ComposeKQL(sources, []string{"payments"})
+		Expect(err).To(MatchError("order references unknown source payments"))
+	})
+	It("should reject tables defined by two sources", func() {
+		duplicated := append(sources, kustoutils.KQLSource{Name: "legacy", KQL: ".create table ['Orders'] (id:string, legacy:bool)"})
+		_, err := kustoutils.ComposeKQL(duplicated, nil)
+		duplicate := kustoutils.ErrDuplicateTable{}
+		Expect(errors.As(err, &duplicate)).To(BeTrue())
+		Expect(duplicate).To(Equal(kustoutils.ErrDuplicateTable{Source1: "orders", Source2: "legacy", TableName: "Orders"}))
+		Expect(err.Error()).To(Equal("table Orders is defined in both orders and legacy"))
+	})
+})
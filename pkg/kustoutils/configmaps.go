@@ -4,28 +4,63 @@ package kustoutils
 // Licensed under the MIT License.
 import (
 	"os"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	defaultStoreAttempts = 3
+	defaultStoreDelay    = 500 * time.Millisecond
+)
+
+// writeFile writes the schema files, it is replaced in tests to simulate IO errors.
+var writeFile = os.WriteFile
+
 // StoreKQLSchemaToFile stores the data given from the `ConfigMap` in a file
 func StoreKQLSchemaToFile(data string) (string, error) {
+	return StoreKQLSchemaToFileWithRetry(data, defaultStoreAttempts, defaultStoreDelay)
+}
 
-	log.Debug().Msgf("config map data: %v", data)
+// StoreKQLSchemaToFileWithRetry stores the `kql` in a file, retrying up to `maxAttempts` times with `delay` between attempts.
+func StoreKQLSchemaToFileWithRetry(kql string, maxAttempts int, delay time.Duration) (string, error) {
 
+	log.Debug().Msgf("config map data: %v", kql)
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	var name string
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			log.Debug().Err(err).Msgf("failed storing the kql - attempt %d of %d in %s", attempt, maxAttempts, delay)
+			time.Sleep(delay)
+		}
+		name, err = storeKQLSchemaToFile(kql)
+		if err == nil {
+			log.Debug().Msgf("wrote %d bytes to %s", len(kql), name)
+			return name, nil
+		}
+	}
+	log.Error().Err(err).Msg("failed to store the kql to a file")
+	return "", err
+}
+
+// storeKQLSchemaToFile makes a single attempt at storing the `kql` in a new temporary file.
+func storeKQLSchemaToFile(kql string) (string, error) {
 	jobPath := "/tmp"
 	f, err := os.CreateTemp(jobPath, "schema-*.kql")
 	if err != nil {
-		log.Error().Err(err).Msg("failed to open file")
 		return "", err
 	}
-	defer f.Close()
-	n, err := f.WriteString(data)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to open file")
+	name := f.Name()
+	if err = f.Close(); err != nil {
 		return "", err
 	}
-	log.Debug().Msgf("wrote %d bytes to %s", n, f.Name())
-	return f.Name(), err
-
+	if err = writeFile(name, []byte(kql), 0600); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
 }
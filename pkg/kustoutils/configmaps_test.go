@@ -0,0 +1,51 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storing the kql", func() {
+	const kql = ".create-merge table Events (Timestamp:datetime)"
+	var restore func()
+	var attempts int
+	var failures int
+
+	BeforeEach(func() {
+		attempts = 0
+		restore = kustoutils.SetWriteFile(func(name string, data []byte, perm os.FileMode) error {
+			attempts++
+			if attempts <= failures {
+				return errors.New("input/output error")
+			}
+			return os.WriteFile(name, data, perm)
+		})
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should retry failed writes", func() {
+		failures = 2
+		name, err := kustoutils.StoreKQLSchemaToFileWithRetry(kql, 3, time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+		data, err := ioutil.ReadFile(name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(kql))
+	})
+	It("should fail after the last attempt", func() {
+		failures = 3
+		_, err := kustoutils.StoreKQLSchemaToFileWithRetry(kql, 3, time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+	})
+})
@@ -0,0 +1,82 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/rs/zerolog/log"
+)
+
+// ConnectionCacheTTL is the time a `TestConnection` result is reused, so the probes don't hammer the cluster.
+const ConnectionCacheTTL = 60 * time.Second
+
+// ClusterInfo describes a kusto cluster, as reported by `.show cluster details`.
+type ClusterInfo struct {
+	Version   string
+	Region    string
+	SKU       string
+	NodeCount int
+}
+
+// connectionCache holds the last `TestConnection` result of a cluster.
+type connectionCache struct {
+	mu     sync.Mutex
+	info   ClusterInfo
+	err    error
+	expiry time.Time
+}
+
+// TestConnection verifies the operator can communicate with the cluster by running `.show cluster details`,
+// within the ping timeout. The result, successful or not, is reused for the `ConnectionCacheTTL`.
+func (c *KustoCluster) TestConnection(ctx context.Context) (ClusterInfo, error) {
+	c.connection.mu.Lock()
+	defer c.connection.mu.Unlock()
+	if time.Now().Before(c.connection.expiry) {
+		return c.connection.info, c.connection.err
+	}
+	info, err := c.showClusterDetails(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed testing the connection to %s", c.URI)
+	}
+	c.connection.info, c.connection.err = info, err
+	c.connection.expiry = time.Now().Add(ConnectionCacheTTL)
+	return info, err
+}
+
+// showClusterDetails reads the cluster info from `.show cluster details`, which returns a row per node.
+func (c *KustoCluster) showClusterDetails(ctx context.Context) (ClusterInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.pingTimeout())
+	defer cancel()
+	iter, err := c.Client.Mgmt(ctx, "", newUnsafeStmt(".show cluster details"))
+	if err != nil {
+		return ClusterInfo{}, err
+	}
+	defer iter.Stop()
+
+	info := ClusterInfo{}
+	err = iter.Do(
+		func(row *table.Row) error {
+			info.NodeCount++
+			for i, col := range row.ColumnTypes {
+				value := row.Values[i].String()
+				if value == "" {
+					continue
+				}
+				switch col.Name {
+				case "ProductVersion":
+					info.Version = value
+				case "Region", "Location":
+					info.Region = value
+				case "SkuName", "Sku":
+					info.SKU = value
+				}
+			}
+			return nil
+		},
+	)
+	return info, err
+}
@@ -0,0 +1,87 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// clusterDetailsHandler answers `.show cluster details` with a row per node, failing with `err` when set.
+func clusterDetailsHandler(err *error) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		if *err != nil {
+			return nil, *err
+		}
+		if stmt != ".show cluster details" {
+			return nil, fmt.Errorf("unexpected command %s", stmt)
+		}
+		columns := table.Columns{
+			{Name: "NodeId", Type: types.String},
+			{Name: "Address", Type: types.String},
+			{Name: "ProductVersion", Type: types.String},
+			{Name: "Region", Type: types.String},
+			{Name: "SkuName", Type: types.String},
+		}
+		return mockRows(columns,
+			[]string{"node-0", "net.tcp://10.0.0.4:23107/", "KustoRelease_2022.05.02.1", "westeurope", "Standard_E8ads_v5"},
+			[]string{"node-1", "net.tcp://10.0.0.5:23107/", "KustoRelease_2022.05.02.1", "westeurope", "Standard_E8ads_v5"},
+			[]string{"node-2", "net.tcp://10.0.0.6:23107/", "KustoRelease_2022.05.02.1", "westeurope", "Standard_E8ads_v5"},
+		)
+	}
+}
+
+var _ = Describe("Test connection", func() {
+	ctx := context.Background()
+
+	It("should parse the cluster details", func() {
+		var err error
+		client := &scriptedKusto{mgmt: clusterDetailsHandler(&err)}
+		cluster := &kustoutils.KustoCluster{URI: "https://cluster1.westeurope.kusto.windows.net", Client: client}
+		info, err := cluster.TestConnection(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(Equal(kustoutils.ClusterInfo{
+			Version:   "KustoRelease_2022.05.02.1",
+			Region:    "westeurope",
+			SKU:       "Standard_E8ads_v5",
+			NodeCount: 3,
+		}))
+	})
+	It("should reuse the result for the cache ttl", func() {
+		var err error
+		client := &scriptedKusto{mgmt: clusterDetailsHandler(&err)}
+		cluster := &kustoutils.KustoCluster{URI: "https://cluster1.westeurope.kusto.windows.net", Client: client}
+		_, err = cluster.TestConnection(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		info, err := cluster.TestConnection(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.NodeCount).To(Equal(3))
+		Expect(client.stmts).To(HaveLen(1))
+		Expect(kustoutils.ConnectionCacheTTL).To(Equal(60 * time.Second))
+	})
+	It("should fail on an unreachable cluster", func() {
+		failure := fmt.Errorf("unreachable")
+		client := &scriptedKusto{mgmt: clusterDetailsHandler(&failure)}
+		cluster := &kustoutils.KustoCluster{URI: "https://cluster1.westeurope.kusto.windows.net", Client: client}
+		_, err := cluster.TestConnection(ctx)
+		Expect(err).To(MatchError("unreachable"))
+
+		failure = nil
+		_, err = cluster.TestConnection(ctx)
+		Expect(err).To(MatchError("unreachable"))
+		Expect(client.stmts).To(HaveLen(1))
+	})
+	It("should time out with the ping timeout", func() {
+		cluster := &kustoutils.KustoCluster{Client: &unreachableKusto{}, PingTimeout: 20 * time.Millisecond}
+		_, err := cluster.TestConnection(ctx)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+	})
+})
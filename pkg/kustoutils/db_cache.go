@@ -0,0 +1,60 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"sync"
+	"time"
+)
+
+// DefaultDatabaseCacheTTL is the time a listed set of databases is reused, unless `DatabaseCacheTTL` is set.
+const DefaultDatabaseCacheTTL = 60 * time.Second
+
+// dbListCache holds the results of `ListDatabases` per regexp expression.
+type dbListCache struct {
+	mu      sync.Mutex
+	entries map[string]dbListEntry
+}
+
+type dbListEntry struct {
+	dbs    []string
+	expiry time.Time
+}
+
+// get returns a copy of the fresh cached list for `expression`.
+func (d *dbListCache) get(expression string) ([]string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.entries[expression]
+	if !ok || !time.Now().Before(entry.expiry) {
+		return nil, false
+	}
+	return append([]string{}, entry.dbs...), true
+}
+
+func (d *dbListCache) set(expression string, dbs []string, ttl time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.entries == nil {
+		d.entries = make(map[string]dbListEntry)
+	}
+	d.entries[expression] = dbListEntry{dbs: append([]string{}, dbs...), expiry: time.Now().Add(ttl)}
+}
+
+func (d *dbListCache) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = nil
+}
+
+// ClearDatabaseCache drops the cached database lists, the next `ListDatabases` queries the cluster.
+func (c *KustoCluster) ClearDatabaseCache() {
+	c.dbListCache.clear()
+}
+
+func (c *KustoCluster) databaseCacheTTL() time.Duration {
+	if c.DatabaseCacheTTL > 0 {
+		return c.DatabaseCacheTTL
+	}
+	return DefaultDatabaseCacheTTL
+}
@@ -0,0 +1,68 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Database list cache", func() {
+	var client *scriptedKusto
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		client = &scriptedKusto{mgmt: func(db, stmt string) (*kusto.RowIterator, error) {
+			return mockRows(table.Columns{{Name: "DatabaseName", Type: types.String}}, []string{"tenant_1"}, []string{"tenant_2"})
+		}}
+		cluster = &kustoutils.KustoCluster{Client: client}
+	})
+
+	It("should reuse the listed databases", func() {
+		dbs, err := cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(Equal([]string{"tenant_1", "tenant_2"}))
+		dbs, err = cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(Equal([]string{"tenant_1", "tenant_2"}))
+		Expect(countPrefix(client.stmts, ".show databases")).To(Equal(1))
+
+		dbs, err = cluster.ListDatabases("_2$")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(Equal([]string{"tenant_2"}))
+		Expect(countPrefix(client.stmts, ".show databases")).To(Equal(2))
+	})
+	It("should query the cluster once the entry expires", func() {
+		cluster.DatabaseCacheTTL = 10 * time.Millisecond
+		_, err := cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(20 * time.Millisecond)
+		_, err = cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".show databases")).To(Equal(2))
+	})
+	It("should query the cluster after a database is created", func() {
+		_, err := cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		client.mgmt = existingDBsHandler()
+		Expect(cluster.EnsureDatabase(context.Background(), "tenant_3", "", "")).To(Succeed())
+		_, err = cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".show databases | project")).To(Equal(2))
+	})
+	It("should query the cluster after the cache is cleared", func() {
+		_, err := cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		cluster.ClearDatabaseCache()
+		_, err = cluster.ListDatabases("tenant_")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".show databases")).To(Equal(2))
+	})
+})
@@ -0,0 +1,197 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// DeltaResult is the outcome of a delta-kusto job passed to the `PostProcess` of the plugins.
+type DeltaResult struct {
+	JobID string
+	// JobFile is the path of the job configuration file delta-kusto ran on.
+	JobFile   string
+	StartedAt time.Time
+	Duration  time.Duration
+	// Output is what delta-kusto wrote to its standard output.
+	Output []byte
+	// ExitCode is the exit code of delta-kusto, -1 when it failed without exiting.
+	ExitCode int
+	// Err is the error of the job, nil when it succeeded.
+	Err error
+}
+
+// DeltaPlugin processes the delta-kusto jobs of a `Wrapper`, e.g. to inject cluster specific variables into the job file.
+type DeltaPlugin interface {
+	// PreProcess runs before delta-kusto on the job file, an error aborts the job.
+	PreProcess(jobFilePath string) error
+	// PostProcess runs once delta-kusto exited, an error fails the job.
+	PostProcess(result *DeltaResult) error
+}
+
+// RegisterPlugin adds `p` to the plugins run around every delta-kusto job of the wrapper, in the registration order.
+// The plugins are registered before the wrapper runs its first job.
+func (w *Wrapper) RegisterPlugin(p DeltaPlugin) {
+	w.plugins = append(w.plugins, p)
+}
+
+// DeltaWrapper returns the delta-kusto wrapper running the jobs of the cluster, e.g. to register plugins.
+func (c *KustoCluster) DeltaWrapper() *Wrapper {
+	if c.wrapper == nil {
+		c.wrapper = NewDeltaWrapper()
+	}
+	return c.wrapper
+}
+
+// SetTelemetry sets the telemetry of the cluster and of its delta-kusto jobs.
+// The telemetry is set before the cluster runs its first job.
+func (c *KustoCluster) SetTelemetry(t *telemetry.SchemaOperatorTelemetry) {
+	c.Telemetry = t
+	c.DeltaWrapper().Telemetry = t
+}
+
+// runDeltaJob runs the delta-kusto job like `runPluggedDeltaJob`, traced and measured by the wrapper telemetry.
+func runDeltaJob(ctx context.Context, w *Wrapper, jobID, dir, deltaCfgfile, resultDir string) error {
+	t := w.telemetry()
+	ctx = t.StartSpan(ctx, "delta-kusto")
+	start := time.Now()
+	err := runPluggedDeltaJob(ctx, w, jobID, dir, deltaCfgfile, resultDir)
+	duration := time.Since(start)
+	t.ObserveDeltaJob(duration, err)
+	t.EndSpan(ctx, err)
+	t.Log().Debug().Str("job", jobID).Dur("duration", duration).Err(err).Msg("delta-kusto job done")
+	return err
+}
+
+// runPluggedDeltaJob runs the delta-kusto job like `runDeltaKusto` between the `PreProcess` and `PostProcess` of the wrapper plugins.
+// The first failing `PreProcess` aborts the job, the `PostProcess` of all the plugins run even when the job failed.
+// The result of the job is recorded in `resultDir` when set (see `ExecutionResult`).
+func runPluggedDeltaJob(ctx context.Context, w *Wrapper, jobID, dir, deltaCfgfile, resultDir string) error {
+	var plugins []DeltaPlugin
+	if w != nil {
+		plugins = w.plugins
+	}
+	if len(plugins) == 0 && resultDir == "" {
+		return runDeltaKusto(ctx, w, jobID, dir, deltaCfgfile, nil)
+	}
+	jobFile := deltaCfgfile
+	if dir != "" && !filepath.IsAbs(jobFile) {
+		jobFile = filepath.Join(dir, jobFile)
+	}
+	for _, plugin := range plugins {
+		if err := plugin.PreProcess(jobFile); err != nil {
+			log.Error().Err(err).Msgf("delta plugin aborted job %s", jobID)
+			return fmt.Errorf("pre-processing job %s: %w", jobID, err)
+		}
+	}
+	output := &bytes.Buffer{}
+	start := time.Now()
+	err := runDeltaKusto(ctx, w, jobID, dir, deltaCfgfile, output)
+	result := &DeltaResult{
+		JobID:     jobID,
+		JobFile:   jobFile,
+		StartedAt: start,
+		Duration:  time.Since(start),
+		Output:    output.Bytes(),
+		ExitCode:  exitCode(err),
+		Err:       err,
+	}
+	for _, plugin := range plugins {
+		if postErr := plugin.PostProcess(result); postErr != nil {
+			log.Error().Err(postErr).Msgf("delta plugin failed post-processing job %s", jobID)
+			if err == nil {
+				err = fmt.Errorf("post-processing job %s: %w", jobID, postErr)
+			}
+		}
+	}
+	if resultDir != "" {
+		if recordErr := recordDeltaResult(resultDir, result); recordErr != nil {
+			log.Error().Err(recordErr).Msgf("failed recording the result of job %s", jobID)
+		}
+	}
+	return err
+}
+
+// LoggingPlugin logs every delta-kusto job and its outcome.
+type LoggingPlugin struct {
+	Logger zerolog.Logger
+}
+
+// PreProcess logs the job file about to run.
+func (p LoggingPlugin) PreProcess(jobFilePath string) error {
+	p.Logger.Info().Str("jobFile", jobFilePath).Msg("running delta-kusto")
+	return nil
+}
+
+// PostProcess logs the duration and error of the job.
+func (p LoggingPlugin) PostProcess(result *DeltaResult) error {
+	if result.Err != nil {
+		p.Logger.Error().Err(result.Err).Str("job", result.JobID).Dur("duration", result.Duration).Msg("delta-kusto failed")
+		return nil
+	}
+	p.Logger.Info().Str("job", result.JobID).Dur("duration", result.Duration).Msg("delta-kusto done")
+	return nil
+}
+
+// ChecksumPlugin fails the jobs whose job file changed while delta-kusto ran on it.
+// Register it after the plugins rewriting the job file, so it records their output.
+type ChecksumPlugin struct {
+	mu   sync.Mutex
+	sums map[string]string
+}
+
+// PreProcess records the checksum of the job file.
+func (p *ChecksumPlugin) PreProcess(jobFilePath string) error {
+	sum, err := fileChecksum(jobFilePath)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sums == nil {
+		p.sums = make(map[string]string)
+	}
+	p.sums[jobFilePath] = sum
+	return nil
+}
+
+// PostProcess compares the job file with the checksum recorded before the job.
+func (p *ChecksumPlugin) PostProcess(result *DeltaResult) error {
+	p.mu.Lock()
+	expected, found := p.sums[result.JobFile]
+	delete(p.sums, result.JobFile)
+	p.mu.Unlock()
+	if !found {
+		return fmt.Errorf("job file %s has no recorded checksum", result.JobFile)
+	}
+	sum, err := fileChecksum(result.JobFile)
+	if err != nil {
+		return err
+	}
+	if sum != expected {
+		return fmt.Errorf("job file %s was modified while delta-kusto ran", result.JobFile)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded sha256 of the file content.
+func fileChecksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,106 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+)
+
+// recordingPlugin records its calls, failing the pre-processing with `preErr`.
+type recordingPlugin struct {
+	name   string
+	calls  *[]string
+	preErr error
+}
+
+func (p recordingPlugin) PreProcess(jobFilePath string) error {
+	*p.calls = append(*p.calls, p.name+" pre")
+	return p.preErr
+}
+
+func (p recordingPlugin) PostProcess(result *kustoutils.DeltaResult) error {
+	*p.calls = append(*p.calls, p.name+" post")
+	return nil
+}
+
+var _ = Describe("Delta plugins", func() {
+	var calls []string
+	var jobFile string
+	var wrapper *kustoutils.Wrapper
+	var runner func(jobFile string) error
+	var restore func()
+
+	BeforeEach(func() {
+		calls = []string{}
+		f, err := os.CreateTemp("", "job-*.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(tstCfgContent)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+		jobFile = f.Name()
+		wrapper = kustoutils.NewDeltaWrapper()
+		runner = func(string) error {
+			calls = append(calls, "delta-kusto")
+			return nil
+		}
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error { return runner(jobFile) })
+	})
+	AfterEach(func() {
+		restore()
+		os.Remove(jobFile)
+	})
+
+	It("should run the plugins around the job in the registration order", func() {
+		wrapper.RegisterPlugin(recordingPlugin{name: "first", calls: &calls})
+		wrapper.RegisterPlugin(recordingPlugin{name: "second", calls: &calls})
+		Expect(kustoutils.RunDeltaJob(wrapper, "job", jobFile)).To(Succeed())
+		Expect(calls).To(Equal([]string{"first pre", "second pre", "delta-kusto", "first post", "second post"}))
+	})
+	It("should abort the job when a pre-processing fails", func() {
+		wrapper.RegisterPlugin(recordingPlugin{name: "first", calls: &calls, preErr: errors.New("missing variable")})
+		wrapper.RegisterPlugin(recordingPlugin{name: "second", calls: &calls})
+		err := kustoutils.RunDeltaJob(wrapper, "job", jobFile)
+		Expect(err).To(MatchError("pre-processing job job: missing variable"))
+		Expect(calls).To(Equal([]string{"first pre"}))
+	})
+	It("should run the plugins around the public job runs", func() {
+		wrapper.RegisterPlugin(recordingPlugin{name: "first", calls: &calls})
+		Expect(wrapper.RunJob("job", jobFile)).To(Succeed())
+		Expect(calls).To(Equal([]string{"first pre", "delta-kusto", "first post"}))
+
+		calls = []string{}
+		Expect(wrapper.RunJobContext(context.Background(), "job", "", jobFile)).To(Succeed())
+		Expect(calls).To(Equal([]string{"first pre", "delta-kusto", "first post"}))
+	})
+	It("should post-process failed jobs", func() {
+		runner = func(string) error { return errors.New("exit status 1") }
+		wrapper.RegisterPlugin(recordingPlugin{name: "first", calls: &calls})
+		Expect(kustoutils.RunDeltaJob(wrapper, "job", jobFile)).To(MatchError("exit status 1"))
+		Expect(calls).To(Equal([]string{"first pre", "first post"}))
+	})
+	It("should fail a job whose job file was modified", func() {
+		wrapper.RegisterPlugin(&kustoutils.ChecksumPlugin{})
+		Expect(kustoutils.RunDeltaJob(wrapper, "job", jobFile)).To(Succeed())
+
+		runner = func(jobFile string) error {
+			return os.WriteFile(jobFile, []byte("tokenProvider: {}"), 0o600)
+		}
+		err := kustoutils.RunDeltaJob(wrapper, "job", jobFile)
+		Expect(err).To(MatchError(ContainSubstring("was modified while delta-kusto ran")))
+	})
+	It("should log the jobs", func() {
+		var buf bytes.Buffer
+		wrapper.RegisterPlugin(kustoutils.LoggingPlugin{Logger: zerolog.New(&buf)})
+		Expect(kustoutils.RunDeltaJob(wrapper, "job", jobFile)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`"jobFile":"` + jobFile + `"`))
+		Expect(buf.String()).To(ContainSubstring(`"message":"delta-kusto done"`))
+	})
+})
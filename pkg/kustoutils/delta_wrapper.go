@@ -3,12 +3,21 @@ package kustoutils
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 import (
+	"context"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
@@ -22,21 +31,56 @@ var (
 	useMSI       bool
 )
 
+// DefaultCancelTimeout is the time a cancelled delta-kusto job has to exit before it is killed.
+const DefaultCancelTimeout = 5 * time.Second
+
 // Wrapper is a delta-kusto thin wrapper
 type Wrapper struct {
 	Executer string
+	// CancelTimeout is the time a cancelled job has to exit after SIGTERM before it gets SIGKILL.
+	CancelTimeout time.Duration
+	// Credentials authorize the jobs instead of the operator identity (optional).
+	Credentials *TenantCredentials
+	// Telemetry traces and measures the jobs (optional).
+	Telemetry *telemetry.SchemaOperatorTelemetry
+	jobs      *runningJobs
+	plugins   []DeltaPlugin
+}
+
+// runningJob is a started delta-kusto process.
+type runningJob struct {
+	process *os.Process
+	// exited is closed once the process exits.
+	exited chan struct{}
 }
 
+// runningJobs holds the running delta-kusto processes by job ID.
+type runningJobs struct {
+	mu        sync.Mutex
+	processes map[string]*runningJob
+}
+
+// deltaJobs is shared by all the wrappers, so a job can be cancelled from any reconciler.
+var deltaJobs = &runningJobs{processes: make(map[string]*runningJob)}
+
 type execConfig struct {
 	Uri            string
 	DBs            []string
 	KqlFile        string
+	ExtraFiles     []string
 	FailIfDataLoss bool
+	DeltaDir       string
+	DryRun         bool
+	Merge          *mergeFlags
 }
 
 const cfgSchemaDeployment = `
 sendErrorOptIn: false
-failIfDataLoss: {{ $.FailIfDataLoss }}
+failIfDataLoss: {{ $.FailIfDataLoss }}{{with $.Merge}}
+allowDropTable: {{ .AllowDropTable }}
+allowDropColumn: {{ .AllowDropColumn }}
+failIfDropColumn: {{ .FailIfDropColumn }}
+allowAlter: {{ .AllowAlter }}{{end}}
 jobs:{{range $db := .DBs}}
   push-{{$db}}-to-prod:
     current:
@@ -45,10 +89,12 @@ jobs:{{range $db := .DBs}}
         database: {{ $db }}
     target:
       scripts:
-        - filePath: {{$.KqlFile}} 
-    action:
-      # filePath: prod-update.kql
-      pushToCurrent: true{{end}}`
+        - filePath: {{$.KqlFile}} {{range $.ExtraFiles}}
+        - filePath: {{.}} {{end}}
+    action:{{if $.DeltaDir}}
+      filePath: {{$.DeltaDir}}/{{$db}}.kql{{else}}
+      # filePath: prod-update.kql{{end}}{{if not $.DryRun}}
+      pushToCurrent: true{{end}}{{end}}`
 
 const secretToken = `
 tokenProvider:
@@ -73,14 +119,69 @@ func init() {
 
 // NewDeltaWrapper returns a `Wrapper` for delta-kusto
 func NewDeltaWrapper() *Wrapper {
-	wrap := &Wrapper{}
+	wrap := &Wrapper{
+		CancelTimeout: DefaultCancelTimeout,
+		jobs:          deltaJobs,
+	}
 
 	return wrap
 }
 
-// CreateExecConfiguration returns a job configuration file for delta-kusto
-func (w *Wrapper) CreateExecConfiguration(uri string, dbs []string, kqlFile string, failIfDataLoss bool) (string, error) {
+// CreateExecConfiguration returns a job configuration file for delta-kusto.
+// `extraFiles` are added as target scripts after the `kqlFile`, an empty `strategy` keeps the delta-kusto defaults.
+func (w *Wrapper) CreateExecConfiguration(uri string, dbs []string, kqlFile string, failIfDataLoss bool, strategy schemav1alpha1.MergeStrategyType, extraFiles ...string) (string, error) {
+	flags, err := strategyFlags(strategy)
+	if err != nil {
+		return "", err
+	}
+	return w.createJob(execConfig{
+		Uri:            uri,
+		DBs:            dbs,
+		KqlFile:        kqlFile,
+		ExtraFiles:     extraFiles,
+		FailIfDataLoss: failIfDataLoss,
+		Merge:          flags,
+	})
+}
+
+// CreateDryRunConfiguration returns a job configuration file for delta-kusto that writes the delta
+// of every database to `<deltaDir>/<db>.kql` instead of pushing it to the cluster.
+func (w *Wrapper) CreateDryRunConfiguration(uri string, dbs []string, kqlFile, deltaDir string, failIfDataLoss bool, strategy schemav1alpha1.MergeStrategyType, extraFiles ...string) (string, error) {
+	flags, err := strategyFlags(strategy)
+	if err != nil {
+		return "", err
+	}
+	return w.createJob(execConfig{
+		Uri:            uri,
+		DBs:            dbs,
+		KqlFile:        kqlFile,
+		ExtraFiles:     extraFiles,
+		FailIfDataLoss: failIfDataLoss,
+		DeltaDir:       deltaDir,
+		DryRun:         true,
+		Merge:          flags,
+	})
+}
 
+// CreateRecordedExecConfiguration returns a job configuration file for delta-kusto that pushes the delta to the cluster
+// like `CreateExecConfiguration`, and also writes the applied delta of every database to `<deltaDir>/<db>.kql`.
+func (w *Wrapper) CreateRecordedExecConfiguration(uri string, dbs []string, kqlFile, deltaDir string, failIfDataLoss bool, strategy schemav1alpha1.MergeStrategyType, extraFiles ...string) (string, error) {
+	flags, err := strategyFlags(strategy)
+	if err != nil {
+		return "", err
+	}
+	return w.createJob(execConfig{
+		Uri:            uri,
+		DBs:            dbs,
+		KqlFile:        kqlFile,
+		ExtraFiles:     extraFiles,
+		FailIfDataLoss: failIfDataLoss,
+		DeltaDir:       deltaDir,
+		Merge:          flags,
+	})
+}
+
+func (w *Wrapper) createJob(exConfig execConfig) (string, error) {
 	log.Debug().Msg("open template file")
 
 	t, err := template.New("cfgTempalte").Parse(cfgSchemaDeployment)
@@ -99,13 +200,7 @@ func (w *Wrapper) CreateExecConfiguration(uri string, dbs []string, kqlFile stri
 	}
 	defer f.Close()
 
-	log.Debug().Strs("dbs", dbs).Str("kql", kqlFile).Msg("define config")
-	exConfig := execConfig{
-		Uri:            uri,
-		DBs:            dbs,
-		KqlFile:        kqlFile,
-		FailIfDataLoss: failIfDataLoss,
-	}
+	log.Debug().Strs("dbs", exConfig.DBs).Str("kql", exConfig.KqlFile).Msg("define config")
 	log.Debug().Msgf("execute template config onto: %s", f.Name())
 	err = t.Execute(f, exConfig)
 	if err != nil {
@@ -113,7 +208,7 @@ func (w *Wrapper) CreateExecConfiguration(uri string, dbs []string, kqlFile stri
 		return f.Name(), err
 	}
 
-	if useMSI {
+	if useMSI && w.credentials() == nil {
 		_, err = f.WriteString(msiToken)
 	} else {
 		_, err = f.WriteString(secretToken)
@@ -122,24 +217,81 @@ func (w *Wrapper) CreateExecConfiguration(uri string, dbs []string, kqlFile stri
 	return f.Name(), err
 }
 
+// telemetry returns the telemetry of the wrapper, nil without one.
+func (w *Wrapper) telemetry() *telemetry.SchemaOperatorTelemetry {
+	if w == nil {
+		return nil
+	}
+	return w.Telemetry
+}
+
+// credentials returns the job credentials of the wrapper, nil for the operator identity.
+func (w *Wrapper) credentials() *TenantCredentials {
+	if w == nil {
+		return nil
+	}
+	return w.Credentials
+}
+
+// runDeltaKusto runs the delta-kusto jobs with the wrapper `w` (a new wrapper when nil) in the working directory `dir`,
+// identified by their file unless a `jobID` is given, until `ctx` is done. The output of the job is also written to
+// `stdout` when set. Replaced in tests.
+var runDeltaKusto = func(ctx context.Context, w *Wrapper, jobID, dir, deltaCfgfile string, stdout io.Writer) error {
+	if jobID == "" {
+		jobID = deltaCfgfile
+	}
+	if w == nil {
+		w = NewDeltaWrapper()
+	}
+	return w.runJob(ctx, jobID, dir, deltaCfgfile, stdout)
+}
+
 // RunDeltaKusto runs delta-kusto on the provided job configuration file.
 func RunDeltaKusto(deltaCfgfile string) error {
+	return NewDeltaWrapper().RunJob(deltaCfgfile, deltaCfgfile)
+}
+
+// RunJob runs delta-kusto on the provided job configuration file, the job can be cancelled by its `jobID` while running.
+func (w *Wrapper) RunJob(jobID, deltaCfgfile string) error {
+	return w.RunJobIn(jobID, "", deltaCfgfile)
+}
+
+// RunJobIn runs delta-kusto like `RunJob` with `dir` as its working directory, the current directory when empty.
+func (w *Wrapper) RunJobIn(jobID, dir, deltaCfgfile string) error {
+	return w.RunJobContext(context.Background(), jobID, dir, deltaCfgfile)
+}
+
+// RunJobContext runs delta-kusto like `RunJobIn`, the job is cancelled like `CancelSchemaJob` once `ctx` is done.
+// The job runs between the `PreProcess` and `PostProcess` of the registered plugins.
+func (w *Wrapper) RunJobContext(ctx context.Context, jobID, dir, deltaCfgfile string) error {
+	return runPluggedDeltaJob(ctx, w, jobID, dir, deltaCfgfile, "")
+}
+
+// runJob runs delta-kusto like `RunJobContext`, also writing its output to `stdout` when set.
+func (w *Wrapper) runJob(ctx context.Context, jobID, dir, deltaCfgfile string, stdout io.Writer) error {
 	log.Debug().Str("tenant", tenantID).Str("client", clientID).Str("sec", clientSecret).Msgf("about to run delta-kusto on: %s", deltaCfgfile)
 	args := []string{"-p", deltaCfgfile}
 
-	if useMSI {
+	if creds := w.credentials(); creds != nil {
+		log.Debug().Str("tenant", creds.TenantID).Msg("Using the tenant credentials")
+		args = append(args, "-o", "tokenProvider.login.tenantId="+creds.TenantID, "tokenProvider.login.clientId="+creds.ClientID, "tokenProvider.login.secret="+creds.ClientSecret)
+	} else if useMSI {
 		log.Debug().Msg("Using MSI - no auth info needed")
 	} else {
 		args = append(args, "-o", "tokenProvider.login.tenantId="+tenantID, "tokenProvider.login.clientId="+clientID, "tokenProvider.login.secret="+clientSecret)
 	}
 	cmd := exec.Command(deltaCmd, args...)
+	cmd.Dir = dir
 	cmd.Env = append(os.Environ(),
 		"PATH=/bin/",
 		"DOTNET_SYSTEM_GLOBALIZATION_INVARIANT=1",
 	)
 	cmd.Stdout = log.Level(zerolog.InfoLevel).With().Str("delta-kusto", deltaCfgfile).Logger()
+	if stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, stdout)
+	}
 	cmd.Stderr = log.Level(zerolog.ErrorLevel).With().Str("delta-kusto", deltaCfgfile).Logger()
-	err := cmd.Run()
+	err := w.run(ctx, jobID, cmd)
 	if err != nil {
 		eerr, ok := err.(*exec.ExitError)
 		if ok {
@@ -152,3 +304,124 @@ func RunDeltaKusto(deltaCfgfile string) error {
 	log.Info().Msgf("Execution of %s done", deltaCfgfile)
 	return nil
 }
+
+// run starts `cmd` and waits for it, keeping the process registered under `jobID` while it runs.
+// Once `ctx` is done the process is cancelled and the context error is returned.
+func (w *Wrapper) run(ctx context.Context, jobID string, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	job := &runningJob{process: cmd.Process, exited: make(chan struct{})}
+	w.jobs.mu.Lock()
+	w.jobs.processes[jobID] = job
+	w.jobs.mu.Unlock()
+
+	cancelled := make(chan struct{})
+	go func() {
+		defer close(cancelled)
+		select {
+		case <-ctx.Done():
+			log.Warn().Err(ctx.Err()).Msgf("context of job %s is done - cancelling it", jobID)
+			_ = w.cancelJob(context.Background(), jobID, job)
+		case <-job.exited:
+		}
+	}()
+	err := cmd.Wait()
+	close(job.exited)
+	<-cancelled
+	w.jobs.mu.Lock()
+	if w.jobs.processes[jobID] == job {
+		delete(w.jobs.processes, jobID)
+	}
+	w.jobs.mu.Unlock()
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("delta-kusto job %s stopped: %w", jobID, ctxErr)
+	}
+	return err
+}
+
+// CancelSchemaJob stops the running delta-kusto job `jobID` with SIGTERM, and with SIGKILL if it did not exit
+// within the `CancelTimeout` or before `ctx` is done. A job that already exited is ignored.
+// The isolated executions of the job (`<jobID>/<db>`) are cancelled with it.
+func (w *Wrapper) CancelSchemaJob(ctx context.Context, jobID string) error {
+	return w.cancelJobs(ctx, jobID, func(id string) bool {
+		return id == jobID || strings.HasPrefix(id, jobID+"/")
+	})
+}
+
+// CancelAllJobs stops every running delta-kusto job like `CancelSchemaJob`, e.g. when the operator shuts down.
+func (w *Wrapper) CancelAllJobs(ctx context.Context) error {
+	return w.cancelJobs(ctx, "*", func(id string) bool { return true })
+}
+
+// cancelJobs cancels the running jobs whose ID is `matches`, `name` describes them in the logs.
+func (w *Wrapper) cancelJobs(ctx context.Context, name string, matches func(id string) bool) error {
+	w.jobs.mu.Lock()
+	jobs := map[string]*runningJob{}
+	for id, job := range w.jobs.processes {
+		if matches(id) {
+			jobs[id] = job
+		}
+	}
+	w.jobs.mu.Unlock()
+	if len(jobs) == 0 {
+		log.Debug().Msgf("job %s is not running - nothing to cancel", name)
+		return nil
+	}
+	for id, job := range jobs {
+		if err := w.cancelJob(ctx, id, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Wrapper) cancelJob(ctx context.Context, jobID string, job *runningJob) error {
+
+	log.Info().Msgf("cancelling delta-kusto job %s", jobID)
+	err := job.process.Signal(syscall.SIGTERM)
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("failed terminating job %s", jobID)
+		return err
+	}
+	timeout := w.CancelTimeout
+	if timeout <= 0 {
+		timeout = DefaultCancelTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-job.exited:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	log.Warn().Msgf("job %s did not exit after SIGTERM - killing it", jobID)
+	err = job.process.Kill()
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("failed killing job %s", jobID)
+		return err
+	}
+	select {
+	case <-job.exited:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// CancelSchemaJob cancels the running delta-kusto job `jobID` of any cluster.
+func CancelSchemaJob(ctx context.Context, jobID string) error {
+	return NewDeltaWrapper().CancelSchemaJob(ctx, jobID)
+}
+
+// CancelAllSchemaJobs cancels the running delta-kusto jobs of all the clusters.
+func CancelAllSchemaJobs(ctx context.Context) error {
+	return NewDeltaWrapper().CancelAllJobs(ctx)
+}
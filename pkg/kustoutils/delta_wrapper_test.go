@@ -3,8 +3,11 @@ package kustoutils_test
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"os/exec"
+	"time"
 
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
 	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
@@ -65,7 +68,7 @@ var _ = Describe("DeltaWrapper", func() {
 			dbs := []string{"db1", "db2", "db3"}
 			kqlFile := "/path/to/schema.kql"
 
-			fileName, err := w.CreateExecConfiguration(uri, dbs, kqlFile, true)
+			fileName, err := w.CreateExecConfiguration(uri, dbs, kqlFile, true, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fileName).To(BeARegularFile())
 			fmt.Fprintf(GinkgoWriter, "generated config file: %s\n", fileName)
@@ -107,4 +110,103 @@ var _ = Describe("DeltaWrapper", func() {
 		})
 
 	})
+	Context("when the configmap has ingestion policies", func() {
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+
+		It("Should include the ingestion policies file in the job", func() {
+			cfgMap := &v1.ConfigMap{
+				Data: map[string]string{
+					"kql":               ".create-merge table Events (Timestamp:datetime)",
+					"ingestionPolicies": `.alter table Events policy ingestionbatching @'{"MaximumBatchingTimeSpan":"00:00:30"}'`,
+				},
+			}
+			exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exeCfg.IngestionPoliciesFile).To(BeARegularFile())
+			job, err := ioutil.ReadFile(exeCfg.JobFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(job)).To(ContainSubstring("- filePath: " + exeCfg.KQLFile + " \n        - filePath: " + exeCfg.IngestionPoliciesFile + " \n"))
+		})
+		It("Should fail on policies for tables missing from the schema", func() {
+			cfgMap := &v1.ConfigMap{
+				Data: map[string]string{
+					"kql":               ".create-merge table Events (Timestamp:datetime)",
+					"ingestionPolicies": `.alter table ['Audit'] policy streamingingestion enable`,
+				},
+			}
+			_, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+			Expect(err).To(Equal(kustoutils.ErrOrphanedPolicy{Table: "Audit"}))
+		})
+		It("Should accept policies for tables of a tables list", func() {
+			cfgMap := &v1.ConfigMap{
+				Data: map[string]string{
+					"kql":               ".create-merge tables Events (Timestamp:datetime), ['Audit'] (Timestamp:datetime, User:string)",
+					"ingestionPolicies": `.alter table ['Audit'] policy streamingingestion enable`,
+				},
+			}
+			exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exeCfg.IngestionPoliciesFile).To(BeARegularFile())
+		})
+	})
+	Context("when a job is cancelled", func() {
+		var wrapper *kustoutils.Wrapper
+		// start runs `script` as the job `jobID` and waits until it is registered.
+		start := func(jobID, script string) chan error {
+			result := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				result <- kustoutils.RunJobCommand(wrapper, jobID, exec.Command("sh", "-c", script))
+			}()
+			Eventually(func() bool { return kustoutils.IsJobRunning(jobID) }).Should(BeTrue())
+			return result
+		}
+
+		BeforeEach(func() {
+			wrapper = kustoutils.NewDeltaWrapper()
+			wrapper.CancelTimeout = 200 * time.Millisecond
+		})
+
+		It("Should terminate the running process", func() {
+			result := start("job-term", "exec sleep 30")
+			Expect(wrapper.CancelSchemaJob(context.Background(), "job-term")).To(Succeed())
+			var err error
+			Eventually(result, time.Second).Should(Receive(&err))
+			Expect(err).To(MatchError("signal: terminated"))
+			Expect(kustoutils.IsJobRunning("job-term")).To(BeFalse())
+		})
+		It("Should kill a process ignoring SIGTERM", func() {
+			result := start("job-kill", "trap '' TERM; exec sleep 30")
+			// give the shell time to ignore SIGTERM before cancelling.
+			time.Sleep(100 * time.Millisecond)
+			Expect(wrapper.CancelSchemaJob(context.Background(), "job-kill")).To(Succeed())
+			var err error
+			Eventually(result, time.Second).Should(Receive(&err))
+			Expect(err).To(MatchError("signal: killed"))
+		})
+		It("Should terminate the process once the context deadline passes", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			err := kustoutils.RunJobCommandContext(ctx, wrapper, "job-deadline", exec.Command("sleep", "30"))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(kustoutils.IsJobRunning("job-deadline")).To(BeFalse())
+		})
+		It("Should cancel all the running jobs", func() {
+			first := start("job-all-1", "exec sleep 30")
+			second := start("job-all-2/db1", "exec sleep 30")
+			Expect(kustoutils.CancelAllSchemaJobs(context.Background())).To(Succeed())
+			for _, result := range []<-chan error{first, second} {
+				var err error
+				Eventually(result, time.Second).Should(Receive(&err))
+				Expect(err).To(MatchError("signal: terminated"))
+			}
+			Expect(kustoutils.IsJobRunning("job-all-1")).To(BeFalse())
+			Expect(kustoutils.IsJobRunning("job-all-2/db1")).To(BeFalse())
+		})
+		It("Should ignore jobs that already exited", func() {
+			Expect(kustoutils.RunJobCommand(wrapper, "job-done", exec.Command("true"))).To(Succeed())
+			Expect(wrapper.CancelSchemaJob(context.Background(), "job-done")).To(Succeed())
+		})
+	})
 })
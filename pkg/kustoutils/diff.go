@@ -0,0 +1,117 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DiffLabel marks config maps holding an exported schema diff.
+	DiffLabel = "schema.operator/diff"
+	// ClusterLabel holds the name of the cluster an exported schema diff was computed on.
+	ClusterLabel = "schema.operator/cluster"
+	// DiffKey is the `ConfigMap` key holding the exported schema diff json.
+	DiffKey = "diff"
+)
+
+// DatabaseDiff is the delta-kusto script that aligns a database with the desired schema.
+type DatabaseDiff struct {
+	Database string `json:"database"`
+	Delta    string `json:"delta"`
+}
+
+// SchemaDiff is the result of a dry run on a cluster.
+type SchemaDiff struct {
+	ClusterURI string         `json:"clusterUri"`
+	Databases  []DatabaseDiff `json:"databases"`
+}
+
+// SchemaDiff reads the delta computed by the dry run described by `config`.
+func (c *KustoCluster) SchemaDiff(config schemav1alpha1.ExecutionConfiguration) (SchemaDiff, error) {
+	return c.readDiff(config.DryRunOutputDir)
+}
+
+// AppliedDiffSummary summarizes the delta applied by the execution described by `config`, and removes it.
+// Executions without a recorded delta return a nil summary.
+func (c *KustoCluster) AppliedDiffSummary(config schemav1alpha1.ExecutionConfiguration) (*schemav1alpha1.SchemaDiffSummary, error) {
+	if config.AppliedDiffDir == "" {
+		return nil, nil
+	}
+	defer os.RemoveAll(config.AppliedDiffDir)
+	diff, err := c.readDiff(config.AppliedDiffDir)
+	if err != nil {
+		return nil, err
+	}
+	summary := diff.Summary()
+	return &summary, nil
+}
+
+// readDiff reads the `<db>.kql` delta files delta-kusto wrote to `dir`.
+func (c *KustoCluster) readDiff(dir string) (SchemaDiff, error) {
+	diff := SchemaDiff{ClusterURI: c.URI, Databases: []DatabaseDiff{}}
+	files, err := filepath.Glob(filepath.Join(dir, "*.kql"))
+	if err != nil {
+		return diff, err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		delta, err := os.ReadFile(file)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed reading delta file %s", file)
+			return diff, err
+		}
+		diff.Databases = append(diff.Databases, DatabaseDiff{
+			Database: strings.TrimSuffix(filepath.Base(file), ".kql"),
+			Delta:    string(delta),
+		})
+	}
+	return diff, nil
+}
+
+// ExportSchemaDiff stores the `diff` json in the `ConfigMap` `namespace`/`name`, creating it if needed.
+func ExportSchemaDiff(ctx context.Context, c client.Client, diff SchemaDiff, namespace, name string) error {
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+	cfgMap := &v1.ConfigMap{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cfgMap)
+	found := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Error().Err(err).Msgf("failed fetching the diff config map %s/%s", namespace, name)
+		return err
+	}
+	if !found {
+		cfgMap.ObjectMeta = metav1.ObjectMeta{Name: name, Namespace: namespace}
+	}
+	if cfgMap.Labels == nil {
+		cfgMap.Labels = make(map[string]string)
+	}
+	cfgMap.Labels[DiffLabel] = "true"
+	cfgMap.Labels[ClusterLabel] = ClusterNameFromURI(diff.ClusterURI)
+	cfgMap.Data = map[string]string{DiffKey: string(data)}
+
+	if found {
+		err = c.Update(ctx, cfgMap)
+	} else {
+		err = c.Create(ctx, cfgMap)
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("failed exporting the schema diff to %s/%s", namespace, name)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"strings"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// Summary counts the changes of the delta of every database of the diff.
+func (d SchemaDiff) Summary() schemav1alpha1.SchemaDiffSummary {
+	summary := schemav1alpha1.SchemaDiffSummary{}
+	for _, db := range d.Databases {
+		delta := SummarizeDelta(db.Delta)
+		summary.TablesAdded += delta.TablesAdded
+		summary.TablesDropped += delta.TablesDropped
+		summary.ColumnsAdded += delta.ColumnsAdded
+		summary.ColumnsDropped += delta.ColumnsDropped
+		summary.FunctionsChanged += delta.FunctionsChanged
+		summary.PoliciesChanged += delta.PoliciesChanged
+	}
+	return summary
+}
+
+// SummarizeDelta counts the changes of a delta-kusto delta script.
+// New tables are `.create` / `.create-merge` table commands and new columns are `.alter-merge table` commands.
+func SummarizeDelta(delta string) schemav1alpha1.SchemaDiffSummary {
+	summary := schemav1alpha1.SchemaDiffSummary{}
+	for _, command := range deltaCommands(delta) {
+		lower := strings.ToLower(command)
+		fields := strings.Fields(lower)
+		if len(fields) < 2 {
+			continue
+		}
+		verb, kind := fields[0], fields[1]
+		switch {
+		case kind == "function":
+			summary.FunctionsChanged++
+		case kind == "functions" && verb == ".drop":
+			summary.FunctionsChanged += len(listItems(command))
+		case strings.Contains(lower, " policy "):
+			if verb == ".alter" || verb == ".alter-merge" || verb == ".delete" {
+				summary.PoliciesChanged++
+			}
+		case kind == "column" && verb == ".drop":
+			summary.ColumnsDropped++
+		case kind == "table" && verb == ".drop" && len(fields) > 3 && fields[3] == "columns":
+			summary.ColumnsDropped += len(listItems(command))
+		case kind == "table" && verb == ".drop":
+			summary.TablesDropped++
+		case kind == "tables" && verb == ".drop":
+			summary.TablesDropped += len(listItems(command))
+		case kind == "table" && verb == ".alter-merge":
+			summary.ColumnsAdded += len(listItems(command))
+		case kind == "table" && (verb == ".create" || verb == ".create-merge"):
+			summary.TablesAdded++
+		case kind == "tables" && (verb == ".create" || verb == ".create-merge"):
+			summary.TablesAdded += len(parenthesizedLists(command))
+		}
+	}
+	return summary
+}
+
+// deltaCommands splits a delta script into its management commands, skipping the comments.
+// Lines not starting a new command (e.g. a multi-line function body) belong to the previous command.
+func deltaCommands(delta string) []string {
+	commands := []string{}
+	for _, line := range strings.Split(delta, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "//"):
+		case strings.HasPrefix(trimmed, "."):
+			commands = append(commands, trimmed)
+		case len(commands) > 0:
+			commands[len(commands)-1] += "\n" + trimmed
+		}
+	}
+	return commands
+}
+
+// listItems returns the comma separated items of the first parenthesized list of the command.
+func listItems(command string) []string {
+	lists := parenthesizedLists(command)
+	if len(lists) == 0 {
+		return nil
+	}
+	items := []string{}
+	depth := 0
+	start := 0
+	list := lists[0]
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(list[start:]) != "" {
+		items = append(items, list[start:])
+	}
+	return items
+}
+
+// parenthesizedLists returns the content of every top level parenthesized list of the command,
+// e.g. the column lists of the tables of a `.create-merge tables` command.
+func parenthesizedLists(command string) []string {
+	lists := []string{}
+	depth := 0
+	start := 0
+	for i, r := range command {
+		switch r {
+		case '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				lists = append(lists, command[start:i])
+			}
+		}
+	}
+	return lists
+}
@@ -0,0 +1,122 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Schema diff", func() {
+	ctx := context.Background()
+	diff := kustoutils.SchemaDiff{
+		ClusterURI: "https://testcluster.westeurope.kusto.windows.net",
+		Databases: []kustoutils.DatabaseDiff{
+			{Database: "db1", Delta: ".create table T (a:string)"},
+		},
+	}
+	readDiff := func(cfgMap *v1.ConfigMap) kustoutils.SchemaDiff {
+		exported := kustoutils.SchemaDiff{}
+		Expect(json.Unmarshal([]byte(cfgMap.Data[kustoutils.DiffKey]), &exported)).To(Succeed())
+		return exported
+	}
+
+	It("should export the diff to a new config map", func() {
+		k8sClient := fake.NewClientBuilder().Build()
+		Expect(kustoutils.ExportSchemaDiff(ctx, k8sClient, diff, "default", "diff-output")).To(Succeed())
+
+		cfgMap := &v1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "diff-output"}, cfgMap)).To(Succeed())
+		Expect(cfgMap.Labels).To(Equal(map[string]string{
+			kustoutils.DiffLabel:    "true",
+			kustoutils.ClusterLabel: "testcluster",
+		}))
+		Expect(readDiff(cfgMap)).To(Equal(diff))
+	})
+	It("should update an existing config map", func() {
+		k8sClient := fake.NewClientBuilder().Build()
+		Expect(kustoutils.ExportSchemaDiff(ctx, k8sClient, kustoutils.SchemaDiff{ClusterURI: diff.ClusterURI}, "default", "diff-output")).To(Succeed())
+		Expect(kustoutils.ExportSchemaDiff(ctx, k8sClient, diff, "default", "diff-output")).To(Succeed())
+
+		cfgMap := &v1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "diff-output"}, cfgMap)).To(Succeed())
+		Expect(readDiff(cfgMap)).To(Equal(diff))
+	})
+	It("should only compute the delta in a dry run", func() {
+		cluster := &kustoutils.KustoCluster{URI: diff.ClusterURI, Client: &mockKusto{}}
+		cfgMap := &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":                   ".create-merge table T (a:string)",
+				"dryRunOutputConfigMap": "diff-output",
+			},
+		}
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(exeCfg.DryRunOutputDir)
+		Expect(exeCfg.DryRunOutputConfigMap).To(Equal("diff-output"))
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).To(ContainSubstring("filePath: " + filepath.Join(exeCfg.DryRunOutputDir, "db1.kql")))
+		Expect(string(job)).NotTo(ContainSubstring("pushToCurrent"))
+
+		// delta-kusto writes the delta of every database
+		Expect(ioutil.WriteFile(filepath.Join(exeCfg.DryRunOutputDir, "db1.kql"), []byte(diff.Databases[0].Delta), 0600)).To(Succeed())
+		computed, err := cluster.SchemaDiff(exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(computed).To(Equal(diff))
+	})
+	It("should summarize a delta-kusto delta", func() {
+		fixture, err := ioutil.ReadFile(filepath.Join("testdata", "delta-kusto-diff.json"))
+		Expect(err).NotTo(HaveOccurred())
+		parsed := kustoutils.SchemaDiff{}
+		Expect(json.Unmarshal(fixture, &parsed)).To(Succeed())
+		Expect(parsed.Databases).To(HaveLen(2))
+
+		Expect(kustoutils.SummarizeDelta(parsed.Databases[0].Delta)).To(Equal(schemav1alpha1.SchemaDiffSummary{
+			TablesAdded:      2,
+			TablesDropped:    1,
+			ColumnsAdded:     2,
+			ColumnsDropped:   2,
+			FunctionsChanged: 2,
+			PoliciesChanged:  2,
+		}))
+		Expect(parsed.Summary()).To(Equal(schemav1alpha1.SchemaDiffSummary{
+			TablesAdded:      3,
+			TablesDropped:    1,
+			ColumnsAdded:     2,
+			ColumnsDropped:   3,
+			FunctionsChanged: 2,
+			PoliciesChanged:  3,
+		}))
+	})
+	It("should record the delta applied by an execution", func() {
+		cluster := &kustoutils.KustoCluster{URI: diff.ClusterURI, Client: &mockKusto{}}
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, &v1.ConfigMap{
+			Data: map[string]string{"kql": ".create-merge table T (a:string)"},
+		}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.AppliedDiffDir).To(BeADirectory())
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).To(ContainSubstring("filePath: " + filepath.Join(exeCfg.AppliedDiffDir, "db1.kql")))
+		Expect(string(job)).To(ContainSubstring("pushToCurrent: true"))
+
+		// delta-kusto writes the applied delta of every database
+		Expect(ioutil.WriteFile(filepath.Join(exeCfg.AppliedDiffDir, "db1.kql"), []byte(".create table ['T'] (['a']:string)\n.alter-merge table ['U'] (['b']:int)"), 0600)).To(Succeed())
+		summary, err := cluster.AppliedDiffSummary(exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(Equal(&schemav1alpha1.SchemaDiffSummary{TablesAdded: 1, ColumnsAdded: 1}))
+		Expect(exeCfg.AppliedDiffDir).NotTo(BeADirectory())
+	})
+})
@@ -0,0 +1,226 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultClusterDiscoveryTTL is the time the discovered clusters are reused, unless the cluster discovery ttl setting is set.
+	DefaultClusterDiscoveryTTL = 10 * time.Minute
+	// resourceGraphEndpoint is the Azure Resource Manager endpoint of the public cloud.
+	resourceGraphEndpoint = "management.azure.com"
+	// resourceGraphResource is the resource of the Azure Resource Manager access tokens.
+	resourceGraphResource = "https://management.azure.com"
+	// kustoClustersQuery lists the kusto clusters with their tags and query endpoint.
+	kustoClustersQuery = "Resources | where type =~ 'microsoft.kusto/clusters' | project id, name, tags, uri = tostring(properties.uri)"
+)
+
+// ClusterResource is a kusto cluster found by Azure Resource Graph.
+type ClusterResource struct {
+	ID   string            `json:"id"`
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags"`
+	URI  string            `json:"uri"`
+}
+
+type resourceGraphRequest struct {
+	Subscriptions []string             `json:"subscriptions"`
+	Query         string               `json:"query"`
+	Options       resourceGraphOptions `json:"options"`
+}
+
+type resourceGraphOptions struct {
+	ResultFormat string `json:"resultFormat"`
+	SkipToken    string `json:"$skipToken,omitempty"`
+}
+
+// resourceGraphPage a page of the kusto clusters query result.
+type resourceGraphPage struct {
+	autorest.Response `json:"-"`
+	Data              []ClusterResource `json:"data"`
+	SkipToken         string            `json:"$skipToken,omitempty"`
+}
+
+// ClusterDiscovery finds the kusto clusters of a subscription with Azure Resource Graph,
+// reusing the clusters of a subscription and tag filter until the TTL passed.
+type ClusterDiscovery struct {
+	autorest.Client
+	// Endpoint is the Azure Resource Manager host.
+	Endpoint string
+	TTL      time.Duration
+
+	mu         sync.Mutex
+	discovered map[string]discoveredClusters
+	newCluster func(uri string) *KustoCluster
+	now        func() time.Time
+}
+
+type discoveredClusters struct {
+	clusters []*KustoCluster
+	created  time.Time
+}
+
+// NewClusterDiscovery returns a discovery reusing its clusters for `ttl`.
+// A non positive `ttl` uses the `DefaultClusterDiscoveryTTL`.
+func NewClusterDiscovery(ttl time.Duration) *ClusterDiscovery {
+	if ttl <= 0 {
+		ttl = DefaultClusterDiscoveryTTL
+	}
+	return &ClusterDiscovery{
+		Client:     autorest.NewClientWithUserAgent("azure-schema-operator"),
+		Endpoint:   resourceGraphEndpoint,
+		TTL:        ttl,
+		discovered: make(map[string]discoveredClusters),
+		newCluster: NewKustoCluster,
+		now:        time.Now,
+	}
+}
+
+var (
+	defaultDiscoveryOnce sync.Once
+	defaultDiscovery     *ClusterDiscovery
+	defaultDiscoveryErr  error
+)
+
+// DiscoverKustoClusters returns the kusto clusters of the subscription whose tags include all the `tagFilter` tags.
+// The request is authorized with the environment credentials, and the clusters are reused for the cluster discovery ttl.
+func DiscoverKustoClusters(ctx context.Context, subscriptionID string, tagFilter map[string]string) ([]*KustoCluster, error) {
+	defaultDiscoveryOnce.Do(func() {
+		defaultDiscovery = NewClusterDiscovery(config.GetDuration(config.ClusterDiscoveryTTLKey))
+		defaultDiscovery.Authorizer, defaultDiscoveryErr = auth.NewAuthorizerFromEnvironmentWithResource(resourceGraphResource)
+	})
+	if defaultDiscoveryErr != nil {
+		return nil, defaultDiscoveryErr
+	}
+	return defaultDiscovery.Discover(ctx, subscriptionID, tagFilter)
+}
+
+// Discover returns the kusto clusters of the subscription whose tags include all the `tagFilter` tags,
+// querying Azure Resource Graph when the clusters were not discovered in the last TTL.
+func (d *ClusterDiscovery) Discover(ctx context.Context, subscriptionID string, tagFilter map[string]string) ([]*KustoCluster, error) {
+	key := discoveryKey(subscriptionID, tagFilter)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.discovered[key]; ok && d.now().Sub(entry.created) < d.TTL {
+		return entry.clusters, nil
+	}
+
+	resources, err := d.ListClusters(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	clusters := []*KustoCluster{}
+	for _, resource := range resources {
+		if resource.URI == "" || !MatchesTags(resource.Tags, tagFilter) {
+			continue
+		}
+		log.Debug().Msgf("discovered kusto cluster %s at %s", resource.Name, resource.URI)
+		clusters = append(clusters, d.newCluster(resource.URI))
+	}
+	d.discovered[key] = discoveredClusters{clusters: clusters, created: d.now()}
+	return clusters, nil
+}
+
+// MatchesTags reports if `tags` hold all the `filter` tags, tag names are case insensitive.
+func MatchesTags(tags map[string]string, filter map[string]string) bool {
+	for name, value := range filter {
+		found := false
+		for tag, tagValue := range tags {
+			if strings.EqualFold(tag, name) && tagValue == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// discoveryKey identifies the clusters of a subscription and tag filter.
+func discoveryKey(subscriptionID string, tagFilter map[string]string) string {
+	tags := make([]string, 0, len(tagFilter))
+	for name, value := range tagFilter {
+		tags = append(tags, strings.ToLower(name)+"="+value)
+	}
+	sort.Strings(tags)
+	return strings.ToLower(subscriptionID) + "#" + strings.Join(tags, ",")
+}
+
+// ListClusters gets all the kusto clusters of the subscription, following the result pages.
+func (d *ClusterDiscovery) ListClusters(ctx context.Context, subscriptionID string) ([]ClusterResource, error) {
+	resources := []ClusterResource{}
+	skipToken := ""
+	for {
+		req, err := d.listClustersPreparer(ctx, subscriptionID, skipToken)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "kustoutils.ClusterDiscovery", "ListClusters", nil, "Failure preparing request")
+		}
+		resp, err := d.Send(req, autorest.DoRetryForStatusCodes(d.RetryAttempts, d.RetryDuration, autorest.StatusCodesForRetry...))
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "kustoutils.ClusterDiscovery", "ListClusters", resp, "Failure sending request")
+		}
+		page, err := d.listClustersResponder(resp)
+		if err != nil {
+			return nil, autorest.NewErrorWithError(err, "kustoutils.ClusterDiscovery", "ListClusters", resp, "Failure responding to request")
+		}
+		resources = append(resources, page.Data...)
+		if strings.TrimSpace(page.SkipToken) == "" {
+			return resources, nil
+		}
+		skipToken = page.SkipToken
+	}
+}
+
+// listClustersPreparer prepares the Resource Graph query for a page of the kusto clusters.
+func (d *ClusterDiscovery) listClustersPreparer(ctx context.Context, subscriptionID, skipToken string) (*http.Request, error) {
+	urlParameters := map[string]interface{}{
+		"endpoint": d.Endpoint,
+	}
+
+	const APIVersion = "2021-03-01"
+	queryParameters := map[string]interface{}{
+		"api-version": APIVersion,
+	}
+
+	body := resourceGraphRequest{
+		Subscriptions: []string{subscriptionID},
+		Query:         kustoClustersQuery,
+		Options:       resourceGraphOptions{ResultFormat: "objectArray", SkipToken: skipToken},
+	}
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType("application/json; charset=utf-8"),
+		autorest.AsPost(),
+		autorest.WithCustomBaseURL("https://{endpoint}", urlParameters),
+		autorest.WithPath("/providers/Microsoft.ResourceGraph/resources"),
+		autorest.WithJSON(body),
+		autorest.WithQueryParameters(queryParameters))
+	return preparer.Prepare((&http.Request{}).WithContext(ctx))
+}
+
+// listClustersResponder handles the response to the Resource Graph query. The method always
+// closes the http.Response Body.
+func (d *ClusterDiscovery) listClustersResponder(resp *http.Response) (result resourceGraphPage, err error) {
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	return
+}
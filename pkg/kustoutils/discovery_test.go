@@ -0,0 +1,126 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Kusto cluster discovery", func() {
+	const subscriptionID = "00000000-0000-0000-0000-000000000000"
+	var server *httptest.Server
+	var queries []map[string]interface{}
+	var now time.Time
+	var discovery *kustoutils.ClusterDiscovery
+
+	pages := map[string]string{
+		"": `{"count": 2, "data": [
+			{"id": "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Kusto/clusters/prod1", "name": "prod1",
+			 "tags": {"Environment": "prod", "team": "a"}, "uri": "https://prod1.westeurope.kusto.windows.net"},
+			{"id": "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Kusto/clusters/dev1", "name": "dev1",
+			 "tags": {"environment": "dev"}, "uri": "https://dev1.westeurope.kusto.windows.net"}
+		], "$skipToken": "page2"}`,
+		"page2": `{"count": 2, "data": [
+			{"id": "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Kusto/clusters/prod2", "name": "prod2",
+			 "tags": {"environment": "prod"}, "uri": "https://prod2.northeurope.kusto.windows.net"},
+			{"id": "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Kusto/clusters/creating", "name": "creating",
+			 "tags": {"environment": "prod"}, "uri": ""}
+		]}`,
+	}
+
+	BeforeEach(func() {
+		queries = nil
+		now = time.Now()
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.URL.Path).To(Equal("/providers/Microsoft.ResourceGraph/resources"))
+			Expect(r.URL.Query().Get("api-version")).To(Equal("2021-03-01"))
+			query := map[string]interface{}{}
+			Expect(json.NewDecoder(r.Body).Decode(&query)).To(Succeed())
+			queries = append(queries, query)
+			skipToken, _ := query["options"].(map[string]interface{})["$skipToken"].(string)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(pages[skipToken]))
+		}))
+		discovery = kustoutils.NewTestClusterDiscovery(time.Minute, strings.TrimPrefix(server.URL, "https://"), server.Client(),
+			func(uri string) *kustoutils.KustoCluster { return &kustoutils.KustoCluster{URI: uri} },
+			func() time.Time { return now })
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	uris := func(clusters []*kustoutils.KustoCluster) []string {
+		found := []string{}
+		for _, cluster := range clusters {
+			found = append(found, cluster.URI)
+		}
+		return found
+	}
+
+	It("should query the kusto clusters of the subscription", func() {
+		clusters, err := discovery.Discover(context.Background(), subscriptionID, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uris(clusters)).To(Equal([]string{
+			"https://prod1.westeurope.kusto.windows.net",
+			"https://dev1.westeurope.kusto.windows.net",
+			"https://prod2.northeurope.kusto.windows.net",
+		}))
+		Expect(queries).To(HaveLen(2))
+		Expect(queries[0]["subscriptions"]).To(Equal([]interface{}{subscriptionID}))
+		Expect(queries[0]["query"]).To(ContainSubstring("microsoft.kusto/clusters"))
+		Expect(queries[1]["options"]).To(HaveKeyWithValue("$skipToken", "page2"))
+	})
+	It("should filter the clusters by their tags", func() {
+		clusters, err := discovery.Discover(context.Background(), subscriptionID, map[string]string{"environment": "prod"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uris(clusters)).To(Equal([]string{
+			"https://prod1.westeurope.kusto.windows.net",
+			"https://prod2.northeurope.kusto.windows.net",
+		}))
+
+		clusters, err = discovery.Discover(context.Background(), subscriptionID, map[string]string{"environment": "prod", "team": "a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uris(clusters)).To(Equal([]string{"https://prod1.westeurope.kusto.windows.net"}))
+	})
+	It("should reuse the discovered clusters until the ttl passed", func() {
+		filter := map[string]string{"environment": "dev"}
+		clusters, err := discovery.Discover(context.Background(), subscriptionID, filter)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).To(HaveLen(2))
+
+		now = now.Add(59 * time.Second)
+		cached, err := discovery.Discover(context.Background(), subscriptionID, filter)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached).To(Equal(clusters))
+		Expect(queries).To(HaveLen(2))
+
+		now = now.Add(time.Second)
+		_, err = discovery.Discover(context.Background(), subscriptionID, filter)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).To(HaveLen(4))
+	})
+	It("should fail when the query fails", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+		_, err := discovery.Discover(context.Background(), subscriptionID, nil)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should match the tags with case insensitive names", func() {
+		tags := map[string]string{"Environment": "prod"}
+		Expect(kustoutils.MatchesTags(tags, nil)).To(BeTrue())
+		Expect(kustoutils.MatchesTags(tags, map[string]string{"environment": "prod"})).To(BeTrue())
+		Expect(kustoutils.MatchesTags(tags, map[string]string{"environment": "Prod"})).To(BeFalse())
+		Expect(kustoutils.MatchesTags(tags, map[string]string{"team": "a"})).To(BeFalse())
+	})
+})
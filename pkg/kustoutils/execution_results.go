@@ -0,0 +1,120 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// ExecutionResult is the outcome of the delta-kusto jobs run by an execution.
+type ExecutionResult struct {
+	// Output is the output of the job, or a json object of the output of every job by its ID when the execution ran several jobs.
+	Output      string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	// ExitCode is the exit code of the first failed job, zero when all the jobs succeeded.
+	ExitCode int
+}
+
+// jobResult is the result of a delta-kusto job recorded in the result directory of its execution.
+type jobResult struct {
+	JobID       string    `json:"jobID"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+	ExitCode    int       `json:"exitCode"`
+	Output      string    `json:"output"`
+}
+
+// recordDeltaResult writes the result of the job to a new file of the `dir`, jobs of an execution may run concurrently.
+func recordDeltaResult(dir string, result *DeltaResult) error {
+	f, err := os.CreateTemp(dir, "result-*.json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(jobResult{
+		JobID:       result.JobID,
+		StartedAt:   result.StartedAt,
+		CompletedAt: result.StartedAt.Add(result.Duration),
+		ExitCode:    result.ExitCode,
+		Output:      string(result.Output),
+	})
+}
+
+// ExecutionResult reads the results of the delta-kusto jobs run by the execution described by `config`, and removes them.
+// Executions without recorded jobs return a nil result.
+func (c *KustoCluster) ExecutionResult(config schemav1alpha1.ExecutionConfiguration) (*ExecutionResult, error) {
+	if config.ResultDir == "" {
+		return nil, nil
+	}
+	defer os.RemoveAll(config.ResultDir)
+	files, err := filepath.Glob(filepath.Join(config.ResultDir, "result-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]jobResult, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		job := jobResult{}
+		if err := json.Unmarshal(content, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.Before(jobs[j].StartedAt) })
+
+	result := &ExecutionResult{StartedAt: jobs[0].StartedAt, Output: jobs[0].Output}
+	outputs := make(map[string]json.RawMessage, len(jobs))
+	for _, job := range jobs {
+		if job.CompletedAt.After(result.CompletedAt) {
+			result.CompletedAt = job.CompletedAt
+		}
+		if result.ExitCode == 0 {
+			result.ExitCode = job.ExitCode
+		}
+		outputs[job.JobID] = jsonOutput(job.Output)
+	}
+	if len(jobs) > 1 {
+		combined, err := json.Marshal(outputs)
+		if err != nil {
+			return nil, err
+		}
+		result.Output = string(combined)
+	}
+	return result, nil
+}
+
+// jsonOutput returns the job output as is when it is json, and as a json string otherwise.
+func jsonOutput(output string) json.RawMessage {
+	if json.Valid([]byte(output)) {
+		return json.RawMessage(output)
+	}
+	quoted, _ := json.Marshal(output)
+	return quoted
+}
+
+// exitCode returns the exit code of the process that failed with `err`, -1 when it failed without exiting.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Execution results", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &mockKusto{}}
+	})
+	configuration := func(data map[string]string) schemav1alpha1.ExecutionConfiguration {
+		data["kql"] = ".create-merge table T (a:string)"
+		config, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: data}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ResultDir).To(BeADirectory())
+		return config
+	}
+
+	It("should record the output of the job", func() {
+		config := configuration(map[string]string{})
+		defer kustoutils.SetDeltaRunnerWithOutput(func(jobID string, stdout io.Writer) error {
+			_, err := fmt.Fprint(stdout, `{"jobs":[{"name":"main","actions":2}]}`)
+			return err
+		})()
+		_, err := cluster.Execute(targets, config)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := cluster.ExecutionResult(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Output).To(MatchJSON(`{"jobs":[{"name":"main","actions":2}]}`))
+		Expect(result.ExitCode).To(BeZero())
+		Expect(result.CompletedAt).NotTo(BeTemporally("<", result.StartedAt))
+		Expect(config.ResultDir).NotTo(BeADirectory())
+	})
+	It("should record the exit code of a failed job", func() {
+		config := configuration(map[string]string{})
+		defer kustoutils.SetDeltaRunnerWithOutput(func(jobID string, stdout io.Writer) error {
+			_, _ = fmt.Fprint(stdout, "connection refused")
+			return exec.Command("sh", "-c", "exit 3").Run()
+		})()
+		_, err := cluster.Execute(targets, config)
+		Expect(err).To(HaveOccurred())
+
+		result, err := cluster.ExecutionResult(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Output).To(Equal("connection refused"))
+		Expect(result.ExitCode).To(Equal(3))
+	})
+	It("should combine the output of the jobs of an isolated execution", func() {
+		config := configuration(map[string]string{kustoutils.IsolateExecutionsKey: "true"})
+		defer kustoutils.SetDeltaRunnerWithOutput(func(jobID string, stdout io.Writer) error {
+			_, err := fmt.Fprintf(stdout, `{"database":%q}`, path.Base(jobID))
+			return err
+		})()
+		_, err := cluster.Execute(targets, config)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := cluster.ExecutionResult(config)
+		Expect(err).NotTo(HaveOccurred())
+		outputs := map[string]map[string]string{}
+		Expect(json.Unmarshal([]byte(result.Output), &outputs)).To(Succeed())
+		Expect(outputs).To(Equal(map[string]map[string]string{
+			config.JobID + "/db1": {"database": "db1"},
+			config.JobID + "/db2": {"database": "db2"},
+		}))
+	})
+	It("should return no result without recorded jobs", func() {
+		result, err := cluster.ExecutionResult(schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+	})
+})
@@ -0,0 +1,121 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// SetDeltaRunner replaces the delta-kusto runner and returns a function restoring the original.
+func SetDeltaRunner(run func(jobFile string) error) func() {
+	orig := runDeltaKusto
+	runDeltaKusto = func(ctx context.Context, w *Wrapper, jobID, dir, jobFile string, stdout io.Writer) error {
+		return run(jobFile)
+	}
+	return func() { runDeltaKusto = orig }
+}
+
+// SetDeltaRunnerWithOutput replaces the delta-kusto runner with one writing the output of the job to `stdout`.
+func SetDeltaRunnerWithOutput(run func(jobID string, stdout io.Writer) error) func() {
+	orig := runDeltaKusto
+	runDeltaKusto = func(ctx context.Context, w *Wrapper, jobID, dir, jobFile string, stdout io.Writer) error {
+		return run(jobID, stdout)
+	}
+	return func() { runDeltaKusto = orig }
+}
+
+// RunJobCommand runs `cmd` as the delta-kusto job `jobID` of the wrapper.
+func RunJobCommand(w *Wrapper, jobID string, cmd *exec.Cmd) error {
+	return w.run(context.Background(), jobID, cmd)
+}
+
+// IsJobRunning reports if the job `jobID` is registered as running.
+func IsJobRunning(jobID string) bool {
+	deltaJobs.mu.Lock()
+	defer deltaJobs.mu.Unlock()
+	_, ok := deltaJobs.processes[jobID]
+	return ok
+}
+
+// SetImportRetryDelay replaces the schema download retry delay and returns a function restoring the original.
+func SetImportRetryDelay(delay time.Duration) func() {
+	orig := importRetryDelay
+	importRetryDelay = delay
+	return func() { importRetryDelay = orig }
+}
+
+// SetWriteFile replaces the schema file writer and returns a function restoring the original.
+func SetWriteFile(write func(name string, data []byte, perm os.FileMode) error) func() {
+	orig := writeFile
+	writeFile = write
+	return func() { writeFile = orig }
+}
+
+// NewTestClusterClientCache returns a cache creating its clusters with `newCluster` and reading the time from `now`.
+func NewTestClusterClientCache(ttl time.Duration, newCluster func(uri string) *KustoCluster, now func() time.Time) *ClusterClientCache {
+	cache := NewClusterClientCache(ttl)
+	cache.newCluster = newCluster
+	cache.now = now
+	return cache
+}
+
+// SetTenantClusterFactory replaces the creation of the tenant clusters of the `cache`.
+func SetTenantClusterFactory(cache *ClusterClientCache, newTenantCluster func(uri string, creds TenantCredentials) *KustoCluster) {
+	cache.newTenantCluster = newTenantCluster
+}
+
+// JobCredentials returns the credentials the delta-kusto jobs of the `cluster` run with.
+func JobCredentials(cluster *KustoCluster) *TenantCredentials {
+	return cluster.wrapper.credentials()
+}
+
+// SetIsolatedDeltaRunner replaces the delta-kusto runner with one receiving the working directory of the job.
+func SetIsolatedDeltaRunner(run func(jobID, dir, jobFile string) error) func() {
+	orig := runDeltaKusto
+	runDeltaKusto = func(ctx context.Context, w *Wrapper, jobID, dir, jobFile string, stdout io.Writer) error {
+		return run(jobID, dir, jobFile)
+	}
+	return func() { runDeltaKusto = orig }
+}
+
+// SetDeltaRunnerWithContext replaces the delta-kusto runner with one receiving the context the job runs in.
+func SetDeltaRunnerWithContext(run func(ctx context.Context, jobID, dir, jobFile string) error) func() {
+	orig := runDeltaKusto
+	runDeltaKusto = func(ctx context.Context, w *Wrapper, jobID, dir, jobFile string, stdout io.Writer) error {
+		return run(ctx, jobID, dir, jobFile)
+	}
+	return func() { runDeltaKusto = orig }
+}
+
+// RunJobCommandContext runs `cmd` as the delta-kusto job `jobID`, cancelling it once `ctx` is done.
+func RunJobCommandContext(ctx context.Context, w *Wrapper, jobID string, cmd *exec.Cmd) error {
+	return w.run(ctx, jobID, cmd)
+}
+
+// SetWebHookClock replaces the time the idempotency keys of the webhook client `c` are computed with.
+func SetWebHookClock(c *WebHookClient, now func() time.Time) {
+	c.now = now
+}
+
+// RunDeltaJob runs the delta-kusto job file with the plugins of the wrapper `w`.
+func RunDeltaJob(w *Wrapper, jobID, jobFile string) error {
+	return runDeltaJob(context.Background(), w, jobID, "", jobFile, "")
+}
+
+// NewTestClusterDiscovery returns a discovery querying the `endpoint` with `sender`, creating its clusters with
+// `newCluster` and reading the time from `now`.
+func NewTestClusterDiscovery(ttl time.Duration, endpoint string, sender autorest.Sender, newCluster func(uri string) *KustoCluster, now func() time.Time) *ClusterDiscovery {
+	discovery := NewClusterDiscovery(ttl)
+	discovery.Endpoint = endpoint
+	discovery.Sender = sender
+	discovery.RetryAttempts = 0
+	discovery.newCluster = newCluster
+	discovery.now = now
+	return discovery
+}
@@ -0,0 +1,198 @@
+// Package fake provides an in-memory kusto cluster implementing `kustoutils.QueryClient`,
+// to test the kusto code paths without a live cluster.
+package fake
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/Azure/azure-kusto-go/kusto/data/value"
+	"github.com/Azure/go-autorest/autorest"
+
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+)
+
+var _ kustoutils.QueryClient = &FakeKustoCluster{}
+
+const (
+	// QueryCall is the `Kind` of the calls to `Query`.
+	QueryCall = "Query"
+	// MgmtCall is the `Kind` of the calls to `Mgmt`.
+	MgmtCall = "Mgmt"
+)
+
+// showDatabasesPrefix starts the database listing commands answered from the `WithDatabaseList` databases.
+const showDatabasesPrefix = ".show databases"
+
+var (
+	// resultColumns are the columns of the statements without a response.
+	resultColumns = table.Columns{{Name: "Result", Type: types.String}}
+	// databaseColumns are the columns of the `.show databases` commands.
+	databaseColumns = table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "DatabaseAccessMode", Type: types.String}}
+)
+
+// Call is a statement run on the fake cluster.
+type Call struct {
+	// Kind is `QueryCall` or `MgmtCall`.
+	Kind string
+	DB   string
+	Stmt string
+}
+
+// FakeKustoCluster answers the statements with preconfigured responses and records every call.
+// Statements without a response return no rows, the `.show databases` commands return the database list.
+type FakeKustoCluster struct {
+	endpoint  string
+	mu        sync.Mutex
+	databases []string
+	mgmt      map[string][]table.Row
+	query     map[string][]table.Row
+	errs      map[string]error
+	calls     []Call
+}
+
+// NewFakeKustoCluster returns a fake cluster with the `endpoint` URI and no databases.
+func NewFakeKustoCluster(endpoint string) *FakeKustoCluster {
+	return &FakeKustoCluster{
+		endpoint: endpoint,
+		mgmt:     make(map[string][]table.Row),
+		query:    make(map[string][]table.Row),
+		errs:     make(map[string]error),
+	}
+}
+
+// WithDatabaseList sets the databases of the cluster, listed by the `.show databases` commands as read-write databases.
+func (f *FakeKustoCluster) WithDatabaseList(dbs []string) *FakeKustoCluster {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.databases = append([]string{}, dbs...)
+	return f
+}
+
+// WithMgmtResponse answers the management command `stmt` with the `rows`, typed by the columns of the first row.
+// A response configured for a `.show databases` command takes precedence over the database list.
+func (f *FakeKustoCluster) WithMgmtResponse(stmt string, rows []table.Row) *FakeKustoCluster {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mgmt[normalize(stmt)] = rows
+	return f
+}
+
+// WithQueryResponse answers the query `stmt` with the `rows`, typed by the columns of the first row.
+func (f *FakeKustoCluster) WithQueryResponse(stmt string, rows []table.Row) *FakeKustoCluster {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.query[normalize(stmt)] = rows
+	return f
+}
+
+// WithError fails the query or management command `stmt` with `err`.
+func (f *FakeKustoCluster) WithError(stmt string, err error) *FakeKustoCluster {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[normalize(stmt)] = err
+	return f
+}
+
+// Calls returns the calls run on the cluster, in order.
+func (f *FakeKustoCluster) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call{}, f.calls...)
+}
+
+// Cluster returns a `KustoCluster` using the fake as its client.
+func (f *FakeKustoCluster) Cluster() *kustoutils.KustoCluster {
+	return &kustoutils.KustoCluster{URI: f.endpoint, Client: f}
+}
+
+// Close is a no-op.
+func (f *FakeKustoCluster) Close() error {
+	return nil
+}
+
+// Auth returns an empty basic authorization.
+func (f *FakeKustoCluster) Auth() kusto.Authorization {
+	return kusto.Authorization{Authorizer: autorest.NewBasicAuthorizer("", "")}
+}
+
+// Endpoint returns the cluster URI.
+func (f *FakeKustoCluster) Endpoint() string {
+	return f.endpoint
+}
+
+// HttpClient returns the default HTTP client.
+func (f *FakeKustoCluster) HttpClient() *http.Client {
+	return http.DefaultClient
+}
+
+// Query records the query and returns its configured response.
+func (f *FakeKustoCluster) Query(ctx context.Context, db string, query kusto.Stmt, options ...kusto.QueryOption) (*kusto.RowIterator, error) {
+	return f.run(QueryCall, db, query.String(), f.query)
+}
+
+// Mgmt records the management command and returns its configured response.
+func (f *FakeKustoCluster) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	return f.run(MgmtCall, db, query.String(), f.mgmt)
+}
+
+// run records the call of `stmt` and plays back its error or rows.
+func (f *FakeKustoCluster) run(kind, db, stmt string, responses map[string][]table.Row) (*kusto.RowIterator, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Kind: kind, DB: db, Stmt: stmt})
+	key := normalize(stmt)
+	if err, ok := f.errs[key]; ok {
+		return nil, err
+	}
+	if rows, ok := responses[key]; ok {
+		columns := resultColumns
+		if len(rows) > 0 {
+			columns = rows[0].ColumnTypes
+		}
+		return playback(columns, rows)
+	}
+	if kind == MgmtCall && strings.HasPrefix(key, showDatabasesPrefix) {
+		return playback(databaseColumns, f.databaseRows())
+	}
+	return playback(resultColumns, nil)
+}
+
+// databaseRows returns the rows of the databases, listed as read-write databases.
+func (f *FakeKustoCluster) databaseRows() []table.Row {
+	rows := make([]table.Row, 0, len(f.databases))
+	for _, db := range f.databases {
+		rows = append(rows, table.Row{
+			ColumnTypes: databaseColumns,
+			Values:      value.Values{value.String{Valid: true, Value: db}, value.String{Valid: true, Value: "ReadWrite"}},
+		})
+	}
+	return rows
+}
+
+// playback returns a `RowIterator` over the rows.
+func playback(columns table.Columns, rows []table.Row) (*kusto.RowIterator, error) {
+	mock, err := kusto.NewMockRows(columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := mock.Row(row.Values); err != nil {
+			return nil, err
+		}
+	}
+	iter := &kusto.RowIterator{}
+	return iter, iter.Mock(mock)
+}
+
+// normalize trims the statements so the responses match regardless of the surrounding whitespace.
+func normalize(stmt string) string {
+	return strings.TrimSpace(stmt)
+}
@@ -0,0 +1,112 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"errors"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/Azure/azure-kusto-go/kusto/data/value"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils/fake"
+)
+
+var _ = Describe("FakeKustoCluster", func() {
+	const listDatabases = ".show databases | project DatabaseName"
+	var cluster *fake.FakeKustoCluster
+
+	BeforeEach(func() {
+		cluster = fake.NewFakeKustoCluster("https://fake.westeurope.kusto.windows.net").
+			WithDatabaseList([]string{"tenant_1", "tenant_2", "audit"})
+	})
+
+	stringRow := func(columns table.Columns, cells ...string) table.Row {
+		values := value.Values{}
+		for _, cell := range cells {
+			values = append(values, value.String{Valid: true, Value: cell})
+		}
+		return table.Row{ColumnTypes: columns, Values: values}
+	}
+
+	Context("ListDatabases", func() {
+		It("should list the matching databases", func() {
+			dbs, err := cluster.Cluster().ListDatabases("tenant_.*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbs).To(Equal([]string{"tenant_1", "tenant_2"}))
+			Expect(cluster.Calls()).To(Equal([]fake.Call{{Kind: fake.MgmtCall, Stmt: listDatabases}}))
+		})
+		It("should return the injected error", func() {
+			cluster.WithError(listDatabases, errors.New("throttled"))
+			_, err := cluster.Cluster().ListDatabases("")
+			Expect(err).To(MatchError("throttled"))
+		})
+	})
+
+	Context("AquireTargets", func() {
+		It("should skip the follower databases", func() {
+			columns := table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "DatabaseAccessMode", Type: types.String}}
+			cluster.WithMgmtResponse(".show databases | project DatabaseName, DatabaseAccessMode", []table.Row{
+				stringRow(columns, "tenant_1", "ReadWrite"),
+				stringRow(columns, "tenant_2", "ReadOnlyFollowing"),
+			})
+			targets, err := cluster.Cluster().AquireTargets(schemav1alpha1.TargetFilter{DB: "tenant_"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"tenant_1"}))
+			Expect(cluster.Calls()).To(HaveLen(2))
+		})
+		It("should create the missing listed databases", func() {
+			targets, err := cluster.Cluster().AquireTargets(schemav1alpha1.TargetFilter{DBS: []string{"tenant_1", "tenant_3"}, AutoCreateDatabases: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"tenant_1", "tenant_3"}))
+			Expect(cluster.Calls()).To(ContainElement(fake.Call{Kind: fake.MgmtCall, Stmt: ".create database ['tenant_3'] ifnotexists"}))
+			Expect(cluster.Calls()).NotTo(ContainElement(fake.Call{Kind: fake.MgmtCall, Stmt: ".create database ['tenant_1'] ifnotexists"}))
+		})
+	})
+
+	Context("Execute", func() {
+		const preCondition = "T | take 1"
+		var restore func()
+		var jobs []string
+
+		BeforeEach(func() {
+			jobs = nil
+			restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+				jobs = append(jobs, jobFile)
+				return nil
+			})
+		})
+		AfterEach(func() {
+			restore()
+		})
+
+		It("should run the pre-condition on every database before the job", func() {
+			cluster.WithQueryResponse(preCondition, []table.Row{stringRow(table.Columns{{Name: "a", Type: types.String}}, "x")})
+			done, err := cluster.Cluster().Execute(
+				schemav1alpha1.ClusterTargets{DBs: []string{"tenant_1", "tenant_2"}},
+				schemav1alpha1.ExecutionConfiguration{JobID: "fake", JobFile: "job.yaml", PreConditionKQL: preCondition})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+				"tenant_1": schemav1alpha1.DBResultExecuted,
+				"tenant_2": schemav1alpha1.DBResultExecuted,
+			}))
+			Expect(jobs).To(Equal([]string{"job.yaml"}))
+			Expect(cluster.Calls()).To(Equal([]fake.Call{
+				{Kind: fake.QueryCall, DB: "tenant_1", Stmt: preCondition},
+				{Kind: fake.QueryCall, DB: "tenant_2", Stmt: preCondition},
+			}))
+		})
+		It("should not run the job when the pre-condition fails", func() {
+			cluster.WithError(preCondition, errors.New("semantic error"))
+			_, err := cluster.Cluster().Execute(
+				schemav1alpha1.ClusterTargets{DBs: []string{"tenant_1"}},
+				schemav1alpha1.ExecutionConfiguration{JobID: "fake", JobFile: "job.yaml", PreConditionKQL: preCondition})
+			Expect(err).To(MatchError("semantic error"))
+			Expect(jobs).To(BeEmpty())
+		})
+	})
+})
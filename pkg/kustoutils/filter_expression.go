@@ -0,0 +1,105 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/filterexpr"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// databaseDetailsCmd lists the name, the tags (kept in the pretty name) and the size in bytes of the databases.
+	databaseDetailsCmd = ".show databases details | project DatabaseName, PrettyName, TotalSize"
+	// databaseCreationCmd lists the creation time of the databases still recorded in the journal.
+	databaseCreationCmd = ".show journal | where Event == 'CREATE-DATABASE' | summarize CreatedAt = min(EventTimestamp) by DatabaseName = EntityName"
+)
+
+// FilterByExpression returns the `dbs` whose properties pass the filter expression `source`.
+// The tags of a database are read from its pretty name, `key=value` pairs separated by `;`.
+func (c *KustoCluster) FilterByExpression(ctx context.Context, dbs []string, source string) ([]string, error) {
+	expr, err := filterexpr.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := c.databaseProperties(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]string, 0, len(dbs))
+	for _, db := range dbs {
+		props, ok := properties[db]
+		if !ok {
+			props = filterexpr.Database{Name: db}
+		}
+		if expr.Match(props) {
+			matching = append(matching, db)
+		}
+	}
+	log.Debug().Msgf("%d of %d databases on %s match %s", len(matching), len(dbs), c.URI, source)
+	return matching, nil
+}
+
+// databaseProperties returns the filter expression properties of every database in the cluster.
+func (c *KustoCluster) databaseProperties(ctx context.Context) (map[string]filterexpr.Database, error) {
+	properties := map[string]filterexpr.Database{}
+	err := c.eachRow(ctx, databaseDetailsCmd, func(columns map[string]string) {
+		db := filterexpr.Database{Name: columns["DatabaseName"], Tags: parseTags(columns["PrettyName"])}
+		if size, err := strconv.ParseFloat(columns["TotalSize"], 64); err == nil {
+			db.SizeGB = size / 1e9
+		}
+		properties[db.Name] = db
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.eachRow(ctx, databaseCreationCmd, func(columns map[string]string) {
+		db, ok := properties[columns["DatabaseName"]]
+		if !ok {
+			return
+		}
+		if createdAt, err := time.Parse(time.RFC3339Nano, columns["CreatedAt"]); err == nil {
+			db.CreatedAt = createdAt
+			properties[db.Name] = db
+		}
+	})
+	return properties, err
+}
+
+// eachRow runs the management command `cmd` and calls `handle` with the values of every row by their column name.
+func (c *KustoCluster) eachRow(ctx context.Context, cmd string, handle func(columns map[string]string)) error {
+	iter, err := c.Client.Mgmt(ctx, "", newUnsafeStmt(cmd))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed running: %s", cmd)
+		return err
+	}
+	defer iter.Stop()
+
+	return iter.Do(
+		func(row *table.Row) error {
+			columns := make(map[string]string, len(row.ColumnTypes))
+			for i, col := range row.ColumnTypes {
+				columns[col.Name] = row.Values[i].String()
+			}
+			handle(columns)
+			return nil
+		},
+	)
+}
+
+// parseTags reads the `key=value` pairs separated by `;` of a database pretty name, ignoring other text.
+func parseTags(prettyName string) map[string]string {
+	tags := map[string]string{}
+	for _, pair := range strings.Split(prettyName, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return tags
+}
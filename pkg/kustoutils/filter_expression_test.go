@@ -0,0 +1,75 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// databasePropertiesHandler answers the database listings with three databases of different sizes, tags and ages.
+func databasePropertiesHandler(db, stmt string) (*kusto.RowIterator, error) {
+	switch {
+	case strings.HasPrefix(stmt, ".show databases details"):
+		columns := table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "PrettyName", Type: types.String}, {Name: "TotalSize", Type: types.String}}
+		return mockRows(columns,
+			[]string{"prod-orders", "env=production; team=orders", "2.5e+11"},
+			[]string{"prod-scratch", "env=production", "5e+09"},
+			[]string{"staging-orders", "Staging orders", "1.2e+11"},
+		)
+	case strings.HasPrefix(stmt, ".show journal"):
+		columns := table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "CreatedAt", Type: types.String}}
+		return mockRows(columns,
+			[]string{"prod-orders", "2023-05-01T10:00:00Z"},
+			[]string{"staging-orders", "2024-02-01T10:00:00Z"},
+		)
+	}
+	columns := table.Columns{{Name: "DatabaseName", Type: types.String}}
+	return mockRows(columns, []string{"prod-orders"}, []string{"prod-scratch"}, []string{"staging-orders"})
+}
+
+var _ = Describe("Filter expressions", func() {
+	var client *scriptedKusto
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		client = &scriptedKusto{mgmt: databasePropertiesHandler}
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: client}
+	})
+
+	It("should target the databases matching the expression", func() {
+		targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{
+			FilterExpression: `db.name =~ "^prod-" && db.tag["env"] == "production" && db.sizeGB > 100`,
+			IncludeFollowers: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets.DBs).To(Equal([]string{"prod-orders"}))
+	})
+	It("should narrow the databases of the db regexp", func() {
+		targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{
+			DB:               "orders$",
+			FilterExpression: `db.createdAt >= "2024-01-01" || db.tag["team"] == "orders"`,
+			IncludeFollowers: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets.DBs).To(Equal([]string{"prod-orders", "staging-orders"}))
+	})
+	It("should treat databases without tags or journal entries as untagged", func() {
+		dbs, err := cluster.FilterByExpression(context.Background(), []string{"prod-scratch", "staging-orders", "missing"}, `!(db.tag["env"] == "production") && db.createdAt < "2000-01-01"`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(Equal([]string{"missing"}))
+	})
+	It("should fail on invalid expressions without querying the cluster", func() {
+		_, err := cluster.FilterByExpression(context.Background(), []string{"prod-orders"}, `db.sizeGB > "big"`)
+		Expect(err).To(MatchError(ContainSubstring("invalid filter expression")))
+		Expect(client.stmts).To(BeEmpty())
+	})
+})
@@ -0,0 +1,207 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	kqlTableCmdRe   = regexp.MustCompile(`(?im)^\s*\.(?:create|create-merge|create-or-alter)\s+(tables?)\b`)
+	kqlEntityNameRe = regexp.MustCompile(`^\s*(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)`)
+	kqlFunctionRe   = regexp.MustCompile(`(?im)^\s*\.(?:create|create-or-alter|alter)\s+function\s+(?:ifnotexists\s+)?(?:with\s*\([^)]*\)\s*)?(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s*\(`)
+)
+
+// ParseKQLObjects returns the names of the tables and functions defined in the `kql` script.
+// Both the `.create table T (...)` and the `.create tables A (...), B (...)` forms are parsed,
+// an error is returned along the parsed names when a table definition could not be read.
+func ParseKQLObjects(kql string) (tables []string, functions []string, err error) {
+	for _, loc := range kqlTableCmdRe.FindAllStringSubmatchIndex(kql, -1) {
+		names, ok := parseTableNames(kql[loc[1]:], strings.EqualFold(kql[loc[2]:loc[3]], "tables"))
+		if !ok {
+			line := strings.TrimSpace(strings.SplitN(kql[loc[0]:], "\n", 2)[0])
+			err = fmt.Errorf("could not parse the table definition: %s", line)
+			continue
+		}
+		tables = append(tables, names...)
+	}
+	for _, match := range kqlFunctionRe.FindAllStringSubmatch(kql, -1) {
+		functions = append(functions, unquoteEntityName(match[1]))
+	}
+	return tables, functions, err
+}
+
+// parseTableNames reads the table name following a `.create table` command, or the names of the
+// `A (columns), B (columns)` list following a `.create tables` command.
+func parseTableNames(definition string, list bool) ([]string, bool) {
+	var names []string
+	for {
+		match := kqlEntityNameRe.FindStringSubmatchIndex(definition)
+		if match == nil {
+			return nil, false
+		}
+		names = append(names, unquoteEntityName(definition[match[2]:match[3]]))
+		if !list {
+			return names, true
+		}
+		definition = strings.TrimLeftFunc(definition[match[1]:], unicode.IsSpace)
+		end := closingParen(definition)
+		if end < 0 {
+			return nil, false
+		}
+		definition = strings.TrimLeftFunc(definition[end+1:], unicode.IsSpace)
+		if !strings.HasPrefix(definition, ",") {
+			return names, true
+		}
+		definition = definition[1:]
+	}
+}
+
+// closingParen returns the index of the parenthesis closing the one `s` starts with, or -1.
+func closingParen(s string) int {
+	if !strings.HasPrefix(s, "(") {
+		return -1
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquoteEntityName strips the `['name']` quoting from an entity name.
+func unquoteEntityName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		name = strings.TrimSpace(name[1 : len(name)-1])
+		name = strings.Trim(name, `'"`)
+	}
+	return name
+}
+
+// CollectGarbage drops the tables and functions of `db` that are not defined in the `kql` script.
+// Objects prefixed with `_` are system objects and are never dropped.
+// When `failIfDataLoss` is set and surplus tables are found nothing is dropped and an error is returned.
+// Nothing is dropped either when the `kql` holds table definitions that could not be parsed.
+func (c *KustoCluster) CollectGarbage(ctx context.Context, db string, kql string, failIfDataLoss bool) error {
+	desiredTables, desiredFunctions, err := ParseKQLObjects(kql)
+	if err != nil {
+		return fmt.Errorf("refusing to garbage collect %s: %w", db, err)
+	}
+
+	tables, err := c.listEntities(ctx, db, fmt.Sprintf(".show database ['%s'] schema", db), "TableName")
+	if err != nil {
+		return err
+	}
+	functions, err := c.listEntities(ctx, db, ".show functions", "Name")
+	if err != nil {
+		return err
+	}
+	surplusTables := surplusEntities(tables, desiredTables)
+	surplusFunctions := surplusEntities(functions, desiredFunctions)
+
+	if failIfDataLoss && len(surplusTables) > 0 {
+		return fmt.Errorf("garbage collection of tables %v in %s would lose data", surplusTables, db)
+	}
+	for _, function := range surplusFunctions {
+		if err := c.runMgmt(ctx, db, fmt.Sprintf(".drop function ['%s'] ifexists", function)); err != nil {
+			return err
+		}
+	}
+	for _, tbl := range surplusTables {
+		if err := c.runMgmt(ctx, db, fmt.Sprintf(".drop table ['%s'] ifexists", tbl)); err != nil {
+			return err
+		}
+	}
+	log.Info().Msgf("garbage collected %s: %d tables, %d functions", db, len(surplusTables), len(surplusFunctions))
+	return nil
+}
+
+// DropManagedObjects drops the tables and functions defined in the `kql` script from the target databases.
+// Functions are dropped first, as they may reference the tables.
+func (c *KustoCluster) DropManagedObjects(ctx context.Context, targets schemav1alpha1.ClusterTargets, kql string) error {
+	tables, functions, err := ParseKQLObjects(kql)
+	if err != nil {
+		return err
+	}
+	for _, db := range targets.DBs {
+		for _, function := range functions {
+			if err := c.runMgmt(ctx, db, fmt.Sprintf(".drop function ['%s'] ifexists", function)); err != nil {
+				return err
+			}
+		}
+		for _, tbl := range tables {
+			if err := c.runMgmt(ctx, db, fmt.Sprintf(".drop table ['%s'] ifexists", tbl)); err != nil {
+				return err
+			}
+		}
+		log.Info().Msgf("dropped the managed objects of %s: %d tables, %d functions", db, len(tables), len(functions))
+	}
+	return nil
+}
+
+// listEntities returns the distinct, non empty values of `column` returned by the `cmd` management command.
+func (c *KustoCluster) listEntities(ctx context.Context, db, cmd, column string) ([]string, error) {
+	iter, err := c.MgmtWithTimeout(ctx, db, newUnsafeStmt(cmd), c.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("failed running: %s", cmd)
+		return nil, err
+	}
+	defer iter.Stop()
+
+	seen := make(map[string]struct{})
+	err = iter.Do(
+		func(row *table.Row) error {
+			for i, col := range row.ColumnTypes {
+				if col.Name == column {
+					name := row.Values[i].String()
+					if name != "" {
+						seen[name] = struct{}{}
+					}
+				}
+			}
+			return nil
+		},
+	)
+	entities := make([]string, 0, len(seen))
+	for name := range seen {
+		entities = append(entities, name)
+	}
+	sort.Strings(entities)
+	return entities, err
+}
+
+// surplusEntities returns the entities in `existing` missing from `desired`, excluding system (`_` prefixed) entities.
+func surplusEntities(existing, desired []string) []string {
+	wanted := make(map[string]struct{}, len(desired))
+	for _, name := range desired {
+		wanted[name] = struct{}{}
+	}
+	var surplus []string
+	for _, name := range existing {
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		if _, found := wanted[name]; !found {
+			surplus = append(surplus, name)
+		}
+	}
+	return surplus
+}
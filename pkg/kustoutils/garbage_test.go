@@ -0,0 +1,132 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const desiredKQL = `
+.create-merge table Events (Timestamp:datetime, Name:string)
+
+.create-merge table ['Audit Log'] (Timestamp:datetime)
+
+.create-or-alter function with (folder='views') LatestEvents() {
+    Events | top 10 by Timestamp
+}
+`
+
+const desiredTablesKQL = `
+.create-merge tables Events (Timestamp:datetime, Payload:dynamic),
+    ['Audit Log'] (Timestamp:datetime), Metrics (Name:string, Value:real) with (folder='raw')
+
+.create-or-alter function LatestEvents() { Events | top 10 by Timestamp }
+`
+
+// schemaHandler answers `.show database schema` and `.show functions` with the given entities.
+func schemaHandler(tables []string, functions []string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		switch {
+		case strings.HasPrefix(stmt, ".show database"):
+			rows := [][]string{}
+			for _, tbl := range tables {
+				rows = append(rows, []string{db, tbl, "Timestamp"})
+			}
+			return mockRows(table.Columns{
+				{Name: "DatabaseName", Type: types.String},
+				{Name: "TableName", Type: types.String},
+				{Name: "ColumnName", Type: types.String},
+			}, rows...)
+		case strings.HasPrefix(stmt, ".show functions"):
+			rows := [][]string{}
+			for _, function := range functions {
+				rows = append(rows, []string{function})
+			}
+			return mockRows(table.Columns{{Name: "Name", Type: types.String}}, rows...)
+		}
+		return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+	}
+}
+
+var _ = Describe("Garbage collection", func() {
+	It("should parse tables and functions from kql", func() {
+		tables, functions, err := kustoutils.ParseKQLObjects(desiredKQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tables).To(ConsistOf("Events", "Audit Log"))
+		Expect(functions).To(ConsistOf("LatestEvents"))
+	})
+	It("should parse the tables list form", func() {
+		tables, _, err := kustoutils.ParseKQLObjects(desiredTablesKQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tables).To(Equal([]string{"Events", "Audit Log", "Metrics"}))
+	})
+	It("should not collect the tables of a tables list", func() {
+		client := &scriptedKusto{mgmt: schemaHandler(
+			[]string{"Events", "Audit Log", "Metrics"},
+			[]string{"LatestEvents"},
+		)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.CollectGarbage(context.Background(), "tenant_1", desiredTablesKQL, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+	})
+	It("should refuse to collect when a table definition could not be parsed", func() {
+		client := &scriptedKusto{mgmt: schemaHandler([]string{"Events", "OldEvents"}, nil)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.CollectGarbage(context.Background(), "tenant_1", ".create-merge tables Events (Timestamp:datetime", false)
+		Expect(err).To(MatchError(ContainSubstring("could not parse the table definition")))
+		Expect(client.stmts).To(BeEmpty())
+	})
+	It("should drop only the surplus objects", func() {
+		client := &scriptedKusto{mgmt: schemaHandler(
+			[]string{"Events", "Audit Log", "OldEvents"},
+			[]string{"LatestEvents", "OldView"},
+		)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.CollectGarbage(context.Background(), "tenant_1", desiredKQL, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.stmts).To(ContainElement(".drop table ['OldEvents'] ifexists"))
+		Expect(client.stmts).To(ContainElement(".drop function ['OldView'] ifexists"))
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(2))
+	})
+	It("should never drop system objects", func() {
+		client := &scriptedKusto{mgmt: schemaHandler(
+			[]string{"Events", "Audit Log", "_Staging"},
+			[]string{"LatestEvents", "_Helper"},
+		)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.CollectGarbage(context.Background(), "tenant_1", desiredKQL, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+	})
+	It("should fail without dropping when tables would be lost", func() {
+		client := &scriptedKusto{mgmt: schemaHandler(
+			[]string{"Events", "Audit Log", "OldEvents"},
+			[]string{"LatestEvents", "OldView"},
+		)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.CollectGarbage(context.Background(), "tenant_1", desiredKQL, true)
+		Expect(err).To(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+	})
+	It("should drop the managed objects of every target", func() {
+		client := &scriptedKusto{mgmt: schemaHandler(nil, nil)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"tenant_1", "tenant_2"}}
+		err := cluster.DropManagedObjects(context.Background(), targets, desiredKQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.stmts).To(Equal([]string{
+			".drop function ['LatestEvents'] ifexists", ".drop table ['Events'] ifexists", ".drop table ['Audit Log'] ifexists",
+			".drop function ['LatestEvents'] ifexists", ".drop table ['Events'] ifexists", ".drop table ['Audit Log'] ifexists",
+		}))
+	})
+})
@@ -0,0 +1,34 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultPreApplyHookTimeout is the time the pre-apply hooks of a database have to complete, unless `PreApplyHookTimeout` is set.
+const DefaultPreApplyHookTimeout = 30 * time.Second
+
+// PreApplyHook runs before the schema is applied to `db` - an error skips the database.
+// The hooks of a database share the per-database `PreApplyHookTimeout`, a hook must return once `ctx` is done.
+type PreApplyHook func(ctx context.Context, cluster *KustoCluster, db string) error
+
+// passesPreApplyHooks runs the `PreApplyHooks` on `db` sequentially and reports if all of them passed.
+func (c *KustoCluster) passesPreApplyHooks(ctx context.Context, db string) bool {
+	timeout := c.PreApplyHookTimeout
+	if timeout <= 0 {
+		timeout = DefaultPreApplyHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, hook := range c.PreApplyHooks {
+		if err := hook(ctx, c, db); err != nil {
+			log.Error().Err(err).Msgf("pre-apply hook failed on %s - skipping", db)
+			return false
+		}
+	}
+	return true
+}
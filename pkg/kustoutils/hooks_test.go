@@ -0,0 +1,90 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Pre-apply hooks", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	cfgMap := &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table Events (Timestamp:datetime)"}}
+	var restore func()
+	var jobs []string
+
+	// lockedOn fails for the databases whose migration semaphore is taken.
+	lockedOn := func(locked ...string) kustoutils.PreApplyHook {
+		return func(ctx context.Context, cluster *kustoutils.KustoCluster, db string) error {
+			for _, l := range locked {
+				if l == db {
+					return fmt.Errorf("migration semaphore of %s is taken", db)
+				}
+			}
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		jobs = nil
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+			job, err := ioutil.ReadFile(jobFile)
+			jobs = append(jobs, string(job))
+			return err
+		})
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should execute the databases passing the hooks", func() {
+		cluster := &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &mockKusto{}}
+		cluster.PreApplyHooks = []kustoutils.PreApplyHook{lockedOn(), lockedOn("db2")}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(Equal([]string{"db1"}))
+		Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+			"db1": schemav1alpha1.DBResultExecuted,
+			"db2": schemav1alpha1.DBResultSkipped,
+		}))
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0]).To(ContainSubstring("database: db1"))
+		Expect(jobs[0]).NotTo(ContainSubstring("database: db2"))
+	})
+	It("should not execute when a hook fails on every database", func() {
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+		cluster.PreApplyHooks = []kustoutils.PreApplyHook{lockedOn("db1", "db2")}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(BeEmpty())
+		Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+			"db1": schemav1alpha1.DBResultSkipped,
+			"db2": schemav1alpha1.DBResultSkipped,
+		}))
+		Expect(jobs).To(BeEmpty())
+	})
+	It("should skip a database whose hooks exceed the timeout", func() {
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}, PreApplyHookTimeout: 10 * time.Millisecond}
+		cluster.PreApplyHooks = []kustoutils.PreApplyHook{func(ctx context.Context, cluster *kustoutils.KustoCluster, db string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		done, _, err := cluster.ApplyPreCondition(context.Background(), targets, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,73 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// capturingTransport records the requests sent through it.
+type capturingTransport struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (t *capturingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, r.URL.String())
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+var _ = Describe("NewKustoClusterWithHTTPClient", func() {
+	env := map[string]string{
+		"AZURE_TENANT_ID":     "tenant",
+		"AZURE_CLIENT_ID":     "client",
+		"AZURE_CLIENT_SECRET": "secret",
+	}
+	saved := map[string]string{}
+	// client credentials from the environment - tokens are only requested when the cluster is called.
+	BeforeEach(func() {
+		for key, value := range env {
+			saved[key] = os.Getenv(key)
+			Expect(os.Setenv(key, value)).To(Succeed())
+		}
+	})
+	AfterEach(func() {
+		for key, value := range saved {
+			Expect(os.Setenv(key, value)).To(Succeed())
+		}
+	})
+
+	It("should use the custom http client", func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"dbs": ["db1", "db2"]}`))
+		}))
+		defer ts.Close()
+		transport := &capturingTransport{}
+		httpClient := &http.Client{Transport: transport}
+
+		cluster, err := kustoutils.NewKustoClusterWithHTTPClient(tenantClusterURI, httpClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Client.HttpClient()).To(BeIdenticalTo(httpClient))
+
+		targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{Webhook: ts.URL + "/dbs?cluster={{.Cluster}}", IncludeFollowers: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets.DBs).To(Equal([]string{"db1", "db2"}))
+		Expect(transport.urls).To(Equal([]string{ts.URL + "/dbs?cluster=cluster1"}))
+	})
+	It("should fall back to the default http client", func() {
+		cluster, err := kustoutils.NewKustoClusterWithHTTPClient(tenantClusterURI, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Client.HttpClient()).To(BeIdenticalTo(http.DefaultClient))
+	})
+})
@@ -0,0 +1,188 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// IsolateExecutionsKey is the `ConfigMap` key running every database in its own delta-kusto working directory.
+	IsolateExecutionsKey = "isolateExecutions"
+	// MaxParallelDatabasesKey is the `ConfigMap` key of the number of databases executed at once.
+	MaxParallelDatabasesKey = "maxParallelDatabases"
+	// MinWorkersKey is the `ConfigMap` key of the least number of databases executed at once.
+	MinWorkersKey = "minWorkers"
+	// MaxWorkersKey is the `ConfigMap` key capping the number of databases executed at once.
+	MaxWorkersKey = "maxWorkers"
+	// DefaultMaxParallelDatabases is the number of databases executed at once when neither the execution nor the operator set it.
+	DefaultMaxParallelDatabases = 1
+	// MaxWorkersLimit is the safety cap of the databases executed at once.
+	MaxWorkersLimit = 50
+)
+
+// isolationRoot holds the working directories of the isolated executions, `<root>/<jobID>/<db>`.
+const isolationRoot = "/tmp/schema-operator"
+
+// executeIsolated runs the job of `config` concurrently on every database, each in its own working directory
+// holding its job file. The directories are removed once the databases are done, whether they succeeded or not.
+// A worker is started for a database only once one of the `ParallelDatabases` slots is free, so thousands of
+// databases don't start thousands of goroutines. `progress` is notified as every database starts and finishes.
+// Once `ctx` is done no further database is started, `done` keeps the started databases and the context error is returned.
+func (c *KustoCluster) executeIsolated(ctx context.Context, done *schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress DatabaseProgressFunc) error {
+	root := filepath.Join(isolationRoot, config.JobID)
+	defer os.RemoveAll(root)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	failures := map[string]error{}
+	slots := semaphore.NewWeighted(int64(ParallelDatabases(config, len(done.DBs))))
+	started := 0
+	var cancelled error
+	for _, db := range done.DBs {
+		if cancelled = slots.Acquire(ctx, 1); cancelled != nil {
+			break
+		}
+		// a slot may be acquired even though the context is already done.
+		if cancelled = ctx.Err(); cancelled != nil {
+			slots.Release(1)
+			break
+		}
+		started++
+		progress.notify([]string{db}, schemav1alpha1.DBPhaseInProgress)
+		wg.Add(1)
+		go func(db string) {
+			defer wg.Done()
+			defer slots.Release(1)
+			err := c.executeDatabase(filepath.Join(root, db), db, config)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				log.Error().Err(err).Msgf("isolated execution of %s on %s failed", db, c.URI)
+				failures[db] = err
+				done.DBResults[db] = schemav1alpha1.DBResultFailed
+				progress.notify([]string{db}, schemav1alpha1.DBPhaseFailed)
+				return
+			}
+			done.DBResults[db] = schemav1alpha1.DBResultExecuted
+			progress.notify([]string{db}, schemav1alpha1.DBPhaseSucceeded)
+		}(db)
+	}
+	wg.Wait()
+	if cancelled != nil {
+		log.Info().Msgf("execution on %s cancelled - %d of %d databases started", c.URI, started, len(done.DBs))
+		done.DBs = done.DBs[:started]
+	}
+	if len(failures) == 0 {
+		return cancelled
+	}
+	failed := make([]string, 0, len(failures))
+	for db := range failures {
+		failed = append(failed, db)
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("failed executing %s on %s: %w", strings.Join(failed, ", "), c.URI, failures[failed[0]])
+}
+
+// ParallelDatabases returns the number of the `total` databases the execution of `exeCfg` runs at once -
+// its `MaxParallelDatabases`, the `schemaop_max_parallel_dbs` operator setting or `DefaultMaxParallelDatabases`,
+// kept between its `MinWorkers` and `MaxWorkers` (`MaxWorkersLimit` when unset).
+func ParallelDatabases(exeCfg schemav1alpha1.ExecutionConfiguration, total int) int {
+	workers := exeCfg.MaxParallelDatabases
+	if workers <= 0 {
+		workers = config.GetInt(config.MaxParallelDBsKey)
+	}
+	if workers <= 0 {
+		workers = DefaultMaxParallelDatabases
+	}
+	if workers < exeCfg.MinWorkers {
+		workers = exeCfg.MinWorkers
+	}
+	maxWorkers := exeCfg.MaxWorkers
+	if maxWorkers <= 0 || maxWorkers > MaxWorkersLimit {
+		maxWorkers = MaxWorkersLimit
+	}
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if workers > total {
+		workers = total
+	}
+	if workers < 1 {
+		return 1
+	}
+	return workers
+}
+
+// parseWorkers returns the `maxParallelDatabases`, `minWorkers` and `maxWorkers` settings of the `ConfigMap` `data`,
+// `minWorkers` is at least 1, `maxWorkers` at most `MaxWorkersLimit` and not below `minWorkers`.
+func parseWorkers(data map[string]string) (parallel, minWorkers, maxWorkers int, err error) {
+	values := map[string]*int{MaxParallelDatabasesKey: &parallel, MinWorkersKey: &minWorkers, MaxWorkersKey: &maxWorkers}
+	for key, value := range values {
+		if val, ok := data[key]; ok {
+			if *value, err = strconv.Atoi(strings.TrimSpace(val)); err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid %s value: %s", key, val)
+			}
+		}
+	}
+	switch {
+	case parallel < 0:
+		err = fmt.Errorf("%s must not be negative, got %d", MaxParallelDatabasesKey, parallel)
+	case data[MinWorkersKey] != "" && minWorkers < 1:
+		err = fmt.Errorf("%s must be at least 1, got %d", MinWorkersKey, minWorkers)
+	case data[MaxWorkersKey] != "" && (maxWorkers < 1 || maxWorkers > MaxWorkersLimit):
+		err = fmt.Errorf("%s must be between 1 and %d, got %d", MaxWorkersKey, MaxWorkersLimit, maxWorkers)
+	case maxWorkers > 0 && minWorkers > maxWorkers:
+		err = fmt.Errorf("%s %d is above %s %d", MinWorkersKey, minWorkers, MaxWorkersKey, maxWorkers)
+	}
+	return parallel, minWorkers, maxWorkers, err
+}
+
+// executeDatabase runs `executeInDir`, reporting a panic of the job as the error of the database
+// so the other databases keep running.
+func (c *KustoCluster) executeDatabase(dir, db string, config schemav1alpha1.ExecutionConfiguration) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("execution of %s panicked: %v", db, r)
+		}
+	}()
+	return c.executeInDir(dir, db, config)
+}
+
+// executeInDir runs the job of `config` on the database `db` with `dir` as the delta-kusto working directory,
+// the job file is named after the job file of `config` and is passed relative to `dir`.
+func (c *KustoCluster) executeInDir(dir, db string, config schemav1alpha1.ExecutionConfiguration) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	tmpJob, err := c.createJobFile([]string{db}, config.KQLFile, config)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpJob)
+	job, err := os.ReadFile(tmpJob)
+	if err != nil {
+		return err
+	}
+	jobFile := filepath.Base(config.JobFile)
+	if err = os.WriteFile(filepath.Join(dir, jobFile), job, 0o600); err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(context.Background(), databaseTimeout(config, db))
+	defer cancel()
+	return runDeltaJob(ctx, c.wrapper, config.JobID+"/"+db, dir, jobFile, config.ResultDir)
+}
@@ -0,0 +1,234 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Isolated executions", func() {
+	var targets schemav1alpha1.ClusterTargets
+	var lock sync.Mutex
+	var dirs map[string]string
+	var cluster *kustoutils.KustoCluster
+	var exeCfg schemav1alpha1.ExecutionConfiguration
+
+	BeforeEach(func() {
+		targets = schemav1alpha1.ClusterTargets{}
+		for i := 0; i < 10; i++ {
+			targets.DBs = append(targets.DBs, fmt.Sprintf("db%d", i))
+		}
+		dirs = map[string]string{}
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &mockKusto{}}
+		var err error
+		exeCfg, err = cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{
+			"kql":                           ".create-merge table T (a:string)",
+			kustoutils.IsolateExecutionsKey: "true",
+		}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.IsolateExecutions).To(BeTrue())
+	})
+	runner := func(fail string) func(jobID, dir, jobFile string) error {
+		return func(jobID, dir, jobFile string) error {
+			defer GinkgoRecover()
+			Expect(filepath.IsAbs(jobFile)).To(BeFalse())
+			job, err := os.ReadFile(filepath.Join(dir, jobFile))
+			Expect(err).NotTo(HaveOccurred())
+			db := filepath.Base(dir)
+			Expect(jobID).To(Equal(exeCfg.JobID + "/" + db))
+			Expect(string(job)).To(ContainSubstring(db))
+			lock.Lock()
+			Expect(dirs).NotTo(ContainElement(dir))
+			dirs[db] = dir
+			lock.Unlock()
+			// keep the executions running together
+			time.Sleep(10 * time.Millisecond)
+			if db == fail {
+				return errors.New("delta-kusto failed")
+			}
+			return nil
+		}
+	}
+
+	It("should run every database in its own directory", func() {
+		defer kustoutils.SetIsolatedDeltaRunner(runner(""))()
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dirs).To(HaveLen(10))
+		for _, db := range targets.DBs {
+			Expect(done.DBResults[db]).To(Equal(schemav1alpha1.DBResultExecuted))
+			Expect(dirs[db]).NotTo(BeADirectory())
+		}
+		Expect(filepath.Dir(dirs["db0"])).NotTo(BeADirectory())
+	})
+	It("should clean up the directories when a database fails", func() {
+		defer kustoutils.SetIsolatedDeltaRunner(runner("db3"))()
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).To(MatchError(ContainSubstring("db3")))
+		Expect(dirs).To(HaveLen(10))
+		Expect(done.DBResults["db3"]).To(Equal(schemav1alpha1.DBResultFailed))
+		Expect(done.DBResults["db4"]).To(Equal(schemav1alpha1.DBResultExecuted))
+		for _, db := range targets.DBs {
+			Expect(dirs[db]).NotTo(BeADirectory())
+		}
+		Expect(filepath.Dir(dirs["db0"])).NotTo(BeADirectory())
+	})
+	It("should run at most the configured number of databases at once", func() {
+		config.SetOverrides(map[string]interface{}{config.MaxParallelDBsKey: 3})
+		defer config.SetOverrides(nil)
+		running, peak := 0, 0
+		run := runner("")
+		defer kustoutils.SetIsolatedDeltaRunner(func(jobID, dir, jobFile string) error {
+			lock.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			lock.Unlock()
+			defer func() {
+				lock.Lock()
+				running--
+				lock.Unlock()
+			}()
+			return run(jobID, dir, jobFile)
+		})()
+		_, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dirs).To(HaveLen(10))
+		Expect(peak).To(Equal(3))
+	})
+	Context("with parallel databases", func() {
+		// peakRunner wraps `run`, recording the peak number of databases running at once in `peak`
+		// and the peak number of goroutines in `goroutines`.
+		peakRunner := func(run func(jobID, dir, jobFile string) error, peak, goroutines *int) func(jobID, dir, jobFile string) error {
+			running := 0
+			return func(jobID, dir, jobFile string) error {
+				lock.Lock()
+				running++
+				if running > *peak {
+					*peak = running
+				}
+				if n := runtime.NumGoroutine(); n > *goroutines {
+					*goroutines = n
+				}
+				lock.Unlock()
+				defer func() {
+					lock.Lock()
+					running--
+					lock.Unlock()
+				}()
+				return run(jobID, dir, jobFile)
+			}
+		}
+
+		It("should run one database at a time by default", func() {
+			peak, goroutines := 0, 0
+			defer kustoutils.SetIsolatedDeltaRunner(peakRunner(runner(""), &peak, &goroutines))()
+			_, err := cluster.Execute(targets, exeCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peak).To(Equal(1))
+		})
+		It("should limit the databases running at once under load", func() {
+			targets.DBs = nil
+			for i := 0; i < 200; i++ {
+				targets.DBs = append(targets.DBs, fmt.Sprintf("db%d", i))
+			}
+			exeCfg.IsolateExecutions = false
+			exeCfg.MaxParallelDatabases = 7
+			peak, goroutines := 0, 0
+			before := runtime.NumGoroutine()
+			defer kustoutils.SetIsolatedDeltaRunner(peakRunner(runner(""), &peak, &goroutines))()
+			done, err := cluster.Execute(targets, exeCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dirs).To(HaveLen(200))
+			Expect(done.DBResults).To(HaveLen(200))
+			Expect(peak).To(Equal(7))
+			// a worker is only started for a free slot.
+			Expect(goroutines).To(BeNumerically("<", before+20))
+		})
+		It("should cap the databases running at once at MaxWorkers", func() {
+			exeCfg.MaxParallelDatabases = 8
+			exeCfg.MaxWorkers = 4
+			peak, goroutines := 0, 0
+			defer kustoutils.SetIsolatedDeltaRunner(peakRunner(runner(""), &peak, &goroutines))()
+			_, err := cluster.Execute(targets, exeCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peak).To(Equal(4))
+		})
+		It("should release the slot of a database whose job panics", func() {
+			exeCfg.MaxParallelDatabases = 2
+			run := runner("")
+			defer kustoutils.SetIsolatedDeltaRunner(func(jobID, dir, jobFile string) error {
+				if filepath.Base(dir) == "db3" || filepath.Base(dir) == "db5" {
+					panic("delta-kusto crashed")
+				}
+				return run(jobID, dir, jobFile)
+			})()
+			done, err := cluster.Execute(targets, exeCfg)
+			Expect(err).To(MatchError(ContainSubstring("execution of db3 panicked: delta-kusto crashed")))
+			Expect(done.DBResults["db3"]).To(Equal(schemav1alpha1.DBResultFailed))
+			Expect(done.DBResults["db5"]).To(Equal(schemav1alpha1.DBResultFailed))
+			Expect(dirs).To(HaveLen(8))
+		})
+		It("should validate the worker settings", func() {
+			cases := []struct {
+				name  string
+				data  map[string]string
+				valid bool
+			}{
+				{"parallel databases", map[string]string{kustoutils.MaxParallelDatabasesKey: "10"}, true},
+				{"workers range", map[string]string{kustoutils.MinWorkersKey: "2", kustoutils.MaxWorkersKey: "50"}, true},
+				{"no min workers", map[string]string{kustoutils.MinWorkersKey: "0"}, false},
+				{"max workers above the cap", map[string]string{kustoutils.MaxWorkersKey: "51"}, false},
+				{"min workers above max workers", map[string]string{kustoutils.MinWorkersKey: "5", kustoutils.MaxWorkersKey: "4"}, false},
+				{"negative parallel databases", map[string]string{kustoutils.MaxParallelDatabasesKey: "-1"}, false},
+				{"not a number", map[string]string{kustoutils.MaxWorkersKey: "many"}, false},
+			}
+			for _, tc := range cases {
+				tc.data["kql"] = ".create-merge table T (a:string)"
+				_, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: tc.data}, true)
+				if tc.valid {
+					Expect(err).NotTo(HaveOccurred(), tc.name)
+				} else {
+					Expect(err).To(HaveOccurred(), tc.name)
+				}
+			}
+		})
+	})
+	It("should stop a database once its timeout passes", func() {
+		targets.DBs = []string{"db0", "db1"}
+		exeCfg.IsolateExecutions = false
+		exeCfg.DatabaseTimeouts = map[string]metav1.Duration{
+			"db0": {Duration: 100 * time.Millisecond},
+			"db1": {Duration: time.Second},
+		}
+		defer kustoutils.SetDeltaRunnerWithContext(func(ctx context.Context, jobID, dir, jobFile string) error {
+			select {
+			case <-time.After(300 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})()
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).To(MatchError(ContainSubstring("db0")))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(done.DBResults["db0"]).To(Equal(schemav1alpha1.DBResultFailed))
+		Expect(done.DBResults["db1"]).To(Equal(schemav1alpha1.DBResultExecuted))
+	})
+})
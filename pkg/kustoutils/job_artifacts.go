@@ -0,0 +1,124 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PreserveJobArtifactKey is the `ConfigMap` key keeping the delta-kusto job file in a `ConfigMap`, when `"true"`.
+	PreserveJobArtifactKey = "preserveJobArtifact"
+	// ArtifactRetentionKey is the `ConfigMap` key of the time a job artifact is kept (e.g. `72h`).
+	ArtifactRetentionKey = "artifactRetention"
+	// DefaultArtifactRetention is the time a job artifact is kept when the execution doesn't set it.
+	DefaultArtifactRetention = 7 * 24 * time.Hour
+	// JobArtifactLabel marks config maps holding a delta-kusto job artifact.
+	JobArtifactLabel = "schema.operator/job-artifact"
+	// JobTimestampLabel holds the unix time a job artifact was stored.
+	JobTimestampLabel = "schema.operator/timestamp"
+	// JobDatabasesAnnotation holds the comma separated databases of a job artifact, too long for a label value.
+	JobDatabasesAnnotation = "schema.operator/databases"
+	// ExpiresAtAnnotation holds the RFC3339 time after which a job artifact is deleted.
+	ExpiresAtAnnotation = "schema.operator/expires-at"
+	// JobArtifactKey is the `ConfigMap` key holding the delta-kusto job file.
+	JobArtifactKey = "job"
+)
+
+// parseJobArtifact reads the job artifact settings of the `ConfigMap` `data` into `config`.
+func parseJobArtifact(data map[string]string, config *schemav1alpha1.ExecutionConfiguration) error {
+	if preserve, ok := data[PreserveJobArtifactKey]; ok {
+		var err error
+		config.PreserveJobArtifact, err = strconv.ParseBool(preserve)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", PreserveJobArtifactKey, preserve, err)
+		}
+	}
+	if retention, ok := data[ArtifactRetentionKey]; ok {
+		d, err := time.ParseDuration(retention)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s value %q", ArtifactRetentionKey, retention)
+		}
+		config.ArtifactRetention = &metav1.Duration{Duration: d}
+	}
+	return nil
+}
+
+// artifactRetention returns the time the job artifact of `config` is kept.
+func artifactRetention(config schemav1alpha1.ExecutionConfiguration) time.Duration {
+	if config.ArtifactRetention != nil && config.ArtifactRetention.Duration > 0 {
+		return config.ArtifactRetention.Duration
+	}
+	return DefaultArtifactRetention
+}
+
+// JobArtifact returns the `schema-job-<hash>` `ConfigMap` in `namespace` holding the job file of `config`,
+// run on the `dbs` of the cluster `clusterURI` at `now`. The name is derived from the job content,
+// so the same job is stored once.
+func JobArtifact(config schemav1alpha1.ExecutionConfiguration, clusterURI string, dbs []string, namespace string, now time.Time) (*v1.ConfigMap, error) {
+	job, err := os.ReadFile(config.JobFile)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(job)
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "schema-job-" + hex.EncodeToString(hash[:])[:12],
+			Namespace: namespace,
+			Labels: map[string]string{
+				JobArtifactLabel:  "true",
+				ClusterLabel:      ClusterNameFromURI(clusterURI),
+				JobTimestampLabel: strconv.FormatInt(now.Unix(), 10),
+			},
+			Annotations: map[string]string{
+				JobDatabasesAnnotation: strings.Join(dbs, ","),
+				ExpiresAtAnnotation:    now.Add(artifactRetention(config)).UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string]string{JobArtifactKey: string(job)},
+	}, nil
+}
+
+// StoreJobArtifact creates the job artifact `ConfigMap` of `config` when it preserves its job artifact.
+func StoreJobArtifact(ctx context.Context, c client.Client, config schemav1alpha1.ExecutionConfiguration, clusterURI string, dbs []string, namespace string, now time.Time) error {
+	if !config.PreserveJobArtifact {
+		return nil
+	}
+	cfgMap, err := JobArtifact(config, clusterURI, dbs, namespace, now)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed reading the job file %s", config.JobFile)
+		return err
+	}
+	err = c.Create(ctx, cfgMap)
+	if apierrors.IsAlreadyExists(err) {
+		log.Info().Msgf("job artifact %s/%s already stored", namespace, cfgMap.Name)
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("failed storing the job artifact %s/%s", namespace, cfgMap.Name)
+	}
+	return err
+}
+
+// ArtifactExpiresAt returns the time the job artifact `cfgMap` expires, false when it has no valid expiry.
+func ArtifactExpiresAt(cfgMap *v1.ConfigMap) (time.Time, bool) {
+	expiresAt, err := time.Parse(time.RFC3339, cfgMap.Annotations[ExpiresAtAnnotation])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
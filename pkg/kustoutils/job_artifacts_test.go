@@ -0,0 +1,60 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Job artifacts", func() {
+	const uri = "https://mock.eastus.kusto.windows.net"
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		cluster = &kustoutils.KustoCluster{URI: uri, Client: &mockKusto{}}
+	})
+	configure := func(data map[string]string) (schemav1alpha1.ExecutionConfiguration, error) {
+		data["kql"] = ".create-merge table T (a:string)"
+		return cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: data}, true)
+	}
+
+	It("should read the artifact settings", func() {
+		exeCfg, err := configure(map[string]string{kustoutils.PreserveJobArtifactKey: "true", kustoutils.ArtifactRetentionKey: "72h"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.PreserveJobArtifact).To(BeTrue())
+		Expect(exeCfg.ArtifactRetention.Duration).To(Equal(72 * time.Hour))
+
+		exeCfg, err = configure(map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.PreserveJobArtifact).To(BeFalse())
+		Expect(exeCfg.ArtifactRetention).To(BeNil())
+
+		_, err = configure(map[string]string{kustoutils.ArtifactRetentionKey: "-1h"})
+		Expect(err).To(HaveOccurred())
+		_, err = configure(map[string]string{kustoutils.PreserveJobArtifactKey: "sometimes"})
+		Expect(err).To(HaveOccurred())
+	})
+	It("should name the artifact after the job content", func() {
+		exeCfg, err := configure(map[string]string{kustoutils.PreserveJobArtifactKey: "true", kustoutils.ArtifactRetentionKey: "1h"})
+		Expect(err).NotTo(HaveOccurred())
+		now := time.Now()
+		artifact, err := kustoutils.JobArtifact(exeCfg, uri, targets.DBs, "default", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifact.Data[kustoutils.JobArtifactKey]).To(ContainSubstring("db1"))
+		Expect(artifact.Labels).To(HaveKeyWithValue(kustoutils.ClusterLabel, "mock"))
+		expiresAt, ok := kustoutils.ArtifactExpiresAt(artifact)
+		Expect(ok).To(BeTrue())
+		Expect(expiresAt).To(BeTemporally("~", now.Add(time.Hour), time.Second))
+
+		again, err := kustoutils.JobArtifact(exeCfg, uri, targets.DBs, "default", now.Add(time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again.Name).To(Equal(artifact.Name))
+	})
+})
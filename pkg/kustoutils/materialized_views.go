@@ -0,0 +1,101 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MaterializedViewsKey is the `ConfigMap` key holding the materialized views kql.
+	MaterializedViewsKey = "materializedViews"
+)
+
+var (
+	kqlMaterializedViewRe = regexp.MustCompile(`(?ims)^\s*\.(?:create|create-or-alter)\s+(?:async\s+)?(?:ifnotexists\s+)?materialized-view\s+(?:with\s*\(([^)]*)\)\s*)?(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s+on\s+table\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)`)
+	kqlBackfillRe         = regexp.MustCompile(`(?i)\bbackfill\s*=\s*true\b`)
+)
+
+// MaterializedView is a materialized view definition.
+type MaterializedView struct {
+	Name        string
+	SourceTable string
+	Backfill    bool
+}
+
+// ErrMaterializedViewRebuild is returned when applying a materialized view requires rebuilding an existing view.
+type ErrMaterializedViewRebuild struct {
+	View string
+	DB   string
+}
+
+func (e ErrMaterializedViewRebuild) Error() string {
+	return fmt.Sprintf("materialized view %s in %s would be rebuilt and lose data", e.View, e.DB)
+}
+
+// ParseMaterializedViews returns the materialized views defined in the `kql` script.
+func ParseMaterializedViews(kql string) []MaterializedView {
+	var views []MaterializedView
+	for _, match := range kqlMaterializedViewRe.FindAllStringSubmatch(kql, -1) {
+		views = append(views, MaterializedView{
+			Name:        unquoteEntityName(match[2]),
+			SourceTable: unquoteEntityName(match[3]),
+			Backfill:    kqlBackfillRe.MatchString(match[1]),
+		})
+	}
+	return views
+}
+
+// ListMaterializedViews returns the materialized views of the database `db`.
+func (c *KustoCluster) ListMaterializedViews(ctx context.Context, db string) ([]MaterializedView, error) {
+	iter, err := c.Client.Mgmt(ctx, db, newUnsafeStmt(".show materialized-views"))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to query materialized views of %s", db)
+		return nil, err
+	}
+	defer iter.Stop()
+
+	views := []MaterializedView{}
+	err = iter.Do(
+		func(row *table.Row) error {
+			view := MaterializedView{}
+			for i, col := range row.ColumnTypes {
+				switch col.Name {
+				case "Name":
+					view.Name = row.Values[i].String()
+				case "SourceTable":
+					view.SourceTable = row.Values[i].String()
+				}
+			}
+			views = append(views, view)
+			return nil
+		},
+	)
+	return views, err
+}
+
+// checkMaterializedViewRebuilds returns an `ErrMaterializedViewRebuild` if any of the `views` already exists in one of the `dbs`
+// and applying it requires a rebuild - a changed source table or a backfill.
+func (c *KustoCluster) checkMaterializedViewRebuilds(ctx context.Context, dbs []string, views []MaterializedView) error {
+	for _, db := range dbs {
+		existing, err := c.ListMaterializedViews(ctx, db)
+		if err != nil {
+			return err
+		}
+		current := make(map[string]MaterializedView, len(existing))
+		for _, view := range existing {
+			current[view.Name] = view
+		}
+		for _, view := range views {
+			if cur, found := current[view.Name]; found && (view.Backfill || cur.SourceTable != view.SourceTable) {
+				return ErrMaterializedViewRebuild{View: view.Name, DB: db}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+const viewsKQL = `
+.create-or-alter materialized-view with (docString='latest events') LatestEvents on table Events
+{
+    Events | summarize arg_max(Timestamp, *) by Name
+}
+`
+
+// viewsHandler answers `.show materialized-views` with the given view name and source table pairs.
+func viewsHandler(views ...[]string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		if !strings.HasPrefix(stmt, ".show materialized-views") {
+			return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+		}
+		return mockRows(table.Columns{
+			{Name: "Name", Type: types.String},
+			{Name: "SourceTable", Type: types.String},
+		}, views...)
+	}
+}
+
+var _ = Describe("Materialized views", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+	newCfgMap := func(views string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":               ".create-merge table Events (Timestamp:datetime, Name:string)",
+				"materializedViews": views,
+			},
+		}
+	}
+
+	It("should parse materialized view definitions", func() {
+		views := kustoutils.ParseMaterializedViews(viewsKQL + `
+.create async ifnotexists materialized-view with (backfill=true) ['Daily Counts'] on table ['Events'] { Events | count }`)
+		Expect(views).To(Equal([]kustoutils.MaterializedView{
+			{Name: "LatestEvents", SourceTable: "Events"},
+			{Name: "Daily Counts", SourceTable: "Events", Backfill: true},
+		}))
+	})
+	It("should include the view definitions in the job", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: viewsHandler()}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, newCfgMap(viewsKQL), true)
+		Expect(err).NotTo(HaveOccurred())
+		views, err := ioutil.ReadFile(exeCfg.MaterializedViewsFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(views)).To(Equal(viewsKQL))
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).To(ContainSubstring("- filePath: " + exeCfg.MaterializedViewsFile))
+	})
+	It("should allow altering a view on the same source", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: viewsHandler([]string{"LatestEvents", "Events"})}}
+		_, err := cluster.CreateExecConfiguration(targets, newCfgMap(viewsKQL), true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("should fail on views that need a rebuild", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: viewsHandler([]string{"LatestEvents", "OldEvents"})}}
+		_, err := cluster.CreateExecConfiguration(targets, newCfgMap(viewsKQL), true)
+		Expect(err).To(Equal(kustoutils.ErrMaterializedViewRebuild{View: "LatestEvents", DB: "db1"}))
+	})
+	It("should not guard views when data loss is allowed", func() {
+		client := &scriptedKusto{mgmt: viewsHandler([]string{"LatestEvents", "OldEvents"})}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		_, err := cluster.CreateExecConfiguration(targets, newCfgMap(viewsKQL), false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.stmts).To(BeEmpty())
+	})
+})
@@ -0,0 +1,39 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// MergeStrategyKey is the `ConfigMap` key holding the `MergeStrategyType` of the schema.
+const MergeStrategyKey = "mergeStrategy"
+
+// mergeFlags are the delta-kusto flags implementing a merge strategy.
+type mergeFlags struct {
+	AllowDropTable   bool
+	AllowDropColumn  bool
+	FailIfDropColumn bool
+	AllowAlter       bool
+}
+
+// mergeStrategyFlags maps every merge strategy to its delta-kusto flags.
+var mergeStrategyFlags = map[schemav1alpha1.MergeStrategyType]mergeFlags{
+	schemav1alpha1.MergeStrategyReplace:   {AllowDropTable: true, AllowDropColumn: true, AllowAlter: true},
+	schemav1alpha1.MergeStrategyAdditive:  {FailIfDropColumn: true},
+	schemav1alpha1.MergeStrategyReconcile: {FailIfDropColumn: true, AllowAlter: true},
+}
+
+// strategyFlags returns the flags of the merge `strategy`, nil keeps the delta-kusto defaults.
+func strategyFlags(strategy schemav1alpha1.MergeStrategyType) (*mergeFlags, error) {
+	if strategy == "" {
+		return nil, nil
+	}
+	flags, ok := mergeStrategyFlags[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown merge strategy %q", strategy)
+	}
+	return &flags, nil
+}
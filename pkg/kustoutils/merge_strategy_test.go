@@ -0,0 +1,65 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"io/ioutil"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Merge strategy", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+	cluster := &kustoutils.KustoCluster{URI: "https://testcluster.westeurope.kusto.windows.net", Client: &mockKusto{}}
+
+	// jobFile returns the content of the job file generated for the `strategy`.
+	jobFile := func(strategy string, failIfDataLoss bool) (string, error) {
+		cfgMap := &v1.ConfigMap{Data: map[string]string{
+			"kql":                       ".create-merge table T (a:string)",
+			kustoutils.MergeStrategyKey: strategy,
+		}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, failIfDataLoss)
+		if err != nil {
+			return "", err
+		}
+		Expect(exeCfg.MergeStrategy).To(Equal(schemav1alpha1.MergeStrategyType(strategy)))
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		return string(job), nil
+	}
+
+	It("should allow every change when replacing", func() {
+		job, err := jobFile("Replace", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job).To(ContainSubstring("allowDropTable: true\nallowDropColumn: true\nfailIfDropColumn: false\nallowAlter: true\n"))
+	})
+	It("should only add objects when additive", func() {
+		job, err := jobFile("Additive", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job).To(ContainSubstring("allowDropTable: false\nallowDropColumn: false\nfailIfDropColumn: true\nallowAlter: false\n"))
+	})
+	It("should never drop when reconciling", func() {
+		job, err := jobFile("Reconcile", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job).To(ContainSubstring("allowDropTable: false\nallowDropColumn: false\nfailIfDropColumn: true\nallowAlter: true\n"))
+	})
+	It("should keep the delta-kusto defaults without a strategy", func() {
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table T (a:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).NotTo(ContainSubstring("allowDrop"))
+	})
+	It("should reject replacing when data loss fails the execution", func() {
+		_, err := jobFile("Replace", true)
+		Expect(err).To(MatchError(ContainSubstring("failIfDataLoss")))
+	})
+	It("should reject an unknown strategy", func() {
+		_, err := jobFile("Merge", false)
+		Expect(err).To(MatchError(ContainSubstring("unknown merge strategy")))
+	})
+})
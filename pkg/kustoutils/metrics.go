@@ -30,11 +30,18 @@ var (
 	},
 		[]string{"cluster"},
 	)
+	circuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kusto_circuit_breaker_state",
+			Help: "State of the kusto cluster circuit breaker 0-closed, 1-open, 2-half open",
+		},
+		[]string{"cluster"},
+	)
 )
 
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(deltaSuccesses, deltaFailures)
+	metrics.Registry.MustRegister(deltaSuccesses, deltaFailures, circuitBreakerState)
 	addFailure("test")
 	addSuccess("test")
 	addDuration("test", 3.2)
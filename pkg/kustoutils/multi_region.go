@@ -0,0 +1,184 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultPropagationTimeout is the time the followers have to catch up with the primary schema.
+	DefaultPropagationTimeout = 5 * time.Minute
+	// DefaultPropagationPollInterval is the time between two schema checks of a follower.
+	DefaultPropagationPollInterval = 10 * time.Second
+)
+
+// ErrPropagationTimeout is returned when the schema did not propagate to a follower cluster in time.
+type ErrPropagationTimeout struct {
+	Cluster string
+}
+
+func (e ErrPropagationTimeout) Error() string {
+	return fmt.Sprintf("schema did not propagate to follower %s in time", e.Cluster)
+}
+
+// ErrPropagationTimeouts holds an `ErrPropagationTimeout` for every follower that did not converge.
+type ErrPropagationTimeouts []ErrPropagationTimeout
+
+func (e ErrPropagationTimeouts) Error() string {
+	clusters := make([]string, 0, len(e))
+	for _, err := range e {
+		clusters = append(clusters, err.Cluster)
+	}
+	return fmt.Sprintf("schema did not propagate to followers %s in time", strings.Join(clusters, ", "))
+}
+
+// MultiRegionKustoCluster applies the schema to a leader cluster and verifies it propagated to its follower clusters.
+type MultiRegionKustoCluster struct {
+	*KustoCluster
+	Followers []*KustoCluster
+	// PropagationTimeout is the time the followers have to match the primary schema.
+	PropagationTimeout time.Duration
+	// PollInterval is the time between two schema checks of a follower.
+	PollInterval time.Duration
+}
+
+// NewMultiRegionKustoCluster returns a `MultiRegionKustoCluster` writing to `primaryURI` and verifying `followerURIs`.
+func NewMultiRegionKustoCluster(primaryURI string, followerURIs []string) *MultiRegionKustoCluster {
+	followers := make([]*KustoCluster, 0, len(followerURIs))
+	for _, uri := range followerURIs {
+		followers = append(followers, NewKustoCluster(uri))
+	}
+	return &MultiRegionKustoCluster{
+		KustoCluster:       NewKustoCluster(primaryURI),
+		Followers:          followers,
+		PropagationTimeout: DefaultPropagationTimeout,
+		PollInterval:       DefaultPropagationPollInterval,
+	}
+}
+
+// Execute runs the `ExecutionConfiguration` on the primary and waits for the followers to match the primary schema.
+// The followers that did not converge within the `PropagationTimeout` are reported with an `ErrPropagationTimeouts`.
+func (c *MultiRegionKustoCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	done, err := c.KustoCluster.Execute(targets, config)
+	if err != nil || config.DryRunOutputDir != "" {
+		return done, err
+	}
+	return done, c.VerifyPropagation(context.Background(), done.DBs)
+}
+
+// VerifyPropagation polls the followers until the schema of the `dbs` matches the primary or the `PropagationTimeout` passes.
+func (c *MultiRegionKustoCluster) VerifyPropagation(ctx context.Context, dbs []string) error {
+	if len(dbs) == 0 || len(c.Followers) == 0 {
+		return nil
+	}
+	desired := make(map[string][]string, len(dbs))
+	for _, db := range dbs {
+		schema, err := c.KustoCluster.databaseSchema(ctx, db)
+		if err != nil {
+			return err
+		}
+		desired[db] = schema
+	}
+
+	timeout := c.PropagationTimeout
+	if timeout <= 0 {
+		timeout = DefaultPropagationTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var timeouts ErrPropagationTimeouts
+	var wg sync.WaitGroup
+	for _, follower := range c.Followers {
+		wg.Add(1)
+		go func(follower *KustoCluster) {
+			defer wg.Done()
+			if !c.waitForSchema(ctx, follower, desired) {
+				mu.Lock()
+				timeouts = append(timeouts, ErrPropagationTimeout{Cluster: follower.URI})
+				mu.Unlock()
+			}
+		}(follower)
+	}
+	wg.Wait()
+	if len(timeouts) == 0 {
+		return nil
+	}
+	sort.Slice(timeouts, func(i, j int) bool { return timeouts[i].Cluster < timeouts[j].Cluster })
+	log.Error().Err(timeouts).Msgf("schema of %s did not propagate", c.URI)
+	return timeouts
+}
+
+// waitForSchema reports if the `follower` schema matched the `desired` schema of every database before `ctx` is done.
+func (c *MultiRegionKustoCluster) waitForSchema(ctx context.Context, follower *KustoCluster, desired map[string][]string) bool {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultPropagationPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		converged := true
+		for db, schema := range desired {
+			current, err := follower.databaseSchema(ctx, db)
+			if err != nil || !reflect.DeepEqual(current, schema) {
+				converged = false
+				break
+			}
+		}
+		if converged {
+			log.Info().Msgf("schema propagated to follower %s", follower.URI)
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// databaseSchema returns the sorted `table.column:type` entries of the database schema.
+func (c *KustoCluster) databaseSchema(ctx context.Context, db string) ([]string, error) {
+	iter, err := c.Client.Mgmt(ctx, db, newUnsafeStmt(fmt.Sprintf(".show database ['%s'] schema", db)))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed reading the schema of %s on %s", db, c.URI)
+		return nil, err
+	}
+	defer iter.Stop()
+
+	schema := []string{}
+	err = iter.Do(
+		func(row *table.Row) error {
+			var tableName, column, columnType string
+			for i, col := range row.ColumnTypes {
+				switch col.Name {
+				case "TableName":
+					tableName = row.Values[i].String()
+				case "ColumnName":
+					column = row.Values[i].String()
+				case "ColumnType":
+					columnType = row.Values[i].String()
+				}
+			}
+			if tableName != "" {
+				schema = append(schema, fmt.Sprintf("%s.%s:%s", tableName, column, columnType))
+			}
+			return nil
+		},
+	)
+	sort.Strings(schema)
+	return schema, err
+}
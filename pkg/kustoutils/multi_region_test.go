@@ -0,0 +1,79 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Multi region cluster", func() {
+	const (
+		primaryURI = "https://leader.westeurope.kusto.windows.net"
+		nearURI    = "https://follower1.northeurope.kusto.windows.net"
+		farURI     = "https://follower2.eastus.kusto.windows.net"
+	)
+	oldSchema := [][]string{{"Events", "Timestamp", "System.DateTime"}}
+	newSchema := [][]string{{"Events", "Timestamp", "System.DateTime"}, {"Events", "Name", "System.String"}}
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+
+	// schemaHandler answers `.show database schema` with the old schema until the `delay` passes.
+	schemaHandler := func(delay time.Duration) func(db, stmt string) (*kusto.RowIterator, error) {
+		start := time.Now()
+		return func(db, stmt string) (*kusto.RowIterator, error) {
+			columns := table.Columns{
+				{Name: "TableName", Type: types.String},
+				{Name: "ColumnName", Type: types.String},
+				{Name: "ColumnType", Type: types.String},
+			}
+			if !strings.HasPrefix(stmt, ".show database") {
+				return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+			}
+			if time.Since(start) < delay {
+				return mockRows(columns, oldSchema...)
+			}
+			return mockRows(columns, newSchema...)
+		}
+	}
+	var restore func()
+	var cluster *kustoutils.MultiRegionKustoCluster
+	var exeCfg schemav1alpha1.ExecutionConfiguration
+
+	BeforeEach(func() {
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error { return nil })
+		cluster = &kustoutils.MultiRegionKustoCluster{
+			KustoCluster: &kustoutils.KustoCluster{URI: primaryURI, Client: &scriptedKusto{mgmt: schemaHandler(0)}},
+			Followers: []*kustoutils.KustoCluster{
+				{URI: nearURI, Client: &scriptedKusto{mgmt: schemaHandler(20 * time.Millisecond)}},
+			},
+			PropagationTimeout: 300 * time.Millisecond,
+			PollInterval:       5 * time.Millisecond,
+		}
+		var err error
+		exeCfg, err = cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table Events (Timestamp:datetime, Name:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should wait for the followers to converge", func() {
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(Equal(targets.DBs))
+	})
+	It("should report the followers that did not converge", func() {
+		cluster.Followers = append(cluster.Followers, &kustoutils.KustoCluster{URI: farURI, Client: &scriptedKusto{mgmt: schemaHandler(time.Hour)}})
+		_, err := cluster.Execute(targets, exeCfg)
+		Expect(err).To(Equal(kustoutils.ErrPropagationTimeouts{{Cluster: farURI}}))
+	})
+})
@@ -0,0 +1,30 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// DryRunKey is the `ConfigMap` key that computes the schema diff into `DryRunOutputDir` instead of applying it, when `"true"`.
+const DryRunKey = "dryRun"
+
+// ObserveSchema computes the diff between the desired and the actual schema of the `targets` without applying it.
+// The `config` must be a dry run configuration (see `DryRunKey`), only delta-kusto reads the databases.
+func (c *KustoCluster) ObserveSchema(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (SchemaDiff, error) {
+	if config.DryRunOutputDir == "" {
+		return SchemaDiff{}, fmt.Errorf("observing %s requires a dry run configuration", c.URI)
+	}
+	if len(targets.DBs) == 0 {
+		return SchemaDiff{ClusterURI: c.URI, Databases: []DatabaseDiff{}}, nil
+	}
+	if err := runDeltaJob(context.Background(), c.wrapper, config.JobID, "", config.JobFile, ""); err != nil {
+		log.Error().Err(err).Msgf("failed computing the schema diff of %s", c.URI)
+		return SchemaDiff{}, err
+	}
+	return c.SchemaDiff(config)
+}
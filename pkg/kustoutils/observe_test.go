@@ -0,0 +1,67 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Observation mode", func() {
+	const clusterURI = "https://testcluster.westeurope.kusto.windows.net"
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	var client *scriptedKusto
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		client = &scriptedKusto{}
+		cluster = &kustoutils.KustoCluster{URI: clusterURI, Client: client}
+	})
+
+	It("should compute the pending diff without any management command", func() {
+		cfgMap := &v1.ConfigMap{Data: map[string]string{
+			"kql":                ".create-merge table T (a:string)",
+			kustoutils.DryRunKey: "true",
+		}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(exeCfg.DryRunOutputDir)
+		Expect(exeCfg.DryRunOutputDir).NotTo(BeEmpty())
+
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error {
+			// delta-kusto writes the delta of every database
+			return ioutil.WriteFile(filepath.Join(exeCfg.DryRunOutputDir, "db1.kql"), []byte(".create table T (a:string)"), 0600)
+		})
+		defer restore()
+
+		diff, err := cluster.ObserveSchema(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff).To(Equal(kustoutils.SchemaDiff{
+			ClusterURI: clusterURI,
+			Databases:  []kustoutils.DatabaseDiff{{Database: "db1", Delta: ".create table T (a:string)"}},
+		}))
+		Expect(client.stmts).To(BeEmpty())
+	})
+	It("should refuse a configuration that applies the schema", func() {
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table T (a:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		ran := false
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error {
+			ran = true
+			return nil
+		})
+		defer restore()
+
+		_, err = cluster.ObserveSchema(targets, exeCfg)
+		Expect(err).To(HaveOccurred())
+		Expect(ran).To(BeFalse())
+		Expect(client.stmts).To(BeEmpty())
+	})
+})
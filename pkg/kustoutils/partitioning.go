@@ -0,0 +1,93 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// PartitioningPoliciesKey is the `ConfigMap` key holding the tables partitioning policies kql.
+	PartitioningPoliciesKey = "partitioningPolicies"
+)
+
+var (
+	kqlPartitioningPolicyRe = regexp.MustCompile(`(?im)^\s*\.alter(?:-merge)?\s+table\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s+policy\s+partitioning\b`)
+	kqlPartitionColumnRe    = regexp.MustCompile(`"ColumnName"\s*:\s*"([^"]+)"`)
+	kqlTableColumnsRe       = regexp.MustCompile(`(?ims)^\s*\.(?:create|create-merge)\s+table\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s*\(([^)]*)\)`)
+	kqlHotCachingPolicyRe   = regexp.MustCompile(`(?im)^\s*\.alter(?:-merge)?\s+table\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s+policy\s+caching\s+hot\s*=`)
+)
+
+// PartitioningPolicy is a partitioning policy of a table, `Columns` are its partition key columns.
+type PartitioningPolicy struct {
+	Table   string
+	Columns []string
+}
+
+// ParsePartitioningPolicies returns the partitioning policies in the `kql` script.
+func ParsePartitioningPolicies(kql string) []PartitioningPolicy {
+	var policies []PartitioningPolicy
+	matches := kqlPartitioningPolicyRe.FindAllStringSubmatchIndex(kql, -1)
+	for i, match := range matches {
+		// the policy body runs until the next policy.
+		end := len(kql)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		policy := PartitioningPolicy{Table: unquoteEntityName(kql[match[2]:match[3]])}
+		for _, column := range kqlPartitionColumnRe.FindAllStringSubmatch(kql[match[1]:end], -1) {
+			policy.Columns = append(policy.Columns, column[1])
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// partitioningTables returns the tables targeted by the partitioning `policies`.
+func partitioningTables(policies []PartitioningPolicy) []string {
+	tables := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		tables = append(tables, policy.Table)
+	}
+	return tables
+}
+
+// PartitioningWarnings returns a warning for every partition key of the `policies` that is a string column
+// of a table without a hot caching policy in the `kql` script, as partitioning such tables rarely pays off.
+func PartitioningWarnings(policies []PartitioningPolicy, kql string) []string {
+	columns := parseTableColumns(kql)
+	hot := map[string]bool{}
+	for _, match := range kqlHotCachingPolicyRe.FindAllStringSubmatch(kql, -1) {
+		hot[unquoteEntityName(match[1])] = true
+	}
+	var warnings []string
+	for _, policy := range policies {
+		if hot[policy.Table] {
+			continue
+		}
+		for _, column := range policy.Columns {
+			if columns[policy.Table][column] == "string" {
+				warnings = append(warnings, fmt.Sprintf("partitioning policy of table %s uses the string column %s but the table has no hot caching policy", policy.Table, column))
+			}
+		}
+	}
+	return warnings
+}
+
+// parseTableColumns returns the column types of every table defined in the `kql` script.
+func parseTableColumns(kql string) map[string]map[string]string {
+	tables := map[string]map[string]string{}
+	for _, match := range kqlTableColumnsRe.FindAllStringSubmatch(kql, -1) {
+		columns := map[string]string{}
+		for _, column := range strings.Split(match[2], ",") {
+			name, kind, found := strings.Cut(column, ":")
+			if found {
+				columns[unquoteEntityName(name)] = strings.ToLower(strings.TrimSpace(kind))
+			}
+		}
+		tables[unquoteEntityName(match[1])] = columns
+	}
+	return tables
+}
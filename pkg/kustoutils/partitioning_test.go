@@ -0,0 +1,64 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"io/ioutil"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+const partitioningKQL = `
+.alter table Events policy partitioning ` + "```" + `
+{
+  "PartitionKeys": [
+    {
+      "ColumnName": "TenantId",
+      "Kind": "Hash",
+      "Properties": {"Function": "XxHash64", "MaxPartitionCount": 128}
+    }
+  ]
+}` + "```" + `
+.alter table ['Audit'] policy partitioning @'{"PartitionKeys":[{"ColumnName":"Timestamp","Kind":"UniformRange"}]}'
+`
+
+var _ = Describe("Partitioning policies", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+	schemaKQL := ".create-merge table Events (Timestamp:datetime, TenantId:string)\n.create-merge table ['Audit'] (Timestamp:datetime, User:string)"
+	cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+
+	It("should parse the partitioned tables and their partition keys", func() {
+		Expect(kustoutils.ParsePartitioningPolicies(partitioningKQL)).To(Equal([]kustoutils.PartitioningPolicy{
+			{Table: "Events", Columns: []string{"TenantId"}},
+			{Table: "Audit", Columns: []string{"Timestamp"}},
+		}))
+	})
+	It("should include the partitioning policies file in the job", func() {
+		cfgMap := &v1.ConfigMap{Data: map[string]string{"kql": schemaKQL, "partitioningPolicies": partitioningKQL}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		policies, err := ioutil.ReadFile(exeCfg.PartitioningPoliciesFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(policies)).To(Equal(partitioningKQL))
+		job, err := ioutil.ReadFile(exeCfg.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).To(ContainSubstring("- filePath: " + exeCfg.KQLFile + " \n        - filePath: " + exeCfg.PartitioningPoliciesFile + " \n"))
+	})
+	It("should fail on policies for tables missing from the schema", func() {
+		cfgMap := &v1.ConfigMap{Data: map[string]string{
+			"kql":                  ".create-merge table Events (Timestamp:datetime, TenantId:string)",
+			"partitioningPolicies": partitioningKQL,
+		}}
+		_, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).To(Equal(kustoutils.ErrOrphanedPolicy{Table: "Audit"}))
+	})
+	It("should warn about string partition keys of tables without a hot caching policy", func() {
+		policies := kustoutils.ParsePartitioningPolicies(partitioningKQL)
+		Expect(kustoutils.PartitioningWarnings(policies, schemaKQL)).To(ConsistOf(ContainSubstring("table Events uses the string column TenantId")))
+		Expect(kustoutils.PartitioningWarnings(policies, schemaKQL+"\n.alter table Events policy caching hot = 30d")).To(BeEmpty())
+	})
+})
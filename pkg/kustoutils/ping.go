@@ -0,0 +1,37 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+)
+
+// DefaultPingTimeout is the time `Ping` waits for the cluster, unless `PingTimeout` or the ping timeout setting is set.
+const DefaultPingTimeout = 5 * time.Second
+
+// Ping verifies the cluster is reachable by running `.show cluster`, it fails once the ping timeout passes.
+func (c *KustoCluster) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.pingTimeout())
+	defer cancel()
+	iter, err := c.Client.Mgmt(ctx, "", newUnsafeStmt(".show cluster"))
+	if err != nil {
+		return err
+	}
+	iter.Stop()
+	return nil
+}
+
+// pingTimeout returns the time the cluster has to answer the reachability checks.
+func (c *KustoCluster) pingTimeout() time.Duration {
+	timeout := c.PingTimeout
+	if timeout <= 0 {
+		timeout = config.GetDuration(config.KustoPingTimeoutKey)
+	}
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	return timeout
+}
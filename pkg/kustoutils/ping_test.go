@@ -0,0 +1,38 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// unreachableKusto is a mock client whose management commands never answer.
+type unreachableKusto struct {
+	scriptedKusto
+}
+
+func (m *unreachableKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var _ = Describe("Ping", func() {
+	It("should succeed on a reachable cluster", func() {
+		client := &scriptedKusto{}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		Expect(cluster.Ping(context.Background())).To(Succeed())
+		Expect(client.stmts).To(Equal([]string{".show cluster"}))
+	})
+	It("should time out on an unreachable cluster", func() {
+		cluster := &kustoutils.KustoCluster{Client: &unreachableKusto{}, PingTimeout: 20 * time.Millisecond}
+		start := time.Now()
+		Expect(cluster.Ping(context.Background())).To(Equal(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
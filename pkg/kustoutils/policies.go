@@ -0,0 +1,54 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// IngestionPoliciesKey is the `ConfigMap` key holding the tables ingestion policies kql.
+	IngestionPoliciesKey = "ingestionPolicies"
+)
+
+var kqlIngestionPolicyRe = regexp.MustCompile(`(?im)^\s*\.alter(?:-merge)?\s+table\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)\s+policy\s+(?:ingestionbatching|streamingingestion)\b`)
+
+// ErrOrphanedPolicy is returned when a policy targets a table missing from the schema.
+type ErrOrphanedPolicy struct {
+	Table string
+}
+
+func (e ErrOrphanedPolicy) Error() string {
+	return fmt.Sprintf("policy targets table %s which is not defined in the schema", e.Table)
+}
+
+// ParseIngestionPolicyTables returns the tables targeted by the ingestion policies in the `kql` script.
+func ParseIngestionPolicyTables(kql string) []string {
+	var tables []string
+	for _, match := range kqlIngestionPolicyRe.FindAllStringSubmatch(kql, -1) {
+		tables = append(tables, unquoteEntityName(match[1]))
+	}
+	return tables
+}
+
+// validatePolicyTables returns an `ErrOrphanedPolicy` for the first policy table missing from the `kql` schema.
+func validatePolicyTables(policyTables []string, kql string) error {
+	if len(policyTables) == 0 {
+		return nil
+	}
+	schemaTables, _, err := ParseKQLObjects(kql)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(schemaTables))
+	for _, tbl := range schemaTables {
+		known[tbl] = struct{}{}
+	}
+	for _, tbl := range policyTables {
+		if _, found := known[tbl]; !found {
+			return ErrOrphanedPolicy{Table: tbl}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PreConditionKey is the `ConfigMap` key holding the per database guard query.
+	PreConditionKey = "preConditionKQL"
+)
+
+// kqlMgmtCommandRe matches a management command at the start of a line or a statement.
+var kqlMgmtCommandRe = regexp.MustCompile(`(?m)(?:^|;)\s*\.`)
+
+// ValidatePreConditionKQL checks the guard query is a read-only tabular expression statement.
+func ValidatePreConditionKQL(kql string) error {
+	if strings.TrimSpace(kql) == "" {
+		return fmt.Errorf("pre-condition query is empty")
+	}
+	if kqlMgmtCommandRe.MatchString(kql) {
+		return fmt.Errorf("pre-condition query must not contain management commands")
+	}
+	return nil
+}
+
+// CheckPreCondition runs the guard query `kql` on the database `db` and reports if it returned any rows.
+func (c *KustoCluster) CheckPreCondition(ctx context.Context, db, kql string) (bool, error) {
+	iter, err := c.QueryWithTimeout(ctx, db, newUnsafeStmt(kql), c.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("failed running the pre-condition on %s", db)
+		return false, err
+	}
+	defer iter.Stop()
+
+	rows := 0
+	err = iter.Do(
+		func(row *table.Row) error {
+			rows++
+			return nil
+		},
+	)
+	return rows > 0, err
+}
+
+// ApplyPreCondition runs the configured guard query and the `PreApplyHooks` on every target database, skipping the
+// databases whose metadata already records the `SchemaVersion`. It returns the targets that passed, with the skipped
+// and up to date ones recorded in `DBResults`, and a configuration whose delta-kusto job only contains the passing databases.
+func (c *KustoCluster) ApplyPreCondition(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, schemav1alpha1.ExecutionConfiguration, error) {
+	passing := schemav1alpha1.ClusterTargets{DBResults: make(map[string]schemav1alpha1.DBResultEnum)}
+	if config.PreConditionKQL == "" && len(c.PreApplyHooks) == 0 && config.SchemaVersion == "" {
+		passing.DBs = targets.DBs
+		return passing, config, nil
+	}
+	annotations := NewSchemaAnnotationProcessor(c)
+	for _, db := range targets.DBs {
+		if config.SchemaVersion != "" {
+			applied, err := annotations.Applied(ctx, db, config.SchemaVersion)
+			if err != nil {
+				return passing, config, err
+			}
+			if applied {
+				log.Info().Msgf("%s already has schema version %s - skipping", db, config.SchemaVersion)
+				passing.DBResults[db] = schemav1alpha1.DBResultUpToDate
+				continue
+			}
+		}
+		if config.PreConditionKQL != "" {
+			ok, err := c.CheckPreCondition(ctx, db, config.PreConditionKQL)
+			if err != nil {
+				return passing, config, err
+			}
+			if !ok {
+				log.Info().Msgf("pre-condition not met on %s - skipping", db)
+				passing.DBResults[db] = schemav1alpha1.DBResultSkipped
+				continue
+			}
+		}
+		if !c.passesPreApplyHooks(ctx, db) {
+			passing.DBResults[db] = schemav1alpha1.DBResultSkipped
+			continue
+		}
+		passing.DBs = append(passing.DBs, db)
+	}
+	if len(passing.DBs) == len(targets.DBs) || len(passing.DBs) == 0 {
+		return passing, config, nil
+	}
+	jobFile, err := c.createJobFile(passing.DBs, config.KQLFile, config)
+	config.JobFile = jobFile
+	return passing, config, err
+}
+
+// createJobFile creates a delta-kusto job applying `kqlFile`, with the extra files of `config`, on the `dbs` of the cluster.
+func (c *KustoCluster) createJobFile(dbs []string, kqlFile string, config schemav1alpha1.ExecutionConfiguration) (string, error) {
+	extraFiles := []string{}
+	for _, file := range []string{config.IngestionPoliciesFile, config.PartitioningPoliciesFile, config.MaterializedViewsFile} {
+		if file != "" {
+			extraFiles = append(extraFiles, file)
+		}
+	}
+	var jobFile string
+	var err error
+	switch {
+	case config.DryRunOutputDir != "":
+		jobFile, err = c.wrapper.CreateDryRunConfiguration(c.URI, dbs, kqlFile, config.DryRunOutputDir, config.FailIfDataLoss, config.MergeStrategy, extraFiles...)
+	case config.AppliedDiffDir != "":
+		jobFile, err = c.wrapper.CreateRecordedExecConfiguration(c.URI, dbs, kqlFile, config.AppliedDiffDir, config.FailIfDataLoss, config.MergeStrategy, extraFiles...)
+	default:
+		jobFile, err = c.wrapper.CreateExecConfiguration(c.URI, dbs, kqlFile, config.FailIfDataLoss, config.MergeStrategy, extraFiles...)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed generating delta kusto configuration file")
+	}
+	return jobFile, err
+}
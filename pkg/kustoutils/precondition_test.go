@@ -0,0 +1,84 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+const guardKQL = "FeatureFlags | where Name == 'new-schema'"
+
+// guardHandler answers the guard query with a row only for the `passing` databases.
+func guardHandler(passing ...string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		columns := table.Columns{{Name: "Name", Type: types.String}}
+		for _, p := range passing {
+			if p == db {
+				return mockRows(columns, []string{"new-schema"})
+			}
+		}
+		return mockRows(columns)
+	}
+}
+
+var _ = Describe("Pre-condition", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+	newCfgMap := func(guard string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":                      ".create-merge table Events (Timestamp:datetime)",
+				kustoutils.PreConditionKey: guard,
+			},
+		}
+	}
+
+	It("should reject management commands", func() {
+		Expect(kustoutils.ValidatePreConditionKQL(guardKQL)).To(Succeed())
+		Expect(kustoutils.ValidatePreConditionKQL(".show tables")).NotTo(Succeed())
+		Expect(kustoutils.ValidatePreConditionKQL("T | take 1;\n.drop table T")).NotTo(Succeed())
+		Expect(kustoutils.ValidatePreConditionKQL("T | take 1; .drop table T")).NotTo(Succeed())
+
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+		_, err := cluster.CreateExecConfiguration(targets, newCfgMap(".drop table Events"), true)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should process databases passing the guard and skip the others", func() {
+		client := &scriptedKusto{query: guardHandler("db1")}
+		cluster := &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: client}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, newCfgMap(guardKQL), true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.PreConditionKQL).To(Equal(guardKQL))
+
+		passing, guarded, err := cluster.ApplyPreCondition(context.Background(), targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.stmts).To(Equal([]string{guardKQL, guardKQL}))
+		Expect(passing.DBs).To(Equal([]string{"db1"}))
+		Expect(passing.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{"db2": schemav1alpha1.DBResultSkipped}))
+		job, err := ioutil.ReadFile(guarded.JobFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(job)).To(ContainSubstring("database: db1"))
+		Expect(string(job)).NotTo(ContainSubstring("database: db2"))
+	})
+	It("should skip execution when no database passes the guard", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: guardHandler()}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, newCfgMap(guardKQL), true)
+		Expect(err).NotTo(HaveOccurred())
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(BeEmpty())
+		Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+			"db1": schemav1alpha1.DBResultSkipped,
+			"db2": schemav1alpha1.DBResultSkipped,
+		}))
+	})
+})
@@ -0,0 +1,82 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sort"
+	"sync"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// DatabaseProgressFunc is notified whenever a database moves to a new execution phase.
+type DatabaseProgressFunc func(db string, phase schemav1alpha1.DBPhase)
+
+// ExecuteWithProgress runs the `ExecutionConfiguration` like `Execute` and notifies `progress` on every phase change,
+// the databases executed in parallel as each of them starts and finishes. `progress` is never called concurrently.
+// The databases start `Pending` - once `ctx` is done no further database is started, the remaining ones are not
+// notified and the context error is returned. In `CanaryMode` a failed canary returns `ErrCanaryFailed`.
+func (c *KustoCluster) ExecuteWithProgress(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress DatabaseProgressFunc) (schemav1alpha1.ClusterTargets, error) {
+	return c.executeTraced(ctx, targets, config, progress.synchronized())
+}
+
+// synchronized returns a `DatabaseProgressFunc` that is never called concurrently and skips the phase a database is
+// already in, nil for a nil `DatabaseProgressFunc`.
+func (p DatabaseProgressFunc) synchronized() DatabaseProgressFunc {
+	if p == nil {
+		return nil
+	}
+	var mu sync.Mutex
+	phases := map[string]schemav1alpha1.DBPhase{}
+	return func(db string, phase schemav1alpha1.DBPhase) {
+		mu.Lock()
+		defer mu.Unlock()
+		if phases[db] == phase {
+			return
+		}
+		phases[db] = phase
+		p(db, phase)
+	}
+}
+
+// notify moves the `dbs` to `phase`, a nil `DatabaseProgressFunc` is never notified.
+func (p DatabaseProgressFunc) notify(dbs []string, phase schemav1alpha1.DBPhase) {
+	if p == nil {
+		return
+	}
+	for _, db := range dbs {
+		p(db, phase)
+	}
+}
+
+// report notifies the final phase of the databases of an execution ending with `err`. The skipped databases have
+// nothing to apply and succeed, the others fail once their `DBResults` is failed, or missing on an error.
+func (p DatabaseProgressFunc) report(done schemav1alpha1.ClusterTargets, err error) {
+	if p == nil {
+		return
+	}
+	phases := make(map[string]schemav1alpha1.DBPhase, len(done.DBResults))
+	for db, result := range done.DBResults {
+		phases[db] = schemav1alpha1.DBPhaseSucceeded
+		if result == schemav1alpha1.DBResultFailed {
+			phases[db] = schemav1alpha1.DBPhaseFailed
+		}
+	}
+	for _, db := range done.DBs {
+		if _, found := phases[db]; !found {
+			phases[db] = schemav1alpha1.DBPhaseSucceeded
+			if err != nil {
+				phases[db] = schemav1alpha1.DBPhaseFailed
+			}
+		}
+	}
+	dbs := make([]string, 0, len(phases))
+	for db := range phases {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	for _, db := range dbs {
+		p(db, phases[db])
+	}
+}
@@ -0,0 +1,136 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Database progress", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2", "db3"}}
+	var restore func()
+	var jobs []string
+	var cluster *kustoutils.KustoCluster
+	var exeCfg schemav1alpha1.ExecutionConfiguration
+
+	BeforeEach(func() {
+		jobs = nil
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+			job, err := ioutil.ReadFile(jobFile)
+			Expect(err).NotTo(HaveOccurred())
+			jobs = append(jobs, string(job))
+			return nil
+		})
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &mockKusto{}}
+		var err error
+		exeCfg, err = cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table T (a:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		restore()
+	})
+
+	It("should execute and report every database", func() {
+		phases := map[string][]schemav1alpha1.DBPhase{}
+		done, err := cluster.ExecuteWithProgress(context.Background(), targets, exeCfg, func(db string, phase schemav1alpha1.DBPhase) {
+			phases[db] = append(phases[db], phase)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(Equal(targets.DBs))
+		Expect(jobs).To(HaveLen(1))
+		for _, db := range targets.DBs {
+			Expect(jobs[0]).To(ContainSubstring("database: " + db))
+			Expect(phases[db]).To(Equal([]schemav1alpha1.DBPhase{schemav1alpha1.DBPhaseInProgress, schemav1alpha1.DBPhaseSucceeded}))
+		}
+	})
+	It("should execute the databases in parallel", func() {
+		restore()
+		var lock sync.Mutex
+		running, peak := 0, 0
+		started := make(chan struct{}, len(targets.DBs))
+		restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+			lock.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			lock.Unlock()
+			started <- struct{}{}
+			// every job waits until all the databases started, or times out when they run one at a time.
+			Eventually(func() int { return len(started) }, time.Second).Should(Equal(len(targets.DBs)))
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return nil
+		})
+		exeCfg.MaxParallelDatabases = len(targets.DBs)
+		phases := map[string][]schemav1alpha1.DBPhase{}
+		done, err := cluster.ExecuteWithProgress(context.Background(), targets, exeCfg, func(db string, phase schemav1alpha1.DBPhase) {
+			phases[db] = append(phases[db], phase)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done.DBs).To(Equal(targets.DBs))
+		Expect(peak).To(Equal(len(targets.DBs)))
+		for _, db := range targets.DBs {
+			Expect(phases[db]).To(Equal([]schemav1alpha1.DBPhase{schemav1alpha1.DBPhaseInProgress, schemav1alpha1.DBPhaseSucceeded}))
+		}
+	})
+	It("should leave the remaining databases pending when cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		progress := map[string]schemav1alpha1.DBPhase{}
+		for _, db := range targets.DBs {
+			progress[db] = schemav1alpha1.DBPhasePending
+		}
+		restore()
+		restore = kustoutils.SetIsolatedDeltaRunner(func(jobID, dir, jobFile string) error {
+			jobs = append(jobs, jobID)
+			return nil
+		})
+		exeCfg.IsolateExecutions = true
+		done, err := cluster.ExecuteWithProgress(ctx, targets, exeCfg, func(db string, phase schemav1alpha1.DBPhase) {
+			progress[db] = phase
+			if phase == schemav1alpha1.DBPhaseInProgress {
+				cancel()
+			}
+		})
+		Expect(err).To(Equal(context.Canceled))
+		Expect(done.DBs).To(Equal([]string{"db1"}))
+		Expect(jobs).To(HaveLen(1))
+		Expect(progress).To(Equal(map[string]schemav1alpha1.DBPhase{
+			"db1": schemav1alpha1.DBPhaseSucceeded,
+			"db2": schemav1alpha1.DBPhasePending,
+			"db3": schemav1alpha1.DBPhasePending,
+		}))
+	})
+	It("should mark the failed databases", func() {
+		restore()
+		restore = kustoutils.SetIsolatedDeltaRunner(func(jobID, dir, jobFile string) error {
+			if filepath.Base(dir) == "db2" {
+				return context.DeadlineExceeded
+			}
+			return nil
+		})
+		exeCfg.IsolateExecutions = true
+		progress := map[string]schemav1alpha1.DBPhase{}
+		_, err := cluster.ExecuteWithProgress(context.Background(), targets, exeCfg, func(db string, phase schemav1alpha1.DBPhase) {
+			progress[db] = phase
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(progress).To(Equal(map[string]schemav1alpha1.DBPhase{
+			"db1": schemav1alpha1.DBPhaseSucceeded,
+			"db2": schemav1alpha1.DBPhaseFailed,
+			"db3": schemav1alpha1.DBPhaseSucceeded,
+		}))
+	})
+})
@@ -0,0 +1,69 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"math"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedKustoCluster throttles the calls to a `KustoCluster` to avoid hitting the kusto management API limits.
+type RateLimitedKustoCluster struct {
+	*KustoCluster
+	// RequestsPerSecond is the maximal rate of calls to the cluster.
+	RequestsPerSecond float64
+	limiter           *rate.Limiter
+}
+
+// NewRateLimitedKustoCluster returns a `RateLimitedKustoCluster` allowing `rps` calls per second to `cluster`.
+// A non positive `rps` does not limit the calls.
+func NewRateLimitedKustoCluster(cluster *KustoCluster, rps float64) *RateLimitedKustoCluster {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	return &RateLimitedKustoCluster{
+		KustoCluster:      cluster,
+		RequestsPerSecond: rps,
+		limiter:           rate.NewLimiter(limit, int(math.Max(1, math.Floor(rps)))),
+	}
+}
+
+// Wait blocks until the next call is allowed or `ctx` is done.
+func (c *RateLimitedKustoCluster) Wait(ctx context.Context) error {
+	err := c.limiter.Wait(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("rate limited call to %s cancelled", c.URI)
+	}
+	return err
+}
+
+// AquireTargets waits for the rate limiter and filters the DBs in the cluster.
+func (c *RateLimitedKustoCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	return c.AquireTargetsWithContext(context.Background(), filter)
+}
+
+// AquireTargetsWithContext waits for the rate limiter, until `ctx` is done, and filters the DBs in the cluster.
+func (c *RateLimitedKustoCluster) AquireTargetsWithContext(ctx context.Context, filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
+	if err := c.Wait(ctx); err != nil {
+		return schemav1alpha1.ClusterTargets{}, err
+	}
+	return c.KustoCluster.AquireTargets(filter)
+}
+
+// Execute waits for the rate limiter and runs the `ExecutionConfiguration` on the provided targets.
+func (c *RateLimitedKustoCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	return c.ExecuteWithContext(context.Background(), targets, config)
+}
+
+// ExecuteWithContext waits for the rate limiter, until `ctx` is done, and runs the `ExecutionConfiguration` on the provided targets.
+func (c *RateLimitedKustoCluster) ExecuteWithContext(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
+	if err := c.Wait(ctx); err != nil {
+		return schemav1alpha1.ClusterTargets{}, err
+	}
+	return c.KustoCluster.Execute(targets, config)
+}
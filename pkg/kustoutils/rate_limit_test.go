@@ -0,0 +1,54 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"sync"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/cluster"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimitedKustoCluster", func() {
+	filter := schemav1alpha1.TargetFilter{DBS: []string{"db1"}}
+
+	It("should implement the cluster interface", func() {
+		var _ cluster.Cluster = kustoutils.NewRateLimitedKustoCluster(&kustoutils.KustoCluster{}, 1)
+	})
+	It("should keep the throughput at the configured rate", func() {
+		const rps, calls = 40, 60
+		limited := kustoutils.NewRateLimitedKustoCluster(&kustoutils.KustoCluster{Client: &mockKusto{}}, rps)
+		start := time.Now()
+		wg := sync.WaitGroup{}
+		for i := 0; i < calls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				targets, err := limited.AquireTargets(filter)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(targets.DBs).To(Equal([]string{"db1"}))
+			}()
+		}
+		wg.Wait()
+		// the first `rps` calls use the burst, the rest are spread at the limit.
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Duration(float64(calls-rps)/rps*float64(time.Second))-20*time.Millisecond))
+	})
+	It("should stop waiting when the context is cancelled", func() {
+		limited := kustoutils.NewRateLimitedKustoCluster(&kustoutils.KustoCluster{Client: &mockKusto{}}, 0.1)
+		_, err := limited.AquireTargets(filter)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = limited.ExecuteWithContext(ctx, schemav1alpha1.ClusterTargets{}, schemav1alpha1.ExecutionConfiguration{})
+		Expect(err).To(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
@@ -0,0 +1,178 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// ManagedPrincipalNote is the note attached to database principals added by the operator.
+// Principals without this note were not added by the operator and are never dropped by it.
+const ManagedPrincipalNote = "schema.operator/managed"
+
+// databaseRoles maps the role names used in the CRD to the kusto role keyword and the role title
+// as returned by `.show database principals`.
+var databaseRoles = map[string]struct {
+	keyword string
+	title   string
+}{
+	"admin":    {keyword: "admins", title: "Database Admin"},
+	"viewer":   {keyword: "viewers", title: "Database Viewer"},
+	"ingestor": {keyword: "ingestors", title: "Database Ingestor"},
+	"monitor":  {keyword: "monitors", title: "Database Monitor"},
+	"user":     {keyword: "users", title: "Database User"},
+}
+
+// DatabasePrincipal is a principal assigned to a role in a kusto database.
+type DatabasePrincipal struct {
+	Role string
+	FQN  string
+	Note string
+}
+
+// RoleChange is a single role assignment to add or drop.
+type RoleChange struct {
+	Role      string
+	Principal string
+}
+
+// ListDatabasePrincipals returns the principals assigned to the database `db`.
+func (c *KustoCluster) ListDatabasePrincipals(ctx context.Context, db string) ([]DatabasePrincipal, error) {
+	iter, err := c.MgmtWithTimeout(ctx, db, newUnsafeStmt(fmt.Sprintf(".show database ['%s'] principals", db)), c.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to query principals of %s", db)
+		return nil, err
+	}
+	defer iter.Stop()
+
+	principals := []DatabasePrincipal{}
+	err = iter.Do(
+		func(row *table.Row) error {
+			p := DatabasePrincipal{}
+			for i, col := range row.ColumnTypes {
+				switch col.Name {
+				case "Role":
+					p.Role = roleFromTitle(row.Values[i].String())
+				case "PrincipalFQN":
+					p.FQN = row.Values[i].String()
+				case "Notes":
+					p.Note = row.Values[i].String()
+				}
+			}
+			if p.Role != "" {
+				principals = append(principals, p)
+			}
+			return nil
+		},
+	)
+	return principals, err
+}
+
+// SyncDatabaseRoles aligns the roles of every target database with the desired `roles`.
+// Missing principals are added (marked with `ManagedPrincipalNote`), and principals previously
+// added by the operator that are no longer desired are dropped.
+func (c *KustoCluster) SyncDatabaseRoles(ctx context.Context, targets schemav1alpha1.ClusterTargets, roles map[string][]string) error {
+	for role, principals := range roles {
+		if _, ok := databaseRoles[role]; !ok {
+			return fmt.Errorf("unsupported database role: %s", role)
+		}
+		// the principals are formatted into the role commands.
+		for _, principal := range principals {
+			if err := schemav1alpha1.ValidatePrincipalFQN(principal); err != nil {
+				return err
+			}
+		}
+	}
+	for _, db := range targets.DBs {
+		current, err := c.ListDatabasePrincipals(ctx, db)
+		if err != nil {
+			return err
+		}
+		toAdd, toDrop := DiffDatabaseRoles(roles, current)
+		for _, change := range toDrop {
+			// the dropped principals are listed by the cluster - a principal kusto accepted may not be a valid FQN.
+			if err := schemav1alpha1.ValidatePrincipalFQN(change.Principal); err != nil {
+				return err
+			}
+		}
+		for _, change := range toAdd {
+			cmd := fmt.Sprintf(".add database ['%s'] %s ('%s') '%s'", db, databaseRoles[change.Role].keyword, change.Principal, ManagedPrincipalNote)
+			if err := c.runMgmt(ctx, db, cmd); err != nil {
+				return err
+			}
+		}
+		for _, change := range toDrop {
+			cmd := fmt.Sprintf(".drop database ['%s'] %s ('%s')", db, databaseRoles[change.Role].keyword, change.Principal)
+			if err := c.runMgmt(ctx, db, cmd); err != nil {
+				return err
+			}
+		}
+		log.Info().Msgf("synced roles of %s: %d added, %d dropped", db, len(toAdd), len(toDrop))
+	}
+	return nil
+}
+
+// DiffDatabaseRoles returns the role assignments to add and drop in order to move from `current` to `desired`.
+// Only principals marked with `ManagedPrincipalNote` are considered for removal.
+func DiffDatabaseRoles(desired map[string][]string, current []DatabasePrincipal) (toAdd []RoleChange, toDrop []RoleChange) {
+	existing := make(map[RoleChange]DatabasePrincipal, len(current))
+	for _, p := range current {
+		existing[RoleChange{Role: p.Role, Principal: strings.ToLower(p.FQN)}] = p
+	}
+	wanted := make(map[RoleChange]struct{})
+	for role, principals := range desired {
+		for _, principal := range principals {
+			key := RoleChange{Role: role, Principal: strings.ToLower(principal)}
+			wanted[key] = struct{}{}
+			if _, found := existing[key]; !found {
+				toAdd = append(toAdd, RoleChange{Role: role, Principal: principal})
+			}
+		}
+	}
+	for key, p := range existing {
+		if _, found := wanted[key]; !found && p.Note == ManagedPrincipalNote {
+			toDrop = append(toDrop, RoleChange{Role: p.Role, Principal: p.FQN})
+		}
+	}
+	sortRoleChanges(toAdd)
+	sortRoleChanges(toDrop)
+	return toAdd, toDrop
+}
+
+func sortRoleChanges(changes []RoleChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Role != changes[j].Role {
+			return changes[i].Role < changes[j].Role
+		}
+		return changes[i].Principal < changes[j].Principal
+	})
+}
+
+// roleFromTitle converts a role title (e.g. `Database Admin`) to the CRD role name.
+// Other roles, e.g. `AllDatabasesAdmin` or `Database UnrestrictedViewer`, have no CRD role name.
+func roleFromTitle(title string) string {
+	for role, r := range databaseRoles {
+		if strings.EqualFold(strings.TrimSpace(title), r.title) {
+			return role
+		}
+	}
+	return ""
+}
+
+// runMgmt runs a management command and discards its result.
+func (c *KustoCluster) runMgmt(ctx context.Context, db, cmd string) error {
+	iter, err := c.MgmtWithTimeout(ctx, db, newUnsafeStmt(cmd), c.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("failed running: %s", cmd)
+		return err
+	}
+	iter.Stop()
+	return nil
+}
@@ -0,0 +1,121 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// principalsHandler answers `.show database principals` with the given rows of role, fqn and notes.
+func principalsHandler(rows ...[]string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		if !strings.HasPrefix(stmt, ".show database") {
+			return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+		}
+		columns := table.Columns{
+			{Name: "Role", Type: types.String},
+			{Name: "PrincipalFQN", Type: types.String},
+			{Name: "Notes", Type: types.String},
+		}
+		return mockRows(columns, rows...)
+	}
+}
+
+var _ = Describe("Roles", func() {
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"tenant_1"}}
+	roles := map[string]struct{ title, keyword string }{
+		"admin":    {title: "Database Admin", keyword: "admins"},
+		"viewer":   {title: "Database Viewer", keyword: "viewers"},
+		"ingestor": {title: "Database Ingestor", keyword: "ingestors"},
+		"monitor":  {title: "Database Monitor", keyword: "monitors"},
+		"user":     {title: "Database User", keyword: "users"},
+	}
+
+	for role, r := range roles {
+		role, r := role, r
+		Context(fmt.Sprintf("when syncing the %s role", role), func() {
+			It("should add a missing principal", func() {
+				client := &scriptedKusto{mgmt: principalsHandler()}
+				cluster := &kustoutils.KustoCluster{Client: client}
+				err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{role: {"aaduser=alice@contoso.com"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.stmts).To(ContainElement(fmt.Sprintf(".add database ['tenant_1'] %s ('aaduser=alice@contoso.com') '%s'", r.keyword, kustoutils.ManagedPrincipalNote)))
+				Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+			})
+			It("should drop a managed principal that is no longer desired", func() {
+				client := &scriptedKusto{mgmt: principalsHandler([]string{r.title, "aaduser=bob@contoso.com", kustoutils.ManagedPrincipalNote})}
+				cluster := &kustoutils.KustoCluster{Client: client}
+				err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{role: {}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.stmts).To(ContainElement(fmt.Sprintf(".drop database ['tenant_1'] %s ('aaduser=bob@contoso.com')", r.keyword)))
+				Expect(countPrefix(client.stmts, ".add")).To(Equal(0))
+			})
+			It("should do nothing when the principals are aligned", func() {
+				client := &scriptedKusto{mgmt: principalsHandler([]string{r.title, "aaduser=Alice@contoso.com", kustoutils.ManagedPrincipalNote})}
+				cluster := &kustoutils.KustoCluster{Client: client}
+				err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{role: {"aaduser=alice@contoso.com"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.stmts).To(HaveLen(1))
+			})
+			It("should not drop principals it does not own", func() {
+				client := &scriptedKusto{mgmt: principalsHandler([]string{r.title, "aaduser=carol@contoso.com", ""})}
+				cluster := &kustoutils.KustoCluster{Client: client}
+				err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{role: {}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.stmts).To(HaveLen(1))
+			})
+		})
+	}
+
+	It("should reject principals that are not AAD principal FQNs", func() {
+		for _, principal := range []string{
+			"aaduser=alice@contoso.com') '; .drop database ['tenant_1'] admins ('aaduser=bob@contoso.com",
+			"aaduser=alice'@contoso.com",
+			"alice@contoso.com",
+			"aaduser=",
+		} {
+			client := &scriptedKusto{mgmt: principalsHandler()}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{"admin": {principal}})
+			Expect(err).To(HaveOccurred(), principal)
+			Expect(client.stmts).To(BeEmpty())
+
+			template := &schemav1alpha1.SchemaDeployment{Spec: schemav1alpha1.SchemaDeploymentSpec{DatabaseRoles: map[string][]string{"admin": {principal}}}}
+			Expect(template.ValidateCreate()).To(HaveOccurred(), principal)
+		}
+		for _, principal := range []string{"aaduser=alice@contoso.com", "aadgroup=8d3ec1b2-0a1c-4b5e-9e0f-1c2d3e4f5a6b;contoso.com", "AADApp=8d3ec1b2-0a1c-4b5e-9e0f-1c2d3e4f5a6b;72f988bf-86f1-41af-91ab-2d7cd011db47"} {
+			Expect(schemav1alpha1.ValidatePrincipalFQN(principal)).To(Succeed(), principal)
+		}
+	})
+	It("should only match the exact role titles", func() {
+		client := &scriptedKusto{mgmt: principalsHandler(
+			[]string{"AllDatabasesAdmin", "aaduser=bob@contoso.com", kustoutils.ManagedPrincipalNote},
+			[]string{"Database UnrestrictedViewer", "aaduser=carol@contoso.com", kustoutils.ManagedPrincipalNote},
+		)}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		principals, err := cluster.ListDatabasePrincipals(context.Background(), "tenant_1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(principals).To(BeEmpty())
+
+		err = cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{"admin": {}, "viewer": {}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+	})
+	It("should reject unsupported roles", func() {
+		client := &scriptedKusto{mgmt: principalsHandler()}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		err := cluster.SyncDatabaseRoles(context.Background(), targets, map[string][]string{"owner": {"aaduser=alice@contoso.com"}})
+		Expect(err).To(HaveOccurred())
+		Expect(client.stmts).To(BeEmpty())
+	})
+})
@@ -0,0 +1,118 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SchemaVersionAnnotation is the database metadata property holding the checksum of the applied schema.
+	SchemaVersionAnnotation = "schema.operator/version"
+	// AnnotateDatabasesKey is the `ConfigMap` key recording the applied schema version in the metadata of every database.
+	AnnotateDatabasesKey = "annotateDatabases"
+)
+
+// SchemaAnnotationProcessor keeps a cluster side idempotency record of the applied schema, independent of the Kubernetes
+// status: the `schema.operator/version` metadata of a database holds the checksum of the schema last applied to it.
+type SchemaAnnotationProcessor struct {
+	cluster *KustoCluster
+}
+
+// NewSchemaAnnotationProcessor returns a processor of the database metadata of `cluster`.
+func NewSchemaAnnotationProcessor(cluster *KustoCluster) *SchemaAnnotationProcessor {
+	return &SchemaAnnotationProcessor{cluster: cluster}
+}
+
+// Version returns the schema version recorded in the metadata of `db`, empty when none was recorded.
+func (p *SchemaAnnotationProcessor) Version(ctx context.Context, db string) (string, error) {
+	iter, err := p.cluster.MgmtWithTimeout(ctx, db, newUnsafeStmt(fmt.Sprintf(".show database ['%s'] metadata", db)), p.cluster.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("failed reading the metadata of %s", db)
+		return "", err
+	}
+	defer iter.Stop()
+
+	version := ""
+	err = iter.Do(
+		func(row *table.Row) error {
+			for i, col := range row.ColumnTypes {
+				if col.Name != "Metadata" || row.Values[i].String() == "" {
+					continue
+				}
+				metadata := map[string]interface{}{}
+				if err := json.Unmarshal([]byte(row.Values[i].String()), &metadata); err != nil {
+					return fmt.Errorf("invalid metadata of %s: %w", db, err)
+				}
+				version, _ = metadata[SchemaVersionAnnotation].(string)
+			}
+			return nil
+		},
+	)
+	return version, err
+}
+
+// Record writes `checksum` as the schema version of `db`.
+func (p *SchemaAnnotationProcessor) Record(ctx context.Context, db, checksum string) error {
+	metadata, err := json.Marshal(map[string]string{SchemaVersionAnnotation: checksum})
+	if err != nil {
+		return err
+	}
+	iter, err := p.cluster.MgmtWithTimeout(ctx, db, newUnsafeStmt(fmt.Sprintf(".alter database ['%s'] metadata '%s'", db, metadata)), p.cluster.mgmtTimeout())
+	if err != nil {
+		log.Error().Err(err).Msgf("failed recording the schema version of %s", db)
+		return err
+	}
+	iter.Stop()
+	return nil
+}
+
+// Applied reports if `checksum` is the schema version recorded in the metadata of `db`.
+func (p *SchemaAnnotationProcessor) Applied(ctx context.Context, db, checksum string) (bool, error) {
+	version, err := p.Version(ctx, db)
+	return version == checksum, err
+}
+
+// recordSchemaVersion records the `version` in the metadata of the executed `dbs`, nothing when the databases are not annotated.
+func (c *KustoCluster) recordSchemaVersion(ctx context.Context, dbs []string, version string) error {
+	if version == "" {
+		return nil
+	}
+	annotations := NewSchemaAnnotationProcessor(c)
+	for _, db := range dbs {
+		if err := annotations.Record(ctx, db, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSchemaAnnotation sets the schema version of `config` to the checksum of its rendered kql when the `ConfigMap`
+// `data` annotates the databases.
+func parseSchemaAnnotation(data map[string]string, config *schemav1alpha1.ExecutionConfiguration) error {
+	annotate, ok := data[AnnotateDatabasesKey]
+	if !ok {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(annotate))
+	if err != nil {
+		return fmt.Errorf("invalid %s value: %s", AnnotateDatabasesKey, annotate)
+	}
+	if !enabled {
+		return nil
+	}
+	kql, err := RenderedKQL(*config)
+	if err != nil {
+		return err
+	}
+	config.SchemaVersion = ComputeKQLChecksum(kql)
+	return nil
+}
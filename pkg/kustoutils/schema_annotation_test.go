@@ -0,0 +1,134 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var alterMetadataRe = regexp.MustCompile(`^\.alter database \['([^']+)'\] metadata '(.*)'$`)
+
+// metadataHandler answers the database metadata commands from `metadata`, updating it on `.alter database metadata`.
+func metadataHandler(metadata map[string]string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		if match := alterMetadataRe.FindStringSubmatch(stmt); match != nil {
+			metadata[match[1]] = match[2]
+			return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+		}
+		if stmt != fmt.Sprintf(".show database ['%s'] metadata", db) {
+			return nil, fmt.Errorf("unexpected command %s", stmt)
+		}
+		rows := [][]string{}
+		if value, ok := metadata[db]; ok {
+			rows = append(rows, []string{db, value})
+		}
+		return mockRows(table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "Metadata", Type: types.String}}, rows...)
+	}
+}
+
+var _ = Describe("Schema annotations", func() {
+	ctx := context.Background()
+	var metadata map[string]string
+	var kustoClient *scriptedKusto
+	var cluster *kustoutils.KustoCluster
+
+	BeforeEach(func() {
+		metadata = map[string]string{"db1": `{"owner": "team-a"}`}
+		kustoClient = &scriptedKusto{mgmt: metadataHandler(metadata)}
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: kustoClient}
+	})
+
+	It("should read and write the schema version of a database", func() {
+		annotations := kustoutils.NewSchemaAnnotationProcessor(cluster)
+		version, err := annotations.Version(ctx, "db1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(BeEmpty())
+
+		Expect(annotations.Record(ctx, "db1", "abc123")).To(Succeed())
+		Expect(kustoClient.stmts).To(ContainElement(`.alter database ['db1'] metadata '{"schema.operator/version":"abc123"}'`))
+		version, err = annotations.Version(ctx, "db1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(Equal("abc123"))
+
+		applied, err := annotations.Applied(ctx, "db2", "abc123")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(BeFalse())
+	})
+	It("should fail on invalid metadata", func() {
+		metadata["db1"] = "not json"
+		_, err := kustoutils.NewSchemaAnnotationProcessor(cluster).Version(ctx, "db1")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should skip the databases recording the schema version and annotate the executed ones", func() {
+		jobs := 0
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error {
+			jobs++
+			return nil
+		})
+		defer restore()
+		cfgMap := &v1.ConfigMap{Data: map[string]string{
+			"kql":                           ".create-merge table Events (Timestamp:datetime)",
+			kustoutils.AnnotateDatabasesKey: "true",
+		}}
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.SchemaVersion).NotTo(BeEmpty())
+		metadata["db2"] = fmt.Sprintf(`{"schema.operator/version": %q}`, exeCfg.SchemaVersion)
+
+		done, err := cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobs).To(Equal(1))
+		Expect(done.DBs).To(Equal([]string{"db1"}))
+		Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+			"db1": schemav1alpha1.DBResultExecuted,
+			"db2": schemav1alpha1.DBResultUpToDate,
+		}))
+		Expect(metadata["db1"]).To(Equal(fmt.Sprintf(`{"schema.operator/version":%q}`, exeCfg.SchemaVersion)))
+
+		done, err = cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobs).To(Equal(1))
+		Expect(done.DBs).To(BeEmpty())
+	})
+	It("should check and record the schema version with the execution context", func() {
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error { return nil })
+		defer restore()
+		ctxClient := &ctxKusto{scriptedKusto: scriptedKusto{mgmt: metadataHandler(metadata)}}
+		cluster.Client = ctxClient
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{
+			"kql":                           ".create-merge table Events (Timestamp:datetime)",
+			kustoutils.AnnotateDatabasesKey: "true",
+		}}, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		type execKey struct{}
+		execCtx := context.WithValue(ctx, execKey{}, "execution")
+		_, err = cluster.ExecuteWithProgress(execCtx, targets, exeCfg, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ctxClient.stmts).To(ContainElement(MatchRegexp(`^\.alter database \['db1'\] metadata`)))
+		Expect(ctxClient.ctx.Value(execKey{})).To(Equal("execution"))
+	})
+	It("should not annotate the databases by default", func() {
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}},
+			&v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table Events (Timestamp:datetime)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.SchemaVersion).To(BeEmpty())
+
+		_, err = cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}},
+			&v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table Events (Timestamp:datetime)", kustoutils.AnnotateDatabasesKey: "maybe"}}, true)
+		Expect(err).To(HaveOccurred())
+	})
+})
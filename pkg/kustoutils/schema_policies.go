@@ -0,0 +1,57 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// ProposedKQLName is the string holding the kql about to be applied in the schema rule queries.
+const ProposedKQLName = "proposedKQL"
+
+// kqlStringEscaper escapes the characters that can't appear as is in a kusto string literal.
+var kqlStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// RuleViolation is a schema rule broken by the kql about to be applied.
+type RuleViolation struct {
+	Rule    schemav1alpha1.SchemaRule
+	Message string
+}
+
+// CheckSchemaRules runs the `rules` on the kql rendered by `config`, in the first target database.
+// A rule is violated when its query returns rows, a rule whose query fails is reported as violated with the error.
+func (c *KustoCluster) CheckSchemaRules(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, rules []schemav1alpha1.SchemaRule) ([]RuleViolation, error) {
+	if len(rules) == 0 || len(targets.DBs) == 0 || config.KQLFile == "" {
+		return nil, nil
+	}
+	kql, err := RenderedKQL(config)
+	if err != nil {
+		return nil, err
+	}
+	declaration := fmt.Sprintf("let %s = \"%s\";\n", ProposedKQLName, kqlStringEscaper.Replace(kql))
+	violations := []RuleViolation{}
+	for _, rule := range rules {
+		message := rule.Message
+		if message == "" {
+			message = fmt.Sprintf("schema rule %s is violated", rule.Name)
+		}
+		rows := 0
+		err := ValidatePreConditionKQL(rule.Query)
+		if err == nil {
+			rows, err = countRows(ctx, c, targets.DBs[0], declaration+rule.Query)
+		}
+		switch {
+		case err != nil:
+			log.Error().Err(err).Msgf("failed running schema rule %s on %s", rule.Name, c.URI)
+			violations = append(violations, RuleViolation{Rule: rule, Message: fmt.Sprintf("%s: %v", message, err)})
+		case rows > 0:
+			violations = append(violations, RuleViolation{Rule: rule, Message: message})
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,82 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Schema rules", func() {
+	rules := []schemav1alpha1.SchemaRule{
+		{
+			Name:        "no-password-columns",
+			Query:       `print kql = proposedKQL | where kql matches regex @"\bpassword\s*:"`,
+			Enforcement: schemav1alpha1.EnforcementDeny,
+			Message:     "columns must not be named password",
+		},
+		{Name: "retention", Query: `print kql = proposedKQL | where kql !contains "retention"`},
+	}
+	// passwordHandler answers the rule queries like kusto would on the declared kql.
+	passwordHandler := func(db, stmt string) (*kusto.RowIterator, error) {
+		lines := strings.SplitN(stmt, "\n", 2)
+		declaration, query := lines[0], lines[1]
+		violated := false
+		switch {
+		case strings.Contains(query, "password"):
+			violated = strings.Contains(declaration, "password:")
+		case strings.Contains(query, "retention"):
+			violated = !strings.Contains(declaration, "retention")
+		}
+		if !violated {
+			return mockRows(table.Columns{{Name: "kql", Type: types.String}})
+		}
+		return mockRows(table.Columns{{Name: "kql", Type: types.String}}, []string{declaration})
+	}
+	var client *scriptedKusto
+	var cluster *kustoutils.KustoCluster
+	targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+
+	BeforeEach(func() {
+		client = &scriptedKusto{query: passwordHandler}
+		cluster = &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: client}
+	})
+	execConfig := func(kql string) schemav1alpha1.ExecutionConfiguration {
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": kql}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		return exeCfg
+	}
+
+	It("should report the rules the proposed kql violates", func() {
+		kql := ".create-merge table Users (Name:string, password:string)\n.alter table Users policy retention \"{}\""
+		violations, err := cluster.CheckSchemaRules(context.Background(), targets, execConfig(kql), rules)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(Equal([]kustoutils.RuleViolation{{Rule: rules[0], Message: "columns must not be named password"}}))
+		Expect(client.stmts).To(HaveLen(2))
+		Expect(client.stmts[0]).To(HavePrefix(`let proposedKQL = ".create-merge table Users (Name:string, password:string)\n.alter table Users policy retention \"{}\"`))
+		Expect(client.stmts[0]).To(HaveSuffix(rules[0].Query))
+	})
+	It("should default the message of a rule", func() {
+		violations, err := cluster.CheckSchemaRules(context.Background(), targets, execConfig(".create-merge table Users (Name:string)"), rules)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(HaveLen(1))
+		Expect(violations[0].Message).To(Equal("schema rule retention is violated"))
+	})
+	It("should report a rule with a management command as violated", func() {
+		invalid := []schemav1alpha1.SchemaRule{{Name: "drop", Query: ".drop table Users", Enforcement: schemav1alpha1.EnforcementDeny}}
+		violations, err := cluster.CheckSchemaRules(context.Background(), targets, execConfig(".create-merge table Users (Name:string)"), invalid)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(HaveLen(1))
+		Expect(violations[0].Message).To(ContainSubstring("management commands"))
+		Expect(client.stmts).To(BeEmpty())
+	})
+})
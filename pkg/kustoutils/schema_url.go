@@ -0,0 +1,105 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SchemaURLKey is the `ConfigMap` key holding the URL the kql is downloaded from instead of the `kql` key.
+	SchemaURLKey = "schemaURL"
+	// DefaultMaxSchemaSize is the maximal size of a downloaded schema, unless configured otherwise.
+	DefaultMaxSchemaSize int64 = 10 << 20
+
+	importAttempts = 3
+)
+
+// importRetryDelay is the delay before the first retry, doubled on every attempt.
+var importRetryDelay = time.Second
+
+// allowedSchemaContentTypes are the content types served by blob storage and github raw for kql files.
+var allowedSchemaContentTypes = map[string]struct{}{
+	"text/plain":               {},
+	"application/octet-stream": {},
+}
+
+// ImportSchemaFromURL downloads the kql schema from `url` (e.g. an Azure Blob SAS URL or a GitHub raw URL).
+// Failed requests and server errors are retried with an exponential backoff.
+// A nil `client` uses `http.DefaultClient`.
+func ImportSchemaFromURL(ctx context.Context, url string, client *http.Client) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxSize := config.GetInt64(config.SchemaURLMaxSizeKey)
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSchemaSize
+	}
+
+	var err error
+	delay := importRetryDelay
+	for attempt := 1; attempt <= importAttempts; attempt++ {
+		var body []byte
+		var retry bool
+		body, retry, err = downloadSchema(ctx, url, client, maxSize)
+		if err == nil {
+			return body, nil
+		}
+		if !retry || attempt == importAttempts {
+			break
+		}
+		log.Warn().Err(err).Msgf("failed downloading the schema - retrying in %s", delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	log.Error().Err(err).Msg("failed downloading the schema")
+	return nil, err
+}
+
+// downloadSchema performs a single GET of `url` and reports if a failure is worth retrying.
+func downloadSchema(ctx context.Context, url string, client *http.Client, maxSize int64) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("unexpected status downloading the schema: %s", resp.Status)
+	}
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid schema content type %q: %w", resp.Header.Get("Content-Type"), err)
+	}
+	if _, ok := allowedSchemaContentTypes[contentType]; !ok {
+		return nil, false, fmt.Errorf("unsupported schema content type: %s", contentType)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, false, fmt.Errorf("schema size %d exceeds the limit of %d bytes", resp.ContentLength, maxSize)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, true, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, false, fmt.Errorf("schema exceeds the limit of %d bytes", maxSize)
+	}
+	return body, false, nil
+}
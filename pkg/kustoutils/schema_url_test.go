@@ -0,0 +1,103 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+)
+
+const remoteKQL = ".create-merge table Remote (Timestamp:datetime)"
+
+var _ = Describe("Schema import from URL", func() {
+	var restore func()
+	var requests int32
+	// failures is the number of requests answered with a server error before the schema is served.
+	var failures int32
+	var contentType string
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		restore = kustoutils.SetImportRetryDelay(time.Millisecond)
+		atomic.StoreInt32(&requests, 0)
+		failures = 0
+		contentType = "text/plain; charset=utf-8"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			if r.URL.Path == "/large.kql" {
+				_, _ = w.Write([]byte(strings.Repeat("a", 64)))
+				return
+			}
+			_, _ = w.Write([]byte(remoteKQL))
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+		restore()
+		viper.Set(config.SchemaURLMaxSizeKey, nil)
+	})
+
+	It("should download the schema", func() {
+		contentType = "application/octet-stream"
+		schema, err := kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/schema.kql?sv=2021-06-08&sig=abc", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(schema)).To(Equal(remoteKQL))
+	})
+	It("should retry server errors", func() {
+		failures = 2
+		schema, err := kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/schema.kql", server.Client())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(schema)).To(Equal(remoteKQL))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+	})
+	It("should give up after three attempts", func() {
+		failures = 3
+		_, err := kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/schema.kql", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+	})
+	It("should reject unexpected content types", func() {
+		contentType = "text/html"
+		_, err := kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/schema.kql", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+	})
+	It("should enforce the max size", func() {
+		viper.Set(config.SchemaURLMaxSizeKey, 56)
+		_, err := kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/large.kql", nil)
+		Expect(err).To(HaveOccurred())
+		_, err = kustoutils.ImportSchemaFromURL(context.Background(), server.URL+"/schema.kql", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("should use the downloaded schema instead of the configmap kql", func() {
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+		cfgMap := &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":                   ".create-merge table Local (Timestamp:datetime)",
+				kustoutils.SchemaURLKey: server.URL + "/schema.kql",
+			},
+		}
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		kql, err := ioutil.ReadFile(exeCfg.KQLFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(kql)).To(Equal(remoteKQL))
+	})
+})
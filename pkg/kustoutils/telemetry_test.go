@@ -0,0 +1,83 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Cluster telemetry", func() {
+	var tracer *fake.Tracer
+	var operatorTelemetry *telemetry.SchemaOperatorTelemetry
+
+	BeforeEach(func() {
+		tracer = &fake.Tracer{}
+		var err error
+		operatorTelemetry, err = telemetry.NewTelemetry(telemetry.TelemetryOptions{Registerer: prometheus.NewRegistry(), Tracer: tracer})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should pass the telemetry to the delta-kusto wrapper", func() {
+		cluster := &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &scriptedKusto{}}
+		cluster.SetTelemetry(operatorTelemetry)
+		Expect(cluster.Telemetry).To(BeIdenticalTo(operatorTelemetry))
+		Expect(cluster.DeltaWrapper().Telemetry).To(BeIdenticalTo(operatorTelemetry))
+	})
+	It("should set the telemetry of the cached clusters", func() {
+		cache := kustoutils.NewTestClusterClientCache(time.Minute, func(uri string) *kustoutils.KustoCluster {
+			return &kustoutils.KustoCluster{URI: uri, Client: &scriptedKusto{}}
+		}, time.Now)
+		cache.Telemetry = operatorTelemetry
+		cluster := cache.GetOrCreateCluster("https://cluster1.westeurope.kusto.windows.net")
+		Expect(cluster.Telemetry).To(BeIdenticalTo(operatorTelemetry))
+		Expect(cluster.DeltaWrapper().Telemetry).To(BeIdenticalTo(operatorTelemetry))
+	})
+	It("should trace and measure the executions", func() {
+		jobErr := error(nil)
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error {
+			return jobErr
+		})
+		defer restore()
+		cluster := &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &scriptedKusto{}}
+		cluster.SetTelemetry(operatorTelemetry)
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table T (a:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+		jobErr = fmt.Errorf("delta-kusto failed")
+		_, err = cluster.Execute(targets, exeCfg)
+		Expect(err).To(HaveOccurred())
+
+		Expect(tracer.SpanNames()).To(Equal([]string{"kusto/Execute", "delta-kusto", "kusto/Execute", "delta-kusto"}))
+		spans := tracer.Spans()
+		Expect(spans[0].Err).NotTo(HaveOccurred())
+		Expect(spans[3].Err).To(MatchError("delta-kusto failed"))
+		Expect(spans[2].Ended).To(BeTrue())
+		Expect(testutil.ToFloat64(operatorTelemetry.Metrics.DeltaJobs.WithLabelValues("success"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(operatorTelemetry.Metrics.DeltaJobs.WithLabelValues("failure"))).To(Equal(1.0))
+	})
+	It("should execute without telemetry", func() {
+		restore := kustoutils.SetDeltaRunner(func(jobFile string) error { return nil })
+		defer restore()
+		cluster := &kustoutils.KustoCluster{URI: "https://mock.eastus.kusto.windows.net", Client: &scriptedKusto{}}
+		cluster.SetTelemetry(nil)
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}
+		exeCfg, err := cluster.CreateExecConfiguration(targets, &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table T (a:string)"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cluster.Execute(targets, exeCfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,146 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// kustoCloudSuffixes maps the kusto domain of each national cloud to its environment. It is the single table of the
+// known kusto domains, for both the cluster names and the cloud environments.
+var kustoCloudSuffixes = map[string]azure.Environment{
+	"kusto.windows.net":       azure.PublicCloud,
+	"kustomfa.windows.net":    azure.PublicCloud,
+	"kustomfa.azure.com":      azure.PublicCloud,
+	"kusto.azuresynapse.net":  azure.PublicCloud,
+	"kusto.chinacloudapi.cn":  azure.ChinaCloud,
+	"kusto.usgovcloudapi.net": azure.USGovernmentCloud,
+}
+
+// DefaultClusterDomainSuffixes are the kusto domains of the public, synapse, government and sovereign clouds.
+var DefaultClusterDomainSuffixes = cloudDomainSuffixes()
+
+// cloudDomainSuffixes returns the sorted domains of the `kustoCloudSuffixes`.
+func cloudDomainSuffixes() []string {
+	suffixes := make([]string, 0, len(kustoCloudSuffixes))
+	for suffix := range kustoCloudSuffixes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+	return suffixes
+}
+
+// CloudEnvironmentForURI returns the national cloud environment of the kusto cluster `uri`.
+// An error is returned when the host does not end with a known kusto domain.
+func CloudEnvironmentForURI(uri string) (azure.Environment, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return azure.Environment{}, err
+	}
+	host := strings.ToLower(u.Hostname())
+	for suffix, env := range kustoCloudSuffixes {
+		if strings.HasSuffix(host, "."+suffix) {
+			return env, nil
+		}
+	}
+	return azure.Environment{}, fmt.Errorf("unknown kusto cloud domain for host: %s", u.Host)
+}
+
+// TenantCredentials are the service principal credentials the kusto clusters of a tenant are authorized with.
+type TenantCredentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// OperatorTenantCredentials returns the credentials of the operator service principal in the tenant `tenantID`.
+// The client ID and secret are read from `AZURE_CLIENT_ID` and `AZURE_CLIENT_SECRET`.
+func OperatorTenantCredentials(tenantID string) TenantCredentials {
+	return TenantCredentials{
+		TenantID:     tenantID,
+		ClientID:     strings.TrimSpace(viper.GetString(config.AzureClientIDKey)),
+		ClientSecret: strings.TrimSpace(viper.GetString(config.AzureClientSecretKey)),
+	}
+}
+
+// ClientCredentialsConfig returns the client credentials of the kusto cluster `uri`, in the national cloud of the cluster.
+func (t TenantCredentials) ClientCredentialsConfig(uri string) (auth.ClientCredentialsConfig, error) {
+	env, err := CloudEnvironmentForURI(uri)
+	if err != nil {
+		return auth.ClientCredentialsConfig{}, err
+	}
+	cfg := auth.NewClientCredentialsConfig(t.ClientID, t.ClientSecret, t.TenantID)
+	cfg.AADEndpoint = env.ActiveDirectoryEndpoint
+	cfg.Resource = uri
+	return cfg, nil
+}
+
+// TenantCredentialsConfig returns client credentials for the kusto cluster `uri` in the tenant `tenantID`.
+// The client ID and secret are read from `AZURE_CLIENT_ID` and `AZURE_CLIENT_SECRET`.
+func TenantCredentialsConfig(uri, tenantID string) (auth.ClientCredentialsConfig, error) {
+	return OperatorTenantCredentials(tenantID).ClientCredentialsConfig(uri)
+}
+
+// NewKustoClusterForTenant returns a new KustoCluster object with a client authorized against the tenant `tenantID`
+// instead of the ambient tenant.
+func NewKustoClusterForTenant(uri, tenantID string) *KustoCluster {
+	cls := &KustoCluster{
+		URI:      uri,
+		TenantID: tenantID,
+		wrapper:  NewDeltaWrapper(),
+	}
+
+	cfg, err := TenantCredentialsConfig(uri, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to configure credentials for %s in tenant %s", uri, tenantID)
+		return cls
+	}
+	cls.Client = newAuthorizedClient(uri, tenantID, cfg)
+	return cls
+}
+
+// NewKustoClusterWithCredentials returns a new KustoCluster object whose client and delta-kusto jobs are
+// authorized with the `creds` service principal instead of the operator identity.
+func NewKustoClusterWithCredentials(uri string, creds TenantCredentials) *KustoCluster {
+	wrapper := NewDeltaWrapper()
+	wrapper.Credentials = &creds
+	cls := &KustoCluster{
+		URI:      uri,
+		TenantID: creds.TenantID,
+		wrapper:  wrapper,
+	}
+
+	cfg, err := creds.ClientCredentialsConfig(uri)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to configure credentials for %s in tenant %s", uri, creds.TenantID)
+		return cls
+	}
+	cls.Client = newAuthorizedClient(uri, creds.TenantID, cfg)
+	return cls
+}
+
+// newAuthorizedClient returns a kusto client of `uri` authorized with `cfg`, nil when the client can't be created.
+func newAuthorizedClient(uri, tenantID string, cfg auth.ClientCredentialsConfig) QueryClient {
+	a, err := cfg.Authorizer()
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to authorize to %s in tenant %s", uri, tenantID)
+		return nil
+	}
+
+	client, err := kusto.New(uri, kusto.Authorization{Authorizer: a})
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to connect to %s", uri)
+		return nil
+	}
+	return client
+}
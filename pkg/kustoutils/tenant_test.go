@@ -0,0 +1,117 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const tenantClusterURI = "https://cluster1.westeurope.kusto.windows.net"
+
+// tokenServer is a mock AAD token endpoint issuing a token named after the requested tenant.
+type tokenServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	tenants []string
+}
+
+func newTokenServer() *tokenServer {
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		ts.mu.Lock()
+		ts.tenants = append(ts.tenants, tenant)
+		ts.mu.Unlock()
+		expires := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "token-" + tenant,
+			"token_type":   "Bearer",
+			"expires_in":   "3600",
+			"expires_on":   expires,
+			"not_before":   expires,
+			"resource":     r.FormValue("resource"),
+		})
+	}))
+	return ts
+}
+
+// authorizationFor returns the authorization header a tenant authorizer adds to a request.
+func authorizationFor(ts *tokenServer, tenantID string) string {
+	cfg, err := kustoutils.TenantCredentialsConfig(tenantClusterURI, tenantID)
+	Expect(err).NotTo(HaveOccurred())
+	cfg.AADEndpoint = ts.URL + "/"
+	authorizer, err := cfg.Authorizer()
+	Expect(err).NotTo(HaveOccurred())
+	req, err := http.NewRequest(http.MethodPost, tenantClusterURI, nil)
+	Expect(err).NotTo(HaveOccurred())
+	req, err = autorest.Prepare(req, authorizer.WithAuthorization())
+	Expect(err).NotTo(HaveOccurred())
+	return req.Header.Get("Authorization")
+}
+
+var _ = Describe("Tenants", func() {
+	BeforeEach(func() {
+		os.Setenv("AZURE_CLIENT_ID", "client-id")
+		os.Setenv("AZURE_CLIENT_SECRET", "client-secret")
+	})
+	AfterEach(func() {
+		os.Unsetenv("AZURE_CLIENT_ID")
+		os.Unsetenv("AZURE_CLIENT_SECRET")
+	})
+
+	It("should build credentials for the given tenant", func() {
+		cfg, err := kustoutils.TenantCredentialsConfig(tenantClusterURI, "tenant-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.TenantID).To(Equal("tenant-a"))
+		Expect(cfg.ClientID).To(Equal("client-id"))
+		Expect(cfg.ClientSecret).To(Equal("client-secret"))
+		Expect(cfg.Resource).To(Equal(tenantClusterURI))
+		Expect(cfg.AADEndpoint).To(Equal(azure.PublicCloud.ActiveDirectoryEndpoint))
+	})
+	It("should acquire tokens from each tenant separately", func() {
+		ts := newTokenServer()
+		defer ts.Close()
+		Expect(authorizationFor(ts, "tenant-a")).To(Equal("Bearer token-tenant-a"))
+		Expect(authorizationFor(ts, "tenant-b")).To(Equal("Bearer token-tenant-b"))
+		Expect(ts.tenants).To(Equal([]string{"tenant-a", "tenant-b"}))
+	})
+	It("should match the cluster domain to the national cloud", func() {
+		env, err := kustoutils.CloudEnvironmentForURI("https://cluster1.chinaeast2.kusto.chinacloudapi.cn")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env.Name).To(Equal(azure.ChinaCloud.Name))
+		env, err = kustoutils.CloudEnvironmentForURI("https://cluster1.usgovvirginia.kusto.usgovcloudapi.net")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env.Name).To(Equal(azure.USGovernmentCloud.Name))
+		_, err = kustoutils.CloudEnvironmentForURI("https://cluster1.westeurope.kusto.example.com")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should keep the tenant on the cluster", func() {
+		cls := kustoutils.NewKustoClusterForTenant(tenantClusterURI, "tenant-a")
+		Expect(cls.TenantID).To(Equal("tenant-a"))
+		Expect(cls.Client).NotTo(BeNil())
+		cls = kustoutils.NewKustoClusterForTenant("https://cluster1.kusto.example.com", "tenant-a")
+		Expect(cls.Client).To(BeNil())
+	})
+	It("should authorize the client and the jobs with the tenant credentials", func() {
+		creds := kustoutils.TenantCredentials{TenantID: "tenant-b", ClientID: "customer-client", ClientSecret: "customer-secret"}
+		cls := kustoutils.NewKustoClusterWithCredentials(tenantClusterURI, creds)
+		Expect(cls.TenantID).To(Equal("tenant-b"))
+		Expect(cls.Client).NotTo(BeNil())
+		Expect(kustoutils.JobCredentials(cls)).To(Equal(&creds))
+		Expect(kustoutils.JobCredentials(kustoutils.NewKustoClusterForTenant(tenantClusterURI, "tenant-a"))).To(BeNil())
+	})
+})
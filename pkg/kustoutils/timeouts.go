@@ -0,0 +1,88 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
+)
+
+// DefaultMgmtTimeout is the time the database management commands may take, unless `MgmtTimeout` or the mgmt timeout setting is set.
+const DefaultMgmtTimeout = 30 * time.Second
+
+// executionTimeout returns the configured time a delta-kusto execution may run, zero for no limit.
+func executionTimeout() time.Duration {
+	return config.GetDuration(config.ExecutionTimeoutKey)
+}
+
+// databaseTimeout returns the time the delta-kusto job of `db` may run,
+// its `DatabaseTimeouts` entry or else the configured execution timeout.
+func databaseTimeout(exeCfg schemav1alpha1.ExecutionConfiguration, db string) time.Duration {
+	if timeout, ok := exeCfg.DatabaseTimeouts[db]; ok && timeout.Duration > 0 {
+		return timeout.Duration
+	}
+	return executionTimeout()
+}
+
+// withTimeout returns a context done once `timeout` passes, without a deadline for a zero `timeout`.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// mgmtTimeout returns the time the database management commands of the cluster may take.
+func (c *KustoCluster) mgmtTimeout() time.Duration {
+	timeout := c.MgmtTimeout
+	if timeout <= 0 {
+		timeout = config.GetDuration(config.KustoMgmtTimeoutKey)
+	}
+	if timeout <= 0 {
+		timeout = DefaultMgmtTimeout
+	}
+	return timeout
+}
+
+// TimedRowIterator is the `kusto.RowIterator` of a command bound by a timeout, stopping it also releases the timeout context.
+type TimedRowIterator struct {
+	*kusto.RowIterator
+	cancel context.CancelFunc
+}
+
+// Stop stops the row stream and cancels the context of the command.
+func (i *TimedRowIterator) Stop() {
+	i.RowIterator.Stop()
+	i.cancel()
+}
+
+// MgmtWithTimeout runs the management command `stmt` on `db`, failing once `timeout` passes instead of hanging on a degraded cluster.
+// The rows are streamed after the call returns, so the deadline also bounds reading them until the returned iterator is stopped.
+func (c *KustoCluster) MgmtWithTimeout(ctx context.Context, db string, stmt kusto.Stmt, timeout time.Duration, opts ...kusto.MgmtOption) (*TimedRowIterator, error) {
+	return withTimedIterator(ctx, timeout, func(ctx context.Context) (*kusto.RowIterator, error) {
+		return c.Client.Mgmt(ctx, db, stmt, opts...)
+	})
+}
+
+// QueryWithTimeout runs the query `stmt` on `db` like `MgmtWithTimeout` runs a management command.
+func (c *KustoCluster) QueryWithTimeout(ctx context.Context, db string, stmt kusto.Stmt, timeout time.Duration, opts ...kusto.QueryOption) (*TimedRowIterator, error) {
+	return withTimedIterator(ctx, timeout, func(ctx context.Context) (*kusto.RowIterator, error) {
+		return c.Client.Query(ctx, db, stmt, opts...)
+	})
+}
+
+// withTimedIterator runs `run` with a context done once `timeout` passes, the context is cancelled on a failed call
+// and otherwise when the returned iterator is stopped.
+func withTimedIterator(ctx context.Context, timeout time.Duration, run func(ctx context.Context) (*kusto.RowIterator, error)) (*TimedRowIterator, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	iter, err := run(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &TimedRowIterator{RowIterator: iter, cancel: cancel}, nil
+}
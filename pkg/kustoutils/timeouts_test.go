@@ -0,0 +1,93 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// slowKusto is a mock client answering the management commands after `delay`, unless the context is done first.
+type slowKusto struct {
+	scriptedKusto
+	delay time.Duration
+}
+
+func (m *slowKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(m.delay):
+		return m.scriptedKusto.Mgmt(ctx, db, query, options...)
+	}
+}
+
+// ctxKusto is a mock client keeping the context of the last management command.
+type ctxKusto struct {
+	scriptedKusto
+	ctx context.Context
+}
+
+func (m *ctxKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	m.ctx = ctx
+	return m.scriptedKusto.Mgmt(ctx, db, query, options...)
+}
+
+var _ = Describe("Management timeouts", func() {
+	ctx := context.Background()
+
+	It("should answer within the timeout", func() {
+		client := &slowKusto{scriptedKusto: scriptedKusto{mgmt: func(db, stmt string) (*kusto.RowIterator, error) {
+			return mockRows(table.Columns{{Name: "DatabaseName", Type: types.String}}, []string{"db1"}, []string{"db2"})
+		}}, delay: 10 * time.Millisecond}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		iter, err := cluster.MgmtWithTimeout(ctx, "", kusto.NewStmt(".show databases"), time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		iter.Stop()
+
+		dbs, err := cluster.ListDatabases("db.*")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dbs).To(ConsistOf("db1", "db2"))
+		Expect(cluster.EnsureDatabase(ctx, "db1", "", "")).To(Succeed())
+	})
+	It("should fail once the timeout passes", func() {
+		client := &slowKusto{scriptedKusto: scriptedKusto{mgmt: existingDBsHandler("db1")}, delay: time.Minute}
+		cluster := &kustoutils.KustoCluster{Client: client, MgmtTimeout: 20 * time.Millisecond}
+		start := time.Now()
+		_, err := cluster.MgmtWithTimeout(ctx, "", kusto.NewStmt(".show databases"), 20*time.Millisecond)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+
+		_, err = cluster.ListDatabases("db.*")
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(cluster.EnsureDatabase(ctx, "db2", "", "")).To(Equal(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(client.stmts).To(BeEmpty())
+	})
+	It("should cancel the command context once the rows are stopped", func() {
+		client := &ctxKusto{scriptedKusto: scriptedKusto{mgmt: existingDBsHandler("db1")}}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		iter, err := cluster.MgmtWithTimeout(ctx, "", kusto.NewStmt(".show databases"), time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ctx.Err()).NotTo(HaveOccurred())
+		iter.Stop()
+		Expect(client.ctx.Err()).To(Equal(context.Canceled))
+	})
+	It("should bound the other management commands", func() {
+		client := &slowKusto{scriptedKusto: scriptedKusto{mgmt: existingDBsHandler("db1")}, delay: time.Minute}
+		cluster := &kustoutils.KustoCluster{Client: client, MgmtTimeout: 20 * time.Millisecond}
+		_, err := cluster.ListDatabasePrincipals(ctx, "db1")
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		_, err = kustoutils.NewSchemaAnnotationProcessor(cluster).Version(ctx, "db1")
+		Expect(err).To(Equal(context.DeadlineExceeded))
+	})
+	It("should default to 30 seconds", func() {
+		Expect(kustoutils.DefaultMgmtTimeout).To(Equal(30 * time.Second))
+	})
+})
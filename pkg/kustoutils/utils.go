@@ -5,17 +5,24 @@ package kustoutils
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/Azure/azure-kusto-go/kusto"
 	"github.com/Azure/azure-kusto-go/kusto/data/errors"
 	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/unsafe"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
 	"github.com/rs/zerolog/log"
 	v1 "k8s.io/api/core/v1"
 )
@@ -36,9 +43,29 @@ type QueryClient interface {
 type KustoCluster struct {
 	URI       string
 	Databases []string
-	Client    QueryClient
+	// TenantID is the tenant the client is authorized against, empty for the ambient tenant.
+	TenantID string
+	Client   QueryClient
 	// Client    *kusto.Client
 	wrapper *Wrapper
+	// httpClient is used for the webhook calls, nil for the default client.
+	httpClient *http.Client
+	// DatabaseCacheTTL is the time the `ListDatabases` results are reused, zero for `DefaultDatabaseCacheTTL`.
+	DatabaseCacheTTL time.Duration
+	dbListCache      dbListCache
+	connection       connectionCache
+	// PreApplyHooks run on every database before the schema is applied, a failing hook skips the database.
+	PreApplyHooks []PreApplyHook
+	// PreApplyHookTimeout is the time the hooks of a database have to complete, zero for `DefaultPreApplyHookTimeout`.
+	PreApplyHookTimeout time.Duration
+	// PostApplyVerifiers run on every executed database, a failing verifier marks the database `Failed`.
+	PostApplyVerifiers []PostApplyVerifier
+	// PingTimeout is the time `Ping` waits for the cluster, zero for the configured or `DefaultPingTimeout`.
+	PingTimeout time.Duration
+	// MgmtTimeout is the time the database management commands may take, zero for the configured or `DefaultMgmtTimeout`.
+	MgmtTimeout time.Duration
+	// Telemetry traces the executions of the cluster (optional), set with `SetTelemetry`.
+	Telemetry *telemetry.SchemaOperatorTelemetry
 }
 
 // NewKustoCluster returns a new KustoCluster object with a client initialized
@@ -66,11 +93,38 @@ func NewKustoCluster(uri string) *KustoCluster {
 	return cls
 }
 
+// NewKustoClusterWithHTTPClient returns a new KustoCluster object whose kusto client and webhook calls use `httpClient`
+// (e.g. for a proxy, mutual TLS or custom timeouts). A nil `httpClient` falls back to `http.DefaultClient`.
+// The authorizer is still created from the environment and is independent of the HTTP client - the
+// client only carries the requests, the authorizer adds the token to each of them.
+func NewKustoClusterWithHTTPClient(uri string, httpClient *http.Client) (*KustoCluster, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	a, err := auth.NewAuthorizerFromEnvironmentWithResource(uri)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to authorize from env to %s", uri)
+		return nil, err
+	}
+	client, err := kusto.New(uri, kusto.Authorization{Authorizer: a}, kusto.WithHttpClient(httpClient))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to connect to %s", uri)
+		return nil, err
+	}
+	return &KustoCluster{
+		URI:        uri,
+		Client:     client,
+		wrapper:    NewDeltaWrapper(),
+		httpClient: httpClient,
+	}, nil
+}
+
 // AquireTargets filters the DBs in the cluster and matchs them with the filter to return DBs to execute on.
 func (c *KustoCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schemav1alpha1.ClusterTargets, error) {
 	var targets schemav1alpha1.ClusterTargets
 	var dbs []string
 	var err error
+	listed := false
 
 	// b.1. get filtered list of dbs to execute on
 	// TODO: Consider extracting this to the Cluster as a filter object
@@ -79,9 +133,18 @@ func (c *KustoCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schema
 	} else if len(filter.DBS) > 0 {
 		// TODO: maybe change this to a filter instead of setting
 		dbs = filter.DBS
+		listed = true
 	} else if filter.Webhook != "" {
-		client := NewWebHookClient(nil)
-		dbs, err = client.PerformQuery(filter.Webhook, ClusterNameFromURI(c.URI), filter.Label)
+		var clusterName string
+		clusterName, err = ClusterNameFromURIWithConfig(c.URI, DefaultClusterDomainSuffixes)
+		if err == nil {
+			var client *WebHookClient
+			client, err = NewWebHookClient(c.httpClient, WithCustomHeaders(filter.WebhookHeaders))
+			if err == nil {
+				dbs, err = client.PerformQuery(filter.Webhook, clusterName, filter.Label)
+			}
+		}
+		listed = true
 	} else {
 		log.Info().Msg("Missing db filter - taking all dbs in the cluster")
 		dbs, err = c.ListDatabases("")
@@ -90,10 +153,174 @@ func (c *KustoCluster) AquireTargets(filter schemav1alpha1.TargetFilter) (schema
 		log.Error().Err(err).Msg("failed retriving list of dbs from cluster")
 		return targets, err
 	}
+	if !listed && filter.FilterExpression != "" {
+		dbs, err = c.FilterByExpression(context.Background(), dbs, filter.FilterExpression)
+		if err != nil {
+			log.Error().Err(err).Msg("failed filtering the dbs with the filter expression")
+			return targets, err
+		}
+	}
+	// dbs taken from the cluster itself exist by definition - only listed ones may be missing.
+	if listed && filter.AutoCreateDatabases {
+		for _, db := range dbs {
+			err = c.EnsureDatabase(context.Background(), db, filter.HotCacheRetention, filter.SoftDeleteRetention)
+			if err != nil {
+				log.Error().Err(err).Msgf("failed ensuring db %s exists", db)
+				return targets, err
+			}
+		}
+	}
+	if !filter.IncludeFollowers {
+		dbs, err = c.withoutFollowers(context.Background(), dbs)
+		if err != nil {
+			return targets, err
+		}
+	}
 	targets.DBs = dbs
 	return targets, err
 }
 
+// IsFollowerDatabase checks if `db` is a read-only follower database, based on its `DatabaseAccessMode`.
+func (c *KustoCluster) IsFollowerDatabase(ctx context.Context, db string) (bool, error) {
+	followers, err := c.followerDatabases(ctx, fmt.Sprintf(".show database ['%s']", db))
+	return followers[db], err
+}
+
+// followerDatabases runs the database listing `cmd` and returns the databases with a read-only `DatabaseAccessMode`.
+func (c *KustoCluster) followerDatabases(ctx context.Context, cmd string) (map[string]bool, error) {
+	iter, err := c.Client.Mgmt(ctx, "", newUnsafeStmt(cmd))
+	if err != nil {
+		log.Error().Err(err).Msgf("failed running: %s", cmd)
+		return nil, err
+	}
+	defer iter.Stop()
+
+	followers := make(map[string]bool)
+	err = iter.Do(
+		func(row *table.Row) error {
+			var db, mode string
+			for i, col := range row.ColumnTypes {
+				switch col.Name {
+				case "DatabaseName":
+					db = row.Values[i].String()
+				case "DatabaseAccessMode":
+					mode = row.Values[i].String()
+				}
+			}
+			if strings.HasPrefix(mode, "ReadOnly") {
+				followers[db] = true
+			}
+			return nil
+		},
+	)
+	return followers, err
+}
+
+// withoutFollowers returns the `dbs` which are not follower databases.
+func (c *KustoCluster) withoutFollowers(ctx context.Context, dbs []string) ([]string, error) {
+	if len(dbs) == 0 {
+		return dbs, nil
+	}
+	followers, err := c.followerDatabases(ctx, ".show databases | project DatabaseName, DatabaseAccessMode")
+	if err != nil {
+		return nil, err
+	}
+	leaders := make([]string, 0, len(dbs))
+	for _, db := range dbs {
+		if followers[db] {
+			log.Warn().Msgf("%s on %s is a follower database - skipping", db, c.URI)
+			continue
+		}
+		leaders = append(leaders, db)
+	}
+	return leaders, nil
+}
+
+var (
+	// kqlDatabaseNameRe are the kusto database names - letters, digits, underscores, spaces, dots and dashes.
+	kqlDatabaseNameRe = regexp.MustCompile(`^[\p{L}\p{N}_ .-]{1,260}$`)
+	// kqlTimespanRe are the kusto timespan literals, e.g. `30d`, `1.5h`, `100ms` or `10.00:00:00`.
+	kqlTimespanRe = regexp.MustCompile(`^(?:\d+(?:\.\d+)?(?:d|h|m|s|ms|microseconds?|ticks?)|(?:\d+\.)?\d{1,2}:\d{2}(?::\d{2}(?:\.\d+)?)?)$`)
+)
+
+// validateDatabaseSettings checks the database name and the retention timespans against the kusto grammar,
+// since they are formatted into the management commands of `EnsureDatabase`.
+func validateDatabaseSettings(db, hotCacheRetention, softDeleteRetention string) error {
+	if !kqlDatabaseNameRe.MatchString(db) {
+		return fmt.Errorf("invalid kusto database name: %q", db)
+	}
+	for _, retention := range []string{hotCacheRetention, softDeleteRetention} {
+		if retention != "" && !kqlTimespanRe.MatchString(retention) {
+			return fmt.Errorf("invalid kusto timespan for database %s: %q", db, retention)
+		}
+	}
+	return nil
+}
+
+// EnsureDatabase creates the database `db` if it does not exist in the cluster.
+// The optional `hotCacheRetention` and `softDeleteRetention` values (e.g. `30d`) are set as the database
+// caching and retention policies when the database is created.
+func (c *KustoCluster) EnsureDatabase(ctx context.Context, db, hotCacheRetention, softDeleteRetention string) error {
+	if err := validateDatabaseSettings(db, hotCacheRetention, softDeleteRetention); err != nil {
+		return err
+	}
+	timeout := c.mgmtTimeout()
+	exists, err := c.databaseExists(ctx, db, timeout)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Debug().Msgf("database %s already exists", db)
+		return nil
+	}
+
+	log.Info().Msgf("database %s is missing - creating it", db)
+	defer c.ClearDatabaseCache()
+	cmds := []string{fmt.Sprintf(".create database ['%s'] ifnotexists", db)}
+	if softDeleteRetention != "" {
+		cmds = append(cmds, fmt.Sprintf(".alter-merge database ['%s'] policy retention softdelete = %s", db, softDeleteRetention))
+	}
+	if hotCacheRetention != "" {
+		cmds = append(cmds, fmt.Sprintf(".alter database ['%s'] policy caching hot = %s", db, hotCacheRetention))
+	}
+	for _, cmd := range cmds {
+		iter, err := c.MgmtWithTimeout(ctx, "", newUnsafeStmt(cmd), timeout)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed running: %s", cmd)
+			return err
+		}
+		iter.Stop()
+	}
+	return nil
+}
+
+// databaseExists checks if a database with the exact name `db` exists in the cluster.
+func (c *KustoCluster) databaseExists(ctx context.Context, db string, timeout time.Duration) (bool, error) {
+	iter, err := c.MgmtWithTimeout(ctx, "", newUnsafeStmt(fmt.Sprintf(".show databases | where DatabaseName == '%s' | project DatabaseName", db)), timeout)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to query mgmt api for db %s", db)
+		return false, err
+	}
+	defer iter.Stop()
+
+	exists := false
+	err = iter.Do(
+		func(row *table.Row) error {
+			if row.Values[0].String() == db {
+				exists = true
+			}
+			return nil
+		},
+	)
+	return exists, err
+}
+
+// newUnsafeStmt builds a `kusto.Stmt` from a dynamically generated command.
+// Only use it with values that come from the operator configuration.
+func newUnsafeStmt(cmd string) kusto.Stmt {
+	return kusto.NewStmt("", kusto.UnsafeStmt(unsafe.Stmt{Add: true, SuppressWarning: true})).UnsafeAdd(cmd)
+}
+
 // ListDatabases lists kusto databases matching the regexp expression.
 func (c *KustoCluster) ListDatabases(expression string) ([]string, error) {
 
@@ -103,10 +330,14 @@ func (c *KustoCluster) ListDatabases(expression string) ([]string, error) {
 		log.Error().Err(err).Msgf("parameter proveded is not a valid regexp: %s", expression)
 		return nil, err
 	}
+	if dbs, ok := c.dbListCache.get(expression); ok {
+		log.Debug().Msgf("using the cached databases of %s", c.URI)
+		return dbs, nil
+	}
 
 	dbs := make([]string, 0)
 
-	iter, err := c.Client.Mgmt(ctx, "", kusto.NewStmt(".show databases | project DatabaseName"))
+	iter, err := c.MgmtWithTimeout(ctx, "", kusto.NewStmt(".show databases | project DatabaseName"), c.mgmtTimeout())
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to query mgmt api")
 		return nil, err
@@ -134,21 +365,108 @@ func (c *KustoCluster) ListDatabases(expression string) ([]string, error) {
 		log.Error().Err(err).Msg("failed to iterate results")
 		return nil, err
 	}
+	c.dbListCache.set(expression, dbs, c.databaseCacheTTL())
 	return dbs, nil
 }
 
-// Execute runs the `ExecutionConfiguration` on the provided targets
+// Execute runs the `ExecutionConfiguration` on the provided targets, the canary database first in `CanaryMode`.
 func (c *KustoCluster) Execute(targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) (schemav1alpha1.ClusterTargets, error) {
-	done := schemav1alpha1.ClusterTargets{}
-	err := RunDeltaKusto(config.JobFile)
+	return c.executeTraced(context.Background(), targets, config, nil)
+}
 
+// executeTraced runs `execute` in a `kusto/Execute` span.
+func (c *KustoCluster) executeTraced(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress DatabaseProgressFunc) (schemav1alpha1.ClusterTargets, error) {
+	spanCtx := c.Telemetry.StartSpan(ctx, "kusto/Execute")
+	done, err := c.execute(ctx, targets, config, progress)
+	c.Telemetry.EndSpan(spanCtx, err)
 	return done, err
 }
 
+// execute runs the `ExecutionConfiguration` on the targets, notifying the optional `progress` of every database.
+// Once `ctx` is done no further delta-kusto job is started.
+func (c *KustoCluster) execute(ctx context.Context, targets schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration, progress DatabaseProgressFunc) (done schemav1alpha1.ClusterTargets, err error) {
+	if _, canary := canaryFirst(targets.DBs, config); canary {
+		return c.executeCanary(ctx, targets, config, progress)
+	}
+	done, config, err = c.ApplyPreCondition(ctx, targets, config)
+	if err != nil {
+		return done, err
+	}
+	defer func() { progress.report(done, err) }()
+	if len(done.DBs) == 0 && len(targets.DBs) > 0 {
+		log.Info().Msgf("no database on %s passed the pre-condition", c.URI)
+		return done, nil
+	}
+	targets.DBs = done.DBs
+	// databases with their own timeout or executed in parallel run in their own delta-kusto job.
+	if config.IsolateExecutions || len(config.DatabaseTimeouts) > 0 || ParallelDatabases(config, len(done.DBs)) > 1 {
+		err = c.executeIsolated(ctx, &done, config, progress)
+	} else if err = ctx.Err(); err == nil {
+		progress.notify(done.DBs, schemav1alpha1.DBPhaseInProgress)
+		jobCtx, cancel := withTimeout(context.Background(), executionTimeout())
+		err = runDeltaJob(jobCtx, c.wrapper, config.JobID, "", config.JobFile, config.ResultDir)
+		cancel()
+	}
+	if err != nil {
+		return done, err
+	}
+	if config.DryRunOutputDir != "" {
+		log.Info().Msgf("dry run on %s - the delta was not applied", c.URI)
+		return done, nil
+	}
+	for _, db := range done.DBs {
+		done.DBResults[db] = schemav1alpha1.DBResultExecuted
+	}
+
+	if config.WorkloadGroupsFile != "" {
+		groups, err := os.ReadFile(config.WorkloadGroupsFile)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed reading workload groups file %s", config.WorkloadGroupsFile)
+			return done, err
+		}
+		_, err = c.SyncWorkloadGroups(ctx, string(groups), config.AllowWorkloadGroupDrop)
+		if err != nil {
+			log.Error().Err(err).Msg("failed syncing workload groups")
+			return done, err
+		}
+	}
+	if config.GarbageCollection {
+		kql, err := os.ReadFile(config.KQLFile)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed reading kql file %s", config.KQLFile)
+			return done, err
+		}
+		for _, db := range targets.DBs {
+			err = c.CollectGarbage(ctx, db, string(kql), config.FailIfDataLoss)
+			if err != nil {
+				log.Error().Err(err).Msgf("failed garbage collecting %s", db)
+				return done, err
+			}
+		}
+	}
+	for _, db := range targets.DBs {
+		if _, err := RunSchemaTests(ctx, c, db, config.Assertions); err != nil {
+			return done, err
+		}
+	}
+	verifyErr := c.verifyExecution(ctx, &done, config)
+	if err = c.recordSchemaVersion(ctx, done.DBs, config.SchemaVersion); err != nil {
+		return done, err
+	}
+	return done, verifyErr
+}
+
 // CreateExecConfiguration creates execution configuration for the given targets and `ConfigMap` configuration.
 func (c *KustoCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTargets, cfgMap *v1.ConfigMap, failIfDataLoss bool) (schemav1alpha1.ExecutionConfiguration, error) {
 	config := schemav1alpha1.ExecutionConfiguration{}
 	kql, ok := cfgMap.Data["kql"]
+	if url := cfgMap.Data[SchemaURLKey]; url != "" {
+		schema, err := ImportSchemaFromURL(context.Background(), url, c.httpClient)
+		if err != nil {
+			return config, err
+		}
+		kql, ok = string(schema), true
+	}
 	if !ok {
 		return config, fmt.Errorf("no kql found in configmap")
 	}
@@ -157,13 +475,159 @@ func (c *KustoCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTar
 		log.Error().Err(err).Msg("failed downloading kql to file")
 		return config, err
 	}
-	deltaCfgFile, err := c.wrapper.CreateExecConfiguration(c.URI, targets.DBs, kqlFile, failIfDataLoss)
+	if policies, ok := cfgMap.Data[IngestionPoliciesKey]; ok {
+		err = validatePolicyTables(ParseIngestionPolicyTables(policies), kql)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid ingestion policies")
+			return config, err
+		}
+		config.IngestionPoliciesFile, err = StoreKQLSchemaToFile(policies)
+		if err != nil {
+			log.Error().Err(err).Msg("failed downloading ingestion policies to file")
+			return config, err
+		}
+	}
+	if policies, ok := cfgMap.Data[PartitioningPoliciesKey]; ok {
+		partitioning := ParsePartitioningPolicies(policies)
+		err = validatePolicyTables(partitioningTables(partitioning), kql)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid partitioning policies")
+			return config, err
+		}
+		for _, warning := range PartitioningWarnings(partitioning, kql+"\n"+policies) {
+			log.Warn().Msg(warning)
+		}
+		config.PartitioningPoliciesFile, err = StoreKQLSchemaToFile(policies)
+		if err != nil {
+			log.Error().Err(err).Msg("failed downloading partitioning policies to file")
+			return config, err
+		}
+	}
+	if views, ok := cfgMap.Data[MaterializedViewsKey]; ok {
+		if failIfDataLoss {
+			err = c.checkMaterializedViewRebuilds(context.Background(), targets.DBs, ParseMaterializedViews(views))
+			if err != nil {
+				log.Error().Err(err).Msg("materialized views would lose data")
+				return config, err
+			}
+		}
+		config.MaterializedViewsFile, err = StoreKQLSchemaToFile(views)
+		if err != nil {
+			log.Error().Err(err).Msg("failed downloading materialized views to file")
+			return config, err
+		}
+	}
+	// workload groups are cluster level and are applied by `Execute` rather than by delta-kusto.
+	if groups, ok := cfgMap.Data[WorkloadGroupsKey]; ok {
+		config.WorkloadGroupsFile, err = StoreKQLSchemaToFile(groups)
+		if err != nil {
+			log.Error().Err(err).Msg("failed downloading workload groups to file")
+			return config, err
+		}
+	}
+	dryRun := false
+	if val, ok := cfgMap.Data[DryRunKey]; ok {
+		dryRun, err = strconv.ParseBool(val)
+		if err != nil {
+			log.Error().Err(err).Msgf("invalid %s value: %s", DryRunKey, val)
+			return config, err
+		}
+	}
+	if output, ok := cfgMap.Data["dryRunOutputConfigMap"]; ok && output != "" {
+		config.DryRunOutputConfigMap = output
+		dryRun = true
+	}
+	if dryRun {
+		config.DryRunOutputDir, err = os.MkdirTemp("/tmp", "delta-*")
+		if err != nil {
+			log.Error().Err(err).Msg("failed creating the dry run output directory")
+			return config, err
+		}
+	} else {
+		config.AppliedDiffDir, err = os.MkdirTemp("/tmp", "applied-*")
+		if err != nil {
+			log.Error().Err(err).Msg("failed creating the applied delta directory")
+			return config, err
+		}
+	}
+	config.ResultDir, err = os.MkdirTemp("/tmp", "results-*")
 	if err != nil {
-		log.Error().Err(err).Msg("failed generating delta kusto configuration file")
+		log.Error().Err(err).Msg("failed creating the job results directory")
 		return config, err
 	}
+	if strategy, ok := cfgMap.Data[MergeStrategyKey]; ok {
+		config.MergeStrategy = schemav1alpha1.MergeStrategyType(strategy)
+		if _, err = strategyFlags(config.MergeStrategy); err != nil {
+			log.Error().Err(err).Msg("invalid merge strategy")
+			return config, err
+		}
+		if config.MergeStrategy == schemav1alpha1.MergeStrategyReplace && failIfDataLoss {
+			err = fmt.Errorf("the %s merge strategy drops objects and can't be used with failIfDataLoss", config.MergeStrategy)
+			log.Error().Err(err).Msg("invalid merge strategy")
+			return config, err
+		}
+	}
 	config.KQLFile = kqlFile
-	config.JobFile = deltaCfgFile
+	config.FailIfDataLoss = failIfDataLoss
+	config.JobFile, err = c.createJobFile(targets.DBs, kqlFile, config)
+	if err != nil {
+		return config, err
+	}
+	config.JobID = strings.TrimSuffix(filepath.Base(config.JobFile), filepath.Ext(config.JobFile))
+	if err = parseSchemaAnnotation(cfgMap.Data, &config); err != nil {
+		log.Error().Err(err).Msg("invalid schema annotation settings")
+		return config, err
+	}
+	if gc, ok := cfgMap.Data["garbageCollection"]; ok {
+		config.GarbageCollection, err = strconv.ParseBool(gc)
+		if err != nil {
+			log.Error().Err(err).Msgf("invalid garbageCollection value: %s", gc)
+			return config, err
+		}
+	}
+	if guard, ok := cfgMap.Data[PreConditionKey]; ok {
+		err = ValidatePreConditionKQL(guard)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid pre-condition")
+			return config, err
+		}
+		config.PreConditionKQL = guard
+	}
+	if assertions, ok := cfgMap.Data[AssertionsKey]; ok {
+		config.Assertions, err = ParseAssertions(assertions)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid assertions")
+			return config, err
+		}
+	}
+	if isolate, ok := cfgMap.Data[IsolateExecutionsKey]; ok {
+		config.IsolateExecutions, err = strconv.ParseBool(isolate)
+		if err != nil {
+			log.Error().Err(err).Msgf("invalid %s value: %s", IsolateExecutionsKey, isolate)
+			return config, err
+		}
+	}
+	if err = parseCanary(cfgMap.Data, targets.DBs, &config); err != nil {
+		log.Error().Err(err).Msg("invalid canary settings")
+		return config, err
+	}
+	config.MaxParallelDatabases, config.MinWorkers, config.MaxWorkers, err = parseWorkers(cfgMap.Data)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid parallel execution settings")
+		return config, err
+	}
+	err = parseJobArtifact(cfgMap.Data, &config)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid job artifact settings")
+		return config, err
+	}
+	if allowDrop, ok := cfgMap.Data["allowWorkloadGroupDrop"]; ok {
+		config.AllowWorkloadGroupDrop, err = strconv.ParseBool(allowDrop)
+		if err != nil {
+			log.Error().Err(err).Msgf("invalid allowWorkloadGroupDrop value: %s", allowDrop)
+			return config, err
+		}
+	}
 	return config, nil
 }
 
@@ -183,6 +647,42 @@ func (c *KustoCluster) CreateExecConfiguration(targets schemav1alpha1.ClusterTar
 // }
 
 // ClusterNameFromURI returns the cluster name from the given URI
+// A host of an unknown domain falls back to its first label.
+//
+// Deprecated: use ClusterNameFromURIWithConfig, which reports unrecognized domains.
 func ClusterNameFromURI(uri string) string {
-	return strings.Split(strings.Split(uri, "https://")[1], ".")[0]
+	name, err := ClusterNameFromURIWithConfig(uri, DefaultClusterDomainSuffixes)
+	if err == nil {
+		return name
+	}
+	if host := uriHost(uri); host != "" {
+		return strings.Split(host, ".")[0]
+	}
+	log.Error().Err(err).Msg("failed to extract the cluster name")
+	return ""
+}
+
+// ClusterNameFromURIWithConfig returns the cluster name from the given URI.
+// The URI host must end with one of the domain `suffixes`.
+func ClusterNameFromURIWithConfig(uri string, suffixes []string) (string, error) {
+	host := uriHost(uri)
+	for _, suffix := range suffixes {
+		suffix = "." + strings.TrimPrefix(strings.ToLower(suffix), ".")
+		if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			return strings.Split(host, ".")[0], nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized kusto domain for cluster uri: %s", uri)
+}
+
+// uriHost returns the lower case host of the cluster `uri`, which may omit its scheme, empty when it has none.
+func uriHost(uri string) string {
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
 }
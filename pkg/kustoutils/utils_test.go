@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Azure/azure-kusto-go/kusto"
 	"github.com/Azure/azure-kusto-go/kusto/data/table"
@@ -64,6 +65,74 @@ func (m *mockKusto) HttpClient() *http.Client {
 	return &http.Client{}
 }
 
+// scriptedKusto is a mock client that records every statement and answers using the provided handlers.
+type scriptedKusto struct {
+	mockKusto
+	stmts []string
+	mgmt  func(db, stmt string) (*kusto.RowIterator, error)
+	query func(db, stmt string) (*kusto.RowIterator, error)
+}
+
+func (m *scriptedKusto) Query(ctx context.Context, db string, query kusto.Stmt, options ...kusto.QueryOption) (*kusto.RowIterator, error) {
+	m.stmts = append(m.stmts, query.String())
+	return m.query(db, query.String())
+}
+
+func (m *scriptedKusto) Mgmt(ctx context.Context, db string, query kusto.Stmt, options ...kusto.MgmtOption) (*kusto.RowIterator, error) {
+	m.stmts = append(m.stmts, query.String())
+	if m.mgmt == nil {
+		return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+	}
+	return m.mgmt(db, query.String())
+}
+
+// mockRows returns a `RowIterator` playing back the given string rows.
+func mockRows(columns table.Columns, rows ...[]string) (*kusto.RowIterator, error) {
+	mr, err := kusto.NewMockRows(columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		values := value.Values{}
+		for _, cell := range row {
+			values = append(values, value.String{Valid: true, Value: cell})
+		}
+		if err := mr.Row(values); err != nil {
+			return nil, err
+		}
+	}
+	ri := &kusto.RowIterator{}
+	err = ri.Mock(mr)
+	return ri, err
+}
+
+// existingDBsHandler answers database existence queries according to the `existing` list.
+func existingDBsHandler(existing ...string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		columns := table.Columns{{Name: "DatabaseName", Type: types.String}}
+		if !strings.HasPrefix(stmt, ".show databases") {
+			return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+		}
+		rows := [][]string{}
+		for _, name := range existing {
+			if strings.Contains(stmt, "'"+name+"'") {
+				rows = append(rows, []string{name})
+			}
+		}
+		return mockRows(columns, rows...)
+	}
+}
+
+func countPrefix(stmts []string, prefix string) int {
+	n := 0
+	for _, stmt := range stmts {
+		if strings.HasPrefix(stmt, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
 var _ = Describe("Utils", func() {
 	// add utils tests
 	Context("Filter results from mock client", func() {
@@ -127,6 +196,115 @@ var _ = Describe("Utils", func() {
 		})
 
 	})
+	Context("when filtering follower databases", func() {
+		// accessModeHandler answers the database listings with a leader and a follower database.
+		accessModeHandler := func(db, stmt string) (*kusto.RowIterator, error) {
+			columns := table.Columns{{Name: "DatabaseName", Type: types.String}, {Name: "DatabaseAccessMode", Type: types.String}}
+			rows := [][]string{{"leader", "ReadWrite"}, {"follower", "ReadOnlyFollowing"}}
+			if strings.HasPrefix(stmt, ".show database ['") {
+				for _, row := range rows {
+					if strings.Contains(stmt, "'"+row[0]+"'") {
+						return mockRows(columns, row)
+					}
+				}
+				return mockRows(columns)
+			}
+			return mockRows(columns, rows...)
+		}
+
+		It("should detect follower databases", func() {
+			cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: accessModeHandler}}
+			follower, err := cluster.IsFollowerDatabase(context.Background(), "follower")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(follower).To(BeTrue())
+			follower, err = cluster.IsFollowerDatabase(context.Background(), "leader")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(follower).To(BeFalse())
+		})
+		It("should only target the leader databases", func() {
+			cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: accessModeHandler}}
+			targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{DBS: []string{"leader", "follower"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"leader"}))
+		})
+		It("should keep the followers when included", func() {
+			client := &scriptedKusto{mgmt: accessModeHandler}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{DBS: []string{"leader", "follower"}, IncludeFollowers: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"leader", "follower"}))
+			Expect(client.stmts).To(BeEmpty())
+		})
+	})
+	Context("when ensuring databases exist", func() {
+		It("should not create an existing database", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler("tenant_1")}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			err := cluster.EnsureDatabase(context.Background(), "tenant_1", "30d", "365d")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countPrefix(client.stmts, ".create database")).To(Equal(0))
+		})
+		It("should create a missing database with the retention settings", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler("tenant_1")}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			err := cluster.EnsureDatabase(context.Background(), "tenant_3", "30d", "365d")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.stmts).To(ContainElement(".create database ['tenant_3'] ifnotexists"))
+			Expect(client.stmts).To(ContainElement(".alter-merge database ['tenant_3'] policy retention softdelete = 365d"))
+			Expect(client.stmts).To(ContainElement(".alter database ['tenant_3'] policy caching hot = 30d"))
+		})
+		It("should skip retention policies when not set", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler()}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			err := cluster.EnsureDatabase(context.Background(), "tenant_3", "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countPrefix(client.stmts, ".create database")).To(Equal(1))
+			Expect(countPrefix(client.stmts, ".alter")).To(Equal(0))
+		})
+		It("should reject database names and retentions outside the kusto grammar", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler()}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			for _, db := range []string{"", "tenant'] ifnotexists; .drop database ['prod", "tenant]", "tenant\n.drop table T"} {
+				Expect(cluster.EnsureDatabase(context.Background(), db, "", "")).To(HaveOccurred(), db)
+			}
+			for _, retention := range []string{"30d; .drop database ['prod']", "thirty days", "30 d"} {
+				Expect(cluster.EnsureDatabase(context.Background(), "tenant_3", retention, "")).To(HaveOccurred(), retention)
+				Expect(cluster.EnsureDatabase(context.Background(), "tenant_3", "", retention)).To(HaveOccurred(), retention)
+			}
+			Expect(client.stmts).To(BeEmpty())
+		})
+		It("should accept the kusto timespan literals", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler("tenant 3.eu-west")}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			for _, retention := range []string{"30d", "1.5h", "90m", "100ms", "10.00:00:00", "12:00:00"} {
+				Expect(cluster.EnsureDatabase(context.Background(), "tenant 3.eu-west", retention, retention)).To(Succeed(), retention)
+			}
+		})
+		It("should create only the missing listed dbs when acquiring targets", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler("tenant_1")}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			filter := schemav1alpha1.TargetFilter{
+				DBS:                 []string{"tenant_1", "tenant_2"},
+				AutoCreateDatabases: true,
+			}
+			targets, err := cluster.AquireTargets(filter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"tenant_1", "tenant_2"}))
+			Expect(client.stmts).To(ContainElement(".create database ['tenant_2'] ifnotexists"))
+			Expect(countPrefix(client.stmts, ".create database")).To(Equal(1))
+		})
+		It("should not touch the cluster without auto creation", func() {
+			client := &scriptedKusto{mgmt: existingDBsHandler()}
+			cluster := &kustoutils.KustoCluster{Client: client}
+			filter := schemav1alpha1.TargetFilter{
+				DBS:              []string{"tenant_1", "tenant_2"},
+				IncludeFollowers: true,
+			}
+			_, err := cluster.AquireTargets(filter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.stmts).To(BeEmpty())
+		})
+	})
 	if liveTest {
 		Context("when testing kusto with a live server", func() {
 			ClusterUri := "https://" + testCluster + ".westeurope.kusto.windows.net"
@@ -158,5 +336,41 @@ var _ = Describe("Utils", func() {
 			kustoCluster := kustoutils.ClusterNameFromURI(uri)
 			Expect(kustoCluster).To(Equal("testcluster"))
 		})
+		It("should get the kusto cluster name in every cloud", func() {
+			for uri, expected := range map[string]string{
+				"https://testcluster.westeurope.kusto.windows.net":       "testcluster",
+				"https://synapsepool.workspace.kusto.azuresynapse.net":   "synapsepool",
+				"https://mfacluster.eastus.kustomfa.azure.com":           "mfacluster",
+				"https://mfalegacy.eastus.kustomfa.windows.net":          "mfalegacy",
+				"https://chinacluster.chinaeast2.kusto.chinacloudapi.cn": "chinacluster",
+				"govcluster.usgovvirginia.kusto.usgovcloudapi.net":       "govcluster",
+			} {
+				name, err := kustoutils.ClusterNameFromURIWithConfig(uri, kustoutils.DefaultClusterDomainSuffixes)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(Equal(expected))
+			}
+		})
+		It("should fail on unrecognized cluster uris", func() {
+			for _, uri := range []string{
+				"https://testcluster.example.com",
+				"https://testcluster.kusto.windows.net.example.com",
+				"https://kusto.windows.net",
+				"https://%zz",
+				"https://",
+			} {
+				_, err := kustoutils.ClusterNameFromURIWithConfig(uri, kustoutils.DefaultClusterDomainSuffixes)
+				Expect(err).To(HaveOccurred(), uri)
+			}
+		})
+		It("should only fall back to the first host label in the deprecated helper", func() {
+			Expect(kustoutils.ClusterNameFromURI("https://testcluster.example.com")).To(Equal("testcluster"))
+			Expect(kustoutils.ClusterNameFromURI("https://")).To(BeEmpty())
+		})
+		It("should know the domains of every cloud environment", func() {
+			for _, suffix := range kustoutils.DefaultClusterDomainSuffixes {
+				_, err := kustoutils.CloudEnvironmentForURI("https://testcluster.region." + suffix)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
 	})
 })
@@ -0,0 +1,102 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/rs/zerolog/log"
+)
+
+// PostApplyVerifier runs after the schema was applied to `db` and returns an error if the schema is not live.
+type PostApplyVerifier func(ctx context.Context, cluster *KustoCluster, db string) error
+
+// ErrVerificationFailed is returned when a `PostApplyVerifier` failed on some of the executed databases.
+type ErrVerificationFailed struct {
+	DBs []string
+	// RolledBack is set when the failed databases were restored to the `RollbackFile` schema.
+	RolledBack bool
+}
+
+func (e ErrVerificationFailed) Error() string {
+	msg := fmt.Sprintf("post apply verification failed on %s", strings.Join(e.DBs, ", "))
+	if e.RolledBack {
+		msg += " - rolled back"
+	}
+	return msg
+}
+
+// TableExistsVerifier returns a `PostApplyVerifier` checking that `tableName` exists in the database.
+func TableExistsVerifier(tableName string) PostApplyVerifier {
+	return func(ctx context.Context, cluster *KustoCluster, db string) error {
+		tables, err := cluster.listEntities(ctx, db, ".show tables", "TableName")
+		if err != nil {
+			return err
+		}
+		if i := sort.SearchStrings(tables, tableName); i < len(tables) && tables[i] == tableName {
+			return nil
+		}
+		return fmt.Errorf("table %s not found in %s", tableName, db)
+	}
+}
+
+// QueryRowCountVerifier returns a `PostApplyVerifier` checking that the `query` returns at least `minRows` rows.
+func QueryRowCountVerifier(query string, minRows int) PostApplyVerifier {
+	return func(ctx context.Context, cluster *KustoCluster, db string) error {
+		rows, err := countRows(ctx, cluster, db, query)
+		if err != nil {
+			return err
+		}
+		if rows < minRows {
+			return fmt.Errorf("query returned %d rows on %s, expected at least %d", rows, db, minRows)
+		}
+		return nil
+	}
+}
+
+// verifyExecution runs the `PostApplyVerifiers` on the executed databases of `done`.
+// A database failing a verifier is moved from `DBs` to a `Failed` result, and rolled back if `RollbackOnFailure` is set.
+func (c *KustoCluster) verifyExecution(ctx context.Context, done *schemav1alpha1.ClusterTargets, config schemav1alpha1.ExecutionConfiguration) error {
+	if len(c.PostApplyVerifiers) == 0 {
+		return nil
+	}
+	passed := make([]string, 0, len(done.DBs))
+	failed := []string{}
+	for _, db := range done.DBs {
+		ok := true
+		for _, verify := range c.PostApplyVerifiers {
+			if err := verify(ctx, c, db); err != nil {
+				log.Error().Err(err).Msgf("post apply verification failed on %s", db)
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			failed = append(failed, db)
+			done.DBResults[db] = schemav1alpha1.DBResultFailed
+			continue
+		}
+		passed = append(passed, db)
+	}
+	done.DBs = passed
+	if len(failed) == 0 {
+		return nil
+	}
+	verifyErr := ErrVerificationFailed{DBs: failed}
+	if !config.RollbackOnFailure {
+		return verifyErr
+	}
+	if config.RollbackFile == "" {
+		log.Warn().Msgf("no rollback file - the failed databases of %s are not rolled back", c.URI)
+		return verifyErr
+	}
+	if err := c.rollback(schemav1alpha1.ClusterTargets{DBs: failed}, config); err != nil {
+		return fmt.Errorf("%s and rollback failed: %w", verifyErr.Error(), err)
+	}
+	verifyErr.RolledBack = true
+	return verifyErr
+}
@@ -0,0 +1,96 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+// tablesHandler answers `.show tables` with the tables of every database.
+func tablesHandler(tables map[string][]string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		rows := [][]string{}
+		for _, t := range tables[db] {
+			rows = append(rows, []string{t, db})
+		}
+		return mockRows(table.Columns{{Name: "TableName", Type: types.String}, {Name: "DatabaseName", Type: types.String}}, rows...)
+	}
+}
+
+var _ = Describe("Post apply verifiers", func() {
+	ctx := context.Background()
+
+	It("should verify the table exists", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{mgmt: tablesHandler(map[string][]string{"db1": {"Audit", "Events"}})}}
+		Expect(kustoutils.TableExistsVerifier("Events")(ctx, cluster, "db1")).To(Succeed())
+		Expect(kustoutils.TableExistsVerifier("Metrics")(ctx, cluster, "db1")).NotTo(Succeed())
+		Expect(kustoutils.TableExistsVerifier("Events")(ctx, cluster, "db2")).NotTo(Succeed())
+	})
+	It("should verify the query row count", func() {
+		cluster := &kustoutils.KustoCluster{Client: &scriptedKusto{query: rowCountHandler(map[string]int{"Events": 3})}}
+		Expect(kustoutils.QueryRowCountVerifier("Events | take 10", 3)(ctx, cluster, "db1")).To(Succeed())
+		Expect(kustoutils.QueryRowCountVerifier("Events | take 10", 4)(ctx, cluster, "db1")).NotTo(Succeed())
+		Expect(kustoutils.QueryRowCountVerifier("Metrics | take 10", 0)(ctx, cluster, "db1")).NotTo(Succeed())
+	})
+
+	Context("when executing", func() {
+		targets := schemav1alpha1.ClusterTargets{DBs: []string{"db1", "db2"}}
+		cfgMap := &v1.ConfigMap{Data: map[string]string{"kql": ".create-merge table Events (Timestamp:datetime)"}}
+		var restore func()
+		var jobs []string
+		var cluster *kustoutils.KustoCluster
+
+		BeforeEach(func() {
+			jobs = nil
+			restore = kustoutils.SetDeltaRunner(func(jobFile string) error {
+				job, err := ioutil.ReadFile(jobFile)
+				jobs = append(jobs, string(job))
+				return err
+			})
+			cluster = &kustoutils.KustoCluster{
+				URI:                "https://mock.eastus.kusto.windows.net",
+				Client:             &scriptedKusto{mgmt: tablesHandler(map[string][]string{"db1": {"Events"}})},
+				PostApplyVerifiers: []kustoutils.PostApplyVerifier{kustoutils.TableExistsVerifier("Events")},
+			}
+		})
+		AfterEach(func() {
+			restore()
+		})
+
+		It("should mark the databases failing verification", func() {
+			exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+			Expect(err).NotTo(HaveOccurred())
+			done, err := cluster.Execute(targets, exeCfg)
+			Expect(err).To(Equal(kustoutils.ErrVerificationFailed{DBs: []string{"db2"}}))
+			Expect(done.DBs).To(Equal([]string{"db1"}))
+			Expect(done.DBResults).To(Equal(map[string]schemav1alpha1.DBResultEnum{
+				"db1": schemav1alpha1.DBResultExecuted,
+				"db2": schemav1alpha1.DBResultFailed,
+			}))
+			Expect(jobs).To(HaveLen(1))
+		})
+		It("should roll back the databases failing verification", func() {
+			exeCfg, err := cluster.CreateExecConfiguration(targets, cfgMap, true)
+			Expect(err).NotTo(HaveOccurred())
+			exeCfg.RollbackOnFailure = true
+			exeCfg.RollbackFile, err = kustoutils.StoreKQLSchemaToFile(".create-merge table Audit (Timestamp:datetime)")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = cluster.Execute(targets, exeCfg)
+			Expect(err).To(Equal(kustoutils.ErrVerificationFailed{DBs: []string{"db2"}, RolledBack: true}))
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs[1]).To(ContainSubstring("database: db2"))
+			Expect(jobs[1]).NotTo(ContainSubstring("database: db1"))
+		})
+	})
+})
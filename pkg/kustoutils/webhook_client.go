@@ -4,19 +4,90 @@ package kustoutils
 // Licensed under the MIT License.
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
+	"github.com/microsoft/azure-schema-operator/pkg/config"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// IdempotencyKeyHeader holds the key the webhook servers can deduplicate retried requests with.
+const IdempotencyKeyHeader = "X-Schema-Operator-Idempotency-Key"
+
 // WebHookClient holds the http client for the webhook
 type WebHookClient struct {
 	HttpClient *http.Client
+	// Checksum is the checksum of the schema the queries are made for, part of the idempotency key.
+	Checksum string
+	now      func() time.Time
+	// headers are the resolved custom headers added to every request.
+	headers       http.Header
+	customHeaders map[string]string
+	secrets       secretResolver
+}
+
+// WebHookOption configures a `WebHookClient`.
+type WebHookOption func(*WebHookClient)
+
+// secretResolver returns the value of the `key` of the secret `name`.
+type secretResolver func(name, key string) (string, error)
+
+// secretReference matches the header values read from a secret - `$(SECRET_NAME:KEY)`.
+var secretReference = regexp.MustCompile(`^\$\(([^:()]+):([^:()]+)\)$`)
+
+// WithCustomHeaders adds the `headers` to every webhook request, e.g. the `Ocp-Apim-Subscription-Key` of an API gateway.
+// A `$(SECRET_NAME:KEY)` value is read from the `KEY` of the secret `SECRET_NAME`, which requires `WithSecretReader`.
+func WithCustomHeaders(headers map[string]string) WebHookOption {
+	return func(c *WebHookClient) {
+		c.customHeaders = headers
+	}
+}
+
+// WithSecretReader reads the secrets of the custom header values from `namespace` with `reader`.
+func WithSecretReader(ctx context.Context, reader client.Reader, namespace string) WebHookOption {
+	return func(c *WebHookClient) {
+		c.secrets = func(name, key string) (string, error) {
+			secret := &corev1.Secret{}
+			if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+				return "", err
+			}
+			value, ok := secret.Data[key]
+			if !ok {
+				return "", fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+			}
+			return string(value), nil
+		}
+	}
+}
+
+// ResolveHeaderSecrets returns the `headers` with their `$(SECRET_NAME:KEY)` values read from the secrets of `namespace`
+// with `reader`, so a cluster shared by several namespaces can be given the resolved headers of each target filter.
+func ResolveHeaderSecrets(ctx context.Context, reader client.Reader, namespace string, headers map[string]string) (map[string]string, error) {
+	c := &WebHookClient{customHeaders: headers}
+	WithSecretReader(ctx, reader, namespace)(c)
+	resolved, err := c.resolveHeaders()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(resolved))
+	for name := range resolved {
+		values[name] = resolved.Get(name)
+	}
+	return values, nil
 }
 
 // Query holds the query parameters for the webhook
@@ -30,17 +101,59 @@ type Response struct {
 	DBS []string `json:"dbs"`
 }
 
-// NewWebHookClient creates a new `WebHookClient`
-func NewWebHookClient(httpClient *http.Client) *WebHookClient {
+// NewWebHookClient creates a new `WebHookClient`, failing when a custom header references a secret that can't be read.
+func NewWebHookClient(httpClient *http.Client, opts ...WebHookOption) (*WebHookClient, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
-	return &WebHookClient{
+	c := &WebHookClient{
 		HttpClient: httpClient,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	headers, err := c.resolveHeaders()
+	if err != nil {
+		return nil, err
+	}
+	c.headers = headers
+	return c, nil
+}
+
+// resolveHeaders returns the custom headers with their secret references replaced by the secret values.
+func (c *WebHookClient) resolveHeaders() (http.Header, error) {
+	headers := http.Header{}
+	for name, value := range c.customHeaders {
+		if strings.HasPrefix(value, "$(") {
+			ref := secretReference.FindStringSubmatch(value)
+			if ref == nil {
+				return nil, fmt.Errorf("header %s has an invalid secret reference %q - expected $(SECRET_NAME:KEY)", name, value)
+			}
+			if c.secrets == nil {
+				return nil, fmt.Errorf("header %s references secret %s but the webhook client has no secret reader", name, ref[1])
+			}
+			secretValue, err := c.secrets(ref[1], ref[2])
+			if err != nil {
+				return nil, fmt.Errorf("failed reading header %s from secret %s: %w", name, ref[1], err)
+			}
+			value = secretValue
+		}
+		headers.Set(name, value)
 	}
+	return headers, nil
+}
+
+// IdempotencyKeyFor returns the idempotency key of a request for the database `db` of the `cluster` with the schema `checksum`.
+// The key is the hex sha256 of the values and of `t` truncated to the minute, so requests retried within the minute share it.
+func IdempotencyKeyFor(cluster, db, checksum string, t time.Time) string {
+	bucket := strconv.FormatInt(t.UTC().Truncate(time.Minute).Unix(), 10)
+	sum := sha256.Sum256([]byte(strings.Join([]string{cluster, db, checksum, bucket}, "\n")))
+	return hex.EncodeToString(sum[:])
 }
 
-// PerformQuery calls the webhook with the provided parameters
+// PerformQuery calls the webhook with the provided parameters, the `label` takes the place of the database in the
+// idempotency key of the request. The request fails once `schemaop_webhook_timeout` passes, when it is set.
 func (c *WebHookClient) PerformQuery(url, server, label string) ([]string, error) {
 	a := Query{Cluster: server, Label: label}
 	buf := &bytes.Buffer{}
@@ -51,11 +164,25 @@ func (c *WebHookClient) PerformQuery(url, server, label string) ([]string, error
 		log.Error().Err(err).Msg("Failed to execute the url query template - please review the template")
 		return nil, err
 	}
-	r, err := http.NewRequest(http.MethodGet, buf.String(), nil)
+	ctx := context.Background()
+	if timeout := config.GetDuration(config.WebhookTimeoutKey); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, buf.String(), nil)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to generate http request")
 		return nil, err
 	}
+	now := c.now
+	if now == nil {
+		now = time.Now
+	}
+	for name, values := range c.headers {
+		r.Header[name] = values
+	}
+	r.Header.Set(IdempotencyKeyHeader, IdempotencyKeyFor(server, label, c.Checksum, now()))
 	resp, err := c.HttpClient.Do(r)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get db list from web-hool")
@@ -3,14 +3,21 @@ package kustoutils_test
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/config"
 	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 type Filtered struct {
@@ -57,7 +64,9 @@ var _ = Describe("Server-side Request handling", func() {
 
 	JustBeforeEach(func() {
 		srv = httptest.NewServer(handler)
-		c = kustoutils.NewWebHookClient(srv.Client())
+		var err error
+		c, err = kustoutils.NewWebHookClient(srv.Client())
+		Expect(err).ToNot(HaveOccurred())
 	})
 
 	AfterEach(func() {
@@ -72,6 +81,118 @@ var _ = Describe("Server-side Request handling", func() {
 		Expect(len(dbs)).To(Equal(2))
 	})
 
+	Context("With idempotency keys", func() {
+		var keys []string
+		BeforeEach(func() {
+			keys = nil
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				keys = append(keys, r.Header.Get(kustoutils.IdempotencyKeyHeader))
+				_, _ = w.Write([]byte(`{"dbs":["db1"]}`))
+			})
+		})
+
+		It("sends the key of the query", func() {
+			now := time.Date(2022, 5, 1, 10, 30, 15, 0, time.UTC)
+			c.Checksum = "abc"
+			kustoutils.SetWebHookClock(c, func() time.Time { return now })
+			_, err := c.PerformQuery(srv.URL+"/dbs?cluster={{.Cluster}}", "test-cluster", "delux")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(Equal([]string{kustoutils.IdempotencyKeyFor("test-cluster", "delux", "abc", now)}))
+		})
+		It("keeps the key stable within the minute", func() {
+			start := time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)
+			key := kustoutils.IdempotencyKeyFor("test-cluster", "db1", "abc", start)
+			Expect(key).To(HaveLen(64))
+			Expect(kustoutils.IdempotencyKeyFor("test-cluster", "db1", "abc", start.Add(59*time.Second))).To(Equal(key))
+			Expect(kustoutils.IdempotencyKeyFor("test-cluster", "db1", "abc", start.Add(time.Minute))).NotTo(Equal(key))
+			Expect(kustoutils.IdempotencyKeyFor("test-cluster", "db1", "abd", start)).NotTo(Equal(key))
+			Expect(kustoutils.IdempotencyKeyFor("test-cluster", "db2", "abc", start)).NotTo(Equal(key))
+		})
+	})
+
+	Context("With a slow webhook", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+				_, _ = w.Write([]byte(`{"dbs":["db1"]}`))
+			})
+		})
+		AfterEach(func() {
+			config.SetOverrides(nil)
+		})
+
+		It("fails once the configured timeout passes", func() {
+			config.SetOverrides(map[string]interface{}{config.WebhookTimeoutKey: 50 * time.Millisecond})
+			_, err := c.PerformQuery(srv.URL+"/dbs?cluster={{.Cluster}}", "test-cluster", "delux")
+			Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+		})
+	})
+
+	Context("With custom headers", func() {
+		var received http.Header
+		secrets := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "apim", Namespace: "default"},
+			Data:       map[string][]byte{"key": []byte("s3cr3t")},
+		}).Build()
+		BeforeEach(func() {
+			received = nil
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+				_, _ = w.Write([]byte(`{"dbs":["db1"]}`))
+			})
+		})
+
+		It("adds the headers to the request", func() {
+			hc, err := kustoutils.NewWebHookClient(srv.Client(), kustoutils.WithCustomHeaders(map[string]string{"X-Team": "schemas"}))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = hc.PerformQuery(srv.URL+"/dbs?cluster={{.Cluster}}", "test-cluster", "delux")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(received.Get("X-Team")).To(Equal("schemas"))
+			Expect(received.Get(kustoutils.IdempotencyKeyHeader)).NotTo(BeEmpty())
+		})
+		It("reads the secret header values", func() {
+			hc, err := kustoutils.NewWebHookClient(srv.Client(),
+				kustoutils.WithCustomHeaders(map[string]string{"Ocp-Apim-Subscription-Key": "$(apim:key)"}),
+				kustoutils.WithSecretReader(context.Background(), secrets, "default"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = hc.PerformQuery(srv.URL+"/dbs?cluster={{.Cluster}}", "test-cluster", "delux")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(received.Get("Ocp-Apim-Subscription-Key")).To(Equal("s3cr3t"))
+		})
+		It("sends the headers of the target filter", func() {
+			headers, err := kustoutils.ResolveHeaderSecrets(context.Background(), secrets, "default",
+				map[string]string{"Ocp-Apim-Subscription-Key": "$(apim:key)", "X-Team": "schemas"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(headers).To(Equal(map[string]string{"Ocp-Apim-Subscription-Key": "s3cr3t", "X-Team": "schemas"}))
+			cluster := &kustoutils.KustoCluster{URI: "https://cluster1.westeurope.kusto.windows.net"}
+			targets, err := cluster.AquireTargets(schemav1alpha1.TargetFilter{
+				Webhook:          srv.URL + "/dbs?cluster={{.Cluster}}",
+				WebhookHeaders:   headers,
+				IncludeFollowers: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targets.DBs).To(Equal([]string{"db1"}))
+			Expect(received.Get("Ocp-Apim-Subscription-Key")).To(Equal("s3cr3t"))
+			Expect(received.Get("X-Team")).To(Equal("schemas"))
+
+			_, err = kustoutils.ResolveHeaderSecrets(context.Background(), secrets, "other", map[string]string{"X-Key": "$(apim:key)"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("fails on invalid secret references", func() {
+			for _, value := range []string{"$(apim)", "$(apim:missing)", "$(other:key)"} {
+				_, err := kustoutils.NewWebHookClient(srv.Client(),
+					kustoutils.WithCustomHeaders(map[string]string{"Ocp-Apim-Subscription-Key": value}),
+					kustoutils.WithSecretReader(context.Background(), secrets, "default"))
+				Expect(err).To(HaveOccurred(), value)
+			}
+			_, err := kustoutils.NewWebHookClient(srv.Client(), kustoutils.WithCustomHeaders(map[string]string{"Ocp-Apim-Subscription-Key": "$(apim:key)"}))
+			Expect(err).To(MatchError(ContainSubstring("no secret reader")))
+		})
+	})
+
 	// Context("Use a different handler", func() {
 	// 	BeforeEach(func() {
 	// 		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
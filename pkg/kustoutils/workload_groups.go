@@ -0,0 +1,116 @@
+package kustoutils
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// WorkloadGroupsKey is the `ConfigMap` key holding the workload groups kql.
+	WorkloadGroupsKey = "workloadGroups"
+)
+
+var kqlWorkloadGroupRe = regexp.MustCompile(`(?im)^\s*\.(?:create-or-alter|create|alter|alter-merge)\s+workload_group\s+(\[\s*'[^']+'\s*\]|\[\s*"[^"]+"\s*\]|[\w.-]+)`)
+
+// builtinWorkloadGroups can not be dropped.
+var builtinWorkloadGroups = map[string]struct{}{
+	"default":  {},
+	"internal": {},
+}
+
+// WorkloadGroup is a workload group definition and the command creating it.
+type WorkloadGroup struct {
+	Name    string
+	Command string
+}
+
+// ParseWorkloadGroups returns the workload groups defined in the `kql` script.
+func ParseWorkloadGroups(kql string) []WorkloadGroup {
+	var groups []WorkloadGroup
+	matches := kqlWorkloadGroupRe.FindAllStringSubmatchIndex(kql, -1)
+	for i, match := range matches {
+		end := len(kql)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		groups = append(groups, WorkloadGroup{
+			Name:    unquoteEntityName(kql[match[2]:match[3]]),
+			Command: strings.TrimSpace(kql[match[0]:end]),
+		})
+	}
+	return groups
+}
+
+// ListWorkloadGroups returns the names of the cluster workload groups.
+func (c *KustoCluster) ListWorkloadGroups(ctx context.Context) ([]string, error) {
+	iter, err := c.Client.Mgmt(ctx, "", newUnsafeStmt(".show workload_groups"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query workload groups")
+		return nil, err
+	}
+	defer iter.Stop()
+
+	groups := []string{}
+	err = iter.Do(
+		func(row *table.Row) error {
+			for i, col := range row.ColumnTypes {
+				if col.Name == "WorkloadGroupName" {
+					groups = append(groups, row.Values[i].String())
+				}
+			}
+			return nil
+		},
+	)
+	sort.Strings(groups)
+	return groups, err
+}
+
+// SyncWorkloadGroups creates or alters the workload groups defined in the `kql` script.
+// Groups missing from the script are returned and only dropped when `allowDrop` is set.
+func (c *KustoCluster) SyncWorkloadGroups(ctx context.Context, kql string, allowDrop bool) ([]string, error) {
+	desired := ParseWorkloadGroups(kql)
+	existing, err := c.ListWorkloadGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]struct{}, len(desired))
+	for _, group := range desired {
+		wanted[group.Name] = struct{}{}
+		if err := c.runMgmt(ctx, "", group.Command); err != nil {
+			return nil, err
+		}
+	}
+
+	var surplus []string
+	for _, name := range existing {
+		if _, found := wanted[name]; found {
+			continue
+		}
+		if _, builtin := builtinWorkloadGroups[strings.ToLower(name)]; builtin {
+			continue
+		}
+		surplus = append(surplus, name)
+	}
+	if len(surplus) == 0 {
+		return surplus, nil
+	}
+	if !allowDrop {
+		log.Warn().Strs("groups", surplus).Msgf("workload groups missing from the schema on %s are kept", c.URI)
+		return surplus, nil
+	}
+	for _, name := range surplus {
+		if err := c.runMgmt(ctx, "", fmt.Sprintf(".drop workload_group ['%s']", name)); err != nil {
+			return surplus, err
+		}
+	}
+	return surplus, nil
+}
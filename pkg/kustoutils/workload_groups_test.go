@@ -0,0 +1,96 @@
+package kustoutils_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/types"
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/kustoutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+const groupsKQL = `
+.create-or-alter workload_group Reports ` + "```" + `
+{ "RequestLimitsPolicy": { "MaxExecutionTime": { "IsRelaxable": false, "Value": "00:05:00" } } }
+` + "```" + `
+
+.create-or-alter workload_group ['Ingestion Jobs'] ` + "```" + `
+{ "RequestRateLimitPolicies": [] }
+` + "```"
+
+// workloadGroupsHandler answers `.show workload_groups` with the given group names.
+func workloadGroupsHandler(groups ...string) func(db, stmt string) (*kusto.RowIterator, error) {
+	return func(db, stmt string) (*kusto.RowIterator, error) {
+		if !strings.HasPrefix(stmt, ".show workload_groups") {
+			return mockRows(table.Columns{{Name: "Result", Type: types.String}})
+		}
+		rows := [][]string{}
+		for _, group := range groups {
+			rows = append(rows, []string{group})
+		}
+		return mockRows(table.Columns{{Name: "WorkloadGroupName", Type: types.String}}, rows...)
+	}
+}
+
+var _ = Describe("Workload groups", func() {
+	It("should parse workload group commands", func() {
+		groups := kustoutils.ParseWorkloadGroups(groupsKQL)
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0].Name).To(Equal("Reports"))
+		Expect(groups[0].Command).To(HavePrefix(".create-or-alter workload_group Reports"))
+		Expect(groups[0].Command).To(HaveSuffix("```"))
+		Expect(groups[1].Name).To(Equal("Ingestion Jobs"))
+	})
+	It("should add missing workload groups", func() {
+		client := &scriptedKusto{mgmt: workloadGroupsHandler("default")}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		surplus, err := cluster.SyncWorkloadGroups(context.Background(), groupsKQL, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(surplus).To(BeEmpty())
+		Expect(countPrefix(client.stmts, ".create-or-alter workload_group")).To(Equal(2))
+	})
+	It("should modify existing workload groups", func() {
+		client := &scriptedKusto{mgmt: workloadGroupsHandler("default", "Reports", "Ingestion Jobs")}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		_, err := cluster.SyncWorkloadGroups(context.Background(), groupsKQL, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(countPrefix(client.stmts, ".create-or-alter workload_group Reports")).To(Equal(1))
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+	})
+	It("should keep groups missing from the schema unless dropping is allowed", func() {
+		client := &scriptedKusto{mgmt: workloadGroupsHandler("default", "internal", "Reports", "Legacy")}
+		cluster := &kustoutils.KustoCluster{Client: client}
+		surplus, err := cluster.SyncWorkloadGroups(context.Background(), groupsKQL, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(surplus).To(Equal([]string{"Legacy"}))
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(0))
+
+		client.stmts = nil
+		surplus, err = cluster.SyncWorkloadGroups(context.Background(), groupsKQL, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(surplus).To(Equal([]string{"Legacy"}))
+		Expect(client.stmts).To(ContainElement(".drop workload_group ['Legacy']"))
+		Expect(countPrefix(client.stmts, ".drop")).To(Equal(1))
+	})
+	It("should read the workload groups from the configmap", func() {
+		cluster := &kustoutils.KustoCluster{Client: &mockKusto{}}
+		cfgMap := &v1.ConfigMap{
+			Data: map[string]string{
+				"kql":                    ".create-merge table Events (Timestamp:datetime)",
+				"workloadGroups":         groupsKQL,
+				"allowWorkloadGroupDrop": "true",
+			},
+		}
+		exeCfg, err := cluster.CreateExecConfiguration(schemav1alpha1.ClusterTargets{DBs: []string{"db1"}}, cfgMap, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exeCfg.WorkloadGroupsFile).To(BeARegularFile())
+		Expect(exeCfg.AllowWorkloadGroupDrop).To(BeTrue())
+	})
+})
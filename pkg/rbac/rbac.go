@@ -0,0 +1,99 @@
+package rbac
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+const (
+	// ServiceAccountName is the name of the operator service account.
+	ServiceAccountName = "schema-operator"
+	// ClusterRoleName is the name of the operator cluster role and of its binding.
+	ClusterRoleName = "schema-operator-manager-role"
+)
+
+// crdVerbs are the verbs the operator needs on the custom resources it reconciles.
+var crdVerbs = []string{"get", "list", "watch", "update", "patch"}
+
+// GenerateRBACManifests returns the `ServiceAccount` of the operator in `namespace`, and the minimal `ClusterRole` and
+// `ClusterRoleBinding` it needs for the custom resources of `crds`. A CRD is named by its `<plural>.<group>` name,
+// a plural without a group belongs to the operator group. `extraVerbs` adds verbs to the CRDs by the same names.
+// The role also allows reading config maps and secrets, and recording events.
+func GenerateRBACManifests(crds []string, namespace string, extraVerbs map[string][]string) []unstructured.Unstructured {
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: metav1.ObjectMeta{Name: ServiceAccountName, Namespace: namespace},
+	}
+	role := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterRoleName},
+		Rules:      rules(crds, extraVerbs),
+	}
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterRoleName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: ClusterRoleName},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: ServiceAccountName, Namespace: namespace}},
+	}
+
+	manifests := make([]unstructured.Unstructured, 0, 3)
+	for _, obj := range []runtime.Object{serviceAccount, role, binding} {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			// the typed objects always convert.
+			panic(err)
+		}
+		manifests = append(manifests, unstructured.Unstructured{Object: content})
+	}
+	return manifests
+}
+
+// rules returns the policy rules of the `crds`, followed by the rules of the core resources.
+func rules(crds []string, extraVerbs map[string][]string) []rbacv1.PolicyRule {
+	policy := make([]rbacv1.PolicyRule, 0, len(crds)+3)
+	for _, crd := range crds {
+		group, resource := splitCRD(crd)
+		policy = append(policy,
+			rbacv1.PolicyRule{APIGroups: []string{group}, Resources: []string{resource}, Verbs: withVerbs(crdVerbs, extraVerbs[crd])},
+			rbacv1.PolicyRule{APIGroups: []string{group}, Resources: []string{resource + "/status"}, Verbs: []string{"get", "update", "patch"}},
+		)
+	}
+	return append(policy,
+		rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps", "secrets"}, Verbs: []string{"get", "list"}},
+		rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "update", "patch"}},
+	)
+}
+
+// splitCRD returns the group and plural resource of the `<plural>.<group>` CRD name.
+func splitCRD(crd string) (group, resource string) {
+	crd = strings.ToLower(strings.TrimSpace(crd))
+	if i := strings.Index(crd, "."); i >= 0 {
+		return crd[i+1:], crd[:i]
+	}
+	return schemav1alpha1.GroupVersion.Group, crd
+}
+
+// withVerbs returns the `verbs` and the `extra` verbs, without duplicates.
+func withVerbs(verbs, extra []string) []string {
+	if len(extra) == 0 {
+		return append([]string{}, verbs...)
+	}
+	seen := make(map[string]bool, len(verbs)+len(extra))
+	merged := make([]string, 0, len(verbs)+len(extra))
+	for _, verb := range append(append([]string{}, verbs...), extra...) {
+		if !seen[verb] {
+			seen[verb] = true
+			merged = append(merged, verb)
+		}
+	}
+	return merged
+}
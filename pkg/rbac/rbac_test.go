@@ -0,0 +1,90 @@
+package rbac_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/microsoft/azure-schema-operator/pkg/rbac"
+)
+
+var _ = Describe("GenerateRBACManifests", func() {
+	crds := []string{"schemadeployments.dbschema.microsoft.com", "clusterexecuters"}
+
+	// manifestsYAML serializes the manifests to a multi document YAML stream, as written for `kubectl apply`.
+	manifestsYAML := func(extraVerbs map[string][]string) [][]byte {
+		stream := &bytes.Buffer{}
+		for _, manifest := range rbac.GenerateRBACManifests(crds, "schema-system", extraVerbs) {
+			data, err := yaml.Marshal(manifest.Object)
+			Expect(err).NotTo(HaveOccurred())
+			stream.WriteString("---\n")
+			stream.Write(data)
+		}
+		docs := bytes.Split(stream.Bytes(), []byte("---\n"))
+		return docs[1:]
+	}
+	decode := func(extraVerbs map[string][]string) (*corev1.ServiceAccount, *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding) {
+		docs := manifestsYAML(extraVerbs)
+		Expect(docs).To(HaveLen(3))
+		sa, role, binding := &corev1.ServiceAccount{}, &rbacv1.ClusterRole{}, &rbacv1.ClusterRoleBinding{}
+		Expect(yaml.UnmarshalStrict(docs[0], sa)).To(Succeed())
+		Expect(yaml.UnmarshalStrict(docs[1], role)).To(Succeed())
+		Expect(yaml.UnmarshalStrict(docs[2], binding)).To(Succeed())
+		return sa, role, binding
+	}
+	ruleFor := func(role *rbacv1.ClusterRole, group, resource string) rbacv1.PolicyRule {
+		for _, rule := range role.Rules {
+			for _, r := range rule.Resources {
+				if r == resource && len(rule.APIGroups) == 1 && rule.APIGroups[0] == group {
+					return rule
+				}
+			}
+		}
+		Fail("no rule for " + resource)
+		return rbacv1.PolicyRule{}
+	}
+
+	It("should generate valid RBAC objects for the operator service account", func() {
+		sa, role, binding := decode(nil)
+		Expect(sa.APIVersion).To(Equal("v1"))
+		Expect(sa.Kind).To(Equal("ServiceAccount"))
+		Expect(sa.Name).To(Equal(rbac.ServiceAccountName))
+		Expect(sa.Namespace).To(Equal("schema-system"))
+
+		Expect(role.APIVersion).To(Equal("rbac.authorization.k8s.io/v1"))
+		Expect(role.Kind).To(Equal("ClusterRole"))
+		Expect(role.Name).To(Equal(rbac.ClusterRoleName))
+		for _, rule := range role.Rules {
+			Expect(rule.APIGroups).NotTo(BeEmpty())
+			Expect(rule.Resources).NotTo(BeEmpty())
+			Expect(rule.Verbs).NotTo(BeEmpty())
+			Expect(rule.Verbs).NotTo(ContainElement("*"))
+		}
+
+		Expect(binding.Kind).To(Equal("ClusterRoleBinding"))
+		Expect(binding.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: role.Name}))
+		Expect(binding.Subjects).To(Equal([]rbacv1.Subject{{Kind: "ServiceAccount", Name: sa.Name, Namespace: sa.Namespace}}))
+	})
+	It("should grant the minimal verbs", func() {
+		_, role, _ := decode(nil)
+		for _, resource := range []string{"schemadeployments", "clusterexecuters"} {
+			Expect(ruleFor(role, "dbschema.microsoft.com", resource).Verbs).To(Equal([]string{"get", "list", "watch", "update", "patch"}))
+			Expect(ruleFor(role, "dbschema.microsoft.com", resource+"/status").Verbs).To(Equal([]string{"get", "update", "patch"}))
+		}
+		Expect(ruleFor(role, "", "configmaps").Verbs).To(Equal([]string{"get", "list"}))
+		Expect(ruleFor(role, "", "secrets").Verbs).To(Equal([]string{"get", "list"}))
+		Expect(ruleFor(role, "", "events").Verbs).To(Equal([]string{"create", "update", "patch"}))
+	})
+	It("should add the extra verbs of a custom resource", func() {
+		_, role, _ := decode(map[string][]string{"clusterexecuters": {"create", "delete", "get"}})
+		Expect(ruleFor(role, "dbschema.microsoft.com", "clusterexecuters").Verbs).To(Equal([]string{"get", "list", "watch", "update", "patch", "create", "delete"}))
+		Expect(ruleFor(role, "dbschema.microsoft.com", "schemadeployments").Verbs).To(Equal([]string{"get", "list", "watch", "update", "patch"}))
+	})
+})
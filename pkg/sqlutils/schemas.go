@@ -80,7 +80,7 @@ func (c *SQLCluster) Execute(targets schemav1alpha1.ClusterTargets, config schem
 			log.Error().Msg("the template name is required to run the dacpac per schema")
 			return executed, fmt.Errorf("the template name is required to run the dacpac per schema")
 		}
-		noOfWorkers := parallelWorkers
+		noOfWorkers := parallelWorkers()
 		if workers, ok := config.Properties["parallelWorkers"]; ok {
 			noOfWorkers, _ = strconv.Atoi(workers)
 		}
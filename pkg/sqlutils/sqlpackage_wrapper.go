@@ -20,11 +20,10 @@ import (
 )
 
 var (
-	useMSI          bool
-	sqlpackgeUser   string
-	sqlpackgePass   string
-	sqlpackgeCmd    string
-	parallelWorkers int
+	useMSI        bool
+	sqlpackgeUser string
+	sqlpackgePass string
+	sqlpackgeCmd  string
 )
 
 func init() {
@@ -36,7 +35,11 @@ func init() {
 	sqlpackgeUser = strings.TrimSpace(viper.GetString(config.SQLPackageUser))
 	sqlpackgePass = strings.TrimSpace(viper.GetString(config.SQLPackagePass))
 	sqlpackgeCmd = strings.TrimSpace(viper.GetString(config.SQLPackageCMDKey))
-	parallelWorkers = viper.GetInt(config.ParallelWorkers)
+}
+
+// parallelWorkers returns the configured number of schemas sqlpackage runs on at once.
+func parallelWorkers() int {
+	return config.GetInt(config.ParallelWorkers)
 }
 
 // updateDacPac creates a duplicate dacpac with the source schema replaced with a destenation schema.
@@ -0,0 +1,75 @@
+// Package fake provides an in-memory tracer implementing `tracing.Tracer`,
+// to test the traced code paths without a trace exporter.
+package fake
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/Azure/go-autorest/tracing"
+)
+
+var _ tracing.Tracer = &Tracer{}
+
+type spanKey struct{}
+
+// Span is a span recorded by the `Tracer`.
+type Span struct {
+	Name string
+	// Ended is set once the span ended, with its error in `Err`.
+	Ended bool
+	Err   error
+}
+
+// Tracer records the spans in the order they started.
+type Tracer struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewTransport returns `base`, the requests are not traced.
+func (t *Tracer) NewTransport(base *http.Transport) http.RoundTripper {
+	return base
+}
+
+// StartSpan records the span `name`, ended by the `EndSpan` of the returned context.
+func (t *Tracer) StartSpan(ctx context.Context, name string) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &Span{Name: name}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// EndSpan ends the span of `ctx`.
+func (t *Tracer) EndSpan(ctx context.Context, httpStatusCode int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.Ended = true
+		span.Err = err
+	}
+}
+
+// Spans returns a copy of the recorded spans.
+func (t *Tracer) Spans() []Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]Span, 0, len(t.spans))
+	for _, span := range t.spans {
+		spans = append(spans, *span)
+	}
+	return spans
+}
+
+// SpanNames returns the names of the recorded spans.
+func (t *Tracer) SpanNames() []string {
+	names := []string{}
+	for _, span := range t.Spans() {
+		names = append(names, span.Name)
+	}
+	return names
+}
@@ -0,0 +1,153 @@
+package telemetry
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Azure/go-autorest/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+// TelemetryOptions configures the subsystems of the operator telemetry.
+type TelemetryOptions struct {
+	// Registerer registers the metrics, the controller-runtime registry served on the metrics endpoint when nil.
+	Registerer prometheus.Registerer
+	// DisableMetrics runs without metrics.
+	DisableMetrics bool
+	// Tracer traces the reconciles and executions, nil disables tracing.
+	Tracer tracing.Tracer
+	// LogWriter receives the json logs, `os.Stderr` when nil.
+	LogWriter io.Writer
+	// LogLevel is the minimal level logged.
+	LogLevel zerolog.Level
+}
+
+// SchemaMetrics are the prometheus metrics of the schema executions.
+type SchemaMetrics struct {
+	Executions     *prometheus.CounterVec
+	DeltaJobs      *prometheus.CounterVec
+	DeltaDurations prometheus.Histogram
+}
+
+// NewSchemaMetrics returns the schema metrics registered with `registerer`.
+func NewSchemaMetrics(registerer prometheus.Registerer) (*SchemaMetrics, error) {
+	m := &SchemaMetrics{
+		Executions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "schema_operator_executions_total",
+				Help: "Number of schema executions by cluster and result",
+			},
+			[]string{"cluster", "result"},
+		),
+		DeltaJobs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "schema_operator_delta_jobs_total",
+				Help: "Number of delta-kusto jobs by result",
+			},
+			[]string{"result"},
+		),
+		DeltaDurations: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "schema_operator_delta_job_duration_seconds",
+			Help:    "delta-kusto job duration distributions.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+	}
+	for _, collector := range []prometheus.Collector{m.Executions, m.DeltaJobs, m.DeltaDurations} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// SchemaOperatorTelemetry wires the metrics, tracing and logging of the operator as a single dependency of the
+// reconcilers and kusto clusters. Every subsystem is optional - a nil telemetry, `Metrics` or `Tracer` is a no-op.
+type SchemaOperatorTelemetry struct {
+	Metrics *SchemaMetrics
+	Tracer  tracing.Tracer
+	Logger  zerolog.Logger
+}
+
+// NewTelemetry returns the telemetry configured by `opts`.
+func NewTelemetry(opts TelemetryOptions) (*SchemaOperatorTelemetry, error) {
+	writer := opts.LogWriter
+	if writer == nil {
+		writer = os.Stderr
+	}
+	t := &SchemaOperatorTelemetry{
+		Tracer: opts.Tracer,
+		Logger: zerolog.New(writer).Level(opts.LogLevel).With().Timestamp().Logger(),
+	}
+	if !opts.DisableMetrics {
+		registerer := opts.Registerer
+		if registerer == nil {
+			registerer = metrics.Registry
+		}
+		m, err := NewSchemaMetrics(registerer)
+		if err != nil {
+			return nil, err
+		}
+		t.Metrics = m
+	}
+	return t, nil
+}
+
+// Log returns the telemetry logger, the global logger without telemetry.
+func (t *SchemaOperatorTelemetry) Log() *zerolog.Logger {
+	if t == nil {
+		return &log.Logger
+	}
+	return &t.Logger
+}
+
+// StartSpan starts the span `name` in the returned context.
+func (t *SchemaOperatorTelemetry) StartSpan(ctx context.Context, name string) context.Context {
+	if t == nil || t.Tracer == nil {
+		return ctx
+	}
+	return t.Tracer.StartSpan(ctx, name)
+}
+
+// EndSpan ends the span of `ctx` started by `StartSpan`, failed with `err` when set.
+func (t *SchemaOperatorTelemetry) EndSpan(ctx context.Context, err error) {
+	if t == nil || t.Tracer == nil {
+		return
+	}
+	t.Tracer.EndSpan(ctx, 0, err)
+}
+
+// ObserveExecution counts a schema execution on `cluster`.
+func (t *SchemaOperatorTelemetry) ObserveExecution(cluster string, err error) {
+	if t == nil || t.Metrics == nil {
+		return
+	}
+	t.Metrics.Executions.WithLabelValues(cluster, result(err)).Inc()
+}
+
+// ObserveDeltaJob counts a delta-kusto job that ran for `duration`.
+func (t *SchemaOperatorTelemetry) ObserveDeltaJob(duration time.Duration, err error) {
+	if t == nil || t.Metrics == nil {
+		return
+	}
+	t.Metrics.DeltaJobs.WithLabelValues(result(err)).Inc()
+	t.Metrics.DeltaDurations.Observe(duration.Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+	return resultSuccess
+}
@@ -0,0 +1,80 @@
+package telemetry_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry"
+	"github.com/microsoft/azure-schema-operator/pkg/telemetry/fake"
+)
+
+var _ = Describe("Telemetry", func() {
+	ctx := context.Background()
+
+	It("should wire the metrics, tracer and json logger", func() {
+		registry := prometheus.NewRegistry()
+		tracer := &fake.Tracer{}
+		logs := &bytes.Buffer{}
+		t, err := telemetry.NewTelemetry(telemetry.TelemetryOptions{
+			Registerer: registry,
+			Tracer:     tracer,
+			LogWriter:  logs,
+			LogLevel:   zerolog.InfoLevel,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		t.ObserveExecution("cluster1", nil)
+		t.ObserveExecution("cluster1", fmt.Errorf("failed"))
+		t.ObserveDeltaJob(3*time.Second, nil)
+		Expect(testutil.ToFloat64(t.Metrics.Executions.WithLabelValues("cluster1", "success"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(t.Metrics.Executions.WithLabelValues("cluster1", "failure"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(t.Metrics.DeltaJobs.WithLabelValues("success"))).To(Equal(1.0))
+		families, err := registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(HaveLen(3))
+
+		spanCtx := t.StartSpan(ctx, "kusto/Execute")
+		t.EndSpan(spanCtx, fmt.Errorf("failed"))
+		Expect(tracer.Spans()).To(Equal([]fake.Span{{Name: "kusto/Execute", Ended: true, Err: fmt.Errorf("failed")}}))
+
+		t.Log().Debug().Msg("hidden")
+		t.Log().Info().Str("cluster", "cluster1").Msg("executed")
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal(logs.Bytes(), &entry)).To(Succeed())
+		Expect(entry).To(HaveKeyWithValue("message", "executed"))
+		Expect(entry).To(HaveKeyWithValue("cluster", "cluster1"))
+		Expect(entry).To(HaveKey("time"))
+	})
+	It("should fail registering the metrics twice", func() {
+		registry := prometheus.NewRegistry()
+		_, err := telemetry.NewTelemetry(telemetry.TelemetryOptions{Registerer: registry})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = telemetry.NewTelemetry(telemetry.TelemetryOptions{Registerer: registry})
+		Expect(err).To(HaveOccurred())
+	})
+	It("should run without the disabled subsystems", func() {
+		t, err := telemetry.NewTelemetry(telemetry.TelemetryOptions{DisableMetrics: true, LogWriter: &bytes.Buffer{}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Metrics).To(BeNil())
+		Expect(t.Tracer).To(BeNil())
+
+		for _, t := range []*telemetry.SchemaOperatorTelemetry{t, nil} {
+			Expect(t.StartSpan(ctx, "kusto/Execute")).To(Equal(ctx))
+			t.EndSpan(ctx, nil)
+			t.ObserveExecution("cluster1", nil)
+			t.ObserveDeltaJob(time.Second, nil)
+			Expect(t.Log()).NotTo(BeNil())
+		}
+	})
+})
@@ -0,0 +1,345 @@
+package filterexpr
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Database holds the properties of a database a filter expression is evaluated on.
+type Database struct {
+	Name      string
+	Tags      map[string]string
+	SizeGB    float64
+	CreatedAt time.Time
+}
+
+// Expression is a parsed filter expression, e.g. `db.name =~ "^prod-" && db.tag["env"] == "production" && db.sizeGB > 100`.
+// Comparisons (`=~`, `==`, `!=`, `>`, `>=`, `<`, `<=`) of the database properties `name`, `tag[key]`, `sizeGB` and `createdAt`
+// (optionally prefixed with `db.`) are combined with `&&`, `||`, `!` and parentheses.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses and type checks the filter expression `source`.
+// `=~` matches a regular expression, and `createdAt` is compared with RFC3339 times or `2006-01-02` dates.
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q at %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", source, err)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// Match reports whether the database `db` passes the expression.
+func (e *Expression) Match(db Database) bool {
+	return e.root.eval(db)
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// valueKind is the type of a property or a literal.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindTime
+)
+
+func (k valueKind) String() string {
+	return [...]string{"string", "number", "time"}[k]
+}
+
+// value is a typed property or literal value.
+type value struct {
+	kind   valueKind
+	str    string
+	number float64
+	time   time.Time
+}
+
+// compare returns -1, 0 or 1 as `v` is less than, equal to or greater than `other` of the same kind.
+func (v value) compare(other value) int {
+	switch v.kind {
+	case kindNumber:
+		switch {
+		case v.number < other.number:
+			return -1
+		case v.number > other.number:
+			return 1
+		}
+	case kindTime:
+		switch {
+		case v.time.Before(other.time):
+			return -1
+		case v.time.After(other.time):
+			return 1
+		}
+	default:
+		switch {
+		case v.str < other.str:
+			return -1
+		case v.str > other.str:
+			return 1
+		}
+	}
+	return 0
+}
+
+// node is an evaluable node of the expression tree.
+type node interface {
+	eval(db Database) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(db Database) bool { return n.left.eval(db) || n.right.eval(db) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(db Database) bool { return n.left.eval(db) && n.right.eval(db) }
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(db Database) bool { return !n.operand.eval(db) }
+
+// operand is a database property or a literal of a comparison.
+type operand struct {
+	property string
+	key      string
+	literal  *value
+}
+
+// resolve returns the value of the operand for the database `db`, missing tags are empty.
+func (o operand) resolve(db Database) value {
+	if o.literal != nil {
+		return *o.literal
+	}
+	switch o.property {
+	case "tag":
+		return value{kind: kindString, str: db.Tags[o.key]}
+	case "sizeGB":
+		return value{kind: kindNumber, number: db.SizeGB}
+	case "createdAt":
+		return value{kind: kindTime, time: db.CreatedAt}
+	}
+	return value{kind: kindString, str: db.Name}
+}
+
+// kind returns the type of the operand.
+func (o operand) kind() valueKind {
+	if o.literal != nil {
+		return o.literal.kind
+	}
+	return propertyKinds[o.property]
+}
+
+// propertyKinds holds the type of every database property.
+var propertyKinds = map[string]valueKind{
+	"name":      kindString,
+	"tag":       kindString,
+	"sizeGB":    kindNumber,
+	"createdAt": kindTime,
+}
+
+type compareNode struct {
+	left, right operand
+	op          string
+	re          *regexp.Regexp
+}
+
+func (n compareNode) eval(db Database) bool {
+	left := n.left.resolve(db)
+	if n.re != nil {
+		return n.re.MatchString(left.str)
+	}
+	cmp := left.compare(n.right.resolve(db))
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	}
+	return cmp <= 0
+}
+
+// parser is a recursive descent parser of the expression tokens.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator `op`.
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator `op`, failing when the next token is anything else.
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q at %d", op, p.peek().pos)
+	}
+	return nil
+}
+
+// parseOr parses `and ("||" and)*`.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right node
+		right, err = p.parseAnd()
+		left = orNode{left: left, right: right}
+	}
+	return left, err
+}
+
+// parseAnd parses `unary ("&&" unary)*`.
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var right node
+		right, err = p.parseUnary()
+		left = andNode{left: left, right: right}
+	}
+	return left, err
+}
+
+// parseUnary parses `"!" unary | "(" or ")" | comparison`.
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		return notNode{operand: operand}, err
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses `operand op operand`, checking both operands have the same type.
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	opToken := p.next()
+	op := opToken.text
+	switch {
+	case opToken.kind != tokenOperator:
+		return nil, fmt.Errorf("expected a comparison operator at %d", opToken.pos)
+	case op != "=~" && op != "==" && op != "!=" && op != ">" && op != ">=" && op != "<" && op != "<=":
+		return nil, fmt.Errorf("unexpected %q at %d", op, opToken.pos)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	// time literals are written as strings.
+	if right.literal != nil && left.kind() == kindTime && right.literal.kind == kindString {
+		if right.literal, err = parseTime(right.literal.str); err != nil {
+			return nil, err
+		}
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("can't compare a %s with a %s at %d", left.kind(), right.kind(), opToken.pos)
+	}
+	cmp := compareNode{left: left, right: right, op: op}
+	if op == "=~" {
+		if left.kind() != kindString || right.literal == nil {
+			return nil, fmt.Errorf("%q matches a string with a literal regular expression at %d", op, opToken.pos)
+		}
+		if cmp.re, err = regexp.Compile(right.literal.str); err != nil {
+			return nil, err
+		}
+	}
+	return cmp, nil
+}
+
+// parseOperand parses a string or number literal, or a database property `[db.]name | tag[key] | sizeGB | createdAt`.
+func (p *parser) parseOperand() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return operand{literal: &value{kind: kindString, str: t.text}}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return operand{literal: &value{kind: kindNumber, number: number}}, nil
+	case tokenIdent:
+	default:
+		return operand{}, fmt.Errorf("expected a property or a literal at %d", t.pos)
+	}
+	if t.text == "db" && p.accept(".") {
+		t = p.next()
+	}
+	if _, ok := propertyKinds[t.text]; !ok || t.kind != tokenIdent {
+		return operand{}, fmt.Errorf("unknown database property %q at %d", t.text, t.pos)
+	}
+	property := operand{property: t.text}
+	if property.property != "tag" {
+		return property, nil
+	}
+	if err := p.expect("["); err != nil {
+		return operand{}, err
+	}
+	key := p.next()
+	if key.kind != tokenString {
+		return operand{}, fmt.Errorf("expected a quoted tag key at %d", key.pos)
+	}
+	property.key = key.text
+	return property, p.expect("]")
+}
+
+// parseTime parses a RFC3339 time or a `2006-01-02` date literal.
+func parseTime(literal string) (*value, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, literal); err == nil {
+			return &value{kind: kindTime, time: t}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid time %q, expected RFC3339 or 2006-01-02", literal)
+}
@@ -0,0 +1,15 @@
+package filterexpr_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFilterexpr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Filterexpr Suite")
+}
@@ -0,0 +1,86 @@
+package filterexpr_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/utils/filterexpr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter expressions", func() {
+	prod := filterexpr.Database{
+		Name:      "prod-orders",
+		Tags:      map[string]string{"env": "production", "team": "orders"},
+		SizeGB:    250,
+		CreatedAt: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	staging := filterexpr.Database{
+		Name:      "staging-orders",
+		Tags:      map[string]string{"env": "staging"},
+		SizeGB:    20,
+		CreatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	valid := []struct {
+		name                    string
+		source                  string
+		matchProd, matchStaging bool
+	}{
+		{"regexp match", `db.name =~ "^prod-"`, true, false},
+		{"equality", `db.name == "staging-orders"`, false, true},
+		{"inequality", `db.tag["env"] != "production"`, false, true},
+		{"greater than", `db.sizeGB > 100`, true, false},
+		{"less than", `db.sizeGB < 100`, false, true},
+		{"greater or equal", `db.sizeGB >= 250`, true, false},
+		{"less or equal", `db.sizeGB <= 20.0`, false, true},
+		{"missing tags are empty", `db.tag["team"] == ""`, false, true},
+		{"dates", `db.createdAt < "2024-01-01"`, true, false},
+		{"RFC3339 times", `db.createdAt >= '2024-02-01T00:00:00Z'`, false, true},
+		{"and", `db.name =~ "orders$" && db.tag["env"] == "production"`, true, false},
+		{"or", `db.sizeGB > 200 || db.tag["env"] == "staging"`, true, true},
+		{"not", `!(db.name =~ "^prod-")`, false, true},
+		{"not binds tighter than and", `!db.name =~ "^prod-" && db.sizeGB < 100`, false, true},
+		{"and binds tighter than or", `db.sizeGB > 1000 && db.name =~ "prod" || db.tag["env"] == "staging"`, false, true},
+		{"parentheses", `db.sizeGB > 1000 && (db.name =~ "prod" || db.tag["env"] == "staging")`, false, false},
+		{"properties without the db prefix", `name =~ "^prod-" && tag["env"] == "production" && sizeGB > 100`, true, false},
+		{"escaped quotes", `db.name != "a\"b"`, true, true},
+	}
+	for _, tc := range valid {
+		tc := tc
+		It("should evaluate "+tc.name, func() {
+			expr, err := filterexpr.Parse(tc.source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expr.Match(prod)).To(Equal(tc.matchProd))
+			Expect(expr.Match(staging)).To(Equal(tc.matchStaging))
+		})
+	}
+
+	invalid := []struct {
+		name    string
+		source  string
+		message string
+	}{
+		{"unknown property", `db.owner == "me"`, "unknown database property"},
+		{"mismatched types", `db.sizeGB == "big"`, "can't compare a number with a string"},
+		{"regexp on a number", `db.sizeGB =~ "1"`, "can't compare"},
+		{"regexp with a property", `db.name =~ db.tag["env"]`, "literal regular expression"},
+		{"invalid regexp", `db.name =~ "("`, "missing closing )"},
+		{"invalid time", `db.createdAt > "yesterday"`, "invalid time"},
+		{"missing operator", `db.name`, "expected a comparison operator"},
+		{"unbalanced parentheses", `(db.sizeGB > 1`, `expected ")"`},
+		{"trailing tokens", `db.sizeGB > 1 db.sizeGB`, "unexpected"},
+		{"unquoted tag key", `db.tag[env] == "prod"`, "quoted tag key"},
+		{"unterminated string", `db.name == "prod`, "unterminated string"},
+		{"unknown characters", `db.name == "a" & db.sizeGB > 1`, "unexpected '&'"},
+	}
+	for _, tc := range invalid {
+		tc := tc
+		It("should reject "+tc.name, func() {
+			_, err := filterexpr.Parse(tc.source)
+			Expect(err).To(MatchError(ContainSubstring(tc.message)))
+		})
+	}
+})
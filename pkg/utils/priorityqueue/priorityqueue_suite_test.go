@@ -0,0 +1,15 @@
+package priorityqueue_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPriorityqueue(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Priorityqueue Suite")
+}
@@ -0,0 +1,214 @@
+package priorityqueue
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// PriorityFunc returns the priority of a queued item, higher priorities are dequeued first.
+type PriorityFunc func(item interface{}) int
+
+// Queue is a rate limiting work queue dequeuing its items by priority, and in insertion order within the same priority.
+// Like the client-go work queues an item is queued once, and an item added while it is processed is queued again once it is done.
+type Queue struct {
+	priority    PriorityFunc
+	rateLimiter workqueue.RateLimiter
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	items        itemHeap
+	queued       map[interface{}]*entry
+	processing   map[interface{}]bool
+	dirty        map[interface{}]bool
+	seq          uint64
+	shuttingDown bool
+}
+
+var _ workqueue.RateLimitingInterface = &Queue{}
+
+// New returns an empty queue ordering its items by `priority`, a nil `rateLimiter` uses the client-go default.
+func New(priority PriorityFunc, rateLimiter workqueue.RateLimiter) *Queue {
+	if rateLimiter == nil {
+		rateLimiter = workqueue.DefaultControllerRateLimiter()
+	}
+	q := &Queue{
+		priority:    priority,
+		rateLimiter: rateLimiter,
+		queued:      map[interface{}]*entry{},
+		processing:  map[interface{}]bool{},
+		dirty:       map[interface{}]bool{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Add queues `item`, an item already queued keeps its place unless its priority was raised.
+func (q *Queue) Add(item interface{}) {
+	// the priority may read other state, so it is computed outside the lock.
+	priority := q.priority(item)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if e, ok := q.queued[item]; ok {
+		if priority > e.priority {
+			e.priority = priority
+			heap.Fix(&q.items, e.index)
+		}
+		return
+	}
+	if q.processing[item] {
+		q.dirty[item] = true
+		return
+	}
+	q.push(item, priority)
+}
+
+// push queues `item`, the caller holds the lock.
+func (q *Queue) push(item interface{}, priority int) {
+	q.seq++
+	e := &entry{item: item, priority: priority, seq: q.seq}
+	heap.Push(&q.items, e)
+	q.queued[item] = e
+	q.cond.Signal()
+}
+
+// Len returns the number of queued items.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// Get blocks until an item is queued and returns the item with the highest priority,
+// `shutdown` is true once the queue is shut down.
+func (q *Queue) Get() (item interface{}, shutdown bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.items.Len() == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.items.Len() == 0 {
+		return nil, true
+	}
+	e := heap.Pop(&q.items).(*entry)
+	delete(q.queued, e.item)
+	q.processing[e.item] = true
+	return e.item, false
+}
+
+// Done marks `item` processed, queuing it again if it was added while it was processed.
+func (q *Queue) Done(item interface{}) {
+	dirty := false
+	q.mu.Lock()
+	delete(q.processing, item)
+	if q.dirty[item] {
+		delete(q.dirty, item)
+		dirty = !q.shuttingDown
+	}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	if dirty {
+		q.Add(item)
+	}
+}
+
+// ShutDown stops queuing new items and wakes up the waiting `Get` calls once the queued items are taken.
+func (q *Queue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts the queue down and waits until the processed items are done.
+func (q *Queue) ShutDownWithDrain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.processing) > 0 {
+		q.cond.Wait()
+	}
+}
+
+// ShuttingDown reports whether the queue is shut down.
+func (q *Queue) ShuttingDown() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shuttingDown
+}
+
+// AddAfter queues `item` once `duration` passed.
+func (q *Queue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+// AddRateLimited queues `item` once the rate limiter allows it.
+func (q *Queue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget resets the rate limiting of `item`.
+func (q *Queue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues returns the number of times `item` was rate limited.
+func (q *Queue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// entry is a queued item, `seq` keeps the insertion order of the items with the same priority.
+type entry struct {
+	item     interface{}
+	priority int
+	seq      uint64
+	index    int
+}
+
+// itemHeap is a `container/heap` of the queued entries, the highest priority first.
+type itemHeap []*entry
+
+func (h itemHeap) Len() int { return len(h) }
+
+// Less orders the entries by descending priority, then by insertion.
+func (h itemHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *itemHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,93 @@
+package priorityqueue_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"time"
+
+	"github.com/microsoft/azure-schema-operator/pkg/utils/priorityqueue"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priority queue", func() {
+	var priorities map[string]int
+	var queue *priorityqueue.Queue
+
+	BeforeEach(func() {
+		priorities = map[string]int{}
+		queue = priorityqueue.New(func(item interface{}) int { return priorities[item.(string)] }, nil)
+	})
+	drain := func() []string {
+		order := []string{}
+		for queue.Len() > 0 {
+			item, shutdown := queue.Get()
+			Expect(shutdown).To(BeFalse())
+			order = append(order, item.(string))
+			queue.Done(item)
+		}
+		return order
+	}
+
+	It("Should dequeue by descending priority then in insertion order", func() {
+		items := []struct {
+			name     string
+			priority int
+		}{
+			{"dev-a", 0}, {"staging", 10}, {"dev-b", 0}, {"prod-a", 100}, {"staging-b", 10}, {"prod-b", 100}, {"dev-c", 0},
+		}
+		for _, item := range items {
+			priorities[item.name] = item.priority
+			queue.Add(item.name)
+		}
+		Expect(drain()).To(Equal([]string{"prod-a", "prod-b", "staging", "staging-b", "dev-a", "dev-b", "dev-c"}))
+	})
+
+	It("Should queue an item once and keep its place unless its priority was raised", func() {
+		priorities["prod"] = 100
+		queue.Add("dev-a")
+		queue.Add("dev-b")
+		queue.Add("prod")
+		queue.Add("dev-a")
+		Expect(queue.Len()).To(Equal(3))
+		Expect(drain()).To(Equal([]string{"prod", "dev-a", "dev-b"}))
+
+		queue.Add("dev-a")
+		queue.Add("dev-b")
+		priorities["dev-b"] = 50
+		queue.Add("dev-b")
+		Expect(drain()).To(Equal([]string{"dev-b", "dev-a"}))
+	})
+
+	It("Should queue an item added while processed once it is done", func() {
+		queue.Add("dev")
+		item, _ := queue.Get()
+		queue.Add("dev")
+		Expect(queue.Len()).To(BeZero())
+		queue.Done(item)
+		Expect(queue.Len()).To(Equal(1))
+	})
+
+	It("Should queue delayed and rate limited items later", func() {
+		queue.AddAfter("dev", 50*time.Millisecond)
+		queue.AddRateLimited("staging")
+		Expect(queue.Len()).To(BeZero())
+		Eventually(queue.Len).Should(Equal(2))
+		Expect(queue.NumRequeues("staging")).To(Equal(1))
+		queue.Forget("staging")
+		Expect(queue.NumRequeues("staging")).To(BeZero())
+	})
+
+	It("Should wake up the waiting workers on shutdown", func() {
+		shutdown := make(chan bool)
+		go func() {
+			defer GinkgoRecover()
+			_, done := queue.Get()
+			shutdown <- done
+		}()
+		queue.ShutDown()
+		Eventually(shutdown).Should(Receive(BeTrue()))
+		queue.Add("dev")
+		Expect(queue.Len()).To(BeZero())
+	})
+})
@@ -0,0 +1,36 @@
+package schemaversions
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+)
+
+// AppendHistory adds an entry to the `history`, pruning the oldest entries beyond its `MaxHistory`.
+func AppendHistory(history *schemav1alpha1.SchemaHistory, entry schemav1alpha1.SchemaHistoryEntry) {
+	max := history.Spec.MaxHistory
+	if max <= 0 {
+		max = schemav1alpha1.DefaultMaxHistory
+	}
+	history.Status.History = append(history.Status.History, entry)
+	if len(history.Status.History) > max {
+		history.Status.History = history.Status.History[len(history.Status.History)-max:]
+	}
+}
+
+// GetSchemaAtTime returns the schema version that was in place at the time `t` - the latest entry applied before or at `t`.
+func GetSchemaAtTime(history schemav1alpha1.SchemaHistory, t time.Time) (*schemav1alpha1.SchemaHistoryEntry, error) {
+	entries := history.Status.History
+	// index of the first entry applied after `t`
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].AppliedAt.Time.After(t)
+	})
+	if i == 0 {
+		return nil, fmt.Errorf("no schema was applied by %s", t.Format(time.RFC3339))
+	}
+	return &entries[i-1], nil
+}
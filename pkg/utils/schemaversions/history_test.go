@@ -0,0 +1,60 @@
+package schemaversions_test
+
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+import (
+	"time"
+
+	schemav1alpha1 "github.com/microsoft/azure-schema-operator/api/v1alpha1"
+	"github.com/microsoft/azure-schema-operator/pkg/utils/schemaversions"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("SchemaHistory", func() {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	entryAt := func(revision int) schemav1alpha1.SchemaHistoryEntry {
+		return schemav1alpha1.SchemaHistoryEntry{
+			Revision:  int32(revision),
+			AppliedAt: metav1.NewTime(start.Add(time.Duration(revision) * time.Hour)),
+		}
+	}
+
+	It("should prune the oldest entries at the default capacity", func() {
+		history := schemav1alpha1.SchemaHistory{}
+		for i := 0; i < schemav1alpha1.DefaultMaxHistory+5; i++ {
+			schemaversions.AppendHistory(&history, entryAt(i))
+		}
+		Expect(history.Status.History).To(HaveLen(schemav1alpha1.DefaultMaxHistory))
+		Expect(history.Status.History[0].Revision).To(Equal(int32(5)))
+		Expect(history.Status.History[schemav1alpha1.DefaultMaxHistory-1].Revision).To(Equal(int32(schemav1alpha1.DefaultMaxHistory + 4)))
+	})
+	It("should prune to the configured capacity", func() {
+		history := schemav1alpha1.SchemaHistory{Spec: schemav1alpha1.SchemaHistorySpec{MaxHistory: 3}}
+		for i := 0; i < 5; i++ {
+			schemaversions.AppendHistory(&history, entryAt(i))
+		}
+		Expect(history.Status.History).To(Equal([]schemav1alpha1.SchemaHistoryEntry{entryAt(2), entryAt(3), entryAt(4)}))
+	})
+	It("should find the schema in place at a given time", func() {
+		history := schemav1alpha1.SchemaHistory{}
+		for i := 0; i < 10; i++ {
+			schemaversions.AppendHistory(&history, entryAt(i*2))
+		}
+		entry, err := schemaversions.GetSchemaAtTime(history, start.Add(5*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Revision).To(Equal(int32(4)))
+
+		entry, err = schemaversions.GetSchemaAtTime(history, start.Add(6*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Revision).To(Equal(int32(6)))
+
+		entry, err = schemaversions.GetSchemaAtTime(history, start.Add(100*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Revision).To(Equal(int32(18)))
+
+		_, err = schemaversions.GetSchemaAtTime(history, start.Add(-time.Hour))
+		Expect(err).To(HaveOccurred())
+	})
+})
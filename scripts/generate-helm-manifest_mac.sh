@@ -25,4 +25,7 @@ sed -i '' "s@$LOCAL_REGISTRY_CONTROLLER_DOCKER_IMAGE@{{.Values.image.repository}
 find "$DIR"charts/azure-schema-operator/templates/generated/ -type f -exec sed -i '' "s@schema-operator-system@{{.Release.Namespace}}@g" {} \;
 sed -i '' "1,/version:.*/s/\(version: \)\(.*\)/\1$VERSION/g" "$DIR"charts/azure-schema-operator/Chart.yaml   # find version key and update the value with the current version
 sed -i '' "1s/^/{{- if .Values.ServiceMonitor }} \n/" "$DIR"charts/azure-schema-operator/templates/generated/monitoring.coreos.com_v1_servicemonitor_schema-operator-controller-manager-metrics-monitor.yaml
-echo "{{- end }}" >> "$DIR"charts/azure-schema-operator/templates/generated/monitoring.coreos.com_v1_servicemonitor_schema-operator-controller-manager-metrics-monitor.yaml
\ No newline at end of file
+echo "{{- end }}" >> "$DIR"charts/azure-schema-operator/templates/generated/monitoring.coreos.com_v1_servicemonitor_schema-operator-controller-manager-metrics-monitor.yaml
+sed -i '' "s@operatorScope: cluster@operatorScope: {{.Values.operatorScope}}@g" "$DIR"charts/azure-schema-operator/templates/generated/*_configmap_schema-operator-manager-config.yaml
+sed -i '' "1s/^/{{- if eq .Values.operatorScope \"cluster\" }} \n/" "$DIR"charts/azure-schema-operator/templates/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_schema-operator-manager-rolebinding.yaml
+echo "{{- end }}" >> "$DIR"charts/azure-schema-operator/templates/generated/rbac.authorization.k8s.io_v1_clusterrolebinding_schema-operator-manager-rolebinding.yaml